@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/pkg/fcr"
+	"os"
+)
+
+// runFcrSim replays a recorded grid frequency against a fleet providing
+// frequency containment reserve and prints its availability, compliance and
+// penalty exposure. It needs no configuration or external services:
+//
+//	go run ./cmd/app fcrsim cmd/app/scenarios/fcr/fleet.json cmd/app/scenarios/fcr/frequency.csv
+func runFcrSim(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: app fcrsim <scenario.json> <frequency.csv>")
+		return 2
+	}
+
+	if err := fcrSim(args[0], args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func fcrSim(scenarioPath, frequencyPath string) error {
+	f, err := os.Open(scenarioPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var sc fcr.Scenario
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	freq, err := os.Open(frequencyPath)
+	if err != nil {
+		return err
+	}
+	defer freq.Close()
+
+	samples, err := fcr.ParseFrequencyCSV(freq)
+	if err != nil {
+		return fmt.Errorf("invalid frequency recording: %w", err)
+	}
+
+	report, err := fcr.Simulate(sc, samples)
+	if err != nil {
+		return fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	if !report.Compliant() {
+		return fmt.Errorf("not compliant: available %.2f%% of the time, %.0f s short of the requirement, penalty exposure €%.2f",
+			report.AvailabilityPct, report.NonCompliantSeconds, report.PenaltyEur)
+	}
+
+	return nil
+}
@@ -2,19 +2,29 @@ package main
 
 import (
 	"context"
+	"github.com/V2G-Minor-Fontys/server/internal/carbon"
 	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/internal/price"
 	"github.com/V2G-Minor-Fontys/server/internal/repository"
 	"github.com/V2G-Minor-Fontys/server/internal/router"
+	"github.com/V2G-Minor-Fontys/server/internal/solar"
+	"github.com/V2G-Minor-Fontys/server/pkg/cache"
 	"github.com/V2G-Minor-Fontys/server/pkg/logger"
+	"github.com/V2G-Minor-Fontys/server/pkg/mqtt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fcrsim" {
+		os.Exit(runFcrSim(os.Args[2:]))
+	}
+
 	cfg, err := config.NewConfig()
 	if err != nil {
 		panic(err)
@@ -28,12 +38,41 @@ func main() {
 		panic(err)
 	}
 
+	broker, err := mqtt.Connect(cfg.Mqtt)
+	if err != nil {
+		panic(err)
+	}
+
+	c, err := cache.New(cfg.Redis)
+	if err != nil {
+		panic(err)
+	}
+
+	prices, err := price.NewSource(cfg.Prices)
+	if err != nil {
+		panic(err)
+	}
+
+	intensities, err := carbon.NewSource(cfg.Carbon)
+	if err != nil {
+		panic(err)
+	}
+
+	weather, err := solar.NewWeatherProvider(cfg.Weather)
+	if err != nil {
+		panic(err)
+	}
+
 	repo := repository.New(conn)
-	srv := router.NewServer(cfg, conn, repo)
+	srv := router.NewServer(cfg, conn, repo, broker, c, prices, intensities, weather)
 	if err = srv.MountHandlers(); err != nil {
 		panic(err)
 	}
 
+	if err = srv.StartWorkers(ctx); err != nil {
+		panic(err)
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	go func() {
@@ -46,6 +85,10 @@ func main() {
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			slog.ErrorContext(ctx, "Error during shutdown", "error", err)
 		}
+		broker.Close()
+		if err := c.Close(); err != nil {
+			slog.ErrorContext(ctx, "Error closing cache", "error", err)
+		}
 		serverStopCtx()
 	}()
 
@@ -0,0 +1,57 @@
+// Command loadsim replays a site scenario through the load balancer and
+// prints whether the connection limit held and how much energy each vehicle
+// received.
+//
+//	go run ./cmd/loadsim cmd/loadsim/scenarios/office.json
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/pkg/loadbalance"
+	"os"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: loadsim <scenario.json>")
+		os.Exit(2)
+	}
+
+	if err := run(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var sc loadbalance.Scenario
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	report, err := loadbalance.Simulate(sc)
+	if err != nil {
+		return fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	if report.Violations > 0 {
+		return fmt.Errorf("connection limit exceeded in %d of %d steps", report.Violations, report.Steps)
+	}
+
+	return nil
+}
@@ -0,0 +1,689 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/chargingprofile"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/internal/shadow"
+	"github.com/V2G-Minor-Fontys/server/pkg/evsim"
+	"github.com/V2G-Minor-Fontys/server/pkg/mqtt"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	tickInterval     = time.Second
+	defaultHeartbeat = 5 * time.Minute
+	vendor           = "V2G Simulator"
+)
+
+// Statuses and reasons shared by both OCPP versions.
+const (
+	statusAvailable      = "Available"
+	statusPreparing      = "Preparing"
+	statusFinishing      = "Finishing"
+	statusOccupied       = "Occupied"
+	stateEVConnected     = "EVConnected"
+	stateCharging        = "Charging"
+	stateSuspendedEV     = "SuspendedEV"
+	stateSuspendedEVSE   = "SuspendedEVSE"
+	stateIdle            = "Idle"
+	reasonEVDisconnected = "EVDisconnected"
+	reasonLocal          = "Local"
+	contextPeriodic      = "Sample.Periodic"
+	contextBegin         = "Transaction.Begin"
+	contextEnd           = "Transaction.End"
+)
+
+// clock is the simulated time, running scale times faster than the wall
+// clock from start.
+type clock struct {
+	start time.Time
+	scale float64
+}
+
+func (c clock) now() time.Time {
+	return c.start.Add(time.Duration(float64(time.Since(c.start)) * c.scale))
+}
+
+type connector struct {
+	id int
+	// queue holds the vehicles that still have to plug in, in order.
+	queue    []*evsim.Vehicle
+	status   string
+	importWh float64
+	exportWh float64
+	session  *session
+}
+
+// session is a vehicle plugged in to a connector.
+type session struct {
+	vehicle       *evsim.Vehicle
+	battery       *evsim.Battery
+	result        *VehicleResult
+	transactionID string
+	seqNo         int
+	state         string
+	powerKw       float64
+}
+
+// chargePoint is a virtual charger. Its connectors are only touched by the
+// simulation loop in run, the central system changes the profiles from the
+// websocket reader.
+type chargePoint struct {
+	charger  *evsim.Charger
+	scenario *evsim.Scenario
+	clock    clock
+	broker   *mqtt.Client
+	conn     *client
+	profiles profiles
+
+	connectors   []*connector
+	transactions int
+	vehicles     map[string]*VehicleResult
+	result       *ChargerResult
+}
+
+func newChargePoint(sc *evsim.Scenario, c *evsim.Charger, clk clock, broker *mqtt.Client, vehicles map[string]*VehicleResult) *chargePoint {
+	cp := &chargePoint{
+		charger:  c,
+		scenario: sc,
+		clock:    clk,
+		broker:   broker,
+		vehicles: vehicles,
+		result:   &ChargerResult{Serial: c.Serial, Version: c.Version, Errors: []string{}},
+	}
+
+	for id := 1; id <= c.Connectors; id++ {
+		cp.connectors = append(cp.connectors, &connector{id: id})
+	}
+	for _, v := range sc.VehiclesOn(c.Serial) {
+		con := cp.connectors[v.Connector-1]
+		con.queue = append(con.queue, v)
+	}
+
+	return cp
+}
+
+func (cp *chargePoint) v201() bool {
+	return cp.charger.Version == evsim.VersionOcpp201
+}
+
+// run connects the charger and plays its vehicles until the scenario ends or
+// ctx is cancelled, transactions still running then are stopped.
+func (cp *chargePoint) run(ctx context.Context, url, password string) {
+	defer func() {
+		cp.result.ProfilesSet = cp.profiles.count()
+		for _, c := range cp.connectors {
+			cp.result.ImportedKwh += round(c.importWh / 1000)
+			cp.result.ExportedKwh += round(c.exportWh / 1000)
+		}
+	}()
+
+	conn, err := dial(ctx, url+"/"+cp.charger.Serial, cp.charger.Serial, password, cp.charger.Version, cp.handle)
+	if err != nil {
+		cp.fail(err)
+		return
+	}
+	cp.conn = conn
+	defer conn.Close()
+	cp.result.Connected = true
+
+	interval, err := cp.boot(ctx)
+	if err != nil {
+		cp.fail(err)
+		return
+	}
+	for _, c := range cp.connectors {
+		cp.setStatus(ctx, c, statusAvailable)
+	}
+
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+	tick := time.NewTicker(tickInterval)
+	defer tick.Stop()
+
+	end := cp.clock.start.Add(cp.scenario.Duration())
+	meterInterval := time.Duration(cp.scenario.MeterIntervalSeconds) * time.Second
+	last := cp.clock.start
+	nextMeter := last.Add(meterInterval)
+	for {
+		select {
+		case <-ctx.Done():
+			cp.stopAll(context.WithoutCancel(ctx), last)
+			return
+		case <-conn.Done():
+			cp.fail(errClosed)
+			return
+		case <-heartbeat.C:
+			if err := conn.Call(ctx, ocpp.ActionHeartbeat, struct{}{}, nil); err != nil {
+				cp.fail(err)
+			}
+		case <-tick.C:
+			now := cp.clock.now()
+			if now.After(end) {
+				now = end
+			}
+
+			for _, c := range cp.connectors {
+				cp.step(ctx, c, now, now.Sub(last))
+			}
+			last = now
+
+			if !now.Before(nextMeter) {
+				cp.meter(ctx, now)
+				for !now.Before(nextMeter) {
+					nextMeter = nextMeter.Add(meterInterval)
+				}
+			}
+
+			if !now.Before(end) {
+				cp.stopAll(ctx, now)
+				return
+			}
+		}
+	}
+}
+
+func (cp *chargePoint) boot(ctx context.Context) (time.Duration, error) {
+	var req any = ocpp.BootNotificationRequest16{
+		ChargePointVendor:       vendor,
+		ChargePointModel:        cp.model(),
+		ChargePointSerialNumber: cp.charger.Serial,
+	}
+	if cp.v201() {
+		r := ocpp.BootNotificationRequest201{Reason: "PowerUp"}
+		r.ChargingStation.VendorName = vendor
+		r.ChargingStation.Model = cp.model()
+		r.ChargingStation.SerialNumber = cp.charger.Serial
+		req = r
+	}
+
+	var res ocpp.BootNotificationResponse
+	if err := cp.conn.Call(ctx, ocpp.ActionBootNotification, req, &res); err != nil {
+		return 0, err
+	}
+
+	cp.result.Registration = res.Status
+	if res.Status != ocpp.RegistrationAccepted {
+		return 0, fmt.Errorf("boot notification was answered with %s", res.Status)
+	}
+
+	if res.Interval <= 0 {
+		return defaultHeartbeat, nil
+	}
+	return time.Duration(res.Interval) * time.Second, nil
+}
+
+func (cp *chargePoint) model() string {
+	if cp.charger.Bidirectional {
+		return fmt.Sprintf("Virtual %g kW bidirectional", cp.charger.MaxKw)
+	}
+
+	return fmt.Sprintf("Virtual %g kW", cp.charger.MaxKw)
+}
+
+// step advances a connector by d up to now: the plugged in vehicle follows
+// the profiles for d, and vehicles plug in or out when their minute passed.
+func (cp *chargePoint) step(ctx context.Context, c *connector, now time.Time, d time.Duration) {
+	minute := now.Sub(cp.clock.start).Minutes()
+
+	if s := c.session; s != nil {
+		setpointKw := cp.setpointKw(c.id, now)
+		s.powerKw = s.battery.Step(setpointKw, d)
+		energyWh := s.powerKw * d.Hours() * 1000
+		if energyWh > 0 {
+			c.importWh += energyWh
+			s.result.ImportedKwh += energyWh / 1000
+		} else {
+			c.exportWh -= energyWh
+			s.result.ExportedKwh -= energyWh / 1000
+		}
+		s.result.FinalSoc = s.battery.Soc
+
+		if minute >= s.vehicle.PlugOutMinute {
+			cp.unplug(ctx, c, now, reasonEVDisconnected)
+			return
+		}
+
+		switch {
+		case s.powerKw != 0:
+			s.state = stateCharging
+		case setpointKw == 0:
+			s.state = stateSuspendedEVSE
+		default:
+			s.state = stateSuspendedEV
+		}
+		if !cp.v201() {
+			cp.setStatus(ctx, c, s.state)
+		}
+		return
+	}
+
+	if len(c.queue) > 0 && minute >= c.queue[0].PlugInMinute {
+		v := c.queue[0]
+		c.queue = c.queue[1:]
+		cp.plugIn(ctx, c, v, now)
+	}
+}
+
+// setpointKw is the power the charger offers on a connector. Without a
+// profile it charges at full power, discharging needs a bidirectional 2.0.1
+// charger.
+func (cp *chargePoint) setpointKw(connectorID int, now time.Time) float64 {
+	maxKw := cp.charger.MaxKw
+	limitW, ok := cp.profiles.limit(connectorID, now)
+	if !ok {
+		return maxKw
+	}
+
+	kw := limitW / 1000
+	if kw < 0 && (!cp.charger.Bidirectional || !cp.v201()) {
+		return 0
+	}
+
+	return math.Max(-maxKw, math.Min(maxKw, kw))
+}
+
+func (cp *chargePoint) plugIn(ctx context.Context, c *connector, v *evsim.Vehicle, now time.Time) {
+	s := &session{
+		vehicle: v,
+		battery: evsim.NewBattery(v),
+		result:  cp.vehicles[v.ID],
+		state:   stateEVConnected,
+	}
+
+	if cp.v201() {
+		cp.setStatus(ctx, c, statusOccupied)
+		cp.transactions++
+		s.transactionID = fmt.Sprintf("%s-%d", cp.charger.Serial, cp.transactions)
+		c.session = s
+
+		var res ocpp.TransactionEventResponse201
+		if err := cp.transactionEvent(ctx, c, now, ocpp.TransactionEventStarted, "CablePluggedIn", "", &res); err != nil {
+			cp.fail(fmt.Errorf("vehicle %s: %w", v.ID, err))
+			c.session = nil
+			cp.setStatus(ctx, c, statusAvailable)
+			return
+		}
+		if res.IdTokenInfo != nil && res.IdTokenInfo.Status != ocpp.AuthorizationAccepted {
+			cp.fail(fmt.Errorf("vehicle %s: id token %s was answered with %s", v.ID, v.IdTag, res.IdTokenInfo.Status))
+		}
+	} else {
+		cp.setStatus(ctx, c, statusPreparing)
+
+		var auth ocpp.AuthorizeResponse16
+		if err := cp.conn.Call(ctx, ocpp.ActionAuthorize, ocpp.AuthorizeRequest16{IdTag: v.IdTag}, &auth); err != nil {
+			cp.fail(fmt.Errorf("vehicle %s: %w", v.ID, err))
+			cp.setStatus(ctx, c, statusAvailable)
+			return
+		}
+		if auth.IdTagInfo.Status != ocpp.AuthorizationAccepted {
+			cp.fail(fmt.Errorf("vehicle %s: id tag %s was answered with %s", v.ID, v.IdTag, auth.IdTagInfo.Status))
+			cp.setStatus(ctx, c, statusAvailable)
+			return
+		}
+
+		var res ocpp.StartTransactionResponse16
+		if err := cp.conn.Call(ctx, ocpp.ActionStartTransaction, ocpp.StartTransactionRequest16{
+			ConnectorID: c.id,
+			IdTag:       v.IdTag,
+			MeterStart:  int(math.Round(c.importWh)),
+			Timestamp:   now,
+		}, &res); err != nil {
+			cp.fail(fmt.Errorf("vehicle %s: %w", v.ID, err))
+			cp.setStatus(ctx, c, statusAvailable)
+			return
+		}
+
+		s.transactionID = strconv.Itoa(res.TransactionID)
+		c.session = s
+		cp.transactions++
+		cp.setStatus(ctx, c, stateCharging)
+	}
+
+	s.result.PluggedIn = true
+	cp.result.Transactions++
+	slog.Info("Vehicle plugged in", "vehicle", v.ID, "charger", cp.charger.Serial, "connector", c.id, "soc", round(s.battery.Soc))
+	cp.report(ctx)
+}
+
+func (cp *chargePoint) unplug(ctx context.Context, c *connector, now time.Time, reason string) {
+	s := c.session
+
+	if cp.v201() {
+		trigger := "EVDeparted"
+		if reason == reasonLocal {
+			trigger = "StopAuthorized"
+		}
+		s.state = stateIdle
+		if err := cp.transactionEvent(ctx, c, now, ocpp.TransactionEventEnded, trigger, reason, nil); err != nil {
+			cp.fail(fmt.Errorf("vehicle %s: %w", s.vehicle.ID, err))
+		}
+		c.session = nil
+		cp.setStatus(ctx, c, statusAvailable)
+	} else {
+		id, _ := strconv.Atoi(s.transactionID)
+		if err := cp.conn.Call(ctx, ocpp.ActionStopTransaction, ocpp.StopTransactionRequest16{
+			IdTag:           s.vehicle.IdTag,
+			MeterStop:       int(math.Round(c.importWh)),
+			Timestamp:       now,
+			TransactionID:   id,
+			Reason:          reason,
+			TransactionData: []ocpp.MeterValue16{cp.sample16(c, now, contextEnd)},
+		}, nil); err != nil {
+			cp.fail(fmt.Errorf("vehicle %s: %w", s.vehicle.ID, err))
+		}
+		c.session = nil
+		cp.setStatus(ctx, c, statusFinishing)
+		cp.setStatus(ctx, c, statusAvailable)
+	}
+
+	slog.Info("Vehicle plugged out", "vehicle", s.vehicle.ID, "charger", cp.charger.Serial, "connector", c.id, "soc", round(s.battery.Soc))
+	cp.report(ctx)
+}
+
+func (cp *chargePoint) stopAll(ctx context.Context, now time.Time) {
+	for _, c := range cp.connectors {
+		if c.session != nil {
+			cp.unplug(ctx, c, now, reasonLocal)
+		}
+	}
+}
+
+// meter sends the periodic meter values of every running transaction and
+// reports the state of the charger to its shadow.
+func (cp *chargePoint) meter(ctx context.Context, now time.Time) {
+	for _, c := range cp.connectors {
+		if c.session == nil {
+			continue
+		}
+
+		var err error
+		if cp.v201() {
+			err = cp.transactionEvent(ctx, c, now, ocpp.TransactionEventUpdated, "MeterValuePeriodic", "", nil)
+		} else {
+			id, _ := strconv.Atoi(c.session.transactionID)
+			err = cp.conn.Call(ctx, ocpp.ActionMeterValues, ocpp.MeterValuesRequest16{
+				ConnectorID:   c.id,
+				TransactionID: &id,
+				MeterValue:    []ocpp.MeterValue16{cp.sample16(c, now, contextPeriodic)},
+			}, nil)
+		}
+		if err != nil {
+			cp.fail(err)
+		}
+	}
+
+	cp.report(ctx)
+}
+
+// transactionEvent sends a 2.0.1 TransactionEvent with the registers of the
+// connector, reason is the stopped reason of an Ended event.
+func (cp *chargePoint) transactionEvent(ctx context.Context, c *connector, now time.Time, eventType, trigger, reason string, res any) error {
+	sampleContext := contextPeriodic
+	switch eventType {
+	case ocpp.TransactionEventStarted:
+		sampleContext = contextBegin
+	case ocpp.TransactionEventEnded:
+		sampleContext = contextEnd
+	}
+
+	s := c.session
+	req := ocpp.TransactionEventRequest201{
+		EventType:     eventType,
+		Timestamp:     now,
+		TriggerReason: trigger,
+		SeqNo:         s.seqNo,
+		TransactionInfo: ocpp.TransactionInfo{
+			TransactionID: s.transactionID,
+			ChargingState: s.state,
+			StoppedReason: reason,
+		},
+		EVSE:       &ocpp.EVSE{ID: c.id, ConnectorID: 1},
+		MeterValue: []ocpp.MeterValue201{cp.sample201(c, now, sampleContext)},
+	}
+	if eventType == ocpp.TransactionEventStarted {
+		req.IdToken = &ocpp.IdToken{IdToken: s.vehicle.IdTag, Type: ocpp.IdTokenCentral}
+	}
+	s.seqNo++
+
+	return cp.conn.Call(ctx, ocpp.ActionTransactionEvent, req, res)
+}
+
+func (cp *chargePoint) sample16(c *connector, now time.Time, sampleContext string) ocpp.MeterValue16 {
+	mv := ocpp.MeterValue16{Timestamp: now}
+	for _, m := range cp.measurands(c) {
+		mv.SampledValue = append(mv.SampledValue, ocpp.SampledValue16{
+			Value:     strconv.FormatFloat(m.value, 'f', 1, 64),
+			Context:   sampleContext,
+			Measurand: m.measurand,
+			Unit:      m.unit,
+		})
+	}
+
+	return mv
+}
+
+func (cp *chargePoint) sample201(c *connector, now time.Time, sampleContext string) ocpp.MeterValue201 {
+	mv := ocpp.MeterValue201{Timestamp: now}
+	for _, m := range cp.measurands(c) {
+		mv.SampledValue = append(mv.SampledValue, ocpp.SampledValue201{
+			Value:         math.Round(m.value*10) / 10,
+			Context:       sampleContext,
+			Measurand:     m.measurand,
+			UnitOfMeasure: &ocpp.UnitOfMeasure{Unit: m.unit},
+		})
+	}
+
+	return mv
+}
+
+type measurand struct {
+	measurand string
+	unit      string
+	value     float64
+}
+
+// measurands are the energy registers of a connector and, while a vehicle
+// is plugged in, its power and state of charge.
+func (cp *chargePoint) measurands(c *connector) []measurand {
+	res := []measurand{
+		{ocpp.MeasurandEnergyImport, "Wh", c.importWh},
+		{ocpp.MeasurandEnergyExport, "Wh", c.exportWh},
+	}
+	if s := c.session; s != nil {
+		res = append(res,
+			measurand{ocpp.MeasurandPowerImport, "W", math.Max(0, s.powerKw*1000)},
+			measurand{ocpp.MeasurandPowerExport, "W", math.Max(0, -s.powerKw*1000)},
+			measurand{ocpp.MeasurandSoC, "Percent", s.battery.Soc},
+		)
+	}
+
+	return res
+}
+
+// setStatus notifies the central system when the status of a connector
+// changed.
+func (cp *chargePoint) setStatus(ctx context.Context, c *connector, status string) {
+	if c.status == status {
+		return
+	}
+	c.status = status
+
+	now := cp.clock.now()
+	var req any = ocpp.StatusNotificationRequest16{
+		ConnectorID: c.id,
+		ErrorCode:   "NoError",
+		Status:      status,
+		Timestamp:   &now,
+	}
+	if cp.v201() {
+		req = ocpp.StatusNotificationRequest201{
+			Timestamp:       now,
+			ConnectorStatus: status,
+			EvseID:          c.id,
+			ConnectorID:     1,
+		}
+	}
+
+	if err := cp.conn.Call(ctx, ocpp.ActionStatusNotification, req, nil); err != nil {
+		cp.fail(err)
+	}
+}
+
+// report publishes the power of the charger and the state of charge of the
+// vehicle plugged in to its shadow, the way the charger firmware does over
+// MQTT. A charger shares one shadow between its connectors, so the state of
+// charge is the one of the first plugged in vehicle.
+func (cp *chargePoint) report(ctx context.Context) {
+	if cp.broker == nil {
+		return
+	}
+
+	var soc any
+	powerKw := 0.0
+	for _, c := range cp.connectors {
+		if c.session == nil {
+			continue
+		}
+		if soc == nil {
+			soc = round(c.session.battery.Soc)
+		}
+		powerKw += c.session.powerKw
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		scheduling.SocField:        soc,
+		chargingprofile.PowerField: round(powerKw),
+	})
+	if err != nil {
+		cp.fail(err)
+		return
+	}
+
+	if err := cp.broker.Publish(ctx, shadow.ReportedTopic(cp.charger.Serial), payload); err != nil {
+		cp.fail(fmt.Errorf("could not report shadow: %w", err))
+	}
+}
+
+func (cp *chargePoint) fail(err error) {
+	slog.Error("Charge point error", "charger", cp.charger.Serial, "error", err)
+	cp.result.Errors = append(cp.result.Errors, err.Error())
+}
+
+// handle answers the calls of the central system, it runs on the websocket
+// reader.
+func (cp *chargePoint) handle(action string, payload json.RawMessage) (any, error) {
+	now := cp.clock.now()
+
+	switch action {
+	case ocpp.ActionSetChargingProfile:
+		decodeProfile := decodeProfile16
+		if cp.v201() {
+			decodeProfile = decodeProfile201
+		}
+
+		p, err := decodeProfile(payload, now)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return ocpp.StatusResponse{Status: ocpp.StatusRejected}, nil
+		}
+
+		cp.profiles.set(p)
+		return ocpp.StatusResponse{Status: ocpp.StatusAccepted}, nil
+	case ocpp.ActionClearChargingProfile:
+		var removed bool
+		if cp.v201() {
+			var req ocpp.ClearChargingProfileRequest201
+			if err := decode(payload, &req); err != nil {
+				return nil, err
+			}
+			if crit := req.ChargingProfileCriteria; crit != nil {
+				removed = cp.profiles.clear(req.ChargingProfileID, crit.EvseID, crit.ChargingProfilePurpose, crit.StackLevel)
+			} else {
+				removed = cp.profiles.clear(req.ChargingProfileID, nil, "", nil)
+			}
+		} else {
+			var req ocpp.ClearChargingProfileRequest16
+			if err := decode(payload, &req); err != nil {
+				return nil, err
+			}
+			removed = cp.profiles.clear(req.ID, req.ConnectorID, req.ChargingProfilePurpose, req.StackLevel)
+		}
+
+		if !removed {
+			return ocpp.StatusResponse{Status: ocpp.StatusUnknown}, nil
+		}
+		return ocpp.StatusResponse{Status: ocpp.StatusAccepted}, nil
+	case ocpp.ActionGetCompositeSchedule:
+		return cp.compositeSchedule(payload, now)
+	}
+
+	return nil, &ocpp.CallError{Code: ocpp.ErrorNotImplemented, Description: fmt.Sprintf("%s is not supported by the simulator", action)}
+}
+
+// compositeSchedule reports the limit in force now for the whole requested
+// duration, the simulator does not merge future periods.
+func (cp *chargePoint) compositeSchedule(payload json.RawMessage, now time.Time) (any, error) {
+	var connectorID, duration int
+	if cp.v201() {
+		var req ocpp.GetCompositeScheduleRequest201
+		if err := decode(payload, &req); err != nil {
+			return nil, err
+		}
+		connectorID, duration = req.EvseID, req.Duration
+	} else {
+		var req ocpp.GetCompositeScheduleRequest16
+		if err := decode(payload, &req); err != nil {
+			return nil, err
+		}
+		connectorID, duration = req.ConnectorID, req.Duration
+	}
+
+	limit, ok := cp.profiles.limit(connectorID, now)
+	if !ok {
+		limit = cp.charger.MaxKw * 1000
+	}
+	periods := []ocpp.ChargingSchedulePeriod{{StartPeriod: 0, Limit: limit}}
+
+	if cp.v201() {
+		return ocpp.GetCompositeScheduleResponse201{
+			Status: ocpp.StatusAccepted,
+			Schedule: &ocpp.CompositeSchedule201{
+				EvseID:                 connectorID,
+				Duration:               duration,
+				ScheduleStart:          now,
+				ChargingRateUnit:       ocpp.RateUnitWatt,
+				ChargingSchedulePeriod: periods,
+			},
+		}, nil
+	}
+
+	return ocpp.GetCompositeScheduleResponse16{
+		Status:        ocpp.StatusAccepted,
+		ConnectorID:   connectorID,
+		ScheduleStart: &now,
+		ChargingSchedule: &ocpp.ChargingSchedule16{
+			Duration:               duration,
+			StartSchedule:          &now,
+			ChargingRateUnit:       ocpp.RateUnitWatt,
+			ChargingSchedulePeriod: periods,
+		},
+	}, nil
+}
+
+func decode(payload json.RawMessage, v any) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return &ocpp.CallError{Code: ocpp.ErrorFormationViolation, Description: err.Error()}
+	}
+
+	return nil
+}
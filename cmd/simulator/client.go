@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	callTimeout  = 30 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+var errClosed = errors.New("connection to the central system closed")
+
+// callHandler answers a call of the central system. Returning an
+// *ocpp.CallError sends that error code back.
+type callHandler func(action string, payload json.RawMessage) (any, error)
+
+// client is the charge point side of an OCPP-J websocket.
+type client struct {
+	ws      *websocket.Conn
+	handle  callHandler
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan *ocpp.Message
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// dial connects with the identity and password as Basic auth credentials.
+func dial(ctx context.Context, url, identity, password, version string, handle callHandler) (*client, error) {
+	dialer := websocket.Dialer{
+		HandshakeTimeout: writeTimeout,
+		Subprotocols:     []string{version},
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(identity+":"+password)))
+	ws, res, err := dialer.DialContext(ctx, url, header)
+	if err != nil {
+		if res != nil {
+			return nil, fmt.Errorf("could not connect to %s: %s", url, res.Status)
+		}
+		return nil, fmt.Errorf("could not connect to %s: %w", url, err)
+	}
+	if ws.Subprotocol() != version {
+		_ = ws.Close()
+		return nil, fmt.Errorf("central system did not accept %s", version)
+	}
+
+	c := &client{
+		ws:      ws,
+		handle:  handle,
+		pending: make(map[string]chan *ocpp.Message),
+		closed:  make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// Call sends an action to the central system and decodes its result into
+// res. A call error frame is returned as *ocpp.CallError.
+func (c *client) Call(ctx context.Context, action string, req, res any) error {
+	id := uuid.NewString()
+	frame, err := ocpp.EncodeCall(id, action, req)
+	if err != nil {
+		return err
+	}
+
+	reply := make(chan *ocpp.Message, 1)
+	c.mu.Lock()
+	c.pending[id] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(frame); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	select {
+	case msg := <-reply:
+		if msg.Type == ocpp.TypeCallError {
+			return &ocpp.CallError{Code: msg.ErrorCode, Description: msg.ErrorDescription}
+		}
+		if res == nil {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Payload, res); err != nil {
+			return fmt.Errorf("could not decode %s result: %w", action, err)
+		}
+		return nil
+	case <-c.closed:
+		return errClosed
+	case <-ctx.Done():
+		return fmt.Errorf("%s was not answered: %w", action, ctx.Err())
+	}
+}
+
+func (c *client) Close() {
+	c.once.Do(func() {
+		close(c.closed)
+		c.writeMu.Lock()
+		_ = c.ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+		c.writeMu.Unlock()
+		_ = c.ws.Close()
+	})
+}
+
+// Done is closed once the connection is gone.
+func (c *client) Done() <-chan struct{} {
+	return c.closed
+}
+
+// read dispatches frames until the socket closes. Calls are answered one at
+// a time, in the order they arrive, like a real charger does.
+func (c *client) read() {
+	defer c.Close()
+
+	for {
+		_, raw, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		msg, err := ocpp.Decode(raw)
+		if err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case ocpp.TypeCall:
+			c.answer(msg)
+		default:
+			c.mu.Lock()
+			reply, ok := c.pending[msg.ID]
+			c.mu.Unlock()
+			if ok {
+				reply <- msg
+			}
+		}
+	}
+}
+
+func (c *client) answer(msg *ocpp.Message) {
+	res, err := c.handle(msg.Action, msg.Payload)
+
+	var frame []byte
+	var callErr *ocpp.CallError
+	switch {
+	case errors.As(err, &callErr):
+		frame, err = ocpp.EncodeError(msg.ID, callErr.Code, callErr.Description)
+	case err != nil:
+		frame, err = ocpp.EncodeError(msg.ID, ocpp.ErrorInternalError, err.Error())
+	default:
+		frame, err = ocpp.EncodeResult(msg.ID, res)
+	}
+	if err != nil {
+		return
+	}
+
+	_ = c.write(frame)
+}
+
+func (c *client) write(frame []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	select {
+	case <-c.closed:
+		return errClosed
+	default:
+	}
+
+	_ = c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.ws.WriteMessage(websocket.TextMessage, frame)
+}
@@ -0,0 +1,207 @@
+// Command simulator connects virtual charge points to a running server over
+// OCPP and plugs virtual vehicles in to them as a scenario describes. The
+// vehicles follow the charging profiles the server sends within what their
+// battery accepts, and the chargers report meter values and, with an MQTT
+// broker, the state of charge to their device shadow. The serials of the
+// chargers must be registered as chargers first and their OCPP passwords
+// given as a JSON object of serial to password. It prints a report of every
+// charger and vehicle and exits non-zero when a charger failed, so it can run
+// against the server in CI.
+//
+//	go run ./cmd/simulator -url ws://localhost:8080/ocpp -passwords passwords.json -mqtt-host tcp://localhost cmd/simulator/scenarios/depot.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/pkg/evsim"
+	"github.com/V2G-Minor-Fontys/server/pkg/mqtt"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type options struct {
+	url          string
+	passwords    string
+	copies       int
+	mqttHost     string
+	mqttPort     string
+	mqttUsername string
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.url, "url", "ws://localhost:8080/ocpp", "websocket URL of the OCPP endpoint, the serial is appended")
+	flag.StringVar(&opts.passwords, "passwords", "", "JSON file of the OCPP password of every charger by serial")
+	flag.IntVar(&opts.copies, "copies", 1, "number of copies of every charger and vehicle, for load tests")
+	flag.StringVar(&opts.mqttHost, "mqtt-host", "", "MQTT broker to report the shadow to, none when empty")
+	flag.StringVar(&opts.mqttPort, "mqtt-port", "1883", "MQTT broker port")
+	flag.StringVar(&opts.mqttUsername, "mqtt-username", "", "MQTT username")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: simulator [flags] <scenario.json>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(opts, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Report is what happened to every charger and vehicle of the scenario.
+type Report struct {
+	Chargers []*ChargerResult `json:"chargers"`
+	Vehicles []*VehicleResult `json:"vehicles"`
+}
+
+type ChargerResult struct {
+	Serial    string `json:"serial"`
+	Version   string `json:"version"`
+	Connected bool   `json:"connected"`
+	// Registration is the status the server answered the boot notification
+	// with.
+	Registration string  `json:"registration"`
+	Transactions int     `json:"transactions"`
+	ProfilesSet  int     `json:"profilesSet"`
+	ImportedKwh  float64 `json:"importedKwh"`
+	ExportedKwh  float64 `json:"exportedKwh"`
+	// Errors are the calls that failed or were refused.
+	Errors []string `json:"errors"`
+}
+
+type VehicleResult struct {
+	ID          string  `json:"id"`
+	Charger     string  `json:"charger"`
+	Connector   int     `json:"connector"`
+	PluggedIn   bool    `json:"pluggedIn"`
+	InitialSoc  float64 `json:"initialSoc"`
+	FinalSoc    float64 `json:"finalSoc"`
+	ImportedKwh float64 `json:"importedKwh"`
+	ExportedKwh float64 `json:"exportedKwh"`
+}
+
+// Failed returns the serials of the chargers that did not connect or had
+// a call fail.
+func (r *Report) Failed() []string {
+	var res []string
+	for _, c := range r.Chargers {
+		if !c.Connected || len(c.Errors) > 0 {
+			res = append(res, c.Serial)
+		}
+	}
+
+	return res
+}
+
+func run(opts options, scenarioPath string) error {
+	f, err := os.Open(scenarioPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var sc evsim.Scenario
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	sc = sc.Replicate(opts.copies)
+	if err := sc.Validate(); err != nil {
+		return fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	passwords, err := readPasswords(opts.passwords)
+	if err != nil {
+		return err
+	}
+
+	var broker *mqtt.Client
+	if opts.mqttHost != "" {
+		broker, err = mqtt.Connect(&config.Mqtt{Host: opts.mqttHost, Port: opts.mqttPort, Username: opts.mqttUsername})
+		if err != nil {
+			return err
+		}
+		defer broker.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report := &Report{}
+	vehicles := make(map[string]*VehicleResult, len(sc.Vehicles))
+	for _, v := range sc.Vehicles {
+		res := &VehicleResult{ID: v.ID, Charger: v.Charger, Connector: v.Connector, InitialSoc: v.InitialSoc, FinalSoc: v.InitialSoc}
+		report.Vehicles = append(report.Vehicles, res)
+		vehicles[v.ID] = res
+	}
+
+	clk := clock{start: time.Now(), scale: sc.TimeScale}
+	var wg sync.WaitGroup
+	for i := range sc.Chargers {
+		cp := newChargePoint(&sc, &sc.Chargers[i], clk, broker, vehicles)
+		report.Chargers = append(report.Chargers, cp.result)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cp.run(ctx, strings.TrimSuffix(opts.url, "/"), passwords[cp.charger.Serial])
+		}()
+	}
+	wg.Wait()
+
+	for _, v := range report.Vehicles {
+		v.FinalSoc = round(v.FinalSoc)
+		v.ImportedKwh = round(v.ImportedKwh)
+		v.ExportedKwh = round(v.ExportedKwh)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d chargers failed: %s", len(failed), len(report.Chargers), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func readPasswords(path string) (map[string]string, error) {
+	passwords := map[string]string{}
+	if path == "" {
+		return passwords, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, &passwords); err != nil {
+		return nil, fmt.Errorf("invalid passwords: %w", err)
+	}
+
+	return passwords, nil
+}
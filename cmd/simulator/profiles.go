@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// profile is an installed charging profile with its schedule in watt.
+// Profiles in another rate unit are refused when they are set.
+type profile struct {
+	id         int
+	connector  int
+	purpose    string
+	stackLevel int
+	start      time.Time
+	duration   time.Duration
+	periods    []ocpp.ChargingSchedulePeriod
+}
+
+// limitAt returns the limit in watt the profile sets at t, false when it is
+// not in force.
+func (p *profile) limitAt(t time.Time) (float64, bool) {
+	if t.Before(p.start) || (p.duration > 0 && !t.Before(p.start.Add(p.duration))) {
+		return 0, false
+	}
+
+	return ocpp.LimitAt(p.periods, int(t.Sub(p.start).Seconds()))
+}
+
+// profiles holds the charging profiles of a charger. They are set by the
+// central system from the websocket reader and read by the simulation loop.
+type profiles struct {
+	mu   sync.Mutex
+	list []*profile
+	// installed counts the profiles set since the charger started.
+	installed int
+}
+
+// set installs a profile, replacing the one with the same id or with the
+// same connector, purpose and stack level as the specification demands.
+func (ps *profiles) set(p *profile) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	kept := ps.list[:0]
+	for _, q := range ps.list {
+		if q.id == p.id || (q.connector == p.connector && q.purpose == p.purpose && q.stackLevel == p.stackLevel) {
+			continue
+		}
+		kept = append(kept, q)
+	}
+	ps.list = append(kept, p)
+	ps.installed++
+}
+
+func (ps *profiles) count() int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.installed
+}
+
+// clear removes the profiles matching every criterion that is set and
+// reports whether any was removed.
+func (ps *profiles) clear(id, connector *int, purpose string, stackLevel *int) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	kept := ps.list[:0]
+	for _, p := range ps.list {
+		if (id == nil || p.id == *id) &&
+			(connector == nil || p.connector == *connector) &&
+			(purpose == "" || p.purpose == purpose) &&
+			(stackLevel == nil || p.stackLevel == *stackLevel) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+
+	removed := len(kept) < len(ps.list)
+	ps.list = kept
+	return removed
+}
+
+// limit combines the profiles of a connector at t into a single limit in
+// watt, false when no profile is in force. Transaction profiles take
+// precedence over default profiles and within a purpose the highest stack
+// level wins. The maximum profile of the charger only caps charging,
+// discharging is left to the profiles of the connectors.
+func (ps *profiles) limit(connector int, t time.Time) (float64, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	best := func(purposes ...string) (float64, bool) {
+		limit, level, found := 0.0, -1, false
+		for _, p := range ps.list {
+			if p.stackLevel <= level || !slices.Contains(purposes, p.purpose) {
+				continue
+			}
+			if p.purpose != ocpp.PurposeChargePointMaxProfile && p.purpose != ocpp.PurposeChargingStationMaxProfile && p.connector != connector {
+				continue
+			}
+			if l, ok := p.limitAt(t); ok {
+				limit, level, found = l, p.stackLevel, true
+			}
+		}
+		return limit, found
+	}
+
+	limit, ok := best(ocpp.PurposeTxProfile)
+	if !ok {
+		limit, ok = best(ocpp.PurposeTxDefaultProfile)
+	}
+
+	if maxLimit, found := best(ocpp.PurposeChargePointMaxProfile, ocpp.PurposeChargingStationMaxProfile); found {
+		if !ok {
+			return maxLimit, true
+		}
+		return math.Min(maxLimit, limit), true
+	}
+
+	return limit, ok
+}
+
+// decodeProfile16 reads the profile of a 1.6 SetChargingProfile request, now
+// anchors relative and recurring schedules.
+func decodeProfile16(payload json.RawMessage, now time.Time) (*profile, error) {
+	var req ocpp.SetChargingProfileRequest16
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, &ocpp.CallError{Code: ocpp.ErrorFormationViolation, Description: err.Error()}
+	}
+
+	cp := req.CsChargingProfiles
+	s := cp.ChargingSchedule
+	if s.ChargingRateUnit != ocpp.RateUnitWatt {
+		return nil, nil
+	}
+
+	p := &profile{
+		id:         cp.ChargingProfileID,
+		connector:  req.ConnectorID,
+		purpose:    cp.ChargingProfilePurpose,
+		stackLevel: cp.StackLevel,
+		start:      now,
+		duration:   time.Duration(s.Duration) * time.Second,
+		periods:    s.ChargingSchedulePeriod,
+	}
+	if s.StartSchedule != nil {
+		p.start = *s.StartSchedule
+	}
+
+	return p, nil
+}
+
+// decodeProfile201 is the 2.0.1 counterpart of decodeProfile16, only the
+// first schedule of a profile is used.
+func decodeProfile201(payload json.RawMessage, now time.Time) (*profile, error) {
+	var req ocpp.SetChargingProfileRequest201
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, &ocpp.CallError{Code: ocpp.ErrorFormationViolation, Description: err.Error()}
+	}
+
+	cp := req.ChargingProfile
+	if len(cp.ChargingSchedule) == 0 || cp.ChargingSchedule[0].ChargingRateUnit != ocpp.RateUnitWatt {
+		return nil, nil
+	}
+
+	s := cp.ChargingSchedule[0]
+	p := &profile{
+		id:         cp.ID,
+		connector:  req.EvseID,
+		purpose:    cp.ChargingProfilePurpose,
+		stackLevel: cp.StackLevel,
+		start:      now,
+		duration:   time.Duration(s.Duration) * time.Second,
+		periods:    s.ChargingSchedulePeriod,
+	}
+	if s.StartSchedule != nil {
+		p.start = *s.StartSchedule
+	}
+
+	return p, nil
+}
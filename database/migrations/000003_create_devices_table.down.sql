@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_devices_owner_id;
+
+DROP TABLE IF EXISTS devices;
\ No newline at end of file
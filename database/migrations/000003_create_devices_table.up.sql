@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS devices
+(
+    id            UUID PRIMARY KEY,
+    owner_id      UUID                NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    serial_number VARCHAR(100) UNIQUE NOT NULL,
+    name          VARCHAR(100)        NOT NULL,
+    kind          VARCHAR(20)         NOT NULL CHECK (kind IN ('gateway', 'charger')),
+    created_at    TIMESTAMPTZ         NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_owner_id ON devices (owner_id);
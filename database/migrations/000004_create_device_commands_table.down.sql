@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_device_commands_due;
+DROP INDEX IF EXISTS idx_device_commands_device_id;
+
+DROP TABLE IF EXISTS device_commands;
\ No newline at end of file
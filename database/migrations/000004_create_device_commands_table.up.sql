@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS device_commands
+(
+    id              UUID PRIMARY KEY,
+    device_id       UUID        NOT NULL REFERENCES devices (id) ON DELETE CASCADE,
+    type            VARCHAR(50) NOT NULL,
+    payload         JSONB       NOT NULL DEFAULT '{}',
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'sent', 'acked', 'nacked', 'timed_out')),
+    reason          TEXT        NOT NULL DEFAULT '',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    max_attempts    INTEGER     NOT NULL,
+    timeout_seconds INTEGER     NOT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_commands_device_id ON device_commands (device_id);
+CREATE INDEX IF NOT EXISTS idx_device_commands_due ON device_commands (next_attempt_at)
+    WHERE status IN ('pending', 'sent');
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS device_shadows
+(
+    device_id           UUID PRIMARY KEY REFERENCES devices (id) ON DELETE CASCADE,
+    reported            JSONB       NOT NULL DEFAULT '{}',
+    desired             JSONB       NOT NULL DEFAULT '{}',
+    version             BIGINT      NOT NULL DEFAULT 1,
+    reported_updated_at TIMESTAMPTZ,
+    desired_updated_at  TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_vehicles_charger_id;
+DROP INDEX IF EXISTS idx_vehicles_owner_id;
+
+DROP TABLE IF EXISTS vehicles;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS vehicles
+(
+    id                   UUID PRIMARY KEY,
+    owner_id             UUID             NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name                 VARCHAR(100)     NOT NULL,
+    battery_capacity_kwh DOUBLE PRECISION NOT NULL CHECK (battery_capacity_kwh > 0),
+    max_charge_kw        DOUBLE PRECISION NOT NULL CHECK (max_charge_kw > 0),
+    max_discharge_kw     DOUBLE PRECISION NOT NULL CHECK (max_discharge_kw >= 0),
+    charger_id           UUID REFERENCES devices (id) ON DELETE SET NULL,
+    created_at           TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicles_owner_id ON vehicles (owner_id);
+CREATE INDEX IF NOT EXISTS idx_vehicles_charger_id ON vehicles (charger_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS vehicle_weekly_departures;
+DROP TABLE IF EXISTS vehicle_preferences;
\ No newline at end of file
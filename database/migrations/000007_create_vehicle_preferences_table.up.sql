@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS vehicle_preferences
+(
+    vehicle_id           UUID PRIMARY KEY REFERENCES vehicles (id) ON DELETE CASCADE,
+    target_soc           SMALLINT     NOT NULL DEFAULT 80 CHECK (target_soc BETWEEN 1 AND 100),
+    min_soc              SMALLINT     NOT NULL DEFAULT 20 CHECK (min_soc BETWEEN 0 AND 100),
+    max_discharge_cycles SMALLINT     NOT NULL DEFAULT 1 CHECK (max_discharge_cycles >= 0),
+    departure_at         TIMESTAMPTZ,
+    timezone             VARCHAR(64)  NOT NULL DEFAULT 'Europe/Amsterdam',
+    boost_until          TIMESTAMPTZ,
+    updated_at           TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (min_soc <= target_soc)
+);
+
+CREATE TABLE IF NOT EXISTS vehicle_weekly_departures
+(
+    vehicle_id    UUID     NOT NULL REFERENCES vehicles (id) ON DELETE CASCADE,
+    weekday       SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    minute_of_day SMALLINT NOT NULL CHECK (minute_of_day BETWEEN 0 AND 1439),
+    PRIMARY KEY (vehicle_id, weekday)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_charging_schedules_vehicle_id_created_at;
+
+DROP TABLE IF EXISTS charging_schedules;
\ No newline at end of file
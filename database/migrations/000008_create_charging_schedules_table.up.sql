@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS charging_schedules
+(
+    id             UUID PRIMARY KEY,
+    vehicle_id     UUID             NOT NULL REFERENCES vehicles (id) ON DELETE CASCADE,
+    reason         VARCHAR(50)      NOT NULL,
+    horizon_start  TIMESTAMPTZ      NOT NULL,
+    horizon_end    TIMESTAMPTZ      NOT NULL,
+    initial_soc    DOUBLE PRECISION NOT NULL,
+    final_soc      DOUBLE PRECISION NOT NULL,
+    target_reached BOOLEAN          NOT NULL,
+    total_cost     DOUBLE PRECISION NOT NULL,
+    setpoints      JSONB            NOT NULL,
+    created_at     TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_charging_schedules_vehicle_id_created_at ON charging_schedules (vehicle_id, created_at DESC);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS connectors;
\ No newline at end of file
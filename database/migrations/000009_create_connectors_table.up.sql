@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS connectors
+(
+    id           UUID PRIMARY KEY,
+    device_id    UUID        NOT NULL REFERENCES devices (id) ON DELETE CASCADE,
+    connector_id INTEGER     NOT NULL CHECK (connector_id > 0),
+    status       VARCHAR(30) NOT NULL DEFAULT 'Unknown',
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (device_id, connector_id)
+);
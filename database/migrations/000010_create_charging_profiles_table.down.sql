@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_charging_profiles_active;
+DROP INDEX IF EXISTS idx_charging_profiles_device_id;
+
+DROP TABLE IF EXISTS charging_profiles;
\ No newline at end of file
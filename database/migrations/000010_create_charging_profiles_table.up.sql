@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS charging_profiles
+(
+    id               UUID PRIMARY KEY,
+    device_id        UUID        NOT NULL REFERENCES devices (id) ON DELETE CASCADE,
+    connector_id     INTEGER     NOT NULL,
+    profile_id       INTEGER     NOT NULL,
+    purpose          VARCHAR(30) NOT NULL,
+    stack_level      INTEGER     NOT NULL,
+    schedule_id      UUID REFERENCES charging_schedules (id) ON DELETE SET NULL,
+    start_schedule   TIMESTAMPTZ NOT NULL,
+    duration_seconds INTEGER     NOT NULL,
+    periods          JSONB       NOT NULL,
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'active', 'rejected', 'superseded', 'expired', 'cleared')),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_charging_profiles_device_id ON charging_profiles (device_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_charging_profiles_active
+    ON charging_profiles (device_id, connector_id, purpose) WHERE status = 'active';
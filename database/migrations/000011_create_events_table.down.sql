@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_events_vehicle_id_created_at;
+DROP INDEX IF EXISTS idx_events_device_id_created_at;
+
+DROP TABLE IF EXISTS events;
\ No newline at end of file
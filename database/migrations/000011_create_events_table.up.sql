@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS events
+(
+    id         UUID PRIMARY KEY,
+    type       VARCHAR(50) NOT NULL,
+    device_id  UUID REFERENCES devices (id) ON DELETE CASCADE,
+    vehicle_id UUID REFERENCES vehicles (id) ON DELETE CASCADE,
+    payload    JSONB       NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_device_id_created_at ON events (device_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_events_vehicle_id_created_at ON events (vehicle_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_prices_zone_starts_at;
+
+DROP TABLE IF EXISTS prices;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS prices
+(
+    zone               VARCHAR(20)      NOT NULL,
+    starts_at          TIMESTAMPTZ      NOT NULL,
+    resolution_minutes INTEGER          NOT NULL CHECK (resolution_minutes > 0),
+    price_eur_mwh      DOUBLE PRECISION NOT NULL,
+    source             VARCHAR(20)      NOT NULL,
+    updated_at         TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (zone, resolution_minutes, starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_prices_zone_starts_at ON prices (zone, starts_at);
@@ -0,0 +1,7 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS tariff_assigned_at,
+    DROP COLUMN IF EXISTS tariff_id;
+
+DROP INDEX IF EXISTS idx_tariffs_owner_id;
+
+DROP TABLE IF EXISTS tariffs;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS tariffs
+(
+    id         UUID PRIMARY KEY,
+    owner_id   UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name       VARCHAR(100) NOT NULL,
+    kind       VARCHAR(20)  NOT NULL CHECK (kind IN ('fixed', 'time_of_use', 'dynamic')),
+    definition JSONB        NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tariffs_owner_id ON tariffs (owner_id);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS tariff_id UUID REFERENCES tariffs (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS tariff_assigned_at TIMESTAMPTZ;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS meter_values;
+
+DROP SEQUENCE IF EXISTS ocpp_transaction_id_seq;
+
+DROP INDEX IF EXISTS idx_charging_sessions_owner_id_started_at;
+
+DROP TABLE IF EXISTS charging_sessions;
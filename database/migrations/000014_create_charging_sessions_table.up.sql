@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS charging_sessions
+(
+    id             UUID PRIMARY KEY,
+    device_id      UUID             NOT NULL REFERENCES devices (id) ON DELETE CASCADE,
+    connector_id   INTEGER          NOT NULL,
+    transaction_id VARCHAR(64)      NOT NULL,
+    owner_id       UUID             NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    vehicle_id     UUID REFERENCES vehicles (id) ON DELETE SET NULL,
+    id_tag         VARCHAR(64)      NOT NULL DEFAULT '',
+    status         VARCHAR(20)      NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed')),
+    stop_reason    VARCHAR(50)      NOT NULL DEFAULT '',
+    started_at     TIMESTAMPTZ      NOT NULL,
+    stopped_at     TIMESTAMPTZ,
+    imported_kwh   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    exported_kwh   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    energy_cost    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    revenue        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    breakdown      JSONB            NOT NULL DEFAULT '[]',
+    computed_at    TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (device_id, transaction_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_charging_sessions_owner_id_started_at ON charging_sessions (owner_id, started_at);
+
+CREATE SEQUENCE IF NOT EXISTS ocpp_transaction_id_seq;
+
+CREATE TABLE IF NOT EXISTS meter_values
+(
+    session_id  UUID             NOT NULL REFERENCES charging_sessions (id) ON DELETE CASCADE,
+    measured_at TIMESTAMPTZ      NOT NULL,
+    import_wh   DOUBLE PRECISION NOT NULL,
+    export_wh   DOUBLE PRECISION,
+    PRIMARY KEY (session_id, measured_at)
+);
@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_devices_site_id;
+
+ALTER TABLE devices
+    DROP CONSTRAINT IF EXISTS devices_kind_check;
+
+DELETE FROM devices
+WHERE kind NOT IN ('gateway', 'charger');
+
+ALTER TABLE devices
+    ADD CONSTRAINT devices_kind_check CHECK (kind IN ('gateway', 'charger'));
+
+ALTER TABLE devices
+    DROP COLUMN IF EXISTS site_id;
+
+DROP INDEX IF EXISTS idx_site_members_user_id;
+
+DROP TABLE IF EXISTS site_members;
+
+DROP TABLE IF EXISTS sites;
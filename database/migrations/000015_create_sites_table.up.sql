@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS sites
+(
+    id            UUID PRIMARY KEY,
+    name          VARCHAR(100)     NOT NULL,
+    kind          VARCHAR(20)      NOT NULL CHECK (kind IN ('home', 'office', 'depot')),
+    phases        SMALLINT         NOT NULL DEFAULT 3 CHECK (phases IN (1, 3)),
+    voltage_v     DOUBLE PRECISION NOT NULL DEFAULT 230 CHECK (voltage_v > 0),
+    max_current_a DOUBLE PRECISION NOT NULL CHECK (max_current_a > 0),
+    created_at    TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS site_members
+(
+    site_id    UUID        NOT NULL REFERENCES sites (id) ON DELETE CASCADE,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role       VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'manager', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (site_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_site_members_user_id ON site_members (user_id);
+
+ALTER TABLE devices
+    ADD COLUMN IF NOT EXISTS site_id UUID REFERENCES sites (id) ON DELETE SET NULL;
+
+ALTER TABLE devices
+    DROP CONSTRAINT IF EXISTS devices_kind_check;
+
+ALTER TABLE devices
+    ADD CONSTRAINT devices_kind_check CHECK (kind IN ('gateway', 'charger', 'meter', 'inverter'));
+
+CREATE INDEX IF NOT EXISTS idx_devices_site_id ON devices (site_id);
@@ -0,0 +1,2 @@
+ALTER TABLE vehicle_preferences
+    DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,2 @@
+ALTER TABLE vehicle_preferences
+    ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 10);
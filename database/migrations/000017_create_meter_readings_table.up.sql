@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS meter_readings
+(
+    device_id       UUID             NOT NULL REFERENCES devices (id) ON DELETE CASCADE,
+    measured_at     TIMESTAMPTZ      NOT NULL,
+    import_kw       DOUBLE PRECISION NOT NULL,
+    export_kw       DOUBLE PRECISION NOT NULL,
+    household_kw    DOUBLE PRECISION NOT NULL,
+    imported_kwh    DOUBLE PRECISION NOT NULL,
+    exported_kwh    DOUBLE PRECISION NOT NULL,
+    tariff          SMALLINT         NOT NULL DEFAULT 0,
+    phases          JSONB            NOT NULL DEFAULT '[]',
+    gas_m3          DOUBLE PRECISION,
+    gas_measured_at TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (device_id, measured_at)
+);
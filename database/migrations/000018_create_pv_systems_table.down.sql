@@ -0,0 +1,9 @@
+ALTER TABLE vehicle_preferences
+    DROP COLUMN IF EXISTS solar_min_kw,
+    DROP COLUMN IF EXISTS charging_mode;
+
+DROP TABLE IF EXISTS pv_readings;
+
+DROP INDEX IF EXISTS idx_pv_systems_site_id;
+
+DROP TABLE IF EXISTS pv_systems;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS pv_systems
+(
+    id          UUID PRIMARY KEY,
+    site_id     UUID             NOT NULL REFERENCES sites (id) ON DELETE CASCADE,
+    name        VARCHAR(100)     NOT NULL,
+    peak_kw     DOUBLE PRECISION NOT NULL CHECK (peak_kw > 0),
+    tilt_deg    DOUBLE PRECISION NOT NULL DEFAULT 35 CHECK (tilt_deg BETWEEN 0 AND 90),
+    azimuth_deg DOUBLE PRECISION NOT NULL DEFAULT 180 CHECK (azimuth_deg >= 0 AND azimuth_deg < 360),
+    created_at  TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_pv_systems_site_id ON pv_systems (site_id);
+
+CREATE TABLE IF NOT EXISTS pv_readings
+(
+    device_id   UUID             NOT NULL REFERENCES devices (id) ON DELETE CASCADE,
+    measured_at TIMESTAMPTZ      NOT NULL,
+    power_kw    DOUBLE PRECISION NOT NULL,
+    energy_kwh  DOUBLE PRECISION,
+    created_at  TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (device_id, measured_at)
+);
+
+ALTER TABLE vehicle_preferences
+    ADD COLUMN IF NOT EXISTS charging_mode VARCHAR(10) NOT NULL DEFAULT 'cost' CHECK (charging_mode IN ('cost', 'solar')),
+    ADD COLUMN IF NOT EXISTS solar_min_kw DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (solar_min_kw >= 0);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS pv_forecasts;
+
+ALTER TABLE sites
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,12 @@
+ALTER TABLE sites
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180);
+
+CREATE TABLE IF NOT EXISTS pv_forecasts
+(
+    site_id    UUID             NOT NULL REFERENCES sites (id) ON DELETE CASCADE,
+    slot_start TIMESTAMPTZ      NOT NULL,
+    modeled_kw DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (site_id, slot_start)
+);
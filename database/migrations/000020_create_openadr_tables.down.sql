@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS openadr_report_values;
+
+DROP TABLE IF EXISTS openadr_opts;
+
+DROP INDEX IF EXISTS idx_openadr_event_targets_ven_id;
+
+DROP TABLE IF EXISTS openadr_event_targets;
+
+DROP INDEX IF EXISTS idx_openadr_events_owner_id;
+
+DROP TABLE IF EXISTS openadr_events;
+
+DROP INDEX IF EXISTS idx_openadr_ven_vehicles_ven_id;
+
+DROP TABLE IF EXISTS openadr_ven_vehicles;
+
+DROP INDEX IF EXISTS idx_openadr_vens_token_hash;
+DROP INDEX IF EXISTS idx_openadr_vens_owner_id;
+
+DROP TABLE IF EXISTS openadr_vens;
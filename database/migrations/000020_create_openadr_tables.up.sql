@@ -0,0 +1,78 @@
+CREATE TABLE IF NOT EXISTS openadr_vens
+(
+    id                  UUID PRIMARY KEY,
+    owner_id            UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name                VARCHAR(100) NOT NULL,
+    token_hash          BYTEA        NOT NULL,
+    registration_id     UUID,
+    registered_at       TIMESTAMPTZ,
+    last_seen_at        TIMESTAMPTZ,
+    events_changed_at   TIMESTAMPTZ,
+    events_delivered_at TIMESTAMPTZ,
+    opts_changed_at     TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_openadr_vens_owner_id ON openadr_vens (owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_openadr_vens_token_hash ON openadr_vens (token_hash);
+
+CREATE TABLE IF NOT EXISTS openadr_ven_vehicles
+(
+    vehicle_id UUID PRIMARY KEY REFERENCES vehicles (id) ON DELETE CASCADE,
+    ven_id     UUID        NOT NULL REFERENCES openadr_vens (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_openadr_ven_vehicles_ven_id ON openadr_ven_vehicles (ven_id);
+
+CREATE TABLE IF NOT EXISTS openadr_events
+(
+    id                  UUID PRIMARY KEY,
+    owner_id            UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    modification_number INTEGER      NOT NULL DEFAULT 0,
+    priority            INTEGER      NOT NULL DEFAULT 0 CHECK (priority >= 0),
+    market_context      VARCHAR(255) NOT NULL,
+    signal_name         VARCHAR(20)  NOT NULL CHECK (signal_name IN ('SIMPLE', 'ELECTRICITY_PRICE', 'LOAD_DISPATCH')),
+    starts_at           TIMESTAMPTZ  NOT NULL,
+    intervals           JSONB        NOT NULL,
+    test_event          BOOLEAN      NOT NULL DEFAULT FALSE,
+    response_required   BOOLEAN      NOT NULL DEFAULT TRUE,
+    cancelled           BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at          TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_openadr_events_owner_id ON openadr_events (owner_id);
+
+CREATE TABLE IF NOT EXISTS openadr_event_targets
+(
+    event_id     UUID NOT NULL REFERENCES openadr_events (id) ON DELETE CASCADE,
+    ven_id       UUID NOT NULL REFERENCES openadr_vens (id) ON DELETE CASCADE,
+    opt_type     VARCHAR(10) CHECK (opt_type IN ('optIn', 'optOut')),
+    responded_at TIMESTAMPTZ,
+    PRIMARY KEY (event_id, ven_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_openadr_event_targets_ven_id ON openadr_event_targets (ven_id);
+
+CREATE TABLE IF NOT EXISTS openadr_opts
+(
+    ven_id     UUID         NOT NULL REFERENCES openadr_vens (id) ON DELETE CASCADE,
+    opt_id     VARCHAR(100) NOT NULL,
+    opt_type   VARCHAR(10)  NOT NULL CHECK (opt_type IN ('optIn', 'optOut')),
+    opt_reason VARCHAR(30)  NOT NULL,
+    starts_at  TIMESTAMPTZ  NOT NULL,
+    ends_at    TIMESTAMPTZ  NOT NULL CHECK (ends_at > starts_at),
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ven_id, opt_id, starts_at)
+);
+
+CREATE TABLE IF NOT EXISTS openadr_report_values
+(
+    ven_id      UUID             NOT NULL REFERENCES openadr_vens (id) ON DELETE CASCADE,
+    r_id        VARCHAR(100)     NOT NULL,
+    measured_at TIMESTAMPTZ      NOT NULL,
+    value       DOUBLE PRECISION NOT NULL,
+    created_at  TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ven_id, r_id, measured_at)
+);
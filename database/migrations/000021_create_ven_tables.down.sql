@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_flexibility_targets_starts_at;
+DROP INDEX IF EXISTS idx_flexibility_targets_event;
+
+DROP TABLE IF EXISTS flexibility_targets;
+
+DROP TABLE IF EXISTS ven_events;
+
+DROP TABLE IF EXISTS ven_registrations;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS ven_registrations
+(
+    vtn_url           VARCHAR(255) PRIMARY KEY,
+    vtn_id            VARCHAR(255) NOT NULL,
+    ven_id            VARCHAR(255) NOT NULL,
+    registration_id   VARCHAR(255) NOT NULL,
+    poll_freq_seconds INTEGER      NOT NULL CHECK (poll_freq_seconds > 0),
+    registered_at     TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ven_events
+(
+    vtn_url             VARCHAR(255) NOT NULL,
+    event_id            VARCHAR(255) NOT NULL,
+    modification_number INTEGER      NOT NULL,
+    priority            INTEGER      NOT NULL,
+    market_context      VARCHAR(255) NOT NULL,
+    status              VARCHAR(20)  NOT NULL CHECK (status IN ('none', 'far', 'near', 'active', 'completed', 'cancelled')),
+    starts_at           TIMESTAMPTZ  NOT NULL,
+    ends_at             TIMESTAMPTZ  NOT NULL,
+    test_event          BOOLEAN      NOT NULL,
+    opt_type            VARCHAR(10)  NOT NULL CHECK (opt_type IN ('optIn', 'optOut')),
+    received_at         TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (vtn_url, event_id)
+);
+
+CREATE TABLE IF NOT EXISTS flexibility_targets
+(
+    id         UUID PRIMARY KEY,
+    vtn_url    VARCHAR(255)     NOT NULL,
+    event_id   VARCHAR(255)     NOT NULL,
+    kind       VARCHAR(20)      NOT NULL CHECK (kind IN ('max_import', 'setpoint', 'price')),
+    starts_at  TIMESTAMPTZ      NOT NULL,
+    ends_at    TIMESTAMPTZ      NOT NULL CHECK (ends_at > starts_at),
+    value      DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vtn_url, event_id) REFERENCES ven_events (vtn_url, event_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_flexibility_targets_event ON flexibility_targets (vtn_url, event_id);
+CREATE INDEX IF NOT EXISTS idx_flexibility_targets_starts_at ON flexibility_targets (starts_at);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS flexibility_forecasts;
+
+DROP INDEX IF EXISTS idx_sites_region;
+
+ALTER TABLE sites
+    DROP COLUMN IF EXISTS region;
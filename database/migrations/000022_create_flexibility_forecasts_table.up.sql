@@ -0,0 +1,18 @@
+ALTER TABLE sites
+    ADD COLUMN IF NOT EXISTS region VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_sites_region ON sites (region);
+
+CREATE TABLE IF NOT EXISTS flexibility_forecasts
+(
+    site_id        UUID             NOT NULL REFERENCES sites (id) ON DELETE CASCADE,
+    starts_at      TIMESTAMPTZ      NOT NULL,
+    vehicles       INTEGER          NOT NULL CHECK (vehicles >= 0),
+    baseline_kw    DOUBLE PRECISION NOT NULL,
+    up_kw          DOUBLE PRECISION NOT NULL CHECK (up_kw >= 0),
+    down_kw        DOUBLE PRECISION NOT NULL CHECK (down_kw >= 0),
+    forecast_at    TIMESTAMPTZ      NOT NULL,
+    actual_kw      DOUBLE PRECISION,
+    actual_samples INTEGER          NOT NULL DEFAULT 0,
+    PRIMARY KEY (site_id, starts_at)
+);
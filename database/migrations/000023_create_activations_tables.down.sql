@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS activation_measurements;
+
+DROP INDEX IF EXISTS idx_activation_participants_vehicle_id;
+DROP TABLE IF EXISTS activation_participants;
+
+DROP INDEX IF EXISTS idx_activations_status;
+DROP INDEX IF EXISTS idx_activations_site_id;
+DROP TABLE IF EXISTS activations;
+
+ALTER TABLE vehicle_preferences
+    DROP COLUMN IF EXISTS flexibility_opt_out;
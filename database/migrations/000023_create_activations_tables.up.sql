@@ -0,0 +1,53 @@
+ALTER TABLE vehicle_preferences
+    ADD COLUMN IF NOT EXISTS flexibility_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS activations
+(
+    id            UUID PRIMARY KEY,
+    source        VARCHAR(20)      NOT NULL CHECK (source IN ('api', 'openadr')),
+    site_id       UUID REFERENCES sites (id) ON DELETE CASCADE,
+    target_id     UUID UNIQUE REFERENCES flexibility_targets (id) ON DELETE SET NULL,
+    created_by    UUID REFERENCES users (id) ON DELETE SET NULL,
+    kind          VARCHAR(20)      NOT NULL CHECK (kind IN ('setpoint', 'max_import')),
+    target_kw     DOUBLE PRECISION NOT NULL,
+    starts_at     TIMESTAMPTZ      NOT NULL,
+    ends_at       TIMESTAMPTZ      NOT NULL CHECK (ends_at > starts_at),
+    status        VARCHAR(20)      NOT NULL DEFAULT 'scheduled'
+        CHECK (status IN ('scheduled', 'active', 'completed', 'cancelled')),
+    requested_kwh DOUBLE PRECISION NOT NULL DEFAULT 0,
+    delivered_kwh DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_activations_site_id ON activations (site_id);
+CREATE INDEX IF NOT EXISTS idx_activations_status ON activations (status, starts_at);
+
+CREATE TABLE IF NOT EXISTS activation_participants
+(
+    activation_id UUID             NOT NULL REFERENCES activations (id) ON DELETE CASCADE,
+    vehicle_id    UUID             NOT NULL REFERENCES vehicles (id) ON DELETE CASCADE,
+    device_id     UUID REFERENCES devices (id) ON DELETE SET NULL,
+    baseline_kw   DOUBLE PRECISION NOT NULL,
+    setpoint_kw   DOUBLE PRECISION NOT NULL,
+    dispatched_at TIMESTAMPTZ      NOT NULL,
+    opted_out     BOOLEAN          NOT NULL DEFAULT FALSE,
+    requested_kwh DOUBLE PRECISION NOT NULL DEFAULT 0,
+    delivered_kwh DOUBLE PRECISION NOT NULL DEFAULT 0,
+    measured_at   TIMESTAMPTZ,
+    PRIMARY KEY (activation_id, vehicle_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_activation_participants_vehicle_id ON activation_participants (vehicle_id);
+
+CREATE TABLE IF NOT EXISTS activation_measurements
+(
+    activation_id UUID             NOT NULL REFERENCES activations (id) ON DELETE CASCADE,
+    measured_at   TIMESTAMPTZ      NOT NULL,
+    vehicles      INTEGER          NOT NULL,
+    target_kw     DOUBLE PRECISION NOT NULL,
+    baseline_kw   DOUBLE PRECISION NOT NULL,
+    setpoint_kw   DOUBLE PRECISION NOT NULL,
+    actual_kw     DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (activation_id, measured_at)
+);
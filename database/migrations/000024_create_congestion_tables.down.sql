@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS congestion_alerts;
+
+DROP INDEX IF EXISTS idx_congestion_limits_ends_at;
+
+DROP TABLE IF EXISTS congestion_limits;
+
+DROP TABLE IF EXISTS congestion_zones;
+
+ALTER TABLE sites
+    DROP COLUMN IF EXISTS postcode;
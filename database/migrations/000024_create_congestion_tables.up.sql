@@ -0,0 +1,39 @@
+ALTER TABLE sites
+    ADD COLUMN IF NOT EXISTS postcode VARCHAR(6) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS congestion_zones
+(
+    id              UUID PRIMARY KEY,
+    name            VARCHAR(100) NOT NULL,
+    operator        VARCHAR(100) NOT NULL DEFAULT '',
+    postcode_ranges JSONB        NOT NULL DEFAULT '[]',
+    polygon         JSONB,
+    created_by      UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS congestion_limits
+(
+    id            UUID PRIMARY KEY,
+    zone_id       UUID         NOT NULL REFERENCES congestion_zones (id) ON DELETE CASCADE,
+    starts_at     TIMESTAMPTZ  NOT NULL,
+    ends_at       TIMESTAMPTZ  NOT NULL CHECK (ends_at > starts_at),
+    max_import_kw DOUBLE PRECISION CHECK (max_import_kw >= 0),
+    max_export_kw DOUBLE PRECISION CHECK (max_export_kw >= 0),
+    reference     VARCHAR(100) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (max_import_kw IS NOT NULL OR max_export_kw IS NOT NULL),
+    UNIQUE (zone_id, starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_congestion_limits_ends_at ON congestion_limits (ends_at);
+
+CREATE TABLE IF NOT EXISTS congestion_alerts
+(
+    limit_id   UUID        NOT NULL REFERENCES congestion_limits (id) ON DELETE CASCADE,
+    vehicle_id UUID        NOT NULL REFERENCES vehicles (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (limit_id, vehicle_id)
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS battery_health;
+
+ALTER TABLE vehicle_preferences
+    DROP COLUMN IF EXISTS wear_cost_per_kwh;
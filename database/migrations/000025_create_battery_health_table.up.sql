@@ -0,0 +1,17 @@
+ALTER TABLE vehicle_preferences
+    ADD COLUMN IF NOT EXISTS wear_cost_per_kwh DOUBLE PRECISION CHECK (wear_cost_per_kwh >= 0);
+
+CREATE TABLE IF NOT EXISTS battery_health
+(
+    vehicle_id      UUID PRIMARY KEY REFERENCES vehicles (id) ON DELETE CASCADE,
+    last_soc        DOUBLE PRECISION NOT NULL CHECK (last_soc BETWEEN 0 AND 100),
+    temperature_c   DOUBLE PRECISION NOT NULL,
+    residue         JSONB            NOT NULL DEFAULT '[]',
+    cycle_stress    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    calendar_stress DOUBLE PRECISION NOT NULL DEFAULT 0,
+    full_cycles     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    charged_kwh     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    discharged_kwh  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    tracked_since   TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sampled_at      TIMESTAMPTZ      NOT NULL
+);
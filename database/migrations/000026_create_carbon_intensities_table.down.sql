@@ -0,0 +1,16 @@
+ALTER TABLE charging_sessions
+    DROP COLUMN IF EXISTS baseline_emissions_kg,
+    DROP COLUMN IF EXISTS emissions_kg;
+
+UPDATE vehicle_preferences
+SET charging_mode = 'cost'
+WHERE charging_mode = 'greenest';
+
+ALTER TABLE vehicle_preferences
+    DROP COLUMN IF EXISTS carbon_weight,
+    DROP CONSTRAINT IF EXISTS vehicle_preferences_charging_mode_check,
+    ADD CONSTRAINT vehicle_preferences_charging_mode_check CHECK (charging_mode IN ('cost', 'solar'));
+
+DROP INDEX IF EXISTS idx_carbon_intensities_zone_starts_at;
+
+DROP TABLE IF EXISTS carbon_intensities;
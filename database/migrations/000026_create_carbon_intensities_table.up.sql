@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS carbon_intensities
+(
+    zone               VARCHAR(20)      NOT NULL,
+    starts_at          TIMESTAMPTZ      NOT NULL,
+    resolution_minutes INTEGER          NOT NULL CHECK (resolution_minutes > 0),
+    grams_co2_kwh      DOUBLE PRECISION NOT NULL CHECK (grams_co2_kwh >= 0),
+    source             VARCHAR(20)      NOT NULL,
+    updated_at         TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (zone, resolution_minutes, starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_carbon_intensities_zone_starts_at ON carbon_intensities (zone, starts_at);
+
+ALTER TABLE vehicle_preferences
+    DROP CONSTRAINT IF EXISTS vehicle_preferences_charging_mode_check,
+    ADD CONSTRAINT vehicle_preferences_charging_mode_check CHECK (charging_mode IN ('cost', 'solar', 'greenest')),
+    ADD COLUMN IF NOT EXISTS carbon_weight DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (carbon_weight BETWEEN 0 AND 1);
+
+ALTER TABLE charging_sessions
+    ADD COLUMN IF NOT EXISTS emissions_kg DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS baseline_emissions_kg DOUBLE PRECISION;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reservations;
+
+DROP SEQUENCE IF EXISTS ocpp_reservation_id_seq;
@@ -0,0 +1,23 @@
+CREATE SEQUENCE IF NOT EXISTS ocpp_reservation_id_seq;
+
+CREATE TABLE IF NOT EXISTS reservations
+(
+    id             UUID PRIMARY KEY,
+    ocpp_id        INTEGER     NOT NULL UNIQUE DEFAULT nextval('ocpp_reservation_id_seq'),
+    site_id        UUID        NOT NULL REFERENCES sites (id) ON DELETE CASCADE,
+    connector_id   UUID        NOT NULL REFERENCES connectors (id) ON DELETE CASCADE,
+    user_id        UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    starts_at      TIMESTAMPTZ NOT NULL,
+    ends_at        TIMESTAMPTZ NOT NULL CHECK (ends_at > starts_at),
+    status         VARCHAR(20) NOT NULL DEFAULT 'booked'
+        CHECK (status IN ('booked', 'held', 'fulfilled', 'cancelled', 'no_show', 'expired')),
+    charger_status VARCHAR(20) NOT NULL DEFAULT '',
+    held_at        TIMESTAMPTZ,
+    session_id     UUID REFERENCES charging_sessions (id) ON DELETE SET NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservations_connector_id ON reservations (connector_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_reservations_site_id ON reservations (site_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_reservations_status ON reservations (status, starts_at);
@@ -0,0 +1,2 @@
+ALTER TABLE devices
+    ADD COLUMN IF NOT EXISTS ocpp_password_hash BYTEA;
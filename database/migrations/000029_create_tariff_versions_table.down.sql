@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tariff_versions_user_id;
+
+DROP TABLE IF EXISTS tariff_versions;
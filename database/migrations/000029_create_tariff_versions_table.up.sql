@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS tariff_versions
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tariff_id  UUID REFERENCES tariffs (id) ON DELETE SET NULL,
+    kind       VARCHAR(20) NOT NULL,
+    definition JSONB       NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tariff_versions_user_id ON tariff_versions (user_id, valid_from);
+
+INSERT INTO tariff_versions (id, user_id, tariff_id, kind, definition, valid_from)
+SELECT gen_random_uuid(), users.id, tariffs.id, tariffs.kind, tariffs.definition,
+       COALESCE(users.tariff_assigned_at, tariffs.updated_at)
+FROM users
+JOIN tariffs ON tariffs.id = users.tariff_id;
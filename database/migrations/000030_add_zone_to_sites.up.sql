@@ -0,0 +1,2 @@
+ALTER TABLE sites
+    ADD COLUMN IF NOT EXISTS zone VARCHAR(20) NOT NULL DEFAULT '';
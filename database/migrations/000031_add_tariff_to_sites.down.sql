@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_tariff_versions_site_id;
+
+DELETE FROM tariff_versions
+WHERE site_id IS NOT NULL;
+
+ALTER TABLE tariff_versions
+    DROP CONSTRAINT IF EXISTS tariff_versions_subject_check,
+    DROP COLUMN IF EXISTS site_id,
+    ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE sites
+    DROP COLUMN IF EXISTS tariff_assigned_at,
+    DROP COLUMN IF EXISTS tariff_id;
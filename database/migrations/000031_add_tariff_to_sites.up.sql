@@ -0,0 +1,10 @@
+ALTER TABLE sites
+    ADD COLUMN IF NOT EXISTS tariff_id UUID REFERENCES tariffs (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS tariff_assigned_at TIMESTAMPTZ;
+
+ALTER TABLE tariff_versions
+    ALTER COLUMN user_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS site_id UUID REFERENCES sites (id) ON DELETE CASCADE,
+    ADD CONSTRAINT tariff_versions_subject_check CHECK ((user_id IS NULL) <> (site_id IS NULL));
+
+CREATE INDEX IF NOT EXISTS idx_tariff_versions_site_id ON tariff_versions (site_id, valid_from);
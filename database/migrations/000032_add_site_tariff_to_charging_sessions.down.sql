@@ -0,0 +1,2 @@
+ALTER TABLE charging_sessions
+    DROP COLUMN IF EXISTS site_tariff;
@@ -0,0 +1,2 @@
+ALTER TABLE charging_sessions
+    ADD COLUMN IF NOT EXISTS site_tariff BOOLEAN NOT NULL DEFAULT FALSE;
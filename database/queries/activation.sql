@@ -0,0 +1,84 @@
+-- name: CreateActivation :one
+INSERT INTO activations (id, source, site_id, target_id, created_by, kind, target_kw, starts_at, ends_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: CreateActivationForTarget :exec
+INSERT INTO activations (id, source, target_id, kind, target_kw, starts_at, ends_at)
+VALUES ($1, 'openadr', $2, $3, $4, $5, $6)
+ON CONFLICT (target_id) DO NOTHING;
+
+-- name: GetActivationById :one
+SELECT * FROM activations
+WHERE id = $1 LIMIT 1;
+
+-- name: ListActivationsBySiteId :many
+SELECT * FROM activations
+WHERE site_id = $1
+ORDER BY starts_at DESC
+LIMIT $2;
+
+-- name: ListDueActivations :many
+SELECT * FROM activations
+WHERE status IN ('scheduled', 'active') AND starts_at <= $1
+ORDER BY created_at;
+
+-- name: SetActivationStatus :exec
+UPDATE activations
+SET status = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: CancelActivation :execrows
+UPDATE activations
+SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('scheduled', 'active');
+
+-- name: CancelOrphanedActivations :many
+UPDATE activations
+SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+WHERE source = 'openadr' AND target_id IS NULL AND status IN ('scheduled', 'active')
+RETURNING *;
+
+-- name: SetActivationEnergy :exec
+UPDATE activations
+SET requested_kwh = $2, delivered_kwh = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpsertActivationParticipant :execrows
+INSERT INTO activation_participants (activation_id, vehicle_id, device_id, baseline_kw, setpoint_kw, dispatched_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (activation_id, vehicle_id) DO UPDATE
+    SET device_id = EXCLUDED.device_id, baseline_kw = EXCLUDED.baseline_kw, setpoint_kw = EXCLUDED.setpoint_kw,
+        dispatched_at = EXCLUDED.dispatched_at
+    WHERE NOT activation_participants.opted_out;
+
+-- name: OptOutActivationParticipant :exec
+INSERT INTO activation_participants (activation_id, vehicle_id, baseline_kw, setpoint_kw, dispatched_at, opted_out)
+VALUES ($1, $2, 0, 0, $3, TRUE)
+ON CONFLICT (activation_id, vehicle_id) DO UPDATE
+    SET opted_out = TRUE;
+
+-- name: SetActivationParticipantDelivery :exec
+UPDATE activation_participants
+SET requested_kwh = $3, delivered_kwh = $4, measured_at = $5
+WHERE activation_id = $1 AND vehicle_id = $2;
+
+-- name: ListActivationParticipants :many
+SELECT * FROM activation_participants
+WHERE activation_id = $1
+ORDER BY vehicle_id;
+
+-- name: ListActivationParticipantsByVehicleId :many
+SELECT * FROM activation_participants
+WHERE vehicle_id = $1
+ORDER BY dispatched_at DESC
+LIMIT $2;
+
+-- name: CreateActivationMeasurement :exec
+INSERT INTO activation_measurements (activation_id, measured_at, vehicles, target_kw, baseline_kw, setpoint_kw, actual_kw)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListActivationMeasurements :many
+SELECT * FROM activation_measurements
+WHERE activation_id = $1
+ORDER BY measured_at;
@@ -0,0 +1,12 @@
+-- name: GetBatteryHealthByVehicleId :one
+SELECT * FROM battery_health
+WHERE vehicle_id = $1 LIMIT 1;
+
+-- name: UpsertBatteryHealth :one
+INSERT INTO battery_health (vehicle_id, last_soc, temperature_c, residue, cycle_stress, calendar_stress, full_cycles, charged_kwh, discharged_kwh, sampled_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (vehicle_id) DO UPDATE
+SET last_soc = EXCLUDED.last_soc, temperature_c = EXCLUDED.temperature_c, residue = EXCLUDED.residue,
+    cycle_stress = EXCLUDED.cycle_stress, calendar_stress = EXCLUDED.calendar_stress, full_cycles = EXCLUDED.full_cycles,
+    charged_kwh = EXCLUDED.charged_kwh, discharged_kwh = EXCLUDED.discharged_kwh, sampled_at = EXCLUDED.sampled_at
+RETURNING *;
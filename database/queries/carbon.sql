@@ -0,0 +1,15 @@
+-- name: UpsertCarbonIntensity :exec
+INSERT INTO carbon_intensities (zone, starts_at, resolution_minutes, grams_co2_kwh, source)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (zone, resolution_minutes, starts_at) DO UPDATE
+SET grams_co2_kwh = EXCLUDED.grams_co2_kwh, source = EXCLUDED.source, updated_at = CURRENT_TIMESTAMP
+WHERE carbon_intensities.grams_co2_kwh IS DISTINCT FROM EXCLUDED.grams_co2_kwh;
+
+-- name: ListCarbonIntensities :many
+SELECT * FROM carbon_intensities
+WHERE zone = sqlc.arg(zone) AND starts_at >= sqlc.arg(starts_from) AND starts_at < sqlc.arg(starts_before)
+ORDER BY starts_at, resolution_minutes;
+
+-- name: GetCarbonIntensitiesUpdatedAt :one
+SELECT COALESCE(MAX(updated_at), 'epoch')::timestamptz AS updated_at FROM carbon_intensities
+WHERE zone = $1;
@@ -0,0 +1,48 @@
+-- name: CreateChargingProfile :one
+INSERT INTO charging_profiles (id, device_id, connector_id, profile_id, purpose, stack_level, schedule_id, start_schedule, duration_seconds, periods)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: SetChargingProfileStatus :exec
+UPDATE charging_profiles
+SET status = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: SupersedeActiveChargingProfiles :exec
+UPDATE charging_profiles
+SET status = 'superseded', updated_at = CURRENT_TIMESTAMP
+WHERE device_id = $1 AND connector_id = $2 AND purpose = $3 AND status = 'active';
+
+-- name: SupersedePendingChargingProfiles :exec
+UPDATE charging_profiles
+SET status = 'superseded', updated_at = CURRENT_TIMESTAMP
+WHERE device_id = $1 AND connector_id = $2 AND purpose = $3 AND status = 'pending';
+
+-- name: ListChargingProfilesByDeviceId :many
+SELECT * FROM charging_profiles
+WHERE device_id = $1 AND status IN ('pending', 'active')
+ORDER BY connector_id, created_at;
+
+-- name: ListChargingProfilesByStatus :many
+SELECT * FROM charging_profiles
+WHERE status = $1
+ORDER BY created_at;
+
+-- name: ClearChargingProfilesByDeviceId :execrows
+UPDATE charging_profiles
+SET status = 'cleared', updated_at = CURRENT_TIMESTAMP
+WHERE device_id = $1 AND status IN ('pending', 'active');
+
+-- name: ClearChargingProfilesByConnector :execrows
+UPDATE charging_profiles
+SET status = 'cleared', updated_at = CURRENT_TIMESTAMP
+WHERE device_id = $1 AND connector_id = $2 AND purpose = $3 AND status IN ('pending', 'active');
+
+-- name: ClearChargingProfilesByPurpose :execrows
+UPDATE charging_profiles
+SET status = 'cleared', updated_at = CURRENT_TIMESTAMP
+WHERE device_id = $1 AND purpose = $2 AND status IN ('pending', 'active');
+
+-- name: DeleteFinishedChargingProfiles :execrows
+DELETE FROM charging_profiles
+WHERE status NOT IN ('pending', 'active') AND updated_at < $1;
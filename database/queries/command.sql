@@ -0,0 +1,30 @@
+-- name: CreateDeviceCommand :one
+INSERT INTO device_commands (id, device_id, type, payload, max_attempts, timeout_seconds, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetDeviceCommand :one
+SELECT * FROM device_commands
+WHERE id = $1 AND device_id = $2 LIMIT 1;
+
+-- name: ListDeviceCommandsByDeviceId :many
+SELECT * FROM device_commands
+WHERE device_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: ListDueDeviceCommands :many
+SELECT * FROM device_commands
+WHERE status IN ('pending', 'sent') AND next_attempt_at <= $1
+ORDER BY next_attempt_at
+LIMIT $2;
+
+-- name: MarkDeviceCommandSent :exec
+UPDATE device_commands
+SET status = 'sent', attempts = attempts + 1, next_attempt_at = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('pending', 'sent');
+
+-- name: CompleteDeviceCommand :execrows
+UPDATE device_commands
+SET status = $3, reason = $4, completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND device_id = $2 AND status IN ('pending', 'sent');
@@ -0,0 +1,44 @@
+-- name: CreateCongestionZone :one
+INSERT INTO congestion_zones (id, name, operator, postcode_ranges, polygon, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetCongestionZoneById :one
+SELECT * FROM congestion_zones
+WHERE id = $1;
+
+-- name: ListCongestionZones :many
+SELECT * FROM congestion_zones
+ORDER BY name;
+
+-- name: UpdateCongestionZone :one
+UPDATE congestion_zones
+SET name = $2, operator = $3, postcode_ranges = $4, polygon = $5, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteCongestionZone :exec
+DELETE FROM congestion_zones
+WHERE id = $1;
+
+-- name: UpsertCongestionLimit :one
+INSERT INTO congestion_limits (id, zone_id, starts_at, ends_at, max_import_kw, max_export_kw, reference)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (zone_id, starts_at) DO UPDATE
+    SET ends_at = EXCLUDED.ends_at, max_import_kw = EXCLUDED.max_import_kw, max_export_kw = EXCLUDED.max_export_kw,
+        reference = EXCLUDED.reference, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: ListCongestionLimitsByZoneId :many
+SELECT * FROM congestion_limits
+WHERE zone_id = $1 AND ends_at > sqlc.arg(ends_after) AND starts_at < sqlc.arg(starts_before)
+ORDER BY starts_at;
+
+-- name: DeleteCongestionLimit :execrows
+DELETE FROM congestion_limits
+WHERE id = $1 AND zone_id = $2;
+
+-- name: CreateCongestionAlert :execrows
+INSERT INTO congestion_alerts (limit_id, vehicle_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
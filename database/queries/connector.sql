@@ -0,0 +1,27 @@
+-- name: UpsertConnectorStatus :one
+INSERT INTO connectors (id, device_id, connector_id, status)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (device_id, connector_id) DO UPDATE
+SET status = EXCLUDED.status, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: ListConnectorsByDeviceId :many
+SELECT * FROM connectors
+WHERE device_id = $1
+ORDER BY connector_id;
+
+-- name: GetConnectorById :one
+SELECT connectors.*, devices.site_id, devices.owner_id FROM connectors
+JOIN devices ON devices.id = connectors.device_id
+WHERE connectors.id = $1;
+
+-- name: ListConnectorsBySiteId :many
+SELECT connectors.*, devices.name AS device_name FROM connectors
+JOIN devices ON devices.id = connectors.device_id
+WHERE devices.site_id = $1 AND devices.kind = 'charger'
+ORDER BY devices.name, connectors.connector_id;
+
+-- name: LockConnector :exec
+SELECT id FROM connectors
+WHERE id = $1
+FOR UPDATE;
@@ -0,0 +1,32 @@
+-- name: CreateDevice :one
+INSERT INTO devices (id, owner_id, serial_number, name, kind, ocpp_password_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetDeviceById :one
+SELECT * FROM devices
+WHERE id = $1 LIMIT 1;
+
+-- name: GetDeviceBySerialNumber :one
+SELECT * FROM devices
+WHERE serial_number = $1 LIMIT 1;
+
+-- name: ListDevicesByOwnerId :many
+SELECT * FROM devices
+WHERE owner_id = $1
+ORDER BY created_at;
+
+-- name: ListDevicesBySiteId :many
+SELECT * FROM devices
+WHERE site_id = $1
+ORDER BY created_at;
+
+-- name: SetDeviceSite :exec
+UPDATE devices
+SET site_id = $2
+WHERE id = $1;
+
+-- name: SetDeviceOcppPasswordHash :exec
+UPDATE devices
+SET ocpp_password_hash = $2
+WHERE id = $1;
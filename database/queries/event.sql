@@ -0,0 +1,9 @@
+-- name: CreateEvent :exec
+INSERT INTO events (id, type, device_id, vehicle_id, payload)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListEventsByDeviceId :many
+SELECT * FROM events
+WHERE device_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
@@ -0,0 +1,21 @@
+-- name: UpsertFlexibilityForecast :exec
+INSERT INTO flexibility_forecasts (site_id, starts_at, vehicles, baseline_kw, up_kw, down_kw, forecast_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (site_id, starts_at) DO UPDATE
+    SET vehicles = EXCLUDED.vehicles, baseline_kw = EXCLUDED.baseline_kw, up_kw = EXCLUDED.up_kw,
+        down_kw = EXCLUDED.down_kw, forecast_at = EXCLUDED.forecast_at
+    WHERE flexibility_forecasts.starts_at > EXCLUDED.forecast_at;
+
+-- name: GetFlexibilityForecast :one
+SELECT * FROM flexibility_forecasts
+WHERE site_id = $1 AND starts_at = $2;
+
+-- name: SetFlexibilityActual :exec
+UPDATE flexibility_forecasts
+SET actual_kw = $3, actual_samples = $4
+WHERE site_id = $1 AND starts_at = $2;
+
+-- name: ListFlexibilityForecastsBySiteId :many
+SELECT * FROM flexibility_forecasts
+WHERE site_id = $1 AND starts_at >= sqlc.arg(starts_from) AND starts_at < sqlc.arg(starts_before)
+ORDER BY starts_at;
@@ -0,0 +1,34 @@
+-- name: CreateMeterReading :exec
+INSERT INTO meter_readings (device_id, measured_at, import_kw, export_kw, household_kw, imported_kwh, exported_kwh, tariff, phases, gas_m3, gas_measured_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (device_id, measured_at) DO NOTHING;
+
+-- name: GetLatestMeterReadingByDeviceId :one
+SELECT * FROM meter_readings
+WHERE device_id = $1
+ORDER BY measured_at DESC
+LIMIT 1;
+
+-- name: GetLatestMeterReadingBySiteId :one
+SELECT meter_readings.* FROM meter_readings
+JOIN devices ON devices.id = meter_readings.device_id
+WHERE devices.site_id = $1
+ORDER BY meter_readings.measured_at DESC
+LIMIT 1;
+
+-- name: ListMeterReadingsByDeviceId :many
+SELECT * FROM meter_readings
+WHERE device_id = $1 AND measured_at >= sqlc.arg(measured_from) AND measured_at < sqlc.arg(measured_before)
+ORDER BY measured_at;
+
+-- name: ListMeterReadingsBySiteId :many
+SELECT meter_readings.* FROM meter_readings
+JOIN devices ON devices.id = meter_readings.device_id
+WHERE devices.site_id = $1 AND meter_readings.measured_at >= sqlc.arg(measured_from)
+ORDER BY meter_readings.measured_at;
+
+-- name: ListMeterReadingsBySiteIdInRange :many
+SELECT meter_readings.* FROM meter_readings
+JOIN devices ON devices.id = meter_readings.device_id
+WHERE devices.site_id = $1 AND meter_readings.measured_at >= sqlc.arg(measured_from) AND meter_readings.measured_at < sqlc.arg(measured_before)
+ORDER BY meter_readings.device_id, meter_readings.measured_at;
@@ -0,0 +1,137 @@
+-- name: CreateOpenadrVen :one
+INSERT INTO openadr_vens (id, owner_id, name, token_hash)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetOpenadrVenById :one
+SELECT * FROM openadr_vens
+WHERE id = $1;
+
+-- name: GetOpenadrVenByTokenHash :one
+SELECT * FROM openadr_vens
+WHERE token_hash = $1;
+
+-- name: ListOpenadrVensByOwnerId :many
+SELECT * FROM openadr_vens
+WHERE owner_id = $1
+ORDER BY created_at;
+
+-- name: DeleteOpenadrVen :exec
+DELETE FROM openadr_vens
+WHERE id = $1;
+
+-- name: RegisterOpenadrVen :one
+UPDATE openadr_vens
+SET registration_id = $2, registered_at = CURRENT_TIMESTAMP, events_changed_at = CURRENT_TIMESTAMP, events_delivered_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: CancelOpenadrVenRegistration :exec
+UPDATE openadr_vens
+SET registration_id = NULL, registered_at = NULL
+WHERE id = $1;
+
+-- name: TouchOpenadrVen :exec
+UPDATE openadr_vens
+SET last_seen_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: MarkOpenadrVenEventsDelivered :exec
+UPDATE openadr_vens
+SET events_delivered_at = $2
+WHERE id = $1;
+
+-- name: MarkOpenadrVensEventsChanged :exec
+UPDATE openadr_vens
+SET events_changed_at = CURRENT_TIMESTAMP
+WHERE id IN (SELECT ven_id FROM openadr_event_targets WHERE event_id = $1);
+
+-- name: MarkOpenadrVenOptsChanged :exec
+UPDATE openadr_vens
+SET opts_changed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpsertOpenadrVenVehicle :one
+INSERT INTO openadr_ven_vehicles (vehicle_id, ven_id)
+VALUES ($1, $2)
+ON CONFLICT (vehicle_id) DO UPDATE
+    SET ven_id = EXCLUDED.ven_id, created_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetOpenadrVenVehicle :one
+SELECT * FROM openadr_ven_vehicles
+WHERE vehicle_id = $1;
+
+-- name: ListOpenadrVenVehicles :many
+SELECT * FROM openadr_ven_vehicles
+WHERE ven_id = $1
+ORDER BY created_at;
+
+-- name: DeleteOpenadrVenVehicle :execrows
+DELETE FROM openadr_ven_vehicles
+WHERE vehicle_id = $1 AND ven_id = $2;
+
+-- name: CreateOpenadrEvent :one
+INSERT INTO openadr_events (id, owner_id, priority, market_context, signal_name, starts_at, intervals, test_event, response_required)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetOpenadrEventById :one
+SELECT * FROM openadr_events
+WHERE id = $1;
+
+-- name: ListOpenadrEventsByOwnerId :many
+SELECT * FROM openadr_events
+WHERE owner_id = $1 AND starts_at >= sqlc.arg(starts_from)
+ORDER BY starts_at;
+
+-- name: CancelOpenadrEvent :one
+UPDATE openadr_events
+SET cancelled = TRUE, modification_number = modification_number + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateOpenadrEventTarget :exec
+INSERT INTO openadr_event_targets (event_id, ven_id)
+VALUES ($1, $2);
+
+-- name: ListOpenadrEventTargets :many
+SELECT * FROM openadr_event_targets
+WHERE event_id = $1;
+
+-- name: ListOpenadrEventsByVenId :many
+SELECT openadr_events.*, openadr_event_targets.opt_type FROM openadr_events
+JOIN openadr_event_targets ON openadr_event_targets.event_id = openadr_events.id
+WHERE openadr_event_targets.ven_id = $1 AND openadr_events.starts_at >= sqlc.arg(starts_from)
+ORDER BY openadr_events.starts_at;
+
+-- name: SetOpenadrEventOpt :execrows
+UPDATE openadr_event_targets
+SET opt_type = $3, responded_at = CURRENT_TIMESTAMP
+WHERE event_id = $1 AND ven_id = $2;
+
+-- name: CreateOpenadrOpt :exec
+INSERT INTO openadr_opts (ven_id, opt_id, opt_type, opt_reason, starts_at, ends_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (ven_id, opt_id, starts_at) DO UPDATE
+    SET opt_type = EXCLUDED.opt_type, opt_reason = EXCLUDED.opt_reason, ends_at = EXCLUDED.ends_at;
+
+-- name: DeleteOpenadrOpt :execrows
+DELETE FROM openadr_opts
+WHERE ven_id = $1 AND opt_id = $2;
+
+-- name: ListOpenadrOptsByVenId :many
+SELECT * FROM openadr_opts
+WHERE ven_id = $1 AND ends_at > sqlc.arg(ends_after)
+ORDER BY starts_at;
+
+-- name: CreateOpenadrReportValue :exec
+INSERT INTO openadr_report_values (ven_id, r_id, measured_at, value)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (ven_id, r_id, measured_at) DO UPDATE
+    SET value = EXCLUDED.value;
+
+-- name: ListOpenadrReportValuesByVenId :many
+SELECT * FROM openadr_report_values
+WHERE ven_id = $1 AND measured_at >= sqlc.arg(measured_from) AND measured_at < sqlc.arg(measured_before)
+ORDER BY r_id, measured_at;
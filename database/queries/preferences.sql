@@ -0,0 +1,33 @@
+-- name: EnsureVehiclePreferences :exec
+INSERT INTO vehicle_preferences (vehicle_id)
+VALUES ($1)
+ON CONFLICT (vehicle_id) DO NOTHING;
+
+-- name: GetVehiclePreferences :one
+SELECT * FROM vehicle_preferences
+WHERE vehicle_id = $1 LIMIT 1;
+
+-- name: UpdateVehiclePreferences :one
+UPDATE vehicle_preferences
+SET target_soc = $2, min_soc = $3, max_discharge_cycles = $4, departure_at = $5, timezone = $6, priority = $7, charging_mode = $8, solar_min_kw = $9, flexibility_opt_out = $10, wear_cost_per_kwh = $11, carbon_weight = $12, updated_at = CURRENT_TIMESTAMP
+WHERE vehicle_id = $1
+RETURNING *;
+
+-- name: SetVehicleBoost :one
+UPDATE vehicle_preferences
+SET boost_until = $2, updated_at = CURRENT_TIMESTAMP
+WHERE vehicle_id = $1
+RETURNING *;
+
+-- name: ListVehicleWeeklyDepartures :many
+SELECT * FROM vehicle_weekly_departures
+WHERE vehicle_id = $1
+ORDER BY weekday;
+
+-- name: DeleteVehicleWeeklyDepartures :exec
+DELETE FROM vehicle_weekly_departures
+WHERE vehicle_id = $1;
+
+-- name: CreateVehicleWeeklyDeparture :exec
+INSERT INTO vehicle_weekly_departures (vehicle_id, weekday, minute_of_day)
+VALUES ($1, $2, $3);
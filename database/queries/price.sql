@@ -0,0 +1,15 @@
+-- name: UpsertPrice :exec
+INSERT INTO prices (zone, starts_at, resolution_minutes, price_eur_mwh, source)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (zone, resolution_minutes, starts_at) DO UPDATE
+SET price_eur_mwh = EXCLUDED.price_eur_mwh, source = EXCLUDED.source, updated_at = CURRENT_TIMESTAMP
+WHERE prices.price_eur_mwh IS DISTINCT FROM EXCLUDED.price_eur_mwh;
+
+-- name: ListPrices :many
+SELECT * FROM prices
+WHERE zone = sqlc.arg(zone) AND starts_at >= sqlc.arg(starts_from) AND starts_at < sqlc.arg(starts_before)
+ORDER BY starts_at, resolution_minutes;
+
+-- name: GetPricesUpdatedAt :one
+SELECT COALESCE(MAX(updated_at), 'epoch')::timestamptz AS updated_at FROM prices
+WHERE zone = $1;
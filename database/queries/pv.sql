@@ -0,0 +1,51 @@
+-- name: CreatePvSystem :one
+INSERT INTO pv_systems (id, site_id, name, peak_kw, tilt_deg, azimuth_deg)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetPvSystemById :one
+SELECT * FROM pv_systems
+WHERE id = $1;
+
+-- name: ListPvSystemsBySiteId :many
+SELECT * FROM pv_systems
+WHERE site_id = $1
+ORDER BY created_at;
+
+-- name: UpdatePvSystem :one
+UPDATE pv_systems
+SET name = $2, peak_kw = $3, tilt_deg = $4, azimuth_deg = $5, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePvSystem :exec
+DELETE FROM pv_systems
+WHERE id = $1;
+
+-- name: CreatePvReading :exec
+INSERT INTO pv_readings (device_id, measured_at, power_kw, energy_kwh)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (device_id, measured_at) DO NOTHING;
+
+-- name: GetLatestPvReadingByDeviceId :one
+SELECT * FROM pv_readings
+WHERE device_id = $1
+ORDER BY measured_at DESC
+LIMIT 1;
+
+-- name: ListPvReadingsBySiteId :many
+SELECT pv_readings.* FROM pv_readings
+JOIN devices ON devices.id = pv_readings.device_id
+WHERE devices.site_id = $1 AND pv_readings.measured_at >= sqlc.arg(measured_from) AND pv_readings.measured_at < sqlc.arg(measured_before)
+ORDER BY pv_readings.device_id, pv_readings.measured_at;
+
+-- name: UpsertPvForecast :exec
+INSERT INTO pv_forecasts (site_id, slot_start, modeled_kw)
+VALUES ($1, $2, $3)
+ON CONFLICT (site_id, slot_start) DO UPDATE
+SET modeled_kw = EXCLUDED.modeled_kw, updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListPvForecastsBySiteId :many
+SELECT * FROM pv_forecasts
+WHERE site_id = $1 AND slot_start >= sqlc.arg(slot_from) AND slot_start < sqlc.arg(slot_before)
+ORDER BY slot_start;
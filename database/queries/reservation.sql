@@ -0,0 +1,72 @@
+-- name: CreateReservation :one
+INSERT INTO reservations (id, site_id, connector_id, user_id, starts_at, ends_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetReservationById :one
+SELECT * FROM reservations
+WHERE id = $1;
+
+-- name: GetReservationByOcppId :one
+SELECT * FROM reservations
+WHERE ocpp_id = $1;
+
+-- name: ListReservationsBySiteId :many
+SELECT * FROM reservations
+WHERE site_id = $1 AND ends_at > sqlc.arg(ends_after) AND starts_at < sqlc.arg(starts_before)
+ORDER BY starts_at;
+
+-- name: ListReservationsBySiteIdAndUserId :many
+SELECT * FROM reservations
+WHERE site_id = $1 AND user_id = $2 AND ends_at > sqlc.arg(ends_after) AND starts_at < sqlc.arg(starts_before)
+ORDER BY starts_at;
+
+-- name: CountOverlappingReservations :one
+SELECT COUNT(*) FROM reservations
+WHERE connector_id = $1 AND status IN ('booked', 'held', 'fulfilled')
+  AND starts_at < sqlc.arg(ends_at) AND ends_at > sqlc.arg(starts_at);
+
+-- name: CountUpcomingReservationsByUserId :one
+SELECT COUNT(*) FROM reservations
+WHERE site_id = $1 AND user_id = $2 AND status IN ('booked', 'held') AND ends_at > $3;
+
+-- name: CountNoShowsByUserId :one
+SELECT COUNT(*) FROM reservations
+WHERE site_id = $1 AND user_id = $2 AND status = 'no_show' AND starts_at >= $3;
+
+-- name: ListOpenReservations :many
+SELECT reservations.*, connectors.device_id, connectors.connector_id AS connector_number FROM reservations
+JOIN connectors ON connectors.id = reservations.connector_id
+WHERE reservations.status IN ('booked', 'held') AND reservations.starts_at <= $1
+ORDER BY reservations.starts_at;
+
+-- name: HoldReservation :execrows
+UPDATE reservations
+SET status = 'held', charger_status = $2, held_at = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'booked';
+
+-- name: SetReservationChargerStatus :exec
+UPDATE reservations
+SET charger_status = $2, held_at = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: FulfilReservation :execrows
+UPDATE reservations
+SET status = 'fulfilled', session_id = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('booked', 'held');
+
+-- name: EndReservation :execrows
+UPDATE reservations
+SET status = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('booked', 'held');
+
+-- name: CancelReservation :one
+UPDATE reservations
+SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('booked', 'held')
+RETURNING *;
+
+-- name: CountCurrentReservationsOnConnector :one
+SELECT COUNT(*) FROM reservations
+WHERE connector_id = $1 AND user_id = $2 AND status IN ('booked', 'held')
+  AND starts_at <= sqlc.arg(starts_before) AND ends_at > sqlc.arg(ends_after);
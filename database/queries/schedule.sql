@@ -0,0 +1,10 @@
+-- name: CreateChargingSchedule :one
+INSERT INTO charging_schedules (id, vehicle_id, reason, horizon_start, horizon_end, initial_soc, final_soc, target_reached, total_cost, setpoints)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: GetLatestChargingSchedule :one
+SELECT * FROM charging_schedules
+WHERE vehicle_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
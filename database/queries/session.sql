@@ -0,0 +1,88 @@
+-- name: CreateChargingSession :one
+INSERT INTO charging_sessions (id, device_id, connector_id, transaction_id, owner_id, vehicle_id, id_tag, started_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (device_id, transaction_id) DO UPDATE
+    SET started_at = LEAST(charging_sessions.started_at, EXCLUDED.started_at),
+        id_tag     = COALESCE(NULLIF(EXCLUDED.id_tag, ''), charging_sessions.id_tag)
+RETURNING *;
+
+-- name: GetChargingSessionById :one
+SELECT * FROM charging_sessions
+WHERE id = $1;
+
+-- name: GetChargingSessionByTransactionId :one
+SELECT * FROM charging_sessions
+WHERE device_id = $1 AND transaction_id = $2;
+
+-- name: GetActiveChargingSessionByConnector :one
+SELECT * FROM charging_sessions
+WHERE device_id = $1 AND connector_id = $2 AND status = 'active'
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- name: StopChargingSession :one
+UPDATE charging_sessions
+SET status = 'completed', stopped_at = $2, stop_reason = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateChargingSessionCost :exec
+UPDATE charging_sessions
+SET imported_kwh = $2, exported_kwh = $3, energy_cost = $4, revenue = $5, breakdown = $6, site_tariff = $7, computed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateChargingSessionEmissions :exec
+UPDATE charging_sessions
+SET emissions_kg = $2, baseline_emissions_kg = $3
+WHERE id = $1;
+
+-- name: ListChargingSessionsByOwnerId :many
+SELECT * FROM charging_sessions
+WHERE owner_id = sqlc.arg(owner_id) AND started_at >= sqlc.arg(started_from) AND started_at < sqlc.arg(started_before)
+ORDER BY started_at;
+
+-- name: ListUncomputedChargingSessions :many
+SELECT * FROM charging_sessions
+WHERE status = 'completed' AND computed_at IS NULL AND stopped_at >= sqlc.arg(stopped_from)
+ORDER BY started_at
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListChargingSessionsWithoutEmissions :many
+SELECT * FROM charging_sessions
+WHERE status = 'completed' AND computed_at IS NOT NULL AND emissions_kg IS NULL AND stopped_at >= sqlc.arg(stopped_from)
+ORDER BY started_at
+LIMIT sqlc.arg(row_limit);
+
+-- name: NextTransactionId :one
+SELECT nextval('ocpp_transaction_id_seq')::integer AS transaction_id;
+
+-- name: CreateMeterValue :exec
+INSERT INTO meter_values (session_id, measured_at, import_wh, export_wh)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (session_id, measured_at) DO NOTHING;
+
+-- name: ListMeterValuesBySessionId :many
+SELECT * FROM meter_values
+WHERE session_id = $1
+ORDER BY measured_at;
+
+-- name: ListActiveChargingSessionsBySiteId :many
+SELECT charging_sessions.* FROM charging_sessions
+JOIN devices ON devices.id = charging_sessions.device_id
+WHERE devices.site_id = $1 AND charging_sessions.status = 'active'
+ORDER BY charging_sessions.started_at;
+
+-- name: ListActiveChargingSessions :many
+SELECT * FROM charging_sessions
+WHERE status = 'active' AND vehicle_id IS NOT NULL
+ORDER BY started_at;
+
+-- name: SumExportedKwhByVehicleId :one
+SELECT COALESCE(SUM(exported_kwh), 0)::DOUBLE PRECISION AS exported_kwh FROM charging_sessions
+WHERE vehicle_id = $1 AND started_at >= sqlc.arg(started_from);
+
+-- name: GetFirstChargingSessionOnConnector :one
+SELECT * FROM charging_sessions
+WHERE device_id = $1 AND connector_id = $2 AND started_at >= sqlc.arg(started_from) AND started_at < sqlc.arg(started_before)
+ORDER BY started_at
+LIMIT 1;
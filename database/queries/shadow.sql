@@ -0,0 +1,20 @@
+-- name: EnsureDeviceShadow :exec
+INSERT INTO device_shadows (device_id)
+VALUES ($1)
+ON CONFLICT (device_id) DO NOTHING;
+
+-- name: GetDeviceShadow :one
+SELECT * FROM device_shadows
+WHERE device_id = $1 LIMIT 1;
+
+-- name: UpdateDeviceShadowDesired :one
+UPDATE device_shadows
+SET desired = $2, version = version + 1, desired_updated_at = CURRENT_TIMESTAMP
+WHERE device_id = $1 AND version = $3
+RETURNING *;
+
+-- name: UpdateDeviceShadowReported :one
+UPDATE device_shadows
+SET reported = $2, version = version + 1, reported_updated_at = CURRENT_TIMESTAMP
+WHERE device_id = $1 AND version = $3
+RETURNING *;
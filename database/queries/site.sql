@@ -0,0 +1,74 @@
+-- name: CreateSite :one
+INSERT INTO sites (id, name, kind, phases, voltage_v, max_current_a, latitude, longitude, region, postcode, zone)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: GetSiteById :one
+SELECT * FROM sites
+WHERE id = $1;
+
+-- name: GetSiteByDeviceId :one
+SELECT sites.* FROM sites
+JOIN devices ON devices.site_id = sites.id
+WHERE devices.id = $1;
+
+-- name: GetSiteByVehicleId :one
+SELECT sites.* FROM sites
+JOIN devices ON devices.site_id = sites.id
+JOIN vehicles ON vehicles.charger_id = devices.id
+WHERE vehicles.id = $1;
+
+-- name: ListSitesByUserId :many
+SELECT sites.* FROM sites
+JOIN site_members ON site_members.site_id = sites.id
+WHERE site_members.user_id = $1
+ORDER BY sites.created_at;
+
+-- name: UpdateSite :one
+UPDATE sites
+SET name = $2, kind = $3, phases = $4, voltage_v = $5, max_current_a = $6, latitude = $7, longitude = $8, region = $9, postcode = $10, zone = $11, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: SetSiteTariff :exec
+UPDATE sites
+SET tariff_id = $2, tariff_assigned_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ListSitesByTariffId :many
+SELECT * FROM sites
+WHERE tariff_id = $1
+ORDER BY created_at;
+
+-- name: DeleteSite :exec
+DELETE FROM sites
+WHERE id = $1;
+
+-- name: UpsertSiteMember :one
+INSERT INTO site_members (site_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (site_id, user_id) DO UPDATE
+    SET role = EXCLUDED.role
+RETURNING *;
+
+-- name: GetSiteMember :one
+SELECT * FROM site_members
+WHERE site_id = $1 AND user_id = $2;
+
+-- name: ListSiteMembers :many
+SELECT site_members.*, users.username FROM site_members
+JOIN users ON users.id = site_members.user_id
+WHERE site_members.site_id = $1
+ORDER BY site_members.created_at;
+
+-- name: CountSiteOwners :one
+SELECT COUNT(*) FROM site_members
+WHERE site_id = $1 AND role = 'owner';
+
+-- name: DeleteSiteMember :exec
+DELETE FROM site_members
+WHERE site_id = $1 AND user_id = $2;
+
+-- name: ListSites :many
+SELECT * FROM sites
+ORDER BY created_at;
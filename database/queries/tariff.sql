@@ -0,0 +1,39 @@
+-- name: CreateTariff :one
+INSERT INTO tariffs (id, owner_id, name, kind, definition)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetTariffById :one
+SELECT * FROM tariffs
+WHERE id = $1;
+
+-- name: ListTariffsByOwnerId :many
+SELECT * FROM tariffs
+WHERE owner_id = $1
+ORDER BY created_at;
+
+-- name: UpdateTariff :one
+UPDATE tariffs
+SET name = $2, kind = $3, definition = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTariff :exec
+DELETE FROM tariffs
+WHERE id = $1;
+
+-- name: CreateTariffVersion :exec
+INSERT INTO tariff_versions (id, user_id, site_id, tariff_id, kind, definition, valid_from)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetTariffVersionAt :one
+SELECT * FROM tariff_versions
+WHERE user_id = $1 AND valid_from <= $2
+ORDER BY valid_from DESC
+LIMIT 1;
+
+-- name: GetSiteTariffVersionAt :one
+SELECT * FROM tariff_versions
+WHERE site_id = $1 AND valid_from <= $2
+ORDER BY valid_from DESC
+LIMIT 1;
@@ -4,4 +4,13 @@ VALUES ($1, $2);
 
 -- name: GetUserById :one
 SELECT * FROM users
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: SetUserTariff :exec
+UPDATE users
+SET tariff_id = $2, tariff_assigned_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetUserByUsername :one
+SELECT * FROM users
+WHERE username = $1;
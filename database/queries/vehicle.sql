@@ -0,0 +1,24 @@
+-- name: CreateVehicle :one
+INSERT INTO vehicles (id, owner_id, name, battery_capacity_kwh, max_charge_kw, max_discharge_kw, charger_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetVehicleById :one
+SELECT * FROM vehicles
+WHERE id = $1 LIMIT 1;
+
+-- name: ListVehiclesByOwnerId :many
+SELECT * FROM vehicles
+WHERE owner_id = $1
+ORDER BY created_at;
+
+-- name: ListVehiclesWithCharger :many
+SELECT * FROM vehicles
+WHERE charger_id IS NOT NULL
+ORDER BY created_at;
+
+-- name: GetVehicleByChargerId :one
+SELECT * FROM vehicles
+WHERE charger_id = $1
+ORDER BY created_at
+LIMIT 1;
@@ -0,0 +1,52 @@
+-- name: GetVenRegistration :one
+SELECT * FROM ven_registrations
+WHERE vtn_url = $1;
+
+-- name: UpsertVenRegistration :one
+INSERT INTO ven_registrations (vtn_url, vtn_id, ven_id, registration_id, poll_freq_seconds)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (vtn_url) DO UPDATE
+    SET vtn_id = EXCLUDED.vtn_id, ven_id = EXCLUDED.ven_id, registration_id = EXCLUDED.registration_id,
+        poll_freq_seconds = EXCLUDED.poll_freq_seconds, registered_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteVenRegistration :exec
+DELETE FROM ven_registrations
+WHERE vtn_url = $1;
+
+-- name: GetVenEvent :one
+SELECT * FROM ven_events
+WHERE vtn_url = $1 AND event_id = $2;
+
+-- name: ListVenEvents :many
+SELECT * FROM ven_events
+WHERE vtn_url = $1 AND ends_at > sqlc.arg(ends_after)
+ORDER BY starts_at;
+
+-- name: UpsertVenEvent :one
+INSERT INTO ven_events (vtn_url, event_id, modification_number, priority, market_context, status, starts_at, ends_at, test_event, opt_type)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (vtn_url, event_id) DO UPDATE
+    SET modification_number = EXCLUDED.modification_number, priority = EXCLUDED.priority,
+        market_context = EXCLUDED.market_context, status = EXCLUDED.status, starts_at = EXCLUDED.starts_at,
+        ends_at = EXCLUDED.ends_at, test_event = EXCLUDED.test_event, opt_type = EXCLUDED.opt_type,
+        received_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: CancelVenEvent :exec
+UPDATE ven_events
+SET status = 'cancelled', received_at = CURRENT_TIMESTAMP
+WHERE vtn_url = $1 AND event_id = $2;
+
+-- name: CreateFlexibilityTarget :exec
+INSERT INTO flexibility_targets (id, vtn_url, event_id, kind, starts_at, ends_at, value)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: DeleteFlexibilityTargetsByEvent :exec
+DELETE FROM flexibility_targets
+WHERE vtn_url = $1 AND event_id = $2;
+
+-- name: ListFlexibilityTargets :many
+SELECT * FROM flexibility_targets
+WHERE vtn_url = $1 AND ends_at > sqlc.arg(ends_after) AND starts_at < sqlc.arg(starts_before)
+ORDER BY starts_at, created_at;
@@ -0,0 +1,103 @@
+package battery
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	// TemperatureField is the key of the battery temperature in °C that
+	// chargers may report in their device shadow next to the state of charge.
+	TemperatureField = "batteryTemperature"
+	sampleInterval   = 5 * time.Minute
+	// replacementCostPerKwh is what a replacement pack costs per kWh of
+	// capacity, it prices the capacity lost to wear.
+	replacementCostPerKwh = 130.0
+	// endOfLifeLoss is the capacity loss at which a pack is worn out.
+	endOfLifeLoss = 0.2
+	// wearCycleDepth is the typical depth of the cycles discharging for the
+	// grid adds, the wear cost per kWh is estimated for cycles this deep.
+	wearCycleDepth = 0.3
+	// maxResidue bounds the open reversals kept per vehicle, the oldest are
+	// counted as half cycles beyond it.
+	maxResidue      = 64
+	minTemperatureC = -40.0
+	maxTemperatureC = 80.0
+)
+
+// Wear cost sources.
+const (
+	SourcePreference = "preference"
+	SourceEstimate   = "estimate"
+)
+
+// Health is the estimated state of a battery.
+type Health struct {
+	VehicleID    uuid.UUID
+	Tracked      bool
+	TrackedSince time.Time
+	SampledAt    time.Time
+	TemperatureC float64
+	// CycleLoss and CalendarLoss split the capacity loss in proportion to
+	// the stress of cycling and of time.
+	CapacityLoss         float64
+	CycleLoss            float64
+	CalendarLoss         float64
+	NominalCapacityKwh   float64
+	EstimatedCapacityKwh float64
+	FullCycles           float64
+	ChargedKwh           float64
+	DischargedKwh        float64
+	WearCostPerKwh       float64
+	WearCostSource       string
+}
+
+type HealthResponse struct {
+	VehicleID uuid.UUID `json:"vehicleId"`
+	// Tracked is false until the charger of the vehicle has reported its
+	// state of charge.
+	Tracked              bool       `json:"tracked"`
+	TrackedSince         *time.Time `json:"trackedSince,omitempty"`
+	LastSampleAt         *time.Time `json:"lastSampleAt,omitempty"`
+	StateOfHealthPct     float64    `json:"stateOfHealthPct"`
+	CapacityLossPct      float64    `json:"capacityLossPct"`
+	CycleAgeingPct       float64    `json:"cycleAgeingPct"`
+	CalendarAgeingPct    float64    `json:"calendarAgeingPct"`
+	NominalCapacityKwh   float64    `json:"nominalCapacityKwh"`
+	EstimatedCapacityKwh float64    `json:"estimatedCapacityKwh"`
+	// FullCycles is the depth of all counted cycles in full cycles.
+	FullCycles     float64  `json:"fullCycles"`
+	ChargedKwh     float64  `json:"chargedKwh"`
+	DischargedKwh  float64  `json:"dischargedKwh"`
+	TemperatureC   *float64 `json:"temperatureC,omitempty"`
+	WearCostPerKwh float64  `json:"wearCostPerKwh"`
+	// WearCostSource tells whether the wear cost comes from the vehicle
+	// preferences or is estimated from the health of the battery.
+	WearCostSource string `json:"wearCostSource"`
+}
+
+func NewHealthResponse(h *Health) *HealthResponse {
+	res := &HealthResponse{
+		VehicleID:            h.VehicleID,
+		Tracked:              h.Tracked,
+		StateOfHealthPct:     round((1 - h.CapacityLoss) * 100),
+		CapacityLossPct:      round(h.CapacityLoss * 100),
+		CycleAgeingPct:       round(h.CycleLoss * 100),
+		CalendarAgeingPct:    round(h.CalendarLoss * 100),
+		NominalCapacityKwh:   h.NominalCapacityKwh,
+		EstimatedCapacityKwh: round(h.EstimatedCapacityKwh),
+		FullCycles:           round(h.FullCycles),
+		ChargedKwh:           round(h.ChargedKwh),
+		DischargedKwh:        round(h.DischargedKwh),
+		WearCostPerKwh:       round(h.WearCostPerKwh),
+		WearCostSource:       h.WearCostSource,
+	}
+
+	if h.Tracked {
+		res.TrackedSince = &h.TrackedSince
+		res.LastSampleAt = &h.SampledAt
+		res.TemperatureC = &h.TemperatureC
+	}
+
+	return res
+}
@@ -0,0 +1,36 @@
+package battery
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	health, err := h.svc.Health(ctx, identityID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewHealthResponse(health))
+	return nil
+}
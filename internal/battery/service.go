@@ -0,0 +1,140 @@
+// Package battery estimates how much capacity the batteries of vehicles lose
+// to cycling and ageing. It samples the state of charge chargers report,
+// counts the cycles and prices the wear of discharging for the scheduler.
+package battery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/V2G-Minor-Fontys/server/pkg/degradation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"math"
+	"time"
+)
+
+type Service struct {
+	queries  *repository.Queries
+	vehicles *vehicle.Service
+}
+
+func NewService(queries *repository.Queries, vehicles *vehicle.Service) *Service {
+	return &Service{queries: queries, vehicles: vehicles}
+}
+
+// Health returns the estimated health of a vehicle battery of the caller.
+func (s *Service) Health(ctx context.Context, identityID, vehicleID uuid.UUID) (*Health, error) {
+	v, err := s.vehicles.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := s.vehicles.LoadPreferences(ctx, v.ID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve vehicle preferences", err)
+	}
+
+	h := &Health{
+		VehicleID:            v.ID,
+		TemperatureC:         degradation.ReferenceTemperatureC,
+		NominalCapacityKwh:   v.BatteryCapacityKwh,
+		EstimatedCapacityKwh: v.BatteryCapacityKwh,
+	}
+
+	row, err := s.queries.GetBatteryHealthByVehicleId(ctx, v.ID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve battery health", err)
+	default:
+		cycleStress, calendarStress, fullCycles, err := estimate(&row, time.Now())
+		if err != nil {
+			return nil, httpx.InternalErr(ctx, "Stored battery health is corrupt", err)
+		}
+
+		h.Tracked = true
+		h.TrackedSince = row.TrackedSince
+		h.SampledAt = row.SampledAt
+		h.TemperatureC = row.TemperatureC
+		h.CapacityLoss = degradation.CapacityLoss(cycleStress + calendarStress)
+		if stress := cycleStress + calendarStress; stress > 0 {
+			h.CycleLoss = h.CapacityLoss * cycleStress / stress
+			h.CalendarLoss = h.CapacityLoss * calendarStress / stress
+		}
+		h.EstimatedCapacityKwh = v.BatteryCapacityKwh * (1 - h.CapacityLoss)
+		h.FullCycles = fullCycles
+		h.ChargedKwh = row.ChargedKwh
+		h.DischargedKwh = row.DischargedKwh
+	}
+
+	h.WearCostPerKwh, h.WearCostSource, err = s.wearCost(ctx, v, prefs)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to estimate wear cost", err)
+	}
+
+	return h, nil
+}
+
+// WearCostPerKwh returns what discharging a kWh from the vehicle costs in
+// battery wear, as set in its preferences or else estimated from its health.
+func (s *Service) WearCostPerKwh(ctx context.Context, v *repository.Vehicle, prefs *vehicle.Preferences) (float64, error) {
+	cost, _, err := s.wearCost(ctx, v, prefs)
+	return cost, err
+}
+
+// wearCost prices the capacity an extra cycle of typical depth costs at the
+// current stress of the battery. A new battery wears faster per cycle than
+// one whose interphase has formed, so its estimate is higher.
+func (s *Service) wearCost(ctx context.Context, v *repository.Vehicle, prefs *vehicle.Preferences) (float64, string, error) {
+	if prefs.WearCostPerKwh != nil {
+		return *prefs.WearCostPerKwh, SourcePreference, nil
+	}
+
+	stress := 0.0
+	row, err := s.queries.GetBatteryHealthByVehicleId(ctx, v.ID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return 0, "", err
+	default:
+		stress = row.CycleStress + row.CalendarStress
+	}
+
+	lossPerKwh := degradation.WearPerKwh(v.BatteryCapacityKwh, wearCycleDepth, stress)
+	return roundPrice(lossPerKwh / endOfLifeLoss * v.BatteryCapacityKwh * replacementCostPerKwh), SourceEstimate, nil
+}
+
+// estimate adds the ageing since the last sample and the open cycles of the
+// residue to the stored stress.
+func estimate(h *repository.BatteryHealth, now time.Time) (float64, float64, float64, error) {
+	var counter degradation.Counter
+	if err := json.Unmarshal(h.Residue, &counter.Residue); err != nil {
+		return 0, 0, 0, err
+	}
+
+	cycleStress, fullCycles := h.CycleStress, h.FullCycles
+	for _, c := range counter.HalfCycles() {
+		cycleStress += degradation.CycleStress(c, h.TemperatureC)
+		fullCycles += c.Depth * c.Count
+	}
+
+	calendarStress := h.CalendarStress
+	if now.After(h.SampledAt) {
+		calendarStress += degradation.CalendarStress(now.Sub(h.SampledAt), h.LastSoc/100, h.TemperatureC)
+	}
+
+	return cycleStress, calendarStress, fullCycles, nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// roundPrice rounds a cost per kWh to the precision of energy prices.
+func roundPrice(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
@@ -0,0 +1,163 @@
+package battery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/pkg/degradation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Run samples the batteries of all vehicles with a charger until the context
+// is done.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.step(ctx, now.UTC())
+		}
+	}
+}
+
+func (s *Service) step(ctx context.Context, now time.Time) {
+	vehicles, err := s.queries.ListVehiclesWithCharger(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list vehicles for battery tracking", "error", err)
+		return
+	}
+
+	for i := range vehicles {
+		if err := s.sample(ctx, &vehicles[i], now); err != nil {
+			slog.WarnContext(ctx, "Failed to track battery", "vehicle.id", vehicles[i].ID, "error", err)
+		}
+	}
+}
+
+// sample adds the state of charge the charger of the vehicle reports to its
+// battery health. Time since the previous sample ages the battery at the
+// average state of charge, and cycles the new sample closes are counted.
+func (s *Service) sample(ctx context.Context, v *repository.Vehicle, now time.Time) error {
+	soc, reportedC, ok, err := s.reported(ctx, uuid.UUID(v.ChargerID.Bytes))
+	if err != nil || !ok {
+		return err
+	}
+
+	h, err := s.queries.GetBatteryHealthByVehicleId(ctx, v.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		residue, err := json.Marshal([]float64{soc / 100})
+		if err != nil {
+			return err
+		}
+
+		_, err = s.queries.UpsertBatteryHealth(ctx, repository.UpsertBatteryHealthParams{
+			VehicleID:    v.ID,
+			LastSoc:      soc,
+			TemperatureC: orDefault(reportedC, degradation.ReferenceTemperatureC),
+			Residue:      residue,
+			SampledAt:    now,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !now.After(h.SampledAt) {
+		return nil
+	}
+
+	counter := degradation.Counter{}
+	if err := json.Unmarshal(h.Residue, &counter.Residue); err != nil {
+		return err
+	}
+
+	temperatureC := orDefault(reportedC, h.TemperatureC)
+	params := repository.UpsertBatteryHealthParams{
+		VehicleID:      v.ID,
+		LastSoc:        soc,
+		TemperatureC:   temperatureC,
+		CycleStress:    h.CycleStress,
+		CalendarStress: h.CalendarStress,
+		FullCycles:     h.FullCycles,
+		ChargedKwh:     h.ChargedKwh,
+		DischargedKwh:  h.DischargedKwh,
+		SampledAt:      now,
+	}
+
+	params.CalendarStress += degradation.CalendarStress(now.Sub(h.SampledAt), (h.LastSoc+soc)/200, (h.TemperatureC+temperatureC)/2)
+
+	cycles := counter.Add(soc / 100)
+	if len(counter.Residue) > maxResidue {
+		cycles = append(cycles, degradation.Cycle{
+			Depth:   math.Abs(counter.Residue[1] - counter.Residue[0]),
+			MeanSoc: (counter.Residue[0] + counter.Residue[1]) / 2,
+			Count:   0.5,
+		})
+		counter.Residue = counter.Residue[1:]
+	}
+	for _, c := range cycles {
+		params.CycleStress += degradation.CycleStress(c, temperatureC)
+		params.FullCycles += c.Depth * c.Count
+	}
+
+	if deltaKwh := (soc - h.LastSoc) / 100 * v.BatteryCapacityKwh; deltaKwh > 0 {
+		params.ChargedKwh += deltaKwh
+	} else {
+		params.DischargedKwh -= deltaKwh
+	}
+
+	if params.Residue, err = json.Marshal(counter.Residue); err != nil {
+		return err
+	}
+
+	_, err = s.queries.UpsertBatteryHealth(ctx, params)
+	return err
+}
+
+// reported returns the state of charge and the battery temperature, when
+// known, in the shadow of a charger. ok is false when it does not report a
+// state of charge.
+func (s *Service) reported(ctx context.Context, chargerID uuid.UUID) (float64, *float64, bool, error) {
+	sh, err := s.queries.GetDeviceShadow(ctx, chargerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil, false, nil
+	}
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	var reported map[string]any
+	if err := json.Unmarshal(sh.Reported, &reported); err != nil {
+		return 0, nil, false, err
+	}
+
+	soc, ok := reported[scheduling.SocField].(float64)
+	if !ok || soc < 0 || soc > 100 {
+		return 0, nil, false, nil
+	}
+
+	celsius, ok := reported[TemperatureField].(float64)
+	if !ok || celsius < minTemperatureC || celsius > maxTemperatureC {
+		return soc, nil, true, nil
+	}
+
+	return soc, &celsius, true, nil
+}
+
+func orDefault(v *float64, fallback float64) float64 {
+	if v == nil {
+		return fallback
+	}
+
+	return *v
+}
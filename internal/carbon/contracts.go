@@ -0,0 +1,104 @@
+package carbon
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRange = 48 * time.Hour
+	maxRange     = 31 * 24 * time.Hour
+	maxZoneLen   = 20
+)
+
+type ListIntensitiesRequest struct {
+	Zone string
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseListIntensitiesRequest reads the zone, from and to query parameters.
+// From defaults to the start of the current UTC day and to two days later.
+func ParseListIntensitiesRequest(q url.Values, now time.Time) ListIntensitiesRequest {
+	req := ListIntensitiesRequest{
+		Zone:      strings.ToUpper(q.Get("zone")),
+		From:      now.UTC().Truncate(24 * time.Hour),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	req.To = req.From.Add(defaultRange)
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	return req
+}
+
+func (r *ListIntensitiesRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if r.Zone == "" {
+		errs.Add("zone", "Zone is required")
+	} else if len(r.Zone) > maxZoneLen {
+		errs.Add("zone", "Zone cannot be longer than 20 characters")
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxRange {
+			errs.Add("to", "The requested range cannot exceed 31 days")
+		}
+	}
+
+	return errs
+}
+
+type IntensityPointResponse struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Intensity float64   `json:"intensity"`
+}
+
+type IntensitiesResponse struct {
+	Zone        string                    `json:"zone"`
+	Unit        string                    `json:"unit"`
+	Intensities []*IntensityPointResponse `json:"intensities"`
+}
+
+func NewIntensitiesResponse(zone string, points []Point) *IntensitiesResponse {
+	res := &IntensitiesResponse{
+		Zone:        zone,
+		Unit:        "gCO2eq/kWh",
+		Intensities: make([]*IntensityPointResponse, 0, len(points)),
+	}
+
+	for _, p := range points {
+		res.Intensities = append(res.Intensities, &IntensityPointResponse{
+			Start:     p.Start,
+			End:       p.End(),
+			Intensity: p.GramsPerKwh,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,90 @@
+package carbon
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultCSVResolution = time.Hour
+
+// ParseCSV reads carbon intensities from a CSV file with a header row. The
+// start column holds RFC 3339 timestamps and g_co2_kwh the intensity. The
+// optional resolution_minutes column defaults to 60, and when a zone column
+// is present only the rows of the requested zone are returned.
+func ParseCSV(r io.Reader, zone string) ([]Point, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	startCol, ok := cols["start"]
+	if !ok {
+		return nil, errors.New("CSV is missing the start column")
+	}
+	intensityCol, ok := cols["g_co2_kwh"]
+	if !ok {
+		return nil, errors.New("CSV is missing the g_co2_kwh column")
+	}
+	resolutionCol, hasResolution := cols["resolution_minutes"]
+	zoneCol, hasZone := cols["zone"]
+
+	var points []Point
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if hasZone && !strings.EqualFold(record[zoneCol], zone) {
+			continue
+		}
+
+		start, err := time.Parse(time.RFC3339, record[startCol])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: start must be an RFC 3339 timestamp", line)
+		}
+
+		grams, err := strconv.ParseFloat(record[intensityCol], 64)
+		if err != nil || grams < 0 {
+			return nil, fmt.Errorf("line %d: g_co2_kwh must be a non-negative number", line)
+		}
+
+		resolution := defaultCSVResolution
+		if hasResolution && record[resolutionCol] != "" {
+			minutes, err := strconv.Atoi(record[resolutionCol])
+			if err != nil || minutes <= 0 {
+				return nil, fmt.Errorf("line %d: resolution_minutes must be a positive integer", line)
+			}
+			resolution = time.Duration(minutes) * time.Minute
+		}
+
+		points = append(points, Point{
+			Zone:        zone,
+			Start:       start.UTC(),
+			Resolution:  resolution,
+			GramsPerKwh: grams,
+		})
+	}
+
+	if len(points) == 0 {
+		return nil, ErrNoData
+	}
+
+	return points, nil
+}
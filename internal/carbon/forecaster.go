@@ -0,0 +1,54 @@
+package carbon
+
+import (
+	"context"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Forecaster offers the carbon intensity of the zone a vehicle charges in to
+// the scheduler.
+type Forecaster struct {
+	svc *Service
+}
+
+func NewForecaster(svc *Service) *Forecaster {
+	return &Forecaster{svc: svc}
+}
+
+func (f *Forecaster) Forecast(ctx context.Context, vehicleID uuid.UUID, from, to time.Time) ([]scheduling.CarbonPoint, error) {
+	zone, err := f.svc.ZoneOfVehicle(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a day early so the interval running at from is included.
+	points, err := f.svc.Range(ctx, zone, from.Add(-24*time.Hour), to)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]scheduling.CarbonPoint, 0, len(points))
+	for _, p := range points {
+		if !p.End().After(from) {
+			continue
+		}
+		res = append(res, scheduling.CarbonPoint{Start: p.Start, GramsPerKwh: p.GramsPerKwh})
+	}
+
+	if len(res) == 0 {
+		return nil, scheduling.ErrNoCarbonForecast
+	}
+
+	return res, nil
+}
+
+func (f *Forecaster) UpdatedAt(ctx context.Context, vehicleID uuid.UUID) (time.Time, error) {
+	zone, err := f.svc.ZoneOfVehicle(ctx, vehicleID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return f.svc.UpdatedAt(ctx, zone)
+}
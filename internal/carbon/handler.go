@@ -0,0 +1,27 @@
+package carbon
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	req := ParseListIntensitiesRequest(r.URL.Query(), time.Now())
+
+	points, err := h.svc.List(r.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewIntensitiesResponse(req.Zone, points))
+	return nil
+}
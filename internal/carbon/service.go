@@ -0,0 +1,231 @@
+// Package carbon imports the carbon intensity of grid electricity per zone,
+// measured and forecast, and serves it to the API, the scheduler and the
+// emission reports of charging sessions.
+package carbon
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const (
+	fetchInterval = time.Hour
+	// fetchHistory re-imports the previous day, so forecasts of the hours
+	// that passed are replaced by the measured intensity.
+	fetchHistory = 24 * time.Hour
+	fetchHorizon = 72 * time.Hour
+)
+
+type Service struct {
+	db      *pgxpool.Pool
+	queries *repository.Queries
+	source  Source
+	zones   []string
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, source Source, zones []string) *Service {
+	return &Service{db: db, queries: queries, source: source, zones: zones}
+}
+
+// DefaultZone is the zone used for users without a more specific one.
+func (s *Service) DefaultZone() string {
+	if len(s.zones) == 0 {
+		return "NL"
+	}
+
+	return s.zones[0]
+}
+
+// ZoneOfDevice returns the zone of the site the device is attached to, the
+// default zone when it is on no site or the site has no zone.
+func (s *Service) ZoneOfDevice(ctx context.Context, deviceID uuid.UUID) (string, error) {
+	site, err := s.queries.GetSiteByDeviceId(ctx, deviceID)
+	return s.zoneOf(&site, err)
+}
+
+// ZoneOfVehicle returns the zone of the site of the charger the vehicle is
+// assigned to, with the same fallback as ZoneOfDevice.
+func (s *Service) ZoneOfVehicle(ctx context.Context, vehicleID uuid.UUID) (string, error) {
+	site, err := s.queries.GetSiteByVehicleId(ctx, vehicleID)
+	return s.zoneOf(&site, err)
+}
+
+func (s *Service) zoneOf(site *repository.Site, err error) (string, error) {
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return s.DefaultZone(), nil
+		}
+
+		return "", err
+	}
+
+	if site.Zone == "" {
+		return s.DefaultZone(), nil
+	}
+
+	return site.Zone, nil
+}
+
+func (s *Service) List(ctx context.Context, req ListIntensitiesRequest) ([]Point, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	points, err := s.Range(ctx, req.Zone, req.From, req.To)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve carbon intensities", err)
+	}
+
+	return points, nil
+}
+
+// Range returns the intensities of a zone starting in [from, to). When a
+// zone is known in several resolutions the finest one is preferred.
+func (s *Service) Range(ctx context.Context, zone string, from, to time.Time) ([]Point, error) {
+	rows, err := s.queries.ListCarbonIntensities(ctx, repository.ListCarbonIntensitiesParams{
+		Zone:         zone,
+		StartsFrom:   from,
+		StartsBefore: to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(rows))
+	var coveredUntil time.Time
+	for _, row := range rows {
+		p := Point{
+			Zone:        row.Zone,
+			Start:       row.StartsAt.UTC(),
+			Resolution:  time.Duration(row.ResolutionMinutes) * time.Minute,
+			GramsPerKwh: row.GramsCo2Kwh,
+		}
+		if p.Start.Before(coveredUntil) {
+			continue
+		}
+
+		points = append(points, p)
+		coveredUntil = p.End()
+	}
+
+	return points, nil
+}
+
+func (s *Service) UpdatedAt(ctx context.Context, zone string) (time.Time, error) {
+	return s.queries.GetCarbonIntensitiesUpdatedAt(ctx, zone)
+}
+
+// Import stores the points in a single transaction, unchanged intensities
+// keep their update time so that schedules are only refreshed on real
+// changes.
+func (s *Service) Import(ctx context.Context, points []Point, source string) error {
+	sort.Slice(points, func(i, j int) bool { return points[i].Start.Before(points[j].Start) })
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	for _, p := range points {
+		if err := qtx.UpsertCarbonIntensity(ctx, repository.UpsertCarbonIntensityParams{
+			Zone:              p.Zone,
+			StartsAt:          p.Start,
+			ResolutionMinutes: int32(p.Resolution / time.Minute),
+			GramsCo2Kwh:       p.GramsPerKwh,
+			Source:            source,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Run fetches intensities for all configured zones right away and then every
+// hour.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(fetchInterval)
+	defer ticker.Stop()
+
+	for {
+		s.fetch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) fetch(ctx context.Context) {
+	from := time.Now().UTC().Truncate(24 * time.Hour).Add(-fetchHistory)
+	to := from.Add(fetchHistory + fetchHorizon)
+
+	for _, zone := range s.zones {
+		points, err := s.source.Fetch(ctx, zone, from, to)
+		if errors.Is(err, ErrNoData) {
+			slog.DebugContext(ctx, "No carbon intensities available", "zone", zone, "error", err)
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to fetch carbon intensities", "zone", zone, "source", s.source.Name(), "error", err)
+			continue
+		}
+
+		if err := s.Import(ctx, points, s.source.Name()); err != nil {
+			slog.ErrorContext(ctx, "Failed to import carbon intensities", "zone", zone, "error", err)
+			continue
+		}
+
+		slog.InfoContext(ctx, "Imported carbon intensities", "zone", zone, "count", len(points))
+	}
+}
+
+// Average returns the time weighted mean intensity of [from, to). It is
+// false when the points do not cover the whole range.
+func Average(points []Point, from, to time.Time) (float64, bool) {
+	total := to.Sub(from).Seconds()
+	if total <= 0 {
+		return 0, false
+	}
+
+	weighted, covered := 0.0, 0.0
+	for _, p := range points {
+		start, end := p.Start, p.End()
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		share := end.Sub(start).Seconds()
+		weighted += p.GramsPerKwh * share
+		covered += share
+	}
+
+	if covered < total-1e-6 {
+		return 0, false
+	}
+
+	return weighted / covered, true
+}
@@ -0,0 +1,211 @@
+package carbon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	SourceElectricityMaps = "electricitymaps"
+	SourceFile            = "file"
+
+	electricityMapsURL = "https://api.electricitymap.org/v3"
+	httpTimeout        = 30 * time.Second
+)
+
+var ErrNoData = errors.New("no carbon intensities available")
+
+// Point is the average carbon intensity of the electricity consumed from the
+// grid of a zone during one interval, in grams CO2 equivalent per kWh.
+type Point struct {
+	Zone        string
+	Start       time.Time
+	Resolution  time.Duration
+	GramsPerKwh float64
+}
+
+func (p Point) End() time.Time {
+	return p.Start.Add(p.Resolution)
+}
+
+// Source supplies carbon intensities of a zone for [from, to), measured for
+// the past and forecast for the future.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, zone string, from, to time.Time) ([]Point, error)
+}
+
+func NewSource(cfg *config.Carbon) (Source, error) {
+	switch cfg.Source {
+	case SourceElectricityMaps:
+		if cfg.Token == "" {
+			return nil, errors.New("an Electricity Maps token is required for the electricitymaps carbon source")
+		}
+		return NewHTTPSource(electricityMapsURL, cfg.Token), nil
+	case SourceFile:
+		return NewFileSource(cfg.Directory), nil
+	}
+
+	return nil, fmt.Errorf("unknown carbon source %q", cfg.Source)
+}
+
+// HTTPSource downloads the hourly carbon intensity of the last day and the
+// forecast of the coming days from the Electricity Maps API.
+type HTTPSource struct {
+	client  *http.Client
+	baseURL string
+	token   string
+}
+
+func NewHTTPSource(baseURL, token string) *HTTPSource {
+	return &HTTPSource{
+		client:  &http.Client{Timeout: httpTimeout},
+		baseURL: baseURL,
+		token:   token,
+	}
+}
+
+func (s *HTTPSource) Name() string {
+	return SourceElectricityMaps
+}
+
+type electricityMapsPoint struct {
+	CarbonIntensity *float64  `json:"carbonIntensity"`
+	Datetime        time.Time `json:"datetime"`
+}
+
+type electricityMapsResponse struct {
+	History  []electricityMapsPoint `json:"history"`
+	Forecast []electricityMapsPoint `json:"forecast"`
+}
+
+func (s *HTTPSource) Fetch(ctx context.Context, zone string, from, to time.Time) ([]Point, error) {
+	var points []Point
+	for _, path := range []string{"/carbon-intensity/history", "/carbon-intensity/forecast"} {
+		body, err := s.get(ctx, path, zone)
+		if err != nil {
+			return nil, err
+		}
+
+		// Measured values come first, so they win over the forecast of the
+		// same hour.
+		for _, p := range append(body.History, body.Forecast...) {
+			if p.CarbonIntensity == nil || *p.CarbonIntensity < 0 {
+				continue
+			}
+			points = append(points, Point{
+				Zone:        zone,
+				Start:       p.Datetime.UTC(),
+				Resolution:  time.Hour,
+				GramsPerKwh: *p.CarbonIntensity,
+			})
+		}
+	}
+
+	points = within(dedupe(points), from, to)
+	if len(points) == 0 {
+		return nil, ErrNoData
+	}
+
+	return points, nil
+}
+
+func (s *HTTPSource) get(ctx context.Context, path, zone string) (*electricityMapsResponse, error) {
+	q := url.Values{}
+	q.Set("zone", zone)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("auth-token", s.token)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Electricity Maps responded with status %d", res.StatusCode)
+	}
+
+	var body electricityMapsResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("could not decode Electricity Maps response: %w", err)
+	}
+
+	return &body, nil
+}
+
+// FileSource reads carbon intensities from {zone}.csv in a directory, for
+// development and tests without network access.
+type FileSource struct {
+	dir string
+}
+
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{dir: dir}
+}
+
+func (s *FileSource) Name() string {
+	return SourceFile
+}
+
+func (s *FileSource) Fetch(_ context.Context, zone string, from, to time.Time) ([]Point, error) {
+	f, err := os.Open(filepath.Join(s.dir, zone+".csv"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoData
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	points, err := ParseCSV(f, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	return within(points, from, to), nil
+}
+
+// dedupe keeps the first point of every start and resolution.
+func dedupe(points []Point) []Point {
+	type key struct {
+		start      time.Time
+		resolution time.Duration
+	}
+
+	seen := make(map[key]bool, len(points))
+	res := make([]Point, 0, len(points))
+	for _, p := range points {
+		k := key{p.Start, p.Resolution}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		res = append(res, p)
+	}
+
+	return res
+}
+
+func within(points []Point, from, to time.Time) []Point {
+	res := make([]Point, 0, len(points))
+	for _, p := range points {
+		if p.End().After(from) && p.Start.Before(to) {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
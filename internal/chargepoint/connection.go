@@ -0,0 +1,132 @@
+package chargepoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"sync"
+	"time"
+)
+
+const (
+	callTimeout  = 30 * time.Second
+	writeTimeout = 10 * time.Second
+	pongTimeout  = 90 * time.Second
+	pingInterval = 30 * time.Second
+)
+
+var ErrConnectionClosed = errors.New("charge point connection closed")
+
+// Connection is a single websocket session with a charge point. Calls to the
+// charge point are correlated with their results by message id.
+type Connection struct {
+	DeviceID uuid.UUID
+	Identity string
+	Version  ocpp.Version
+
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan *ocpp.Message
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newConnection(deviceID uuid.UUID, identity string, ws *websocket.Conn) *Connection {
+	return &Connection{
+		DeviceID: deviceID,
+		Identity: identity,
+		Version:  ocpp.Version(ws.Subprotocol()),
+		ws:       ws,
+		pending:  make(map[string]chan *ocpp.Message),
+		closed:   make(chan struct{}),
+	}
+}
+
+// Call sends an action to the charge point and decodes its result into res.
+// A call error frame is returned as *ocpp.CallError.
+func (c *Connection) Call(ctx context.Context, action string, req, res any) error {
+	id := uuid.NewString()
+	frame, err := ocpp.EncodeCall(id, action, req)
+	if err != nil {
+		return err
+	}
+
+	reply := make(chan *ocpp.Message, 1)
+	c.mu.Lock()
+	c.pending[id] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(frame); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	select {
+	case msg := <-reply:
+		if msg.Type == ocpp.TypeCallError {
+			return &ocpp.CallError{Code: msg.ErrorCode, Description: msg.ErrorDescription}
+		}
+		if res == nil {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Payload, res); err != nil {
+			return fmt.Errorf("could not decode %s result: %w", action, err)
+		}
+		return nil
+	case <-c.closed:
+		return ErrConnectionClosed
+	case <-ctx.Done():
+		return fmt.Errorf("%s was not answered: %w", action, ctx.Err())
+	}
+}
+
+func (c *Connection) write(frame []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_ = c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.ws.WriteMessage(websocket.TextMessage, frame)
+}
+
+func (c *Connection) ping() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+}
+
+// resolve hands a result or error frame to the call waiting for it. The call
+// is removed right away, so a duplicate answer finds nobody waiting instead of
+// blocking the read loop on the full reply channel.
+func (c *Connection) resolve(msg *ocpp.Message) bool {
+	c.mu.Lock()
+	reply, ok := c.pending[msg.ID]
+	delete(c.pending, msg.ID)
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	reply <- msg
+	return true
+}
+
+func (c *Connection) Close() {
+	c.once.Do(func() {
+		close(c.closed)
+		_ = c.ws.Close()
+	})
+}
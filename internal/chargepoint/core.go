@@ -0,0 +1,110 @@
+package chargepoint
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/cache"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/google/uuid"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const heartbeatIntervalSeconds = 300
+
+// Decode unmarshals a call payload, reporting a FormationViolation to the
+// charge point when it does not match the expected shape.
+func Decode(payload json.RawMessage, v any) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return &ocpp.CallError{Code: ocpp.ErrorFormationViolation, Description: err.Error()}
+	}
+
+	return nil
+}
+
+// IdTag is the id tag calls made on behalf of a user carry, such as their
+// reservations. OCPP 1.6 limits id tags to 20 characters, so it is the first
+// 10 bytes of the user id in hex.
+func IdTag(userID uuid.UUID) string {
+	return strings.ToUpper(hex.EncodeToString(userID[:10]))
+}
+
+// ConnectorsCacheKey is the key of the connector statuses of a charger.
+func ConnectorsCacheKey(deviceID uuid.UUID) string {
+	return "connectors:" + deviceID.String()
+}
+
+// Connectors returns the last reported status of every connector of the
+// charger. They are read on every profile the server sends, so they are
+// cached until the charger reports a new status.
+func (s *Server) Connectors(ctx context.Context, deviceID uuid.UUID) ([]repository.Connector, error) {
+	return cache.GetOrLoad(ctx, s.cache, ConnectorsCacheKey(deviceID), func(ctx context.Context) ([]repository.Connector, error) {
+		return s.queries.ListConnectorsByDeviceId(ctx, deviceID)
+	})
+}
+
+func (s *Server) handleBootNotification(ctx context.Context, c *Connection, payload json.RawMessage) (any, error) {
+	var model, vendor string
+	if c.Version == ocpp.V201 {
+		var req ocpp.BootNotificationRequest201
+		if err := Decode(payload, &req); err != nil {
+			return nil, err
+		}
+		model, vendor = req.ChargingStation.Model, req.ChargingStation.VendorName
+	} else {
+		var req ocpp.BootNotificationRequest16
+		if err := Decode(payload, &req); err != nil {
+			return nil, err
+		}
+		model, vendor = req.ChargePointModel, req.ChargePointVendor
+	}
+
+	slog.InfoContext(ctx, "Charge point booted", "identity", c.Identity, "vendor", vendor, "model", model)
+	return ocpp.BootNotificationResponse{
+		CurrentTime: time.Now().UTC(),
+		Interval:    heartbeatIntervalSeconds,
+		Status:      ocpp.RegistrationAccepted,
+	}, nil
+}
+
+func (s *Server) handleHeartbeat(context.Context, *Connection, json.RawMessage) (any, error) {
+	return ocpp.HeartbeatResponse{CurrentTime: time.Now().UTC()}, nil
+}
+
+// handleStatusNotification stores the status per connector. For 2.0.1 the EVSE
+// is tracked as the connector, since profiles are addressed per EVSE.
+func (s *Server) handleStatusNotification(ctx context.Context, c *Connection, payload json.RawMessage) (any, error) {
+	var connectorID int
+	var status string
+	if c.Version == ocpp.V201 {
+		var req ocpp.StatusNotificationRequest201
+		if err := Decode(payload, &req); err != nil {
+			return nil, err
+		}
+		connectorID, status = req.EvseID, req.ConnectorStatus
+	} else {
+		var req ocpp.StatusNotificationRequest16
+		if err := Decode(payload, &req); err != nil {
+			return nil, err
+		}
+		connectorID, status = req.ConnectorID, req.Status
+	}
+
+	// Connector 0 reports the charge point as a whole.
+	if connectorID > 0 {
+		if _, err := s.queries.UpsertConnectorStatus(ctx, repository.UpsertConnectorStatusParams{
+			ID:          uuid.New(),
+			DeviceID:    c.DeviceID,
+			ConnectorID: int32(connectorID),
+			Status:      status,
+		}); err != nil {
+			return nil, err
+		}
+		s.cache.Invalidate(ctx, ConnectorsCacheKey(c.DeviceID))
+	}
+
+	return struct{}{}, nil
+}
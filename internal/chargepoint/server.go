@@ -0,0 +1,263 @@
+// Package chargepoint is the OCPP central system. Chargers connect over a
+// websocket at /ocpp/{identity}, where identity is the serial number they were
+// registered with, and negotiate OCPP 1.6 or 2.0.1 as subprotocol. They
+// authenticate with HTTP Basic auth, the identity as username and the password
+// they were given on registration, as in OCPP security profile 1, or profile
+// 2 when TLS is terminated in front of the server.
+package chargepoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/cache"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrNotConnected = errors.New("charge point is not connected")
+
+// CallHandler answers a call initiated by a charge point. Returning an
+// *ocpp.CallError sends that error code back, any other error is reported as
+// an internal error.
+type CallHandler func(ctx context.Context, c *Connection, payload json.RawMessage) (any, error)
+
+type Server struct {
+	queries  *repository.Queries
+	cache    *cache.Loader
+	upgrader websocket.Upgrader
+
+	mu       sync.RWMutex
+	conns    map[uuid.UUID]*Connection
+	handlers map[string]CallHandler
+}
+
+func NewServer(queries *repository.Queries, c *cache.Loader) *Server {
+	s := &Server{
+		queries: queries,
+		cache:   c,
+		upgrader: websocket.Upgrader{
+			Subprotocols: ocpp.SupportedVersions,
+		},
+		conns:    make(map[uuid.UUID]*Connection),
+		handlers: make(map[string]CallHandler),
+	}
+
+	s.Handle(ocpp.ActionBootNotification, s.handleBootNotification)
+	s.Handle(ocpp.ActionHeartbeat, s.handleHeartbeat)
+	s.Handle(ocpp.ActionStatusNotification, s.handleStatusNotification)
+	return s
+}
+
+// Handle registers the handler for calls of the given action, replacing any
+// previous one.
+func (s *Server) Handle(action string, h CallHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[action] = h
+}
+
+// Connection returns the live connection of a charger.
+func (s *Server) Connection(deviceID uuid.UUID) (*Connection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.conns[deviceID]
+	if !ok {
+		return nil, ErrNotConnected
+	}
+
+	return c, nil
+}
+
+// Disconnect closes the live connection of a charger, if any, for example
+// after its password changed.
+func (s *Server) Disconnect(deviceID uuid.UUID) {
+	if c, err := s.Connection(deviceID); err == nil {
+		s.unregister(c)
+	}
+}
+
+func (s *Server) ConnectHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identity := chi.URLParam(r, "identity")
+
+	d, err := s.authenticate(w, r, identity)
+	if err != nil {
+		return err
+	}
+
+	offered := websocket.Subprotocols(r)
+	if !slices.ContainsFunc(ocpp.SupportedVersions, func(v string) bool { return slices.Contains(offered, v) }) {
+		return httpx.BadRequest(ctx, "Sec-WebSocket-Protocol must offer ocpp2.0.1 or ocpp1.6")
+	}
+
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already wrote an error response.
+		slog.WarnContext(ctx, "Charge point websocket upgrade failed", "identity", identity, "error", err)
+		return nil
+	}
+
+	// Only an authenticated charger replaces the connection of the charger.
+	c := newConnection(d.ID, identity, ws)
+	s.register(c)
+	defer s.unregister(c)
+
+	slog.InfoContext(ctx, "Charge point connected", "identity", identity, "version", c.Version)
+	s.serve(context.WithoutCancel(ctx), c)
+	slog.InfoContext(ctx, "Charge point disconnected", "identity", identity)
+	return nil
+}
+
+// authenticate checks the Basic auth credentials of a connecting charger. An
+// unknown identity is answered the same as a wrong password, so identities
+// cannot be probed.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, identity string) (*repository.Device, error) {
+	ctx := r.Context()
+	w.Header().Set("WWW-Authenticate", `Basic realm="ocpp", charset="UTF-8"`)
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, httpx.Unauthorized(ctx, "Charge point credentials are required")
+	}
+
+	if username != identity {
+		return nil, httpx.Unauthorized(ctx, "Charge point credentials are invalid")
+	}
+
+	d, err := s.queries.GetDeviceBySerialNumber(ctx, identity)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.Unauthorized(ctx, "Charge point credentials are invalid")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve charge point", err)
+	}
+
+	if d.Kind != device.KindCharger || !device.CheckOcppPassword(&d, password) {
+		return nil, httpx.Unauthorized(ctx, "Charge point credentials are invalid")
+	}
+
+	w.Header().Del("WWW-Authenticate")
+	return &d, nil
+}
+
+// register replaces an existing connection of the same charger, which happens
+// when it reconnects before the old socket timed out.
+func (s *Server) register(c *Connection) {
+	s.mu.Lock()
+	old, ok := s.conns[c.DeviceID]
+	s.conns[c.DeviceID] = c
+	s.mu.Unlock()
+
+	if ok {
+		old.Close()
+	}
+}
+
+func (s *Server) unregister(c *Connection) {
+	s.mu.Lock()
+	if s.conns[c.DeviceID] == c {
+		delete(s.conns, c.DeviceID)
+	}
+	s.mu.Unlock()
+
+	c.Close()
+}
+
+func (s *Server) serve(ctx context.Context, c *Connection) {
+	_ = c.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	go s.keepAlive(c)
+
+	for {
+		_, raw, err := c.ws.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.DebugContext(ctx, "Charge point read failed", "identity", c.Identity, "error", err)
+			}
+			return
+		}
+		_ = c.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+
+		msg, err := ocpp.Decode(raw)
+		if err != nil {
+			slog.WarnContext(ctx, "Ignoring malformed OCPP frame", "identity", c.Identity, "error", err)
+			continue
+		}
+
+		switch msg.Type {
+		case ocpp.TypeCall:
+			go s.dispatch(ctx, c, msg)
+		case ocpp.TypeCallResult, ocpp.TypeCallError:
+			if !c.resolve(msg) {
+				slog.DebugContext(ctx, "Ignoring unexpected OCPP result", "identity", c.Identity, "message.id", msg.ID)
+			}
+		}
+	}
+}
+
+func (s *Server) keepAlive(c *Connection) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if err := c.ping(); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) dispatch(ctx context.Context, c *Connection, msg *ocpp.Message) {
+	s.mu.RLock()
+	h, ok := s.handlers[msg.Action]
+	s.mu.RUnlock()
+
+	var frame []byte
+	var err error
+	if !ok {
+		frame, err = ocpp.EncodeError(msg.ID, ocpp.ErrorNotImplemented, "Action "+msg.Action+" is not supported")
+	} else {
+		res, callErr := h(ctx, c, msg.Payload)
+		var ce *ocpp.CallError
+		switch {
+		case errors.As(callErr, &ce):
+			frame, err = ocpp.EncodeError(msg.ID, ce.Code, ce.Description)
+		case callErr != nil:
+			slog.ErrorContext(ctx, "Failed to handle OCPP call", "identity", c.Identity, "action", msg.Action, "error", callErr)
+			frame, err = ocpp.EncodeError(msg.ID, ocpp.ErrorInternalError, "Call could not be processed")
+		default:
+			frame, err = ocpp.EncodeResult(msg.ID, res)
+		}
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode OCPP response", "identity", c.Identity, "action", msg.Action, "error", err)
+		return
+	}
+
+	if err := c.write(frame); err != nil {
+		slog.WarnContext(ctx, "Failed to answer OCPP call", "identity", c.Identity, "action", msg.Action, "error", err)
+	}
+}
@@ -0,0 +1,55 @@
+package chargingprofile
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusActive     = "active"
+	StatusRejected   = "rejected"
+	StatusSuperseded = "superseded"
+	StatusExpired    = "expired"
+	StatusCleared    = "cleared"
+)
+
+type ProfileResponse struct {
+	ID              uuid.UUID       `json:"id"`
+	ConnectorID     int32           `json:"connectorId"`
+	ProfileID       int32           `json:"profileId"`
+	Purpose         string          `json:"purpose"`
+	StackLevel      int32           `json:"stackLevel"`
+	ScheduleID      *uuid.UUID      `json:"scheduleId,omitempty"`
+	StartSchedule   time.Time       `json:"startSchedule"`
+	DurationSeconds int32           `json:"durationSeconds"`
+	Periods         json.RawMessage `json:"periods"`
+	Status          string          `json:"status"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+}
+
+func NewProfileResponse(p *repository.ChargingProfile) *ProfileResponse {
+	res := &ProfileResponse{
+		ID:              p.ID,
+		ConnectorID:     p.ConnectorID,
+		ProfileID:       p.ProfileID,
+		Purpose:         p.Purpose,
+		StackLevel:      p.StackLevel,
+		StartSchedule:   p.StartSchedule,
+		DurationSeconds: p.DurationSeconds,
+		Periods:         p.Periods,
+		Status:          p.Status,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+	}
+
+	if p.ScheduleID.Valid {
+		id := uuid.UUID(p.ScheduleID.Bytes)
+		res.ScheduleID = &id
+	}
+
+	return res
+}
@@ -0,0 +1,61 @@
+package chargingprofile
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	profiles, err := h.svc.List(ctx, identityID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*ProfileResponse, 0, len(profiles))
+	for i := range profiles {
+		res = append(res, NewProfileResponse(&profiles[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) ClearHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Clear(ctx, identityID, deviceID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
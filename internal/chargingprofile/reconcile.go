@@ -0,0 +1,322 @@
+package chargingprofile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/chargepoint"
+	"github.com/V2G-Minor-Fontys/server/internal/event"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/google/uuid"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const (
+	retryInterval     = time.Minute
+	reconcileInterval = 5 * time.Minute
+	// compositeDuration is how far ahead the composite schedule is requested,
+	// only the limit in effect right now is compared.
+	compositeDuration = 900
+	// reportStaleAfter ignores shadow power readings older than this.
+	reportStaleAfter = 5 * time.Minute
+	// finishedRetention keeps superseded, cleared and expired profiles this
+	// long after they finished, every balancer tick replaces a profile.
+	finishedRetention = 24 * time.Hour
+	// PowerField is the key of the measured power in kW, negative while
+	// discharging, that chargers report in their device shadow.
+	PowerField = "powerKw"
+)
+
+// Run retries pending profiles, expires finished ones and periodically
+// compares active profiles with the composite schedule the charger reports
+// and the power it measures. Deviations are recorded as events and the profile
+// is sent again. Profiles that finished more than a day ago are deleted.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+
+	var lastReconcile time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.retryPending(ctx, now); err != nil {
+				slog.ErrorContext(ctx, "Failed to retry pending charging profiles", "error", err)
+			}
+
+			if now.Sub(lastReconcile) < reconcileInterval {
+				continue
+			}
+			lastReconcile = now
+			if err := s.reconcile(ctx, now); err != nil {
+				slog.ErrorContext(ctx, "Failed to reconcile charging profiles", "error", err)
+			}
+			if _, err := s.queries.DeleteFinishedChargingProfiles(ctx, now.Add(-finishedRetention)); err != nil {
+				slog.ErrorContext(ctx, "Failed to delete finished charging profiles", "error", err)
+			}
+		}
+	}
+}
+
+func (s *Service) retryPending(ctx context.Context, now time.Time) error {
+	pending, err := s.queries.ListChargingProfilesByStatus(ctx, StatusPending)
+	if err != nil {
+		return err
+	}
+
+	for i := range pending {
+		p := &pending[i]
+		if s.expire(ctx, p, now) {
+			continue
+		}
+
+		if err := s.send(ctx, p); err != nil && !errors.Is(err, chargepoint.ErrNotConnected) {
+			slog.WarnContext(ctx, "Failed to send charging profile", "profile.id", p.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) reconcile(ctx context.Context, now time.Time) error {
+	active, err := s.queries.ListChargingProfilesByStatus(ctx, StatusActive)
+	if err != nil {
+		return err
+	}
+
+	for i := range active {
+		p := &active[i]
+		if s.expire(ctx, p, now) {
+			continue
+		}
+
+		conn, err := s.chargers.Connection(p.DeviceID)
+		if err != nil {
+			continue
+		}
+
+		if err := s.reconcileProfile(ctx, conn, p, now); err != nil {
+			slog.WarnContext(ctx, "Failed to reconcile charging profile", "profile.id", p.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// expire marks profiles whose schedule has ended, the charger discards them
+// on its own.
+func (s *Service) expire(ctx context.Context, p *repository.ChargingProfile, now time.Time) bool {
+	end := p.StartSchedule.Add(time.Duration(p.DurationSeconds) * time.Second)
+	if now.Before(end) {
+		return false
+	}
+
+	if err := s.queries.SetChargingProfileStatus(ctx, repository.SetChargingProfileStatusParams{
+		ID:     p.ID,
+		Status: StatusExpired,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to expire charging profile", "profile.id", p.ID, "error", err)
+	}
+
+	return true
+}
+
+func (s *Service) reconcileProfile(ctx context.Context, conn *chargepoint.Connection, p *repository.ChargingProfile, now time.Time) error {
+	var periods []ocpp.ChargingSchedulePeriod
+	if err := json.Unmarshal(p.Periods, &periods); err != nil {
+		return err
+	}
+
+	expected, ok := ocpp.LimitAt(periods, int(now.Sub(p.StartSchedule).Seconds()))
+	if !ok {
+		return nil
+	}
+	expected = limitFor(conn.Version, expected)
+
+	// Composite schedules are reported per connector, a maximum profile for
+	// the whole charger is only compared with the power it measures.
+	if p.Purpose == ocpp.PurposeChargePointMaxProfile {
+		s.checkPower(ctx, p, expected, now)
+		return nil
+	}
+
+	// A transaction profile overrides the default profile of its connector,
+	// which is only compared again once the transaction profile ends.
+	if p.Purpose == ocpp.PurposeTxDefaultProfile {
+		if _, ok := s.activeLimit(ctx, p.DeviceID, p.ConnectorID, ocpp.PurposeTxProfile, now); ok {
+			return nil
+		}
+	}
+
+	if maxW, ok := s.activeLimit(ctx, p.DeviceID, 0, ocpp.PurposeChargePointMaxProfile, now); ok && expected > maxW {
+		expected = maxW
+	}
+
+	composite, ok, err := s.compositeLimit(ctx, conn, int(p.ConnectorID), now)
+	if err != nil {
+		return err
+	}
+
+	if ok && math.Abs(composite-expected) > tolerance(expected, 100, 0.05) {
+		s.events.Record(ctx, event.Event{
+			Type:     event.TypeCompositeScheduleMismatch,
+			DeviceID: &p.DeviceID,
+			Payload: map[string]any{
+				"chargingProfileId": p.ID,
+				"connectorId":       p.ConnectorID,
+				"expectedW":         expected,
+				"compositeW":        composite,
+			},
+		})
+
+		return s.send(ctx, p)
+	}
+
+	s.checkPower(ctx, p, expected, now)
+	return nil
+}
+
+// compositeLimit asks the charger which limit in watt it applies right now
+// after combining all its profiles. It reports false when the charger has no
+// schedule or expresses it in another unit.
+func (s *Service) compositeLimit(ctx context.Context, conn *chargepoint.Connection, connectorID int, now time.Time) (float64, bool, error) {
+	var start time.Time
+	var unit string
+	var periods []ocpp.ChargingSchedulePeriod
+
+	if conn.Version == ocpp.V201 {
+		var res ocpp.GetCompositeScheduleResponse201
+		if err := conn.Call(ctx, ocpp.ActionGetCompositeSchedule, ocpp.GetCompositeScheduleRequest201{
+			Duration:         compositeDuration,
+			ChargingRateUnit: ocpp.RateUnitWatt,
+			EvseID:           connectorID,
+		}, &res); err != nil {
+			return 0, false, err
+		}
+		if res.Status != ocpp.StatusAccepted || res.Schedule == nil {
+			return 0, false, nil
+		}
+		start, unit, periods = res.Schedule.ScheduleStart, res.Schedule.ChargingRateUnit, res.Schedule.ChargingSchedulePeriod
+	} else {
+		var res ocpp.GetCompositeScheduleResponse16
+		if err := conn.Call(ctx, ocpp.ActionGetCompositeSchedule, ocpp.GetCompositeScheduleRequest16{
+			ConnectorID:      connectorID,
+			Duration:         compositeDuration,
+			ChargingRateUnit: ocpp.RateUnitWatt,
+		}, &res); err != nil {
+			return 0, false, err
+		}
+		if res.Status != ocpp.StatusAccepted || res.ChargingSchedule == nil {
+			return 0, false, nil
+		}
+
+		start = now
+		switch {
+		case res.ScheduleStart != nil:
+			start = *res.ScheduleStart
+		case res.ChargingSchedule.StartSchedule != nil:
+			start = *res.ChargingSchedule.StartSchedule
+		}
+		unit, periods = res.ChargingSchedule.ChargingRateUnit, res.ChargingSchedule.ChargingSchedulePeriod
+	}
+
+	if unit != ocpp.RateUnitWatt {
+		return 0, false, nil
+	}
+
+	limit, ok := ocpp.LimitAt(periods, max(0, int(now.Sub(start).Seconds())))
+	return limit, ok, nil
+}
+
+// checkPower records an event when the power the charger reports exceeds the
+// limit, or flows in the other direction than the profile allows. Drawing
+// less than the limit is normal, the vehicle decides within it.
+func (s *Service) checkPower(ctx context.Context, p *repository.ChargingProfile, expectedW float64, now time.Time) {
+	reportedKw, ok := s.reportedPower(ctx, p.DeviceID, now)
+	if !ok {
+		return
+	}
+
+	expectedKw := expectedW / 1000
+	tol := tolerance(expectedKw, 0.5, 0.1)
+	var exceeded bool
+	switch {
+	case p.Purpose == ocpp.PurposeChargePointMaxProfile:
+		// Maximum profiles only cap charging, discharging is left to the
+		// profiles of the connectors.
+		exceeded = reportedKw > expectedKw+tol
+	case expectedKw >= 0:
+		exceeded = reportedKw > expectedKw+tol || reportedKw < -tol
+	default:
+		exceeded = reportedKw < expectedKw-tol || reportedKw > tol
+	}
+	if !exceeded {
+		return
+	}
+
+	s.events.Record(ctx, event.Event{
+		Type:     event.TypePowerMismatch,
+		DeviceID: &p.DeviceID,
+		Payload: map[string]any{
+			"chargingProfileId": p.ID,
+			"connectorId":       p.ConnectorID,
+			"expectedKw":        expectedKw,
+			"reportedKw":        reportedKw,
+		},
+	})
+}
+
+// activeLimit returns the limit in watt of the active profile with the
+// purpose on the connector that is in effect right now. The maximum profile
+// of the charger is on connector 0 and takes precedence over higher limits
+// of its connectors.
+func (s *Service) activeLimit(ctx context.Context, deviceID uuid.UUID, connectorID int32, purpose string, now time.Time) (float64, bool) {
+	profiles, err := s.queries.ListChargingProfilesByDeviceId(ctx, deviceID)
+	if err != nil {
+		return 0, false
+	}
+
+	for _, p := range profiles {
+		if p.ConnectorID != connectorID || p.Purpose != purpose || p.Status != StatusActive {
+			continue
+		}
+
+		end := p.StartSchedule.Add(time.Duration(p.DurationSeconds) * time.Second)
+		if now.Before(p.StartSchedule) || !now.Before(end) {
+			continue
+		}
+
+		var periods []ocpp.ChargingSchedulePeriod
+		if err := json.Unmarshal(p.Periods, &periods); err != nil {
+			continue
+		}
+
+		return ocpp.LimitAt(periods, int(now.Sub(p.StartSchedule).Seconds()))
+	}
+
+	return 0, false
+}
+
+func (s *Service) reportedPower(ctx context.Context, deviceID uuid.UUID, now time.Time) (float64, bool) {
+	sh, err := s.queries.GetDeviceShadow(ctx, deviceID)
+	if err != nil || !sh.ReportedUpdatedAt.Valid || now.Sub(sh.ReportedUpdatedAt.Time) > reportStaleAfter {
+		return 0, false
+	}
+
+	var reported map[string]any
+	if err := json.Unmarshal(sh.Reported, &reported); err != nil {
+		return 0, false
+	}
+
+	power, ok := reported[PowerField].(float64)
+	return power, ok
+}
+
+// tolerance is the larger of an absolute margin and a share of the value.
+func tolerance(value, absolute, relative float64) float64 {
+	return math.Max(absolute, math.Abs(value)*relative)
+}
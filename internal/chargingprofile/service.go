@@ -0,0 +1,439 @@
+// Package chargingprofile pushes planned schedules to OCPP chargers as
+// charging profiles and keeps track of the profile active per connector.
+package chargingprofile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/chargepoint"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/event"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/V2G-Minor-Fontys/server/pkg/optimizer"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const defaultConnectorID = 1
+
+var ErrProfileRejected = errors.New("charging profile was rejected")
+
+// occupiedStatuses are the connector statuses, of either OCPP version, that
+// indicate a vehicle is plugged in.
+var occupiedStatuses = map[string]bool{
+	"Preparing":     true,
+	"Charging":      true,
+	"SuspendedEV":   true,
+	"SuspendedEVSE": true,
+	"Finishing":     true,
+	"Occupied":      true,
+}
+
+type Service struct {
+	db       *pgxpool.Pool
+	queries  *repository.Queries
+	devices  *device.Service
+	chargers *chargepoint.Server
+	events   *event.Service
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, devices *device.Service, chargers *chargepoint.Server, events *event.Service) *Service {
+	return &Service{db: db, queries: queries, devices: devices, chargers: chargers, events: events}
+}
+
+func (s *Service) List(ctx context.Context, identityID, deviceID uuid.UUID) ([]repository.ChargingProfile, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := s.queries.ListChargingProfilesByDeviceId(ctx, d.ID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve charging profiles", err)
+	}
+
+	return profiles, nil
+}
+
+// Clear removes the profiles installed by the server from the charger, which
+// falls back to charging without limits of ours.
+func (s *Service) Clear(ctx context.Context, identityID, deviceID uuid.UUID) error {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	if d.Kind != device.KindCharger {
+		return httpx.BadRequest(ctx, "Charging profiles can only be cleared on chargers")
+	}
+
+	conn, err := s.chargers.Connection(d.ID)
+	if err != nil {
+		return httpx.Conflict(ctx, "Charger is not connected")
+	}
+
+	if err := clearProfiles(ctx, conn, ""); err != nil {
+		var refused clearRefusedError
+		if errors.As(err, &refused) {
+			return httpx.Conflict(ctx, fmt.Sprintf("Charger answered %s to clearing its charging profiles", string(refused)))
+		}
+
+		return httpx.InternalErr(ctx, "Charger did not clear its charging profiles", err)
+	}
+
+	if _, err := s.queries.ClearChargingProfilesByDeviceId(ctx, d.ID); err != nil {
+		return httpx.InternalErr(ctx, "Failed to update charging profiles", err)
+	}
+
+	return nil
+}
+
+// clearRefusedError is the status a charger answered instead of clearing its
+// profiles.
+type clearRefusedError string
+
+func (e clearRefusedError) Error() string {
+	return "charger answered " + string(e) + " to clearing its charging profiles"
+}
+
+// clearProfiles removes the profiles the server installed on the charger,
+// limited to one purpose unless it is empty.
+func clearProfiles(ctx context.Context, conn *chargepoint.Connection, purpose string) error {
+	level := StackLevel
+	var res ocpp.StatusResponse
+	var err error
+	if conn.Version == ocpp.V201 {
+		err = conn.Call(ctx, ocpp.ActionClearChargingProfile, ocpp.ClearChargingProfileRequest201{
+			ChargingProfileCriteria: &ocpp.ClearChargingProfileCriteria201{StackLevel: &level, ChargingProfilePurpose: purpose},
+		}, &res)
+	} else {
+		err = conn.Call(ctx, ocpp.ActionClearChargingProfile, ocpp.ClearChargingProfileRequest16{StackLevel: &level, ChargingProfilePurpose: purpose}, &res)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Unknown means the charger had none of our profiles installed.
+	if res.Status != ocpp.StatusAccepted && res.Status != ocpp.StatusUnknown {
+		return clearRefusedError(res.Status)
+	}
+
+	return nil
+}
+
+// clearProfile removes a single profile the server installed on the charger.
+func clearProfile(ctx context.Context, conn *chargepoint.Connection, profileID int) error {
+	var res ocpp.StatusResponse
+	var err error
+	if conn.Version == ocpp.V201 {
+		err = conn.Call(ctx, ocpp.ActionClearChargingProfile, ocpp.ClearChargingProfileRequest201{ChargingProfileID: &profileID}, &res)
+	} else {
+		err = conn.Call(ctx, ocpp.ActionClearChargingProfile, ocpp.ClearChargingProfileRequest16{ID: &profileID}, &res)
+	}
+	if err != nil {
+		return err
+	}
+
+	if res.Status != ocpp.StatusAccepted && res.Status != ocpp.StatusUnknown {
+		return clearRefusedError(res.Status)
+	}
+
+	return nil
+}
+
+// Apply is a scheduling.Listener that turns a new schedule into a profile for
+// the charger the vehicle is linked to. The profile is stored as pending and
+// sent in the background, the reconciliation loop retries it when the
+// charger is offline.
+func (s *Service) Apply(ctx context.Context, v *repository.Vehicle, sched *repository.ChargingSchedule) {
+	if !v.ChargerID.Valid {
+		return
+	}
+
+	p, err := s.create(ctx, uuid.UUID(v.ChargerID.Bytes), sched, v.MaxChargeKw)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create charging profile", "vehicle.id", v.ID, "schedule.id", sched.ID, "error", err)
+		return
+	}
+	if p == nil {
+		return
+	}
+
+	go func(ctx context.Context) {
+		if err := s.send(ctx, p); err != nil && !errors.Is(err, chargepoint.ErrNotConnected) {
+			slog.WarnContext(ctx, "Failed to send charging profile", "profile.id", p.ID, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// create stores the schedule as a default profile. Solar schedules are only
+// the grid fallback, on chargers that belong to a site the vehicle may charge
+// at full power and is capped by the maximum profiles of the site balancer
+// instead.
+func (s *Service) create(ctx context.Context, deviceID uuid.UUID, sched *repository.ChargingSchedule, maxChargeKw float64) (*repository.ChargingProfile, error) {
+	var setpoints []optimizer.Setpoint
+	if err := json.Unmarshal(sched.Setpoints, &setpoints); err != nil {
+		return nil, err
+	}
+	if sched.Reason == scheduling.ReasonSolar {
+		d, err := s.queries.GetDeviceById(ctx, deviceID)
+		if err != nil {
+			return nil, err
+		}
+		if d.SiteID.Valid {
+			for i := range setpoints {
+				setpoints[i].PowerKw = maxChargeKw
+			}
+		}
+	}
+
+	start, duration, periods := buildPeriods(setpoints, scheduling.Resolution, time.Now().UTC())
+	if len(periods) == 0 {
+		return nil, nil
+	}
+
+	connectorID, err := s.activeConnector(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store(ctx, repository.CreateChargingProfileParams{
+		DeviceID:        deviceID,
+		ConnectorID:     int32(connectorID),
+		Purpose:         ocpp.PurposeTxDefaultProfile,
+		ScheduleID:      pgtype.UUID{Bytes: sched.ID, Valid: true},
+		StartSchedule:   start,
+		DurationSeconds: int32(duration),
+	}, periods)
+}
+
+// SetMaxPower limits the total power of a charger for the given duration
+// with a maximum profile on connector 0, which caps the schedules of all its
+// connectors. The profile is sent right away, the reconciliation loop retries
+// it when the charger is offline.
+func (s *Service) SetMaxPower(ctx context.Context, deviceID uuid.UUID, limitKw float64, validFor time.Duration) error {
+	p, err := s.store(ctx, repository.CreateChargingProfileParams{
+		DeviceID:        deviceID,
+		ConnectorID:     0,
+		Purpose:         ocpp.PurposeChargePointMaxProfile,
+		StartSchedule:   time.Now().UTC().Truncate(time.Second),
+		DurationSeconds: int32(validFor.Seconds()),
+	}, []ocpp.ChargingSchedulePeriod{{StartPeriod: 0, Limit: math.Round(math.Max(0, limitKw) * 1000)}})
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, p)
+}
+
+// SetPower makes the vehicle on a charger draw powerKw until the given time
+// with a transaction profile, which takes precedence over the schedule of
+// the default profile. Negative powers discharge on 2.0.1 chargers, 1.6
+// chargers pause instead. The profile is sent right away, the reconciliation
+// loop retries it when the charger is offline.
+func (s *Service) SetPower(ctx context.Context, deviceID uuid.UUID, powerKw float64, until time.Time) error {
+	connectorID, err := s.activeConnector(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+
+	return s.SetConnectorPower(ctx, deviceID, connectorID, powerKw, until)
+}
+
+// SetConnectorPower is SetPower for a given connector of the charger.
+func (s *Service) SetConnectorPower(ctx context.Context, deviceID uuid.UUID, connectorID int, powerKw float64, until time.Time) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	p, err := s.store(ctx, repository.CreateChargingProfileParams{
+		DeviceID:        deviceID,
+		ConnectorID:     int32(connectorID),
+		Purpose:         ocpp.PurposeTxProfile,
+		StartSchedule:   now,
+		DurationSeconds: int32(math.Ceil(until.Sub(now).Seconds())),
+	}, []ocpp.ChargingSchedulePeriod{{StartPeriod: 0, Limit: math.Round(powerKw * 1000)}})
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, p)
+}
+
+// ReleasePower removes the transaction profiles of the charger, after which
+// it follows its schedule again. A charger that is offline keeps the profile
+// until it expires.
+func (s *Service) ReleasePower(ctx context.Context, deviceID uuid.UUID) error {
+	if _, err := s.queries.ClearChargingProfilesByPurpose(ctx, repository.ClearChargingProfilesByPurposeParams{
+		DeviceID: deviceID,
+		Purpose:  ocpp.PurposeTxProfile,
+	}); err != nil {
+		return err
+	}
+
+	conn, err := s.chargers.Connection(deviceID)
+	if err != nil {
+		return err
+	}
+
+	return clearProfiles(ctx, conn, ocpp.PurposeTxProfile)
+}
+
+// ReleaseConnectorPower is ReleasePower for a single connector, the
+// transaction profiles of the other connectors of the charger stay.
+func (s *Service) ReleaseConnectorPower(ctx context.Context, deviceID uuid.UUID, connectorID int) error {
+	if _, err := s.queries.ClearChargingProfilesByConnector(ctx, repository.ClearChargingProfilesByConnectorParams{
+		DeviceID:    deviceID,
+		ConnectorID: int32(connectorID),
+		Purpose:     ocpp.PurposeTxProfile,
+	}); err != nil {
+		return err
+	}
+
+	conn, err := s.chargers.Connection(deviceID)
+	if err != nil {
+		return err
+	}
+
+	return clearProfile(ctx, conn, ProfileID(connectorID, ocpp.PurposeTxProfile))
+}
+
+// Controls reports whether the charger is connected to receive profiles and
+// whether it can discharge, which 1.6 chargers cannot.
+func (s *Service) Controls(deviceID uuid.UUID) (connected, discharge bool) {
+	conn, err := s.chargers.Connection(deviceID)
+	if err != nil {
+		return false, false
+	}
+
+	return true, conn.Version == ocpp.V201
+}
+
+// store saves a pending profile, replacing profiles of the same connector and
+// purpose that never reached the charger.
+func (s *Service) store(ctx context.Context, params repository.CreateChargingProfileParams, periods []ocpp.ChargingSchedulePeriod) (*repository.ChargingProfile, error) {
+	raw, err := json.Marshal(periods)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.queries.SupersedePendingChargingProfiles(ctx, repository.SupersedePendingChargingProfilesParams{
+		DeviceID:    params.DeviceID,
+		ConnectorID: params.ConnectorID,
+		Purpose:     params.Purpose,
+	}); err != nil {
+		return nil, err
+	}
+
+	params.ID = uuid.New()
+	params.ProfileID = int32(ProfileID(int(params.ConnectorID), params.Purpose))
+	params.StackLevel = StackLevel
+	params.Periods = raw
+	p, err := s.queries.CreateChargingProfile(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// activeConnector picks the connector a vehicle is plugged in to, or the
+// first connector when none reports being occupied.
+func (s *Service) activeConnector(ctx context.Context, deviceID uuid.UUID) (int, error) {
+	connectors, err := s.chargers.Connectors(ctx, deviceID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range connectors {
+		if occupiedStatuses[c.Status] {
+			return int(c.ConnectorID), nil
+		}
+	}
+
+	return defaultConnectorID, nil
+}
+
+// send installs the profile on the charger and marks it active, superseding
+// the previous profile of the same connector and purpose.
+func (s *Service) send(ctx context.Context, p *repository.ChargingProfile) error {
+	conn, err := s.chargers.Connection(p.DeviceID)
+	if err != nil {
+		return err
+	}
+
+	var periods []ocpp.ChargingSchedulePeriod
+	if err := json.Unmarshal(p.Periods, &periods); err != nil {
+		return err
+	}
+
+	var res ocpp.StatusResponse
+	if conn.Version == ocpp.V201 {
+		err = conn.Call(ctx, ocpp.ActionSetChargingProfile, setRequest201(p, periods), &res)
+	} else {
+		err = conn.Call(ctx, ocpp.ActionSetChargingProfile, setRequest16(p, periods), &res)
+	}
+	if err != nil {
+		return err
+	}
+
+	if res.Status != ocpp.StatusAccepted {
+		if err := s.queries.SetChargingProfileStatus(ctx, repository.SetChargingProfileStatusParams{
+			ID:     p.ID,
+			Status: StatusRejected,
+		}); err != nil {
+			return err
+		}
+
+		s.events.Record(ctx, event.Event{
+			Type:     event.TypeProfileRejected,
+			DeviceID: &p.DeviceID,
+			Payload: map[string]any{
+				"chargingProfileId": p.ID,
+				"connectorId":       p.ConnectorID,
+				"status":            res.Status,
+			},
+		})
+		return ErrProfileRejected
+	}
+
+	return s.activate(ctx, p)
+}
+
+func (s *Service) activate(ctx context.Context, p *repository.ChargingProfile) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.SupersedeActiveChargingProfiles(ctx, repository.SupersedeActiveChargingProfilesParams{
+		DeviceID:    p.DeviceID,
+		ConnectorID: p.ConnectorID,
+		Purpose:     p.Purpose,
+	}); err != nil {
+		return err
+	}
+
+	if err := qtx.SetChargingProfileStatus(ctx, repository.SetChargingProfileStatusParams{
+		ID:     p.ID,
+		Status: StatusActive,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,116 @@
+package chargingprofile
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/V2G-Minor-Fontys/server/pkg/optimizer"
+	"math"
+	"time"
+)
+
+// StackLevel is the level the server installs its profiles at, local
+// profiles of the charger with a higher level keep precedence.
+const StackLevel = 1
+
+// ProfileID derives a stable id per connector and purpose, so that sending a
+// new profile replaces the previous one on the charger.
+func ProfileID(connectorID int, purpose string) int {
+	code := 1
+	switch purpose {
+	case ocpp.PurposeTxProfile:
+		code = 2
+	case ocpp.PurposeChargePointMaxProfile:
+		code = 3
+	}
+
+	return connectorID*10 + code
+}
+
+// buildPeriods turns the remaining setpoints of a schedule into periods in
+// watt relative to the returned start, merging consecutive equal limits.
+// Negative limits request discharging.
+func buildPeriods(setpoints []optimizer.Setpoint, resolution time.Duration, now time.Time) (time.Time, int, []ocpp.ChargingSchedulePeriod) {
+	var start time.Time
+	var periods []ocpp.ChargingSchedulePeriod
+	duration := 0
+
+	for _, sp := range setpoints {
+		end := sp.Start.Add(resolution)
+		if !end.After(now) {
+			continue
+		}
+		if start.IsZero() {
+			start = sp.Start
+		}
+
+		offset := int(sp.Start.Sub(start).Seconds())
+		limit := math.Round(sp.PowerKw * 1000)
+		if len(periods) == 0 || periods[len(periods)-1].Limit != limit {
+			periods = append(periods, ocpp.ChargingSchedulePeriod{StartPeriod: offset, Limit: limit})
+		}
+		duration = int(end.Sub(start).Seconds())
+	}
+
+	return start, duration, periods
+}
+
+// limitFor returns the limit the charger is expected to apply, 1.6 chargers
+// cannot discharge so negative limits are sent as zero.
+func limitFor(version ocpp.Version, limit float64) float64 {
+	if version == ocpp.V201 {
+		return limit
+	}
+
+	return math.Max(0, limit)
+}
+
+func setRequest16(p *repository.ChargingProfile, periods []ocpp.ChargingSchedulePeriod) ocpp.SetChargingProfileRequest16 {
+	clamped := make([]ocpp.ChargingSchedulePeriod, len(periods))
+	for i, period := range periods {
+		clamped[i] = period
+		clamped[i].Limit = limitFor(ocpp.V16, period.Limit)
+	}
+
+	start := p.StartSchedule
+	return ocpp.SetChargingProfileRequest16{
+		ConnectorID: int(p.ConnectorID),
+		CsChargingProfiles: ocpp.ChargingProfile16{
+			ChargingProfileID:      int(p.ProfileID),
+			StackLevel:             int(p.StackLevel),
+			ChargingProfilePurpose: p.Purpose,
+			ChargingProfileKind:    ocpp.KindAbsolute,
+			ChargingSchedule: ocpp.ChargingSchedule16{
+				Duration:               int(p.DurationSeconds),
+				StartSchedule:          &start,
+				ChargingRateUnit:       ocpp.RateUnitWatt,
+				ChargingSchedulePeriod: clamped,
+			},
+		},
+	}
+}
+
+func setRequest201(p *repository.ChargingProfile, periods []ocpp.ChargingSchedulePeriod) ocpp.SetChargingProfileRequest201 {
+	// Profiles are stored with their 1.6 purpose.
+	purpose := p.Purpose
+	if purpose == ocpp.PurposeChargePointMaxProfile {
+		purpose = ocpp.PurposeChargingStationMaxProfile
+	}
+
+	start := p.StartSchedule
+	return ocpp.SetChargingProfileRequest201{
+		EvseID: int(p.ConnectorID),
+		ChargingProfile: ocpp.ChargingProfile201{
+			ID:                     int(p.ProfileID),
+			StackLevel:             int(p.StackLevel),
+			ChargingProfilePurpose: purpose,
+			ChargingProfileKind:    ocpp.KindAbsolute,
+			ChargingSchedule: []ocpp.ChargingSchedule201{{
+				ID:                     int(p.ProfileID),
+				Duration:               int(p.DurationSeconds),
+				StartSchedule:          &start,
+				ChargingRateUnit:       ocpp.RateUnitWatt,
+				ChargingSchedulePeriod: periods,
+			}},
+		},
+	}
+}
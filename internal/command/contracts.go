@@ -0,0 +1,111 @@
+package command
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TypeSetChargePower = "set_charge_power"
+	TypeStartDischarge = "start_discharge"
+	TypePause          = "pause"
+	TypeUpdateSchedule = "update_schedule"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusSent     = "sent"
+	StatusAcked    = "acked"
+	StatusNacked   = "nacked"
+	StatusTimedOut = "timed_out"
+)
+
+const (
+	AckStatusAck  = "ack"
+	AckStatusNack = "nack"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int32
+	AckTimeout  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	AckTimeout:  30 * time.Second,
+}
+
+type CreateCommandRequest struct {
+	Type           string          `json:"type,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	MaxAttempts    int32           `json:"maxAttempts,omitempty"`
+	TimeoutSeconds int32           `json:"timeoutSeconds,omitempty"`
+}
+
+type PowerPayload struct {
+	PowerKw float64 `json:"powerKw"`
+}
+
+type ScheduleInterval struct {
+	Start   time.Time `json:"start"`
+	PowerKw float64   `json:"powerKw"`
+}
+
+type SchedulePayload struct {
+	Intervals []ScheduleInterval `json:"intervals"`
+}
+
+// Envelope is the message published on a device's command topic.
+type Envelope struct {
+	ID       uuid.UUID       `json:"id"`
+	Type     string          `json:"type"`
+	Payload  json.RawMessage `json:"payload"`
+	Attempt  int32           `json:"attempt"`
+	IssuedAt time.Time       `json:"issuedAt"`
+}
+
+// Ack is the message a device publishes on its acknowledgement topic.
+type Ack struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+type CommandResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	DeviceID       uuid.UUID       `json:"deviceId"`
+	Type           string          `json:"type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Reason         string          `json:"reason,omitempty"`
+	Attempts       int32           `json:"attempts"`
+	MaxAttempts    int32           `json:"maxAttempts"`
+	TimeoutSeconds int32           `json:"timeoutSeconds"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+	CompletedAt    *time.Time      `json:"completedAt,omitempty"`
+}
+
+func NewCommandResponse(c *repository.DeviceCommand) *CommandResponse {
+	res := &CommandResponse{
+		ID:             c.ID,
+		DeviceID:       c.DeviceID,
+		Type:           c.Type,
+		Payload:        c.Payload,
+		Status:         c.Status,
+		Reason:         c.Reason,
+		Attempts:       c.Attempts,
+		MaxAttempts:    c.MaxAttempts,
+		TimeoutSeconds: c.TimeoutSeconds,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+	}
+
+	if c.CompletedAt.Valid {
+		res.CompletedAt = &c.CompletedAt.Time
+	}
+
+	return res
+}
@@ -0,0 +1,94 @@
+package command
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req CreateCommandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	cmd, err := h.svc.Issue(ctx, identityID, deviceID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusAccepted, NewCommandResponse(cmd))
+	return nil
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	cmds, err := h.svc.List(ctx, identityID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*CommandResponse, 0, len(cmds))
+	for i := range cmds {
+		res = append(res, NewCommandResponse(&cmds[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	commandID, err := httpx.URLParamUUID(r, "cmdId")
+	if err != nil {
+		return err
+	}
+
+	cmd, err := h.svc.Get(ctx, identityID, deviceID, commandID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewCommandResponse(cmd))
+	return nil
+}
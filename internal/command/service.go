@@ -0,0 +1,325 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/mqtt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+const (
+	maxAttemptsLimit   = 10
+	minTimeoutSeconds  = 5
+	maxTimeoutSeconds  = 600
+	listLimit          = 50
+	dueBatchSize       = 100
+	retryCheckInterval = 5 * time.Second
+	awaitInterval      = 250 * time.Millisecond
+)
+
+type Service struct {
+	queries *repository.Queries
+	devices *device.Service
+	broker  *mqtt.Client
+	policy  RetryPolicy
+}
+
+func NewService(queries *repository.Queries, devices *device.Service, broker *mqtt.Client) *Service {
+	return &Service{
+		queries: queries,
+		devices: devices,
+		broker:  broker,
+		policy:  DefaultRetryPolicy,
+	}
+}
+
+func (s *Service) Issue(ctx context.Context, identityID, deviceID uuid.UUID, req CreateCommandRequest) (*repository.DeviceCommand, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := validatePayload(req.Type, req.Payload)
+	if err != nil {
+		return nil, httpx.BadRequest(ctx, err.Error())
+	}
+
+	maxAttempts := s.policy.MaxAttempts
+	if req.MaxAttempts != 0 {
+		if req.MaxAttempts < 1 || req.MaxAttempts > maxAttemptsLimit {
+			return nil, httpx.BadRequest(ctx, fmt.Sprintf("maxAttempts must be between 1 and %d", maxAttemptsLimit))
+		}
+		maxAttempts = req.MaxAttempts
+	}
+
+	timeoutSeconds := int32(s.policy.AckTimeout / time.Second)
+	if req.TimeoutSeconds != 0 {
+		if req.TimeoutSeconds < minTimeoutSeconds || req.TimeoutSeconds > maxTimeoutSeconds {
+			return nil, httpx.BadRequest(ctx, fmt.Sprintf("timeoutSeconds must be between %d and %d", minTimeoutSeconds, maxTimeoutSeconds))
+		}
+		timeoutSeconds = req.TimeoutSeconds
+	}
+
+	cmd, err := s.queries.CreateDeviceCommand(ctx, repository.CreateDeviceCommandParams{
+		ID:             uuid.New(),
+		DeviceID:       d.ID,
+		Type:           req.Type,
+		Payload:        payload,
+		MaxAttempts:    maxAttempts,
+		TimeoutSeconds: timeoutSeconds,
+		NextAttemptAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to store command", err)
+	}
+
+	// A failed publish leaves the command pending, the retry loop picks it up.
+	if err := s.dispatch(ctx, &cmd, d.SerialNumber); err != nil {
+		slog.WarnContext(ctx, "Initial command dispatch failed", "command.id", cmd.ID, "error", err)
+		return &cmd, nil
+	}
+
+	return s.get(ctx, d.ID, cmd.ID)
+}
+
+func (s *Service) Get(ctx context.Context, identityID, deviceID, commandID uuid.UUID) (*repository.DeviceCommand, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.get(ctx, d.ID, commandID)
+}
+
+func (s *Service) List(ctx context.Context, identityID, deviceID uuid.UUID) ([]repository.DeviceCommand, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds, err := s.queries.ListDeviceCommandsByDeviceId(ctx, repository.ListDeviceCommandsByDeviceIdParams{
+		DeviceID: d.ID,
+		Limit:    listLimit,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve commands", err)
+	}
+
+	return cmds, nil
+}
+
+// Await waits until the device acknowledged the command or ctx is done. A
+// command that is still open by then is timed out, so it is not retried
+// after the caller gave up on it.
+func (s *Service) Await(ctx context.Context, cmd *repository.DeviceCommand) (*repository.DeviceCommand, error) {
+	ticker := time.NewTicker(awaitInterval)
+	defer ticker.Stop()
+
+	for {
+		current, err := s.queries.GetDeviceCommand(ctx, repository.GetDeviceCommandParams{
+			ID:       cmd.ID,
+			DeviceID: cmd.DeviceID,
+		})
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if err == nil && current.Status != StatusPending && current.Status != StatusSent {
+			return &current, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			ctx := context.WithoutCancel(ctx)
+			if _, err := s.queries.CompleteDeviceCommand(ctx, repository.CompleteDeviceCommandParams{
+				ID:       cmd.ID,
+				DeviceID: cmd.DeviceID,
+				Status:   StatusTimedOut,
+				Reason:   "No acknowledgement before the caller gave up",
+			}); err != nil {
+				return nil, err
+			}
+
+			// The acknowledgement may have arrived just before.
+			return s.get(ctx, cmd.DeviceID, cmd.ID)
+		}
+	}
+}
+
+func (s *Service) get(ctx context.Context, deviceID, commandID uuid.UUID) (*repository.DeviceCommand, error) {
+	cmd, err := s.queries.GetDeviceCommand(ctx, repository.GetDeviceCommandParams{
+		ID:       commandID,
+		DeviceID: deviceID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Command could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve command", err)
+	}
+
+	return &cmd, nil
+}
+
+// Subscribe starts listening for acknowledgements of all devices.
+func (s *Service) Subscribe() error {
+	return s.broker.Subscribe(AckSubscription, s.handleAck)
+}
+
+// Run re-publishes unacknowledged commands once their ack timeout elapses and
+// marks them as timed out when the retry policy is exhausted.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(retryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.processDue(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to process due commands", "error", err)
+			}
+		}
+	}
+}
+
+func (s *Service) processDue(ctx context.Context) error {
+	due, err := s.queries.ListDueDeviceCommands(ctx, repository.ListDueDeviceCommandsParams{
+		NextAttemptAt: time.Now().UTC(),
+		Limit:         dueBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		cmd := &due[i]
+		if cmd.Attempts >= cmd.MaxAttempts {
+			if _, err := s.queries.CompleteDeviceCommand(ctx, repository.CompleteDeviceCommandParams{
+				ID:       cmd.ID,
+				DeviceID: cmd.DeviceID,
+				Status:   StatusTimedOut,
+				Reason:   fmt.Sprintf("No acknowledgement after %d attempts", cmd.Attempts),
+			}); err != nil {
+				slog.ErrorContext(ctx, "Failed to time out command", "command.id", cmd.ID, "error", err)
+			}
+			continue
+		}
+
+		d, err := s.queries.GetDeviceById(ctx, cmd.DeviceID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to resolve command device", "command.id", cmd.ID, "error", err)
+			continue
+		}
+
+		if err := s.dispatch(ctx, cmd, d.SerialNumber); err != nil {
+			slog.WarnContext(ctx, "Command dispatch failed", "command.id", cmd.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) dispatch(ctx context.Context, cmd *repository.DeviceCommand, serialNumber string) error {
+	msg, err := json.Marshal(Envelope{
+		ID:       cmd.ID,
+		Type:     cmd.Type,
+		Payload:  cmd.Payload,
+		Attempt:  cmd.Attempts + 1,
+		IssuedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.broker.Publish(ctx, CommandTopic(serialNumber), msg); err != nil {
+		return err
+	}
+
+	// The device can acknowledge before the command is marked as sent, the
+	// update leaves a completed command alone.
+	return s.queries.MarkDeviceCommandSent(ctx, repository.MarkDeviceCommandSentParams{
+		ID:            cmd.ID,
+		NextAttemptAt: time.Now().UTC().Add(time.Duration(cmd.TimeoutSeconds) * time.Second),
+	})
+}
+
+func (s *Service) handleAck(topic string, payload []byte) {
+	ctx := context.Background()
+	serial, ok := serialFromAckTopic(topic)
+	if !ok {
+		slog.Warn("Ignoring acknowledgement on unexpected topic", "topic", topic)
+		return
+	}
+
+	var ack Ack
+	if err := json.Unmarshal(payload, &ack); err != nil {
+		slog.Warn("Ignoring malformed acknowledgement", "topic", topic, "error", err)
+		return
+	}
+
+	status := StatusAcked
+	switch ack.Status {
+	case AckStatusAck:
+	case AckStatusNack:
+		status = StatusNacked
+	default:
+		slog.Warn("Ignoring acknowledgement with unknown status", "topic", topic, "status", ack.Status)
+		return
+	}
+
+	d, err := s.queries.GetDeviceBySerialNumber(ctx, serial)
+	if err != nil {
+		slog.Warn("Acknowledgement from unknown device", "serialNumber", serial, "error", err)
+		return
+	}
+
+	rows, err := s.queries.CompleteDeviceCommand(ctx, repository.CompleteDeviceCommandParams{
+		ID:       ack.ID,
+		DeviceID: d.ID,
+		Status:   status,
+		Reason:   ack.Reason,
+	})
+	if err != nil {
+		slog.Error("Failed to store acknowledgement", "command.id", ack.ID, "error", err)
+		return
+	}
+
+	if rows == 0 {
+		slog.Debug("Acknowledgement for unknown or completed command", "command.id", ack.ID, "device.id", d.ID)
+	}
+}
+
+func validatePayload(typ string, raw json.RawMessage) ([]byte, error) {
+	if len(raw) == 0 {
+		raw = json.RawMessage("{}")
+	}
+
+	switch typ {
+	case TypeSetChargePower, TypeStartDischarge:
+		var p PowerPayload
+		if err := json.Unmarshal(raw, &p); err != nil || p.PowerKw <= 0 {
+			return nil, errors.New("payload.powerKw must be a positive number")
+		}
+	case TypeUpdateSchedule:
+		var p SchedulePayload
+		if err := json.Unmarshal(raw, &p); err != nil || len(p.Intervals) == 0 {
+			return nil, errors.New("payload.intervals must contain at least one interval")
+		}
+	case TypePause:
+	default:
+		return nil, fmt.Errorf("unsupported command type %q", typ)
+	}
+
+	return raw, nil
+}
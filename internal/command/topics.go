@@ -0,0 +1,22 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+const AckSubscription = "devices/+/commands/ack"
+
+func CommandTopic(serialNumber string) string {
+	return fmt.Sprintf("devices/%s/commands", serialNumber)
+}
+
+// serialFromAckTopic extracts the serial number from devices/{serial}/commands/ack.
+func serialFromAckTopic(topic string) (string, bool) {
+	parts := strings.Split(topic, "/")
+	if len(parts) != 4 || parts[0] != "devices" || parts[2] != "commands" || parts[3] != "ack" {
+		return "", false
+	}
+
+	return parts[1], parts[1] != ""
+}
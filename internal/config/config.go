@@ -35,12 +35,18 @@ func mustGetInt(s string) int {
 }
 
 type Config struct {
-	Server   *Server
-	Logger   *Logger
-	Database *Database
-	Redis    *Redis
-	Mqtt     *Mqtt
-	Jwt      *Jwt
+	Server     *Server
+	Logger     *Logger
+	Database   *Database
+	Redis      *Redis
+	Mqtt       *Mqtt
+	Jwt        *Jwt
+	Prices     *Prices
+	Carbon     *Carbon
+	Weather    *Weather
+	OpenADR    *OpenADR
+	Ven        *Ven
+	Congestion *Congestion
 }
 
 type Server struct {
@@ -136,6 +142,149 @@ func NewJwtConfigFromEnv() *Jwt {
 	}
 }
 
+type Prices struct {
+	// Source is either "entsoe" to download from the ENTSO-E Transparency
+	// Platform or "file" to read XML and CSV files from Directory.
+	Source      string   `json:"source,omitempty"`
+	EntsoeToken string   `json:"entsoeToken,omitempty"`
+	Directory   string   `json:"directory,omitempty"`
+	Zones       []string `json:"zones,omitempty"`
+}
+
+func NewPricesConfigFromEnv() *Prices {
+	source, ok := os.LookupEnv("PRICES_SOURCE")
+	if !ok {
+		source = "file"
+	}
+
+	directory, ok := os.LookupEnv("PRICES_DIRECTORY")
+	if !ok {
+		directory = "data/prices"
+	}
+
+	zones, ok := os.LookupEnv("PRICES_ZONES")
+	if !ok {
+		zones = "NL"
+	}
+
+	return &Prices{
+		Source:      source,
+		EntsoeToken: os.Getenv("ENTSOE_TOKEN"),
+		Directory:   directory,
+		Zones:       strings.Split(zones, ","),
+	}
+}
+
+type Carbon struct {
+	// Source is either "electricitymaps" to download forecasts from the
+	// Electricity Maps API or "file" to read CSV files from Directory.
+	Source    string   `json:"source,omitempty"`
+	Token     string   `json:"token,omitempty"`
+	Directory string   `json:"directory,omitempty"`
+	Zones     []string `json:"zones,omitempty"`
+}
+
+func NewCarbonConfigFromEnv() *Carbon {
+	source, ok := os.LookupEnv("CARBON_SOURCE")
+	if !ok {
+		source = "file"
+	}
+
+	directory, ok := os.LookupEnv("CARBON_DIRECTORY")
+	if !ok {
+		directory = "data/carbon"
+	}
+
+	zones, ok := os.LookupEnv("CARBON_ZONES")
+	if !ok {
+		zones = "NL"
+	}
+
+	return &Carbon{
+		Source:    source,
+		Token:     os.Getenv("ELECTRICITYMAPS_TOKEN"),
+		Directory: directory,
+		Zones:     strings.Split(zones, ","),
+	}
+}
+
+type Weather struct {
+	// Source is either "openmeteo" to download forecasts from Open-Meteo or
+	// "file" to read a weather.csv from Directory.
+	Source    string `json:"source,omitempty"`
+	Directory string `json:"directory,omitempty"`
+}
+
+func NewWeatherConfigFromEnv() *Weather {
+	source, ok := os.LookupEnv("WEATHER_SOURCE")
+	if !ok {
+		source = "file"
+	}
+
+	directory, ok := os.LookupEnv("WEATHER_DIRECTORY")
+	if !ok {
+		directory = "data/weather"
+	}
+
+	return &Weather{
+		Source:    source,
+		Directory: directory,
+	}
+}
+
+type OpenADR struct {
+	// VtnID identifies the server to VENs, PollFreq is the poll interval it
+	// asks them to use.
+	VtnID    string        `json:"vtnId,omitempty"`
+	PollFreq time.Duration `json:"pollFreq,omitempty"`
+}
+
+func NewOpenADRConfigFromEnv() *OpenADR {
+	vtnID, ok := os.LookupEnv("OPENADR_VTN_ID")
+	if !ok {
+		vtnID = "v2g-vtn"
+	}
+
+	pollFreq := 10
+	if v, ok := os.LookupEnv("OPENADR_POLL_FREQ_SECONDS"); ok {
+		pollFreq = mustGetInt(v)
+	}
+
+	return &OpenADR{
+		VtnID:    vtnID,
+		PollFreq: time.Duration(pollFreq) * time.Second,
+	}
+}
+
+type Ven struct {
+	// VtnURL is the base URL of the aggregator's VTN the server takes part in
+	// as VEN, the client is disabled when it is empty. Token is sent as
+	// bearer token, PollFreq is used until the VTN asks for another one.
+	VtnURL   string        `json:"vtnUrl,omitempty"`
+	Token    string        `json:"token,omitempty"`
+	VenName  string        `json:"venName,omitempty"`
+	PollFreq time.Duration `json:"pollFreq,omitempty"`
+}
+
+func NewVenConfigFromEnv() *Ven {
+	venName, ok := os.LookupEnv("OPENADR_VEN_NAME")
+	if !ok {
+		venName = "v2g-server"
+	}
+
+	pollFreq := 30
+	if v, ok := os.LookupEnv("OPENADR_VEN_POLL_FREQ_SECONDS"); ok {
+		pollFreq = mustGetInt(v)
+	}
+
+	return &Ven{
+		VtnURL:   os.Getenv("OPENADR_VEN_VTN_URL"),
+		Token:    os.Getenv("OPENADR_VEN_TOKEN"),
+		VenName:  venName,
+		PollFreq: time.Duration(pollFreq) * time.Second,
+	}
+}
+
 func loadConfigFromFile(filePath string) (*Config, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -159,17 +308,66 @@ func loadConfigFromFile(filePath string) (*Config, error) {
 		config.Redis.Expire = config.Redis.Expire * time.Minute
 	}
 
+	if config.Prices == nil {
+		config.Prices = NewPricesConfigFromEnv()
+	}
+
+	if config.Carbon == nil {
+		config.Carbon = NewCarbonConfigFromEnv()
+	}
+
+	if config.Weather == nil {
+		config.Weather = NewWeatherConfigFromEnv()
+	}
+
+	if config.OpenADR == nil {
+		config.OpenADR = NewOpenADRConfigFromEnv()
+	} else {
+		config.OpenADR.PollFreq = config.OpenADR.PollFreq * time.Second
+	}
+
+	if config.Ven == nil {
+		config.Ven = NewVenConfigFromEnv()
+	} else {
+		config.Ven.PollFreq = config.Ven.PollFreq * time.Second
+	}
+
+	if config.Congestion == nil {
+		config.Congestion = NewCongestionConfigFromEnv()
+	}
+
 	return &config, nil
 }
 
+type Congestion struct {
+	// Operators are the usernames of the grid operator accounts that may
+	// manage congestion zones and their limits.
+	Operators []string `json:"operators,omitempty"`
+}
+
+func NewCongestionConfigFromEnv() *Congestion {
+	var operators []string
+	if v := os.Getenv("CONGESTION_OPERATORS"); v != "" {
+		operators = strings.Split(v, ",")
+	}
+
+	return &Congestion{Operators: operators}
+}
+
 func loadConfigFromEnv() *Config {
 	config := &Config{
-		Server:   NewServerConfigFromEnv(),
-		Logger:   NewLoggerConfig(),
-		Database: NewDatabaseConfigFromEnv(),
-		Redis:    NewRedisConfigFromEnv(),
-		Jwt:      NewJwtConfigFromEnv(),
-		Mqtt:     NewMqttConfigFromEnv(),
+		Server:     NewServerConfigFromEnv(),
+		Logger:     NewLoggerConfig(),
+		Database:   NewDatabaseConfigFromEnv(),
+		Redis:      NewRedisConfigFromEnv(),
+		Jwt:        NewJwtConfigFromEnv(),
+		Mqtt:       NewMqttConfigFromEnv(),
+		Prices:     NewPricesConfigFromEnv(),
+		Carbon:     NewCarbonConfigFromEnv(),
+		Weather:    NewWeatherConfigFromEnv(),
+		OpenADR:    NewOpenADRConfigFromEnv(),
+		Ven:        NewVenConfigFromEnv(),
+		Congestion: NewCongestionConfigFromEnv(),
 	}
 
 	return config
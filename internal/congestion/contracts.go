@@ -0,0 +1,365 @@
+package congestion
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"io"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxNameLength     = 100
+	maxPolygonPoints  = 1000
+	maxLimitDuration  = 366 * 24 * time.Hour
+	maxImportBytes    = 1 << 20
+	defaultListRange  = 7 * 24 * time.Hour
+	maxListRange      = 92 * 24 * time.Hour
+	postcodeDigitsLen = 4
+)
+
+var postcodeAreaPattern = regexp.MustCompile(`^[1-9][0-9]{3}$`)
+
+// PostcodeRange covers the postcodes from From up to and including To. Both
+// are either a full postcode or the four digits of an area, which covers all
+// of its postcodes.
+type PostcodeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Point is a corner of a zone polygon.
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ZoneRequest describes a congestion zone by postcode ranges, a polygon or
+// both. A site belongs to the zone when either matches.
+type ZoneRequest struct {
+	Name           string          `json:"name,omitempty"`
+	Operator       string          `json:"operator,omitempty"`
+	PostcodeRanges []PostcodeRange `json:"postcodeRanges,omitempty"`
+	Polygon        []Point         `json:"polygon,omitempty"`
+}
+
+// Validate checks the request and normalises the postcodes of its ranges.
+func (r *ZoneRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.Name == "" {
+		errs.Add("name", "Name is required")
+	} else if len(r.Name) > maxNameLength {
+		errs.Add("name", fmt.Sprintf("Name must be at most %d characters", maxNameLength))
+	}
+	if len(r.Operator) > maxNameLength {
+		errs.Add("operator", fmt.Sprintf("Operator must be at most %d characters", maxNameLength))
+	}
+
+	if len(r.PostcodeRanges) == 0 && len(r.Polygon) == 0 {
+		errs.Add("postcodeRanges", "A zone needs postcode ranges or a polygon")
+	}
+
+	for i := range r.PostcodeRanges {
+		pr := &r.PostcodeRanges[i]
+		from, fromOK := normalizeBound(pr.From)
+		to, toOK := normalizeBound(pr.To)
+		if !fromOK || !toOK {
+			errs.Add("postcodeRanges", "Postcode ranges must be bounded by postcodes such as 1234 AB or areas such as 1234")
+			break
+		}
+		if lowest(from) > highest(to) {
+			errs.Add("postcodeRanges", "Postcode ranges must start before they end")
+			break
+		}
+		pr.From, pr.To = from, to
+	}
+
+	if len(r.Polygon) > 0 && len(r.Polygon) < 3 {
+		errs.Add("polygon", "A polygon needs at least three points")
+	}
+	if len(r.Polygon) > maxPolygonPoints {
+		errs.Add("polygon", fmt.Sprintf("A polygon can have at most %d points", maxPolygonPoints))
+	}
+	for _, p := range r.Polygon {
+		if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+			errs.Add("polygon", "Points must have a latitude between -90 and 90 and a longitude between -180 and 180 degrees")
+			break
+		}
+	}
+
+	return errs
+}
+
+// LimitRequest limits what each connection in a zone may import or export
+// during a period. At least one direction must be limited.
+type LimitRequest struct {
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+	MaxImportKw *float64  `json:"maxImportKw,omitempty"`
+	MaxExportKw *float64  `json:"maxExportKw,omitempty"`
+	// Reference is the grid operator's identifier of the limitation.
+	Reference string `json:"reference,omitempty"`
+}
+
+func (r *LimitRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.StartsAt.IsZero() {
+		errs.Add("startsAt", "Start is required")
+	}
+	if !r.EndsAt.After(r.StartsAt) {
+		errs.Add("endsAt", "End must be after start")
+	} else if r.EndsAt.Sub(r.StartsAt) > maxLimitDuration {
+		errs.Add("endsAt", "A limit cannot last longer than a year")
+	}
+
+	if r.MaxImportKw == nil && r.MaxExportKw == nil {
+		errs.Add("maxImportKw", "Either the import or the export must be limited")
+	}
+	if r.MaxImportKw != nil && *r.MaxImportKw < 0 {
+		errs.Add("maxImportKw", "Maximum import cannot be negative")
+	}
+	if r.MaxExportKw != nil && *r.MaxExportKw < 0 {
+		errs.Add("maxExportKw", "Maximum export cannot be negative")
+	}
+
+	if len(r.Reference) > maxNameLength {
+		errs.Add("reference", fmt.Sprintf("Reference must be at most %d characters", maxNameLength))
+	}
+
+	return errs
+}
+
+// ParseLimitsCSV reads limits from a CSV file with a header row. The start
+// and end columns hold RFC 3339 timestamps, max_import_kw and max_export_kw
+// may be left empty for a direction that is not limited. The reference
+// column is optional.
+func ParseLimitsCSV(r io.Reader) ([]LimitRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"start", "end", "max_import_kw", "max_export_kw"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("CSV is missing the %s column", name)
+		}
+	}
+
+	var limits []LimitRequest
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var req LimitRequest
+		if req.StartsAt, err = time.Parse(time.RFC3339, record[cols["start"]]); err != nil {
+			return nil, fmt.Errorf("line %d: start must be an RFC 3339 timestamp", line)
+		}
+		if req.EndsAt, err = time.Parse(time.RFC3339, record[cols["end"]]); err != nil {
+			return nil, fmt.Errorf("line %d: end must be an RFC 3339 timestamp", line)
+		}
+
+		for name, dst := range map[string]**float64{"max_import_kw": &req.MaxImportKw, "max_export_kw": &req.MaxExportKw} {
+			v := record[cols[name]]
+			if v == "" {
+				continue
+			}
+
+			kw, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s must be a number", line, name)
+			}
+			*dst = &kw
+		}
+
+		if col, ok := cols["reference"]; ok {
+			req.Reference = record[col]
+		}
+
+		if errs := req.Validate(); len(errs) > 0 {
+			return nil, fmt.Errorf("line %d: %s", line, strings.Join(slices.Sorted(maps.Values(errs)), ", "))
+		}
+
+		limits = append(limits, req)
+	}
+
+	if len(limits) == 0 {
+		return nil, errors.New("CSV contains no limits")
+	}
+
+	return limits, nil
+}
+
+type ListLimitsRequest struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseListLimitsRequest reads the from and to query parameters, they
+// default to the coming week.
+func ParseListLimitsRequest(q url.Values, now time.Time) ListLimitsRequest {
+	req := ListLimitsRequest{
+		From:      now.UTC(),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	req.To = req.From.Add(defaultListRange)
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	return req
+}
+
+func (r *ListLimitsRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxListRange {
+			errs.Add("to", "The requested range cannot exceed 92 days")
+		}
+	}
+
+	return errs
+}
+
+type ZoneResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	Name           string          `json:"name"`
+	Operator       string          `json:"operator,omitempty"`
+	PostcodeRanges []PostcodeRange `json:"postcodeRanges"`
+	Polygon        []Point         `json:"polygon,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+}
+
+func NewZoneResponse(z *Zone) *ZoneResponse {
+	return &ZoneResponse{
+		ID:             z.ID,
+		Name:           z.Name,
+		Operator:       z.Operator,
+		PostcodeRanges: z.PostcodeRanges,
+		Polygon:        z.Polygon,
+		CreatedAt:      z.CreatedAt,
+		UpdatedAt:      z.UpdatedAt,
+	}
+}
+
+type LimitResponse struct {
+	ID          uuid.UUID `json:"id"`
+	ZoneID      uuid.UUID `json:"zoneId"`
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+	MaxImportKw *float64  `json:"maxImportKw,omitempty"`
+	MaxExportKw *float64  `json:"maxExportKw,omitempty"`
+	Reference   string    `json:"reference,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func NewLimitResponse(l *repository.CongestionLimit) *LimitResponse {
+	return &LimitResponse{
+		ID:          l.ID,
+		ZoneID:      l.ZoneID,
+		StartsAt:    l.StartsAt,
+		EndsAt:      l.EndsAt,
+		MaxImportKw: optional(l.MaxImportKw),
+		MaxExportKw: optional(l.MaxExportKw),
+		Reference:   l.Reference,
+		UpdatedAt:   l.UpdatedAt,
+	}
+}
+
+// SiteCongestionResponse is a zone a site belongs to with its limits.
+type SiteCongestionResponse struct {
+	Zone   *ZoneResponse    `json:"zone"`
+	Limits []*LimitResponse `json:"limits"`
+}
+
+// ImportResponse reports how many limits a CSV import stored.
+type ImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+// normalizeBound writes a range bound as a postcode or area.
+func normalizeBound(v string) (string, bool) {
+	bound := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+	if postcodeAreaPattern.MatchString(bound) {
+		return bound, true
+	}
+
+	return site.NormalizePostcode(bound)
+}
+
+// lowest and highest are the first and last postcode a bound covers.
+func lowest(bound string) string {
+	if len(bound) == postcodeDigitsLen {
+		return bound + "AA"
+	}
+
+	return bound
+}
+
+func highest(bound string) string {
+	if len(bound) == postcodeDigitsLen {
+		return bound + "ZZ"
+	}
+
+	return bound
+}
+
+func optional(v pgtype.Float8) *float64 {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.Float64
+}
+
+func nullable(v *float64) pgtype.Float8 {
+	if v == nil {
+		return pgtype.Float8{}
+	}
+
+	return pgtype.Float8{Float64: *v, Valid: true}
+}
@@ -0,0 +1,233 @@
+package congestion
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateZoneHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req ZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	z, err := h.svc.CreateZone(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, NewZoneResponse(z))
+	return nil
+}
+
+func (h *Handler) ListZonesHandler(w http.ResponseWriter, r *http.Request) error {
+	zones, err := h.svc.ListZones(r.Context())
+	if err != nil {
+		return err
+	}
+
+	res := make([]*ZoneResponse, 0, len(zones))
+	for _, z := range zones {
+		res = append(res, NewZoneResponse(z))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetZoneHandler(w http.ResponseWriter, r *http.Request) error {
+	zoneID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	z, err := h.svc.GetZone(r.Context(), zoneID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewZoneResponse(z))
+	return nil
+}
+
+func (h *Handler) UpdateZoneHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	zoneID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req ZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	z, err := h.svc.UpdateZone(ctx, identityID, zoneID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewZoneResponse(z))
+	return nil
+}
+
+func (h *Handler) DeleteZoneHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	zoneID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.DeleteZone(ctx, identityID, zoneID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) CreateLimitHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	zoneID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req LimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	l, err := h.svc.CreateLimit(ctx, identityID, zoneID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, NewLimitResponse(l))
+	return nil
+}
+
+// ImportLimitsHandler stores the limits of a CSV request body, see
+// ParseLimitsCSV for its columns.
+func (h *Handler) ImportLimitsHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	zoneID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	n, err := h.svc.ImportLimits(ctx, identityID, zoneID, http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, &ImportResponse{Imported: n})
+	return nil
+}
+
+// ListLimitsHandler returns the limits of a zone between the from and to
+// query parameters.
+func (h *Handler) ListLimitsHandler(w http.ResponseWriter, r *http.Request) error {
+	zoneID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	limits, err := h.svc.ListLimits(r.Context(), zoneID, ParseListLimitsRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	res := make([]*LimitResponse, 0, len(limits))
+	for i := range limits {
+		res = append(res, NewLimitResponse(&limits[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) DeleteLimitHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	zoneID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	limitID, err := httpx.URLParamUUID(r, "limitId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.DeleteLimit(ctx, identityID, zoneID, limitID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+// SiteCongestionHandler returns the zones a site lies in with their limits
+// between the from and to query parameters.
+func (h *Handler) SiteCongestionHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	res, err := h.svc.SiteCongestion(ctx, identityID, siteID, ParseListLimitsRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
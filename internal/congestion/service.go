@@ -0,0 +1,539 @@
+// Package congestion keeps the congestion zones of grid operators and the
+// limits they impose on the connections in them. The limits lower the
+// capacity the scheduler, the site balancer and the flexibility forecasts
+// work with, and owners are alerted when a limit keeps their vehicle from
+// reaching its departure target.
+package congestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/internal/event"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+)
+
+// socTolerance is how far below its target the planner still considers a
+// vehicle to have reached it, in percent.
+const socTolerance = 0.01
+
+type Service struct {
+	db        *pgxpool.Pool
+	queries   *repository.Queries
+	sites     *site.Service
+	vehicles  *vehicle.Service
+	events    *event.Service
+	operators map[string]bool
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, sites *site.Service, vehicles *vehicle.Service, events *event.Service, cfg *config.Congestion) *Service {
+	operators := make(map[string]bool, len(cfg.Operators))
+	for _, username := range cfg.Operators {
+		operators[username] = true
+	}
+
+	return &Service{db: db, queries: queries, sites: sites, vehicles: vehicles, events: events, operators: operators}
+}
+
+func (s *Service) CreateZone(ctx context.Context, identityID uuid.UUID, req ZoneRequest) (*Zone, error) {
+	if err := s.authorizeOperator(ctx, identityID); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	ranges, polygon, err := encodeArea(&req)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to encode zone", err)
+	}
+
+	z, err := s.queries.CreateCongestionZone(ctx, repository.CreateCongestionZoneParams{
+		ID:             uuid.New(),
+		Name:           req.Name,
+		Operator:       req.Operator,
+		PostcodeRanges: ranges,
+		Polygon:        polygon,
+		CreatedBy:      pgtype.UUID{Bytes: identityID, Valid: true},
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to store zone", err)
+	}
+
+	return s.decode(ctx, &z)
+}
+
+// ListZones returns all congestion zones, they are public information.
+func (s *Service) ListZones(ctx context.Context) ([]*Zone, error) {
+	zones, err := s.zones(ctx)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve zones", err)
+	}
+
+	return zones, nil
+}
+
+func (s *Service) GetZone(ctx context.Context, zoneID uuid.UUID) (*Zone, error) {
+	z, err := s.queries.GetCongestionZoneById(ctx, zoneID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Zone could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve zone", err)
+	}
+
+	return s.decode(ctx, &z)
+}
+
+func (s *Service) UpdateZone(ctx context.Context, identityID, zoneID uuid.UUID, req ZoneRequest) (*Zone, error) {
+	if err := s.authorizeOperator(ctx, identityID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetZone(ctx, zoneID); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	ranges, polygon, err := encodeArea(&req)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to encode zone", err)
+	}
+
+	z, err := s.queries.UpdateCongestionZone(ctx, repository.UpdateCongestionZoneParams{
+		ID:             zoneID,
+		Name:           req.Name,
+		Operator:       req.Operator,
+		PostcodeRanges: ranges,
+		Polygon:        polygon,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to update zone", err)
+	}
+
+	return s.decode(ctx, &z)
+}
+
+func (s *Service) DeleteZone(ctx context.Context, identityID, zoneID uuid.UUID) error {
+	if err := s.authorizeOperator(ctx, identityID); err != nil {
+		return err
+	}
+
+	if _, err := s.GetZone(ctx, zoneID); err != nil {
+		return err
+	}
+
+	if err := s.queries.DeleteCongestionZone(ctx, zoneID); err != nil {
+		return httpx.InternalErr(ctx, "Failed to delete zone", err)
+	}
+
+	return nil
+}
+
+// CreateLimit stores a limit of a zone. A limit that starts at the same time
+// is replaced, so grid operators can correct their announcements.
+func (s *Service) CreateLimit(ctx context.Context, identityID, zoneID uuid.UUID, req LimitRequest) (*repository.CongestionLimit, error) {
+	if err := s.authorizeOperator(ctx, identityID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetZone(ctx, zoneID); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	l, err := s.queries.UpsertCongestionLimit(ctx, limitParams(zoneID, &req))
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to store limit", err)
+	}
+
+	return &l, nil
+}
+
+// ImportLimits stores the limits of a CSV file as described by
+// ParseLimitsCSV. Either all limits are stored or none.
+func (s *Service) ImportLimits(ctx context.Context, identityID, zoneID uuid.UUID, r io.Reader) (int, error) {
+	if err := s.authorizeOperator(ctx, identityID); err != nil {
+		return 0, err
+	}
+
+	if _, err := s.GetZone(ctx, zoneID); err != nil {
+		return 0, err
+	}
+
+	limits, err := ParseLimitsCSV(r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return 0, httpx.BadRequest(ctx, "CSV file is too large")
+		}
+
+		return 0, httpx.BadRequest(ctx, "Could not parse CSV: "+err.Error())
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	for i := range limits {
+		if _, err := qtx.UpsertCongestionLimit(ctx, limitParams(zoneID, &limits[i])); err != nil {
+			return 0, httpx.InternalErr(ctx, "Failed to store limit", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	return len(limits), nil
+}
+
+func (s *Service) ListLimits(ctx context.Context, zoneID uuid.UUID, req ListLimitsRequest) ([]repository.CongestionLimit, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	if _, err := s.GetZone(ctx, zoneID); err != nil {
+		return nil, err
+	}
+
+	limits, err := s.queries.ListCongestionLimitsByZoneId(ctx, repository.ListCongestionLimitsByZoneIdParams{
+		ZoneID:       zoneID,
+		EndsAfter:    req.From,
+		StartsBefore: req.To,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve limits", err)
+	}
+
+	return limits, nil
+}
+
+func (s *Service) DeleteLimit(ctx context.Context, identityID, zoneID, limitID uuid.UUID) error {
+	if err := s.authorizeOperator(ctx, identityID); err != nil {
+		return err
+	}
+
+	n, err := s.queries.DeleteCongestionLimit(ctx, repository.DeleteCongestionLimitParams{
+		ID:     limitID,
+		ZoneID: zoneID,
+	})
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to delete limit", err)
+	}
+	if n == 0 {
+		return httpx.NotFound(ctx, "Limit could not be found")
+	}
+
+	return nil
+}
+
+// SiteCongestion returns the zones a site of the caller lies in with their
+// limits in the requested range.
+func (s *Service) SiteCongestion(ctx context.Context, identityID, siteID uuid.UUID, req ListLimitsRequest) ([]*SiteCongestionResponse, error) {
+	st, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	zones, err := s.zones(ctx)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve zones", err)
+	}
+
+	res := []*SiteCongestionResponse{}
+	for _, z := range zones {
+		if !z.Contains(st) {
+			continue
+		}
+
+		limits, err := s.queries.ListCongestionLimitsByZoneId(ctx, repository.ListCongestionLimitsByZoneIdParams{
+			ZoneID:       z.ID,
+			EndsAfter:    req.From,
+			StartsBefore: req.To,
+		})
+		if err != nil {
+			return nil, httpx.InternalErr(ctx, "Failed to retrieve limits", err)
+		}
+
+		sc := &SiteCongestionResponse{Zone: NewZoneResponse(z), Limits: make([]*LimitResponse, 0, len(limits))}
+		for i := range limits {
+			sc.Limits = append(sc.Limits, NewLimitResponse(&limits[i]))
+		}
+		res = append(res, sc)
+	}
+
+	return res, nil
+}
+
+// Caps implements site.CongestionSource with the limits of every zone the
+// site lies in.
+func (s *Service) Caps(ctx context.Context, st *repository.Site, from, to time.Time) ([]site.CongestionCap, error) {
+	limits, err := s.siteLimits(ctx, st, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCaps(limits), nil
+}
+
+func toCaps(limits []repository.CongestionLimit) []site.CongestionCap {
+	caps := make([]site.CongestionCap, 0, len(limits))
+	for _, l := range limits {
+		c := site.CongestionCap{
+			Start:       l.StartsAt,
+			End:         l.EndsAt,
+			MaxImportKw: math.Inf(1),
+			MaxExportKw: math.Inf(1),
+		}
+		if l.MaxImportKw.Valid {
+			c.MaxImportKw = l.MaxImportKw.Float64
+		}
+		if l.MaxExportKw.Valid {
+			c.MaxExportKw = l.MaxExportKw.Float64
+		}
+		caps = append(caps, c)
+	}
+
+	return caps
+}
+
+// CheckSchedule alerts the owner of a vehicle whose new schedule misses its
+// target because of import limits below the capacity of its connection. A
+// vehicle that could not reach its target at the full capacity either, such
+// as one plugged in too late, is not reported. Each limit is reported once
+// per vehicle.
+func (s *Service) CheckSchedule(ctx context.Context, v *repository.Vehicle, sched *repository.ChargingSchedule) {
+	if sched.TargetReached || !v.ChargerID.Valid {
+		return
+	}
+
+	st, err := s.queries.GetSiteByDeviceId(ctx, v.ChargerID.Bytes)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "Failed to retrieve site of charger", "vehicle.id", v.ID, "error", err)
+		}
+		return
+	}
+
+	limits, err := s.siteLimits(ctx, &st, sched.HorizonStart, sched.HorizonEnd)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve congestion limits", "site.id", st.ID, "error", err)
+		return
+	}
+	if len(limits) == 0 {
+		return
+	}
+
+	prefs, err := s.vehicles.LoadPreferences(ctx, v.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve vehicle preferences", "vehicle.id", v.ID, "error", err)
+		return
+	}
+
+	// Solar schedules only have to bring the vehicle to its minimum.
+	targetSoc := prefs.TargetSoc
+	if sched.Reason == scheduling.ReasonSolar {
+		targetSoc = prefs.MinSoc
+	}
+	if !capsMissTarget(v, &st, toCaps(limits), sched, targetSoc) {
+		return
+	}
+
+	deviceID := uuid.UUID(v.ChargerID.Bytes)
+	for _, l := range limits {
+		if !l.MaxImportKw.Valid || l.MaxImportKw.Float64 >= site.MaxPowerKw(&st) {
+			continue
+		}
+
+		n, err := s.queries.CreateCongestionAlert(ctx, repository.CreateCongestionAlertParams{
+			LimitID:   l.ID,
+			VehicleID: v.ID,
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to store congestion alert", "vehicle.id", v.ID, "error", err)
+			continue
+		}
+		if n == 0 {
+			continue
+		}
+
+		s.events.Record(ctx, event.Event{
+			Type:      event.TypeCongestionTargetAtRisk,
+			DeviceID:  &deviceID,
+			VehicleID: &v.ID,
+			Payload: map[string]any{
+				"zoneId":      l.ZoneID,
+				"limitId":     l.ID,
+				"startsAt":    l.StartsAt,
+				"endsAt":      l.EndsAt,
+				"maxImportKw": l.MaxImportKw.Float64,
+				"departureAt": sched.HorizonEnd,
+				"finalSoc":    sched.FinalSoc,
+			},
+		})
+	}
+}
+
+// capsMissTarget reports whether the vehicle can reach targetSoc within the
+// schedule at the capacity of the connection but not within the caps.
+func capsMissTarget(v *repository.Vehicle, st *repository.Site, caps []site.CongestionCap, sched *repository.ChargingSchedule, targetSoc float64) bool {
+	neededKwh := (targetSoc - sched.InitialSoc - socTolerance) / 100 * v.BatteryCapacityKwh
+	if neededKwh <= 0 {
+		return false
+	}
+
+	limitKw := site.MaxPowerKw(st)
+	hours := scheduling.Resolution.Hours()
+	uncappedKwh, cappedKwh := 0.0, 0.0
+	for t := sched.HorizonStart; t.Before(sched.HorizonEnd); t = t.Add(scheduling.Resolution) {
+		importKw, _ := site.CapsDuring(caps, t, t.Add(scheduling.Resolution), limitKw)
+		uncappedKwh += math.Min(v.MaxChargeKw, limitKw) * hours * scheduling.DefaultEfficiency
+		cappedKwh += math.Min(v.MaxChargeKw, math.Max(0, importKw)) * hours * scheduling.DefaultEfficiency
+	}
+
+	return uncappedKwh >= neededKwh && cappedKwh < neededKwh
+}
+
+// siteLimits returns the limits of the zones the site lies in that overlap
+// [from, to).
+func (s *Service) siteLimits(ctx context.Context, st *repository.Site, from, to time.Time) ([]repository.CongestionLimit, error) {
+	zones, err := s.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits []repository.CongestionLimit
+	for _, z := range zones {
+		if !z.Contains(st) {
+			continue
+		}
+
+		zoneLimits, err := s.queries.ListCongestionLimitsByZoneId(ctx, repository.ListCongestionLimitsByZoneIdParams{
+			ZoneID:       z.ID,
+			EndsAfter:    from,
+			StartsBefore: to,
+		})
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, zoneLimits...)
+	}
+
+	return limits, nil
+}
+
+func (s *Service) zones(ctx context.Context) ([]*Zone, error) {
+	rows, err := s.queries.ListCongestionZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]*Zone, 0, len(rows))
+	for i := range rows {
+		z, err := newZone(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, z)
+	}
+
+	return zones, nil
+}
+
+func (s *Service) decode(ctx context.Context, z *repository.CongestionZone) (*Zone, error) {
+	zone, err := newZone(z)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to decode zone", err)
+	}
+
+	return zone, nil
+}
+
+// authorizeOperator only lets the configured grid operator accounts manage
+// zones, their limits apply to the sites of all users.
+func (s *Service) authorizeOperator(ctx context.Context, identityID uuid.UUID) error {
+	u, err := s.queries.GetUserById(ctx, identityID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return httpx.Forbidden(ctx, "Only grid operators can manage congestion zones")
+		}
+
+		return httpx.InternalErr(ctx, "Failed to retrieve user", err)
+	}
+
+	if !s.operators[u.Username] {
+		return httpx.Forbidden(ctx, "Only grid operators can manage congestion zones")
+	}
+
+	return nil
+}
+
+func encodeArea(req *ZoneRequest) ([]byte, []byte, error) {
+	ranges := req.PostcodeRanges
+	if ranges == nil {
+		ranges = []PostcodeRange{}
+	}
+
+	rawRanges, err := json.Marshal(ranges)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(req.Polygon) == 0 {
+		return rawRanges, nil, nil
+	}
+
+	rawPolygon, err := json.Marshal(req.Polygon)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rawRanges, rawPolygon, nil
+}
+
+func limitParams(zoneID uuid.UUID, req *LimitRequest) repository.UpsertCongestionLimitParams {
+	return repository.UpsertCongestionLimitParams{
+		ID:          uuid.New(),
+		ZoneID:      zoneID,
+		StartsAt:    req.StartsAt.UTC(),
+		EndsAt:      req.EndsAt.UTC(),
+		MaxImportKw: nullable(req.MaxImportKw),
+		MaxExportKw: nullable(req.MaxExportKw),
+		Reference:   req.Reference,
+	}
+}
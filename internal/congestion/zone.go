@@ -0,0 +1,64 @@
+package congestion
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+)
+
+// Zone is a stored congestion zone with its decoded area.
+type Zone struct {
+	repository.CongestionZone
+	PostcodeRanges []PostcodeRange
+	Polygon        []Point
+}
+
+func newZone(z *repository.CongestionZone) (*Zone, error) {
+	zone := &Zone{CongestionZone: *z}
+	if err := json.Unmarshal(z.PostcodeRanges, &zone.PostcodeRanges); err != nil {
+		return nil, err
+	}
+	if len(z.Polygon) > 0 {
+		if err := json.Unmarshal(z.Polygon, &zone.Polygon); err != nil {
+			return nil, err
+		}
+	}
+
+	return zone, nil
+}
+
+// Contains tells whether the site lies in the zone, either by its postcode or
+// by its location.
+func (z *Zone) Contains(s *repository.Site) bool {
+	if s.Postcode != "" {
+		for _, pr := range z.PostcodeRanges {
+			if s.Postcode >= lowest(pr.From) && s.Postcode <= highest(pr.To) {
+				return true
+			}
+		}
+	}
+
+	if len(z.Polygon) >= 3 && s.Latitude.Valid && s.Longitude.Valid {
+		return inPolygon(z.Polygon, Point{Latitude: s.Latitude.Float64, Longitude: s.Longitude.Float64})
+	}
+
+	return false
+}
+
+// inPolygon casts a ray from p towards increasing longitude and counts the
+// edges it crosses. Zones are small enough to treat coordinates as planar.
+func inPolygon(polygon []Point, p Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Latitude > p.Latitude) == (b.Latitude > p.Latitude) {
+			continue
+		}
+
+		crossing := a.Longitude + (p.Latitude-a.Latitude)*(b.Longitude-a.Longitude)/(b.Latitude-a.Latitude)
+		if p.Longitude < crossing {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
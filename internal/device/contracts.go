@@ -0,0 +1,90 @@
+package device
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	KindGateway  = "gateway"
+	KindCharger  = "charger"
+	KindMeter    = "meter"
+	KindInverter = "inverter"
+)
+
+var kinds = map[string]bool{
+	KindGateway:  true,
+	KindCharger:  true,
+	KindMeter:    true,
+	KindInverter: true,
+}
+
+type CreateDeviceRequest struct {
+	SerialNumber string `json:"serialNumber,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Kind         string `json:"kind,omitempty"`
+}
+
+func (r *CreateDeviceRequest) Validate() bool {
+	return r.SerialNumber != "" && r.Name != "" && kinds[r.Kind]
+}
+
+func (r *CreateDeviceRequest) ToCreateDeviceParams(ownerID uuid.UUID, ocppPasswordHash []byte) repository.CreateDeviceParams {
+	return repository.CreateDeviceParams{
+		ID:               uuid.New(),
+		OwnerID:          ownerID,
+		SerialNumber:     r.SerialNumber,
+		Name:             r.Name,
+		Kind:             r.Kind,
+		OcppPasswordHash: ocppPasswordHash,
+	}
+}
+
+type DeviceResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	SerialNumber string     `json:"serialNumber"`
+	Name         string     `json:"name"`
+	Kind         string     `json:"kind"`
+	SiteID       *uuid.UUID `json:"siteId,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	// OcppPassword is the password a charger authenticates its OCPP
+	// connection with, it is only returned when it is generated.
+	OcppPassword string `json:"ocppPassword,omitempty"`
+}
+
+func NewDeviceResponse(d *repository.Device) *DeviceResponse {
+	res := &DeviceResponse{
+		ID:           d.ID,
+		SerialNumber: d.SerialNumber,
+		Name:         d.Name,
+		Kind:         d.Kind,
+		CreatedAt:    d.CreatedAt,
+	}
+
+	if d.SiteID.Valid {
+		id := uuid.UUID(d.SiteID.Bytes)
+		res.SiteID = &id
+	}
+
+	return res
+}
+
+// HashOcppPassword returns the hash of an OCPP password as it is stored, the
+// passwords are random so a plain hash is enough.
+func HashOcppPassword(password string) []byte {
+	sum := sha256.Sum256([]byte(password))
+	return sum[:]
+}
+
+// CheckOcppPassword reports whether the password is the one of the charger.
+// Chargers without a password cannot authenticate.
+func CheckOcppPassword(d *repository.Device, password string) bool {
+	if len(d.OcppPasswordHash) == 0 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(d.OcppPasswordHash, HashOcppPassword(password)) == 1
+}
@@ -0,0 +1,104 @@
+package device
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req CreateDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	d, password, err := h.svc.Create(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	res := NewDeviceResponse(d)
+	res.OcppPassword = password
+	httpx.ResponseWithJSON(w, http.StatusCreated, res)
+	return nil
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	devices, err := h.svc.List(ctx, identityID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*DeviceResponse, 0, len(devices))
+	for i := range devices {
+		res = append(res, NewDeviceResponse(&devices[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	d, err := h.svc.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewDeviceResponse(d))
+	return nil
+}
+
+func (h *Handler) ResetOcppPasswordHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	d, password, err := h.svc.ResetOcppPassword(ctx, identityID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	res := NewDeviceResponse(d)
+	res.OcppPassword = password
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
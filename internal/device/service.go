@@ -0,0 +1,135 @@
+package device
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ocppPasswordBytes makes passwords of 40 hex characters, the longest OCPP
+// allows.
+const ocppPasswordBytes = 20
+
+// PasswordListener is notified after the OCPP password of a charger changed.
+type PasswordListener func(deviceID uuid.UUID)
+
+type Service struct {
+	queries   *repository.Queries
+	listeners []PasswordListener
+}
+
+func NewService(queries *repository.Queries) *Service {
+	return &Service{queries: queries}
+}
+
+// OnPasswordReset registers a listener for OCPP password resets, which the
+// charge point server uses to drop connections made with the old password.
+func (s *Service) OnPasswordReset(l PasswordListener) {
+	s.listeners = append(s.listeners, l)
+}
+
+// Create registers a device. Chargers get the password they authenticate
+// their OCPP connection with, which is returned once and only its hash is
+// stored.
+func (s *Service) Create(ctx context.Context, ownerID uuid.UUID, req CreateDeviceRequest) (*repository.Device, string, error) {
+	if !req.Validate() {
+		return nil, "", httpx.BadRequest(ctx, "Serial number, name and a kind of gateway, charger, meter or inverter are required")
+	}
+
+	var password string
+	var hash []byte
+	if req.Kind == KindCharger {
+		var err error
+		if password, err = newOcppPassword(); err != nil {
+			return nil, "", httpx.InternalErr(ctx, "Could not generate OCPP password", err)
+		}
+		hash = HashOcppPassword(password)
+	}
+
+	d, err := s.queries.CreateDevice(ctx, req.ToCreateDeviceParams(ownerID, hash))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, "", httpx.Conflict(ctx, "Device with this serial number is already registered")
+		}
+
+		return nil, "", httpx.InternalErr(ctx, "Failed to register device", err)
+	}
+
+	return &d, password, nil
+}
+
+// ResetOcppPassword replaces the password of a charger. Its live connection
+// is closed, so it has to authenticate with the new one when it reconnects.
+func (s *Service) ResetOcppPassword(ctx context.Context, identityID, deviceID uuid.UUID) (*repository.Device, string, error) {
+	d, err := s.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if d.Kind != KindCharger {
+		return nil, "", httpx.BadRequest(ctx, "Only chargers connect over OCPP")
+	}
+
+	password, err := newOcppPassword()
+	if err != nil {
+		return nil, "", httpx.InternalErr(ctx, "Could not generate OCPP password", err)
+	}
+
+	if err := s.queries.SetDeviceOcppPasswordHash(ctx, repository.SetDeviceOcppPasswordHashParams{
+		ID:               d.ID,
+		OcppPasswordHash: HashOcppPassword(password),
+	}); err != nil {
+		return nil, "", httpx.InternalErr(ctx, "Failed to update OCPP password", err)
+	}
+
+	for _, l := range s.listeners {
+		l(d.ID)
+	}
+
+	return d, password, nil
+}
+
+func (s *Service) List(ctx context.Context, ownerID uuid.UUID) ([]repository.Device, error) {
+	devices, err := s.queries.ListDevicesByOwnerId(ctx, ownerID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve devices", err)
+	}
+
+	return devices, nil
+}
+
+// GetOwned returns the device only when it belongs to the given identity, so
+// that callers can rely on it for authorisation of device scoped endpoints.
+func (s *Service) GetOwned(ctx context.Context, identityID, deviceID uuid.UUID) (*repository.Device, error) {
+	d, err := s.queries.GetDeviceById(ctx, deviceID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Device could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve device", err)
+	}
+
+	if d.OwnerID != identityID {
+		return nil, httpx.NotFound(ctx, "Device could not be found")
+	}
+
+	return &d, nil
+}
+
+func newOcppPassword() (string, error) {
+	raw := make([]byte, ocppPasswordBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,224 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/ven"
+	"github.com/V2G-Minor-Fontys/server/pkg/flexibility"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	SourceAPI     = "api"
+	SourceOpenADR = "openadr"
+)
+
+// Activation kinds, the same as the fleet-level targets of the VEN. A
+// setpoint asks the participating vehicles to draw TargetKw together,
+// negative to discharge, max_import caps what they draw.
+const (
+	KindSetpoint  = ven.TargetSetpoint
+	KindMaxImport = ven.TargetMaxImport
+)
+
+const (
+	StatusScheduled = "scheduled"
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	maxDurationMinutes = 24 * 60
+	listLimit          = 100
+	dispatchInterval   = 30 * time.Second
+	// syncHorizon is how far ahead targets of the VEN become activations.
+	syncHorizon = 24 * time.Hour
+	// settleTime is what a vehicle gets to reach its setpoint before a
+	// deviation counts as a shortfall.
+	settleTime = time.Minute
+	// minChangeKw avoids sending a new profile for every small change of a
+	// setpoint.
+	minChangeKw = 0.5
+	// readingStaleAfter ignores shadow readings older than this.
+	readingStaleAfter = 2 * time.Minute
+)
+
+// TargetSource supplies the fleet-level targets received from a VTN.
+type TargetSource interface {
+	Targets(ctx context.Context, from, to time.Time) ([]ven.Target, error)
+}
+
+// OfferSource tells what the vehicle of a session can deliver from now until
+// end, false when it cannot take part.
+type OfferSource interface {
+	Offer(ctx context.Context, cs *repository.ChargingSession, end, now time.Time) (flexibility.Offer, bool)
+}
+
+type ActivationRequest struct {
+	SiteID uuid.UUID `json:"siteId"`
+	// Kind defaults to a setpoint.
+	Kind     string  `json:"kind"`
+	TargetKw float64 `json:"targetKw"`
+	// StartsAt defaults to now.
+	StartsAt        *time.Time `json:"startsAt,omitempty"`
+	DurationMinutes int        `json:"durationMinutes"`
+}
+
+func (r *ActivationRequest) Validate(now time.Time) httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.SiteID == uuid.Nil {
+		errs.Add("siteId", "Site id is required")
+	}
+
+	switch r.Kind {
+	case KindSetpoint:
+	case KindMaxImport:
+		if r.TargetKw < 0 {
+			errs.Add("targetKw", "A maximum import cannot be negative")
+		}
+	default:
+		errs.Add("kind", fmt.Sprintf("Kind must be %s or %s", KindSetpoint, KindMaxImport))
+	}
+
+	if r.DurationMinutes < 1 || r.DurationMinutes > maxDurationMinutes {
+		errs.Add("durationMinutes", fmt.Sprintf("Duration must be between 1 and %d minutes", maxDurationMinutes))
+	}
+
+	if r.StartsAt != nil && r.StartsAt.Before(now.Add(-time.Minute)) {
+		errs.Add("startsAt", "Start cannot be in the past")
+	}
+
+	return errs
+}
+
+type OptOutRequest struct {
+	VehicleID uuid.UUID `json:"vehicleId"`
+}
+
+type ParticipantResponse struct {
+	ActivationID uuid.UUID  `json:"activationId"`
+	VehicleID    uuid.UUID  `json:"vehicleId"`
+	DeviceID     *uuid.UUID `json:"deviceId,omitempty"`
+	BaselineKw   float64    `json:"baselineKw"`
+	SetpointKw   float64    `json:"setpointKw"`
+	OptedOut     bool       `json:"optedOut"`
+	RequestedKwh float64    `json:"requestedKwh"`
+	DeliveredKwh float64    `json:"deliveredKwh"`
+	// Performance is the share of the requested energy that was delivered.
+	Performance  *float64  `json:"performance,omitempty"`
+	DispatchedAt time.Time `json:"dispatchedAt"`
+}
+
+func NewParticipantResponse(p *repository.ActivationParticipant) *ParticipantResponse {
+	res := &ParticipantResponse{
+		ActivationID: p.ActivationID,
+		VehicleID:    p.VehicleID,
+		BaselineKw:   p.BaselineKw,
+		SetpointKw:   p.SetpointKw,
+		OptedOut:     p.OptedOut,
+		RequestedKwh: p.RequestedKwh,
+		DeliveredKwh: p.DeliveredKwh,
+		Performance:  performance(p.RequestedKwh, p.DeliveredKwh),
+		DispatchedAt: p.DispatchedAt,
+	}
+
+	if p.DeviceID.Valid {
+		id := uuid.UUID(p.DeviceID.Bytes)
+		res.DeviceID = &id
+	}
+
+	return res
+}
+
+type MeasurementResponse struct {
+	MeasuredAt time.Time `json:"measuredAt"`
+	Vehicles   int32     `json:"vehicles"`
+	TargetKw   float64   `json:"targetKw"`
+	BaselineKw float64   `json:"baselineKw"`
+	SetpointKw float64   `json:"setpointKw"`
+	ActualKw   float64   `json:"actualKw"`
+}
+
+type ActivationResponse struct {
+	ID           uuid.UUID              `json:"id"`
+	Source       string                 `json:"source"`
+	SiteID       *uuid.UUID             `json:"siteId,omitempty"`
+	Kind         string                 `json:"kind"`
+	TargetKw     float64                `json:"targetKw"`
+	StartsAt     time.Time              `json:"startsAt"`
+	EndsAt       time.Time              `json:"endsAt"`
+	Status       string                 `json:"status"`
+	RequestedKwh float64                `json:"requestedKwh"`
+	DeliveredKwh float64                `json:"deliveredKwh"`
+	Performance  *float64               `json:"performance,omitempty"`
+	Participants []*ParticipantResponse `json:"participants,omitempty"`
+	Measurements []*MeasurementResponse `json:"measurements,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+}
+
+func NewActivationResponse(a *repository.Activation) *ActivationResponse {
+	res := &ActivationResponse{
+		ID:           a.ID,
+		Source:       a.Source,
+		Kind:         a.Kind,
+		TargetKw:     a.TargetKw,
+		StartsAt:     a.StartsAt,
+		EndsAt:       a.EndsAt,
+		Status:       a.Status,
+		RequestedKwh: a.RequestedKwh,
+		DeliveredKwh: a.DeliveredKwh,
+		Performance:  performance(a.RequestedKwh, a.DeliveredKwh),
+		CreatedAt:    a.CreatedAt,
+	}
+
+	if a.SiteID.Valid {
+		id := uuid.UUID(a.SiteID.Bytes)
+		res.SiteID = &id
+	}
+
+	return res
+}
+
+// NewActivationDetailResponse adds the participants and measurements to the
+// activation.
+func NewActivationDetailResponse(d *ActivationDetail) *ActivationResponse {
+	res := NewActivationResponse(&d.Activation)
+	res.Participants = make([]*ParticipantResponse, 0, len(d.Participants))
+	for i := range d.Participants {
+		res.Participants = append(res.Participants, NewParticipantResponse(&d.Participants[i]))
+	}
+
+	for _, m := range d.Measurements {
+		res.Measurements = append(res.Measurements, &MeasurementResponse{
+			MeasuredAt: m.MeasuredAt,
+			Vehicles:   m.Vehicles,
+			TargetKw:   m.TargetKw,
+			BaselineKw: m.BaselineKw,
+			SetpointKw: m.SetpointKw,
+			ActualKw:   m.ActualKw,
+		})
+	}
+
+	return res
+}
+
+// ActivationDetail is an activation with the participants and measurements
+// the caller may see.
+type ActivationDetail struct {
+	Activation   repository.Activation
+	Participants []repository.ActivationParticipant
+	Measurements []repository.ActivationMeasurement
+}
+
+func performance(requestedKwh, deliveredKwh float64) *float64 {
+	if requestedKwh <= 0 {
+		return nil
+	}
+
+	p := deliveredKwh / requestedKwh
+	return &p
+}
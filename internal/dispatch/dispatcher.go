@@ -0,0 +1,502 @@
+package dispatch
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/chargingprofile"
+	"github.com/V2G-Minor-Fontys/server/internal/event"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/flexibility"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const (
+	// A participant is on target within toleranceKw or toleranceShare of
+	// what it was asked to deviate from its baseline, whichever is larger.
+	toleranceKw    = 0.5
+	toleranceShare = 0.1
+)
+
+// member is a vehicle that takes part in an activation during this round.
+type member struct {
+	vehicleID uuid.UUID
+	deviceID  uuid.UUID
+	offer     flexibility.Offer
+	// actualKw is what the charger draws, known is false without a recent
+	// reading.
+	actualKw float64
+	known    bool
+	// lagging members missed their setpoint and are held at what they draw.
+	lagging bool
+}
+
+// Run dispatches the activations that are due until the context is done.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.step(ctx, now.UTC()); err != nil {
+				slog.ErrorContext(ctx, "Failed to dispatch activations", "error", err)
+			}
+		}
+	}
+}
+
+// step takes over the targets of the VEN, completes activations that ended
+// and dispatches the running ones. A vehicle takes part in one activation at
+// a time, the one created first.
+func (s *Service) step(ctx context.Context, now time.Time) error {
+	if s.targets != nil {
+		if err := s.sync(ctx, now); err != nil {
+			slog.WarnContext(ctx, "Failed to synchronise flexibility targets", "error", err)
+		}
+	}
+
+	due, err := s.queries.ListDueActivations(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	running := map[uuid.UUID]bool{}
+	claimed := map[uuid.UUID]bool{}
+	for i := range due {
+		a := &due[i]
+		if !now.Before(a.EndsAt) {
+			if err := s.complete(ctx, a, now); err != nil {
+				slog.WarnContext(ctx, "Failed to complete activation", "activation.id", a.ID, "error", err)
+			}
+			continue
+		}
+
+		if a.Status == StatusScheduled {
+			if err := s.queries.SetActivationStatus(ctx, repository.SetActivationStatusParams{
+				ID:     a.ID,
+				Status: StatusActive,
+			}); err != nil {
+				slog.WarnContext(ctx, "Failed to start activation", "activation.id", a.ID, "error", err)
+				continue
+			}
+		}
+
+		running[a.ID] = true
+		if err := s.dispatch(ctx, a, claimed, now); err != nil {
+			slog.WarnContext(ctx, "Failed to dispatch activation", "activation.id", a.ID, "error", err)
+		}
+	}
+
+	for key := range s.lagging {
+		if !running[key.activationID] {
+			delete(s.lagging, key)
+		}
+	}
+
+	return nil
+}
+
+// sync creates an activation for each setpoint and max_import target of the
+// VEN in the coming hours. Targets the VTN modified or cancelled are removed
+// by the VEN, which cancels their activations.
+func (s *Service) sync(ctx context.Context, now time.Time) error {
+	targets, err := s.targets.Targets(ctx, now, now.Add(syncHorizon))
+	if err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		if t.ID == uuid.Nil || (t.Kind != KindSetpoint && t.Kind != KindMaxImport) {
+			continue
+		}
+
+		if err := s.queries.CreateActivationForTarget(ctx, repository.CreateActivationForTargetParams{
+			ID:       uuid.New(),
+			TargetID: pgtype.UUID{Bytes: t.ID, Valid: true},
+			Kind:     t.Kind,
+			TargetKw: t.Value,
+			StartsAt: t.Start,
+			EndsAt:   t.End,
+		}); err != nil {
+			return err
+		}
+	}
+
+	orphaned, err := s.queries.CancelOrphanedActivations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range orphaned {
+		s.release(ctx, a.ID)
+	}
+
+	return nil
+}
+
+// complete accounts the last round of an activation until its end and marks
+// it completed. The setpoints of its chargers expire on their own.
+func (s *Service) complete(ctx context.Context, a *repository.Activation, now time.Time) error {
+	participants, err := s.queries.ListActivationParticipants(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.account(ctx, a, participants, a.EndsAt, now); err != nil {
+		return err
+	}
+
+	return s.queries.SetActivationStatus(ctx, repository.SetActivationStatusParams{
+		ID:     a.ID,
+		Status: StatusCompleted,
+	})
+}
+
+// dispatch accounts what the participants delivered since the last round
+// and divides the activation over the vehicles that can take part now.
+// Participants that fell short of their setpoint are held at what they
+// draw and the rest of the activation is divided over the others.
+func (s *Service) dispatch(ctx context.Context, a *repository.Activation, claimed map[uuid.UUID]bool, now time.Time) error {
+	participants, err := s.queries.ListActivationParticipants(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[uuid.UUID]*repository.ActivationParticipant, len(participants))
+	for i := range participants {
+		known[participants[i].VehicleID] = &participants[i]
+	}
+
+	totals, err := s.account(ctx, a, participants, now, now)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.sessions(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	var members []member
+	for i := range sessions {
+		cs := &sessions[i]
+		if !cs.VehicleID.Valid || claimed[uuid.UUID(cs.VehicleID.Bytes)] {
+			continue
+		}
+		if p, ok := known[uuid.UUID(cs.VehicleID.Bytes)]; ok && p.OptedOut {
+			continue
+		}
+
+		m, ok := s.member(ctx, cs, a.EndsAt, now)
+		if !ok {
+			continue
+		}
+
+		if p, ok := known[m.vehicleID]; ok && m.known && now.Sub(p.DispatchedAt) >= settleTime {
+			m.lagging = offTarget(p, m.actualKw)
+		}
+		s.reportLagging(ctx, a, known[m.vehicleID], &m)
+		if m.lagging {
+			m.offer = flexibility.Offer{BaselineKw: m.actualKw}
+		}
+
+		claimed[m.vehicleID] = true
+		members = append(members, m)
+	}
+
+	offers := make([]flexibility.Offer, 0, len(members))
+	baselineKw := 0.0
+	for _, m := range members {
+		offers = append(offers, m.offer)
+		baselineKw += m.offer.BaselineKw
+	}
+
+	deltaKw := a.TargetKw - baselineKw
+	if a.Kind == KindMaxImport {
+		deltaKw = math.Min(0, deltaKw)
+	}
+	setpoints, _ := flexibility.Disaggregate(deltaKw, offers)
+
+	measurement := repository.CreateActivationMeasurementParams{
+		ActivationID: a.ID,
+		MeasuredAt:   now,
+		Vehicles:     int32(len(members)),
+		TargetKw:     a.TargetKw,
+		BaselineKw:   baselineKw,
+	}
+	taking := map[uuid.UUID]bool{}
+	for i, m := range members {
+		taking[m.vehicleID] = true
+		setpointKw := s.command(ctx, a, known[m.vehicleID], &m, setpoints[i], now)
+		measurement.SetpointKw += setpointKw
+		if m.known {
+			measurement.ActualKw += m.actualKw
+		} else {
+			measurement.ActualKw += m.offer.BaselineKw
+		}
+	}
+
+	// Participants that left or cannot take part anymore keep what they
+	// delivered but are not asked for more.
+	for _, p := range participants {
+		if p.OptedOut || taking[p.VehicleID] || p.SetpointKw == p.BaselineKw {
+			continue
+		}
+
+		if _, err := s.queries.UpsertActivationParticipant(ctx, repository.UpsertActivationParticipantParams{
+			ActivationID: a.ID,
+			VehicleID:    p.VehicleID,
+			DeviceID:     p.DeviceID,
+			BaselineKw:   p.BaselineKw,
+			SetpointKw:   p.BaselineKw,
+			DispatchedAt: p.DispatchedAt,
+		}); err != nil {
+			return err
+		}
+		if p.DeviceID.Valid && !claimed[p.VehicleID] {
+			s.releaseDevice(ctx, p.DeviceID.Bytes)
+		}
+	}
+
+	if err := s.queries.CreateActivationMeasurement(ctx, measurement); err != nil {
+		return err
+	}
+
+	return s.queries.SetActivationEnergy(ctx, repository.SetActivationEnergyParams{
+		ID:           a.ID,
+		RequestedKwh: totals.requestedKwh,
+		DeliveredKwh: totals.deliveredKwh,
+	})
+}
+
+// member describes the vehicle of a session when its charger is connected
+// and the vehicle can take part. Chargers that cannot discharge only let the
+// vehicle charge less, others let it discharge within what is left of the
+// daily discharge cycles of its owner.
+func (s *Service) member(ctx context.Context, cs *repository.ChargingSession, end, now time.Time) (member, bool) {
+	connected, discharge := s.profiles.Controls(cs.DeviceID)
+	if !connected {
+		return member{}, false
+	}
+
+	offer, ok := s.offers.Offer(ctx, cs, end, now)
+	if !ok {
+		return member{}, false
+	}
+
+	m := member{
+		vehicleID: uuid.UUID(cs.VehicleID.Bytes),
+		deviceID:  cs.DeviceID,
+		offer:     offer,
+	}
+	if !discharge {
+		m.offer.UpKw = math.Min(m.offer.UpKw, math.Max(0, offer.BaselineKw))
+	} else if m.offer.UpKw > offer.BaselineKw {
+		m.offer.UpKw = math.Min(m.offer.UpKw, offer.BaselineKw+s.dischargeBudgetKw(ctx, m.vehicleID, end, now))
+	}
+	m.offer.UpKw = math.Max(0, m.offer.UpKw)
+
+	m.actualKw, m.known = s.powerKw(ctx, cs.DeviceID, now)
+	return m, true
+}
+
+// dischargeBudgetKw is the discharge power the vehicle can hold until end
+// without exceeding the discharge cycles its owner allows per day. Sessions
+// count towards the day they started on.
+func (s *Service) dischargeBudgetKw(ctx context.Context, vehicleID uuid.UUID, end, now time.Time) float64 {
+	v, err := s.queries.GetVehicleById(ctx, vehicleID)
+	if err != nil {
+		return 0
+	}
+
+	prefs, err := s.vehicles.LoadPreferences(ctx, vehicleID)
+	if err != nil {
+		return 0
+	}
+
+	local := now.In(prefs.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, prefs.Location)
+	exportedKwh, err := s.queries.SumExportedKwhByVehicleId(ctx, repository.SumExportedKwhByVehicleIdParams{
+		VehicleID:   pgtype.UUID{Bytes: vehicleID, Valid: true},
+		StartedFrom: midnight,
+	})
+	if err != nil {
+		return 0
+	}
+
+	budgetKwh := math.Max(0, float64(prefs.MaxDischargeCycles)*v.BatteryCapacityKwh-exportedKwh)
+	return budgetKwh / end.Sub(now).Hours()
+}
+
+// command sends a member its new setpoint and stores it as participant,
+// returning the setpoint the member holds. Chargers are only sent a new
+// setpoint when it changed noticeably, lagging members keep theirs.
+func (s *Service) command(ctx context.Context, a *repository.Activation, p *repository.ActivationParticipant, m *member, setpointKw float64, now time.Time) float64 {
+	params := repository.UpsertActivationParticipantParams{
+		ActivationID: a.ID,
+		VehicleID:    m.vehicleID,
+		DeviceID:     pgtype.UUID{Bytes: m.deviceID, Valid: true},
+		BaselineKw:   m.offer.BaselineKw,
+		SetpointKw:   setpointKw,
+		DispatchedAt: now,
+	}
+
+	var send bool
+	switch {
+	case m.lagging:
+		params.BaselineKw, params.SetpointKw, params.DispatchedAt = p.BaselineKw, p.SetpointKw, p.DispatchedAt
+	case p == nil:
+		send = math.Abs(setpointKw-m.offer.BaselineKw) >= minChangeKw
+	case p.DeviceID.Bytes != m.deviceID || math.Abs(setpointKw-p.SetpointKw) >= minChangeKw:
+		send = true
+	default:
+		params.SetpointKw, params.DispatchedAt = p.SetpointKw, p.DispatchedAt
+	}
+
+	if p == nil && !send {
+		return m.offer.BaselineKw
+	}
+
+	if send {
+		if err := s.profiles.SetPower(ctx, m.deviceID, setpointKw, a.EndsAt); err != nil {
+			slog.WarnContext(ctx, "Failed to send setpoint", "activation.id", a.ID, "device.id", m.deviceID, "error", err)
+			if p == nil {
+				return m.offer.BaselineKw
+			}
+			params.SetpointKw, params.DispatchedAt = p.SetpointKw, p.DispatchedAt
+		}
+	}
+
+	n, err := s.queries.UpsertActivationParticipant(ctx, params)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to store activation participant", "activation.id", a.ID, "vehicle.id", m.vehicleID, "error", err)
+	}
+	if err == nil && n == 0 {
+		// The owner opted out while the setpoint was sent.
+		if send {
+			s.releaseDevice(ctx, m.deviceID)
+		}
+		return m.offer.BaselineKw
+	}
+
+	return params.SetpointKw
+}
+
+// reportLagging records an event when a participant starts missing its
+// setpoint.
+func (s *Service) reportLagging(ctx context.Context, a *repository.Activation, p *repository.ActivationParticipant, m *member) {
+	key := participantKey{activationID: a.ID, vehicleID: m.vehicleID}
+	if !m.lagging {
+		delete(s.lagging, key)
+		return
+	}
+	if s.lagging[key] {
+		return
+	}
+
+	s.lagging[key] = true
+	s.events.Record(ctx, event.Event{
+		Type:      event.TypeFlexibilityShortfall,
+		DeviceID:  &m.deviceID,
+		VehicleID: &m.vehicleID,
+		Payload: map[string]any{
+			"activationId": a.ID,
+			"setpointKw":   p.SetpointKw,
+			"actualKw":     m.actualKw,
+		},
+	})
+}
+
+type energy struct {
+	requestedKwh float64
+	deliveredKwh float64
+}
+
+// account adds what each participant was asked for and delivered since it
+// was last measured until until, and returns the totals of the activation.
+// Delivered energy is the deviation from the baseline in the requested
+// direction, at most what was requested. Without a reading a participant
+// is assumed to follow its baseline, and a gap between measurements is
+// counted for at most readingStaleAfter.
+func (s *Service) account(ctx context.Context, a *repository.Activation, participants []repository.ActivationParticipant, until, now time.Time) (energy, error) {
+	var total energy
+	for i := range participants {
+		p := &participants[i]
+		from := p.DispatchedAt
+		if p.MeasuredAt.Valid && p.MeasuredAt.Time.After(from) {
+			from = p.MeasuredAt.Time
+		}
+
+		if !p.OptedOut && p.DeviceID.Valid && until.After(from) {
+			actualKw, ok := s.powerKw(ctx, p.DeviceID.Bytes, now)
+			if !ok {
+				actualKw = p.BaselineKw
+			}
+
+			hours := min(until.Sub(from), readingStaleAfter).Hours()
+			askedKw := math.Abs(p.SetpointKw - p.BaselineKw)
+			p.RequestedKwh += askedKw * hours
+			if askedKw > 0 {
+				deviationKw := (actualKw - p.BaselineKw) * math.Copysign(1, p.SetpointKw-p.BaselineKw)
+				p.DeliveredKwh += math.Max(0, math.Min(askedKw, deviationKw)) * hours
+			}
+
+			if err := s.queries.SetActivationParticipantDelivery(ctx, repository.SetActivationParticipantDeliveryParams{
+				ActivationID: a.ID,
+				VehicleID:    p.VehicleID,
+				RequestedKwh: p.RequestedKwh,
+				DeliveredKwh: p.DeliveredKwh,
+				MeasuredAt:   pgtype.Timestamptz{Time: until, Valid: true},
+			}); err != nil {
+				return total, err
+			}
+		}
+
+		total.requestedKwh += p.RequestedKwh
+		total.deliveredKwh += p.DeliveredKwh
+	}
+
+	return total, nil
+}
+
+// sessions returns the active sessions an activation applies to, those at
+// its site or all of them for activations of the whole fleet.
+func (s *Service) sessions(ctx context.Context, a *repository.Activation) ([]repository.ChargingSession, error) {
+	if a.SiteID.Valid {
+		return s.queries.ListActiveChargingSessionsBySiteId(ctx, a.SiteID)
+	}
+
+	return s.queries.ListActiveChargingSessions(ctx)
+}
+
+// powerKw is the power the charger reports in its shadow, false when it is
+// missing or stale.
+func (s *Service) powerKw(ctx context.Context, deviceID uuid.UUID, now time.Time) (float64, bool) {
+	sh, err := s.queries.GetDeviceShadow(ctx, deviceID)
+	if err != nil || !sh.ReportedUpdatedAt.Valid || now.Sub(sh.ReportedUpdatedAt.Time) > readingStaleAfter {
+		return 0, false
+	}
+
+	var reported map[string]any
+	if err := json.Unmarshal(sh.Reported, &reported); err != nil {
+		return 0, false
+	}
+
+	power, ok := reported[chargingprofile.PowerField].(float64)
+	return power, ok
+}
+
+// offTarget tells whether a participant draws too far from its setpoint.
+func offTarget(p *repository.ActivationParticipant, actualKw float64) bool {
+	if p.OptedOut || p.SetpointKw == p.BaselineKw {
+		return false
+	}
+
+	tolerance := math.Max(toleranceKw, toleranceShare*math.Abs(p.SetpointKw-p.BaselineKw))
+	return math.Abs(actualKw-p.SetpointKw) > tolerance
+}
@@ -0,0 +1,160 @@
+package dispatch
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req ActivationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	a, err := h.svc.Create(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, NewActivationResponse(a))
+	return nil
+}
+
+// ListHandler returns the latest activations of the site in the siteId query
+// parameter.
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := uuid.Parse(r.URL.Query().Get("siteId"))
+	if err != nil {
+		return httpx.BadRequest(ctx, "Query parameter siteId must be a UUID")
+	}
+
+	activations, err := h.svc.List(ctx, identityID, siteID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*ActivationResponse, 0, len(activations))
+	for i := range activations {
+		res = append(res, NewActivationResponse(&activations[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	activationID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	detail, err := h.svc.Get(ctx, identityID, activationID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewActivationDetailResponse(detail))
+	return nil
+}
+
+func (h *Handler) CancelHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	activationID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Cancel(ctx, identityID, activationID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) OptOutHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	activationID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req OptOutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	if err := h.svc.OptOut(ctx, identityID, activationID, req); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+// ListForVehicleHandler returns the latest activations a vehicle took part
+// in with what it delivered.
+func (h *Handler) ListForVehicleHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	participants, err := h.svc.VehicleActivations(ctx, identityID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*ParticipantResponse, 0, len(participants))
+	for i := range participants {
+		res = append(res, NewParticipantResponse(&participants[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
@@ -0,0 +1,286 @@
+// Package dispatch carries out activations of the fleet's flexibility, such
+// as "deliver -500 kW for 30 minutes". Each activation is divided over the
+// plugged-in vehicles that can take part, which are commanded through their
+// chargers, and the delivered energy is measured per vehicle so it can be
+// settled. Activations are created through the API for a site or follow the
+// fleet-level targets received from a VTN.
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/chargingprofile"
+	"github.com/V2G-Minor-Fontys/server/internal/event"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"time"
+)
+
+type Service struct {
+	queries  *repository.Queries
+	sites    *site.Service
+	vehicles *vehicle.Service
+	offers   OfferSource
+	profiles *chargingprofile.Service
+	events   *event.Service
+	targets  TargetSource
+	// lagging holds the participants that missed their setpoint in the last
+	// round, so a shortfall is only reported once. Only the dispatch loop
+	// uses it.
+	lagging map[participantKey]bool
+}
+
+type participantKey struct {
+	activationID uuid.UUID
+	vehicleID    uuid.UUID
+}
+
+// NewService creates the dispatcher. Targets may be nil when the server does
+// not act as a VEN.
+func NewService(queries *repository.Queries, sites *site.Service, vehicles *vehicle.Service, offers OfferSource, profiles *chargingprofile.Service, events *event.Service, targets TargetSource) *Service {
+	return &Service{
+		queries:  queries,
+		sites:    sites,
+		vehicles: vehicles,
+		offers:   offers,
+		profiles: profiles,
+		events:   events,
+		targets:  targets,
+		lagging:  map[participantKey]bool{},
+	}
+}
+
+// Create schedules an activation of the vehicles at a site, which requires
+// the manager role.
+func (s *Service) Create(ctx context.Context, identityID uuid.UUID, req ActivationRequest) (*repository.Activation, error) {
+	now := time.Now().UTC()
+	if req.Kind == "" {
+		req.Kind = KindSetpoint
+	}
+	if errs := req.Validate(now); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	if _, _, err := s.sites.Authorize(ctx, identityID, req.SiteID, site.RoleManager); err != nil {
+		return nil, err
+	}
+
+	startsAt := now.Truncate(time.Second)
+	if req.StartsAt != nil && req.StartsAt.After(now) {
+		startsAt = req.StartsAt.UTC()
+	}
+
+	a, err := s.queries.CreateActivation(ctx, repository.CreateActivationParams{
+		ID:        uuid.New(),
+		Source:    SourceAPI,
+		SiteID:    pgtype.UUID{Bytes: req.SiteID, Valid: true},
+		CreatedBy: pgtype.UUID{Bytes: identityID, Valid: true},
+		Kind:      req.Kind,
+		TargetKw:  req.TargetKw,
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(time.Duration(req.DurationMinutes) * time.Minute),
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to create activation", err)
+	}
+
+	return &a, nil
+}
+
+// List returns the latest activations of a site.
+func (s *Service) List(ctx context.Context, identityID, siteID uuid.UUID) ([]repository.Activation, error) {
+	if _, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	activations, err := s.queries.ListActivationsBySiteId(ctx, repository.ListActivationsBySiteIdParams{
+		SiteID: pgtype.UUID{Bytes: siteID, Valid: true},
+		Limit:  listLimit,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve activations", err)
+	}
+
+	return activations, nil
+}
+
+// Get returns an activation with its participants. Members of the site see
+// all of them, activations of the whole fleet are only visible to owners of
+// a participating vehicle and only show their own vehicles.
+func (s *Service) Get(ctx context.Context, identityID, activationID uuid.UUID) (*ActivationDetail, error) {
+	a, err := s.get(ctx, activationID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants, err := s.queries.ListActivationParticipants(ctx, a.ID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve activation participants", err)
+	}
+
+	if a.SiteID.Valid {
+		if _, _, err := s.sites.Authorize(ctx, identityID, a.SiteID.Bytes, site.RoleViewer); err != nil {
+			return nil, err
+		}
+
+		measurements, err := s.queries.ListActivationMeasurements(ctx, a.ID)
+		if err != nil {
+			return nil, httpx.InternalErr(ctx, "Failed to retrieve activation measurements", err)
+		}
+
+		return &ActivationDetail{Activation: *a, Participants: participants, Measurements: measurements}, nil
+	}
+
+	owned, err := s.vehicles.List(ctx, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	mine := map[uuid.UUID]bool{}
+	for _, v := range owned {
+		mine[v.ID] = true
+	}
+
+	detail := &ActivationDetail{Activation: *a}
+	for _, p := range participants {
+		if mine[p.VehicleID] {
+			detail.Participants = append(detail.Participants, p)
+		}
+	}
+	if len(detail.Participants) == 0 {
+		return nil, httpx.NotFound(ctx, "Activation could not be found")
+	}
+
+	return detail, nil
+}
+
+// Cancel ends an activation of a site early, after which its vehicles follow
+// their schedules again. Activations of a VTN are cancelled by the VTN.
+func (s *Service) Cancel(ctx context.Context, identityID, activationID uuid.UUID) error {
+	a, err := s.get(ctx, activationID)
+	if err != nil {
+		return err
+	}
+
+	if !a.SiteID.Valid {
+		return httpx.NotFound(ctx, "Activation could not be found")
+	}
+	if _, _, err := s.sites.Authorize(ctx, identityID, a.SiteID.Bytes, site.RoleManager); err != nil {
+		return err
+	}
+	if a.Source == SourceOpenADR {
+		return httpx.Conflict(ctx, "Activations received from a VTN can only be cancelled by the VTN")
+	}
+
+	n, err := s.queries.CancelActivation(ctx, a.ID)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to cancel activation", err)
+	}
+	if n == 0 {
+		return httpx.Conflict(ctx, "Activation has already ended")
+	}
+
+	s.release(ctx, a.ID)
+	return nil
+}
+
+// OptOut takes a vehicle of the caller out of an activation that has not
+// ended yet. The vehicle follows its schedule again and is not dispatched
+// for the activation anymore.
+func (s *Service) OptOut(ctx context.Context, identityID, activationID uuid.UUID, req OptOutRequest) error {
+	v, err := s.vehicles.GetOwned(ctx, identityID, req.VehicleID)
+	if err != nil {
+		return err
+	}
+
+	a, err := s.get(ctx, activationID)
+	if err != nil {
+		return err
+	}
+	if a.Status != StatusScheduled && a.Status != StatusActive {
+		return httpx.Conflict(ctx, "Activation has already ended")
+	}
+
+	participants, err := s.queries.ListActivationParticipants(ctx, a.ID)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to retrieve activation participants", err)
+	}
+
+	if err := s.queries.OptOutActivationParticipant(ctx, repository.OptOutActivationParticipantParams{
+		ActivationID: a.ID,
+		VehicleID:    v.ID,
+		DispatchedAt: time.Now().UTC(),
+	}); err != nil {
+		return httpx.InternalErr(ctx, "Failed to opt out of activation", err)
+	}
+
+	for _, p := range participants {
+		if p.VehicleID == v.ID && !p.OptedOut && p.DeviceID.Valid {
+			s.releaseDevice(ctx, p.DeviceID.Bytes)
+		}
+	}
+
+	return nil
+}
+
+// VehicleActivations lists the latest activations a vehicle of the caller
+// took part in.
+func (s *Service) VehicleActivations(ctx context.Context, identityID, vehicleID uuid.UUID) ([]repository.ActivationParticipant, error) {
+	v, err := s.vehicles.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants, err := s.queries.ListActivationParticipantsByVehicleId(ctx, repository.ListActivationParticipantsByVehicleIdParams{
+		VehicleID: v.ID,
+		Limit:     listLimit,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve activations", err)
+	}
+
+	return participants, nil
+}
+
+func (s *Service) get(ctx context.Context, activationID uuid.UUID) (*repository.Activation, error) {
+	a, err := s.queries.GetActivationById(ctx, activationID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Activation could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve activation", err)
+	}
+
+	return &a, nil
+}
+
+// release hands the chargers of an activation that ended early back to their
+// schedules.
+func (s *Service) release(ctx context.Context, activationID uuid.UUID) {
+	participants, err := s.queries.ListActivationParticipants(ctx, activationID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve activation participants", "activation.id", activationID, "error", err)
+		return
+	}
+
+	for _, p := range participants {
+		if !p.OptedOut && p.DeviceID.Valid {
+			s.releaseDevice(ctx, p.DeviceID.Bytes)
+		}
+	}
+}
+
+// releaseDevice removes the setpoint of a charger. Offline chargers keep it
+// until it expires at the end of the activation.
+func (s *Service) releaseDevice(ctx context.Context, deviceID uuid.UUID) {
+	if err := s.profiles.ReleasePower(ctx, deviceID); err != nil {
+		slog.WarnContext(ctx, "Failed to release charger from activation", "device.id", deviceID, "error", err)
+	}
+}
@@ -0,0 +1,55 @@
+package event
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TypeProfileRejected           = "charging_profile_rejected"
+	TypeCompositeScheduleMismatch = "composite_schedule_mismatch"
+	TypePowerMismatch             = "power_mismatch"
+	TypeFlexibilityShortfall      = "flexibility_shortfall"
+	TypeCongestionTargetAtRisk    = "congestion_target_at_risk"
+	TypeReservationNoShow         = "reservation_no_show"
+)
+
+// Event is something noteworthy that happened to a device or vehicle, such as
+// a charger deviating from the schedule it was given.
+type Event struct {
+	Type      string
+	DeviceID  *uuid.UUID
+	VehicleID *uuid.UUID
+	Payload   any
+}
+
+type EventResponse struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	DeviceID  *uuid.UUID      `json:"deviceId,omitempty"`
+	VehicleID *uuid.UUID      `json:"vehicleId,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+func NewEventResponse(e *repository.Event) *EventResponse {
+	res := &EventResponse{
+		ID:        e.ID,
+		Type:      e.Type,
+		Payload:   e.Payload,
+		CreatedAt: e.CreatedAt,
+	}
+
+	if e.DeviceID.Valid {
+		id := uuid.UUID(e.DeviceID.Bytes)
+		res.DeviceID = &id
+	}
+	if e.VehicleID.Valid {
+		id := uuid.UUID(e.VehicleID.Bytes)
+		res.VehicleID = &id
+	}
+
+	return res
+}
@@ -0,0 +1,41 @@
+package event
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) ListForDeviceHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	events, err := h.svc.ListForDevice(ctx, identityID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*EventResponse, 0, len(events))
+	for i := range events {
+		res = append(res, NewEventResponse(&events[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
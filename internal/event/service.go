@@ -0,0 +1,65 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+)
+
+const listLimit = 100
+
+type Service struct {
+	queries *repository.Queries
+	devices *device.Service
+}
+
+func NewService(queries *repository.Queries, devices *device.Service) *Service {
+	return &Service{queries: queries, devices: devices}
+}
+
+// Record stores the event. Recording is best effort, failures are logged so
+// that they never interrupt the operation being observed.
+func (s *Service) Record(ctx context.Context, e Event) {
+	payload, err := json.Marshal(e.Payload)
+	if err != nil || e.Payload == nil {
+		payload = []byte("{}")
+	}
+
+	params := repository.CreateEventParams{
+		ID:      uuid.New(),
+		Type:    e.Type,
+		Payload: payload,
+	}
+	if e.DeviceID != nil {
+		params.DeviceID = pgtype.UUID{Bytes: *e.DeviceID, Valid: true}
+	}
+	if e.VehicleID != nil {
+		params.VehicleID = pgtype.UUID{Bytes: *e.VehicleID, Valid: true}
+	}
+
+	if err := s.queries.CreateEvent(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to record event", "event.type", e.Type, "error", err)
+	}
+}
+
+func (s *Service) ListForDevice(ctx context.Context, identityID, deviceID uuid.UUID) ([]repository.Event, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.queries.ListEventsByDeviceId(ctx, repository.ListEventsByDeviceIdParams{
+		DeviceID: pgtype.UUID{Bytes: d.ID, Valid: true},
+		Limit:    listLimit,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve events", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,198 @@
+package flexibility
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultRange = 4 * time.Hour
+	maxRange     = 48 * time.Hour
+	// maxForecastListRange bounds the stored forecasts returned at once.
+	maxForecastListRange = 31 * 24 * time.Hour
+)
+
+// Interval is the flexibility of one or more sites from Start until the next
+// interval. Vehicles counts the plugged-in vehicles with a known state of
+// charge, BaselineKw is what they are planned to draw.
+type Interval struct {
+	Start      time.Time `json:"start"`
+	Vehicles   int       `json:"vehicles"`
+	BaselineKw float64   `json:"baselineKw"`
+	UpKw       float64   `json:"upKw"`
+	DownKw     float64   `json:"downKw"`
+}
+
+type FlexibilityRequest struct {
+	SiteID *uuid.UUID
+	Region string
+	From   time.Time
+	To     time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseFlexibilityRequest reads the siteId, region, from and to query
+// parameters. The range defaults to the next four hours, starting with the
+// current interval.
+func ParseFlexibilityRequest(q url.Values, now time.Time) FlexibilityRequest {
+	req := FlexibilityRequest{
+		Region:    q.Get("region"),
+		From:      now.UTC(),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("siteId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			req.parseErrs.Add("siteId", "Site id must be a UUID")
+		}
+		req.SiteID = &id
+	}
+
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	req.To = req.From.Add(defaultRange)
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	return req
+}
+
+// Validate requires the range to end in the future, flexibility of the past
+// is what the stored forecasts are for.
+func (r *FlexibilityRequest) Validate(now time.Time) httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxRange {
+			errs.Add("to", "The requested range cannot exceed 48 hours")
+		} else if !r.To.After(now) {
+			errs.Add("to", "To must be in the future")
+		}
+	}
+
+	return errs
+}
+
+type ListForecastsRequest struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseListForecastsRequest reads the from and to query parameters, they
+// default to the last 24 hours.
+func ParseListForecastsRequest(q url.Values, now time.Time) ListForecastsRequest {
+	req := ListForecastsRequest{
+		To:        now.UTC(),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	req.From = req.To.Add(-24 * time.Hour)
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	return req
+}
+
+func (r *ListForecastsRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxForecastListRange {
+			errs.Add("to", "The requested range cannot exceed 31 days")
+		}
+	}
+
+	return errs
+}
+
+// SiteFlexibility is the flexibility of a single site.
+type SiteFlexibility struct {
+	SiteID    uuid.UUID  `json:"siteId"`
+	Name      string     `json:"name"`
+	Region    string     `json:"region,omitempty"`
+	Intervals []Interval `json:"intervals"`
+}
+
+// FlexibilityResponse holds the flexibility per site and their sum.
+type FlexibilityResponse struct {
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	Region    string            `json:"region,omitempty"`
+	Sites     []SiteFlexibility `json:"sites"`
+	Intervals []Interval        `json:"intervals"`
+}
+
+// ForecastResponse compares a stored forecast with what the chargers of the
+// site drew. DeviationKw is the actual power minus the baseline, it is only
+// set once the interval has been measured.
+type ForecastResponse struct {
+	Start       time.Time `json:"start"`
+	Vehicles    int32     `json:"vehicles"`
+	BaselineKw  float64   `json:"baselineKw"`
+	UpKw        float64   `json:"upKw"`
+	DownKw      float64   `json:"downKw"`
+	ForecastAt  time.Time `json:"forecastAt"`
+	ActualKw    *float64  `json:"actualKw,omitempty"`
+	DeviationKw *float64  `json:"deviationKw,omitempty"`
+}
+
+func NewForecastResponse(f *repository.FlexibilityForecast) *ForecastResponse {
+	res := &ForecastResponse{
+		Start:      f.StartsAt,
+		Vehicles:   f.Vehicles,
+		BaselineKw: f.BaselineKw,
+		UpKw:       f.UpKw,
+		DownKw:     f.DownKw,
+		ForecastAt: f.ForecastAt,
+	}
+
+	if f.ActualKw.Valid {
+		actual := f.ActualKw.Float64
+		deviation := actual - f.BaselineKw
+		res.ActualKw = &actual
+		res.DeviationKw = &deviation
+	}
+
+	return res
+}
@@ -0,0 +1,64 @@
+package flexibility
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetHandler returns the flexibility per interval of a site, or of all the
+// caller's sites in a region.
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.svc.Flexibility(ctx, identityID, ParseFlexibilityRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+// ListForecastsHandler compares the stored forecasts of a site with the
+// power its chargers drew.
+func (h *Handler) ListForecastsHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	q := r.URL.Query()
+	siteID, err := uuid.Parse(q.Get("siteId"))
+	if err != nil {
+		return httpx.BadRequest(ctx, "Query parameter siteId must be a UUID")
+	}
+
+	forecasts, err := h.svc.Forecasts(ctx, identityID, siteID, ParseListForecastsRequest(q, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	res := make([]*ForecastResponse, 0, len(forecasts))
+	for i := range forecasts {
+		res = append(res, NewForecastResponse(&forecasts[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
@@ -0,0 +1,435 @@
+// Package flexibility aggregates how much the plugged-in vehicles of a site
+// can shift their charging or discharge, per interval, so the fleet can be
+// offered to aggregators. Forecasts are stored ahead of each interval and
+// compared with what the chargers actually drew.
+package flexibility
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/meter"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/V2G-Minor-Fontys/server/pkg/flexibility"
+	"github.com/V2G-Minor-Fontys/server/pkg/optimizer"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const (
+	measureInterval = time.Minute
+	// forecastHorizon is how far ahead forecasts are stored, they are
+	// refreshed every interval until the interval starts.
+	forecastHorizon = 4 * time.Hour
+	// readingStaleAfter ignores shadow readings older than this.
+	readingStaleAfter = 2 * time.Minute
+)
+
+type Service struct {
+	queries  *repository.Queries
+	sites    *site.Service
+	vehicles *vehicle.Service
+	limits   scheduling.GridLimiter
+	meters   *meter.Service
+	// forecastedAt is the interval the last forecasts were stored in.
+	forecastedAt time.Time
+}
+
+func NewService(queries *repository.Queries, sites *site.Service, vehicles *vehicle.Service, limits scheduling.GridLimiter, meters *meter.Service) *Service {
+	return &Service{queries: queries, sites: sites, vehicles: vehicles, limits: limits, meters: meters}
+}
+
+// Flexibility aggregates the flexibility of the requested site, or of all
+// sites of the caller in the requested region. Intervals before the current
+// one are left out.
+func (s *Service) Flexibility(ctx context.Context, identityID uuid.UUID, req FlexibilityRequest) (*FlexibilityResponse, error) {
+	now := time.Now().UTC()
+	if errs := req.Validate(now); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	var sites []repository.Site
+	if req.SiteID != nil {
+		st, _, err := s.sites.Authorize(ctx, identityID, *req.SiteID, site.RoleViewer)
+		if err != nil {
+			return nil, err
+		}
+		if req.Region != "" && st.Region != req.Region {
+			return nil, httpx.BadRequest(ctx, "Site is not part of the requested region")
+		}
+		sites = append(sites, *st)
+	} else {
+		all, err := s.sites.List(ctx, identityID)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range all {
+			if req.Region == "" || st.Region == req.Region {
+				sites = append(sites, st)
+			}
+		}
+	}
+
+	from := maxTime(req.From, now).Truncate(scheduling.Resolution)
+	res := &FlexibilityResponse{
+		From:      from,
+		To:        req.To,
+		Region:    req.Region,
+		Sites:     make([]SiteFlexibility, 0, len(sites)),
+		Intervals: emptyIntervals(from, req.To),
+	}
+
+	for i := range sites {
+		st := &sites[i]
+		intervals, err := s.SiteFlexibility(ctx, st, from, req.To, now)
+		if err != nil {
+			return nil, httpx.InternalErr(ctx, "Failed to compute flexibility", err)
+		}
+
+		for j, in := range intervals {
+			total := &res.Intervals[j]
+			total.Vehicles += in.Vehicles
+			total.BaselineKw += in.BaselineKw
+			total.UpKw += in.UpKw
+			total.DownKw += in.DownKw
+		}
+
+		res.Sites = append(res.Sites, SiteFlexibility{
+			SiteID:    st.ID,
+			Name:      st.Name,
+			Region:    st.Region,
+			Intervals: intervals,
+		})
+	}
+
+	return res, nil
+}
+
+// Forecasts lists the stored forecasts of a site with what was delivered.
+func (s *Service) Forecasts(ctx context.Context, identityID, siteID uuid.UUID, req ListForecastsRequest) ([]repository.FlexibilityForecast, error) {
+	if _, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	forecasts, err := s.queries.ListFlexibilityForecastsBySiteId(ctx, repository.ListFlexibilityForecastsBySiteIdParams{
+		SiteID:       siteID,
+		StartsFrom:   req.From,
+		StartsBefore: req.To,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve flexibility forecasts", err)
+	}
+
+	return forecasts, nil
+}
+
+// SiteFlexibility sums up the flexibility of the vehicles plugged in at the
+// site for each interval from from, which must be aligned to
+// scheduling.Resolution, until to. The sums are capped by the grid
+// connection: the site cannot import more than its limit plus the expected
+// solar surplus, nor export more than its limit.
+func (s *Service) SiteFlexibility(ctx context.Context, st *repository.Site, from, to, now time.Time) ([]Interval, error) {
+	intervals := emptyIntervals(from, to)
+	sessions, err := s.queries.ListActiveChargingSessionsBySiteId(ctx, pgtype.UUID{Bytes: st.ID, Valid: true})
+	if err != nil || len(sessions) == 0 {
+		return intervals, err
+	}
+
+	for i := range sessions {
+		slots, ok := s.vehicleFlexibility(ctx, &sessions[i], from, len(intervals), now)
+		if !ok {
+			continue
+		}
+
+		for j, slot := range slots {
+			if !slot.Available {
+				continue
+			}
+			intervals[j].Vehicles++
+			intervals[j].BaselineKw += slot.BaselineKw
+			intervals[j].UpKw += slot.UpKw
+			intervals[j].DownKw += slot.DownKw
+		}
+	}
+
+	limits, err := s.limits.GridLimits(ctx, sessions[0].DeviceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for j := range intervals {
+		in := &intervals[j]
+		l, ok := scheduling.LimitAt(limits, in.Start)
+		if !ok {
+			continue
+		}
+
+		in.DownKw = math.Min(in.DownKw, math.Max(0, l.MaxImportKw+l.SurplusKw-in.BaselineKw))
+		in.UpKw = math.Min(in.UpKw, math.Max(0, l.MaxExportKw+in.BaselineKw))
+	}
+
+	return intervals, nil
+}
+
+// vehicleFlexibility computes the flexibility of the vehicle of a session.
+// Sessions without a known vehicle or state of charge are left out. A
+// boosting vehicle charges at full power and offers nothing, nor does a
+// vehicle whose owner opted out of flexibility.
+func (s *Service) vehicleFlexibility(ctx context.Context, cs *repository.ChargingSession, from time.Time, intervals int, now time.Time) ([]flexibility.Slot, bool) {
+	fv, prefs, ok := s.flexibleVehicle(ctx, cs, now)
+	if !ok {
+		return nil, false
+	}
+
+	fv.BaselineKw = s.baseline(ctx, uuid.UUID(cs.VehicleID.Bytes), from, intervals)
+	slots := flexibility.Compute(*fv, from, scheduling.Resolution, intervals)
+	if prefs.Boosting(now) || prefs.FlexibilityOptOut {
+		for i := range slots {
+			slots[i].UpKw, slots[i].DownKw = 0, 0
+		}
+	}
+
+	return slots, true
+}
+
+// Offer is what the vehicle of a session can deliver from now until end when
+// it holds the same power all along, relative to what its schedule plans
+// now. It is false for sessions whose vehicle cannot take part: unknown,
+// without a state of charge, boosting or opted out.
+func (s *Service) Offer(ctx context.Context, cs *repository.ChargingSession, end, now time.Time) (flexibility.Offer, bool) {
+	fv, prefs, ok := s.flexibleVehicle(ctx, cs, now)
+	if !ok || !end.After(now) || prefs.Boosting(now) || prefs.FlexibilityOptOut {
+		return flexibility.Offer{}, false
+	}
+
+	fv.BaselineKw = s.baseline(ctx, uuid.UUID(cs.VehicleID.Bytes), now.Truncate(scheduling.Resolution), 1)
+	slots := flexibility.Compute(*fv, now, end.Sub(now), 1)
+	if len(slots) == 0 || !slots[0].Available {
+		return flexibility.Offer{}, false
+	}
+
+	return flexibility.Offer{
+		BaselineKw: slots[0].BaselineKw,
+		UpKw:       slots[0].UpKw,
+		DownKw:     slots[0].DownKw,
+	}, true
+}
+
+// flexibleVehicle describes the vehicle of a session without its baseline.
+//
+// Vehicles only offer discharge when their owner allows discharge cycles and
+// they are not in solar mode, in which the grid only brings them to their
+// minimum.
+func (s *Service) flexibleVehicle(ctx context.Context, cs *repository.ChargingSession, now time.Time) (*flexibility.Vehicle, *vehicle.Preferences, bool) {
+	if !cs.VehicleID.Valid {
+		return nil, nil, false
+	}
+
+	v, err := s.queries.GetVehicleById(ctx, uuid.UUID(cs.VehicleID.Bytes))
+	if err != nil {
+		return nil, nil, false
+	}
+
+	soc, ok := s.reported(ctx, cs.DeviceID, now)[scheduling.SocField].(float64)
+	if !ok || soc < 0 || soc > 100 {
+		return nil, nil, false
+	}
+
+	prefs, err := s.vehicles.LoadPreferences(ctx, v.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load vehicle preferences", "vehicle.id", v.ID, "error", err)
+		return nil, nil, false
+	}
+
+	fv := &flexibility.Vehicle{
+		CapacityKwh:    v.BatteryCapacityKwh,
+		Soc:            soc,
+		MinSoc:         prefs.MinSoc,
+		TargetSoc:      prefs.TargetSoc,
+		MaxChargeKw:    v.MaxChargeKw,
+		MaxDischargeKw: v.MaxDischargeKw,
+		Efficiency:     scheduling.DefaultEfficiency,
+	}
+	if departure, ok := prefs.NextDeparture(now); ok {
+		fv.Departure = departure
+	}
+	if prefs.MaxDischargeCycles == 0 || prefs.ChargingMode == vehicle.ModeSolar {
+		fv.MaxDischargeKw = 0
+	}
+	if prefs.ChargingMode == vehicle.ModeSolar {
+		fv.TargetSoc = fv.MinSoc
+	}
+
+	return fv, prefs, true
+}
+
+// baseline is the power the latest schedule of the vehicle plans for each
+// interval from from, intervals outside the schedule are idle.
+func (s *Service) baseline(ctx context.Context, vehicleID uuid.UUID, from time.Time, intervals int) []float64 {
+	baseline := make([]float64, intervals)
+	sched, err := s.queries.GetLatestChargingSchedule(ctx, vehicleID)
+	if err != nil {
+		return baseline
+	}
+
+	var setpoints []optimizer.Setpoint
+	if err := json.Unmarshal(sched.Setpoints, &setpoints); err != nil {
+		return baseline
+	}
+
+	for _, sp := range setpoints {
+		i := int(sp.Start.Sub(from) / scheduling.Resolution)
+		if !sp.Start.Before(from) && i < intervals {
+			baseline[i] = sp.PowerKw
+		}
+	}
+
+	return baseline
+}
+
+// Run stores forecasts for the coming intervals of every site with vehicles
+// plugged in, once per interval, and measures what the chargers draw during
+// intervals that were forecast.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(measureInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.track(ctx, now.UTC()); err != nil {
+				slog.ErrorContext(ctx, "Failed to track flexibility", "error", err)
+			}
+		}
+	}
+}
+
+func (s *Service) track(ctx context.Context, now time.Time) error {
+	sites, err := s.queries.ListSites(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := now.Truncate(scheduling.Resolution)
+	forecast := current.After(s.forecastedAt)
+	for i := range sites {
+		st := &sites[i]
+		if err := s.measure(ctx, st, current, now); err != nil {
+			slog.WarnContext(ctx, "Failed to measure delivered flexibility", "site.id", st.ID, "error", err)
+		}
+
+		if forecast {
+			if err := s.forecast(ctx, st, current.Add(scheduling.Resolution), now); err != nil {
+				slog.WarnContext(ctx, "Failed to forecast flexibility", "site.id", st.ID, "error", err)
+			}
+		}
+	}
+
+	if forecast {
+		s.forecastedAt = current
+	}
+
+	return nil
+}
+
+// measure adds what the chargers of the site draw now to the running
+// average of the current interval, when it was forecast.
+func (s *Service) measure(ctx context.Context, st *repository.Site, current, now time.Time) error {
+	f, err := s.queries.GetFlexibilityForecast(ctx, repository.GetFlexibilityForecastParams{
+		SiteID:   st.ID,
+		StartsAt: current,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	powerKw := s.meters.ChargersKw(ctx, pgtype.UUID{Bytes: st.ID, Valid: true}, now)
+	n := float64(f.ActualSamples)
+	return s.queries.SetFlexibilityActual(ctx, repository.SetFlexibilityActualParams{
+		SiteID:        st.ID,
+		StartsAt:      current,
+		ActualKw:      pgtype.Float8{Float64: (f.ActualKw.Float64*n + powerKw) / (n + 1), Valid: true},
+		ActualSamples: f.ActualSamples + 1,
+	})
+}
+
+// forecast stores the flexibility of the site for the intervals with
+// vehicles plugged in from from until the end of the horizon. Forecasts of
+// intervals that already started are kept.
+func (s *Service) forecast(ctx context.Context, st *repository.Site, from, now time.Time) error {
+	intervals, err := s.SiteFlexibility(ctx, st, from, from.Add(forecastHorizon), now)
+	if err != nil {
+		return err
+	}
+
+	for _, in := range intervals {
+		if in.Vehicles == 0 {
+			continue
+		}
+
+		if err := s.queries.UpsertFlexibilityForecast(ctx, repository.UpsertFlexibilityForecastParams{
+			SiteID:     st.ID,
+			StartsAt:   in.Start,
+			Vehicles:   int32(in.Vehicles),
+			BaselineKw: in.BaselineKw,
+			UpKw:       in.UpKw,
+			DownKw:     in.DownKw,
+			ForecastAt: now,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reported returns the reported shadow state of a device, or nil when it is
+// missing or stale.
+func (s *Service) reported(ctx context.Context, deviceID uuid.UUID, now time.Time) map[string]any {
+	sh, err := s.queries.GetDeviceShadow(ctx, deviceID)
+	if err != nil || !sh.ReportedUpdatedAt.Valid || now.Sub(sh.ReportedUpdatedAt.Time) > readingStaleAfter {
+		return nil
+	}
+
+	var reported map[string]any
+	if err := json.Unmarshal(sh.Reported, &reported); err != nil {
+		return nil
+	}
+
+	return reported
+}
+
+// emptyIntervals covers [from, to) with intervals of scheduling.Resolution,
+// the last one may extend beyond to.
+func emptyIntervals(from, to time.Time) []Interval {
+	var intervals []Interval
+	for t := from; t.Before(to); t = t.Add(scheduling.Resolution) {
+		intervals = append(intervals, Interval{Start: t})
+	}
+
+	return intervals
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,17 @@
+package httpx
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+func URLParamUUID(r *http.Request, key string) (uuid.UUID, error) {
+	id, err := uuid.Parse(chi.URLParam(r, key))
+	if err != nil {
+		return uuid.Nil, BadRequest(r.Context(), fmt.Sprintf("Path parameter %s must be a valid UUID", key))
+	}
+
+	return id, nil
+}
@@ -11,18 +11,31 @@ import (
 
 const (
 	UnauthorizedType = "https://datatracker.ietf.org/doc/html/rfc7235#section-3.1"
+	ForbiddenType    = "https://datatracker.ietf.org/doc/html/rfc7231#section-6.5.3"
 	NotFoundType     = "https://datatracker.ietf.org/doc/html/rfc7231#section-6.5.4"
 	ConflictType     = "https://datatracker.ietf.org/doc/html/rfc7231#section-6.5.8"
 	BadRequestType   = "https://datatracker.ietf.org/doc/html/rfc7231#section-6.5.1"
+	ValidationType   = "https://datatracker.ietf.org/doc/html/rfc4918#section-11.2"
 	InternalType     = "https://datatracker.ietf.org/doc/html/rfc7231#section-6.6.1"
+	TimeoutType      = "https://datatracker.ietf.org/doc/html/rfc7231#section-6.6.5"
 )
 
+// ValidationErrors maps request fields to the reason they were rejected.
+type ValidationErrors map[string]string
+
+func (v ValidationErrors) Add(field, reason string) {
+	if _, ok := v[field]; !ok {
+		v[field] = reason
+	}
+}
+
 type Problem struct {
-	Type     string `json:"type,omitempty"`
-	Title    string `json:"title"`
-	Status   int    `json:"status"`
-	Detail   string `json:"detail,omitempty"`
-	Instance string `json:"instance,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Title    string           `json:"title"`
+	Status   int              `json:"status"`
+	Detail   string           `json:"detail,omitempty"`
+	Instance string           `json:"instance,omitempty"`
+	Errors   ValidationErrors `json:"errors,omitempty"`
 	err      error
 }
 
@@ -71,6 +84,17 @@ func Unauthorized(ctx context.Context, detail string) *Problem {
 	)
 }
 
+func Forbidden(ctx context.Context, detail string) *Problem {
+	return newProblem(
+		ctx,
+		http.StatusForbidden,
+		"Forbidden",
+		detail,
+		ForbiddenType,
+		nil,
+	)
+}
+
 func NotFound(ctx context.Context, detail string) *Problem {
 	return newProblem(
 		ctx,
@@ -114,3 +138,30 @@ func InternalErr(ctx context.Context, detail string, err error) *Problem {
 		err,
 	)
 }
+
+// GatewayTimeout reports that a device the request was forwarded to did not
+// answer in time.
+func GatewayTimeout(ctx context.Context, detail string) *Problem {
+	return newProblem(
+		ctx,
+		http.StatusGatewayTimeout,
+		"Gateway Timeout",
+		detail,
+		TimeoutType,
+		nil,
+	)
+}
+
+func ValidationFailed(ctx context.Context, errs ValidationErrors) *Problem {
+	p := newProblem(
+		ctx,
+		http.StatusUnprocessableEntity,
+		"Validation Failed",
+		"One or more fields are invalid",
+		ValidationType,
+		nil,
+	)
+	p.Errors = errs
+
+	return p
+}
@@ -0,0 +1,112 @@
+package meter
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/dsmr"
+	"github.com/google/uuid"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultListRange = 24 * time.Hour
+	maxListRange     = 31 * 24 * time.Hour
+)
+
+type ListReadingsRequest struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseListReadingsRequest reads the from and to query parameters, they
+// default to the last 24 hours.
+func ParseListReadingsRequest(q url.Values, now time.Time) ListReadingsRequest {
+	req := ListReadingsRequest{
+		To:        now.UTC(),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	req.From = req.To.Add(-defaultListRange)
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	return req
+}
+
+func (r *ListReadingsRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxListRange {
+			errs.Add("to", "The requested range cannot exceed 31 days")
+		}
+	}
+
+	return errs
+}
+
+type GasResponse struct {
+	MeasuredAt time.Time `json:"measuredAt"`
+	M3         float64   `json:"m3"`
+}
+
+type ReadingResponse struct {
+	DeviceID    uuid.UUID    `json:"deviceId"`
+	MeasuredAt  time.Time    `json:"measuredAt"`
+	ImportKw    float64      `json:"importKw"`
+	ExportKw    float64      `json:"exportKw"`
+	NetKw       float64      `json:"netKw"`
+	HouseholdKw float64      `json:"householdKw"`
+	ImportedKwh float64      `json:"importedKwh"`
+	ExportedKwh float64      `json:"exportedKwh"`
+	Tariff      int16        `json:"tariff"`
+	Phases      []dsmr.Phase `json:"phases"`
+	Gas         *GasResponse `json:"gas,omitempty"`
+}
+
+func NewReadingResponse(r *repository.MeterReading) (*ReadingResponse, error) {
+	res := &ReadingResponse{
+		DeviceID:    r.DeviceID,
+		MeasuredAt:  r.MeasuredAt,
+		ImportKw:    r.ImportKw,
+		ExportKw:    r.ExportKw,
+		NetKw:       r.ImportKw - r.ExportKw,
+		HouseholdKw: r.HouseholdKw,
+		ImportedKwh: r.ImportedKwh,
+		ExportedKwh: r.ExportedKwh,
+		Tariff:      r.Tariff,
+		Phases:      []dsmr.Phase{},
+	}
+
+	if err := json.Unmarshal(r.Phases, &res.Phases); err != nil {
+		return nil, err
+	}
+
+	if r.GasM3.Valid && r.GasMeasuredAt.Valid {
+		res.Gas = &GasResponse{MeasuredAt: r.GasMeasuredAt.Time, M3: r.GasM3.Float64}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,90 @@
+package meter
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxTelegramBytes is well above the size of a DSMR 5 telegram, which is
+// around 1 KiB without text messages.
+const maxTelegramBytes = 16 << 10
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// IngestHandler accepts a raw P1 telegram as the request body. Telegrams
+// arriving within a minute of the last stored one are parsed but not stored,
+// which is answered with 202 instead of 201.
+func (h *Handler) IngestHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxTelegramBytes))
+	if err != nil {
+		return httpx.BadRequest(ctx, "Telegram could not be read or is too large")
+	}
+
+	reading, stored, err := h.svc.IngestOwned(ctx, identityID, deviceID, raw)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewReadingResponse(reading)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to decode meter reading", err)
+	}
+
+	status := http.StatusAccepted
+	if stored {
+		status = http.StatusCreated
+	}
+
+	httpx.ResponseWithJSON(w, status, res)
+	return nil
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	readings, err := h.svc.List(ctx, identityID, deviceID, ParseListReadingsRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	res := make([]*ReadingResponse, 0, len(readings))
+	for i := range readings {
+		reading, err := NewReadingResponse(&readings[i])
+		if err != nil {
+			return httpx.InternalErr(ctx, "Failed to decode meter reading", err)
+		}
+		res = append(res, reading)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
@@ -0,0 +1,270 @@
+// Package meter stores the readings of the smart meter at the grid connection
+// of a site, received as DSMR P1 telegrams, and derives the load of the
+// household behind it.
+package meter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/chargingprofile"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/dsmr"
+	"github.com/V2G-Minor-Fontys/server/pkg/mqtt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"time"
+)
+
+const (
+	// storeInterval thins out the telegrams, meters send one every second
+	// (DSMR 5) or every ten seconds (DSMR 4).
+	storeInterval = time.Minute
+	// currentWithin is how old a reading may be to count as the current load.
+	currentWithin = 2 * time.Minute
+	// reportStaleAfter ignores charger power readings older than this.
+	reportStaleAfter = 2 * time.Minute
+	// historyDays of readings are averaged into the household load forecast.
+	historyDays = 7
+	// ForecastResolution is the length of the household load forecast slots.
+	ForecastResolution = 15 * time.Minute
+)
+
+type Service struct {
+	queries *repository.Queries
+	devices *device.Service
+	broker  *mqtt.Client
+}
+
+func NewService(queries *repository.Queries, devices *device.Service, broker *mqtt.Client) *Service {
+	return &Service{queries: queries, devices: devices, broker: broker}
+}
+
+// IngestOwned parses a telegram posted for one of the caller's devices.
+func (s *Service) IngestOwned(ctx context.Context, identityID, deviceID uuid.UUID, raw []byte) (*repository.MeterReading, bool, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return s.Ingest(ctx, d, raw, time.Now())
+}
+
+// Ingest parses a telegram into a reading of the device and reports whether
+// it was stored, which happens at most once per storeInterval. The household
+// load is the net import minus what the chargers of the site draw.
+func (s *Service) Ingest(ctx context.Context, d *repository.Device, raw []byte, now time.Time) (*repository.MeterReading, bool, error) {
+	if d.Kind != device.KindMeter && d.Kind != device.KindGateway {
+		return nil, false, httpx.BadRequest(ctx, "Telegrams can only be sent by meters and gateways")
+	}
+
+	t, err := dsmr.Parse(raw)
+	if err != nil {
+		return nil, false, httpx.BadRequest(ctx, "Telegram is invalid: "+err.Error())
+	}
+
+	phases, err := json.Marshal(t.Phases)
+	if err != nil {
+		return nil, false, httpx.InternalErr(ctx, "Failed to encode phases", err)
+	}
+
+	measuredAt := t.Timestamp
+	if measuredAt.IsZero() {
+		measuredAt = now.UTC().Truncate(time.Second)
+	}
+
+	latest, err := s.queries.GetLatestMeterReadingByDeviceId(ctx, d.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, httpx.InternalErr(ctx, "Failed to retrieve meter reading", err)
+	}
+	store := err != nil || measuredAt.Sub(latest.MeasuredAt) >= storeInterval
+
+	params := repository.CreateMeterReadingParams{
+		DeviceID:    d.ID,
+		MeasuredAt:  measuredAt,
+		ImportKw:    t.ImportKw,
+		ExportKw:    t.ExportKw,
+		ImportedKwh: t.ImportedKwh(),
+		ExportedKwh: t.ExportedKwh(),
+		Tariff:      int16(t.Tariff),
+		Phases:      phases,
+	}
+	if t.Gas != nil {
+		params.GasM3 = pgtype.Float8{Float64: t.Gas.M3, Valid: true}
+		params.GasMeasuredAt = pgtype.Timestamptz{Time: t.Gas.MeasuredAt, Valid: true}
+	}
+
+	// Telegrams that are not stored reuse the charger power of the latest
+	// reading rather than looking it up every second.
+	if store {
+		params.HouseholdKw = t.NetKw() - s.ChargersKw(ctx, d.SiteID, now)
+		if err := s.queries.CreateMeterReading(ctx, params); err != nil {
+			return nil, false, httpx.InternalErr(ctx, "Failed to store meter reading", err)
+		}
+	} else {
+		params.HouseholdKw = t.NetKw() - (latest.ImportKw - latest.ExportKw - latest.HouseholdKw)
+	}
+
+	return &repository.MeterReading{
+		DeviceID:      params.DeviceID,
+		MeasuredAt:    params.MeasuredAt,
+		ImportKw:      params.ImportKw,
+		ExportKw:      params.ExportKw,
+		HouseholdKw:   params.HouseholdKw,
+		ImportedKwh:   params.ImportedKwh,
+		ExportedKwh:   params.ExportedKwh,
+		Tariff:        params.Tariff,
+		Phases:        params.Phases,
+		GasM3:         params.GasM3,
+		GasMeasuredAt: params.GasMeasuredAt,
+	}, store, nil
+}
+
+func (s *Service) List(ctx context.Context, identityID, deviceID uuid.UUID, req ListReadingsRequest) ([]repository.MeterReading, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	readings, err := s.queries.ListMeterReadingsByDeviceId(ctx, repository.ListMeterReadingsByDeviceIdParams{
+		DeviceID:       d.ID,
+		MeasuredFrom:   req.From,
+		MeasuredBefore: req.To,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve meter readings", err)
+	}
+
+	return readings, nil
+}
+
+// Current returns the latest reading of the site's meter when it is recent
+// enough to describe the load right now.
+func (s *Service) Current(ctx context.Context, siteID uuid.UUID, now time.Time) (*repository.MeterReading, bool) {
+	r, err := s.queries.GetLatestMeterReadingBySiteId(ctx, pgtype.UUID{Bytes: siteID, Valid: true})
+	if err != nil || now.Sub(r.MeasuredAt) > currentWithin {
+		return nil, false
+	}
+
+	return &r, true
+}
+
+// Load is the expected household load from Start until the next slot.
+type Load struct {
+	Start       time.Time
+	HouseholdKw float64
+}
+
+// HouseholdForecast predicts the household load of a site per slot from the
+// average of the same time of day over the past week. The current slot uses
+// the latest reading. Sites without readings have no forecast.
+func (s *Service) HouseholdForecast(ctx context.Context, siteID uuid.UUID, from, to, now time.Time) ([]Load, error) {
+	readings, err := s.queries.ListMeterReadingsBySiteId(ctx, repository.ListMeterReadingsBySiteIdParams{
+		SiteID:       pgtype.UUID{Bytes: siteID, Valid: true},
+		MeasuredFrom: now.AddDate(0, 0, -historyDays),
+	})
+	if err != nil || len(readings) == 0 {
+		return nil, err
+	}
+
+	const slotsPerDay = int(24 * time.Hour / ForecastResolution)
+	var sums, counts [slotsPerDay]float64
+	total := 0.0
+	for _, r := range readings {
+		slot := slotOfDay(r.MeasuredAt)
+		sums[slot] += r.HouseholdKw
+		counts[slot]++
+		total += r.HouseholdKw
+	}
+	average := total / float64(len(readings))
+
+	current, hasCurrent := s.Current(ctx, siteID, now)
+	var loads []Load
+	for t := from.UTC().Truncate(ForecastResolution); t.Before(to); t = t.Add(ForecastResolution) {
+		load := Load{Start: t, HouseholdKw: average}
+		if slot := slotOfDay(t); counts[slot] > 0 {
+			load.HouseholdKw = sums[slot] / counts[slot]
+		}
+		if hasCurrent && !now.Before(t) && now.Before(t.Add(ForecastResolution)) {
+			load.HouseholdKw = current.HouseholdKw
+		}
+
+		loads = append(loads, load)
+	}
+
+	return loads, nil
+}
+
+func slotOfDay(t time.Time) int {
+	t = t.UTC()
+	return (t.Hour()*60 + t.Minute()) / int(ForecastResolution/time.Minute)
+}
+
+// ChargersKw sums the power the chargers of a site report. Chargers without
+// a recent report are assumed to be idle.
+func (s *Service) ChargersKw(ctx context.Context, siteID pgtype.UUID, now time.Time) float64 {
+	if !siteID.Valid {
+		return 0
+	}
+
+	devices, err := s.queries.ListDevicesBySiteId(ctx, siteID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve site devices", "site.id", uuid.UUID(siteID.Bytes), "error", err)
+		return 0
+	}
+
+	total := 0.0
+	for _, d := range devices {
+		if d.Kind != device.KindCharger {
+			continue
+		}
+
+		sh, err := s.queries.GetDeviceShadow(ctx, d.ID)
+		if err != nil || !sh.ReportedUpdatedAt.Valid || now.Sub(sh.ReportedUpdatedAt.Time) > reportStaleAfter {
+			continue
+		}
+
+		var reported map[string]any
+		if err := json.Unmarshal(sh.Reported, &reported); err != nil {
+			continue
+		}
+
+		if power, ok := reported[chargingprofile.PowerField].(float64); ok {
+			total += power
+		}
+	}
+
+	return total
+}
+
+// Subscribe starts listening for telegrams forwarded by gateways.
+func (s *Service) Subscribe() error {
+	return s.broker.Subscribe(TelegramSubscription, s.handleTelegram)
+}
+
+func (s *Service) handleTelegram(topic string, payload []byte) {
+	ctx := context.Background()
+	serial, ok := serialFromTelegramTopic(topic)
+	if !ok {
+		slog.Warn("Ignoring telegram on unexpected topic", "topic", topic)
+		return
+	}
+
+	d, err := s.queries.GetDeviceBySerialNumber(ctx, serial)
+	if err != nil {
+		slog.Warn("Telegram from unknown device", "serialNumber", serial, "error", err)
+		return
+	}
+
+	if _, _, err := s.Ingest(ctx, &d, payload, time.Now()); err != nil {
+		slog.Warn("Failed to ingest telegram", "device.id", d.ID, "error", err)
+	}
+}
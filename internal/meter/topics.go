@@ -0,0 +1,17 @@
+package meter
+
+import "strings"
+
+// TelegramSubscription receives raw P1 telegrams that gateways forward from
+// the smart meter.
+const TelegramSubscription = "devices/+/p1"
+
+// serialFromTelegramTopic extracts the serial number from devices/{serial}/p1.
+func serialFromTelegramTopic(topic string) (string, bool) {
+	parts := strings.Split(topic, "/")
+	if len(parts) != 3 || parts[0] != "devices" || parts[2] != "p1" {
+		return "", false
+	}
+
+	return parts[1], parts[1] != ""
+}
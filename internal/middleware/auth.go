@@ -5,6 +5,7 @@ import (
 	"github.com/V2G-Minor-Fontys/server/internal/config"
 	"github.com/V2G-Minor-Fontys/server/internal/httpx"
 	"github.com/V2G-Minor-Fontys/server/pkg/jwt"
+	"github.com/google/uuid"
 	"net/http"
 	"strings"
 )
@@ -33,3 +34,17 @@ func AuthVerifier(cfg *config.Jwt) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+func GetIdentityID(ctx context.Context) (uuid.UUID, error) {
+	sub, ok := ctx.Value(IdentityIDKey).(string)
+	if !ok {
+		return uuid.Nil, httpx.Unauthorized(ctx, "Request is not authenticated")
+	}
+
+	id, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, httpx.Unauthorized(ctx, "Access token subject is not a valid identity")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,106 @@
+package price
+
+import (
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRange = 48 * time.Hour
+	maxRange     = 31 * 24 * time.Hour
+)
+
+type ListPricesRequest struct {
+	Zone string
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseListPricesRequest reads the zone, from and to query parameters. From
+// defaults to the start of the current UTC day and to two days later.
+func ParseListPricesRequest(q url.Values, now time.Time) ListPricesRequest {
+	req := ListPricesRequest{
+		Zone:      strings.ToUpper(q.Get("zone")),
+		From:      now.UTC().Truncate(24 * time.Hour),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	req.To = req.From.Add(defaultRange)
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	return req
+}
+
+func (r *ListPricesRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if r.Zone == "" {
+		errs.Add("zone", "Zone is required")
+	} else if _, ok := Zones[r.Zone]; !ok {
+		errs.Add("zone", fmt.Sprintf("Unknown bidding zone %s", r.Zone))
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxRange {
+			errs.Add("to", "The requested range cannot exceed 31 days")
+		}
+	}
+
+	return errs
+}
+
+type PricePointResponse struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Price float64   `json:"price"`
+}
+
+type PricesResponse struct {
+	Zone     string                `json:"zone"`
+	Currency string                `json:"currency"`
+	Unit     string                `json:"unit"`
+	Prices   []*PricePointResponse `json:"prices"`
+}
+
+func NewPricesResponse(zone string, points []Point) *PricesResponse {
+	res := &PricesResponse{
+		Zone:     zone,
+		Currency: "EUR",
+		Unit:     "MWh",
+		Prices:   make([]*PricePointResponse, 0, len(points)),
+	}
+
+	for _, p := range points {
+		res.Prices = append(res.Prices, &PricePointResponse{
+			Start: p.Start,
+			End:   p.End(),
+			Price: p.PriceEurMwh,
+		})
+	}
+
+	return res
+}
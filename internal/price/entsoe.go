@@ -0,0 +1,154 @@
+package price
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// entsoeTimeLayout is the minute precision UTC format of ENTSO-E intervals.
+const entsoeTimeLayout = "2006-01-02T15:04Z"
+
+// Zones maps the bidding zones the server knows to their EIC codes.
+var Zones = map[string]string{
+	"NL":    "10YNL----------L",
+	"BE":    "10YBE----------2",
+	"DE_LU": "10Y1001A1001A82H",
+	"FR":    "10YFR-RTE------C",
+	"DK1":   "10YDK-1--------W",
+	"DK2":   "10YDK-2--------M",
+}
+
+var ErrNoData = errors.New("no prices available")
+
+type entsoeDocument struct {
+	XMLName    xml.Name
+	TimeSeries []entsoeTimeSeries `xml:"TimeSeries"`
+	Reason     []struct {
+		Code string `xml:"code"`
+		Text string `xml:"text"`
+	} `xml:"Reason"`
+}
+
+type entsoeTimeSeries struct {
+	Currency  string         `xml:"currency_Unit.name"`
+	Unit      string         `xml:"price_Measure_Unit.name"`
+	CurveType string         `xml:"curveType"`
+	Periods   []entsoePeriod `xml:"Period"`
+}
+
+type entsoePeriod struct {
+	Start      string `xml:"timeInterval>start"`
+	End        string `xml:"timeInterval>end"`
+	Resolution string `xml:"resolution"`
+	Points     []struct {
+		Position int     `xml:"position"`
+		Amount   float64 `xml:"price.amount"`
+	} `xml:"Point"`
+}
+
+// ParseEntsoe reads a day-ahead Publication_MarketDocument. Positions omitted
+// by the A03 curve type repeat the previous price. An acknowledgement
+// document, which ENTSO-E returns when nothing is published, yields ErrNoData.
+func ParseEntsoe(r io.Reader, zone string) ([]Point, error) {
+	var doc entsoeDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not decode ENTSO-E document: %w", err)
+	}
+
+	switch doc.XMLName.Local {
+	case "Publication_MarketDocument":
+	case "Acknowledgement_MarketDocument":
+		if len(doc.Reason) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoData, doc.Reason[0].Text)
+		}
+		return nil, ErrNoData
+	default:
+		return nil, fmt.Errorf("unexpected ENTSO-E document %s", doc.XMLName.Local)
+	}
+
+	var points []Point
+	for _, ts := range doc.TimeSeries {
+		if ts.Currency != "EUR" || !strings.EqualFold(ts.Unit, "MWH") {
+			return nil, fmt.Errorf("unsupported price unit %s/%s", ts.Currency, ts.Unit)
+		}
+
+		for _, p := range ts.Periods {
+			parsed, err := parseEntsoePeriod(p, zone)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, parsed...)
+		}
+	}
+
+	if len(points) == 0 {
+		return nil, ErrNoData
+	}
+
+	return points, nil
+}
+
+func parseEntsoePeriod(p entsoePeriod, zone string) ([]Point, error) {
+	start, err := time.Parse(entsoeTimeLayout, p.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid period start %q", p.Start)
+	}
+	end, err := time.Parse(entsoeTimeLayout, p.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid period end %q", p.End)
+	}
+	resolution, err := parseResolution(p.Resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	count := int(end.Sub(start) / resolution)
+	prices := make(map[int]float64, len(p.Points))
+	for _, pt := range p.Points {
+		if pt.Position < 1 || pt.Position > count {
+			return nil, fmt.Errorf("point position %d outside of period", pt.Position)
+		}
+		prices[pt.Position] = pt.Amount
+	}
+
+	points := make([]Point, 0, count)
+	last, known := 0.0, false
+	for pos := 1; pos <= count; pos++ {
+		if amount, ok := prices[pos]; ok {
+			last, known = amount, true
+		}
+		if !known {
+			continue
+		}
+
+		points = append(points, Point{
+			Zone:        zone,
+			Start:       start.Add(time.Duration(pos-1) * resolution),
+			Resolution:  resolution,
+			PriceEurMwh: last,
+		})
+	}
+
+	return points, nil
+}
+
+// parseResolution supports the ISO 8601 durations ENTSO-E uses for day-ahead
+// prices, such as PT15M and PT60M.
+func parseResolution(s string) (time.Duration, error) {
+	switch {
+	case strings.HasPrefix(s, "PT") && strings.HasSuffix(s, "M"):
+		minutes, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(s, "PT"), "M"))
+		if err == nil && minutes > 0 {
+			return time.Duration(minutes) * time.Minute, nil
+		}
+	case s == "P1D":
+		return 24 * time.Hour, nil
+	}
+
+	return 0, fmt.Errorf("unsupported resolution %q", s)
+}
@@ -0,0 +1,228 @@
+// Package price imports day-ahead wholesale electricity prices per bidding
+// zone and serves them to the API and the scheduler.
+package price
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/cache"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	fetchInterval = time.Hour
+	// fetchHorizon covers today and tomorrow, day-ahead prices for tomorrow
+	// are published around noon.
+	fetchHorizon = 48 * time.Hour
+	day          = 24 * time.Hour
+)
+
+type Service struct {
+	db      *pgxpool.Pool
+	queries *repository.Queries
+	source  Source
+	zones   []string
+	cache   *cache.Loader
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, source Source, zones []string, c *cache.Loader) *Service {
+	return &Service{db: db, queries: queries, source: source, zones: zones, cache: c}
+}
+
+// CacheKey is the key of the prices of a zone starting on a UTC day.
+func CacheKey(zone string, day time.Time) string {
+	return "prices:" + zone + ":" + day.UTC().Format(time.DateOnly)
+}
+
+func updatedAtCacheKey(zone string) string {
+	return "prices:" + zone + ":updated_at"
+}
+
+// DefaultZone is the zone used for users without a more specific one.
+func (s *Service) DefaultZone() string {
+	if len(s.zones) == 0 {
+		return "NL"
+	}
+
+	return s.zones[0]
+}
+
+func (s *Service) List(ctx context.Context, req ListPricesRequest) ([]Point, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	points, err := s.Range(ctx, req.Zone, req.From, req.To)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve prices", err)
+	}
+
+	return points, nil
+}
+
+// Range returns the prices of a zone starting in [from, to). When a zone is
+// published in several resolutions the finest one is preferred.
+func (s *Service) Range(ctx context.Context, zone string, from, to time.Time) ([]Point, error) {
+	all, err := s.load(ctx, zone, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(all))
+	var coveredUntil time.Time
+	for _, p := range all {
+		if p.Start.Before(coveredUntil) {
+			continue
+		}
+
+		points = append(points, p)
+		coveredUntil = p.End()
+	}
+
+	return points, nil
+}
+
+// load returns every published price of a zone starting in [from, to). The
+// API and the scheduler keep asking for the prices of yesterday up to
+// tomorrow, those are read per day through the cache.
+func (s *Service) load(ctx context.Context, zone string, from, to time.Time) ([]Point, error) {
+	today := time.Now().UTC().Truncate(day)
+	if from.Before(today.Add(-day)) || to.After(today.Add(fetchHorizon)) {
+		return s.query(ctx, zone, from, to)
+	}
+
+	var points []Point
+	for d := from.UTC().Truncate(day); d.Before(to); d = d.Add(day) {
+		dayPoints, err := cache.GetOrLoadFor(ctx, s.cache, CacheKey(zone, d), fetchInterval, func(ctx context.Context) ([]Point, error) {
+			return s.query(ctx, zone, d, d.Add(day))
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range dayPoints {
+			if !p.Start.Before(from) && p.Start.Before(to) {
+				points = append(points, p)
+			}
+		}
+	}
+
+	return points, nil
+}
+
+func (s *Service) query(ctx context.Context, zone string, from, to time.Time) ([]Point, error) {
+	rows, err := s.queries.ListPrices(ctx, repository.ListPricesParams{
+		Zone:         zone,
+		StartsFrom:   from,
+		StartsBefore: to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, Point{
+			Zone:        row.Zone,
+			Start:       row.StartsAt.UTC(),
+			Resolution:  time.Duration(row.ResolutionMinutes) * time.Minute,
+			PriceEurMwh: row.PriceEurMwh,
+		})
+	}
+
+	return points, nil
+}
+
+func (s *Service) UpdatedAt(ctx context.Context, zone string) (time.Time, error) {
+	return cache.GetOrLoadFor(ctx, s.cache, updatedAtCacheKey(zone), fetchInterval, func(ctx context.Context) (time.Time, error) {
+		return s.queries.GetPricesUpdatedAt(ctx, zone)
+	})
+}
+
+// Import stores the points in a single transaction, unchanged prices keep
+// their update time so that schedules are only refreshed on real changes.
+func (s *Service) Import(ctx context.Context, points []Point, source string) error {
+	sort.Slice(points, func(i, j int) bool { return points[i].Start.Before(points[j].Start) })
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	keys := make(map[string]struct{})
+	for _, p := range points {
+		keys[CacheKey(p.Zone, p.Start.UTC().Truncate(day))] = struct{}{}
+		keys[updatedAtCacheKey(p.Zone)] = struct{}{}
+		if err := qtx.UpsertPrice(ctx, repository.UpsertPriceParams{
+			Zone:              p.Zone,
+			StartsAt:          p.Start,
+			ResolutionMinutes: int32(p.Resolution / time.Minute),
+			PriceEurMwh:       p.PriceEurMwh,
+			Source:            source,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	s.cache.Invalidate(ctx, slices.Collect(maps.Keys(keys))...)
+	return nil
+}
+
+// Run fetches prices for all configured zones right away and then every hour.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(fetchInterval)
+	defer ticker.Stop()
+
+	for {
+		s.fetch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) fetch(ctx context.Context) {
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	to := from.Add(fetchHorizon)
+
+	for _, zone := range s.zones {
+		points, err := s.source.Fetch(ctx, zone, from, to)
+		if errors.Is(err, ErrNoData) {
+			slog.DebugContext(ctx, "No prices published yet", "zone", zone, "error", err)
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to fetch prices", "zone", zone, "source", s.source.Name(), "error", err)
+			continue
+		}
+
+		if err := s.Import(ctx, points, s.source.Name()); err != nil {
+			slog.ErrorContext(ctx, "Failed to import prices", "zone", zone, "error", err)
+			continue
+		}
+
+		slog.InfoContext(ctx, "Imported prices", "zone", zone, "count", len(points))
+	}
+}
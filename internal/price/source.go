@@ -0,0 +1,170 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	SourceEntsoe = "entsoe"
+	SourceFile   = "file"
+
+	entsoeURL         = "https://web-api.tp.entsoe.eu/api"
+	entsoeDayAhead    = "A44"
+	entsoeQueryLayout = "200601021504"
+	httpTimeout       = 30 * time.Second
+)
+
+// Point is the wholesale price of one interval in a bidding zone.
+type Point struct {
+	Zone        string
+	Start       time.Time
+	Resolution  time.Duration
+	PriceEurMwh float64
+}
+
+func (p Point) End() time.Time {
+	return p.Start.Add(p.Resolution)
+}
+
+// Source supplies day-ahead prices of a bidding zone for [from, to).
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, zone string, from, to time.Time) ([]Point, error)
+}
+
+func NewSource(cfg *config.Prices) (Source, error) {
+	switch cfg.Source {
+	case SourceEntsoe:
+		if cfg.EntsoeToken == "" {
+			return nil, errors.New("an ENTSO-E security token is required for the entsoe price source")
+		}
+		return NewHTTPSource(entsoeURL, cfg.EntsoeToken), nil
+	case SourceFile:
+		return NewFileSource(cfg.Directory), nil
+	}
+
+	return nil, fmt.Errorf("unknown price source %q", cfg.Source)
+}
+
+// HTTPSource downloads day-ahead prices from the ENTSO-E Transparency
+// Platform REST API.
+type HTTPSource struct {
+	client  *http.Client
+	baseURL string
+	token   string
+}
+
+func NewHTTPSource(baseURL, token string) *HTTPSource {
+	return &HTTPSource{
+		client:  &http.Client{Timeout: httpTimeout},
+		baseURL: baseURL,
+		token:   token,
+	}
+}
+
+func (s *HTTPSource) Name() string {
+	return SourceEntsoe
+}
+
+func (s *HTTPSource) Fetch(ctx context.Context, zone string, from, to time.Time) ([]Point, error) {
+	eic, ok := Zones[zone]
+	if !ok {
+		return nil, fmt.Errorf("unknown bidding zone %q", zone)
+	}
+
+	q := url.Values{}
+	q.Set("securityToken", s.token)
+	q.Set("documentType", entsoeDayAhead)
+	q.Set("in_Domain", eic)
+	q.Set("out_Domain", eic)
+	q.Set("periodStart", from.UTC().Format(entsoeQueryLayout))
+	q.Set("periodEnd", to.UTC().Format(entsoeQueryLayout))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// ENTSO-E answers missing data with an acknowledgement document and a
+	// 400 status, which the parser turns into ErrNoData.
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusBadRequest {
+		return nil, fmt.Errorf("ENTSO-E responded with status %d", res.StatusCode)
+	}
+
+	points, err := ParseEntsoe(res.Body, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	return within(points, from, to), nil
+}
+
+// FileSource reads prices from {zone}.xml, in ENTSO-E format, or {zone}.csv
+// in a directory, for development and tests without network access.
+type FileSource struct {
+	dir string
+}
+
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{dir: dir}
+}
+
+func (s *FileSource) Name() string {
+	return SourceFile
+}
+
+func (s *FileSource) Fetch(_ context.Context, zone string, from, to time.Time) ([]Point, error) {
+	parsers := []struct {
+		ext   string
+		parse func(io.Reader, string) ([]Point, error)
+	}{
+		{".xml", ParseEntsoe},
+		{".csv", ParseCSV},
+	}
+
+	for _, p := range parsers {
+		f, err := os.Open(filepath.Join(s.dir, zone+p.ext))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		points, err := p.parse(f, zone)
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		return within(points, from, to), nil
+	}
+
+	return nil, ErrNoData
+}
+
+func within(points []Point, from, to time.Time) []Point {
+	res := make([]Point, 0, len(points))
+	for _, p := range points {
+		if p.End().After(from) && p.Start.Before(to) {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
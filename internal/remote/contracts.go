@@ -0,0 +1,95 @@
+package remote
+
+import (
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	ActionStart     = "start"
+	ActionStop      = "stop"
+	ActionPause     = "pause"
+	ActionResume    = "resume"
+	ActionDischarge = "discharge"
+)
+
+// Channels a request reaches the device over.
+const (
+	ChannelOcpp = "ocpp"
+	ChannelMqtt = "mqtt"
+)
+
+const (
+	// ackTimeout bounds how long a request waits for the device to answer.
+	ackTimeout              = 30 * time.Second
+	maxDurationMinutes      = 24 * 60
+	defaultDischargeMinutes = 60
+)
+
+type PauseRequest struct {
+	// DurationMinutes is how long the session pauses, until it is resumed or
+	// for a day when zero.
+	DurationMinutes int `json:"durationMinutes"`
+}
+
+func (r *PauseRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.DurationMinutes < 0 || r.DurationMinutes > maxDurationMinutes {
+		errs.Add("durationMinutes", fmt.Sprintf("Duration cannot be negative or exceed %d minutes", maxDurationMinutes))
+	}
+
+	return errs
+}
+
+type DischargeRequest struct {
+	// PowerKw is the power to discharge at.
+	PowerKw float64 `json:"powerKw"`
+	// DurationMinutes defaults to an hour.
+	DurationMinutes int `json:"durationMinutes"`
+}
+
+func (r *DischargeRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.PowerKw <= 0 {
+		errs.Add("powerKw", "Power must be a positive number")
+	}
+	if r.DurationMinutes < 0 || r.DurationMinutes > maxDurationMinutes {
+		errs.Add("durationMinutes", fmt.Sprintf("Duration cannot be negative or exceed %d minutes", maxDurationMinutes))
+	}
+
+	return errs
+}
+
+// PowerRequest is the power a device controlled over MQTT charges or
+// discharges at.
+type PowerRequest struct {
+	PowerKw float64 `json:"powerKw"`
+}
+
+func (r *PowerRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.PowerKw <= 0 {
+		errs.Add("powerKw", "Power must be a positive number")
+	}
+
+	return errs
+}
+
+// ControlResponse is the acknowledgement of the device. A started charger
+// opens the session once the vehicle is authorised, so it can appear in the
+// sessions shortly after.
+type ControlResponse struct {
+	Action    string     `json:"action"`
+	Channel   string     `json:"channel"`
+	DeviceID  uuid.UUID  `json:"deviceId"`
+	Connector int32      `json:"connector,omitempty"`
+	SessionID *uuid.UUID `json:"sessionId,omitempty"`
+	// TransactionID is set when a 2.0.1 charger started the transaction
+	// right away.
+	TransactionID string     `json:"transactionId,omitempty"`
+	CommandID     *uuid.UUID `json:"commandId,omitempty"`
+	// Until is when a pause or discharge ends by itself.
+	Until *time.Time `json:"until,omitempty"`
+}
@@ -0,0 +1,104 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) StartHandler(w http.ResponseWriter, r *http.Request) error {
+	return h.control(w, r, h.svc.Start)
+}
+
+func (h *Handler) StopHandler(w http.ResponseWriter, r *http.Request) error {
+	return h.control(w, r, h.svc.Stop)
+}
+
+// PauseHandler pauses the session, the body with the duration is optional.
+func (h *Handler) PauseHandler(w http.ResponseWriter, r *http.Request) error {
+	var req PauseRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return httpx.BadRequest(r.Context(), "Could not parse JSON body")
+		}
+	}
+
+	return h.control(w, r, func(ctx context.Context, identityID, sessionID uuid.UUID) (*ControlResponse, error) {
+		return h.svc.Pause(ctx, identityID, sessionID, req)
+	})
+}
+
+func (h *Handler) ResumeHandler(w http.ResponseWriter, r *http.Request) error {
+	return h.control(w, r, h.svc.Resume)
+}
+
+func (h *Handler) DischargeHandler(w http.ResponseWriter, r *http.Request) error {
+	var req DischargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(r.Context(), "Could not parse JSON body")
+	}
+
+	return h.control(w, r, func(ctx context.Context, identityID, sessionID uuid.UUID) (*ControlResponse, error) {
+		return h.svc.Discharge(ctx, identityID, sessionID, req)
+	})
+}
+
+func (h *Handler) StartDeviceHandler(w http.ResponseWriter, r *http.Request) error {
+	var req PowerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(r.Context(), "Could not parse JSON body")
+	}
+
+	return h.control(w, r, func(ctx context.Context, identityID, deviceID uuid.UUID) (*ControlResponse, error) {
+		return h.svc.StartDevice(ctx, identityID, deviceID, req)
+	})
+}
+
+func (h *Handler) PauseDeviceHandler(w http.ResponseWriter, r *http.Request) error {
+	return h.control(w, r, h.svc.PauseDevice)
+}
+
+func (h *Handler) DischargeDeviceHandler(w http.ResponseWriter, r *http.Request) error {
+	var req PowerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(r.Context(), "Could not parse JSON body")
+	}
+
+	return h.control(w, r, func(ctx context.Context, identityID, deviceID uuid.UUID) (*ControlResponse, error) {
+		return h.svc.DischargeDevice(ctx, identityID, deviceID, req)
+	})
+}
+
+// control runs an action on the connector, session or device in the id URL
+// parameter and answers with the acknowledgement of the device.
+func (h *Handler) control(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, identityID, id uuid.UUID) (*ControlResponse, error)) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	res, err := action(ctx, identityID, id)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
@@ -0,0 +1,351 @@
+// Package remote lets users start, stop and pause charging and force
+// discharging from the app. Chargers are reached over their OCPP connection,
+// other devices over the MQTT command channel, and every request waits for
+// the device to acknowledge it.
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/chargepoint"
+	"github.com/V2G-Minor-Fontys/server/internal/chargingprofile"
+	"github.com/V2G-Minor-Fontys/server/internal/command"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/reservation"
+	"github.com/V2G-Minor-Fontys/server/internal/session"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+type Service struct {
+	queries      *repository.Queries
+	devices      *device.Service
+	chargers     *chargepoint.Server
+	profiles     *chargingprofile.Service
+	commands     *command.Service
+	reservations *reservation.Service
+}
+
+func NewService(queries *repository.Queries, devices *device.Service, chargers *chargepoint.Server, profiles *chargingprofile.Service, commands *command.Service, reservations *reservation.Service) *Service {
+	return &Service{
+		queries:      queries,
+		devices:      devices,
+		chargers:     chargers,
+		profiles:     profiles,
+		commands:     commands,
+		reservations: reservations,
+	}
+}
+
+// Start asks the charger to start a transaction on the connector with the id
+// tag of the caller. The owner of the charger may start it, and at shared
+// sites so may the member holding the current reservation of the connector.
+func (s *Service) Start(ctx context.Context, identityID, connectorID uuid.UUID) (*ControlResponse, error) {
+	c, err := s.queries.GetConnectorById(ctx, connectorID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Connector could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve connector", err)
+	}
+
+	if c.OwnerID != identityID {
+		holds, err := s.reservations.Holds(ctx, identityID, c.ID, time.Now().UTC())
+		if err != nil {
+			return nil, httpx.InternalErr(ctx, "Failed to check reservations", err)
+		}
+		if !holds {
+			return nil, httpx.NotFound(ctx, "Connector could not be found")
+		}
+	}
+
+	conn, err := s.chargers.Connection(c.DeviceID)
+	if err != nil {
+		return nil, httpx.Conflict(ctx, "Charger is not connected")
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, ackTimeout)
+	defer cancel()
+
+	res := &ControlResponse{Action: ActionStart, Channel: ChannelOcpp, DeviceID: c.DeviceID, Connector: c.ConnectorID}
+	connector := int(c.ConnectorID)
+	idTag := chargepoint.IdTag(identityID)
+	var status string
+	if conn.Version == ocpp.V201 {
+		var ans ocpp.RequestStartTransactionResponse201
+		err = conn.Call(callCtx, ocpp.ActionRequestStartTransaction, ocpp.RequestStartTransactionRequest201{
+			EvseID:        &connector,
+			RemoteStartID: rand.IntN(math.MaxInt32),
+			IdToken:       ocpp.IdToken{IdToken: idTag, Type: ocpp.IdTokenCentral},
+		}, &ans)
+		status, res.TransactionID = ans.Status, ans.TransactionID
+	} else {
+		var ans ocpp.StatusResponse
+		err = conn.Call(callCtx, ocpp.ActionRemoteStartTransaction, ocpp.RemoteStartTransactionRequest16{
+			ConnectorID: &connector,
+			IdTag:       idTag,
+		}, &ans)
+		status = ans.Status
+	}
+
+	if err := answer(ctx, "start charging", status, err); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Stop asks the charger to end the transaction of the session.
+func (s *Service) Stop(ctx context.Context, identityID, sessionID uuid.UUID) (*ControlResponse, error) {
+	cs, conn, err := s.activeSession(ctx, identityID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, ackTimeout)
+	defer cancel()
+
+	var res ocpp.StatusResponse
+	if conn.Version == ocpp.V201 {
+		err = conn.Call(callCtx, ocpp.ActionRequestStopTransaction, ocpp.RequestStopTransactionRequest201{TransactionID: cs.TransactionID}, &res)
+	} else {
+		transactionID, convErr := strconv.Atoi(cs.TransactionID)
+		if convErr != nil {
+			return nil, httpx.InternalErr(ctx, "Session has no OCPP 1.6 transaction id", convErr)
+		}
+		err = conn.Call(callCtx, ocpp.ActionRemoteStopTransaction, ocpp.RemoteStopTransactionRequest16{TransactionID: transactionID}, &res)
+	}
+
+	if err := answer(ctx, "stop charging", res.Status, err); err != nil {
+		return nil, err
+	}
+
+	return newSessionResponse(ActionStop, cs, nil), nil
+}
+
+// Pause holds the session at zero power with a transaction profile, which
+// takes precedence over the schedule until it expires or is resumed.
+func (s *Service) Pause(ctx context.Context, identityID, sessionID uuid.UUID, req PauseRequest) (*ControlResponse, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	cs, _, err := s.activeSession(ctx, identityID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	minutes := req.DurationMinutes
+	if minutes == 0 {
+		minutes = maxDurationMinutes
+	}
+
+	return s.setPower(ctx, ActionPause, cs, 0, minutes)
+}
+
+// Discharge makes the vehicle of the session feed power back with a
+// negative transaction profile, which only 2.0.1 chargers accept.
+func (s *Service) Discharge(ctx context.Context, identityID, sessionID uuid.UUID, req DischargeRequest) (*ControlResponse, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	cs, conn, err := s.activeSession(ctx, identityID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if conn.Version != ocpp.V201 {
+		return nil, httpx.Conflict(ctx, "Charger cannot discharge, OCPP 1.6 has no way to request it")
+	}
+
+	minutes := req.DurationMinutes
+	if minutes == 0 {
+		minutes = defaultDischargeMinutes
+	}
+
+	return s.setPower(ctx, ActionDischarge, cs, -req.PowerKw, minutes)
+}
+
+// Resume removes the transaction profile of the connector of the session,
+// after which it follows its schedule again.
+func (s *Service) Resume(ctx context.Context, identityID, sessionID uuid.UUID) (*ControlResponse, error) {
+	cs, _, err := s.activeSession(ctx, identityID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, ackTimeout)
+	defer cancel()
+
+	if err := answer(ctx, "resume charging", ocpp.StatusAccepted, s.profiles.ReleaseConnectorPower(callCtx, cs.DeviceID, int(cs.ConnectorID))); err != nil {
+		return nil, err
+	}
+
+	return newSessionResponse(ActionResume, cs, nil), nil
+}
+
+// setPower installs the transaction profile on the connector of the session.
+// A profile the charger did not answer in time stays pending and is retried
+// by the reconciliation loop, like those of schedules.
+func (s *Service) setPower(ctx context.Context, action string, cs *repository.ChargingSession, powerKw float64, minutes int) (*ControlResponse, error) {
+	callCtx, cancel := context.WithTimeout(ctx, ackTimeout)
+	defer cancel()
+
+	until := time.Now().UTC().Add(time.Duration(minutes) * time.Minute)
+	err := s.profiles.SetConnectorPower(callCtx, cs.DeviceID, int(cs.ConnectorID), powerKw, until)
+	if err := answer(ctx, action, ocpp.StatusAccepted, err); err != nil {
+		return nil, err
+	}
+
+	return newSessionResponse(action, cs, &until), nil
+}
+
+// StartDevice makes a device controlled over MQTT charge at the given power.
+func (s *Service) StartDevice(ctx context.Context, identityID, deviceID uuid.UUID, req PowerRequest) (*ControlResponse, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	return s.command(ctx, identityID, deviceID, ActionStart, command.TypeSetChargePower, command.PowerPayload{PowerKw: req.PowerKw})
+}
+
+// PauseDevice makes a device controlled over MQTT stop charging and
+// discharging.
+func (s *Service) PauseDevice(ctx context.Context, identityID, deviceID uuid.UUID) (*ControlResponse, error) {
+	return s.command(ctx, identityID, deviceID, ActionPause, command.TypePause, struct{}{})
+}
+
+// DischargeDevice makes a device controlled over MQTT discharge at the given
+// power.
+func (s *Service) DischargeDevice(ctx context.Context, identityID, deviceID uuid.UUID, req PowerRequest) (*ControlResponse, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	return s.command(ctx, identityID, deviceID, ActionDischarge, command.TypeStartDischarge, command.PowerPayload{PowerKw: req.PowerKw})
+}
+
+// command sends a command over MQTT once, without the retries of the
+// command channel, and waits for the device to acknowledge it.
+func (s *Service) command(ctx context.Context, identityID, deviceID uuid.UUID, action, typ string, payload any) (*ControlResponse, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch d.Kind {
+	case device.KindGateway:
+	case device.KindCharger:
+		return nil, httpx.BadRequest(ctx, "Chargers are controlled through their connectors and sessions")
+	default:
+		return nil, httpx.BadRequest(ctx, "Only gateways can be controlled remotely")
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to encode command", err)
+	}
+
+	cmd, err := s.commands.Issue(ctx, identityID, d.ID, command.CreateCommandRequest{
+		Type:           typ,
+		Payload:        raw,
+		MaxAttempts:    1,
+		TimeoutSeconds: int32(ackTimeout / time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, ackTimeout)
+	defer cancel()
+
+	cmd, err = s.commands.Await(callCtx, cmd)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve command", err)
+	}
+
+	switch cmd.Status {
+	case command.StatusAcked:
+	case command.StatusNacked:
+		detail := "Device rejected the command"
+		if cmd.Reason != "" {
+			detail += ": " + cmd.Reason
+		}
+		return nil, httpx.Conflict(ctx, detail)
+	default:
+		return nil, httpx.GatewayTimeout(ctx, "Device did not acknowledge the command in time, it may be offline")
+	}
+
+	return &ControlResponse{Action: action, Channel: ChannelMqtt, DeviceID: d.ID, CommandID: &cmd.ID}, nil
+}
+
+// activeSession returns an active session the caller may control, which is
+// one on their charger or one they started themselves, with the connection
+// of its charger.
+func (s *Service) activeSession(ctx context.Context, identityID, sessionID uuid.UUID) (*repository.ChargingSession, *chargepoint.Connection, error) {
+	cs, err := s.queries.GetChargingSessionById(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, httpx.NotFound(ctx, "Charging session could not be found")
+		}
+
+		return nil, nil, httpx.InternalErr(ctx, "Failed to retrieve charging session", err)
+	}
+
+	if cs.OwnerID != identityID && cs.IDTag != chargepoint.IdTag(identityID) {
+		return nil, nil, httpx.NotFound(ctx, "Charging session could not be found")
+	}
+	if cs.Status != session.StatusActive {
+		return nil, nil, httpx.Conflict(ctx, "Charging session has already ended")
+	}
+
+	conn, err := s.chargers.Connection(cs.DeviceID)
+	if err != nil {
+		return nil, nil, httpx.Conflict(ctx, "Charger is not connected")
+	}
+
+	return &cs, conn, nil
+}
+
+// answer turns the outcome of a request to a charger into a problem detail.
+func answer(ctx context.Context, action, status string, err error) error {
+	var callErr *ocpp.CallError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return httpx.GatewayTimeout(ctx, fmt.Sprintf("Charger did not answer the request to %s in time", action))
+	case errors.Is(err, chargepoint.ErrNotConnected), errors.Is(err, chargepoint.ErrConnectionClosed):
+		return httpx.Conflict(ctx, "Charger is not connected")
+	case errors.As(err, &callErr):
+		return httpx.Conflict(ctx, fmt.Sprintf("Charger could not %s: %s", action, callErr.Code))
+	case errors.Is(err, chargingprofile.ErrProfileRejected):
+		return httpx.Conflict(ctx, fmt.Sprintf("Charger rejected the request to %s", action))
+	case err != nil:
+		return httpx.InternalErr(ctx, fmt.Sprintf("Failed to %s", action), err)
+	case status != ocpp.StatusAccepted:
+		return httpx.Conflict(ctx, fmt.Sprintf("Charger rejected the request to %s", action))
+	}
+
+	return nil
+}
+
+func newSessionResponse(action string, cs *repository.ChargingSession, until *time.Time) *ControlResponse {
+	return &ControlResponse{
+		Action:        action,
+		Channel:       ChannelOcpp,
+		DeviceID:      cs.DeviceID,
+		Connector:     cs.ConnectorID,
+		SessionID:     &cs.ID,
+		TransactionID: cs.TransactionID,
+		Until:         until,
+	}
+}
@@ -8,8 +8,232 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Activation struct {
+	ID           uuid.UUID   `db:"id"`
+	Source       string      `db:"source"`
+	SiteID       pgtype.UUID `db:"site_id"`
+	TargetID     pgtype.UUID `db:"target_id"`
+	CreatedBy    pgtype.UUID `db:"created_by"`
+	Kind         string      `db:"kind"`
+	TargetKw     float64     `db:"target_kw"`
+	StartsAt     time.Time   `db:"starts_at"`
+	EndsAt       time.Time   `db:"ends_at"`
+	Status       string      `db:"status"`
+	RequestedKwh float64     `db:"requested_kwh"`
+	DeliveredKwh float64     `db:"delivered_kwh"`
+	CreatedAt    time.Time   `db:"created_at"`
+	UpdatedAt    time.Time   `db:"updated_at"`
+}
+
+type ActivationMeasurement struct {
+	ActivationID uuid.UUID `db:"activation_id"`
+	MeasuredAt   time.Time `db:"measured_at"`
+	Vehicles     int32     `db:"vehicles"`
+	TargetKw     float64   `db:"target_kw"`
+	BaselineKw   float64   `db:"baseline_kw"`
+	SetpointKw   float64   `db:"setpoint_kw"`
+	ActualKw     float64   `db:"actual_kw"`
+}
+
+type ActivationParticipant struct {
+	ActivationID uuid.UUID          `db:"activation_id"`
+	VehicleID    uuid.UUID          `db:"vehicle_id"`
+	DeviceID     pgtype.UUID        `db:"device_id"`
+	BaselineKw   float64            `db:"baseline_kw"`
+	SetpointKw   float64            `db:"setpoint_kw"`
+	DispatchedAt time.Time          `db:"dispatched_at"`
+	OptedOut     bool               `db:"opted_out"`
+	RequestedKwh float64            `db:"requested_kwh"`
+	DeliveredKwh float64            `db:"delivered_kwh"`
+	MeasuredAt   pgtype.Timestamptz `db:"measured_at"`
+}
+
+type BatteryHealth struct {
+	VehicleID      uuid.UUID `db:"vehicle_id"`
+	LastSoc        float64   `db:"last_soc"`
+	TemperatureC   float64   `db:"temperature_c"`
+	Residue        []byte    `db:"residue"`
+	CycleStress    float64   `db:"cycle_stress"`
+	CalendarStress float64   `db:"calendar_stress"`
+	FullCycles     float64   `db:"full_cycles"`
+	ChargedKwh     float64   `db:"charged_kwh"`
+	DischargedKwh  float64   `db:"discharged_kwh"`
+	TrackedSince   time.Time `db:"tracked_since"`
+	SampledAt      time.Time `db:"sampled_at"`
+}
+
+type CarbonIntensity struct {
+	Zone              string    `db:"zone"`
+	StartsAt          time.Time `db:"starts_at"`
+	ResolutionMinutes int32     `db:"resolution_minutes"`
+	GramsCo2Kwh       float64   `db:"grams_co2_kwh"`
+	Source            string    `db:"source"`
+	UpdatedAt         time.Time `db:"updated_at"`
+}
+
+type ChargingProfile struct {
+	ID              uuid.UUID   `db:"id"`
+	DeviceID        uuid.UUID   `db:"device_id"`
+	ConnectorID     int32       `db:"connector_id"`
+	ProfileID       int32       `db:"profile_id"`
+	Purpose         string      `db:"purpose"`
+	StackLevel      int32       `db:"stack_level"`
+	ScheduleID      pgtype.UUID `db:"schedule_id"`
+	StartSchedule   time.Time   `db:"start_schedule"`
+	DurationSeconds int32       `db:"duration_seconds"`
+	Periods         []byte      `db:"periods"`
+	Status          string      `db:"status"`
+	CreatedAt       time.Time   `db:"created_at"`
+	UpdatedAt       time.Time   `db:"updated_at"`
+}
+
+type ChargingSchedule struct {
+	ID            uuid.UUID `db:"id"`
+	VehicleID     uuid.UUID `db:"vehicle_id"`
+	Reason        string    `db:"reason"`
+	HorizonStart  time.Time `db:"horizon_start"`
+	HorizonEnd    time.Time `db:"horizon_end"`
+	InitialSoc    float64   `db:"initial_soc"`
+	FinalSoc      float64   `db:"final_soc"`
+	TargetReached bool      `db:"target_reached"`
+	TotalCost     float64   `db:"total_cost"`
+	Setpoints     []byte    `db:"setpoints"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+type ChargingSession struct {
+	ID                  uuid.UUID          `db:"id"`
+	DeviceID            uuid.UUID          `db:"device_id"`
+	ConnectorID         int32              `db:"connector_id"`
+	TransactionID       string             `db:"transaction_id"`
+	OwnerID             uuid.UUID          `db:"owner_id"`
+	VehicleID           pgtype.UUID        `db:"vehicle_id"`
+	IDTag               string             `db:"id_tag"`
+	Status              string             `db:"status"`
+	StopReason          string             `db:"stop_reason"`
+	StartedAt           time.Time          `db:"started_at"`
+	StoppedAt           pgtype.Timestamptz `db:"stopped_at"`
+	ImportedKwh         float64            `db:"imported_kwh"`
+	ExportedKwh         float64            `db:"exported_kwh"`
+	EnergyCost          float64            `db:"energy_cost"`
+	Revenue             float64            `db:"revenue"`
+	Breakdown           []byte             `db:"breakdown"`
+	ComputedAt          pgtype.Timestamptz `db:"computed_at"`
+	CreatedAt           time.Time          `db:"created_at"`
+	EmissionsKg         pgtype.Float8      `db:"emissions_kg"`
+	BaselineEmissionsKg pgtype.Float8      `db:"baseline_emissions_kg"`
+	SiteTariff          bool               `db:"site_tariff"`
+}
+
+type CongestionAlert struct {
+	LimitID   uuid.UUID `db:"limit_id"`
+	VehicleID uuid.UUID `db:"vehicle_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type CongestionLimit struct {
+	ID          uuid.UUID     `db:"id"`
+	ZoneID      uuid.UUID     `db:"zone_id"`
+	StartsAt    time.Time     `db:"starts_at"`
+	EndsAt      time.Time     `db:"ends_at"`
+	MaxImportKw pgtype.Float8 `db:"max_import_kw"`
+	MaxExportKw pgtype.Float8 `db:"max_export_kw"`
+	Reference   string        `db:"reference"`
+	CreatedAt   time.Time     `db:"created_at"`
+	UpdatedAt   time.Time     `db:"updated_at"`
+}
+
+type CongestionZone struct {
+	ID             uuid.UUID   `db:"id"`
+	Name           string      `db:"name"`
+	Operator       string      `db:"operator"`
+	PostcodeRanges []byte      `db:"postcode_ranges"`
+	Polygon        []byte      `db:"polygon"`
+	CreatedBy      pgtype.UUID `db:"created_by"`
+	CreatedAt      time.Time   `db:"created_at"`
+	UpdatedAt      time.Time   `db:"updated_at"`
+}
+
+type Connector struct {
+	ID          uuid.UUID `db:"id"`
+	DeviceID    uuid.UUID `db:"device_id"`
+	ConnectorID int32     `db:"connector_id"`
+	Status      string    `db:"status"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+type Device struct {
+	ID               uuid.UUID   `db:"id"`
+	OwnerID          uuid.UUID   `db:"owner_id"`
+	SerialNumber     string      `db:"serial_number"`
+	Name             string      `db:"name"`
+	Kind             string      `db:"kind"`
+	CreatedAt        time.Time   `db:"created_at"`
+	SiteID           pgtype.UUID `db:"site_id"`
+	OcppPasswordHash []byte      `db:"ocpp_password_hash"`
+}
+
+type DeviceCommand struct {
+	ID             uuid.UUID          `db:"id"`
+	DeviceID       uuid.UUID          `db:"device_id"`
+	Type           string             `db:"type"`
+	Payload        []byte             `db:"payload"`
+	Status         string             `db:"status"`
+	Reason         string             `db:"reason"`
+	Attempts       int32              `db:"attempts"`
+	MaxAttempts    int32              `db:"max_attempts"`
+	TimeoutSeconds int32              `db:"timeout_seconds"`
+	NextAttemptAt  time.Time          `db:"next_attempt_at"`
+	CreatedAt      time.Time          `db:"created_at"`
+	UpdatedAt      time.Time          `db:"updated_at"`
+	CompletedAt    pgtype.Timestamptz `db:"completed_at"`
+}
+
+type DeviceShadow struct {
+	DeviceID          uuid.UUID          `db:"device_id"`
+	Reported          []byte             `db:"reported"`
+	Desired           []byte             `db:"desired"`
+	Version           int64              `db:"version"`
+	ReportedUpdatedAt pgtype.Timestamptz `db:"reported_updated_at"`
+	DesiredUpdatedAt  pgtype.Timestamptz `db:"desired_updated_at"`
+	CreatedAt         time.Time          `db:"created_at"`
+}
+
+type Event struct {
+	ID        uuid.UUID   `db:"id"`
+	Type      string      `db:"type"`
+	DeviceID  pgtype.UUID `db:"device_id"`
+	VehicleID pgtype.UUID `db:"vehicle_id"`
+	Payload   []byte      `db:"payload"`
+	CreatedAt time.Time   `db:"created_at"`
+}
+
+type FlexibilityForecast struct {
+	SiteID        uuid.UUID     `db:"site_id"`
+	StartsAt      time.Time     `db:"starts_at"`
+	Vehicles      int32         `db:"vehicles"`
+	BaselineKw    float64       `db:"baseline_kw"`
+	UpKw          float64       `db:"up_kw"`
+	DownKw        float64       `db:"down_kw"`
+	ForecastAt    time.Time     `db:"forecast_at"`
+	ActualKw      pgtype.Float8 `db:"actual_kw"`
+	ActualSamples int32         `db:"actual_samples"`
+}
+
+type FlexibilityTarget struct {
+	ID        uuid.UUID `db:"id"`
+	VtnUrl    string    `db:"vtn_url"`
+	EventID   string    `db:"event_id"`
+	Kind      string    `db:"kind"`
+	StartsAt  time.Time `db:"starts_at"`
+	EndsAt    time.Time `db:"ends_at"`
+	Value     float64   `db:"value"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
 type Identity struct {
 	ID           uuid.UUID `db:"id"`
 	Username     string    `db:"username"`
@@ -17,6 +241,124 @@ type Identity struct {
 	CreatedAt    time.Time `db:"created_at"`
 }
 
+type MeterReading struct {
+	DeviceID      uuid.UUID          `db:"device_id"`
+	MeasuredAt    time.Time          `db:"measured_at"`
+	ImportKw      float64            `db:"import_kw"`
+	ExportKw      float64            `db:"export_kw"`
+	HouseholdKw   float64            `db:"household_kw"`
+	ImportedKwh   float64            `db:"imported_kwh"`
+	ExportedKwh   float64            `db:"exported_kwh"`
+	Tariff        int16              `db:"tariff"`
+	Phases        []byte             `db:"phases"`
+	GasM3         pgtype.Float8      `db:"gas_m3"`
+	GasMeasuredAt pgtype.Timestamptz `db:"gas_measured_at"`
+	CreatedAt     time.Time          `db:"created_at"`
+}
+
+type MeterValue struct {
+	SessionID  uuid.UUID     `db:"session_id"`
+	MeasuredAt time.Time     `db:"measured_at"`
+	ImportWh   float64       `db:"import_wh"`
+	ExportWh   pgtype.Float8 `db:"export_wh"`
+}
+
+type OpenadrEvent struct {
+	ID                 uuid.UUID `db:"id"`
+	OwnerID            uuid.UUID `db:"owner_id"`
+	ModificationNumber int32     `db:"modification_number"`
+	Priority           int32     `db:"priority"`
+	MarketContext      string    `db:"market_context"`
+	SignalName         string    `db:"signal_name"`
+	StartsAt           time.Time `db:"starts_at"`
+	Intervals          []byte    `db:"intervals"`
+	TestEvent          bool      `db:"test_event"`
+	ResponseRequired   bool      `db:"response_required"`
+	Cancelled          bool      `db:"cancelled"`
+	CreatedAt          time.Time `db:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at"`
+}
+
+type OpenadrEventTarget struct {
+	EventID     uuid.UUID          `db:"event_id"`
+	VenID       uuid.UUID          `db:"ven_id"`
+	OptType     pgtype.Text        `db:"opt_type"`
+	RespondedAt pgtype.Timestamptz `db:"responded_at"`
+}
+
+type OpenadrOpt struct {
+	VenID     uuid.UUID `db:"ven_id"`
+	OptID     string    `db:"opt_id"`
+	OptType   string    `db:"opt_type"`
+	OptReason string    `db:"opt_reason"`
+	StartsAt  time.Time `db:"starts_at"`
+	EndsAt    time.Time `db:"ends_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type OpenadrReportValue struct {
+	VenID      uuid.UUID `db:"ven_id"`
+	RID        string    `db:"r_id"`
+	MeasuredAt time.Time `db:"measured_at"`
+	Value      float64   `db:"value"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type OpenadrVen struct {
+	ID                uuid.UUID          `db:"id"`
+	OwnerID           uuid.UUID          `db:"owner_id"`
+	Name              string             `db:"name"`
+	TokenHash         []byte             `db:"token_hash"`
+	RegistrationID    pgtype.UUID        `db:"registration_id"`
+	RegisteredAt      pgtype.Timestamptz `db:"registered_at"`
+	LastSeenAt        pgtype.Timestamptz `db:"last_seen_at"`
+	EventsChangedAt   pgtype.Timestamptz `db:"events_changed_at"`
+	EventsDeliveredAt pgtype.Timestamptz `db:"events_delivered_at"`
+	OptsChangedAt     pgtype.Timestamptz `db:"opts_changed_at"`
+	CreatedAt         time.Time          `db:"created_at"`
+}
+
+type OpenadrVenVehicle struct {
+	VehicleID uuid.UUID `db:"vehicle_id"`
+	VenID     uuid.UUID `db:"ven_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type Price struct {
+	Zone              string    `db:"zone"`
+	StartsAt          time.Time `db:"starts_at"`
+	ResolutionMinutes int32     `db:"resolution_minutes"`
+	PriceEurMwh       float64   `db:"price_eur_mwh"`
+	Source            string    `db:"source"`
+	UpdatedAt         time.Time `db:"updated_at"`
+}
+
+type PvForecast struct {
+	SiteID    uuid.UUID `db:"site_id"`
+	SlotStart time.Time `db:"slot_start"`
+	ModeledKw float64   `db:"modeled_kw"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type PvReading struct {
+	DeviceID   uuid.UUID     `db:"device_id"`
+	MeasuredAt time.Time     `db:"measured_at"`
+	PowerKw    float64       `db:"power_kw"`
+	EnergyKwh  pgtype.Float8 `db:"energy_kwh"`
+	CreatedAt  time.Time     `db:"created_at"`
+}
+
+type PvSystem struct {
+	ID         uuid.UUID `db:"id"`
+	SiteID     uuid.UUID `db:"site_id"`
+	Name       string    `db:"name"`
+	PeakKw     float64   `db:"peak_kw"`
+	TiltDeg    float64   `db:"tilt_deg"`
+	AzimuthDeg float64   `db:"azimuth_deg"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
 type RefreshToken struct {
 	Token      []byte    `db:"token"`
 	IdentityID uuid.UUID `db:"identity_id"`
@@ -24,8 +366,129 @@ type RefreshToken struct {
 	ExpiresAt  time.Time `db:"expires_at"`
 }
 
-type User struct {
-	ID        uuid.UUID `db:"id"`
-	Username  string    `db:"username"`
+type Reservation struct {
+	ID            uuid.UUID          `db:"id"`
+	OcppID        int32              `db:"ocpp_id"`
+	SiteID        uuid.UUID          `db:"site_id"`
+	ConnectorID   uuid.UUID          `db:"connector_id"`
+	UserID        uuid.UUID          `db:"user_id"`
+	StartsAt      time.Time          `db:"starts_at"`
+	EndsAt        time.Time          `db:"ends_at"`
+	Status        string             `db:"status"`
+	ChargerStatus string             `db:"charger_status"`
+	HeldAt        pgtype.Timestamptz `db:"held_at"`
+	SessionID     pgtype.UUID        `db:"session_id"`
+	CreatedAt     time.Time          `db:"created_at"`
+	UpdatedAt     time.Time          `db:"updated_at"`
+}
+
+type Site struct {
+	ID               uuid.UUID          `db:"id"`
+	Name             string             `db:"name"`
+	Kind             string             `db:"kind"`
+	Phases           int16              `db:"phases"`
+	VoltageV         float64            `db:"voltage_v"`
+	MaxCurrentA      float64            `db:"max_current_a"`
+	CreatedAt        time.Time          `db:"created_at"`
+	UpdatedAt        time.Time          `db:"updated_at"`
+	Latitude         pgtype.Float8      `db:"latitude"`
+	Longitude        pgtype.Float8      `db:"longitude"`
+	Region           string             `db:"region"`
+	Postcode         string             `db:"postcode"`
+	Zone             string             `db:"zone"`
+	TariffID         pgtype.UUID        `db:"tariff_id"`
+	TariffAssignedAt pgtype.Timestamptz `db:"tariff_assigned_at"`
+}
+
+type SiteMember struct {
+	SiteID    uuid.UUID `db:"site_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	Role      string    `db:"role"`
 	CreatedAt time.Time `db:"created_at"`
 }
+
+type Tariff struct {
+	ID         uuid.UUID `db:"id"`
+	OwnerID    uuid.UUID `db:"owner_id"`
+	Name       string    `db:"name"`
+	Kind       string    `db:"kind"`
+	Definition []byte    `db:"definition"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+type TariffVersion struct {
+	ID         uuid.UUID   `db:"id"`
+	UserID     pgtype.UUID `db:"user_id"`
+	TariffID   pgtype.UUID `db:"tariff_id"`
+	Kind       string      `db:"kind"`
+	Definition []byte      `db:"definition"`
+	ValidFrom  time.Time   `db:"valid_from"`
+	CreatedAt  time.Time   `db:"created_at"`
+	SiteID     pgtype.UUID `db:"site_id"`
+}
+
+type User struct {
+	ID               uuid.UUID          `db:"id"`
+	Username         string             `db:"username"`
+	CreatedAt        time.Time          `db:"created_at"`
+	TariffID         pgtype.UUID        `db:"tariff_id"`
+	TariffAssignedAt pgtype.Timestamptz `db:"tariff_assigned_at"`
+}
+
+type Vehicle struct {
+	ID                 uuid.UUID   `db:"id"`
+	OwnerID            uuid.UUID   `db:"owner_id"`
+	Name               string      `db:"name"`
+	BatteryCapacityKwh float64     `db:"battery_capacity_kwh"`
+	MaxChargeKw        float64     `db:"max_charge_kw"`
+	MaxDischargeKw     float64     `db:"max_discharge_kw"`
+	ChargerID          pgtype.UUID `db:"charger_id"`
+	CreatedAt          time.Time   `db:"created_at"`
+}
+
+type VehiclePreference struct {
+	VehicleID          uuid.UUID          `db:"vehicle_id"`
+	TargetSoc          int16              `db:"target_soc"`
+	MinSoc             int16              `db:"min_soc"`
+	MaxDischargeCycles int16              `db:"max_discharge_cycles"`
+	DepartureAt        pgtype.Timestamptz `db:"departure_at"`
+	Timezone           string             `db:"timezone"`
+	BoostUntil         pgtype.Timestamptz `db:"boost_until"`
+	UpdatedAt          time.Time          `db:"updated_at"`
+	Priority           int16              `db:"priority"`
+	ChargingMode       string             `db:"charging_mode"`
+	SolarMinKw         float64            `db:"solar_min_kw"`
+	FlexibilityOptOut  bool               `db:"flexibility_opt_out"`
+	WearCostPerKwh     pgtype.Float8      `db:"wear_cost_per_kwh"`
+	CarbonWeight       float64            `db:"carbon_weight"`
+}
+
+type VehicleWeeklyDeparture struct {
+	VehicleID   uuid.UUID `db:"vehicle_id"`
+	Weekday     int16     `db:"weekday"`
+	MinuteOfDay int16     `db:"minute_of_day"`
+}
+
+type VenEvent struct {
+	VtnUrl             string    `db:"vtn_url"`
+	EventID            string    `db:"event_id"`
+	ModificationNumber int32     `db:"modification_number"`
+	Priority           int32     `db:"priority"`
+	MarketContext      string    `db:"market_context"`
+	Status             string    `db:"status"`
+	StartsAt           time.Time `db:"starts_at"`
+	EndsAt             time.Time `db:"ends_at"`
+	TestEvent          bool      `db:"test_event"`
+	OptType            string    `db:"opt_type"`
+	ReceivedAt         time.Time `db:"received_at"`
+}
+
+type VenRegistration struct {
+	VtnUrl          string    `db:"vtn_url"`
+	VtnID           string    `db:"vtn_id"`
+	VenID           string    `db:"ven_id"`
+	RegistrationID  string    `db:"registration_id"`
+	PollFreqSeconds int32     `db:"poll_freq_seconds"`
+	RegisteredAt    time.Time `db:"registered_at"`
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createUser = `-- name: CreateUser :exec
@@ -27,13 +28,53 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, username, created_at FROM users
+SELECT id, username, created_at, tariff_id, tariff_assigned_at FROM users
 WHERE id = $1
 `
 
 func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 	row := q.db.QueryRow(ctx, getUserById, id)
 	var i User
-	err := row.Scan(&i.ID, &i.Username, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CreatedAt,
+		&i.TariffID,
+		&i.TariffAssignedAt,
+	)
 	return i, err
 }
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT id, username, created_at, tariff_id, tariff_assigned_at FROM users
+WHERE username = $1
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CreatedAt,
+		&i.TariffID,
+		&i.TariffAssignedAt,
+	)
+	return i, err
+}
+
+const setUserTariff = `-- name: SetUserTariff :exec
+UPDATE users
+SET tariff_id = $2, tariff_assigned_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type SetUserTariffParams struct {
+	ID       uuid.UUID   `db:"id"`
+	TariffID pgtype.UUID `db:"tariff_id"`
+}
+
+func (q *Queries) SetUserTariff(ctx context.Context, arg SetUserTariffParams) error {
+	_, err := q.db.Exec(ctx, setUserTariff, arg.ID, arg.TariffID)
+	return err
+}
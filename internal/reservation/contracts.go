@@ -0,0 +1,208 @@
+package reservation
+
+import (
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"net/url"
+	"time"
+)
+
+// A reservation is booked until it starts, then held on the charger until the
+// user plugs in, which fulfils it. Nobody plugging in within the grace period
+// makes it a no-show, reservations the charger could not hold expire at their
+// end instead.
+const (
+	StatusBooked    = "booked"
+	StatusHeld      = "held"
+	StatusFulfilled = "fulfilled"
+	StatusCancelled = "cancelled"
+	StatusNoShow    = "no_show"
+	StatusExpired   = "expired"
+)
+
+// ChargerOffline is stored as charger status when the charger was not
+// connected to hold the reservation, it is sent again once it connects.
+const ChargerOffline = "Offline"
+
+const (
+	minDuration = 15 * time.Minute
+	maxDuration = 24 * time.Hour
+	// maxAhead is how far in advance a connector can be reserved.
+	maxAhead = 30 * 24 * time.Hour
+	// maxUpcoming limits the reservations a user holds at a site at once.
+	maxUpcoming = 3
+	// maxNoShows within noShowWindow bars a user from reserving at the site.
+	maxNoShows   = 3
+	noShowWindow = 30 * 24 * time.Hour
+	// noShowGrace is how long the charger holds the connector before the
+	// reservation is released as a no-show.
+	noShowGrace = 15 * time.Minute
+	// earlyArrival counts sessions started this long before the reservation
+	// as fulfilling it.
+	earlyArrival = 15 * time.Minute
+
+	reservationInterval = 30 * time.Second
+	defaultAvailability = 24 * time.Hour
+	maxAvailability     = 14 * 24 * time.Hour
+	defaultListRange    = 30 * 24 * time.Hour
+	maxListRange        = 92 * 24 * time.Hour
+)
+
+type ReservationRequest struct {
+	ConnectorID uuid.UUID `json:"connectorId"`
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+}
+
+func (r *ReservationRequest) Validate(now time.Time) httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.ConnectorID == uuid.Nil {
+		errs.Add("connectorId", "Connector id is required")
+	}
+
+	switch {
+	case r.StartsAt.IsZero():
+		errs.Add("startsAt", "Start is required")
+	case r.StartsAt.Before(now.Add(-time.Minute)):
+		errs.Add("startsAt", "Start cannot be in the past")
+	case r.StartsAt.After(now.Add(maxAhead)):
+		errs.Add("startsAt", fmt.Sprintf("Connectors can be reserved at most %d days ahead", int(maxAhead.Hours()/24)))
+	}
+
+	if d := r.EndsAt.Sub(r.StartsAt); d < minDuration || d > maxDuration {
+		errs.Add("endsAt", fmt.Sprintf("A reservation must last between %d minutes and %d hours", int(minDuration.Minutes()), int(maxDuration.Hours())))
+	}
+
+	return errs
+}
+
+// Window is the range of time a listing covers.
+type Window struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseWindow reads the from and to query parameters. From defaults to now
+// and to to span after from.
+func ParseWindow(q url.Values, now time.Time, span time.Duration) Window {
+	w := Window{
+		From:      now.UTC(),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			w.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		w.From = from.UTC()
+	}
+
+	w.To = w.From.Add(span)
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			w.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		w.To = to.UTC()
+	}
+
+	return w
+}
+
+func (w *Window) Validate(maxSpan time.Duration) httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range w.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !w.To.After(w.From) {
+			errs.Add("to", "To must be after from")
+		} else if w.To.Sub(w.From) > maxSpan {
+			errs.Add("to", fmt.Sprintf("Range cannot exceed %d days", int(maxSpan.Hours()/24)))
+		}
+	}
+
+	return errs
+}
+
+type ReservationResponse struct {
+	ID          uuid.UUID `json:"id"`
+	SiteID      uuid.UUID `json:"siteId"`
+	ConnectorID uuid.UUID `json:"connectorId"`
+	UserID      uuid.UUID `json:"userId"`
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+	Status      string    `json:"status"`
+	// ChargerStatus is what the charger answered when it was asked to hold
+	// the connector.
+	ChargerStatus string     `json:"chargerStatus,omitempty"`
+	HeldAt        *time.Time `json:"heldAt,omitempty"`
+	SessionID     *uuid.UUID `json:"sessionId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+func NewReservationResponse(r *repository.Reservation) *ReservationResponse {
+	res := &ReservationResponse{
+		ID:            r.ID,
+		SiteID:        r.SiteID,
+		ConnectorID:   r.ConnectorID,
+		UserID:        r.UserID,
+		StartsAt:      r.StartsAt,
+		EndsAt:        r.EndsAt,
+		Status:        r.Status,
+		ChargerStatus: r.ChargerStatus,
+		CreatedAt:     r.CreatedAt,
+	}
+
+	if r.HeldAt.Valid {
+		res.HeldAt = &r.HeldAt.Time
+	}
+	if r.SessionID.Valid {
+		id := uuid.UUID(r.SessionID.Bytes)
+		res.SessionID = &id
+	}
+
+	return res
+}
+
+// SlotResponse is a reserved period of a connector. The reservation id is
+// only shown to the user who made it and to managers.
+type SlotResponse struct {
+	ReservationID *uuid.UUID `json:"reservationId,omitempty"`
+	StartsAt      time.Time  `json:"startsAt"`
+	EndsAt        time.Time  `json:"endsAt"`
+	Status        string     `json:"status,omitempty"`
+	Mine          bool       `json:"mine,omitempty"`
+}
+
+type ConnectorAvailability struct {
+	ID         uuid.UUID `json:"id"`
+	DeviceID   uuid.UUID `json:"deviceId"`
+	DeviceName string    `json:"deviceName"`
+	Connector  int32     `json:"connector"`
+	// Status is the last status the charger reported for the connector.
+	Status   string          `json:"status"`
+	Online   bool            `json:"online"`
+	Reserved []*SlotResponse `json:"reserved"`
+	// Free are the periods long enough to be reserved.
+	Free []*SlotResponse `json:"free"`
+}
+
+type AvailabilityResponse struct {
+	SiteID     uuid.UUID                `json:"siteId"`
+	From       time.Time                `json:"from"`
+	To         time.Time                `json:"to"`
+	Connectors []*ConnectorAvailability `json:"connectors"`
+}
+
+// blocking reports whether a reservation in the status keeps others from
+// reserving the connector.
+func blocking(status string) bool {
+	return status == StatusBooked || status == StatusHeld || status == StatusFulfilled
+}
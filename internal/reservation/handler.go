@@ -0,0 +1,145 @@
+package reservation
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req ReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	res, err := h.svc.Create(ctx, identityID, siteID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, NewReservationResponse(res))
+	return nil
+}
+
+// ListHandler returns the reservations overlapping the from and to query
+// parameters, by default those of the coming 30 days.
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	reservations, err := h.svc.List(ctx, identityID, siteID, ParseWindow(r.URL.Query(), time.Now(), defaultListRange))
+	if err != nil {
+		return err
+	}
+
+	res := make([]*ReservationResponse, 0, len(reservations))
+	for i := range reservations {
+		res = append(res, NewReservationResponse(&reservations[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	reservationID, err := httpx.URLParamUUID(r, "reservationId")
+	if err != nil {
+		return err
+	}
+
+	res, err := h.svc.Get(ctx, identityID, siteID, reservationID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewReservationResponse(res))
+	return nil
+}
+
+func (h *Handler) CancelHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	reservationID, err := httpx.URLParamUUID(r, "reservationId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Cancel(ctx, identityID, siteID, reservationID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+// AvailabilityHandler returns the calendar of the connectors at the site
+// between the from and to query parameters, by default the coming 24 hours.
+func (h *Handler) AvailabilityHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	res, err := h.svc.Availability(ctx, identityID, siteID, ParseWindow(r.URL.Query(), time.Now(), defaultAvailability))
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
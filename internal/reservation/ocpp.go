@@ -0,0 +1,122 @@
+package reservation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/chargepoint"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+)
+
+// RegisterHandlers makes the service handle the reservation updates of the
+// chargers connected to srv.
+func (s *Service) RegisterHandlers(srv *chargepoint.Server) {
+	srv.Handle(ocpp.ActionReservationStatusUpdate, s.handleReservationStatusUpdate)
+}
+
+// handleReservationStatusUpdate records that a 2.0.1 station dropped a
+// reservation on its own. The reservation keeps the connector blocked for
+// others until it ends, but no longer counts as a no-show.
+func (s *Service) handleReservationStatusUpdate(ctx context.Context, c *chargepoint.Connection, payload json.RawMessage) (any, error) {
+	var req ocpp.ReservationStatusUpdateRequest201
+	if err := chargepoint.Decode(payload, &req); err != nil {
+		return nil, err
+	}
+
+	r, err := s.queries.GetReservationByOcppId(ctx, int32(req.ReservationID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "Status update of unknown reservation", "identity", c.Identity, "reservation", req.ReservationID)
+			return struct{}{}, nil
+		}
+
+		return nil, err
+	}
+
+	conn, err := s.queries.GetConnectorById(ctx, r.ConnectorID)
+	if err != nil {
+		return nil, err
+	}
+	if conn.DeviceID != c.DeviceID {
+		slog.WarnContext(ctx, "Status update of reservation on another charger", "identity", c.Identity, "reservation.id", r.ID)
+		return struct{}{}, nil
+	}
+
+	if err := s.queries.SetReservationChargerStatus(ctx, repository.SetReservationChargerStatusParams{
+		ID:            r.ID,
+		ChargerStatus: req.ReservationUpdateStatus,
+		HeldAt:        r.HeldAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	return struct{}{}, nil
+}
+
+// reserve asks the charger to hold the connector for the user until the
+// reservation ends and returns its answer, ChargerOffline when it could not
+// be reached.
+func (s *Service) reserve(ctx context.Context, r *repository.ListOpenReservationsRow) string {
+	conn, err := s.chargers.Connection(r.DeviceID)
+	if err != nil {
+		return ChargerOffline
+	}
+
+	idTag := chargepoint.IdTag(r.UserID)
+	var res ocpp.StatusResponse
+	if conn.Version == ocpp.V201 {
+		evseID := int(r.ConnectorNumber)
+		err = conn.Call(ctx, ocpp.ActionReserveNow, ocpp.ReserveNowRequest201{
+			ID:             int(r.OcppID),
+			ExpiryDateTime: r.EndsAt,
+			IdToken:        ocpp.IdToken{IdToken: idTag, Type: ocpp.IdTokenCentral},
+			EvseID:         &evseID,
+		}, &res)
+	} else {
+		err = conn.Call(ctx, ocpp.ActionReserveNow, ocpp.ReserveNowRequest16{
+			ConnectorID:   int(r.ConnectorNumber),
+			ExpiryDate:    r.EndsAt,
+			IdTag:         idTag,
+			ReservationID: int(r.OcppID),
+		}, &res)
+	}
+
+	var callErr *ocpp.CallError
+	switch {
+	case errors.As(err, &callErr):
+		// Chargers without the reservation feature answer NotImplemented.
+		slog.WarnContext(ctx, "Charger refused ReserveNow", "reservation.id", r.ID, "error", err)
+		return ocpp.StatusRejected
+	case err != nil:
+		slog.WarnContext(ctx, "Charger did not answer ReserveNow", "reservation.id", r.ID, "error", err)
+		return ChargerOffline
+	}
+
+	return res.Status
+}
+
+// release cancels a reservation the charger holds.
+func (s *Service) release(ctx context.Context, deviceID uuid.UUID, ocppID int32) error {
+	conn, err := s.chargers.Connection(deviceID)
+	if err != nil {
+		return err
+	}
+
+	var res ocpp.StatusResponse
+	if err := conn.Call(ctx, ocpp.ActionCancelReservation, ocpp.CancelReservationRequest{ReservationID: int(ocppID)}, &res); err != nil {
+		return err
+	}
+
+	// Rejected means the charger no longer knows the reservation, it may
+	// have expired or been used already.
+	if res.Status != ocpp.StatusAccepted && res.Status != ocpp.StatusRejected {
+		return fmt.Errorf("charger answered %s", res.Status)
+	}
+
+	return nil
+}
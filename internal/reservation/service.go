@@ -0,0 +1,461 @@
+// Package reservation lets the members of a site reserve a charger connector
+// for a period of time. Reservations of a connector cannot overlap, are held
+// on the charger with ReserveNow once they start and are released as a
+// no-show when nobody plugs in within the grace period.
+package reservation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/chargepoint"
+	"github.com/V2G-Minor-Fontys/server/internal/event"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"time"
+)
+
+type Service struct {
+	db       *pgxpool.Pool
+	queries  *repository.Queries
+	sites    *site.Service
+	chargers *chargepoint.Server
+	events   *event.Service
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, sites *site.Service, chargers *chargepoint.Server, events *event.Service) *Service {
+	return &Service{db: db, queries: queries, sites: sites, chargers: chargers, events: events}
+}
+
+// Create reserves a connector of the site for the caller, any member of the
+// site may reserve.
+func (s *Service) Create(ctx context.Context, identityID, siteID uuid.UUID, req ReservationRequest) (*repository.Reservation, error) {
+	now := time.Now().UTC()
+	if errs := req.Validate(now); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	if _, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	c, err := s.queries.GetConnectorById(ctx, req.ConnectorID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Connector could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve connector", err)
+	}
+	if !c.SiteID.Valid || uuid.UUID(c.SiteID.Bytes) != siteID {
+		return nil, httpx.NotFound(ctx, "Connector could not be found")
+	}
+
+	noShows, err := s.queries.CountNoShowsByUserId(ctx, repository.CountNoShowsByUserIdParams{
+		SiteID:   siteID,
+		UserID:   identityID,
+		StartsAt: now.Add(-noShowWindow),
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to count no-shows", err)
+	}
+	if noShows >= maxNoShows {
+		return nil, httpx.Forbidden(ctx, fmt.Sprintf("Reserving at this site is blocked after %d no-shows in the last %d days", noShows, int(noShowWindow.Hours()/24)))
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	// Locking the connector serialises its reservations, so two overlapping
+	// requests cannot both pass the check below.
+	if err := qtx.LockConnector(ctx, c.ID); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to lock connector", err)
+	}
+
+	upcoming, err := qtx.CountUpcomingReservationsByUserId(ctx, repository.CountUpcomingReservationsByUserIdParams{
+		SiteID: siteID,
+		UserID: identityID,
+		EndsAt: now,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to count reservations", err)
+	}
+	if upcoming >= maxUpcoming {
+		return nil, httpx.Conflict(ctx, fmt.Sprintf("You can hold at most %d upcoming reservations at a site", maxUpcoming))
+	}
+
+	overlapping, err := qtx.CountOverlappingReservations(ctx, repository.CountOverlappingReservationsParams{
+		ConnectorID: c.ID,
+		EndsAt:      req.EndsAt,
+		StartsAt:    req.StartsAt,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to check reservations", err)
+	}
+	if overlapping > 0 {
+		return nil, httpx.Conflict(ctx, "Connector is already reserved during this period")
+	}
+
+	r, err := qtx.CreateReservation(ctx, repository.CreateReservationParams{
+		ID:          uuid.New(),
+		SiteID:      siteID,
+		ConnectorID: c.ID,
+		UserID:      identityID,
+		StartsAt:    req.StartsAt.UTC(),
+		EndsAt:      req.EndsAt.UTC(),
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to create reservation", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	return &r, nil
+}
+
+// List returns the reservations of the caller at the site within the window,
+// managers see those of every member.
+func (s *Service) List(ctx context.Context, identityID, siteID uuid.UUID, w Window) ([]repository.Reservation, error) {
+	if errs := w.Validate(maxListRange); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	_, role, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	var reservations []repository.Reservation
+	if manages(role) {
+		reservations, err = s.queries.ListReservationsBySiteId(ctx, repository.ListReservationsBySiteIdParams{
+			SiteID:       siteID,
+			EndsAfter:    w.From,
+			StartsBefore: w.To,
+		})
+	} else {
+		reservations, err = s.queries.ListReservationsBySiteIdAndUserId(ctx, repository.ListReservationsBySiteIdAndUserIdParams{
+			SiteID:       siteID,
+			UserID:       identityID,
+			EndsAfter:    w.From,
+			StartsBefore: w.To,
+		})
+	}
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve reservations", err)
+	}
+
+	return reservations, nil
+}
+
+func (s *Service) Get(ctx context.Context, identityID, siteID, reservationID uuid.UUID) (*repository.Reservation, error) {
+	return s.get(ctx, identityID, siteID, reservationID)
+}
+
+// Cancel ends a reservation that has not been fulfilled yet and releases the
+// connector when the charger is already holding it. The user who made the
+// reservation and managers of the site may cancel it.
+func (s *Service) Cancel(ctx context.Context, identityID, siteID, reservationID uuid.UUID) error {
+	if _, err := s.get(ctx, identityID, siteID, reservationID); err != nil {
+		return err
+	}
+
+	r, err := s.queries.CancelReservation(ctx, reservationID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return httpx.Conflict(ctx, "Reservation has already ended")
+		}
+
+		return httpx.InternalErr(ctx, "Failed to cancel reservation", err)
+	}
+
+	if r.ChargerStatus != ocpp.StatusAccepted {
+		return nil
+	}
+
+	c, err := s.queries.GetConnectorById(ctx, r.ConnectorID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve connector of cancelled reservation", "reservation.id", r.ID, "error", err)
+		return nil
+	}
+
+	go func(ctx context.Context) {
+		if err := s.release(ctx, c.DeviceID, r.OcppID); err != nil {
+			slog.WarnContext(ctx, "Charger did not cancel reservation", "reservation.id", r.ID, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+// Availability returns the reserved and free periods of every connector at
+// the site within the window.
+func (s *Service) Availability(ctx context.Context, identityID, siteID uuid.UUID, w Window) (*AvailabilityResponse, error) {
+	if errs := w.Validate(maxAvailability); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	_, role, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	connectors, err := s.queries.ListConnectorsBySiteId(ctx, pgtype.UUID{Bytes: siteID, Valid: true})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve connectors", err)
+	}
+
+	reservations, err := s.queries.ListReservationsBySiteId(ctx, repository.ListReservationsBySiteIdParams{
+		SiteID:       siteID,
+		EndsAfter:    w.From,
+		StartsBefore: w.To,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve reservations", err)
+	}
+
+	byConnector := make(map[uuid.UUID][]*SlotResponse, len(connectors))
+	for _, r := range reservations {
+		if !blocking(r.Status) {
+			continue
+		}
+
+		slot := &SlotResponse{StartsAt: r.StartsAt, EndsAt: r.EndsAt, Status: r.Status, Mine: r.UserID == identityID}
+		if slot.Mine || manages(role) {
+			slot.ReservationID = &r.ID
+		}
+		byConnector[r.ConnectorID] = append(byConnector[r.ConnectorID], slot)
+	}
+
+	res := &AvailabilityResponse{
+		SiteID:     siteID,
+		From:       w.From,
+		To:         w.To,
+		Connectors: make([]*ConnectorAvailability, 0, len(connectors)),
+	}
+	for _, c := range connectors {
+		_, err := s.chargers.Connection(c.DeviceID)
+		reserved := byConnector[c.ID]
+		if reserved == nil {
+			reserved = []*SlotResponse{}
+		}
+
+		res.Connectors = append(res.Connectors, &ConnectorAvailability{
+			ID:         c.ID,
+			DeviceID:   c.DeviceID,
+			DeviceName: c.DeviceName,
+			Connector:  c.ConnectorID,
+			Status:     c.Status,
+			Online:     err == nil,
+			Reserved:   reserved,
+			Free:       freeSlots(reserved, w),
+		})
+	}
+
+	return res, nil
+}
+
+// Holds reports whether the user has a reservation of the connector that
+// started, or starts within the early arrival margin, and has not ended.
+func (s *Service) Holds(ctx context.Context, userID, connectorID uuid.UUID, now time.Time) (bool, error) {
+	n, err := s.queries.CountCurrentReservationsOnConnector(ctx, repository.CountCurrentReservationsOnConnectorParams{
+		ConnectorID:  connectorID,
+		UserID:       userID,
+		StartsBefore: now.Add(earlyArrival),
+		EndsAfter:    now,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(reservationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.step(ctx, now.UTC()); err != nil {
+				slog.ErrorContext(ctx, "Failed to process reservations", "error", err)
+			}
+		}
+	}
+}
+
+// step advances the open reservations that have started or that an early
+// session could fulfil.
+func (s *Service) step(ctx context.Context, now time.Time) error {
+	open, err := s.queries.ListOpenReservations(ctx, now.Add(earlyArrival))
+	if err != nil {
+		return err
+	}
+
+	for i := range open {
+		if err := s.advance(ctx, &open[i], now); err != nil {
+			slog.WarnContext(ctx, "Failed to advance reservation", "reservation.id", open[i].ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// advance fulfils a reservation once a session starts on its connector,
+// holds it on the charger once it starts and ends it as a no-show or expired.
+// Sessions are not linked to the user that reserved, so any session within
+// the reservation fulfils it; while the charger holds the connector only the
+// id tag of the user can start one.
+func (s *Service) advance(ctx context.Context, r *repository.ListOpenReservationsRow, now time.Time) error {
+	cs, err := s.queries.GetFirstChargingSessionOnConnector(ctx, repository.GetFirstChargingSessionOnConnectorParams{
+		DeviceID:      r.DeviceID,
+		ConnectorID:   r.ConnectorNumber,
+		StartedFrom:   r.StartsAt.Add(-earlyArrival),
+		StartedBefore: r.EndsAt,
+	})
+	switch {
+	case err == nil:
+		_, err := s.queries.FulfilReservation(ctx, repository.FulfilReservationParams{
+			ID:        r.ID,
+			SessionID: pgtype.UUID{Bytes: cs.ID, Valid: true},
+		})
+		return err
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	if !r.EndsAt.After(now) {
+		// The charger drops the reservation itself at the expiry it was given.
+		_, err := s.queries.EndReservation(ctx, repository.EndReservationParams{ID: r.ID, Status: StatusExpired})
+		return err
+	}
+	if r.StartsAt.After(now) {
+		return nil
+	}
+
+	heldAt := pgtype.Timestamptz{Time: now, Valid: true}
+	switch {
+	case r.Status == StatusBooked:
+		_, err := s.queries.HoldReservation(ctx, repository.HoldReservationParams{
+			ID:            r.ID,
+			ChargerStatus: s.reserve(ctx, r),
+			HeldAt:        heldAt,
+		})
+		return err
+	case r.ChargerStatus == ChargerOffline:
+		// The grace period starts again once the charger holds the connector.
+		status := s.reserve(ctx, r)
+		if status == ChargerOffline {
+			return nil
+		}
+		return s.queries.SetReservationChargerStatus(ctx, repository.SetReservationChargerStatusParams{
+			ID:            r.ID,
+			ChargerStatus: status,
+			HeldAt:        heldAt,
+		})
+	case r.ChargerStatus == ocpp.StatusAccepted && r.HeldAt.Valid && !now.Before(r.HeldAt.Time.Add(noShowGrace)):
+		return s.noShow(ctx, r)
+	}
+
+	// The charger refused to hold the connector, for example because another
+	// vehicle is plugged in, which is not the user's fault. The reservation
+	// expires at its end.
+	return nil
+}
+
+// noShow releases the connector of a reservation nobody turned up for.
+func (s *Service) noShow(ctx context.Context, r *repository.ListOpenReservationsRow) error {
+	n, err := s.queries.EndReservation(ctx, repository.EndReservationParams{ID: r.ID, Status: StatusNoShow})
+	if err != nil || n == 0 {
+		return err
+	}
+
+	if err := s.release(ctx, r.DeviceID, r.OcppID); err != nil {
+		slog.WarnContext(ctx, "Charger did not cancel reservation", "reservation.id", r.ID, "error", err)
+	}
+
+	s.events.Record(ctx, event.Event{
+		Type:     event.TypeReservationNoShow,
+		DeviceID: &r.DeviceID,
+		Payload: map[string]any{
+			"reservationId": r.ID,
+			"siteId":        r.SiteID,
+			"userId":        r.UserID,
+			"connector":     r.ConnectorNumber,
+			"startsAt":      r.StartsAt,
+			"endsAt":        r.EndsAt,
+		},
+	})
+
+	slog.InfoContext(ctx, "Reservation released as no-show", "reservation.id", r.ID)
+	return nil
+}
+
+// freeSlots returns the gaps between the reserved periods, which are ordered
+// by start, that are long enough to be reserved.
+func freeSlots(reserved []*SlotResponse, w Window) []*SlotResponse {
+	res := []*SlotResponse{}
+	cursor := w.From
+	for _, r := range reserved {
+		if r.StartsAt.Sub(cursor) >= minDuration {
+			res = append(res, &SlotResponse{StartsAt: cursor, EndsAt: r.StartsAt})
+		}
+		if r.EndsAt.After(cursor) {
+			cursor = r.EndsAt
+		}
+	}
+
+	if w.To.Sub(cursor) >= minDuration {
+		res = append(res, &SlotResponse{StartsAt: cursor, EndsAt: w.To})
+	}
+
+	return res
+}
+
+// get returns a reservation of the site the caller may see.
+func (s *Service) get(ctx context.Context, identityID, siteID, reservationID uuid.UUID) (*repository.Reservation, error) {
+	_, role, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.queries.GetReservationById(ctx, reservationID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Reservation could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve reservation", err)
+	}
+
+	if r.SiteID != siteID || (r.UserID != identityID && !manages(role)) {
+		return nil, httpx.NotFound(ctx, "Reservation could not be found")
+	}
+
+	return &r, nil
+}
+
+func manages(role string) bool {
+	return role == site.RoleOwner || role == site.RoleManager
+}
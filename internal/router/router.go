@@ -5,10 +5,38 @@ import (
 	"errors"
 	"fmt"
 	"github.com/V2G-Minor-Fontys/server/internal/auth"
+	"github.com/V2G-Minor-Fontys/server/internal/battery"
+	"github.com/V2G-Minor-Fontys/server/internal/carbon"
+	"github.com/V2G-Minor-Fontys/server/internal/chargepoint"
+	"github.com/V2G-Minor-Fontys/server/internal/chargingprofile"
+	"github.com/V2G-Minor-Fontys/server/internal/command"
 	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/internal/congestion"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/dispatch"
+	"github.com/V2G-Minor-Fontys/server/internal/event"
+	"github.com/V2G-Minor-Fontys/server/internal/flexibility"
+	"github.com/V2G-Minor-Fontys/server/internal/meter"
 	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"github.com/V2G-Minor-Fontys/server/internal/price"
+	"github.com/V2G-Minor-Fontys/server/internal/remote"
 	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/reservation"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/internal/session"
+	"github.com/V2G-Minor-Fontys/server/internal/shadow"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/V2G-Minor-Fontys/server/internal/solar"
+	"github.com/V2G-Minor-Fontys/server/internal/statement"
 	"github.com/V2G-Minor-Fontys/server/internal/system"
+	"github.com/V2G-Minor-Fontys/server/internal/tariff"
+	"github.com/V2G-Minor-Fontys/server/internal/user"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/V2G-Minor-Fontys/server/internal/ven"
+	"github.com/V2G-Minor-Fontys/server/internal/vtn"
+	"github.com/V2G-Minor-Fontys/server/pkg/cache"
+	"github.com/V2G-Minor-Fontys/server/pkg/mqtt"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -21,12 +49,119 @@ type Server struct {
 	cfg        *config.Config
 	httpServer *http.Server
 	auth       *auth.Handler
+	users      *user.Handler
+	devices    *device.Handler
+	commands   *command.Handler
+	commandSvc *command.Service
+	shadows    *shadow.Handler
+	shadowSvc  *shadow.Service
+	vehicles   *vehicle.Handler
+	schedules  *scheduling.Handler
+	planner    *scheduling.Service
+	chargers   *chargepoint.Server
+	profiles   *chargingprofile.Handler
+	profileSvc *chargingprofile.Service
+	events     *event.Handler
+	prices     *price.Handler
+	priceSvc   *price.Service
+	carbon     *carbon.Handler
+	carbonSvc  *carbon.Service
+	tariffs    *tariff.Handler
+	sessions   *session.Handler
+	sessionSvc *session.Service
+	statements *statement.Handler
+	sites      *site.Handler
+	balancer   *site.Balancer
+	meters     *meter.Handler
+	meterSvc   *meter.Service
+	solar      *solar.Handler
+	solarSvc   *solar.Service
+	vtn        *vtn.Handler
+	venClient  *ven.Client
+	flex       *flexibility.Handler
+	flexSvc    *flexibility.Service
+	dispatch   *dispatch.Handler
+	dispatcher *dispatch.Service
+	congestion *congestion.Handler
+	battery    *battery.Handler
+	batterySvc *battery.Service
+	bookings   *reservation.Handler
+	bookingSvc *reservation.Service
+	remote     *remote.Handler
 }
 
-func NewServer(cfg *config.Config, pool *pgxpool.Pool, queries *repository.Queries) *Server {
+func NewServer(cfg *config.Config, pool *pgxpool.Pool, queries *repository.Queries, broker *mqtt.Client, c *cache.Loader, prices price.Source, intensities carbon.Source, weather solar.WeatherProvider) *Server {
+	deviceSvc := device.NewService(queries)
+	commandSvc := command.NewService(queries, deviceSvc, broker)
+	shadowSvc := shadow.NewService(queries, deviceSvc, broker, c)
+	vehicleSvc := vehicle.NewService(pool, queries, deviceSvc)
+	priceSvc := price.NewService(pool, queries, prices, cfg.Prices.Zones, c)
+	siteSvc := site.NewService(pool, queries, deviceSvc)
+	tariffSvc := tariff.NewService(pool, queries, priceSvc, siteSvc, c)
+	carbonSvc := carbon.NewService(pool, queries, intensities, cfg.Carbon.Zones)
+	meterSvc := meter.NewService(queries, deviceSvc, broker)
+	solarSvc := solar.NewService(queries, siteSvc, deviceSvc, broker, weather)
+	vtnSvc := vtn.NewService(pool, queries, vehicleSvc, cfg.OpenADR)
+	venSvc := ven.NewService(pool, queries, cfg.Ven)
+	eventSvc := event.NewService(queries, deviceSvc)
+	congestionSvc := congestion.NewService(pool, queries, siteSvc, vehicleSvc, eventSvc, cfg.Congestion)
+	limiter := site.NewGridLimiter(queries, meterSvc, solarSvc, congestionSvc)
+	batterySvc := battery.NewService(queries, vehicleSvc)
+	planner := scheduling.NewService(queries, vehicleSvc, tariff.NewForecaster(tariffSvc), limiter, vtnSvc, batterySvc, carbon.NewForecaster(carbonSvc))
+	flexSvc := flexibility.NewService(queries, siteSvc, vehicleSvc, limiter, meterSvc)
+	chargers := chargepoint.NewServer(queries, c)
+	deviceSvc.OnPasswordReset(chargers.Disconnect)
+	profileSvc := chargingprofile.NewService(pool, queries, deviceSvc, chargers, eventSvc)
+	planner.Subscribe(profileSvc.Apply)
+	planner.Subscribe(congestionSvc.CheckSchedule)
+	dispatcher := dispatch.NewService(queries, siteSvc, vehicleSvc, flexSvc, profileSvc, eventSvc, venSvc)
+	sessionSvc := session.NewService(queries, tariffSvc, carbonSvc)
+	sessionSvc.RegisterHandlers(chargers)
+	reservationSvc := reservation.NewService(pool, queries, siteSvc, chargers, eventSvc)
+	reservationSvc.RegisterHandlers(chargers)
+
 	srv := &Server{
-		cfg:  cfg,
-		auth: auth.NewHandler(cfg.Jwt, pool, queries),
+		cfg:        cfg,
+		auth:       auth.NewHandler(cfg.Jwt, pool, queries),
+		users:      user.NewHandler(user.NewService(queries, c)),
+		devices:    device.NewHandler(deviceSvc),
+		commands:   command.NewHandler(commandSvc),
+		commandSvc: commandSvc,
+		shadows:    shadow.NewHandler(shadowSvc),
+		shadowSvc:  shadowSvc,
+		vehicles:   vehicle.NewHandler(vehicleSvc),
+		schedules:  scheduling.NewHandler(planner),
+		planner:    planner,
+		chargers:   chargers,
+		profiles:   chargingprofile.NewHandler(profileSvc),
+		profileSvc: profileSvc,
+		events:     event.NewHandler(eventSvc),
+		prices:     price.NewHandler(priceSvc),
+		priceSvc:   priceSvc,
+		carbon:     carbon.NewHandler(carbonSvc),
+		carbonSvc:  carbonSvc,
+		tariffs:    tariff.NewHandler(tariffSvc),
+		sessions:   session.NewHandler(sessionSvc),
+		sessionSvc: sessionSvc,
+		statements: statement.NewHandler(statement.NewService(queries, tariffSvc)),
+		sites:      site.NewHandler(siteSvc),
+		balancer:   site.NewBalancer(queries, vehicleSvc, profileSvc, meterSvc, congestionSvc),
+		meters:     meter.NewHandler(meterSvc),
+		meterSvc:   meterSvc,
+		solar:      solar.NewHandler(solarSvc),
+		solarSvc:   solarSvc,
+		vtn:        vtn.NewHandler(vtnSvc),
+		venClient:  ven.NewClient(cfg.Ven, venSvc, venSvc),
+		flex:       flexibility.NewHandler(flexSvc),
+		flexSvc:    flexSvc,
+		dispatch:   dispatch.NewHandler(dispatcher),
+		dispatcher: dispatcher,
+		congestion: congestion.NewHandler(congestionSvc),
+		battery:    battery.NewHandler(batterySvc),
+		batterySvc: batterySvc,
+		bookings:   reservation.NewHandler(reservationSvc),
+		bookingSvc: reservationSvc,
+		remote:     remote.NewHandler(remote.NewService(queries, deviceSvc, chargers, profileSvc, commandSvc, reservationSvc)),
 	}
 
 	srv.httpServer = &http.Server{
@@ -45,6 +180,9 @@ func (s *Server) MountHandlers() error {
 		middleware.Logger,
 	)
 
+	r.Get("/ocpp/{identity}", middleware.ErrHandler(s.chargers.ConnectHandler))
+	r.Post(openadr.BasePath+"/{service}", middleware.ErrHandler(s.vtn.ServiceHandler))
+
 	r.Route("/api", func(r chi.Router) {
 		r.Get("/healthz", middleware.ErrHandler(system.HealthHandler))
 		r.Route("/auth", func(r chi.Router) {
@@ -57,12 +195,211 @@ func (s *Server) MountHandlers() error {
 					r.Delete("/revoke", middleware.ErrHandler(s.auth.RevokeTokenHandler))
 				})
 		})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/users/me", func(r chi.Router) {
+				r.Get("/", middleware.ErrHandler(s.users.MeHandler))
+				r.Get("/tariff", middleware.ErrHandler(s.tariffs.GetMineHandler))
+				r.Put("/tariff", middleware.ErrHandler(s.tariffs.AssignHandler))
+				r.Delete("/tariff", middleware.ErrHandler(s.tariffs.UnassignHandler))
+				r.Get("/statements", middleware.ErrHandler(s.statements.ListHandler))
+				r.Get("/statements/{month}", middleware.ErrHandler(s.statements.GetHandler))
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/congestion/zones", func(r chi.Router) {
+				r.Post("/", middleware.ErrHandler(s.congestion.CreateZoneHandler))
+				r.Get("/", middleware.ErrHandler(s.congestion.ListZonesHandler))
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", middleware.ErrHandler(s.congestion.GetZoneHandler))
+					r.Put("/", middleware.ErrHandler(s.congestion.UpdateZoneHandler))
+					r.Delete("/", middleware.ErrHandler(s.congestion.DeleteZoneHandler))
+					r.Get("/limits", middleware.ErrHandler(s.congestion.ListLimitsHandler))
+					r.Post("/limits", middleware.ErrHandler(s.congestion.CreateLimitHandler))
+					r.Post("/limits/import", middleware.ErrHandler(s.congestion.ImportLimitsHandler))
+					r.Delete("/limits/{limitId}", middleware.ErrHandler(s.congestion.DeleteLimitHandler))
+				})
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/devices", func(r chi.Router) {
+				r.Post("/", middleware.ErrHandler(s.devices.CreateHandler))
+				r.Get("/", middleware.ErrHandler(s.devices.ListHandler))
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", middleware.ErrHandler(s.devices.GetHandler))
+					r.Post("/ocpp-password", middleware.ErrHandler(s.devices.ResetOcppPasswordHandler))
+					r.Post("/commands", middleware.ErrHandler(s.commands.CreateHandler))
+					r.Get("/commands", middleware.ErrHandler(s.commands.ListHandler))
+					r.Get("/commands/{cmdId}", middleware.ErrHandler(s.commands.GetHandler))
+					r.Post("/start", middleware.ErrHandler(s.remote.StartDeviceHandler))
+					r.Post("/pause", middleware.ErrHandler(s.remote.PauseDeviceHandler))
+					r.Post("/discharge", middleware.ErrHandler(s.remote.DischargeDeviceHandler))
+					r.Get("/shadow", middleware.ErrHandler(s.shadows.GetHandler))
+					r.Patch("/shadow/desired", middleware.ErrHandler(s.shadows.PatchDesiredHandler))
+					r.Get("/charging-profiles", middleware.ErrHandler(s.profiles.ListHandler))
+					r.Delete("/charging-profiles", middleware.ErrHandler(s.profiles.ClearHandler))
+					r.Get("/events", middleware.ErrHandler(s.events.ListForDeviceHandler))
+					r.Post("/p1", middleware.ErrHandler(s.meters.IngestHandler))
+					r.Get("/meter-readings", middleware.ErrHandler(s.meters.ListHandler))
+					r.Post("/production", middleware.ErrHandler(s.solar.IngestHandler))
+				})
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/activations", func(r chi.Router) {
+				r.Post("/", middleware.ErrHandler(s.dispatch.CreateHandler))
+				r.Get("/", middleware.ErrHandler(s.dispatch.ListHandler))
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", middleware.ErrHandler(s.dispatch.GetHandler))
+					r.Delete("/", middleware.ErrHandler(s.dispatch.CancelHandler))
+					r.Post("/opt-out", middleware.ErrHandler(s.dispatch.OptOutHandler))
+				})
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/flexibility", func(r chi.Router) {
+				r.Get("/", middleware.ErrHandler(s.flex.GetHandler))
+				r.Get("/forecasts", middleware.ErrHandler(s.flex.ListForecastsHandler))
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/openadr", func(r chi.Router) {
+				r.Post("/vens", middleware.ErrHandler(s.vtn.CreateVenHandler))
+				r.Get("/vens", middleware.ErrHandler(s.vtn.ListVensHandler))
+				r.Route("/vens/{id}", func(r chi.Router) {
+					r.Get("/", middleware.ErrHandler(s.vtn.GetVenHandler))
+					r.Delete("/", middleware.ErrHandler(s.vtn.DeleteVenHandler))
+					r.Get("/vehicles", middleware.ErrHandler(s.vtn.ListVehiclesHandler))
+					r.Put("/vehicles/{vehicleId}", middleware.ErrHandler(s.vtn.EnrolHandler))
+					r.Delete("/vehicles/{vehicleId}", middleware.ErrHandler(s.vtn.UnenrolHandler))
+					r.Get("/reports", middleware.ErrHandler(s.vtn.ListReportsHandler))
+				})
+				r.Post("/events", middleware.ErrHandler(s.vtn.CreateEventHandler))
+				r.Get("/events", middleware.ErrHandler(s.vtn.ListEventsHandler))
+				r.Get("/events/{id}", middleware.ErrHandler(s.vtn.GetEventHandler))
+				r.Delete("/events/{id}", middleware.ErrHandler(s.vtn.CancelEventHandler))
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Get("/prices", middleware.ErrHandler(s.prices.ListHandler))
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Get("/carbon-intensity", middleware.ErrHandler(s.carbon.ListHandler))
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/sessions", func(r chi.Router) {
+				r.Get("/", middleware.ErrHandler(s.sessions.ListHandler))
+				r.Get("/{id}", middleware.ErrHandler(s.sessions.GetHandler))
+				r.Post("/{id}/stop", middleware.ErrHandler(s.remote.StopHandler))
+				r.Post("/{id}/pause", middleware.ErrHandler(s.remote.PauseHandler))
+				r.Post("/{id}/resume", middleware.ErrHandler(s.remote.ResumeHandler))
+				r.Post("/{id}/discharge", middleware.ErrHandler(s.remote.DischargeHandler))
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Post("/connectors/{id}/start", middleware.ErrHandler(s.remote.StartHandler))
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/sites", func(r chi.Router) {
+				r.Post("/", middleware.ErrHandler(s.sites.CreateHandler))
+				r.Get("/", middleware.ErrHandler(s.sites.ListHandler))
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", middleware.ErrHandler(s.sites.GetHandler))
+					r.Put("/", middleware.ErrHandler(s.sites.UpdateHandler))
+					r.Delete("/", middleware.ErrHandler(s.sites.DeleteHandler))
+					r.Get("/members", middleware.ErrHandler(s.sites.ListMembersHandler))
+					r.Post("/members", middleware.ErrHandler(s.sites.AddMemberHandler))
+					r.Put("/members/{userId}", middleware.ErrHandler(s.sites.SetRoleHandler))
+					r.Delete("/members/{userId}", middleware.ErrHandler(s.sites.RemoveMemberHandler))
+					r.Get("/devices", middleware.ErrHandler(s.sites.ListDevicesHandler))
+					r.Put("/devices/{deviceId}", middleware.ErrHandler(s.sites.AttachDeviceHandler))
+					r.Delete("/devices/{deviceId}", middleware.ErrHandler(s.sites.DetachDeviceHandler))
+					r.Get("/tariff", middleware.ErrHandler(s.tariffs.GetSiteHandler))
+					r.Put("/tariff", middleware.ErrHandler(s.tariffs.AssignSiteHandler))
+					r.Delete("/tariff", middleware.ErrHandler(s.tariffs.UnassignSiteHandler))
+					r.Get("/pv-systems", middleware.ErrHandler(s.solar.ListHandler))
+					r.Post("/pv-systems", middleware.ErrHandler(s.solar.CreateHandler))
+					r.Put("/pv-systems/{pvId}", middleware.ErrHandler(s.solar.UpdateHandler))
+					r.Delete("/pv-systems/{pvId}", middleware.ErrHandler(s.solar.DeleteHandler))
+					r.Get("/solar", middleware.ErrHandler(s.solar.ReportHandler))
+					r.Get("/solar/forecast", middleware.ErrHandler(s.solar.ForecastHandler))
+					r.Get("/congestion", middleware.ErrHandler(s.congestion.SiteCongestionHandler))
+					r.Get("/availability", middleware.ErrHandler(s.bookings.AvailabilityHandler))
+					r.Post("/reservations", middleware.ErrHandler(s.bookings.CreateHandler))
+					r.Get("/reservations", middleware.ErrHandler(s.bookings.ListHandler))
+					r.Get("/reservations/{reservationId}", middleware.ErrHandler(s.bookings.GetHandler))
+					r.Delete("/reservations/{reservationId}", middleware.ErrHandler(s.bookings.CancelHandler))
+				})
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/tariffs", func(r chi.Router) {
+				r.Post("/", middleware.ErrHandler(s.tariffs.CreateHandler))
+				r.Get("/", middleware.ErrHandler(s.tariffs.ListHandler))
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", middleware.ErrHandler(s.tariffs.GetHandler))
+					r.Put("/", middleware.ErrHandler(s.tariffs.UpdateHandler))
+					r.Delete("/", middleware.ErrHandler(s.tariffs.DeleteHandler))
+					r.Get("/prices", middleware.ErrHandler(s.tariffs.PricesHandler))
+				})
+			})
+
+		r.With(middleware.AuthVerifier(s.cfg.Jwt)).
+			Route("/vehicles", func(r chi.Router) {
+				r.Post("/", middleware.ErrHandler(s.vehicles.CreateHandler))
+				r.Get("/", middleware.ErrHandler(s.vehicles.ListHandler))
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", middleware.ErrHandler(s.vehicles.GetHandler))
+					r.Get("/preferences", middleware.ErrHandler(s.vehicles.GetPreferencesHandler))
+					r.Put("/preferences", middleware.ErrHandler(s.vehicles.PutPreferencesHandler))
+					r.Post("/preferences/boost", middleware.ErrHandler(s.vehicles.BoostHandler))
+					r.Delete("/preferences/boost", middleware.ErrHandler(s.vehicles.CancelBoostHandler))
+					r.Get("/schedule", middleware.ErrHandler(s.schedules.GetHandler))
+					r.Post("/schedule", middleware.ErrHandler(s.schedules.ReplanHandler))
+					r.Get("/activations", middleware.ErrHandler(s.dispatch.ListForVehicleHandler))
+					r.Get("/battery-health", middleware.ErrHandler(s.battery.HealthHandler))
+				})
+			})
 	})
 
 	s.Mux = r
 	return nil
 }
 
+// StartWorkers subscribes to device topics and launches the background loops
+// that keep running until ctx is cancelled.
+func (s *Server) StartWorkers(ctx context.Context) error {
+	if err := s.commandSvc.Subscribe(); err != nil {
+		return fmt.Errorf("failed to subscribe to command acknowledgements: %w", err)
+	}
+
+	if err := s.shadowSvc.Subscribe(); err != nil {
+		return fmt.Errorf("failed to subscribe to device shadow topics: %w", err)
+	}
+
+	if err := s.meterSvc.Subscribe(); err != nil {
+		return fmt.Errorf("failed to subscribe to P1 telegrams: %w", err)
+	}
+
+	if err := s.solarSvc.Subscribe(); err != nil {
+		return fmt.Errorf("failed to subscribe to inverter production: %w", err)
+	}
+
+	go s.commandSvc.Run(ctx)
+	go s.priceSvc.Run(ctx)
+	go s.carbonSvc.Run(ctx)
+	go s.planner.Run(ctx)
+	go s.profileSvc.Run(ctx)
+	go s.sessionSvc.Run(ctx)
+	go s.balancer.Run(ctx)
+	go s.venClient.Run(ctx)
+	go s.flexSvc.Run(ctx)
+	go s.dispatcher.Run(ctx)
+	go s.batterySvc.Run(ctx)
+	go s.bookingSvc.Run(ctx)
+	return nil
+}
+
 func (s *Server) ListenAndServe() error {
 	go func() {
 		slog.Info(fmt.Sprintf("HTTP server is listening on %d", s.cfg.Server.Port))
@@ -0,0 +1,66 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/google/uuid"
+	"time"
+)
+
+var ErrNoCarbonForecast = errors.New("no carbon intensity forecast available")
+
+// CarbonPoint is the carbon intensity of grid energy in gCO2eq/kWh from Start
+// until the next point.
+type CarbonPoint struct {
+	Start       time.Time
+	GramsPerKwh float64
+}
+
+// CarbonForecaster supplies the carbon intensity of the grid a vehicle
+// charges from.
+type CarbonForecaster interface {
+	Forecast(ctx context.Context, vehicleID uuid.UUID, from, to time.Time) ([]CarbonPoint, error)
+	// UpdatedAt reports when the vehicle's forecast last changed, so plans
+	// weighing emissions that were made before that moment can be refreshed.
+	UpdatedAt(ctx context.Context, vehicleID uuid.UUID) (time.Time, error)
+}
+
+// carbonWeight is how much the plan of a vehicle weighs its emissions against
+// its cost, vehicles in greenest mode only consider emissions.
+func carbonWeight(prefs *vehicle.Preferences) float64 {
+	if prefs.ChargingMode == vehicle.ModeGreenest {
+		return 1
+	}
+
+	return prefs.CarbonWeight
+}
+
+// intensities returns the carbon intensity of every planning slot in
+// [start, end) for the vehicle. Slots after the last point reuse the last
+// known intensity.
+func (s *Service) intensities(ctx context.Context, v *repository.Vehicle, start, end time.Time) ([]float64, error) {
+	if s.carbon == nil {
+		return nil, ErrNoCarbonForecast
+	}
+
+	points, err := s.carbon.Forecast(ctx, v.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 || points[0].Start.After(start) {
+		return nil, ErrNoCarbonForecast
+	}
+
+	var slots []float64
+	i := 0
+	for t := start; t.Before(end); t = t.Add(Resolution) {
+		for i+1 < len(points) && !points[i+1].Start.After(t) {
+			i++
+		}
+		slots = append(slots, points[i].GramsPerKwh)
+	}
+
+	return slots, nil
+}
@@ -0,0 +1,61 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/optimizer"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	ReasonInitial            = "initial"
+	ReasonManual             = "manual"
+	ReasonPreferencesChanged = "preferences_changed"
+	ReasonPricesChanged      = "prices_changed"
+	ReasonPeriodic           = "periodic"
+	ReasonBoost              = "boost"
+	ReasonEventsChanged      = "events_changed"
+	ReasonCarbonChanged      = "carbon_changed"
+	// ReasonSolar marks the grid fallback of a vehicle in solar mode, the
+	// site balancer adds the PV surplus on top of it.
+	ReasonSolar = "solar"
+	// ReasonGreenest marks the plan of a vehicle in greenest mode, which
+	// charges when the grid emits the least regardless of price.
+	ReasonGreenest = "greenest"
+)
+
+type ScheduleResponse struct {
+	ID            uuid.UUID            `json:"id"`
+	VehicleID     uuid.UUID            `json:"vehicleId"`
+	Reason        string               `json:"reason"`
+	HorizonStart  time.Time            `json:"horizonStart"`
+	HorizonEnd    time.Time            `json:"horizonEnd"`
+	InitialSoc    float64              `json:"initialSoc"`
+	FinalSoc      float64              `json:"finalSoc"`
+	TargetReached bool                 `json:"targetReached"`
+	TotalCost     float64              `json:"totalCost"`
+	Setpoints     []optimizer.Setpoint `json:"setpoints"`
+	CreatedAt     time.Time            `json:"createdAt"`
+}
+
+func NewScheduleResponse(s *repository.ChargingSchedule) (*ScheduleResponse, error) {
+	res := &ScheduleResponse{
+		ID:            s.ID,
+		VehicleID:     s.VehicleID,
+		Reason:        s.Reason,
+		HorizonStart:  s.HorizonStart,
+		HorizonEnd:    s.HorizonEnd,
+		InitialSoc:    s.InitialSoc,
+		FinalSoc:      s.FinalSoc,
+		TargetReached: s.TargetReached,
+		TotalCost:     s.TotalCost,
+		CreatedAt:     s.CreatedAt,
+	}
+
+	if err := json.Unmarshal(s.Setpoints, &res.Setpoints); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,76 @@
+package scheduling
+
+import (
+	"context"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/optimizer"
+	"github.com/google/uuid"
+	"math"
+	"time"
+)
+
+// Event is a demand response request for a vehicle from Start until End.
+// MaxImportKw caps the charging power and ImportPrice replaces the price of
+// energy taken from the grid, either may be nil.
+type Event struct {
+	Start       time.Time
+	End         time.Time
+	MaxImportKw *float64
+	ImportPrice *float64
+}
+
+// EventSource supplies the demand response events a vehicle takes part in.
+// Events are ordered by ascending priority, the price of a later event takes
+// precedence while caps always apply.
+type EventSource interface {
+	Events(ctx context.Context, v *repository.Vehicle, from, to time.Time) ([]Event, error)
+	// UpdatedAt is when the events of the vehicle last changed.
+	UpdatedAt(ctx context.Context, vehicleID uuid.UUID) (time.Time, error)
+}
+
+// applyEvents caps and re-prices every interval that overlaps an event.
+func applyEvents(intervals []optimizer.Interval, events []Event) {
+	for i := range intervals {
+		for _, e := range events {
+			if !overlaps(intervals[i].Start, e) {
+				continue
+			}
+
+			if e.MaxImportKw != nil {
+				limit := math.Max(*e.MaxImportKw, minLimitKw)
+				if intervals[i].MaxImportKw <= 0 || limit < intervals[i].MaxImportKw {
+					intervals[i].MaxImportKw = limit
+				}
+			}
+			if e.ImportPrice != nil {
+				intervals[i].ImportPrice = *e.ImportPrice
+			}
+		}
+	}
+}
+
+// eventCapAt is the lowest cap of the events overlapping the slot at t.
+func eventCapAt(events []Event, t time.Time) (float64, bool) {
+	capKw, ok := math.Inf(1), false
+	for _, e := range events {
+		if e.MaxImportKw != nil && overlaps(t, e) {
+			capKw, ok = math.Min(capKw, math.Max(0, *e.MaxImportKw)), true
+		}
+	}
+
+	return capKw, ok
+}
+
+func overlaps(slotStart time.Time, e Event) bool {
+	return slotStart.Before(e.End) && slotStart.Add(Resolution).After(e.Start)
+}
+
+// demandEvents returns the demand response events of the vehicle, or none
+// when there is no event source.
+func (s *Service) demandEvents(ctx context.Context, v *repository.Vehicle, from, to time.Time) ([]Event, error) {
+	if s.events == nil {
+		return nil, nil
+	}
+
+	return s.events.Events(ctx, v, from, to)
+}
@@ -0,0 +1,67 @@
+package scheduling
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	sched, err := h.svc.GetLatest(ctx, identityID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewScheduleResponse(sched)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Stored schedule is corrupt", err)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) ReplanHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	sched, err := h.svc.Replan(ctx, identityID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewScheduleResponse(sched)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Stored schedule is corrupt", err)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, res)
+	return nil
+}
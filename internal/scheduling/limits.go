@@ -0,0 +1,66 @@
+package scheduling
+
+import (
+	"context"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/optimizer"
+	"github.com/google/uuid"
+	"math"
+	"time"
+)
+
+// minLimitKw stands in for a limit of zero, which the optimizer would read as
+// no limit at all.
+const minLimitKw = 1e-6
+
+// GridLimit is the grid power a charger may draw and feed back from Start
+// until the next limit. SurplusKw is the expected PV production the site
+// would export, which the charger may draw on top of MaxImportKw.
+type GridLimit struct {
+	Start       time.Time
+	MaxImportKw float64
+	MaxExportKw float64
+	SurplusKw   float64
+}
+
+// GridLimiter supplies the capacity of the grid connection a charger is
+// behind. No limits means the charger is not part of a site and only the
+// vehicle limits apply.
+type GridLimiter interface {
+	GridLimits(ctx context.Context, chargerID uuid.UUID, from, to time.Time) ([]GridLimit, error)
+}
+
+// LimitAt returns the limit in effect at t, limits must be ordered by start.
+func LimitAt(limits []GridLimit, t time.Time) (GridLimit, bool) {
+	for i := len(limits) - 1; i >= 0; i-- {
+		if !limits[i].Start.After(t) {
+			return limits[i], true
+		}
+	}
+
+	return GridLimit{}, false
+}
+
+// applyLimits caps each interval with the grid limit in effect at its start.
+func applyLimits(intervals []optimizer.Interval, limits []GridLimit) {
+	for i := range intervals {
+		l, ok := LimitAt(limits, intervals[i].Start)
+		if !ok {
+			continue
+		}
+
+		intervals[i].MaxImportKw = math.Max(l.MaxImportKw, minLimitKw)
+		intervals[i].MaxExportKw = math.Max(l.MaxExportKw, minLimitKw)
+		intervals[i].SurplusKw = l.SurplusKw
+	}
+}
+
+// gridLimits returns the limits of the connection the vehicle's charger is
+// behind, or none when there is no limiter or charger.
+func (s *Service) gridLimits(ctx context.Context, v *repository.Vehicle, from, to time.Time) ([]GridLimit, error) {
+	if s.limits == nil || !v.ChargerID.Valid {
+		return nil, nil
+	}
+
+	return s.limits.GridLimits(ctx, uuid.UUID(v.ChargerID.Bytes), from, to)
+}
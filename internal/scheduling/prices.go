@@ -0,0 +1,61 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+var ErrNoPriceForecast = errors.New("no price forecast available")
+
+// PricePoint is the effective price from Start until the next point, and
+// until End for the last one.
+type PricePoint struct {
+	Start       time.Time
+	End         time.Time
+	ImportPrice float64
+	ExportPrice float64
+}
+
+// PriceForecaster supplies the prices a vehicle owner pays and receives when
+// charging the vehicle at its site.
+type PriceForecaster interface {
+	Forecast(ctx context.Context, ownerID, vehicleID uuid.UUID, from, to time.Time) ([]PricePoint, error)
+	// UpdatedAt reports when the vehicle's forecast last changed, so plans
+	// made before that moment can be refreshed.
+	UpdatedAt(ctx context.Context, ownerID, vehicleID uuid.UUID) (time.Time, error)
+}
+
+// expandPrices maps possibly coarser price points onto planning slots of the
+// given resolution. The slots stop where the known prices end, which may be
+// before end, rather than repeating the last price for days.
+func expandPrices(points []PricePoint, start, end time.Time, resolution time.Duration) ([]PricePoint, error) {
+	if len(points) == 0 || points[0].Start.After(start) {
+		return nil, ErrNoPriceForecast
+	}
+
+	if last := points[len(points)-1].End; last.Before(end) {
+		end = last
+	}
+
+	var slots []PricePoint
+	i := 0
+	for t := start; t.Before(end); t = t.Add(resolution) {
+		for i+1 < len(points) && !points[i+1].Start.After(t) {
+			i++
+		}
+
+		slots = append(slots, PricePoint{
+			Start:       t,
+			End:         t.Add(resolution),
+			ImportPrice: points[i].ImportPrice,
+			ExportPrice: points[i].ExportPrice,
+		})
+	}
+	if len(slots) == 0 {
+		return nil, ErrNoPriceForecast
+	}
+
+	return slots, nil
+}
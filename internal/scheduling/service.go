@@ -0,0 +1,396 @@
+package scheduling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/V2G-Minor-Fontys/server/pkg/optimizer"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const (
+	Resolution          = optimizer.DefaultResolution
+	DefaultEfficiency   = 0.92
+	defaultHorizon      = 24 * time.Hour
+	replanInterval      = time.Hour
+	replanCheckInterval = time.Minute
+)
+
+// Listener is notified after a new schedule has been stored for a vehicle.
+type Listener func(ctx context.Context, v *repository.Vehicle, sched *repository.ChargingSchedule)
+
+type Service struct {
+	queries   *repository.Queries
+	vehicles  *vehicle.Service
+	prices    PriceForecaster
+	limits    GridLimiter
+	events    EventSource
+	wear      WearEstimator
+	carbon    CarbonForecaster
+	listeners []Listener
+}
+
+func NewService(queries *repository.Queries, vehicles *vehicle.Service, prices PriceForecaster, limits GridLimiter, events EventSource, wear WearEstimator, carbon CarbonForecaster) *Service {
+	return &Service{queries: queries, vehicles: vehicles, prices: prices, limits: limits, events: events, wear: wear, carbon: carbon}
+}
+
+// Subscribe registers a listener for new schedules, it must be called before
+// the service starts planning.
+func (s *Service) Subscribe(l Listener) {
+	s.listeners = append(s.listeners, l)
+}
+
+func (s *Service) GetLatest(ctx context.Context, identityID, vehicleID uuid.UUID) (*repository.ChargingSchedule, error) {
+	v, err := s.vehicles.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	sched, err := s.queries.GetLatestChargingSchedule(ctx, v.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "No schedule has been planned for this vehicle yet")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve schedule", err)
+	}
+
+	return &sched, nil
+}
+
+func (s *Service) Replan(ctx context.Context, identityID, vehicleID uuid.UUID) (*repository.ChargingSchedule, error) {
+	v, err := s.vehicles.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	sched, err := s.Plan(ctx, v, ReasonManual)
+	switch {
+	case errors.Is(err, ErrNoPriceForecast):
+		return nil, httpx.Conflict(ctx, "No price forecast is available to plan with")
+	case errors.Is(err, ErrNoCarbonForecast):
+		return nil, httpx.Conflict(ctx, "No carbon intensity forecast is available to plan with")
+	case errors.Is(err, ErrUnknownSoc):
+		return nil, httpx.Conflict(ctx, "The state of charge of this vehicle is unknown, link a charger that reports it")
+	case err != nil:
+		return nil, httpx.InternalErr(ctx, "Failed to plan schedule", err)
+	}
+
+	return sched, nil
+}
+
+// Plan computes and stores a new schedule for the vehicle from now until its
+// next departure.
+func (s *Service) Plan(ctx context.Context, v *repository.Vehicle, reason string) (*repository.ChargingSchedule, error) {
+	if s.prices == nil {
+		return nil, ErrNoPriceForecast
+	}
+
+	prefs, err := s.vehicles.LoadPreferences(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	soc, err := s.currentSoc(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	start := now.Truncate(Resolution)
+	end := start.Add(defaultHorizon)
+	if departure, ok := prefs.NextDeparture(now); ok {
+		end = departure.UTC().Truncate(Resolution)
+	}
+	if !end.After(start) {
+		end = start.Add(Resolution)
+	}
+
+	points, err := s.prices.Forecast(ctx, v.OwnerID, v.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	slots, err := expandPrices(points, start, end, Resolution)
+	if err != nil {
+		return nil, err
+	}
+	// Without prices up to the departure the plan ends with the known prices
+	// and is extended when the next day-ahead prices are published.
+	end = slots[len(slots)-1].End
+
+	limits, err := s.gridLimits(ctx, v, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.demandEvents(ctx, v, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan *optimizer.Schedule
+	if prefs.Boosting(now) {
+		reason = ReasonBoost
+		plan = boostSchedule(v, prefs, soc, slots, limits, events)
+	} else {
+		switch prefs.ChargingMode {
+		case vehicle.ModeSolar:
+			reason = ReasonSolar
+		case vehicle.ModeGreenest:
+			reason = ReasonGreenest
+		}
+		wearCost, err := s.wearCost(ctx, v, prefs)
+		if err != nil {
+			return nil, err
+		}
+
+		var intensities []float64
+		if carbonWeight(prefs) > 0 {
+			if intensities, err = s.intensities(ctx, v, start, end); err != nil {
+				return nil, err
+			}
+		}
+
+		plan, err = optimizer.Optimize(s.problem(v, prefs, soc, slots, limits, events, wearCost, intensities))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	setpoints, err := json.Marshal(plan.Setpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	sched, err := s.queries.CreateChargingSchedule(ctx, repository.CreateChargingScheduleParams{
+		ID:            uuid.New(),
+		VehicleID:     v.ID,
+		Reason:        reason,
+		HorizonStart:  start,
+		HorizonEnd:    end,
+		InitialSoc:    soc,
+		FinalSoc:      plan.FinalSoc,
+		TargetReached: plan.TargetReached,
+		TotalCost:     plan.TotalCost,
+		Setpoints:     setpoints,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range s.listeners {
+		l(ctx, v, &sched)
+	}
+
+	return &sched, nil
+}
+
+// problem builds the optimisation of the vehicle. Emissions are only weighed
+// when the carbon intensity of every slot is given.
+func (s *Service) problem(v *repository.Vehicle, prefs *vehicle.Preferences, soc float64, slots []PricePoint, limits []GridLimit, events []Event, wearCostPerKwh float64, intensities []float64) optimizer.Problem {
+	intervals := make([]optimizer.Interval, 0, len(slots))
+	for _, p := range slots {
+		intervals = append(intervals, optimizer.Interval{
+			Start:       p.Start,
+			ImportPrice: p.ImportPrice,
+			ExportPrice: p.ExportPrice,
+		})
+	}
+
+	weight := 0.0
+	if len(intensities) == len(intervals) {
+		weight = carbonWeight(prefs)
+		for i := range intervals {
+			intervals[i].CarbonIntensity = intensities[i]
+		}
+	}
+
+	applyLimits(intervals, limits)
+	applyEvents(intervals, events)
+
+	// A discharge cycle is one full battery worth of energy per day.
+	days := math.Max(1, math.Ceil(float64(len(slots))*Resolution.Hours()/24))
+	budget := float64(prefs.MaxDischargeCycles) * v.BatteryCapacityKwh * days
+
+	// In solar mode the grid only has to bring the vehicle to its minimum,
+	// the surplus of the site does the rest and is not discharged again.
+	targetSoc, maxDischargeKw := prefs.TargetSoc, v.MaxDischargeKw
+	if prefs.ChargingMode == vehicle.ModeSolar {
+		targetSoc, maxDischargeKw = prefs.MinSoc, 0
+	}
+
+	return optimizer.Problem{
+		Battery: optimizer.Battery{
+			CapacityKwh:         v.BatteryCapacityKwh,
+			InitialSoc:          soc,
+			MinSoc:              prefs.MinSoc,
+			TargetSoc:           targetSoc,
+			MaxChargeKw:         v.MaxChargeKw,
+			MaxDischargeKw:      maxDischargeKw,
+			ChargeEfficiency:    DefaultEfficiency,
+			DischargeEfficiency: DefaultEfficiency,
+		},
+		Intervals:       intervals,
+		Resolution:      Resolution,
+		MaxDischargeKwh: budget,
+		WearCostPerKwh:  wearCostPerKwh,
+		CarbonWeight:    weight,
+	}
+}
+
+func (s *Service) wearCost(ctx context.Context, v *repository.Vehicle, prefs *vehicle.Preferences) (float64, error) {
+	if s.wear == nil {
+		return 0, nil
+	}
+
+	return s.wear.WearCostPerKwh(ctx, v, prefs)
+}
+
+// boostSchedule charges at full power from the first slot until the target is
+// reached, regardless of price. The grid limits and the caps of demand
+// response events still apply.
+func boostSchedule(v *repository.Vehicle, prefs *vehicle.Preferences, soc float64, slots []PricePoint, limits []GridLimit, events []Event) *optimizer.Schedule {
+	hours := Resolution.Hours()
+	plan := &optimizer.Schedule{Setpoints: make([]optimizer.Setpoint, 0, len(slots))}
+	current := soc
+
+	for _, p := range slots {
+		missingKwh := math.Max(0, prefs.TargetSoc-current) / 100 * v.BatteryCapacityKwh
+		power := math.Min(v.MaxChargeKw, missingKwh/DefaultEfficiency/hours)
+		surplusKw := 0.0
+		if l, ok := LimitAt(limits, p.Start); ok {
+			power = math.Min(power, l.MaxImportKw+l.SurplusKw)
+			surplusKw = math.Min(power, l.SurplusKw)
+		}
+		if capKw, ok := eventCapAt(events, p.Start); ok && power > capKw+surplusKw {
+			power = capKw + surplusKw
+		}
+		next := current + power*hours*DefaultEfficiency/v.BatteryCapacityKwh*100
+		cost := (power-surplusKw)*hours*p.ImportPrice + surplusKw*hours*p.ExportPrice
+
+		plan.Setpoints = append(plan.Setpoints, optimizer.Setpoint{
+			Start:    p.Start,
+			PowerKw:  power,
+			SocStart: current,
+			SocEnd:   next,
+			Cost:     cost,
+		})
+		plan.TotalCost += cost
+		plan.ChargedKwh += power * hours
+		current = next
+	}
+
+	plan.FinalSoc = current
+	plan.TargetReached = current >= prefs.TargetSoc-0.01
+	return plan
+}
+
+// Run periodically re-plans vehicles whose preferences or prices changed
+// since their last schedule, and rolls the horizon of older schedules.
+func (s *Service) Run(ctx context.Context) {
+	if s.prices == nil {
+		slog.WarnContext(ctx, "No price forecaster configured, automatic planning is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(replanCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.replanDue(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to re-plan schedules", "error", err)
+			}
+		}
+	}
+}
+
+func (s *Service) replanDue(ctx context.Context) error {
+	vehicles, err := s.queries.ListVehiclesWithCharger(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for i := range vehicles {
+		v := &vehicles[i]
+		reason, err := s.replanReason(ctx, v, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to check schedule", "vehicle.id", v.ID, "error", err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+
+		if _, err := s.Plan(ctx, v, reason); err != nil {
+			if errors.Is(err, ErrUnknownSoc) || errors.Is(err, ErrNoPriceForecast) || errors.Is(err, ErrNoCarbonForecast) {
+				slog.DebugContext(ctx, "Skipping planning", "vehicle.id", v.ID, "reason", err)
+				continue
+			}
+			slog.ErrorContext(ctx, "Failed to plan schedule", "vehicle.id", v.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) replanReason(ctx context.Context, v *repository.Vehicle, now time.Time) (string, error) {
+	latest, err := s.queries.GetLatestChargingSchedule(ctx, v.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ReasonInitial, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	prefs, err := s.vehicles.LoadPreferences(ctx, v.ID)
+	if err != nil {
+		return "", err
+	}
+
+	pricesUpdatedAt, err := s.prices.UpdatedAt(ctx, v.OwnerID, v.ID)
+	if err != nil {
+		return "", err
+	}
+
+	var eventsUpdatedAt time.Time
+	if s.events != nil {
+		if eventsUpdatedAt, err = s.events.UpdatedAt(ctx, v.ID); err != nil {
+			return "", err
+		}
+	}
+
+	var carbonUpdatedAt time.Time
+	if s.carbon != nil && carbonWeight(prefs) > 0 {
+		if carbonUpdatedAt, err = s.carbon.UpdatedAt(ctx, v.ID); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case prefs.UpdatedAt.After(latest.CreatedAt):
+		return ReasonPreferencesChanged, nil
+	case pricesUpdatedAt.After(latest.CreatedAt):
+		return ReasonPricesChanged, nil
+	case eventsUpdatedAt.After(latest.CreatedAt):
+		return ReasonEventsChanged, nil
+	case carbonUpdatedAt.After(latest.CreatedAt):
+		return ReasonCarbonChanged, nil
+	case now.Sub(latest.CreatedAt) >= replanInterval:
+		return ReasonPeriodic, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,38 @@
+package scheduling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var ErrUnknownSoc = errors.New("vehicle state of charge is unknown")
+
+// SocField is the key of the state of charge in percent that chargers report
+// in their device shadow.
+const SocField = "soc"
+
+func (s *Service) currentSoc(ctx context.Context, v *repository.Vehicle) (float64, error) {
+	if !v.ChargerID.Valid {
+		return 0, ErrUnknownSoc
+	}
+
+	sh, err := s.queries.GetDeviceShadow(ctx, uuid.UUID(v.ChargerID.Bytes))
+	if err != nil {
+		return 0, ErrUnknownSoc
+	}
+
+	var reported map[string]any
+	if err := json.Unmarshal(sh.Reported, &reported); err != nil {
+		return 0, err
+	}
+
+	soc, ok := reported[SocField].(float64)
+	if !ok || soc < 0 || soc > 100 {
+		return 0, ErrUnknownSoc
+	}
+
+	return soc, nil
+}
@@ -0,0 +1,13 @@
+package scheduling
+
+import (
+	"context"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+)
+
+// WearEstimator prices the battery wear of discharging a vehicle, so the
+// optimizer only discharges when the price spread covers it.
+type WearEstimator interface {
+	WearCostPerKwh(ctx context.Context, v *repository.Vehicle, prefs *vehicle.Preferences) (float64, error)
+}
@@ -0,0 +1,156 @@
+package session
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultListRange = 30 * 24 * time.Hour
+	maxListRange     = 366 * 24 * time.Hour
+)
+
+type ListSessionsRequest struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseListSessionsRequest reads the from and to query parameters, which
+// bound the start of the sessions. They default to the last 30 days.
+func ParseListSessionsRequest(q url.Values, now time.Time) ListSessionsRequest {
+	req := ListSessionsRequest{
+		To:        now.UTC(),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	req.From = req.To.Add(-defaultListRange)
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	return req
+}
+
+func (r *ListSessionsRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxListRange {
+			errs.Add("to", "The requested range cannot exceed 366 days")
+		}
+	}
+
+	return errs
+}
+
+type SessionResponse struct {
+	ID            uuid.UUID   `json:"id"`
+	DeviceID      uuid.UUID   `json:"deviceId"`
+	ConnectorID   int32       `json:"connectorId"`
+	TransactionID string      `json:"transactionId"`
+	VehicleID     *uuid.UUID  `json:"vehicleId,omitempty"`
+	Status        string      `json:"status"`
+	StopReason    string      `json:"stopReason,omitempty"`
+	StartedAt     time.Time   `json:"startedAt"`
+	StoppedAt     *time.Time  `json:"stoppedAt,omitempty"`
+	ImportedKwh   float64     `json:"importedKwh"`
+	ExportedKwh   float64     `json:"exportedKwh"`
+	EnergyCost    float64     `json:"energyCost"`
+	Revenue       float64     `json:"revenue"`
+	NetCost       float64     `json:"netCost"`
+	Currency      string      `json:"currency"`
+	ComputedAt    *time.Time  `json:"computedAt,omitempty"`
+	Breakdown     *[]Interval `json:"breakdown,omitempty"`
+	// Emissions is left out until the carbon intensities of the session
+	// are known.
+	Emissions *EmissionsResponse `json:"emissions,omitempty"`
+}
+
+// EmissionsResponse is the carbon footprint of a session in kg CO2eq, and
+// what it avoided compared to charging at full power after plugging in.
+type EmissionsResponse struct {
+	EmittedKg  float64 `json:"emittedKg"`
+	BaselineKg float64 `json:"baselineKg"`
+	AvoidedKg  float64 `json:"avoidedKg"`
+}
+
+// NewSessionResponse describes the session, the breakdown per interval is
+// only included when withBreakdown is set.
+func NewSessionResponse(cs *repository.ChargingSession, withBreakdown bool) (*SessionResponse, error) {
+	res := &SessionResponse{
+		ID:            cs.ID,
+		DeviceID:      cs.DeviceID,
+		ConnectorID:   cs.ConnectorID,
+		TransactionID: cs.TransactionID,
+		Status:        cs.Status,
+		StopReason:    cs.StopReason,
+		StartedAt:     cs.StartedAt,
+		ImportedKwh:   cs.ImportedKwh,
+		ExportedKwh:   cs.ExportedKwh,
+		EnergyCost:    cs.EnergyCost,
+		Revenue:       cs.Revenue,
+		NetCost:       round(cs.EnergyCost - cs.Revenue),
+		Currency:      "EUR",
+	}
+
+	if cs.VehicleID.Valid {
+		id := uuid.UUID(cs.VehicleID.Bytes)
+		res.VehicleID = &id
+	}
+	if cs.StoppedAt.Valid {
+		res.StoppedAt = &cs.StoppedAt.Time
+	}
+	if cs.ComputedAt.Valid {
+		res.ComputedAt = &cs.ComputedAt.Time
+	}
+	if e, ok := StoredEmissions(cs); ok {
+		res.Emissions = &EmissionsResponse{
+			EmittedKg:  e.EmittedKg,
+			BaselineKg: e.BaselineKg,
+			AvoidedKg:  e.AvoidedKg(),
+		}
+	}
+
+	if withBreakdown {
+		breakdown, err := Breakdown(cs)
+		if err != nil {
+			return nil, err
+		}
+		res.Breakdown = &breakdown
+	}
+
+	return res, nil
+}
+
+// Breakdown decodes the stored intervals of a session.
+func Breakdown(cs *repository.ChargingSession) ([]Interval, error) {
+	intervals := []Interval{}
+	if err := json.Unmarshal(cs.Breakdown, &intervals); err != nil {
+		return nil, err
+	}
+
+	return intervals, nil
+}
@@ -0,0 +1,185 @@
+package session
+
+import (
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/tariff"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"math"
+	"time"
+)
+
+var ErrMissingPrices = errors.New("prices are not known for the whole session")
+
+// Interval is the energy charged and discharged during one tariff slot, with
+// the prices in EUR/kWh and the resulting amounts in EUR.
+type Interval struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	ImportKwh   float64   `json:"importKwh"`
+	ExportKwh   float64   `json:"exportKwh"`
+	ImportPrice float64   `json:"importPrice"`
+	ExportPrice float64   `json:"exportPrice"`
+	Cost        float64   `json:"cost"`
+	Revenue     float64   `json:"revenue"`
+}
+
+// Cost is the priced energy of a session. The totals are the sums of the
+// rounded intervals, so they add up exactly on statements.
+type Cost struct {
+	ImportedKwh float64
+	ExportedKwh float64
+	EnergyCost  float64
+	Revenue     float64
+	Intervals   []Interval
+}
+
+// Compute prices the energy between consecutive readings, which must be
+// ordered by time. The energy of a pair of readings is assumed to have been
+// drawn evenly over the time between them and is split over the slots it
+// overlaps. The result only depends on the readings and slots, so recomputing
+// after a late reading always yields the same breakdown.
+func Compute(readings []ocpp.Reading, slots []tariff.Slot) (*Cost, error) {
+	if len(readings) < 2 {
+		return &Cost{Intervals: []Interval{}}, nil
+	}
+
+	first, last := readings[0].Timestamp, readings[len(readings)-1].Timestamp
+	if len(slots) == 0 || slots[0].Start.After(first) || slots[len(slots)-1].End.Before(last) {
+		return nil, ErrMissingPrices
+	}
+
+	importWh := make([]float64, len(slots))
+	exportWh := make([]float64, len(slots))
+
+	prevExport := readings[0].ExportWh
+	for i := 1; i < len(readings); i++ {
+		prev, cur := readings[i-1], readings[i]
+
+		curExport := cur.ExportWh
+		if curExport == nil {
+			curExport = prevExport
+		}
+
+		var exported float64
+		if prevExport != nil && curExport != nil {
+			exported = *curExport - *prevExport
+		}
+		if curExport != nil {
+			prevExport = curExport
+		}
+
+		// Registers only go backwards when the meter was reset or replaced,
+		// the energy of such an interval cannot be known.
+		imported := cur.ImportWh - prev.ImportWh
+		if imported < 0 {
+			imported = 0
+		}
+		if exported < 0 {
+			exported = 0
+		}
+
+		if err := spread(slots, prev.Timestamp, cur.Timestamp, imported, importWh); err != nil {
+			return nil, err
+		}
+		if err := spread(slots, prev.Timestamp, cur.Timestamp, exported, exportWh); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Cost{Intervals: []Interval{}}
+	for i, s := range slots {
+		in, out := round(importWh[i]/1000), round(exportWh[i]/1000)
+		if in == 0 && out == 0 {
+			continue
+		}
+
+		importPrice, exportPrice := roundPrice(s.ImportPrice), roundPrice(s.ExportPrice)
+
+		iv := Interval{
+			Start:       s.Start,
+			End:         s.End,
+			ImportKwh:   in,
+			ExportKwh:   out,
+			ImportPrice: importPrice,
+			ExportPrice: exportPrice,
+			Cost:        round(in * importPrice),
+			Revenue:     round(out * exportPrice),
+		}
+		c.Intervals = append(c.Intervals, iv)
+
+		c.ImportedKwh += iv.ImportKwh
+		c.ExportedKwh += iv.ExportKwh
+		c.EnergyCost += iv.Cost
+		c.Revenue += iv.Revenue
+	}
+
+	c.ImportedKwh = round(c.ImportedKwh)
+	c.ExportedKwh = round(c.ExportedKwh)
+	c.EnergyCost = round(c.EnergyCost)
+	c.Revenue = round(c.Revenue)
+	return c, nil
+}
+
+// spread distributes wh over the slots overlapping [from, to) in proportion
+// to the overlap. Energy measured at a single instant goes to its slot.
+func spread(slots []tariff.Slot, from, to time.Time, wh float64, into []float64) error {
+	if wh == 0 {
+		return nil
+	}
+
+	if !to.After(from) {
+		for i, s := range slots {
+			if !s.Start.After(from) && s.End.After(from) {
+				into[i] += wh
+				return nil
+			}
+		}
+
+		return ErrMissingPrices
+	}
+
+	total := to.Sub(from).Seconds()
+	covered := 0.0
+	for i, s := range slots {
+		start, end := maxTime(s.Start, from), minTime(s.End, to)
+		if !end.After(start) {
+			continue
+		}
+
+		share := end.Sub(start).Seconds()
+		into[i] += wh * share / total
+		covered += share
+	}
+
+	// Slots are contiguous, a gap means a price is missing.
+	if math.Abs(covered-total) > 1e-6 {
+		return ErrMissingPrices
+	}
+
+	return nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
+
+// roundPrice rounds a price to the precision shown on the tariff curves.
+func roundPrice(v float64) float64 {
+	return math.Round(v*1e5) / 1e5
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,84 @@
+package session
+
+import (
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/carbon"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"math"
+	"time"
+)
+
+var ErrMissingIntensity = errors.New("carbon intensities are not known for the whole session")
+
+// Emissions is the carbon footprint of a session in kg CO2eq. Energy fed back
+// displaces grid energy and counts as negative emissions. BaselineKg is what
+// charging the energy the vehicle kept would have emitted when charged at
+// full power straight after plugging in, as chargers do without a schedule.
+type Emissions struct {
+	EmittedKg  float64
+	BaselineKg float64
+}
+
+// AvoidedKg is the CO2 the session saved compared to its baseline.
+func (e *Emissions) AvoidedKg() float64 {
+	return round(e.BaselineKg - e.EmittedKg)
+}
+
+// StoredEmissions returns the footprint stored with a session, it is false
+// until the intensities of the session were known.
+func StoredEmissions(cs *repository.ChargingSession) (*Emissions, bool) {
+	if !cs.EmissionsKg.Valid || !cs.BaselineEmissionsKg.Valid {
+		return nil, false
+	}
+
+	return &Emissions{EmittedKg: cs.EmissionsKg.Float64, BaselineKg: cs.BaselineEmissionsKg.Float64}, true
+}
+
+// ComputeEmissions weighs the energy of the priced intervals of a session
+// with the carbon intensity of the grid at the time. The baseline charges
+// from the slot the session started in at the highest power the session drew
+// in any interval.
+func ComputeEmissions(startedAt time.Time, intervals []Interval, intensities []carbon.Point) (*Emissions, error) {
+	e := &Emissions{}
+	if len(intervals) == 0 {
+		return e, nil
+	}
+
+	var importedKwh, exportedKwh, maxKw float64
+	for _, iv := range intervals {
+		grams, ok := carbon.Average(intensities, iv.Start, iv.End)
+		if !ok {
+			return nil, ErrMissingIntensity
+		}
+
+		e.EmittedKg += (iv.ImportKwh - iv.ExportKwh) * grams / 1000
+		importedKwh += iv.ImportKwh
+		exportedKwh += iv.ExportKwh
+		maxKw = math.Max(maxKw, iv.ImportKwh/iv.End.Sub(iv.Start).Hours())
+	}
+
+	// Intervals are slots of the same length, the baseline fills them from
+	// the one the session started in. It never needs more slots than the
+	// session used.
+	slot := intervals[0].End.Sub(intervals[0].Start)
+	first := intervals[0].Start
+	for first.After(startedAt) {
+		first = first.Add(-slot)
+	}
+
+	remaining := math.Max(0, importedKwh-exportedKwh)
+	for start := first; remaining > 1e-9 && maxKw > 0; start = start.Add(slot) {
+		grams, ok := carbon.Average(intensities, start, start.Add(slot))
+		if !ok {
+			return nil, ErrMissingIntensity
+		}
+
+		drawn := math.Min(remaining, maxKw*slot.Hours())
+		e.BaselineKg += drawn * grams / 1000
+		remaining -= drawn
+	}
+
+	e.EmittedKg = round(e.EmittedKg)
+	e.BaselineKg = round(e.BaselineKg)
+	return e, nil
+}
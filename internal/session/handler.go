@@ -0,0 +1,67 @@
+package session
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := h.svc.List(ctx, identityID, ParseListSessionsRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	res := make([]*SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		s, err := NewSessionResponse(&sessions[i], false)
+		if err != nil {
+			return err
+		}
+		res = append(res, s)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	cs, err := h.svc.GetOwned(ctx, identityID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewSessionResponse(cs, true)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
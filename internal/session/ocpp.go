@@ -0,0 +1,226 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/chargepoint"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"strconv"
+)
+
+// RegisterHandlers makes the service handle the transaction related calls of
+// the chargers connected to srv.
+func (s *Service) RegisterHandlers(srv *chargepoint.Server) {
+	s.handle(srv, ocpp.ActionAuthorize, s.handleAuthorize)
+	s.handle(srv, ocpp.ActionStartTransaction, s.handleStartTransaction)
+	s.handle(srv, ocpp.ActionStopTransaction, s.handleStopTransaction)
+	s.handle(srv, ocpp.ActionTransactionEvent, s.handleTransactionEvent)
+	s.handle(srv, ocpp.ActionMeterValues, s.handleMeterValues)
+}
+
+// handle registers a handler that receives the device of the charger.
+func (s *Service) handle(srv *chargepoint.Server, action string, h func(ctx context.Context, c *chargepoint.Connection, d *repository.Device, payload json.RawMessage) (any, error)) {
+	srv.Handle(action, func(ctx context.Context, c *chargepoint.Connection, payload json.RawMessage) (any, error) {
+		d, err := s.queries.GetDeviceById(ctx, c.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+
+		return h(ctx, c, &d, payload)
+	})
+}
+
+// handleAuthorize accepts every id tag, chargers are only reachable once they
+// have been registered by their owner.
+func (s *Service) handleAuthorize(_ context.Context, c *chargepoint.Connection, _ *repository.Device, _ json.RawMessage) (any, error) {
+	info := ocpp.IdTagInfo{Status: ocpp.AuthorizationAccepted}
+	if c.Version == ocpp.V201 {
+		return ocpp.AuthorizeResponse201{IdTokenInfo: info}, nil
+	}
+
+	return ocpp.AuthorizeResponse16{IdTagInfo: info}, nil
+}
+
+func (s *Service) handleStartTransaction(ctx context.Context, c *chargepoint.Connection, d *repository.Device, payload json.RawMessage) (any, error) {
+	var req ocpp.StartTransactionRequest16
+	if err := chargepoint.Decode(payload, &req); err != nil {
+		return nil, err
+	}
+
+	id, err := s.queries.NextTransactionId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := s.start(ctx, d, req.ConnectorID, strconv.Itoa(int(id)), req.IdTag, req.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.record(ctx, cs, []ocpp.Reading{{Timestamp: req.Timestamp, ImportWh: float64(req.MeterStart)}}); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Charging session started", "session.id", cs.ID, "identity", c.Identity, "connector", req.ConnectorID)
+	return ocpp.StartTransactionResponse16{
+		IdTagInfo:     ocpp.IdTagInfo{Status: ocpp.AuthorizationAccepted},
+		TransactionID: int(id),
+	}, nil
+}
+
+func (s *Service) handleStopTransaction(ctx context.Context, c *chargepoint.Connection, d *repository.Device, payload json.RawMessage) (any, error) {
+	var req ocpp.StopTransactionRequest16
+	if err := chargepoint.Decode(payload, &req); err != nil {
+		return nil, err
+	}
+
+	cs, err := s.byTransaction(ctx, d, strconv.Itoa(req.TransactionID))
+	if err != nil {
+		return nil, err
+	}
+	if cs == nil {
+		// The charger must not retry a stop we cannot match, so it is
+		// acknowledged anyway.
+		slog.WarnContext(ctx, "Stop of unknown transaction", "identity", c.Identity, "transaction", req.TransactionID)
+		return ocpp.StopTransactionResponse16{}, nil
+	}
+
+	if err := s.stop(ctx, cs, req.Timestamp, req.Reason); err != nil {
+		return nil, err
+	}
+
+	readings := append(ocpp.Readings16(req.TransactionData), ocpp.Reading{Timestamp: req.Timestamp, ImportWh: float64(req.MeterStop)})
+	if err := s.record(ctx, cs, readings); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Charging session stopped", "session.id", cs.ID, "identity", c.Identity)
+	return ocpp.StopTransactionResponse16{IdTagInfo: &ocpp.IdTagInfo{Status: ocpp.AuthorizationAccepted}}, nil
+}
+
+// handleTransactionEvent follows a 2.0.1 transaction. Events queued while the
+// charger was offline can arrive in any order, so every event opens the
+// session when it is not known yet.
+func (s *Service) handleTransactionEvent(ctx context.Context, c *chargepoint.Connection, d *repository.Device, payload json.RawMessage) (any, error) {
+	var req ocpp.TransactionEventRequest201
+	if err := chargepoint.Decode(payload, &req); err != nil {
+		return nil, err
+	}
+
+	evseID := 0
+	if req.EVSE != nil {
+		evseID = req.EVSE.ID
+	}
+	idTag := ""
+	if req.IdToken != nil {
+		idTag = req.IdToken.IdToken
+	}
+
+	cs, err := s.byTransaction(ctx, d, req.TransactionInfo.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if cs == nil || req.EventType == ocpp.TransactionEventStarted {
+		if cs, err = s.start(ctx, d, evseID, req.TransactionInfo.TransactionID, idTag, req.Timestamp); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.EventType == ocpp.TransactionEventEnded {
+		if err := s.stop(ctx, cs, req.Timestamp, req.TransactionInfo.StoppedReason); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.record(ctx, cs, ocpp.Readings201(req.MeterValue)); err != nil {
+		return nil, err
+	}
+
+	// A stop, or a start arriving after the stop, changes the billed period
+	// even when the readings were all stored before.
+	if cs.Status == StatusCompleted && req.EventType != ocpp.TransactionEventUpdated && len(req.MeterValue) == 0 {
+		s.recompute(ctx, cs)
+	}
+
+	res := ocpp.TransactionEventResponse201{}
+	if req.IdToken != nil {
+		res.IdTokenInfo = &ocpp.IdTagInfo{Status: ocpp.AuthorizationAccepted}
+	}
+
+	return res, nil
+}
+
+// handleMeterValues stores the periodic readings of a transaction, readings
+// outside of a transaction are not billed and ignored.
+func (s *Service) handleMeterValues(ctx context.Context, c *chargepoint.Connection, d *repository.Device, payload json.RawMessage) (any, error) {
+	var cs *repository.ChargingSession
+	var readings []ocpp.Reading
+	var err error
+
+	if c.Version == ocpp.V201 {
+		var req ocpp.MeterValuesRequest201
+		if err := chargepoint.Decode(payload, &req); err != nil {
+			return nil, err
+		}
+		readings = ocpp.Readings201(req.MeterValue)
+		cs, err = s.activeOnConnector(ctx, d, req.EvseID)
+	} else {
+		var req ocpp.MeterValuesRequest16
+		if err := chargepoint.Decode(payload, &req); err != nil {
+			return nil, err
+		}
+		readings = ocpp.Readings16(req.MeterValue)
+		if req.TransactionID != nil {
+			cs, err = s.byTransaction(ctx, d, strconv.Itoa(*req.TransactionID))
+		} else {
+			cs, err = s.activeOnConnector(ctx, d, req.ConnectorID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cs != nil {
+		if err := s.record(ctx, cs, readings); err != nil {
+			return nil, err
+		}
+	}
+
+	return struct{}{}, nil
+}
+
+func (s *Service) byTransaction(ctx context.Context, d *repository.Device, transactionID string) (*repository.ChargingSession, error) {
+	cs, err := s.queries.GetChargingSessionByTransactionId(ctx, repository.GetChargingSessionByTransactionIdParams{
+		DeviceID:      d.ID,
+		TransactionID: transactionID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &cs, nil
+}
+
+func (s *Service) activeOnConnector(ctx context.Context, d *repository.Device, connectorID int) (*repository.ChargingSession, error) {
+	cs, err := s.queries.GetActiveChargingSessionByConnector(ctx, repository.GetActiveChargingSessionByConnectorParams{
+		DeviceID:    d.ID,
+		ConnectorID: int32(connectorID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &cs, nil
+}
@@ -0,0 +1,315 @@
+// Package session records the charging sessions reported by OCPP chargers,
+// prices the energy charged and discharged with the tariff of the owner and
+// weighs it with the carbon intensity of the grid.
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/carbon"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/tariff"
+	"github.com/V2G-Minor-Fontys/server/pkg/ocpp"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"time"
+)
+
+const (
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+
+	recomputeInterval  = time.Hour
+	recomputeBatchSize = 100
+	// pricingWindow bounds how long after a session stopped its cost is
+	// retried. Prices imported from files may arrive late, but sessions in
+	// zones without prices would otherwise block the batch forever.
+	pricingWindow = 35 * 24 * time.Hour
+	// emissionsWindow bounds how long after a session stopped its emissions
+	// are retried, zones without intensities would otherwise be retried
+	// forever.
+	emissionsWindow = 7 * 24 * time.Hour
+)
+
+type Service struct {
+	queries *repository.Queries
+	tariffs *tariff.Service
+	carbon  *carbon.Service
+}
+
+func NewService(queries *repository.Queries, tariffs *tariff.Service, carbon *carbon.Service) *Service {
+	return &Service{queries: queries, tariffs: tariffs, carbon: carbon}
+}
+
+func (s *Service) List(ctx context.Context, identityID uuid.UUID, req ListSessionsRequest) ([]repository.ChargingSession, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	sessions, err := s.queries.ListChargingSessionsByOwnerId(ctx, repository.ListChargingSessionsByOwnerIdParams{
+		OwnerID:       identityID,
+		StartedFrom:   req.From,
+		StartedBefore: req.To,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve charging sessions", err)
+	}
+
+	return sessions, nil
+}
+
+func (s *Service) GetOwned(ctx context.Context, identityID, sessionID uuid.UUID) (*repository.ChargingSession, error) {
+	cs, err := s.queries.GetChargingSessionById(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Charging session could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve charging session", err)
+	}
+
+	if cs.OwnerID != identityID {
+		return nil, httpx.NotFound(ctx, "Charging session could not be found")
+	}
+
+	return &cs, nil
+}
+
+// start opens the session of a transaction, or returns the existing one when
+// the charger reports the same transaction again.
+func (s *Service) start(ctx context.Context, d *repository.Device, connectorID int, transactionID, idTag string, at time.Time) (*repository.ChargingSession, error) {
+	var vehicleID pgtype.UUID
+	v, err := s.queries.GetVehicleByChargerId(ctx, pgtype.UUID{Bytes: d.ID, Valid: true})
+	switch {
+	case err == nil:
+		vehicleID = pgtype.UUID{Bytes: v.ID, Valid: true}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	cs, err := s.queries.CreateChargingSession(ctx, repository.CreateChargingSessionParams{
+		ID:            uuid.New(),
+		DeviceID:      d.ID,
+		ConnectorID:   int32(connectorID),
+		TransactionID: transactionID,
+		OwnerID:       d.OwnerID,
+		VehicleID:     vehicleID,
+		IDTag:         idTag,
+		StartedAt:     at.UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cs, nil
+}
+
+func (s *Service) stop(ctx context.Context, cs *repository.ChargingSession, at time.Time, reason string) error {
+	stopped, err := s.queries.StopChargingSession(ctx, repository.StopChargingSessionParams{
+		ID:         cs.ID,
+		StoppedAt:  pgtype.Timestamptz{Time: at.UTC(), Valid: true},
+		StopReason: reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	*cs = stopped
+	return nil
+}
+
+// record stores readings of the session, readings already known for the same
+// moment are kept as they were. Completed sessions are recomputed, since the
+// readings arrived after the session was priced.
+func (s *Service) record(ctx context.Context, cs *repository.ChargingSession, readings []ocpp.Reading) error {
+	for _, r := range readings {
+		var exportWh pgtype.Float8
+		if r.ExportWh != nil {
+			exportWh = pgtype.Float8{Float64: *r.ExportWh, Valid: true}
+		}
+
+		if err := s.queries.CreateMeterValue(ctx, repository.CreateMeterValueParams{
+			SessionID:  cs.ID,
+			MeasuredAt: r.Timestamp.UTC(),
+			ImportWh:   r.ImportWh,
+			ExportWh:   exportWh,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(readings) > 0 && cs.Status == StatusCompleted {
+		s.recompute(ctx, cs)
+	}
+
+	return nil
+}
+
+// recompute prices a completed session, logging instead of failing since
+// sessions without prices are retried by Run.
+func (s *Service) recompute(ctx context.Context, cs *repository.ChargingSession) {
+	if err := s.compute(ctx, cs); err != nil {
+		if errors.Is(err, ErrMissingPrices) {
+			slog.InfoContext(ctx, "Postponing session cost until prices are known", "session.id", cs.ID)
+			return
+		}
+
+		slog.ErrorContext(ctx, "Failed to compute session cost", "session.id", cs.ID, "error", err)
+	}
+}
+
+func (s *Service) compute(ctx context.Context, cs *repository.ChargingSession) error {
+	values, err := s.queries.ListMeterValuesBySessionId(ctx, cs.ID)
+	if err != nil {
+		return err
+	}
+
+	readings := timeline(cs, values)
+	var slots []tariff.Slot
+	siteTariff := false
+	if len(readings) >= 2 {
+		from := readings[0].Timestamp
+		to := readings[len(readings)-1].Timestamp.Add(time.Nanosecond)
+		// The session is priced with the tariff in effect when it started, so
+		// a later change of tariff does not alter it.
+		if slots, siteTariff, err = s.tariffs.CurveAt(ctx, cs.OwnerID, cs.DeviceID, cs.StartedAt, from, to); err != nil {
+			return err
+		}
+	}
+
+	c, err := Compute(readings, slots)
+	if err != nil {
+		return err
+	}
+
+	breakdown, err := json.Marshal(c.Intervals)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.UpdateChargingSessionCost(ctx, repository.UpdateChargingSessionCostParams{
+		ID:          cs.ID,
+		ImportedKwh: c.ImportedKwh,
+		ExportedKwh: c.ExportedKwh,
+		EnergyCost:  c.EnergyCost,
+		Revenue:     c.Revenue,
+		Breakdown:   breakdown,
+		SiteTariff:  siteTariff,
+	}); err != nil {
+		return err
+	}
+
+	return s.computeEmissions(ctx, cs, c.Intervals)
+}
+
+// computeEmissions stores the carbon footprint of the priced intervals of a
+// session. Sessions are left without one until the intensities of their
+// intervals are known, Run retries them.
+func (s *Service) computeEmissions(ctx context.Context, cs *repository.ChargingSession, intervals []Interval) error {
+	var intensities []carbon.Point
+	if len(intervals) > 0 {
+		zone, err := s.carbon.ZoneOfDevice(ctx, cs.DeviceID)
+		if err != nil {
+			return err
+		}
+
+		from, to := minTime(cs.StartedAt, intervals[0].Start), intervals[len(intervals)-1].End
+		// Start a day early so the interval running at from is included.
+		if intensities, err = s.carbon.Range(ctx, zone, from.Add(-24*time.Hour), to); err != nil {
+			return err
+		}
+	}
+
+	// A footprint of earlier readings no longer applies, it is cleared until
+	// the intensities are known.
+	params := repository.UpdateChargingSessionEmissionsParams{ID: cs.ID}
+	e, err := ComputeEmissions(cs.StartedAt, intervals, intensities)
+	switch {
+	case errors.Is(err, ErrMissingIntensity):
+		slog.InfoContext(ctx, "Postponing session emissions until carbon intensities are known", "session.id", cs.ID)
+	case err != nil:
+		return err
+	default:
+		params.EmissionsKg = pgtype.Float8{Float64: e.EmittedKg, Valid: true}
+		params.BaselineEmissionsKg = pgtype.Float8{Float64: e.BaselineKg, Valid: true}
+	}
+
+	return s.queries.UpdateChargingSessionEmissions(ctx, params)
+}
+
+// timeline returns the readings that fall within the session. Chargers may
+// report values sampled shortly outside of it, those are not billed.
+func timeline(cs *repository.ChargingSession, values []repository.MeterValue) []ocpp.Reading {
+	readings := make([]ocpp.Reading, 0, len(values))
+	for _, v := range values {
+		if v.MeasuredAt.Before(cs.StartedAt) || (cs.StoppedAt.Valid && v.MeasuredAt.After(cs.StoppedAt.Time)) {
+			continue
+		}
+
+		r := ocpp.Reading{Timestamp: v.MeasuredAt, ImportWh: v.ImportWh}
+		if v.ExportWh.Valid {
+			exportWh := v.ExportWh.Float64
+			r.ExportWh = &exportWh
+		}
+		readings = append(readings, r)
+	}
+
+	return readings
+}
+
+// Run prices the completed sessions that could not be priced before, because
+// the market prices of their intervals were not yet published, and weighs
+// the emissions of recent sessions whose carbon intensities were missing.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(recomputeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.computePending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) computePending(ctx context.Context) {
+	sessions, err := s.queries.ListUncomputedChargingSessions(ctx, repository.ListUncomputedChargingSessionsParams{
+		StoppedFrom: time.Now().Add(-pricingWindow),
+		RowLimit:    recomputeBatchSize,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list unpriced charging sessions", "error", err)
+		return
+	}
+
+	for i := range sessions {
+		s.recompute(ctx, &sessions[i])
+	}
+
+	sessions, err = s.queries.ListChargingSessionsWithoutEmissions(ctx, repository.ListChargingSessionsWithoutEmissionsParams{
+		StoppedFrom: time.Now().Add(-emissionsWindow),
+		RowLimit:    recomputeBatchSize,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list charging sessions without emissions", "error", err)
+		return
+	}
+
+	for i := range sessions {
+		cs := &sessions[i]
+		intervals, err := Breakdown(cs)
+		if err == nil {
+			err = s.computeEmissions(ctx, cs, intervals)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to compute session emissions", "session.id", cs.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package shadow
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"time"
+)
+
+type PatchDesiredRequest struct {
+	Version int64    `json:"version"`
+	Desired Document `json:"desired"`
+}
+
+type ShadowResponse struct {
+	DeviceID          uuid.UUID  `json:"deviceId"`
+	Reported          Document   `json:"reported"`
+	Desired           Document   `json:"desired"`
+	Delta             Document   `json:"delta"`
+	Version           int64      `json:"version"`
+	ReportedUpdatedAt *time.Time `json:"reportedUpdatedAt,omitempty"`
+	DesiredUpdatedAt  *time.Time `json:"desiredUpdatedAt,omitempty"`
+}
+
+func NewShadowResponse(s *repository.DeviceShadow) (*ShadowResponse, error) {
+	reported, err := decodeDocument(s.Reported)
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := decodeDocument(s.Desired)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ShadowResponse{
+		DeviceID: s.DeviceID,
+		Reported: reported,
+		Desired:  desired,
+		Delta:    Delta(desired, reported),
+		Version:  s.Version,
+	}
+
+	if s.ReportedUpdatedAt.Valid {
+		res.ReportedUpdatedAt = &s.ReportedUpdatedAt.Time
+	}
+
+	if s.DesiredUpdatedAt.Valid {
+		res.DesiredUpdatedAt = &s.DesiredUpdatedAt.Time
+	}
+
+	return res, nil
+}
+
+// DeltaMessage is published to a device whenever its desired state changes
+// or when it asks for its shadow after reconnecting.
+type DeltaMessage struct {
+	Version int64    `json:"version"`
+	State   Document `json:"state"`
+}
@@ -0,0 +1,72 @@
+package shadow
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Document is a decoded reported or desired section of a shadow.
+type Document map[string]any
+
+func decodeDocument(raw []byte) (Document, error) {
+	doc := Document{}
+	if len(raw) == 0 {
+		return doc, nil
+	}
+
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Merge applies patch on top of doc following JSON merge patch semantics
+// (RFC 7386): nested objects are merged recursively and null removes a key.
+func Merge(doc, patch Document) Document {
+	out := Document{}
+	for k, v := range doc {
+		out[k] = v
+	}
+
+	for k, v := range patch {
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+
+		pv, ok := v.(map[string]any)
+		if !ok {
+			out[k] = v
+			continue
+		}
+
+		tv, _ := out[k].(map[string]any)
+		out[k] = map[string]any(Merge(tv, pv))
+	}
+
+	return out
+}
+
+// Delta returns the parts of desired that the device has not reported yet.
+func Delta(desired, reported Document) Document {
+	out := Document{}
+	for k, dv := range desired {
+		rv, ok := reported[k]
+		dm, dIsMap := dv.(map[string]any)
+		rm, rIsMap := rv.(map[string]any)
+
+		if dIsMap && rIsMap {
+			if sub := Delta(dm, rm); len(sub) > 0 {
+				out[k] = map[string]any(sub)
+			}
+			continue
+		}
+
+		if !ok || !reflect.DeepEqual(dv, rv) {
+			out[k] = dv
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,73 @@
+package shadow
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	sh, err := h.svc.Get(ctx, identityID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewShadowResponse(sh)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Stored shadow is corrupt", err)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) PatchDesiredHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req PatchDesiredRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	sh, err := h.svc.PatchDesired(ctx, identityID, deviceID, req)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewShadowResponse(sh)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Stored shadow is corrupt", err)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
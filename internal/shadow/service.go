@@ -0,0 +1,248 @@
+package shadow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/cache"
+	"github.com/V2G-Minor-Fontys/server/pkg/mqtt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+)
+
+const maxReportRetries = 5
+
+var errVersionConflict = errors.New("shadow version conflict")
+
+type Service struct {
+	queries *repository.Queries
+	devices *device.Service
+	broker  *mqtt.Client
+	cache   *cache.Loader
+}
+
+func NewService(queries *repository.Queries, devices *device.Service, broker *mqtt.Client, c *cache.Loader) *Service {
+	return &Service{queries: queries, devices: devices, broker: broker, cache: c}
+}
+
+func CacheKey(deviceID uuid.UUID) string {
+	return "shadows:" + deviceID.String()
+}
+
+func (s *Service) Get(ctx context.Context, identityID, deviceID uuid.UUID) (*repository.DeviceShadow, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	sh, err := cache.GetOrLoad(ctx, s.cache, CacheKey(d.ID), func(ctx context.Context) (*repository.DeviceShadow, error) {
+		return s.load(ctx, d.ID)
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve device shadow", err)
+	}
+
+	return sh, nil
+}
+
+func (s *Service) PatchDesired(ctx context.Context, identityID, deviceID uuid.UUID, req PatchDesiredRequest) (*repository.DeviceShadow, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Desired == nil {
+		return nil, httpx.BadRequest(ctx, "Desired state must be a JSON object")
+	}
+
+	sh, err := s.load(ctx, d.ID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve device shadow", err)
+	}
+
+	if req.Version != sh.Version {
+		return nil, httpx.Conflict(ctx, fmt.Sprintf("Shadow version %d is outdated, current version is %d", req.Version, sh.Version))
+	}
+
+	desired, err := decodeDocument(sh.Desired)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Stored desired state is corrupt", err)
+	}
+
+	raw, err := json.Marshal(Merge(desired, req.Desired))
+	if err != nil {
+		return nil, httpx.BadRequest(ctx, "Desired state could not be encoded")
+	}
+
+	updated, err := s.queries.UpdateDeviceShadowDesired(ctx, repository.UpdateDeviceShadowDesiredParams{
+		DeviceID: d.ID,
+		Desired:  raw,
+		Version:  req.Version,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.Conflict(ctx, "Shadow was modified concurrently, fetch the latest version and retry")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to update desired state", err)
+	}
+	s.cache.Invalidate(ctx, CacheKey(d.ID))
+
+	// Offline devices pick up the delta when they request their shadow on reconnect.
+	if err := s.publishDelta(ctx, d.SerialNumber, &updated); err != nil {
+		slog.WarnContext(ctx, "Could not publish shadow delta", "device.id", d.ID, "error", err)
+	}
+
+	return &updated, nil
+}
+
+// Report merges a partial reported state sent by the device into its shadow,
+// retrying when the version changed in between.
+func (s *Service) Report(ctx context.Context, deviceID uuid.UUID, patch Document) (*repository.DeviceShadow, error) {
+	for range maxReportRetries {
+		sh, err := s.load(ctx, deviceID)
+		if err != nil {
+			return nil, err
+		}
+
+		reported, err := decodeDocument(sh.Reported)
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := json.Marshal(Merge(reported, patch))
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := s.queries.UpdateDeviceShadowReported(ctx, repository.UpdateDeviceShadowReportedParams{
+			DeviceID: deviceID,
+			Reported: raw,
+			Version:  sh.Version,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s.cache.Invalidate(ctx, CacheKey(deviceID))
+		return &updated, nil
+	}
+
+	return nil, errVersionConflict
+}
+
+func (s *Service) load(ctx context.Context, deviceID uuid.UUID) (*repository.DeviceShadow, error) {
+	if err := s.queries.EnsureDeviceShadow(ctx, deviceID); err != nil {
+		return nil, err
+	}
+
+	sh, err := s.queries.GetDeviceShadow(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sh, nil
+}
+
+// Subscribe starts listening for reported state and shadow requests of all devices.
+func (s *Service) Subscribe() error {
+	if err := s.broker.Subscribe(ReportedSubscription, s.handleReported); err != nil {
+		return err
+	}
+
+	return s.broker.Subscribe(GetSubscription, s.handleGet)
+}
+
+func (s *Service) handleReported(topic string, payload []byte) {
+	ctx := context.Background()
+	serial, ok := serialFromTopic(topic, "reported")
+	if !ok {
+		slog.Warn("Ignoring shadow report on unexpected topic", "topic", topic)
+		return
+	}
+
+	patch, err := decodeDocument(payload)
+	if err != nil {
+		slog.Warn("Ignoring malformed shadow report", "topic", topic, "error", err)
+		return
+	}
+
+	d, err := s.queries.GetDeviceBySerialNumber(ctx, serial)
+	if err != nil {
+		slog.Warn("Shadow report from unknown device", "serialNumber", serial, "error", err)
+		return
+	}
+
+	if _, err := s.Report(ctx, d.ID, patch); err != nil {
+		slog.Error("Failed to store reported state", "device.id", d.ID, "error", err)
+	}
+}
+
+// handleGet answers a device that (re)connected with its full shadow and any
+// pending delta, so desired changes made while it was offline are applied.
+func (s *Service) handleGet(topic string, _ []byte) {
+	ctx := context.Background()
+	serial, ok := serialFromTopic(topic, "get")
+	if !ok {
+		slog.Warn("Ignoring shadow request on unexpected topic", "topic", topic)
+		return
+	}
+
+	d, err := s.queries.GetDeviceBySerialNumber(ctx, serial)
+	if err != nil {
+		slog.Warn("Shadow request from unknown device", "serialNumber", serial, "error", err)
+		return
+	}
+
+	sh, err := s.load(ctx, d.ID)
+	if err != nil {
+		slog.Error("Failed to load shadow", "device.id", d.ID, "error", err)
+		return
+	}
+
+	res, err := NewShadowResponse(sh)
+	if err != nil {
+		slog.Error("Failed to decode shadow", "device.id", d.ID, "error", err)
+		return
+	}
+
+	msg, err := json.Marshal(res)
+	if err != nil {
+		slog.Error("Failed to encode shadow", "device.id", d.ID, "error", err)
+		return
+	}
+
+	if err := s.broker.Publish(ctx, DocumentTopic(serial), msg); err != nil {
+		slog.Warn("Could not publish shadow document", "device.id", d.ID, "error", err)
+	}
+
+	if err := s.publishDelta(ctx, serial, sh); err != nil {
+		slog.Warn("Could not publish shadow delta", "device.id", d.ID, "error", err)
+	}
+}
+
+func (s *Service) publishDelta(ctx context.Context, serialNumber string, sh *repository.DeviceShadow) error {
+	res, err := NewShadowResponse(sh)
+	if err != nil {
+		return err
+	}
+
+	if len(res.Delta) == 0 {
+		return nil
+	}
+
+	msg, err := json.Marshal(DeltaMessage{Version: res.Version, State: res.Delta})
+	if err != nil {
+		return err
+	}
+
+	return s.broker.Publish(ctx, DeltaTopic(serialNumber), msg)
+}
@@ -0,0 +1,34 @@
+package shadow
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ReportedSubscription = "devices/+/shadow/reported"
+	GetSubscription      = "devices/+/shadow/get"
+)
+
+func DocumentTopic(serialNumber string) string {
+	return fmt.Sprintf("devices/%s/shadow", serialNumber)
+}
+
+func DeltaTopic(serialNumber string) string {
+	return fmt.Sprintf("devices/%s/shadow/delta", serialNumber)
+}
+
+// ReportedTopic is where a device publishes changes of its state.
+func ReportedTopic(serialNumber string) string {
+	return fmt.Sprintf("devices/%s/shadow/reported", serialNumber)
+}
+
+// serialFromTopic extracts the serial number from devices/{serial}/shadow/{action}.
+func serialFromTopic(topic, action string) (string, bool) {
+	parts := strings.Split(topic, "/")
+	if len(parts) != 4 || parts[0] != "devices" || parts[2] != "shadow" || parts[3] != action {
+		return "", false
+	}
+
+	return parts[1], parts[1] != ""
+}
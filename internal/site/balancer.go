@@ -0,0 +1,329 @@
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/chargepoint"
+	"github.com/V2G-Minor-Fontys/server/internal/chargingprofile"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/meter"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/V2G-Minor-Fontys/server/pkg/loadbalance"
+	"github.com/V2G-Minor-Fontys/server/pkg/optimizer"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const (
+	balanceInterval = 30 * time.Second
+	// limitValidity is how long a charger keeps its limit, it falls back to
+	// its own maximum when the server stops balancing.
+	limitValidity = 15 * time.Minute
+	refreshAfter  = 5 * time.Minute
+	// minIncreaseKw avoids sending a new profile for every small fluctuation,
+	// decreases are always sent.
+	minIncreaseKw = 0.5
+	// marginShare of the connection is kept free for load changes between
+	// two balancing rounds.
+	marginShare = 0.05
+	// minCurrentA is the lowest charging current IEC 61851 allows.
+	minCurrentA = 6
+	// defaultMaxKw is assumed for sessions without a known vehicle.
+	defaultMaxKw = 22
+	// boostPriority puts boosting vehicles ahead of any configured priority.
+	boostPriority = 100
+	// readingStaleAfter ignores shadow readings older than this.
+	readingStaleAfter = 2 * time.Minute
+)
+
+type sentLimit struct {
+	limitKw float64
+	at      time.Time
+}
+
+// Balancer keeps the chargers of a site within its connection limit. It
+// periodically measures the load of the site, divides what is left among the
+// active sessions and caps every charger with a maximum profile. Vehicles in
+// solar mode only get what the site would otherwise export on top of their
+// planned minimum.
+type Balancer struct {
+	queries    *repository.Queries
+	vehicles   *vehicle.Service
+	profiles   *chargingprofile.Service
+	meters     *meter.Service
+	congestion CongestionSource
+	sent       map[uuid.UUID]sentLimit
+}
+
+func NewBalancer(queries *repository.Queries, vehicles *vehicle.Service, profiles *chargingprofile.Service, meters *meter.Service, congestion CongestionSource) *Balancer {
+	return &Balancer{
+		queries:    queries,
+		vehicles:   vehicles,
+		profiles:   profiles,
+		meters:     meters,
+		congestion: congestion,
+		sent:       make(map[uuid.UUID]sentLimit),
+	}
+}
+
+func (b *Balancer) Run(ctx context.Context) {
+	ticker := time.NewTicker(balanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := b.balance(ctx, now); err != nil {
+				slog.ErrorContext(ctx, "Failed to balance site load", "error", err)
+			}
+		}
+	}
+}
+
+func (b *Balancer) balance(ctx context.Context, now time.Time) error {
+	sites, err := b.queries.ListSites(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range sites {
+		if err := b.balanceSite(ctx, &sites[i], now); err != nil {
+			slog.WarnContext(ctx, "Failed to balance site load", "site.id", sites[i].ID, "error", err)
+		}
+	}
+
+	// Limits of chargers without sessions expire on the charger.
+	for id, sent := range b.sent {
+		if now.Sub(sent.at) > limitValidity {
+			delete(b.sent, id)
+		}
+	}
+
+	return nil
+}
+
+func (b *Balancer) balanceSite(ctx context.Context, s *repository.Site, now time.Time) error {
+	siteID := pgtype.UUID{Bytes: s.ID, Valid: true}
+	sessions, err := b.queries.ListActiveChargingSessionsBySiteId(ctx, siteID)
+	if err != nil || len(sessions) == 0 {
+		return err
+	}
+
+	devices, err := b.queries.ListDevicesBySiteId(ctx, siteID)
+	if err != nil {
+		return err
+	}
+
+	// The meter measures the import of the whole connection, including the
+	// chargers. P1 readings take precedence over meters reporting through
+	// their shadow, without either only the chargers are counted.
+	var importKw, chargersKw float64
+	metered := false
+	chargerKw := make(map[uuid.UUID]float64)
+	for _, d := range devices {
+		reported := b.reported(ctx, d.ID, now)
+		power, ok := reported[chargingprofile.PowerField].(float64)
+		if !ok {
+			continue
+		}
+
+		switch d.Kind {
+		case device.KindMeter:
+			importKw += power
+			metered = true
+		case device.KindCharger:
+			chargersKw += power
+			chargerKw[d.ID] = power
+		}
+	}
+	if r, ok := b.meters.Current(ctx, s.ID, now); ok {
+		importKw = r.ImportKw - r.ExportKw
+		metered = true
+	}
+	if !metered {
+		importKw = chargersKw
+	}
+
+	limitKw := b.importLimitKw(ctx, s, now)
+	availableKw := loadbalance.Available(limitKw, importKw, chargersKw, limitKw*marginShare)
+	minKw := minCurrentA * float64(s.Phases) * s.VoltageV / 1000
+
+	demands := make([]loadbalance.Session, 0, len(sessions))
+	var solar []solarSession
+	solarKw := 0.0
+	for i := range sessions {
+		d, floorKw, ok := b.demand(ctx, &sessions[i], minKw, now)
+		if ok {
+			solar = append(solar, solarSession{index: len(demands), floorKw: floorKw})
+			solarKw += chargerKw[sessions[i].DeviceID]
+		}
+		demands = append(demands, d)
+	}
+
+	// Solar sessions share what the site would otherwise export, which
+	// includes what they draw right now. Without a meter the surplus is
+	// unknown and they only charge their floor.
+	surplusKw := 0.0
+	if metered && len(solar) > 0 {
+		surplusKw = math.Max(0, solarKw-importKw) / float64(len(solar))
+	}
+	for _, ss := range solar {
+		d := &demands[ss.index]
+		d.MaxKw = math.Min(d.MaxKw, ss.floorKw+surplusKw)
+		if d.MaxKw < d.MinKw {
+			d.MaxKw = 0
+		}
+	}
+
+	allocations := loadbalance.Allocate(availableKw, demands, now)
+	limits := make(map[uuid.UUID]float64)
+	for _, cs := range sessions {
+		limits[cs.DeviceID] += allocations[cs.ID.String()]
+	}
+
+	for deviceID, limit := range limits {
+		b.apply(ctx, deviceID, limit, now)
+	}
+
+	return nil
+}
+
+// importLimitKw is what the site may import now, the capacity of its
+// connection unless the grid operator caps it.
+func (b *Balancer) importLimitKw(ctx context.Context, s *repository.Site, now time.Time) float64 {
+	limitKw := MaxPowerKw(s)
+	if b.congestion == nil {
+		return limitKw
+	}
+
+	caps, err := b.congestion.Caps(ctx, s, now, now.Add(balanceInterval))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve congestion caps", "site.id", s.ID, "error", err)
+		return limitKw
+	}
+
+	importKw, _ := CapsDuring(caps, now, now.Add(balanceInterval), limitKw)
+	return importKw
+}
+
+// solarSession is a session in solar mode, floorKw is what it charges
+// regardless of the surplus.
+type solarSession struct {
+	index   int
+	floorKw float64
+}
+
+// demand describes what a session needs from the preferences and state of
+// charge of its vehicle. Sessions without a vehicle are balanced as equals
+// without a deadline.
+//
+// Vehicles in solar mode have no deadline of their own, their floor is the
+// grid fallback the scheduler planned for now or their configured minimum,
+// and the caller adds their share of the surplus. A boost overrides solar
+// mode.
+func (b *Balancer) demand(ctx context.Context, cs *repository.ChargingSession, minKw float64, now time.Time) (loadbalance.Session, float64, bool) {
+	d := loadbalance.Session{ID: cs.ID.String(), NeededKwh: -1, MinKw: minKw, MaxKw: defaultMaxKw}
+	if !cs.VehicleID.Valid {
+		return d, 0, false
+	}
+
+	v, err := b.queries.GetVehicleById(ctx, uuid.UUID(cs.VehicleID.Bytes))
+	if err != nil {
+		return d, 0, false
+	}
+	d.MaxKw = v.MaxChargeKw
+	d.MinKw = math.Min(minKw, v.MaxChargeKw)
+
+	prefs, err := b.vehicles.LoadPreferences(ctx, v.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load vehicle preferences", "vehicle.id", v.ID, "error", err)
+		return d, 0, false
+	}
+
+	d.Priority = prefs.Priority
+	if prefs.Boosting(now) {
+		d.Priority += boostPriority
+	}
+
+	if prefs.ChargingMode == vehicle.ModeSolar && !prefs.Boosting(now) {
+		floorKw := math.Max(b.scheduledKw(ctx, v.ID, now), prefs.SolarMinKw)
+		if floorKw > 0 {
+			floorKw = math.Max(floorKw, d.MinKw)
+		}
+
+		return d, floorKw, true
+	}
+
+	if departure, ok := prefs.NextDeparture(now); ok {
+		d.Departure = departure
+	}
+
+	if soc, ok := b.reported(ctx, cs.DeviceID, now)[scheduling.SocField].(float64); ok && soc >= 0 && soc <= 100 {
+		d.NeededKwh = math.Max(0, prefs.TargetSoc-soc) / 100 * v.BatteryCapacityKwh
+	}
+
+	return d, 0, false
+}
+
+// scheduledKw is the charging power the latest schedule of the vehicle plans
+// for now, zero when there is none.
+func (b *Balancer) scheduledKw(ctx context.Context, vehicleID uuid.UUID, now time.Time) float64 {
+	sched, err := b.queries.GetLatestChargingSchedule(ctx, vehicleID)
+	if err != nil {
+		return 0
+	}
+
+	var setpoints []optimizer.Setpoint
+	if err := json.Unmarshal(sched.Setpoints, &setpoints); err != nil {
+		return 0
+	}
+
+	for _, sp := range setpoints {
+		if !now.Before(sp.Start) && now.Before(sp.Start.Add(scheduling.Resolution)) {
+			return math.Max(0, sp.PowerKw)
+		}
+	}
+
+	return 0
+}
+
+// apply sends the limit when it is lower than the last one, noticeably
+// higher, or about to expire.
+func (b *Balancer) apply(ctx context.Context, deviceID uuid.UUID, limitKw float64, now time.Time) {
+	last, ok := b.sent[deviceID]
+	if ok && limitKw >= last.limitKw && limitKw-last.limitKw < minIncreaseKw && now.Sub(last.at) < refreshAfter {
+		return
+	}
+
+	// Chargers that are offline receive the profile when they reconnect.
+	if err := b.profiles.SetMaxPower(ctx, deviceID, limitKw, limitValidity); err != nil && !errors.Is(err, chargepoint.ErrNotConnected) {
+		slog.WarnContext(ctx, "Failed to limit charger power", "device.id", deviceID, "limitKw", limitKw, "error", err)
+		return
+	}
+
+	b.sent[deviceID] = sentLimit{limitKw: limitKw, at: now}
+}
+
+// reported returns the reported shadow state of a device, or nil when it is
+// missing or stale.
+func (b *Balancer) reported(ctx context.Context, deviceID uuid.UUID, now time.Time) map[string]any {
+	sh, err := b.queries.GetDeviceShadow(ctx, deviceID)
+	if err != nil || !sh.ReportedUpdatedAt.Valid || now.Sub(sh.ReportedUpdatedAt.Time) > readingStaleAfter {
+		return nil
+	}
+
+	var reported map[string]any
+	if err := json.Unmarshal(sh.Reported, &reported); err != nil {
+		return nil
+	}
+
+	return reported
+}
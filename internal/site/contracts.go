@@ -0,0 +1,213 @@
+package site
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	KindHome   = "home"
+	KindOffice = "office"
+	KindDepot  = "depot"
+)
+
+const (
+	RoleOwner   = "owner"
+	RoleManager = "manager"
+	RoleViewer  = "viewer"
+)
+
+const defaultVoltageV = 230
+
+var postcodePattern = regexp.MustCompile(`^[1-9][0-9]{3}[A-Z]{2}$`)
+
+// roleRank orders the roles, every role may do what lower ranked roles can.
+var roleRank = map[string]int{
+	RoleViewer:  1,
+	RoleManager: 2,
+	RoleOwner:   3,
+}
+
+type SiteRequest struct {
+	Name        string  `json:"name,omitempty"`
+	Kind        string  `json:"kind,omitempty"`
+	Phases      int16   `json:"phases,omitempty"`
+	VoltageV    float64 `json:"voltageV,omitempty"`
+	MaxCurrentA float64 `json:"maxCurrentA,omitempty"`
+	// Latitude and Longitude locate the site for solar forecasts.
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	// Region groups sites for flexibility aggregation, e.g. a grid area.
+	Region string `json:"region,omitempty"`
+	// Postcode places the site in the congestion zones of the grid operator.
+	Postcode string `json:"postcode,omitempty"`
+	// Zone is the bidding zone of the grid connection, e.g. NL, whose market
+	// prices and carbon intensities apply. The default zone when empty.
+	Zone string `json:"zone,omitempty"`
+}
+
+// Validate checks the request, defaulting to a three phase 230 V connection.
+func (r *SiteRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.Name == "" {
+		errs.Add("name", "Name is required")
+	}
+
+	switch r.Kind {
+	case KindHome, KindOffice, KindDepot:
+	default:
+		errs.Add("kind", "Kind must be home, office or depot")
+	}
+
+	if r.Phases == 0 {
+		r.Phases = 3
+	}
+	if r.Phases != 1 && r.Phases != 3 {
+		errs.Add("phases", "Phases must be 1 or 3")
+	}
+
+	if r.VoltageV == 0 {
+		r.VoltageV = defaultVoltageV
+	}
+	if r.VoltageV < 0 {
+		errs.Add("voltageV", "Voltage must be positive")
+	}
+
+	if r.MaxCurrentA <= 0 {
+		errs.Add("maxCurrentA", "Maximum current per phase must be positive")
+	}
+
+	if (r.Latitude == nil) != (r.Longitude == nil) {
+		errs.Add("latitude", "Latitude and longitude must be given together")
+	}
+	if r.Latitude != nil && (*r.Latitude < -90 || *r.Latitude > 90) {
+		errs.Add("latitude", "Latitude must be between -90 and 90 degrees")
+	}
+	if r.Longitude != nil && (*r.Longitude < -180 || *r.Longitude > 180) {
+		errs.Add("longitude", "Longitude must be between -180 and 180 degrees")
+	}
+
+	if len(r.Region) > 50 {
+		errs.Add("region", "Region must be at most 50 characters")
+	}
+
+	r.Zone = strings.ToUpper(strings.TrimSpace(r.Zone))
+	if len(r.Zone) > 20 {
+		errs.Add("zone", "Zone must be at most 20 characters")
+	}
+
+	if r.Postcode != "" {
+		postcode, ok := NormalizePostcode(r.Postcode)
+		if !ok {
+			errs.Add("postcode", "Postcode must be a Dutch postcode such as 1234 AB")
+		}
+		r.Postcode = postcode
+	}
+
+	return errs
+}
+
+func (r *SiteRequest) location() (pgtype.Float8, pgtype.Float8) {
+	if r.Latitude == nil || r.Longitude == nil {
+		return pgtype.Float8{}, pgtype.Float8{}
+	}
+
+	return pgtype.Float8{Float64: *r.Latitude, Valid: true}, pgtype.Float8{Float64: *r.Longitude, Valid: true}
+}
+
+// NormalizePostcode writes a Dutch postcode as four digits followed by two
+// capitals, false when v is not a postcode.
+func NormalizePostcode(v string) (string, bool) {
+	postcode := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+	if !postcodePattern.MatchString(postcode) {
+		return "", false
+	}
+
+	return postcode, true
+}
+
+type MemberRequest struct {
+	Username string `json:"username,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
+
+func (r *MemberRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.Username == "" {
+		errs.Add("username", "Username is required")
+	}
+	if _, ok := roleRank[r.Role]; !ok {
+		errs.Add("role", "Role must be owner, manager or viewer")
+	}
+
+	return errs
+}
+
+type RoleRequest struct {
+	Role string `json:"role,omitempty"`
+}
+
+type SiteResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	Phases      int16     `json:"phases"`
+	VoltageV    float64   `json:"voltageV"`
+	MaxCurrentA float64   `json:"maxCurrentA"`
+	MaxPowerKw  float64   `json:"maxPowerKw"`
+	Latitude    *float64  `json:"latitude,omitempty"`
+	Longitude   *float64  `json:"longitude,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	Postcode    string    `json:"postcode,omitempty"`
+	Zone        string    `json:"zone,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// NewSiteResponse describes the site as seen by a member with the given role.
+func NewSiteResponse(s *repository.Site, role string) *SiteResponse {
+	res := &SiteResponse{
+		ID:          s.ID,
+		Name:        s.Name,
+		Kind:        s.Kind,
+		Phases:      s.Phases,
+		VoltageV:    s.VoltageV,
+		MaxCurrentA: s.MaxCurrentA,
+		MaxPowerKw:  MaxPowerKw(s),
+		Region:      s.Region,
+		Postcode:    s.Postcode,
+		Zone:        s.Zone,
+		Role:        role,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+
+	if s.Latitude.Valid && s.Longitude.Valid {
+		res.Latitude = &s.Latitude.Float64
+		res.Longitude = &s.Longitude.Float64
+	}
+
+	return res
+}
+
+type MemberResponse struct {
+	UserID    uuid.UUID `json:"userId"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewMemberResponse(m *repository.ListSiteMembersRow) *MemberResponse {
+	return &MemberResponse{
+		UserID:    m.UserID,
+		Username:  m.Username,
+		Role:      m.Role,
+		CreatedAt: m.CreatedAt,
+	}
+}
@@ -0,0 +1,308 @@
+package site
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req SiteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	s, err := h.svc.Create(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, NewSiteResponse(s, RoleOwner))
+	return nil
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	sites, err := h.svc.List(ctx, identityID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*SiteResponse, 0, len(sites))
+	for i := range sites {
+		res = append(res, NewSiteResponse(&sites[i], ""))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	s, role, err := h.svc.Authorize(ctx, identityID, siteID, RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewSiteResponse(s, role))
+	return nil
+}
+
+func (h *Handler) UpdateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req SiteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	s, err := h.svc.Update(ctx, identityID, siteID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewSiteResponse(s, ""))
+	return nil
+}
+
+func (h *Handler) DeleteHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Delete(ctx, identityID, siteID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) ListMembersHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	members, err := h.svc.Members(ctx, identityID, siteID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*MemberResponse, 0, len(members))
+	for i := range members {
+		res = append(res, NewMemberResponse(&members[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) AddMemberHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req MemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	if err := h.svc.AddMember(ctx, identityID, siteID, req); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) SetRoleHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	userID, err := httpx.URLParamUUID(r, "userId")
+	if err != nil {
+		return err
+	}
+
+	var req RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	if err := h.svc.SetRole(ctx, identityID, siteID, userID, req); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	userID, err := httpx.URLParamUUID(r, "userId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.RemoveMember(ctx, identityID, siteID, userID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) ListDevicesHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	devices, err := h.svc.Devices(ctx, identityID, siteID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*device.DeviceResponse, 0, len(devices))
+	for i := range devices {
+		res = append(res, device.NewDeviceResponse(&devices[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) AttachDeviceHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "deviceId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.AttachDevice(ctx, identityID, siteID, deviceID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) DetachDeviceHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "deviceId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.DetachDevice(ctx, identityID, siteID, deviceID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
@@ -0,0 +1,147 @@
+package site
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/meter"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/pkg/pvforecast"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// MaxPowerKw is the capacity of the grid connection, the same in both
+// directions.
+func MaxPowerKw(s *repository.Site) float64 {
+	return math.Round(float64(s.Phases)*s.VoltageV*s.MaxCurrentA) / 1000
+}
+
+// ProductionForecaster predicts the PV production of a site.
+type ProductionForecaster interface {
+	ProductionForecast(ctx context.Context, siteID uuid.UUID, from, to time.Time) ([]pvforecast.Slot, error)
+}
+
+// CongestionCap is a limit the grid operator imposes on the connection of a
+// site from Start until End. A direction that is not limited is
+// math.Inf(1).
+type CongestionCap struct {
+	Start       time.Time
+	End         time.Time
+	MaxImportKw float64
+	MaxExportKw float64
+}
+
+// CongestionSource supplies the congestion caps of a site that overlap
+// [from, to).
+type CongestionSource interface {
+	Caps(ctx context.Context, s *repository.Site, from, to time.Time) ([]CongestionCap, error)
+}
+
+// GridLimiter exposes the connection limit of sites to the scheduler.
+type GridLimiter struct {
+	queries    *repository.Queries
+	meters     *meter.Service
+	production ProductionForecaster
+	congestion CongestionSource
+}
+
+func NewGridLimiter(queries *repository.Queries, meters *meter.Service, production ProductionForecaster, congestion CongestionSource) *GridLimiter {
+	return &GridLimiter{queries: queries, meters: meters, production: production, congestion: congestion}
+}
+
+// GridLimits implements scheduling.GridLimiter for chargers that are part of a
+// site. The expected household load is taken from the connection capacity,
+// when the house consumes more is left for charging and less for feeding
+// back, and the other way around while it exports solar power. Sites without
+// meter readings or production forecast get the full capacity over the whole
+// horizon.
+//
+// The household forecast is based on net readings, so on sunny days it
+// already contains part of the production. Where a production forecast is
+// available only the consumption is kept and the forecast production is
+// offered as surplus instead.
+//
+// Congestion caps of the grid operator lower the capacity of the connection
+// for every interval they overlap.
+func (l *GridLimiter) GridLimits(ctx context.Context, chargerID uuid.UUID, from, to time.Time) ([]scheduling.GridLimit, error) {
+	s, err := l.queries.GetSiteByDeviceId(ctx, chargerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	limit := MaxPowerKw(&s)
+	loads, err := l.meters.HouseholdForecast(ctx, s.ID, from, to, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var production []pvforecast.Slot
+	if l.production != nil {
+		if production, err = l.production.ProductionForecast(ctx, s.ID, from, to); err != nil {
+			slog.WarnContext(ctx, "Failed to forecast PV production", "site.id", s.ID, "error", err)
+		}
+	}
+
+	var caps []CongestionCap
+	if l.congestion != nil {
+		if caps, err = l.congestion.Caps(ctx, &s, from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(loads) == 0 && len(production) == 0 && len(caps) == 0 {
+		return []scheduling.GridLimit{{Start: from, MaxImportKw: limit, MaxExportKw: limit}}, nil
+	}
+
+	householdKw := make(map[time.Time]float64, len(loads))
+	for _, load := range loads {
+		householdKw[load.Start] = load.HouseholdKw
+	}
+	productionKw := make(map[time.Time]float64, len(production))
+	for _, slot := range production {
+		productionKw[slot.Start] = slot.PowerKw
+	}
+
+	var limits []scheduling.GridLimit
+	for t := from.UTC().Truncate(meter.ForecastResolution); t.Before(to); t = t.Add(meter.ForecastResolution) {
+		h := householdKw[t]
+		importKw, exportKw := CapsDuring(caps, t, t.Add(meter.ForecastResolution), limit)
+		gl := scheduling.GridLimit{
+			Start:       t,
+			MaxImportKw: math.Max(0, importKw-h),
+			MaxExportKw: math.Max(0, exportKw+h),
+		}
+		if pv, ok := productionKw[t]; ok {
+			consumption := math.Max(0, h)
+			gl.MaxImportKw = math.Max(0, importKw-consumption)
+			gl.MaxExportKw = math.Max(0, exportKw+consumption-pv)
+			gl.SurplusKw = math.Max(0, pv-consumption)
+		}
+
+		limits = append(limits, gl)
+	}
+
+	return limits, nil
+}
+
+// CapsDuring lowers the capacity of a connection to the tightest import and
+// export caps that overlap [from, to).
+func CapsDuring(caps []CongestionCap, from, to time.Time, limitKw float64) (importKw, exportKw float64) {
+	importKw, exportKw = limitKw, limitKw
+	for _, c := range caps {
+		if c.Start.Before(to) && c.End.After(from) {
+			importKw = math.Min(importKw, c.MaxImportKw)
+			exportKw = math.Min(exportKw, c.MaxExportKw)
+		}
+	}
+
+	return importKw, exportKw
+}
@@ -0,0 +1,345 @@
+// Package site groups chargers, meters and inverters behind one grid
+// connection and manages who may see and change them.
+package site
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+)
+
+type Service struct {
+	db      *pgxpool.Pool
+	queries *repository.Queries
+	devices *device.Service
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, devices *device.Service) *Service {
+	return &Service{db: db, queries: queries, devices: devices}
+}
+
+// Create stores the site with the caller as its owner.
+func (s *Service) Create(ctx context.Context, identityID uuid.UUID, req SiteRequest) (*repository.Site, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	latitude, longitude := req.location()
+	qtx := s.queries.WithTx(tx)
+	site, err := qtx.CreateSite(ctx, repository.CreateSiteParams{
+		ID:          uuid.New(),
+		Name:        req.Name,
+		Kind:        req.Kind,
+		Phases:      req.Phases,
+		VoltageV:    req.VoltageV,
+		MaxCurrentA: req.MaxCurrentA,
+		Latitude:    latitude,
+		Longitude:   longitude,
+		Region:      req.Region,
+		Postcode:    req.Postcode,
+		Zone:        req.Zone,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to store site", err)
+	}
+
+	if _, err := qtx.UpsertSiteMember(ctx, repository.UpsertSiteMemberParams{
+		SiteID: site.ID,
+		UserID: identityID,
+		Role:   RoleOwner,
+	}); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to store site member", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	return &site, nil
+}
+
+func (s *Service) List(ctx context.Context, identityID uuid.UUID) ([]repository.Site, error) {
+	sites, err := s.queries.ListSitesByUserId(ctx, identityID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve sites", err)
+	}
+
+	return sites, nil
+}
+
+// Authorize returns the site and the caller's role when the caller has at
+// least the given role. Sites the caller is not a member of are reported as
+// not found.
+func (s *Service) Authorize(ctx context.Context, identityID, siteID uuid.UUID, role string) (*repository.Site, string, error) {
+	m, err := s.queries.GetSiteMember(ctx, repository.GetSiteMemberParams{SiteID: siteID, UserID: identityID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", httpx.NotFound(ctx, "Site could not be found")
+		}
+
+		return nil, "", httpx.InternalErr(ctx, "Failed to retrieve site member", err)
+	}
+
+	if roleRank[m.Role] < roleRank[role] {
+		return nil, "", httpx.Forbidden(ctx, "This requires the "+role+" role on the site")
+	}
+
+	site, err := s.queries.GetSiteById(ctx, siteID)
+	if err != nil {
+		return nil, "", httpx.InternalErr(ctx, "Failed to retrieve site", err)
+	}
+
+	return &site, m.Role, nil
+}
+
+func (s *Service) Update(ctx context.Context, identityID, siteID uuid.UUID, req SiteRequest) (*repository.Site, error) {
+	if _, _, err := s.Authorize(ctx, identityID, siteID, RoleManager); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	latitude, longitude := req.location()
+	site, err := s.queries.UpdateSite(ctx, repository.UpdateSiteParams{
+		ID:          siteID,
+		Name:        req.Name,
+		Kind:        req.Kind,
+		Phases:      req.Phases,
+		VoltageV:    req.VoltageV,
+		MaxCurrentA: req.MaxCurrentA,
+		Latitude:    latitude,
+		Longitude:   longitude,
+		Region:      req.Region,
+		Postcode:    req.Postcode,
+		Zone:        req.Zone,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to update site", err)
+	}
+
+	return &site, nil
+}
+
+// Delete removes the site, its devices stay registered to their owners.
+func (s *Service) Delete(ctx context.Context, identityID, siteID uuid.UUID) error {
+	if _, _, err := s.Authorize(ctx, identityID, siteID, RoleOwner); err != nil {
+		return err
+	}
+
+	if err := s.queries.DeleteSite(ctx, siteID); err != nil {
+		return httpx.InternalErr(ctx, "Failed to delete site", err)
+	}
+
+	return nil
+}
+
+func (s *Service) Members(ctx context.Context, identityID, siteID uuid.UUID) ([]repository.ListSiteMembersRow, error) {
+	if _, _, err := s.Authorize(ctx, identityID, siteID, RoleViewer); err != nil {
+		return nil, err
+	}
+
+	members, err := s.queries.ListSiteMembers(ctx, siteID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve site members", err)
+	}
+
+	return members, nil
+}
+
+// AddMember grants a user a role on the site, replacing any role they had.
+func (s *Service) AddMember(ctx context.Context, identityID, siteID uuid.UUID, req MemberRequest) error {
+	if _, _, err := s.Authorize(ctx, identityID, siteID, RoleOwner); err != nil {
+		return err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return httpx.ValidationFailed(ctx, errs)
+	}
+
+	u, err := s.queries.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return httpx.NotFound(ctx, "User could not be found")
+		}
+
+		return httpx.InternalErr(ctx, "Failed to retrieve user", err)
+	}
+
+	return s.setRole(ctx, siteID, u.ID, req.Role)
+}
+
+func (s *Service) SetRole(ctx context.Context, identityID, siteID, userID uuid.UUID, req RoleRequest) error {
+	if _, _, err := s.Authorize(ctx, identityID, siteID, RoleOwner); err != nil {
+		return err
+	}
+
+	if _, ok := roleRank[req.Role]; !ok {
+		return httpx.ValidationFailed(ctx, httpx.ValidationErrors{"role": "Role must be owner, manager or viewer"})
+	}
+
+	if _, err := s.member(ctx, siteID, userID); err != nil {
+		return err
+	}
+
+	return s.setRole(ctx, siteID, userID, req.Role)
+}
+
+// RemoveMember revokes access to the site. Owners may remove anyone, other
+// members may only leave themselves.
+func (s *Service) RemoveMember(ctx context.Context, identityID, siteID, userID uuid.UUID) error {
+	role := RoleOwner
+	if identityID == userID {
+		role = RoleViewer
+	}
+	if _, _, err := s.Authorize(ctx, identityID, siteID, role); err != nil {
+		return err
+	}
+
+	m, err := s.member(ctx, siteID, userID)
+	if err != nil {
+		return err
+	}
+
+	if m.Role == RoleOwner {
+		if err := s.ensureOtherOwner(ctx, siteID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.queries.DeleteSiteMember(ctx, repository.DeleteSiteMemberParams{SiteID: siteID, UserID: userID}); err != nil {
+		return httpx.InternalErr(ctx, "Failed to remove site member", err)
+	}
+
+	return nil
+}
+
+func (s *Service) setRole(ctx context.Context, siteID, userID uuid.UUID, role string) error {
+	m, err := s.queries.GetSiteMember(ctx, repository.GetSiteMemberParams{SiteID: siteID, UserID: userID})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return httpx.InternalErr(ctx, "Failed to retrieve site member", err)
+	}
+	if err == nil && m.Role == RoleOwner && role != RoleOwner {
+		if err := s.ensureOtherOwner(ctx, siteID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.queries.UpsertSiteMember(ctx, repository.UpsertSiteMemberParams{
+		SiteID: siteID,
+		UserID: userID,
+		Role:   role,
+	}); err != nil {
+		return httpx.InternalErr(ctx, "Failed to store site member", err)
+	}
+
+	return nil
+}
+
+func (s *Service) member(ctx context.Context, siteID, userID uuid.UUID) (*repository.SiteMember, error) {
+	m, err := s.queries.GetSiteMember(ctx, repository.GetSiteMemberParams{SiteID: siteID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Site member could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve site member", err)
+	}
+
+	return &m, nil
+}
+
+// ensureOtherOwner prevents a site from losing its last owner.
+func (s *Service) ensureOtherOwner(ctx context.Context, siteID uuid.UUID) error {
+	owners, err := s.queries.CountSiteOwners(ctx, siteID)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to count site owners", err)
+	}
+
+	if owners <= 1 {
+		return httpx.Conflict(ctx, "A site needs at least one owner, delete the site instead")
+	}
+
+	return nil
+}
+
+func (s *Service) Devices(ctx context.Context, identityID, siteID uuid.UUID) ([]repository.Device, error) {
+	if _, _, err := s.Authorize(ctx, identityID, siteID, RoleViewer); err != nil {
+		return nil, err
+	}
+
+	devices, err := s.queries.ListDevicesBySiteId(ctx, pgtype.UUID{Bytes: siteID, Valid: true})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve devices", err)
+	}
+
+	return devices, nil
+}
+
+// AttachDevice places one of the caller's own devices at the site, moving it
+// away from any site it was at before.
+func (s *Service) AttachDevice(ctx context.Context, identityID, siteID, deviceID uuid.UUID) error {
+	if _, _, err := s.Authorize(ctx, identityID, siteID, RoleManager); err != nil {
+		return err
+	}
+
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.SetDeviceSite(ctx, repository.SetDeviceSiteParams{
+		ID:     d.ID,
+		SiteID: pgtype.UUID{Bytes: siteID, Valid: true},
+	}); err != nil {
+		return httpx.InternalErr(ctx, "Failed to attach device", err)
+	}
+
+	return nil
+}
+
+// DetachDevice removes a device from the site. Managers may detach any
+// device, so that a site can be cleaned up after its owner left.
+func (s *Service) DetachDevice(ctx context.Context, identityID, siteID, deviceID uuid.UUID) error {
+	if _, _, err := s.Authorize(ctx, identityID, siteID, RoleManager); err != nil {
+		return err
+	}
+
+	d, err := s.queries.GetDeviceById(ctx, deviceID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return httpx.NotFound(ctx, "Device could not be found")
+		}
+
+		return httpx.InternalErr(ctx, "Failed to retrieve device", err)
+	}
+
+	if !d.SiteID.Valid || uuid.UUID(d.SiteID.Bytes) != siteID {
+		return httpx.NotFound(ctx, "Device could not be found at this site")
+	}
+
+	if err := s.queries.SetDeviceSite(ctx, repository.SetDeviceSiteParams{ID: d.ID}); err != nil {
+		return httpx.InternalErr(ctx, "Failed to detach device", err)
+	}
+
+	return nil
+}
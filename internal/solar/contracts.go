@@ -0,0 +1,285 @@
+package solar
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultTiltDeg     = 35
+	defaultAzimuthDeg  = 180
+	defaultReportRange = 24 * time.Hour
+	maxReportRange     = 366 * 24 * time.Hour
+	maxForecastRange   = 7 * 24 * time.Hour
+)
+
+// PvSystemRequest describes an array of panels. Azimuth is the compass
+// direction the panels face, 180 is south, and tilt is measured from the
+// horizontal.
+type PvSystemRequest struct {
+	Name       string   `json:"name"`
+	PeakKw     float64  `json:"peakKw"`
+	TiltDeg    *float64 `json:"tiltDeg,omitempty"`
+	AzimuthDeg *float64 `json:"azimuthDeg,omitempty"`
+}
+
+func (r *PvSystemRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.Name == "" {
+		errs.Add("name", "Name is required")
+	} else if len(r.Name) > 100 {
+		errs.Add("name", "Name cannot be longer than 100 characters")
+	}
+	if r.PeakKw <= 0 {
+		errs.Add("peakKw", "Peak power must be positive")
+	}
+	if r.TiltDeg != nil && (*r.TiltDeg < 0 || *r.TiltDeg > 90) {
+		errs.Add("tiltDeg", "Tilt must be between 0 and 90 degrees")
+	}
+	if r.AzimuthDeg != nil && (*r.AzimuthDeg < 0 || *r.AzimuthDeg >= 360) {
+		errs.Add("azimuthDeg", "Azimuth must be at least 0 and below 360 degrees")
+	}
+
+	return errs
+}
+
+func (r *PvSystemRequest) orientation() (tilt, azimuth float64) {
+	tilt, azimuth = defaultTiltDeg, defaultAzimuthDeg
+	if r.TiltDeg != nil {
+		tilt = *r.TiltDeg
+	}
+	if r.AzimuthDeg != nil {
+		azimuth = *r.AzimuthDeg
+	}
+
+	return tilt, azimuth
+}
+
+type PvSystemResponse struct {
+	ID         uuid.UUID `json:"id"`
+	SiteID     uuid.UUID `json:"siteId"`
+	Name       string    `json:"name"`
+	PeakKw     float64   `json:"peakKw"`
+	TiltDeg    float64   `json:"tiltDeg"`
+	AzimuthDeg float64   `json:"azimuthDeg"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func NewPvSystemResponse(p *repository.PvSystem) *PvSystemResponse {
+	return &PvSystemResponse{
+		ID:         p.ID,
+		SiteID:     p.SiteID,
+		Name:       p.Name,
+		PeakKw:     p.PeakKw,
+		TiltDeg:    p.TiltDeg,
+		AzimuthDeg: p.AzimuthDeg,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
+
+// ProductionRequest is a measurement of an inverter. EnergyKwh is its
+// lifetime production counter, when it reports one.
+type ProductionRequest struct {
+	MeasuredAt *time.Time `json:"measuredAt,omitempty"`
+	PowerKw    float64    `json:"powerKw"`
+	EnergyKwh  *float64   `json:"energyKwh,omitempty"`
+}
+
+func (r *ProductionRequest) Validate(now time.Time) httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.PowerKw < 0 {
+		errs.Add("powerKw", "Power cannot be negative")
+	}
+	if r.EnergyKwh != nil && *r.EnergyKwh < 0 {
+		errs.Add("energyKwh", "Energy cannot be negative")
+	}
+	if r.MeasuredAt != nil && r.MeasuredAt.After(now.Add(time.Minute)) {
+		errs.Add("measuredAt", "Measurement cannot be in the future")
+	}
+
+	return errs
+}
+
+type ProductionResponse struct {
+	DeviceID   uuid.UUID `json:"deviceId"`
+	MeasuredAt time.Time `json:"measuredAt"`
+	PowerKw    float64   `json:"powerKw"`
+	EnergyKwh  *float64  `json:"energyKwh,omitempty"`
+}
+
+func NewProductionResponse(r *repository.PvReading) *ProductionResponse {
+	res := &ProductionResponse{
+		DeviceID:   r.DeviceID,
+		MeasuredAt: r.MeasuredAt,
+		PowerKw:    r.PowerKw,
+	}
+	if r.EnergyKwh.Valid {
+		res.EnergyKwh = &r.EnergyKwh.Float64
+	}
+
+	return res
+}
+
+type ReportRequest struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseReportRequest reads the from and to query parameters, they default to
+// the last 24 hours.
+func ParseReportRequest(q url.Values, now time.Time) ReportRequest {
+	req := ReportRequest{
+		To:        now.UTC(),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	req.From = req.To.Add(-defaultReportRange)
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	return req
+}
+
+func (r *ReportRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxReportRange {
+			errs.Add("to", "The requested range cannot exceed 366 days")
+		}
+	}
+
+	return errs
+}
+
+// Report summarises the energy flows of a site. Self-consumption is the share
+// of the production used on site, self-sufficiency the share of the
+// consumption covered by it. Both are omitted when there is nothing to divide
+// by.
+type Report struct {
+	SiteID             uuid.UUID `json:"siteId"`
+	From               time.Time `json:"from"`
+	To                 time.Time `json:"to"`
+	PeakKw             float64   `json:"peakKw"`
+	ProductionKwh      float64   `json:"productionKwh"`
+	ExportedKwh        float64   `json:"exportedKwh"`
+	ImportedKwh        float64   `json:"importedKwh"`
+	SelfConsumedKwh    float64   `json:"selfConsumedKwh"`
+	SelfConsumptionPct *float64  `json:"selfConsumptionPct,omitempty"`
+	SelfSufficiencyPct *float64  `json:"selfSufficiencyPct,omitempty"`
+	// Metered is false when the site has no meter readings in the range, the
+	// export is then unknown and all production counts as self-consumed.
+	Metered bool `json:"metered"`
+}
+
+type ForecastRequest struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseForecastRequest reads the from and to query parameters, they default
+// to now until the end of tomorrow in UTC.
+func ParseForecastRequest(q url.Values, now time.Time) ForecastRequest {
+	req := ForecastRequest{
+		From:      now.UTC(),
+		To:        now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 2),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	return req
+}
+
+func (r *ForecastRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxForecastRange {
+			errs.Add("to", "The requested range cannot exceed 7 days")
+		}
+	}
+
+	return errs
+}
+
+type ForecastSlotResponse struct {
+	Start      time.Time `json:"start"`
+	PowerKw    float64   `json:"powerKw"`
+	ClearSkyKw float64   `json:"clearSkyKw"`
+}
+
+type ForecastResponse struct {
+	SiteID uuid.UUID `json:"siteId"`
+	// Calibration is the factor the model output was corrected by after
+	// comparing past forecasts with the measured production.
+	Calibration float64                `json:"calibration"`
+	EnergyKwh   float64                `json:"energyKwh"`
+	Slots       []ForecastSlotResponse `json:"slots"`
+}
+
+func NewForecastResponse(siteID uuid.UUID, f *Forecast) *ForecastResponse {
+	res := &ForecastResponse{
+		SiteID:      siteID,
+		Calibration: round(f.Calibration),
+		Slots:       make([]ForecastSlotResponse, 0, len(f.Slots)),
+	}
+
+	for _, slot := range f.Slots {
+		res.EnergyKwh += slot.PowerKw * ForecastResolution.Hours()
+		res.Slots = append(res.Slots, ForecastSlotResponse{
+			Start:      slot.Start,
+			PowerKw:    round(slot.PowerKw),
+			ClearSkyKw: round(slot.ClearSkyKw),
+		})
+	}
+	res.EnergyKwh = round(res.EnergyKwh)
+
+	return res
+}
@@ -0,0 +1,196 @@
+package solar
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/V2G-Minor-Fontys/server/pkg/pvforecast"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const (
+	ForecastResolution = 15 * time.Minute
+	// calibrationDays of forecasts are compared with what the inverters
+	// measured to correct the model for shading, soiling and wrong
+	// parameters.
+	calibrationDays = 14
+	// minCalibrationKwh of modelled production is needed before the model is
+	// corrected at all.
+	minCalibrationKwh = 5
+)
+
+// Forecast is the expected production of the PV systems of a site. The slots
+// are already multiplied by the calibration factor.
+type Forecast struct {
+	Calibration float64
+	Slots       []pvforecast.Slot
+}
+
+// ProductionForecast predicts the production of a site per 15 minutes, for
+// the scheduler. Sites without a location, PV systems or weather forecast
+// have no forecast.
+func (s *Service) ProductionForecast(ctx context.Context, siteID uuid.UUID, from, to time.Time) ([]pvforecast.Slot, error) {
+	st, err := s.queries.GetSiteById(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.forecast(ctx, &st, from, to, time.Now())
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	return f.Slots, nil
+}
+
+// GetForecast returns the forecast of a site to one of its members.
+func (s *Service) GetForecast(ctx context.Context, identityID, siteID uuid.UUID, req ForecastRequest) (*Forecast, error) {
+	st, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	f, err := s.forecast(ctx, st, req.From, req.To, time.Now())
+	switch {
+	case err != nil:
+		return nil, httpx.InternalErr(ctx, "Failed to forecast production", err)
+	case f == nil && !st.Latitude.Valid:
+		return nil, httpx.Conflict(ctx, "The site has no location to forecast production for")
+	case f == nil:
+		return nil, httpx.Conflict(ctx, "No PV systems or weather forecast are available for this site")
+	}
+
+	return f, nil
+}
+
+func (s *Service) forecast(ctx context.Context, st *repository.Site, from, to, now time.Time) (*Forecast, error) {
+	if s.weather == nil || !st.Latitude.Valid || !st.Longitude.Valid {
+		return nil, nil
+	}
+
+	systems, err := s.queries.ListPvSystemsBySiteId(ctx, st.ID)
+	if err != nil || len(systems) == 0 {
+		return nil, err
+	}
+
+	loc := pvforecast.Location{Latitude: st.Latitude.Float64, Longitude: st.Longitude.Float64}
+	weather, err := s.weather.Fetch(ctx, loc, from, to)
+	if errors.Is(err, ErrNoWeather) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	arrays := make([]pvforecast.Array, 0, len(systems))
+	peakKw := 0.0
+	for _, p := range systems {
+		arrays = append(arrays, pvforecast.Array{PeakKw: p.PeakKw, TiltDeg: p.TiltDeg, AzimuthDeg: p.AzimuthDeg})
+		peakKw += p.PeakKw
+	}
+
+	slots := pvforecast.Forecast(loc, arrays, weather, from, to, ForecastResolution)
+	if len(slots) == 0 {
+		return nil, nil
+	}
+
+	// The uncalibrated output of upcoming slots is kept to calibrate against
+	// once the slots have passed, the latest forecast of a slot wins.
+	current := now.UTC().Truncate(ForecastResolution)
+	for _, slot := range slots {
+		if slot.Start.Before(current) {
+			continue
+		}
+		if err := s.queries.UpsertPvForecast(ctx, repository.UpsertPvForecastParams{
+			SiteID:    st.ID,
+			SlotStart: slot.Start,
+			ModeledKw: slot.PowerKw,
+		}); err != nil {
+			slog.WarnContext(ctx, "Failed to store PV forecast", "site.id", st.ID, "error", err)
+			break
+		}
+	}
+
+	factor, err := s.calibration(ctx, st.ID, current)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range slots {
+		slots[i].PowerKw = math.Min(peakKw, slots[i].PowerKw*factor)
+		slots[i].ClearSkyKw = math.Min(peakKw, slots[i].ClearSkyKw*factor)
+	}
+
+	return &Forecast{Calibration: factor, Slots: slots}, nil
+}
+
+// calibration compares the forecasts of the past days with the average power
+// the inverters measured in the same slots. Slots without measurements are
+// skipped, so an inverter that was offline does not count as no production.
+func (s *Service) calibration(ctx context.Context, siteID uuid.UUID, until time.Time) (float64, error) {
+	since := until.AddDate(0, 0, -calibrationDays)
+	forecasts, err := s.queries.ListPvForecastsBySiteId(ctx, repository.ListPvForecastsBySiteIdParams{
+		SiteID:     siteID,
+		SlotFrom:   since,
+		SlotBefore: until,
+	})
+	if err != nil || len(forecasts) == 0 {
+		return 1, err
+	}
+
+	readings, err := s.queries.ListPvReadingsBySiteId(ctx, repository.ListPvReadingsBySiteIdParams{
+		SiteID:         pgtype.UUID{Bytes: siteID, Valid: true},
+		MeasuredFrom:   since,
+		MeasuredBefore: until,
+	})
+	if err != nil {
+		return 1, err
+	}
+
+	actual := actualKwPerSlot(readings)
+	hours := ForecastResolution.Hours()
+	var actualKwh, modeledKwh float64
+	for _, f := range forecasts {
+		kw, ok := actual[f.SlotStart.UTC()]
+		if !ok {
+			continue
+		}
+		actualKwh += kw * hours
+		modeledKwh += f.ModeledKw * hours
+	}
+
+	return pvforecast.Calibration(actualKwh, modeledKwh, minCalibrationKwh), nil
+}
+
+// actualKwPerSlot averages the readings of every inverter per slot and sums
+// the inverters.
+func actualKwPerSlot(readings []repository.PvReading) map[time.Time]float64 {
+	type key struct {
+		deviceID uuid.UUID
+		slot     time.Time
+	}
+	sums := make(map[key]float64)
+	counts := make(map[key]int)
+	for _, r := range readings {
+		k := key{r.DeviceID, r.MeasuredAt.UTC().Truncate(ForecastResolution)}
+		sums[k] += r.PowerKw
+		counts[k]++
+	}
+
+	slots := make(map[time.Time]float64)
+	for k, sum := range sums {
+		slots[k.slot] += sum / float64(counts[k])
+	}
+
+	return slots
+}
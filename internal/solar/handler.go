@@ -0,0 +1,200 @@
+package solar
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req PvSystemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	p, err := h.svc.Create(ctx, identityID, siteID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, NewPvSystemResponse(p))
+	return nil
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	systems, err := h.svc.List(ctx, identityID, siteID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*PvSystemResponse, 0, len(systems))
+	for i := range systems {
+		res = append(res, NewPvSystemResponse(&systems[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) UpdateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	systemID, err := httpx.URLParamUUID(r, "pvId")
+	if err != nil {
+		return err
+	}
+
+	var req PvSystemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	p, err := h.svc.Update(ctx, identityID, siteID, systemID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewPvSystemResponse(p))
+	return nil
+}
+
+func (h *Handler) DeleteHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	systemID, err := httpx.URLParamUUID(r, "pvId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Delete(ctx, identityID, siteID, systemID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+// IngestHandler accepts a measurement of an inverter. Measurements arriving
+// within a minute of the last stored one are answered with 202 instead of 201.
+func (h *Handler) IngestHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req ProductionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	reading, stored, err := h.svc.IngestOwned(ctx, identityID, deviceID, req)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	if stored {
+		status = http.StatusCreated
+	}
+
+	httpx.ResponseWithJSON(w, status, NewProductionResponse(reading))
+	return nil
+}
+
+func (h *Handler) ReportHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	report, err := h.svc.Report(ctx, identityID, siteID, ParseReportRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, report)
+	return nil
+}
+
+func (h *Handler) ForecastHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	f, err := h.svc.GetForecast(ctx, identityID, siteID, ParseForecastRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewForecastResponse(siteID, f))
+	return nil
+}
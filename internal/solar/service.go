@@ -0,0 +1,337 @@
+// Package solar models the PV systems of a site, stores what their inverters
+// produce, forecasts their production from the weather and reports how much
+// of it is consumed on site.
+package solar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/V2G-Minor-Fontys/server/pkg/mqtt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const (
+	// storeInterval thins out inverters that report every few seconds.
+	storeInterval = time.Minute
+	// maxGap is the longest interval between two readings that is still
+	// integrated, production during longer outages is unknown.
+	maxGap = 5 * time.Minute
+)
+
+type Service struct {
+	queries *repository.Queries
+	sites   *site.Service
+	devices *device.Service
+	broker  *mqtt.Client
+	weather WeatherProvider
+}
+
+func NewService(queries *repository.Queries, sites *site.Service, devices *device.Service, broker *mqtt.Client, weather WeatherProvider) *Service {
+	return &Service{queries: queries, sites: sites, devices: devices, broker: broker, weather: weather}
+}
+
+func (s *Service) Create(ctx context.Context, identityID, siteID uuid.UUID, req PvSystemRequest) (*repository.PvSystem, error) {
+	if _, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleManager); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	tilt, azimuth := req.orientation()
+	p, err := s.queries.CreatePvSystem(ctx, repository.CreatePvSystemParams{
+		ID:         uuid.New(),
+		SiteID:     siteID,
+		Name:       req.Name,
+		PeakKw:     req.PeakKw,
+		TiltDeg:    tilt,
+		AzimuthDeg: azimuth,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to create PV system", err)
+	}
+
+	return &p, nil
+}
+
+func (s *Service) List(ctx context.Context, identityID, siteID uuid.UUID) ([]repository.PvSystem, error) {
+	if _, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	systems, err := s.queries.ListPvSystemsBySiteId(ctx, siteID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve PV systems", err)
+	}
+
+	return systems, nil
+}
+
+func (s *Service) Update(ctx context.Context, identityID, siteID, systemID uuid.UUID, req PvSystemRequest) (*repository.PvSystem, error) {
+	if _, err := s.get(ctx, identityID, siteID, systemID, site.RoleManager); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	tilt, azimuth := req.orientation()
+	p, err := s.queries.UpdatePvSystem(ctx, repository.UpdatePvSystemParams{
+		ID:         systemID,
+		Name:       req.Name,
+		PeakKw:     req.PeakKw,
+		TiltDeg:    tilt,
+		AzimuthDeg: azimuth,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to update PV system", err)
+	}
+
+	return &p, nil
+}
+
+func (s *Service) Delete(ctx context.Context, identityID, siteID, systemID uuid.UUID) error {
+	if _, err := s.get(ctx, identityID, siteID, systemID, site.RoleManager); err != nil {
+		return err
+	}
+
+	if err := s.queries.DeletePvSystem(ctx, systemID); err != nil {
+		return httpx.InternalErr(ctx, "Failed to delete PV system", err)
+	}
+
+	return nil
+}
+
+// get returns a PV system of the site, systems of other sites are reported
+// as not found.
+func (s *Service) get(ctx context.Context, identityID, siteID, systemID uuid.UUID, role string) (*repository.PvSystem, error) {
+	if _, _, err := s.sites.Authorize(ctx, identityID, siteID, role); err != nil {
+		return nil, err
+	}
+
+	p, err := s.queries.GetPvSystemById(ctx, systemID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "PV system could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve PV system", err)
+	}
+	if p.SiteID != siteID {
+		return nil, httpx.NotFound(ctx, "PV system could not be found")
+	}
+
+	return &p, nil
+}
+
+// IngestOwned stores a measurement posted for one of the caller's inverters.
+func (s *Service) IngestOwned(ctx context.Context, identityID, deviceID uuid.UUID, req ProductionRequest) (*repository.PvReading, bool, error) {
+	d, err := s.devices.GetOwned(ctx, identityID, deviceID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return s.Ingest(ctx, d, req, time.Now())
+}
+
+// Ingest stores a measurement of an inverter and reports whether it was
+// stored, which happens at most once per storeInterval.
+func (s *Service) Ingest(ctx context.Context, d *repository.Device, req ProductionRequest, now time.Time) (*repository.PvReading, bool, error) {
+	if d.Kind != device.KindInverter {
+		return nil, false, httpx.BadRequest(ctx, "Production can only be reported by inverters")
+	}
+
+	if errs := req.Validate(now); len(errs) > 0 {
+		return nil, false, httpx.ValidationFailed(ctx, errs)
+	}
+
+	reading := repository.PvReading{
+		DeviceID:   d.ID,
+		MeasuredAt: now.UTC().Truncate(time.Second),
+		PowerKw:    req.PowerKw,
+	}
+	if req.MeasuredAt != nil {
+		reading.MeasuredAt = req.MeasuredAt.UTC()
+	}
+	if req.EnergyKwh != nil {
+		reading.EnergyKwh = pgtype.Float8{Float64: *req.EnergyKwh, Valid: true}
+	}
+
+	latest, err := s.queries.GetLatestPvReadingByDeviceId(ctx, d.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, httpx.InternalErr(ctx, "Failed to retrieve production reading", err)
+	}
+	if err == nil && reading.MeasuredAt.Sub(latest.MeasuredAt) < storeInterval {
+		return &reading, false, nil
+	}
+
+	if err := s.queries.CreatePvReading(ctx, repository.CreatePvReadingParams{
+		DeviceID:   reading.DeviceID,
+		MeasuredAt: reading.MeasuredAt,
+		PowerKw:    reading.PowerKw,
+		EnergyKwh:  reading.EnergyKwh,
+	}); err != nil {
+		return nil, false, httpx.InternalErr(ctx, "Failed to store production reading", err)
+	}
+
+	return &reading, true, nil
+}
+
+// Report computes the production, export and import of a site over the
+// requested range. Production comes from the inverters and export and import
+// from the counters of the site's meter.
+func (s *Service) Report(ctx context.Context, identityID, siteID uuid.UUID, req ReportRequest) (*Report, error) {
+	if _, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	systems, err := s.queries.ListPvSystemsBySiteId(ctx, siteID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve PV systems", err)
+	}
+
+	production, err := s.queries.ListPvReadingsBySiteId(ctx, repository.ListPvReadingsBySiteIdParams{
+		SiteID:         pgtype.UUID{Bytes: siteID, Valid: true},
+		MeasuredFrom:   req.From,
+		MeasuredBefore: req.To,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve production readings", err)
+	}
+
+	meter, err := s.queries.ListMeterReadingsBySiteIdInRange(ctx, repository.ListMeterReadingsBySiteIdInRangeParams{
+		SiteID:         pgtype.UUID{Bytes: siteID, Valid: true},
+		MeasuredFrom:   req.From,
+		MeasuredBefore: req.To,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve meter readings", err)
+	}
+
+	report := &Report{SiteID: siteID, From: req.From, To: req.To, Metered: len(meter) > 0}
+	for _, p := range systems {
+		report.PeakKw += p.PeakKw
+	}
+
+	report.ProductionKwh = producedKwh(production)
+	report.ImportedKwh, report.ExportedKwh = meteredKwh(meter)
+	report.SelfConsumedKwh = math.Max(0, report.ProductionKwh-report.ExportedKwh)
+	if report.ProductionKwh > 0 {
+		pct := round(report.SelfConsumedKwh / report.ProductionKwh * 100)
+		report.SelfConsumptionPct = &pct
+	}
+	if consumed := report.SelfConsumedKwh + report.ImportedKwh; report.Metered && consumed > 0 {
+		pct := round(report.SelfConsumedKwh / consumed * 100)
+		report.SelfSufficiencyPct = &pct
+	}
+
+	report.ProductionKwh = round(report.ProductionKwh)
+	report.ExportedKwh = round(report.ExportedKwh)
+	report.ImportedKwh = round(report.ImportedKwh)
+	report.SelfConsumedKwh = round(report.SelfConsumedKwh)
+	return report, nil
+}
+
+// producedKwh sums the production of every inverter, the readings are
+// ordered by device and time. Inverters with an energy counter are measured
+// by its increase, the power of the others is integrated.
+func producedKwh(readings []repository.PvReading) float64 {
+	total := 0.0
+	for start := 0; start < len(readings); {
+		end := start
+		for end < len(readings) && readings[end].DeviceID == readings[start].DeviceID {
+			end++
+		}
+
+		total += deviceKwh(readings[start:end])
+		start = end
+	}
+
+	return total
+}
+
+func deviceKwh(readings []repository.PvReading) float64 {
+	first, last := readings[0], readings[len(readings)-1]
+	if first.EnergyKwh.Valid && last.EnergyKwh.Valid && last.EnergyKwh.Float64 >= first.EnergyKwh.Float64 && len(readings) > 1 {
+		return last.EnergyKwh.Float64 - first.EnergyKwh.Float64
+	}
+
+	kwh := 0.0
+	for i := 1; i < len(readings); i++ {
+		gap := readings[i].MeasuredAt.Sub(readings[i-1].MeasuredAt)
+		if gap > maxGap {
+			continue
+		}
+		kwh += (readings[i-1].PowerKw + readings[i].PowerKw) / 2 * gap.Hours()
+	}
+
+	return kwh
+}
+
+// meteredKwh returns the increase of the import and export counters of every
+// meter, the readings are ordered by device and time.
+func meteredKwh(readings []repository.MeterReading) (importedKwh, exportedKwh float64) {
+	for start := 0; start < len(readings); {
+		end := start
+		for end < len(readings) && readings[end].DeviceID == readings[start].DeviceID {
+			end++
+		}
+
+		first, last := readings[start], readings[end-1]
+		importedKwh += math.Max(0, last.ImportedKwh-first.ImportedKwh)
+		exportedKwh += math.Max(0, last.ExportedKwh-first.ExportedKwh)
+		start = end
+	}
+
+	return importedKwh, exportedKwh
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Subscribe starts listening for measurements published by inverters.
+func (s *Service) Subscribe() error {
+	return s.broker.Subscribe(ProductionSubscription, s.handleProduction)
+}
+
+func (s *Service) handleProduction(topic string, payload []byte) {
+	ctx := context.Background()
+	serial, ok := serialFromProductionTopic(topic)
+	if !ok {
+		slog.Warn("Ignoring production on unexpected topic", "topic", topic)
+		return
+	}
+
+	var req ProductionRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		slog.Warn("Invalid production payload", "topic", topic, "error", err)
+		return
+	}
+
+	d, err := s.queries.GetDeviceBySerialNumber(ctx, serial)
+	if err != nil {
+		slog.Warn("Production from unknown device", "serialNumber", serial, "error", err)
+		return
+	}
+
+	if _, _, err := s.Ingest(ctx, &d, req, time.Now()); err != nil {
+		slog.Warn("Failed to ingest production", "device.id", d.ID, "error", err)
+	}
+}
@@ -0,0 +1,18 @@
+package solar
+
+import "strings"
+
+// ProductionSubscription receives the measurements inverters publish, in the
+// same JSON format as the production endpoint.
+const ProductionSubscription = "devices/+/production"
+
+// serialFromProductionTopic extracts the serial number from
+// devices/{serial}/production.
+func serialFromProductionTopic(topic string) (string, bool) {
+	parts := strings.Split(topic, "/")
+	if len(parts) != 3 || parts[0] != "devices" || parts[2] != "production" {
+		return "", false
+	}
+
+	return parts[1], parts[1] != ""
+}
@@ -0,0 +1,287 @@
+package solar
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/pkg/pvforecast"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	WeatherSourceOpenMeteo = "openmeteo"
+	WeatherSourceFile      = "file"
+
+	openMeteoURL             = "https://api.open-meteo.com/v1/forecast"
+	openMeteoTimeLayout      = "2006-01-02T15:04"
+	weatherFile              = "weather.csv"
+	defaultWeatherResolution = time.Hour
+	httpTimeout              = 30 * time.Second
+)
+
+var ErrNoWeather = errors.New("no weather forecast available")
+
+// WeatherProvider supplies weather forecasts for a location in [from, to),
+// ordered by start.
+type WeatherProvider interface {
+	Name() string
+	Fetch(ctx context.Context, loc pvforecast.Location, from, to time.Time) ([]pvforecast.Weather, error)
+}
+
+func NewWeatherProvider(cfg *config.Weather) (WeatherProvider, error) {
+	switch cfg.Source {
+	case WeatherSourceOpenMeteo:
+		return NewOpenMeteoProvider(openMeteoURL), nil
+	case WeatherSourceFile:
+		return NewFileProvider(cfg.Directory), nil
+	}
+
+	return nil, fmt.Errorf("unknown weather source %q", cfg.Source)
+}
+
+// OpenMeteoProvider downloads hourly forecasts from the Open-Meteo API, which
+// needs no key for non-commercial use.
+type OpenMeteoProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewOpenMeteoProvider(baseURL string) *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		client:  &http.Client{Timeout: httpTimeout},
+		baseURL: baseURL,
+	}
+}
+
+func (p *OpenMeteoProvider) Name() string {
+	return WeatherSourceOpenMeteo
+}
+
+type openMeteoResponse struct {
+	Hourly struct {
+		Time               []string   `json:"time"`
+		ShortwaveRadiation []*float64 `json:"shortwave_radiation"`
+		CloudCover         []*float64 `json:"cloud_cover"`
+		Temperature        []*float64 `json:"temperature_2m"`
+	} `json:"hourly"`
+}
+
+func (p *OpenMeteoProvider) Fetch(ctx context.Context, loc pvforecast.Location, from, to time.Time) ([]pvforecast.Weather, error) {
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(loc.Latitude, 'f', 4, 64))
+	q.Set("longitude", strconv.FormatFloat(loc.Longitude, 'f', 4, 64))
+	q.Set("hourly", "shortwave_radiation,cloud_cover,temperature_2m")
+	q.Set("timezone", "GMT")
+	q.Set("start_date", from.UTC().Format(time.DateOnly))
+	q.Set("end_date", to.UTC().Format(time.DateOnly))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Open-Meteo responded with status %d", res.StatusCode)
+	}
+
+	var body openMeteoResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("could not decode Open-Meteo response: %w", err)
+	}
+
+	h := body.Hourly
+	weather := make([]pvforecast.Weather, 0, len(h.Time))
+	for i, v := range h.Time {
+		end, err := time.Parse(openMeteoTimeLayout, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Open-Meteo time %q", v)
+		}
+
+		// Radiation is the average of the preceding hour, the hour is
+		// labelled by its end.
+		w := pvforecast.Weather{Start: end.Add(-time.Hour), Resolution: time.Hour}
+		if i < len(h.ShortwaveRadiation) {
+			w.GhiWm2 = h.ShortwaveRadiation[i]
+		}
+		if i < len(h.CloudCover) && h.CloudCover[i] != nil {
+			c := *h.CloudCover[i] / 100
+			w.CloudCover = &c
+		}
+		if i < len(h.Temperature) {
+			w.TemperatureC = h.Temperature[i]
+		}
+		weather = append(weather, w)
+	}
+
+	return within(weather, from, to), nil
+}
+
+// FileProvider reads forecasts from a weather.csv in a directory, for
+// development and tests without network access.
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) Name() string {
+	return WeatherSourceFile
+}
+
+func (p *FileProvider) Fetch(_ context.Context, loc pvforecast.Location, from, to time.Time) ([]pvforecast.Weather, error) {
+	f, err := os.Open(filepath.Join(p.dir, weatherFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoWeather
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	weather, err := ParseWeatherCSV(f, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return within(weather, from, to), nil
+}
+
+// ParseWeatherCSV reads forecasts from a CSV file with a header row. The start
+// column holds RFC 3339 timestamps, the optional resolution_minutes column
+// defaults to 60. The ghi_wm2, cloud_cover_pct and temperature_c columns are
+// optional and may be left empty. When latitude and longitude columns are
+// present only the rows of the location nearest to loc are returned.
+func ParseWeatherCSV(r io.Reader, loc pvforecast.Location) ([]pvforecast.Weather, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	startCol, ok := cols["start"]
+	if !ok {
+		return nil, errors.New("CSV is missing the start column")
+	}
+	latCol, hasLat := cols["latitude"]
+	lonCol, hasLon := cols["longitude"]
+	located := hasLat && hasLon
+
+	type row struct {
+		distance float64
+		weather  pvforecast.Weather
+	}
+	var rows []row
+	nearest := math.Inf(1)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		start, err := time.Parse(time.RFC3339, record[startCol])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: start must be an RFC 3339 timestamp", line)
+		}
+
+		w := pvforecast.Weather{Start: start.UTC(), Resolution: defaultWeatherResolution}
+		if col, ok := cols["resolution_minutes"]; ok && record[col] != "" {
+			minutes, err := strconv.Atoi(record[col])
+			if err != nil || minutes <= 0 {
+				return nil, fmt.Errorf("line %d: resolution_minutes must be a positive integer", line)
+			}
+			w.Resolution = time.Duration(minutes) * time.Minute
+		}
+
+		fields := []struct {
+			name  string
+			dst   **float64
+			scale float64
+		}{
+			{"ghi_wm2", &w.GhiWm2, 1},
+			{"cloud_cover_pct", &w.CloudCover, 0.01},
+			{"temperature_c", &w.TemperatureC, 1},
+		}
+		for _, field := range fields {
+			col, ok := cols[field.name]
+			if !ok || record[col] == "" {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(record[col], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s must be a number", line, field.name)
+			}
+			v *= field.scale
+			*field.dst = &v
+		}
+
+		distance := 0.0
+		if located {
+			lat, latErr := strconv.ParseFloat(record[latCol], 64)
+			lon, lonErr := strconv.ParseFloat(record[lonCol], 64)
+			if latErr != nil || lonErr != nil {
+				return nil, fmt.Errorf("line %d: latitude and longitude must be numbers", line)
+			}
+			distance = math.Hypot(lat-loc.Latitude, (lon-loc.Longitude)*math.Cos(lat*math.Pi/180))
+		}
+
+		nearest = math.Min(nearest, distance)
+		rows = append(rows, row{distance: distance, weather: w})
+	}
+
+	var weather []pvforecast.Weather
+	for _, r := range rows {
+		if r.distance == nearest {
+			weather = append(weather, r.weather)
+		}
+	}
+	if len(weather) == 0 {
+		return nil, ErrNoWeather
+	}
+
+	slices.SortStableFunc(weather, func(a, b pvforecast.Weather) int {
+		return a.Start.Compare(b.Start)
+	})
+	return weather, nil
+}
+
+func within(weather []pvforecast.Weather, from, to time.Time) []pvforecast.Weather {
+	res := make([]pvforecast.Weather, 0, len(weather))
+	for _, w := range weather {
+		if w.Start.Add(w.Resolution).After(from) && w.Start.Before(to) {
+			res = append(res, w)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,178 @@
+package statement
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/google/uuid"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatPDF  = "pdf"
+)
+
+const minYear = 2000
+
+type ListStatementsRequest struct {
+	Year int
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseListStatementsRequest reads the year query parameter, defaulting to the
+// current year.
+func ParseListStatementsRequest(q url.Values, now time.Time) ListStatementsRequest {
+	req := ListStatementsRequest{Year: now.Year(), parseErrs: httpx.ValidationErrors{}}
+	if v := q.Get("year"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			req.parseErrs.Add("year", "Year must be a number")
+		}
+		req.Year = year
+	}
+
+	return req
+}
+
+func (r *ListStatementsRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 && r.Year < minYear {
+		errs.Add("year", "Year must be 2000 or later")
+	}
+
+	return errs
+}
+
+type GetStatementRequest struct {
+	Year   int
+	Month  time.Month
+	Format string
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseGetStatementRequest reads a month in YYYY-MM form and the format query
+// parameter, which defaults to JSON.
+func ParseGetStatementRequest(month string, q url.Values) GetStatementRequest {
+	req := GetStatementRequest{Format: q.Get("format"), parseErrs: httpx.ValidationErrors{}}
+	if req.Format == "" {
+		req.Format = FormatJSON
+	}
+
+	m, err := time.Parse("2006-01", month)
+	if err != nil {
+		req.parseErrs.Add("month", "Month must be in YYYY-MM form")
+	}
+	req.Year, req.Month = m.Year(), m.Month()
+
+	return req
+}
+
+func (r *GetStatementRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 && r.Year < minYear {
+		errs.Add("month", "Month must be in 2000 or later")
+	}
+
+	switch r.Format {
+	case FormatJSON, FormatCSV, FormatPDF:
+	default:
+		errs.Add("format", "Format must be json, csv or pdf")
+	}
+
+	return errs
+}
+
+type LineResponse struct {
+	SessionID   uuid.UUID  `json:"sessionId"`
+	DeviceID    uuid.UUID  `json:"deviceId"`
+	VehicleID   *uuid.UUID `json:"vehicleId,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	StoppedAt   *time.Time `json:"stoppedAt,omitempty"`
+	ImportedKwh float64    `json:"importedKwh"`
+	ExportedKwh float64    `json:"exportedKwh"`
+	EnergyCost  float64    `json:"energyCost"`
+	Revenue     float64    `json:"revenue"`
+	Pending     bool       `json:"pending"`
+	// EmissionsKg and AvoidedEmissionsKg are left out until the carbon
+	// intensities of the session are known.
+	EmissionsKg        *float64 `json:"emissionsKg,omitempty"`
+	AvoidedEmissionsKg *float64 `json:"avoidedEmissionsKg,omitempty"`
+}
+
+type StatementResponse struct {
+	Month            string          `json:"month"`
+	From             time.Time       `json:"from"`
+	To               time.Time       `json:"to"`
+	Currency         string          `json:"currency"`
+	Sessions         int             `json:"sessions"`
+	PendingSessions  int             `json:"pendingSessions"`
+	ImportedKwh      float64         `json:"importedKwh"`
+	ExportedKwh      float64         `json:"exportedKwh"`
+	EnergyCost       float64         `json:"energyCost"`
+	DischargeRevenue float64         `json:"dischargeRevenue"`
+	PeakKw           float64         `json:"peakKw"`
+	FixedCost        float64         `json:"fixedCost"`
+	Total            float64         `json:"total"`
+	Lines            []*LineResponse `json:"lines,omitempty"`
+	// EmissionsKg is the CO2 of the sessions of the month, AvoidedEmissionsKg
+	// what smart charging saved compared to charging right after plugging
+	// in.
+	EmissionsKg        float64 `json:"emissionsKg"`
+	AvoidedEmissionsKg float64 `json:"avoidedEmissionsKg"`
+}
+
+// NewStatementResponse describes the statement, the individual sessions are
+// only included when withLines is set.
+func NewStatementResponse(st *Statement, withLines bool) *StatementResponse {
+	res := &StatementResponse{
+		Month:              st.Label(),
+		From:               st.From,
+		To:                 st.To,
+		Currency:           "EUR",
+		Sessions:           len(st.Lines),
+		PendingSessions:    st.Pending,
+		ImportedKwh:        st.ImportedKwh,
+		ExportedKwh:        st.ExportedKwh,
+		EnergyCost:         st.EnergyCost,
+		DischargeRevenue:   st.DischargeRevenue,
+		PeakKw:             st.PeakKw,
+		FixedCost:          st.FixedCost,
+		Total:              st.Total,
+		EmissionsKg:        st.EmissionsKg,
+		AvoidedEmissionsKg: st.AvoidedEmissionsKg,
+	}
+
+	if withLines {
+		res.Lines = make([]*LineResponse, 0, len(st.Lines))
+		for _, l := range st.Lines {
+			res.Lines = append(res.Lines, &LineResponse{
+				SessionID:          l.SessionID,
+				DeviceID:           l.DeviceID,
+				VehicleID:          l.VehicleID,
+				StartedAt:          l.StartedAt,
+				StoppedAt:          l.StoppedAt,
+				ImportedKwh:        l.ImportedKwh,
+				ExportedKwh:        l.ExportedKwh,
+				EnergyCost:         l.EnergyCost,
+				Revenue:            l.Revenue,
+				Pending:            l.Pending,
+				EmissionsKg:        l.EmissionsKg,
+				AvoidedEmissionsKg: l.AvoidedEmissionsKg,
+			})
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,68 @@
+package statement
+
+import (
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	statements, err := h.svc.List(ctx, identityID, ParseListStatementsRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	res := make([]*StatementResponse, 0, len(statements))
+	for _, st := range statements {
+		res = append(res, NewStatementResponse(st, false))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := ParseGetStatementRequest(chi.URLParam(r, "month"), r.URL.Query())
+	st, err := h.svc.Get(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("statement-%s.%s", st.Label(), req.Format)
+	switch req.Format {
+	case FormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		return WriteCSV(w, st)
+	case FormatPDF:
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		return WritePDF(w, st)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewStatementResponse(st, true))
+	return nil
+}
@@ -0,0 +1,162 @@
+package statement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/pkg/pdf"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	pdfMargin     = 50.0
+	pdfLineHeight = 14.0
+)
+
+// WriteCSV writes a row per session followed by the fixed charges and the
+// total. The first column tells the kind of row.
+func WriteCSV(w io.Writer, st *Statement) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"type", "session_id", "device_id", "vehicle_id", "started_at", "stopped_at", "imported_kwh", "exported_kwh", "cost_eur", "revenue_eur", "net_eur", "pending", "emissions_kg", "avoided_emissions_kg"}}
+
+	for _, l := range st.Lines {
+		vehicleID, stoppedAt := "", ""
+		if l.VehicleID != nil {
+			vehicleID = l.VehicleID.String()
+		}
+		if l.StoppedAt != nil {
+			stoppedAt = l.StoppedAt.Format(time.RFC3339)
+		}
+
+		rows = append(rows, []string{
+			"session", l.SessionID.String(), l.DeviceID.String(), vehicleID,
+			l.StartedAt.Format(time.RFC3339), stoppedAt,
+			number(l.ImportedKwh, 4), number(l.ExportedKwh, 4),
+			number(l.EnergyCost, 4), number(l.Revenue, 4), number(l.EnergyCost-l.Revenue, 4),
+			strconv.FormatBool(l.Pending), optional(l.EmissionsKg, 4), optional(l.AvoidedEmissionsKg, 4),
+		})
+	}
+
+	rows = append(rows,
+		[]string{"fixed", "", "", "", "", "", "", "", number(st.FixedCost, 2), "", number(st.FixedCost, 2), "", "", ""},
+		[]string{"total", "", "", "", st.From.Format(time.RFC3339), st.To.Format(time.RFC3339),
+			number(st.ImportedKwh, 2), number(st.ExportedKwh, 2),
+			number(st.EnergyCost+st.FixedCost, 2), number(st.DischargeRevenue, 2), number(st.Total, 2),
+			strconv.FormatBool(st.Pending > 0), number(st.EmissionsKg, 2), number(st.AvoidedEmissionsKg, 2)},
+	)
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+// WritePDF renders the statement as a printable A4 document, continuing the
+// session table on new pages as needed.
+func WritePDF(w io.Writer, st *Statement) error {
+	doc := pdf.New("Energy statement " + st.Label())
+	loc := st.From.Location()
+	y := pdf.PageHeight - pdfMargin
+	right := pdf.PageWidth - pdfMargin
+
+	line := func() {
+		y -= pdfLineHeight
+	}
+	summary := func(label, value string, font pdf.Font) {
+		doc.Text(pdfMargin, y, font, 10, label)
+		doc.TextRight(right, y, font, 10, value)
+		line()
+	}
+
+	doc.AddPage()
+	doc.Text(pdfMargin, y, pdf.Bold, 18, "Energy statement "+st.From.Format("January 2006"))
+	y -= 2 * pdfLineHeight
+	doc.Text(pdfMargin, y, pdf.Regular, 10, fmt.Sprintf("Period %s to %s (%s)",
+		st.From.Format("2 January 2006"), st.To.AddDate(0, 0, -1).Format("2 January 2006"), loc))
+	y -= 2 * pdfLineHeight
+
+	summary("Charged", number(st.ImportedKwh, 2)+" kWh", pdf.Regular)
+	summary("Discharged", number(st.ExportedKwh, 2)+" kWh", pdf.Regular)
+	summary("Peak import", number(st.PeakKw, 2)+" kW", pdf.Regular)
+	summary("Emissions", number(st.EmissionsKg, 2)+" kg CO2", pdf.Regular)
+	summary("Avoided emissions", number(st.AvoidedEmissionsKg, 2)+" kg CO2", pdf.Regular)
+	line()
+	summary("Energy cost", euro(st.EnergyCost), pdf.Regular)
+	summary("Fixed charges", euro(st.FixedCost), pdf.Regular)
+	summary("Discharge revenue", euro(-st.DischargeRevenue), pdf.Regular)
+	doc.Line(pdfMargin, right, y+pdfLineHeight-3)
+	summary("Total", euro(st.Total), pdf.Bold)
+	if st.Pending > 0 {
+		doc.Text(pdfMargin, y, pdf.Regular, 9, fmt.Sprintf("%d session(s) are not priced yet and not included in the total.", st.Pending))
+		line()
+	}
+	y -= pdfLineHeight
+
+	header := func() {
+		doc.Text(pdfMargin, y, pdf.Bold, 10, "Sessions")
+		line()
+		doc.Text(pdfMargin, y, pdf.Bold, 9, "Start")
+		doc.Text(pdfMargin+110, y, pdf.Bold, 9, "End")
+		doc.TextRight(right-180, y, pdf.Bold, 9, "kWh in")
+		doc.TextRight(right-120, y, pdf.Bold, 9, "kWh out")
+		doc.TextRight(right-60, y, pdf.Bold, 9, "Cost")
+		doc.TextRight(right, y, pdf.Bold, 9, "Revenue")
+		doc.Line(pdfMargin, right, y-4)
+		line()
+	}
+	header()
+
+	if len(st.Lines) == 0 {
+		doc.Text(pdfMargin, y, pdf.Regular, 9, "No charging sessions in this month.")
+	}
+	for _, l := range st.Lines {
+		if y < pdfMargin {
+			doc.AddPage()
+			y = pdf.PageHeight - pdfMargin
+			header()
+		}
+
+		end := "running"
+		if l.StoppedAt != nil {
+			end = l.StoppedAt.In(loc).Format("02-01 15:04")
+		}
+		cost, revenue := euro(l.EnergyCost), euro(l.Revenue)
+		if l.Pending {
+			cost, revenue = "pending", "pending"
+		}
+
+		doc.Text(pdfMargin, y, pdf.Regular, 9, l.StartedAt.In(loc).Format("02-01-2006 15:04"))
+		doc.Text(pdfMargin+110, y, pdf.Regular, 9, end)
+		doc.TextRight(right-180, y, pdf.Regular, 9, number(l.ImportedKwh, 2))
+		doc.TextRight(right-120, y, pdf.Regular, 9, number(l.ExportedKwh, 2))
+		doc.TextRight(right-60, y, pdf.Regular, 9, cost)
+		doc.TextRight(right, y, pdf.Regular, 9, revenue)
+		line()
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func number(v float64, decimals int) string {
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// optional formats a value that may not be known yet as an empty cell.
+func optional(v *float64, decimals int) string {
+	if v == nil {
+		return ""
+	}
+
+	return number(*v, decimals)
+}
+
+func euro(v float64) string {
+	if v < 0 {
+		return "-€ " + number(-v, 2)
+	}
+
+	return "€ " + number(v, 2)
+}
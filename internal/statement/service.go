@@ -0,0 +1,89 @@
+// Package statement produces the monthly overview of the charging costs and
+// discharging revenue of a user.
+package statement
+
+import (
+	"context"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/tariff"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Service struct {
+	queries *repository.Queries
+	tariffs *tariff.Service
+}
+
+func NewService(queries *repository.Queries, tariffs *tariff.Service) *Service {
+	return &Service{queries: queries, tariffs: tariffs}
+}
+
+// List returns the statements of the months of the year that have started.
+func (s *Service) List(ctx context.Context, identityID uuid.UUID, req ListStatementsRequest) ([]*Statement, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	t, err := s.tariffs.ForOwner(ctx, identityID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to load tariff", err)
+	}
+
+	now := time.Now().In(t.Location())
+	statements := []*Statement{}
+	for month := time.January; month <= time.December; month++ {
+		if from, _ := monthRange(req.Year, month, t.Location()); from.After(now) {
+			break
+		}
+
+		st, err := s.month(ctx, identityID, t, req.Year, month)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, st)
+	}
+
+	return statements, nil
+}
+
+func (s *Service) Get(ctx context.Context, identityID uuid.UUID, req GetStatementRequest) (*Statement, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	t, err := s.tariffs.ForOwner(ctx, identityID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to load tariff", err)
+	}
+
+	return s.month(ctx, identityID, t, req.Year, req.Month)
+}
+
+// month builds the statement with the tariff in effect when the month
+// started, t is the current tariff that tells in which timezone it did.
+func (s *Service) month(ctx context.Context, identityID uuid.UUID, current *tariff.Tariff, year int, month time.Month) (*Statement, error) {
+	start, _ := monthRange(year, month, current.Location())
+	t, err := s.tariffs.ForOwnerAt(ctx, identityID, start)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to load tariff", err)
+	}
+
+	from, to := monthRange(year, month, t.Location())
+	sessions, err := s.queries.ListChargingSessionsByOwnerId(ctx, repository.ListChargingSessionsByOwnerIdParams{
+		OwnerID:       identityID,
+		StartedFrom:   from,
+		StartedBefore: to,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve charging sessions", err)
+	}
+
+	st, err := build(year, month, t, sessions)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to build statement", err)
+	}
+
+	return st, nil
+}
@@ -0,0 +1,143 @@
+package statement
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/session"
+	"github.com/V2G-Minor-Fontys/server/internal/tariff"
+	"github.com/google/uuid"
+	"math"
+	"time"
+)
+
+// Statement summarises the sessions started in a calendar month of the
+// tariff's timezone. A session running past midnight at the end of the month
+// is billed in the month it started.
+type Statement struct {
+	Year  int
+	Month time.Month
+	From  time.Time
+	To    time.Time
+
+	Lines []Line
+
+	ImportedKwh      float64
+	ExportedKwh      float64
+	EnergyCost       float64
+	DischargeRevenue float64
+	PeakKw           float64
+	FixedCost        float64
+	Total            float64
+	// EmissionsKg and AvoidedEmissionsKg total the carbon footprint of the
+	// priced sessions whose carbon intensities are known, in kg CO2eq.
+	EmissionsKg        float64
+	AvoidedEmissionsKg float64
+	// Pending counts the sessions that are still running or whose prices are
+	// not known yet, they are not included in the totals.
+	Pending int
+}
+
+type Line struct {
+	SessionID   uuid.UUID
+	DeviceID    uuid.UUID
+	VehicleID   *uuid.UUID
+	StartedAt   time.Time
+	StoppedAt   *time.Time
+	ImportedKwh float64
+	ExportedKwh float64
+	EnergyCost  float64
+	Revenue     float64
+	Pending     bool
+	// EmissionsKg and AvoidedEmissionsKg are nil until the carbon
+	// intensities of the session are known.
+	EmissionsKg        *float64
+	AvoidedEmissionsKg *float64
+}
+
+// Label is the month in YYYY-MM form.
+func (s *Statement) Label() string {
+	return s.From.Format("2006-01")
+}
+
+func monthRange(year int, month time.Month, loc *time.Location) (time.Time, time.Time) {
+	from := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	return from, from.AddDate(0, 1, 0)
+}
+
+// build totals the sessions of the month. The import peak used for the
+// capacity charge is the highest import of all sessions together in a tariff
+// slot, expressed as average power. Sessions billed with the tariff of their
+// site did not draw through the owner's connection and are left out of it.
+func build(year int, month time.Month, t *tariff.Tariff, sessions []repository.ChargingSession) (*Statement, error) {
+	from, to := monthRange(year, month, t.Location())
+	st := &Statement{Year: year, Month: month, From: from, To: to, Lines: []Line{}}
+
+	importPerSlot := map[time.Time]float64{}
+	for i := range sessions {
+		cs := &sessions[i]
+		line := Line{
+			SessionID:   cs.ID,
+			DeviceID:    cs.DeviceID,
+			StartedAt:   cs.StartedAt,
+			ImportedKwh: cs.ImportedKwh,
+			ExportedKwh: cs.ExportedKwh,
+			EnergyCost:  cs.EnergyCost,
+			Revenue:     cs.Revenue,
+			Pending:     cs.Status != session.StatusCompleted || !cs.ComputedAt.Valid,
+		}
+		if cs.VehicleID.Valid {
+			id := uuid.UUID(cs.VehicleID.Bytes)
+			line.VehicleID = &id
+		}
+		if cs.StoppedAt.Valid {
+			line.StoppedAt = &cs.StoppedAt.Time
+		}
+		if e, ok := session.StoredEmissions(cs); ok && !line.Pending {
+			emitted, avoided := e.EmittedKg, e.AvoidedKg()
+			line.EmissionsKg, line.AvoidedEmissionsKg = &emitted, &avoided
+			st.EmissionsKg += emitted
+			st.AvoidedEmissionsKg += avoided
+		}
+		st.Lines = append(st.Lines, line)
+
+		if line.Pending {
+			st.Pending++
+			continue
+		}
+
+		st.ImportedKwh += cs.ImportedKwh
+		st.ExportedKwh += cs.ExportedKwh
+		st.EnergyCost += cs.EnergyCost
+		st.DischargeRevenue += cs.Revenue
+		if cs.SiteTariff {
+			continue
+		}
+
+		intervals, err := session.Breakdown(cs)
+		if err != nil {
+			return nil, err
+		}
+		for _, iv := range intervals {
+			importPerSlot[iv.Start] += iv.ImportKwh
+		}
+	}
+
+	hours := tariff.Resolution.Hours()
+	for _, kwh := range importPerSlot {
+		st.PeakKw = math.Max(st.PeakKw, kwh/hours)
+	}
+
+	st.ImportedKwh = round(st.ImportedKwh)
+	st.ExportedKwh = round(st.ExportedKwh)
+	st.EnergyCost = round(st.EnergyCost)
+	st.DischargeRevenue = round(st.DischargeRevenue)
+	st.PeakKw = round(st.PeakKw)
+	st.FixedCost = round(t.MonthlyFixedCost(st.PeakKw))
+	st.Total = round(st.EnergyCost + st.FixedCost - st.DischargeRevenue)
+	st.EmissionsKg = round(st.EmissionsKg)
+	st.AvoidedEmissionsKg = round(st.AvoidedEmissionsKg)
+	return st, nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
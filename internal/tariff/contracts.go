@@ -0,0 +1,220 @@
+package tariff
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"math"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultCurveRange = 48 * time.Hour
+	maxCurveRange     = 7 * 24 * time.Hour
+)
+
+var feedInKinds = map[string]bool{
+	FeedInNone:        true,
+	FeedInFixed:       true,
+	FeedInDynamic:     true,
+	FeedInNetMetering: true,
+}
+
+type TariffRequest struct {
+	Name       string     `json:"name,omitempty"`
+	Kind       string     `json:"kind,omitempty"`
+	Definition Definition `json:"definition"`
+}
+
+func (r *TariffRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.Name == "" {
+		errs.Add("name", "Name is required")
+	}
+
+	d := &r.Definition
+	switch r.Kind {
+	case KindFixed:
+		if d.EnergyPriceEurKwh < 0 {
+			errs.Add("definition.energyPriceEurKwh", "Energy price cannot be negative")
+		}
+	case KindTimeOfUse:
+		if len(d.Periods) == 0 {
+			errs.Add("definition.periods", "A time of use tariff needs at least one period")
+		}
+	case KindDynamic:
+		if d.WholesaleMultiplier < 0 {
+			errs.Add("definition.wholesaleMultiplier", "Wholesale multiplier cannot be negative")
+		}
+	default:
+		errs.Add("kind", "Kind must be fixed, time_of_use or dynamic")
+	}
+
+	if d.VatRate < 0 || d.VatRate > 1 {
+		errs.Add("definition.vatRate", "VAT rate must be between 0 and 1")
+	}
+	if d.EnergyTaxEurKwh < 0 {
+		errs.Add("definition.energyTaxEurKwh", "Energy tax cannot be negative")
+	}
+	if d.StandingChargeEurMonth < 0 {
+		errs.Add("definition.standingChargeEurMonth", "Standing charge cannot be negative")
+	}
+	if d.CapacityCharge != nil && (d.CapacityCharge.EurPerKwMonth < 0 || d.CapacityCharge.MinimumKw < 0) {
+		errs.Add("definition.capacityCharge", "Capacity charge and minimum cannot be negative")
+	}
+
+	if d.FeedIn.Kind == "" {
+		d.FeedIn.Kind = FeedInNone
+	}
+	if !feedInKinds[d.FeedIn.Kind] {
+		errs.Add("definition.feedIn.kind", "Feed-in kind must be none, fixed, dynamic or net_metering")
+	}
+
+	for _, p := range d.Periods {
+		for _, day := range p.Weekdays {
+			if day < 0 || day > 6 {
+				errs.Add("definition.periods", "Weekdays must be between 0 (Sunday) and 6")
+			}
+		}
+		if p.From == p.To {
+			errs.Add("definition.periods", "Period "+p.From+" must not start and end at the same time")
+		}
+	}
+
+	if len(errs) == 0 {
+		if _, err := New(r.Kind, r.Definition); err != nil {
+			errs.Add("definition", err.Error())
+		}
+	}
+
+	return errs
+}
+
+type AssignTariffRequest struct {
+	TariffID uuid.UUID `json:"tariffId"`
+}
+
+type TariffResponse struct {
+	ID         *uuid.UUID `json:"id,omitempty"`
+	Name       string     `json:"name"`
+	Kind       string     `json:"kind"`
+	Definition Definition `json:"definition"`
+	IsDefault  bool       `json:"isDefault"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
+
+func NewTariffResponse(t *repository.Tariff) (*TariffResponse, error) {
+	var def Definition
+	if err := json.Unmarshal(t.Definition, &def); err != nil {
+		return nil, err
+	}
+
+	return &TariffResponse{
+		ID:         &t.ID,
+		Name:       t.Name,
+		Kind:       t.Kind,
+		Definition: def,
+		CreatedAt:  &t.CreatedAt,
+		UpdatedAt:  &t.UpdatedAt,
+	}, nil
+}
+
+func NewDefaultTariffResponse() *TariffResponse {
+	return &TariffResponse{
+		Name:       "Default",
+		Kind:       KindDynamic,
+		Definition: DefaultDefinition,
+		IsDefault:  true,
+	}
+}
+
+type CurveRequest struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseCurveRequest reads the from and to query parameters, defaulting to the
+// start of the current UTC day and two days later.
+func ParseCurveRequest(q url.Values, now time.Time) CurveRequest {
+	req := CurveRequest{
+		From:      now.UTC().Truncate(24 * time.Hour),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	req.To = req.From.Add(defaultCurveRange)
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	return req
+}
+
+func (r *CurveRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxCurveRange {
+			errs.Add("to", "The requested range cannot exceed 7 days")
+		}
+	}
+
+	return errs
+}
+
+type SlotResponse struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	ImportPrice float64   `json:"importPrice"`
+	ExportPrice float64   `json:"exportPrice"`
+}
+
+type CurveResponse struct {
+	Currency string          `json:"currency"`
+	Unit     string          `json:"unit"`
+	Prices   []*SlotResponse `json:"prices"`
+}
+
+func NewCurveResponse(slots []Slot) *CurveResponse {
+	res := &CurveResponse{
+		Currency: "EUR",
+		Unit:     "kWh",
+		Prices:   make([]*SlotResponse, 0, len(slots)),
+	}
+
+	for _, s := range slots {
+		res.Prices = append(res.Prices, &SlotResponse{
+			Start:       s.Start,
+			End:         s.End,
+			ImportPrice: round(s.ImportPrice),
+			ExportPrice: round(s.ExportPrice),
+		})
+	}
+
+	return res
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1e5) / 1e5
+}
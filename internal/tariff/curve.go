@@ -0,0 +1,47 @@
+package tariff
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/price"
+	"time"
+)
+
+// Resolution of the effective price curves, matching the finest day-ahead
+// market resolution.
+const Resolution = 15 * time.Minute
+
+// Slot is the effective price in EUR/kWh, including taxes, of one interval.
+type Slot struct {
+	Start       time.Time
+	End         time.Time
+	ImportPrice float64
+	ExportPrice float64
+}
+
+// Curve computes the effective prices for [from, to) from ordered wholesale
+// points. When the tariff depends on the market the curve stops where the
+// known wholesale prices end.
+func (t *Tariff) Curve(wholesale []price.Point, from, to time.Time) []Slot {
+	var slots []Slot
+	i := 0
+	for start := from.UTC().Truncate(Resolution); start.Before(to); start = start.Add(Resolution) {
+		for i < len(wholesale) && !wholesale[i].End().After(start) {
+			i++
+		}
+
+		var eurKwh float64
+		if i < len(wholesale) && !wholesale[i].Start.After(start) {
+			eurKwh = wholesale[i].PriceEurMwh / 1000
+		} else if t.NeedsWholesale() {
+			break
+		}
+
+		slots = append(slots, Slot{
+			Start:       start,
+			End:         start.Add(Resolution),
+			ImportPrice: t.ImportPrice(start, eurKwh),
+			ExportPrice: t.ExportPrice(start, eurKwh),
+		})
+	}
+
+	return slots
+}
@@ -0,0 +1,44 @@
+package tariff
+
+import (
+	"context"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Forecaster offers the effective price curve of a vehicle to the scheduler.
+type Forecaster struct {
+	svc *Service
+}
+
+func NewForecaster(svc *Service) *Forecaster {
+	return &Forecaster{svc: svc}
+}
+
+func (f *Forecaster) Forecast(ctx context.Context, ownerID, vehicleID uuid.UUID, from, to time.Time) ([]scheduling.PricePoint, error) {
+	slots, err := f.svc.Curve(ctx, ownerID, vehicleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(slots) == 0 {
+		return nil, scheduling.ErrNoPriceForecast
+	}
+
+	points := make([]scheduling.PricePoint, 0, len(slots))
+	for _, s := range slots {
+		points = append(points, scheduling.PricePoint{
+			Start:       s.Start,
+			End:         s.End,
+			ImportPrice: s.ImportPrice,
+			ExportPrice: s.ExportPrice,
+		})
+	}
+
+	return points, nil
+}
+
+func (f *Forecaster) UpdatedAt(ctx context.Context, ownerID, vehicleID uuid.UUID) (time.Time, error) {
+	return f.svc.UpdatedAt(ctx, ownerID, vehicleID)
+}
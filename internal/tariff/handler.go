@@ -0,0 +1,301 @@
+package tariff
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req TariffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	t, err := h.svc.Create(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewTariffResponse(t)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, res)
+	return nil
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tariffs, err := h.svc.List(ctx, identityID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*TariffResponse, 0, len(tariffs))
+	for i := range tariffs {
+		t, err := NewTariffResponse(&tariffs[i])
+		if err != nil {
+			return err
+		}
+		res = append(res, t)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tariffID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	t, err := h.svc.GetOwned(ctx, identityID, tariffID)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewTariffResponse(t)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) UpdateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tariffID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req TariffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	t, err := h.svc.Update(ctx, identityID, tariffID, req)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewTariffResponse(t)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) DeleteHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tariffID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Delete(ctx, identityID, tariffID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) PricesHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tariffID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	slots, err := h.svc.Preview(ctx, identityID, tariffID, ParseCurveRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewCurveResponse(slots))
+	return nil
+}
+
+func (h *Handler) GetMineHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	t, err := h.svc.GetAssigned(ctx, identityID)
+	if err != nil {
+		return err
+	}
+
+	if t == nil {
+		httpx.ResponseWithJSON(w, http.StatusOK, NewDefaultTariffResponse())
+		return nil
+	}
+
+	res, err := NewTariffResponse(t)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) AssignHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req AssignTariffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	if err := h.svc.Assign(ctx, identityID, &req.TariffID); err != nil {
+		return err
+	}
+
+	return h.GetMineHandler(w, r)
+}
+
+func (h *Handler) UnassignHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Assign(ctx, identityID, nil); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) GetSiteHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	t, err := h.svc.GetSiteAssigned(ctx, identityID, siteID)
+	if err != nil {
+		return err
+	}
+
+	// Without a tariff of its own the site bills its members with theirs.
+	if t == nil {
+		return httpx.NotFound(ctx, "Site has no tariff assigned")
+	}
+
+	res, err := NewTariffResponse(t)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) AssignSiteHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req AssignTariffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	if err := h.svc.AssignToSite(ctx, identityID, siteID, &req.TariffID); err != nil {
+		return err
+	}
+
+	return h.GetSiteHandler(w, r)
+}
+
+func (h *Handler) UnassignSiteHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	siteID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.AssignToSite(ctx, identityID, siteID, nil); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
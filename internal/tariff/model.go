@@ -0,0 +1,225 @@
+package tariff
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	KindFixed     = "fixed"
+	KindTimeOfUse = "time_of_use"
+	KindDynamic   = "dynamic"
+)
+
+const (
+	FeedInNone        = "none"
+	FeedInFixed       = "fixed"
+	FeedInDynamic     = "dynamic"
+	FeedInNetMetering = "net_metering"
+)
+
+// Definition holds the components of a tariff. Prices are in EUR/kWh and
+// exclude VAT, which is applied on top of all components.
+type Definition struct {
+	// EnergyPriceEurKwh is the price of a fixed tariff.
+	EnergyPriceEurKwh float64 `json:"energyPriceEurKwh,omitempty"`
+	// Periods are the time of use windows in the tariff's timezone, moments
+	// outside all windows pay EnergyPriceEurKwh.
+	Periods  []Period `json:"periods,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+	// WholesaleMultiplier scales the day-ahead price of a dynamic tariff,
+	// zero means one.
+	WholesaleMultiplier    float64         `json:"wholesaleMultiplier,omitempty"`
+	SupplierMarkupEurKwh   float64         `json:"supplierMarkupEurKwh"`
+	EnergyTaxEurKwh        float64         `json:"energyTaxEurKwh"`
+	VatRate                float64         `json:"vatRate"`
+	FeedIn                 FeedIn          `json:"feedIn"`
+	CapacityCharge         *CapacityCharge `json:"capacityCharge,omitempty"`
+	StandingChargeEurMonth float64         `json:"standingChargeEurMonth,omitempty"`
+}
+
+// Period applies PriceEurKwh from From until To, both "HH:MM", on the given
+// weekdays (0 is Sunday) or every day when empty. From after To wraps past
+// midnight.
+type Period struct {
+	Weekdays    []int   `json:"weekdays,omitempty"`
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	PriceEurKwh float64 `json:"priceEurKwh"`
+}
+
+// FeedIn describes the compensation for energy delivered to the grid. Net
+// metering pays the full import price, dynamic pays the scaled day-ahead
+// price minus a fee.
+type FeedIn struct {
+	Kind                string  `json:"kind"`
+	PriceEurKwh         float64 `json:"priceEurKwh,omitempty"`
+	WholesaleMultiplier float64 `json:"wholesaleMultiplier,omitempty"`
+	FeeEurKwh           float64 `json:"feeEurKwh,omitempty"`
+}
+
+// CapacityCharge bills the monthly peak of grid import, with a minimum peak
+// that is always billed.
+type CapacityCharge struct {
+	EurPerKwMonth float64 `json:"eurPerKwMonth"`
+	MinimumKw     float64 `json:"minimumKw,omitempty"`
+}
+
+// DefaultDefinition approximates a Dutch dynamic contract in 2025 and is used
+// for users that have not assigned a tariff.
+var DefaultDefinition = Definition{
+	Timezone:             "Europe/Amsterdam",
+	WholesaleMultiplier:  1,
+	SupplierMarkupEurKwh: 0.02,
+	EnergyTaxEurKwh:      0.10154,
+	VatRate:              0.21,
+	FeedIn:               FeedIn{Kind: FeedInNetMetering},
+}
+
+// Tariff is a validated definition ready for price calculation.
+type Tariff struct {
+	Kind       string
+	Definition Definition
+	loc        *time.Location
+	periods    []window
+}
+
+type window struct {
+	weekdays map[time.Weekday]bool
+	from, to int
+	price    float64
+}
+
+func New(kind string, def Definition) (*Tariff, error) {
+	tz := def.Timezone
+	if tz == "" {
+		tz = DefaultDefinition.Timezone
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", tz)
+	}
+
+	t := &Tariff{Kind: kind, Definition: def, loc: loc}
+	for _, p := range def.Periods {
+		from, err := parseClock(p.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseClock(p.To)
+		if err != nil {
+			return nil, err
+		}
+
+		w := window{from: from, to: to, price: p.PriceEurKwh}
+		if len(p.Weekdays) > 0 {
+			w.weekdays = make(map[time.Weekday]bool, len(p.Weekdays))
+			for _, d := range p.Weekdays {
+				w.weekdays[time.Weekday(d)] = true
+			}
+		}
+		t.periods = append(t.periods, w)
+	}
+
+	return t, nil
+}
+
+// Location is the timezone the tariff's periods and billing months are in.
+func (t *Tariff) Location() *time.Location {
+	return t.loc
+}
+
+// NeedsWholesale reports whether prices depend on the day-ahead market.
+func (t *Tariff) NeedsWholesale() bool {
+	return t.Kind == KindDynamic || t.Definition.FeedIn.Kind == FeedInDynamic
+}
+
+// ImportPrice is the price paid per kWh taken from the grid at the given
+// moment, including taxes.
+func (t *Tariff) ImportPrice(at time.Time, wholesaleEurKwh float64) float64 {
+	d := t.Definition
+	var base float64
+	switch t.Kind {
+	case KindDynamic:
+		base = wholesaleEurKwh * multiplier(d.WholesaleMultiplier)
+	case KindTimeOfUse:
+		base = t.timeOfUsePrice(at)
+	default:
+		base = d.EnergyPriceEurKwh
+	}
+
+	return t.withVat(base + d.SupplierMarkupEurKwh + d.EnergyTaxEurKwh)
+}
+
+// ExportPrice is the compensation per kWh fed back at the given moment.
+func (t *Tariff) ExportPrice(at time.Time, wholesaleEurKwh float64) float64 {
+	f := t.Definition.FeedIn
+	switch f.Kind {
+	case FeedInNetMetering:
+		return t.ImportPrice(at, wholesaleEurKwh)
+	case FeedInFixed:
+		return f.PriceEurKwh
+	case FeedInDynamic:
+		return wholesaleEurKwh*multiplier(f.WholesaleMultiplier) - f.FeeEurKwh
+	}
+
+	return 0
+}
+
+// MonthlyFixedCost is the standing charge plus the capacity charge for the
+// given monthly import peak, including VAT.
+func (t *Tariff) MonthlyFixedCost(peakKw float64) float64 {
+	d := t.Definition
+	cost := d.StandingChargeEurMonth
+	if d.CapacityCharge != nil {
+		cost += math.Max(peakKw, d.CapacityCharge.MinimumKw) * d.CapacityCharge.EurPerKwMonth
+	}
+
+	return t.withVat(cost)
+}
+
+func (t *Tariff) withVat(v float64) float64 {
+	return v * (1 + t.Definition.VatRate)
+}
+
+func (t *Tariff) timeOfUsePrice(at time.Time) float64 {
+	local := at.In(t.loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, w := range t.periods {
+		if w.weekdays != nil && !w.weekdays[local.Weekday()] {
+			continue
+		}
+
+		inside := minute >= w.from && minute < w.to
+		if w.from > w.to {
+			inside = minute >= w.from || minute < w.to
+		}
+		if inside {
+			return w.price
+		}
+	}
+
+	return t.Definition.EnergyPriceEurKwh
+}
+
+func multiplier(m float64) float64 {
+	if m == 0 {
+		return 1
+	}
+
+	return m
+}
+
+// parseClock converts "HH:MM" into minutes since midnight, allowing "24:00".
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || len(s) != 5 ||
+		h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+
+	return h*60 + m, nil
+}
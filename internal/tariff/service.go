@@ -0,0 +1,576 @@
+// Package tariff turns wholesale prices into the prices a consumer actually
+// pays and receives, based on the tariff assigned to them or to the site the
+// energy is used at.
+package tariff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/price"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/site"
+	"github.com/V2G-Minor-Fontys/server/internal/user"
+	"github.com/V2G-Minor-Fontys/server/pkg/cache"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"time"
+)
+
+// kindNone marks a site version recording that the site's tariff was
+// removed, energy used there is billed with the tariff of the owner again.
+const kindNone = ""
+
+type Service struct {
+	db      *pgxpool.Pool
+	queries *repository.Queries
+	prices  *price.Service
+	sites   *site.Service
+	cache   *cache.Loader
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, prices *price.Service, sites *site.Service, c *cache.Loader) *Service {
+	return &Service{db: db, queries: queries, prices: prices, sites: sites, cache: c}
+}
+
+func (s *Service) Create(ctx context.Context, ownerID uuid.UUID, req TariffRequest) (*repository.Tariff, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	def, err := json.Marshal(req.Definition)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to encode tariff", err)
+	}
+
+	t, err := s.queries.CreateTariff(ctx, repository.CreateTariffParams{
+		ID:         uuid.New(),
+		OwnerID:    ownerID,
+		Name:       req.Name,
+		Kind:       req.Kind,
+		Definition: def,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to store tariff", err)
+	}
+
+	return &t, nil
+}
+
+func (s *Service) List(ctx context.Context, ownerID uuid.UUID) ([]repository.Tariff, error) {
+	tariffs, err := s.queries.ListTariffsByOwnerId(ctx, ownerID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve tariffs", err)
+	}
+
+	return tariffs, nil
+}
+
+func (s *Service) GetOwned(ctx context.Context, identityID, tariffID uuid.UUID) (*repository.Tariff, error) {
+	t, err := s.queries.GetTariffById(ctx, tariffID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Tariff could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve tariff", err)
+	}
+
+	if t.OwnerID != identityID {
+		return nil, httpx.NotFound(ctx, "Tariff could not be found")
+	}
+
+	return &t, nil
+}
+
+// Update changes the tariff. When it is assigned the change applies from now
+// on, sessions and months before keep the version they were priced with.
+func (s *Service) Update(ctx context.Context, identityID, tariffID uuid.UUID, req TariffRequest) (*repository.Tariff, error) {
+	if _, err := s.GetOwned(ctx, identityID, tariffID); err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	def, err := json.Marshal(req.Definition)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to encode tariff", err)
+	}
+
+	u, err := s.queries.GetUserById(ctx, identityID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve user", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	t, err := qtx.UpdateTariff(ctx, repository.UpdateTariffParams{
+		ID:         tariffID,
+		Name:       req.Name,
+		Kind:       req.Kind,
+		Definition: def,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to update tariff", err)
+	}
+
+	if err := s.addVersions(ctx, qtx, &u, &t, &t); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	return &t, nil
+}
+
+func (s *Service) Delete(ctx context.Context, identityID, tariffID uuid.UUID) error {
+	t, err := s.GetOwned(ctx, identityID, tariffID)
+	if err != nil {
+		return err
+	}
+
+	u, err := s.queries.GetUserById(ctx, identityID)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to retrieve user", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	// The assignments are cleared by the foreign key, the user is billed
+	// with the default tariff and the sites with their members' from now on.
+	qtx := s.queries.WithTx(tx)
+	if err := s.addVersions(ctx, qtx, &u, t, nil); err != nil {
+		return err
+	}
+
+	if err := qtx.DeleteTariff(ctx, tariffID); err != nil {
+		return httpx.InternalErr(ctx, "Failed to delete tariff", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	s.cache.Invalidate(ctx, user.CacheKey(identityID))
+	return nil
+}
+
+// Assign makes the tariff the one the user is billed with from now on, a nil
+// id reverts to the default tariff.
+func (s *Service) Assign(ctx context.Context, identityID uuid.UUID, tariffID *uuid.UUID) error {
+	params := repository.SetUserTariffParams{ID: identityID}
+	var row *repository.Tariff
+	if tariffID != nil {
+		t, err := s.GetOwned(ctx, identityID, *tariffID)
+		if err != nil {
+			return err
+		}
+		params.TariffID = pgtype.UUID{Bytes: t.ID, Valid: true}
+		row = t
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.SetUserTariff(ctx, params); err != nil {
+		return httpx.InternalErr(ctx, "Failed to assign tariff", err)
+	}
+
+	if err := addVersion(ctx, qtx, pgtype.UUID{Bytes: identityID, Valid: true}, pgtype.UUID{}, row, time.Now().UTC()); err != nil {
+		return httpx.InternalErr(ctx, "Failed to store tariff version", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	s.cache.Invalidate(ctx, user.CacheKey(identityID))
+	return nil
+}
+
+// AssignToSite makes the tariff the one energy used at the site is billed
+// with from now on, over the tariffs of its members. A nil id removes it.
+// Only site owners can assign, and only tariffs of their own.
+func (s *Service) AssignToSite(ctx context.Context, identityID, siteID uuid.UUID, tariffID *uuid.UUID) error {
+	if _, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleOwner); err != nil {
+		return err
+	}
+
+	params := repository.SetSiteTariffParams{ID: siteID}
+	var row *repository.Tariff
+	if tariffID != nil {
+		t, err := s.GetOwned(ctx, identityID, *tariffID)
+		if err != nil {
+			return err
+		}
+		params.TariffID = pgtype.UUID{Bytes: t.ID, Valid: true}
+		row = t
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.SetSiteTariff(ctx, params); err != nil {
+		return httpx.InternalErr(ctx, "Failed to assign tariff", err)
+	}
+
+	if err := addVersion(ctx, qtx, pgtype.UUID{}, pgtype.UUID{Bytes: siteID, Valid: true}, row, time.Now().UTC()); err != nil {
+		return httpx.InternalErr(ctx, "Failed to store tariff version", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	return nil
+}
+
+// GetSiteAssigned returns the tariff assigned to the site, or nil when its
+// members are billed with their own.
+func (s *Service) GetSiteAssigned(ctx context.Context, identityID, siteID uuid.UUID) (*repository.Tariff, error) {
+	st, _, err := s.sites.Authorize(ctx, identityID, siteID, site.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if !st.TariffID.Valid {
+		return nil, nil
+	}
+
+	t, err := s.queries.GetTariffById(ctx, uuid.UUID(st.TariffID.Bytes))
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve tariff", err)
+	}
+
+	return &t, nil
+}
+
+// addVersions starts a version for the owner and every site the tariff is
+// assigned to, with the changed tariff or nil when it is deleted.
+func (s *Service) addVersions(ctx context.Context, q *repository.Queries, u *repository.User, t, changed *repository.Tariff) error {
+	now := time.Now().UTC()
+	if u.TariffID.Valid && uuid.UUID(u.TariffID.Bytes) == t.ID {
+		if err := addVersion(ctx, q, pgtype.UUID{Bytes: u.ID, Valid: true}, pgtype.UUID{}, changed, now); err != nil {
+			return httpx.InternalErr(ctx, "Failed to store tariff version", err)
+		}
+	}
+
+	sites, err := q.ListSitesByTariffId(ctx, pgtype.UUID{Bytes: t.ID, Valid: true})
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to retrieve sites", err)
+	}
+
+	for i := range sites {
+		if err := addVersion(ctx, q, pgtype.UUID{}, pgtype.UUID{Bytes: sites[i].ID, Valid: true}, changed, now); err != nil {
+			return httpx.InternalErr(ctx, "Failed to store tariff version", err)
+		}
+	}
+
+	return nil
+}
+
+// GetAssigned returns the tariff assigned to the user, or nil when the user
+// is on the default tariff.
+func (s *Service) GetAssigned(ctx context.Context, identityID uuid.UUID) (*repository.Tariff, error) {
+	u, err := s.queries.GetUserById(ctx, identityID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "User could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve user", err)
+	}
+
+	if !u.TariffID.Valid {
+		return nil, nil
+	}
+
+	return s.GetOwned(ctx, identityID, uuid.UUID(u.TariffID.Bytes))
+}
+
+func (s *Service) Preview(ctx context.Context, identityID, tariffID uuid.UUID, req CurveRequest) ([]Slot, error) {
+	row, err := s.GetOwned(ctx, identityID, tariffID)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	t, err := fromRow(row)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to load tariff", err)
+	}
+
+	slots, err := s.curve(ctx, t, s.prices.DefaultZone(), req.From, req.To)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to calculate prices", err)
+	}
+
+	return slots, nil
+}
+
+// ForOwner loads the tariff the owner is billed with, falling back to the
+// default tariff.
+func (s *Service) ForOwner(ctx context.Context, ownerID uuid.UUID) (*Tariff, error) {
+	u, err := s.queries.GetUserById(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.TariffID.Valid {
+		return New(KindDynamic, DefaultDefinition)
+	}
+
+	row, err := s.queries.GetTariffById(ctx, uuid.UUID(u.TariffID.Bytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return fromRow(&row)
+}
+
+// ForOwnerAt loads the tariff the owner was billed with at the given moment,
+// so that past sessions and months are priced the same after the tariff was
+// changed or switched.
+func (s *Service) ForOwnerAt(ctx context.Context, ownerID uuid.UUID, at time.Time) (*Tariff, error) {
+	v, err := s.queries.GetTariffVersionAt(ctx, repository.GetTariffVersionAtParams{
+		UserID:    pgtype.UUID{Bytes: ownerID, Valid: true},
+		ValidFrom: at,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return New(KindDynamic, DefaultDefinition)
+		}
+
+		return nil, err
+	}
+
+	return fromVersion(&v)
+}
+
+// forSiteAt loads the tariff energy used at the site was billed with at the
+// given moment and reports whether it is the site's. A tariff assigned to the
+// site takes precedence over the owner's, which also applies when site is nil.
+func (s *Service) forSiteAt(ctx context.Context, ownerID uuid.UUID, site *repository.Site, at time.Time) (*Tariff, bool, error) {
+	if site != nil {
+		v, err := s.queries.GetSiteTariffVersionAt(ctx, repository.GetSiteTariffVersionAtParams{
+			SiteID:    pgtype.UUID{Bytes: site.ID, Valid: true},
+			ValidFrom: at,
+		})
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return nil, false, err
+		case v.Kind != kindNone:
+			t, err := fromVersion(&v)
+			return t, true, err
+		}
+	}
+
+	t, err := s.ForOwnerAt(ctx, ownerID, at)
+	return t, false, err
+}
+
+// Curve returns the effective prices the owner pays and receives in
+// [from, to) for charging the vehicle, at the site of its charger when it
+// has one.
+func (s *Service) Curve(ctx context.Context, ownerID, vehicleID uuid.UUID, from, to time.Time) ([]Slot, error) {
+	site, err := s.site(s.queries.GetSiteByVehicleId(ctx, vehicleID))
+	if err != nil {
+		return nil, err
+	}
+
+	t, _, err := s.forSiteAt(ctx, ownerID, site, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.curve(ctx, t, s.zone(site), from, to)
+}
+
+// CurveAt returns the prices in [from, to) of energy used by the charger
+// with the tariff that was billed at the given moment, and whether that was
+// the tariff of the charger's site rather than the owner's.
+func (s *Service) CurveAt(ctx context.Context, ownerID, deviceID uuid.UUID, at, from, to time.Time) ([]Slot, bool, error) {
+	site, err := s.site(s.queries.GetSiteByDeviceId(ctx, deviceID))
+	if err != nil {
+		return nil, false, err
+	}
+
+	t, siteTariff, err := s.forSiteAt(ctx, ownerID, site, at)
+	if err != nil {
+		return nil, false, err
+	}
+
+	slots, err := s.curve(ctx, t, s.zone(site), from, to)
+	return slots, siteTariff, err
+}
+
+func (s *Service) curve(ctx context.Context, t *Tariff, zone string, from, to time.Time) ([]Slot, error) {
+	// Start a day early so the interval running at from is included.
+	wholesale, err := s.prices.Range(ctx, zone, from.Add(-24*time.Hour), to)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Curve(wholesale, from, to), nil
+}
+
+// UpdatedAt reports the last moment the prices of charging the vehicle
+// changed, either through new market prices or a change of the tariff of the
+// owner or the site.
+func (s *Service) UpdatedAt(ctx context.Context, ownerID, vehicleID uuid.UUID) (time.Time, error) {
+	site, err := s.site(s.queries.GetSiteByVehicleId(ctx, vehicleID))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	updatedAt, err := s.prices.UpdatedAt(ctx, s.zone(site))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Every assignment and change of an assigned tariff starts a version.
+	now := time.Now()
+	v, err := s.queries.GetTariffVersionAt(ctx, repository.GetTariffVersionAtParams{
+		UserID:    pgtype.UUID{Bytes: ownerID, Valid: true},
+		ValidFrom: now,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, err
+	}
+	if err == nil && v.ValidFrom.After(updatedAt) {
+		updatedAt = v.ValidFrom
+	}
+
+	if site != nil {
+		v, err := s.queries.GetSiteTariffVersionAt(ctx, repository.GetSiteTariffVersionAtParams{
+			SiteID:    pgtype.UUID{Bytes: site.ID, Valid: true},
+			ValidFrom: now,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, err
+		}
+		if err == nil && v.ValidFrom.After(updatedAt) {
+			updatedAt = v.ValidFrom
+		}
+	}
+
+	return updatedAt, nil
+}
+
+// site turns a site lookup into nil when the device or vehicle is not on a
+// site.
+func (s *Service) site(site repository.Site, err error) (*repository.Site, error) {
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &site, nil
+}
+
+// zone is the bidding zone whose market prices apply at the site.
+func (s *Service) zone(site *repository.Site) string {
+	if site == nil || site.Zone == "" {
+		return s.prices.DefaultZone()
+	}
+
+	return site.Zone
+}
+
+func fromRow(row *repository.Tariff) (*Tariff, error) {
+	var def Definition
+	if err := json.Unmarshal(row.Definition, &def); err != nil {
+		return nil, err
+	}
+
+	return New(row.Kind, def)
+}
+
+func fromVersion(v *repository.TariffVersion) (*Tariff, error) {
+	var def Definition
+	if err := json.Unmarshal(v.Definition, &def); err != nil {
+		return nil, err
+	}
+
+	return New(v.Kind, def)
+}
+
+// addVersion records the tariff the user or site is billed with from the
+// given moment. A nil row is the default tariff for a user, for a site it
+// records that the site no longer has a tariff of its own.
+func addVersion(ctx context.Context, q *repository.Queries, userID, siteID pgtype.UUID, row *repository.Tariff, at time.Time) error {
+	params := repository.CreateTariffVersionParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		SiteID:    siteID,
+		Kind:      KindDynamic,
+		ValidFrom: at,
+	}
+	switch {
+	case row != nil:
+		params.TariffID = pgtype.UUID{Bytes: row.ID, Valid: true}
+		params.Kind, params.Definition = row.Kind, row.Definition
+	case siteID.Valid:
+		params.Kind, params.Definition = kindNone, []byte("null")
+	default:
+		def, err := json.Marshal(DefaultDefinition)
+		if err != nil {
+			return err
+		}
+		params.Definition = def
+	}
+
+	return q.CreateTariffVersion(ctx, params)
+}
@@ -0,0 +1,29 @@
+package user
+
+import (
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"time"
+)
+
+type UserResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Username  string     `json:"username"`
+	TariffID  *uuid.UUID `json:"tariffId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func NewUserResponse(u *repository.User) *UserResponse {
+	res := &UserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		CreatedAt: u.CreatedAt,
+	}
+
+	if u.TariffID.Valid {
+		id := uuid.UUID(u.TariffID.Bytes)
+		res.TariffID = &id
+	}
+
+	return res
+}
@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/cache"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type Service struct {
+	queries *repository.Queries
+	cache   *cache.Loader
+}
+
+func NewService(queries *repository.Queries, c *cache.Loader) *Service {
+	return &Service{queries: queries, cache: c}
+}
+
+func CacheKey(id uuid.UUID) string {
+	return "users:" + id.String()
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*repository.User, error) {
+	u, err := cache.GetOrLoad(ctx, s.cache, CacheKey(id), func(ctx context.Context) (repository.User, error) {
+		return s.queries.GetUserById(ctx, id)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "User could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve user", err)
+	}
+
+	return &u, nil
+}
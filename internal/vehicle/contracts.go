@@ -0,0 +1,240 @@
+package vehicle
+
+import (
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+const (
+	maxDischargeCyclesLimit = 10
+	maxWearCostPerKwh       = 1
+	maxPriority             = 10
+	defaultBoostMinutes     = 120
+	maxBoostMinutes         = 720
+)
+
+type CreateVehicleRequest struct {
+	Name               string     `json:"name,omitempty"`
+	BatteryCapacityKwh float64    `json:"batteryCapacityKwh"`
+	MaxChargeKw        float64    `json:"maxChargeKw"`
+	MaxDischargeKw     float64    `json:"maxDischargeKw"`
+	ChargerID          *uuid.UUID `json:"chargerId,omitempty"`
+}
+
+func (r *CreateVehicleRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.Name == "" {
+		errs.Add("name", "Name is required")
+	}
+	if r.BatteryCapacityKwh <= 0 {
+		errs.Add("batteryCapacityKwh", "Battery capacity must be positive")
+	}
+	if r.MaxChargeKw <= 0 {
+		errs.Add("maxChargeKw", "Maximum charge power must be positive")
+	}
+	if r.MaxDischargeKw < 0 {
+		errs.Add("maxDischargeKw", "Maximum discharge power cannot be negative")
+	}
+
+	return errs
+}
+
+func (r *CreateVehicleRequest) ToCreateVehicleParams(ownerID uuid.UUID) repository.CreateVehicleParams {
+	params := repository.CreateVehicleParams{
+		ID:                 uuid.New(),
+		OwnerID:            ownerID,
+		Name:               r.Name,
+		BatteryCapacityKwh: r.BatteryCapacityKwh,
+		MaxChargeKw:        r.MaxChargeKw,
+		MaxDischargeKw:     r.MaxDischargeKw,
+	}
+
+	if r.ChargerID != nil {
+		params.ChargerID = pgtype.UUID{Bytes: *r.ChargerID, Valid: true}
+	}
+
+	return params
+}
+
+type VehicleResponse struct {
+	ID                 uuid.UUID  `json:"id"`
+	Name               string     `json:"name"`
+	BatteryCapacityKwh float64    `json:"batteryCapacityKwh"`
+	MaxChargeKw        float64    `json:"maxChargeKw"`
+	MaxDischargeKw     float64    `json:"maxDischargeKw"`
+	ChargerID          *uuid.UUID `json:"chargerId,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+}
+
+func NewVehicleResponse(v *repository.Vehicle) *VehicleResponse {
+	res := &VehicleResponse{
+		ID:                 v.ID,
+		Name:               v.Name,
+		BatteryCapacityKwh: v.BatteryCapacityKwh,
+		MaxChargeKw:        v.MaxChargeKw,
+		MaxDischargeKw:     v.MaxDischargeKw,
+		CreatedAt:          v.CreatedAt,
+	}
+
+	if v.ChargerID.Valid {
+		id := uuid.UUID(v.ChargerID.Bytes)
+		res.ChargerID = &id
+	}
+
+	return res
+}
+
+// WeeklyDeparture is a recurring departure, weekday 0 is Sunday and time is
+// a local HH:MM in the preference timezone.
+type WeeklyDeparture struct {
+	Weekday int16  `json:"weekday"`
+	Time    string `json:"time"`
+}
+
+type PreferencesRequest struct {
+	TargetSoc          int16             `json:"targetSoc"`
+	MinSoc             int16             `json:"minSoc"`
+	MaxDischargeCycles int16             `json:"maxDischargeCycles"`
+	Priority           int16             `json:"priority"`
+	DepartureAt        *time.Time        `json:"departureAt,omitempty"`
+	WeeklyDepartures   []WeeklyDeparture `json:"weeklyDepartures,omitempty"`
+	Timezone           string            `json:"timezone,omitempty"`
+	// ChargingMode is cost (the default), solar, in which the vehicle
+	// charges from the PV surplus of its site on top of SolarMinKw, or
+	// greenest, in which it charges when grid energy emits the least.
+	ChargingMode string  `json:"chargingMode,omitempty"`
+	SolarMinKw   float64 `json:"solarMinKw"`
+	// FlexibilityOptOut keeps the vehicle out of flexibility activations.
+	FlexibilityOptOut bool `json:"flexibilityOptOut"`
+	// WearCostPerKwh is what discharging a kWh costs in battery wear, left
+	// out to have it estimated from the health of the battery.
+	WearCostPerKwh *float64 `json:"wearCostPerKwh,omitempty"`
+	// CarbonWeight weighs emissions against cost when planning in cost or
+	// solar mode, 0 plans the cheapest and 1 the greenest charging.
+	CarbonWeight float64 `json:"carbonWeight"`
+}
+
+func (r *PreferencesRequest) Validate(now time.Time) httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.TargetSoc < 1 || r.TargetSoc > 100 {
+		errs.Add("targetSoc", "Target state of charge must be between 1 and 100 percent")
+	}
+	if r.MinSoc < 0 || r.MinSoc > 100 {
+		errs.Add("minSoc", "Minimum state of charge must be between 0 and 100 percent")
+	} else if r.MinSoc > r.TargetSoc {
+		errs.Add("minSoc", "Minimum state of charge cannot exceed the target")
+	}
+	if r.MaxDischargeCycles < 0 || r.MaxDischargeCycles > maxDischargeCyclesLimit {
+		errs.Add("maxDischargeCycles", fmt.Sprintf("Discharge cycles per day must be between 0 and %d", maxDischargeCyclesLimit))
+	}
+	if r.Priority < 0 || r.Priority > maxPriority {
+		errs.Add("priority", fmt.Sprintf("Priority must be between 0 and %d", maxPriority))
+	}
+	if r.ChargingMode != ModeCost && r.ChargingMode != ModeSolar && r.ChargingMode != ModeGreenest {
+		errs.Add("chargingMode", fmt.Sprintf("Charging mode must be %s, %s or %s", ModeCost, ModeSolar, ModeGreenest))
+	}
+	if r.SolarMinKw < 0 {
+		errs.Add("solarMinKw", "Minimum solar charging power cannot be negative")
+	}
+	if r.WearCostPerKwh != nil && (*r.WearCostPerKwh < 0 || *r.WearCostPerKwh > maxWearCostPerKwh) {
+		errs.Add("wearCostPerKwh", fmt.Sprintf("Wear cost must be between 0 and %d per kWh", maxWearCostPerKwh))
+	}
+	if r.CarbonWeight < 0 || r.CarbonWeight > 1 {
+		errs.Add("carbonWeight", "Carbon weight must be between 0 and 1")
+	}
+	if r.DepartureAt != nil && !r.DepartureAt.After(now) {
+		errs.Add("departureAt", "Departure must be in the future")
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		errs.Add("timezone", "Timezone must be a valid IANA time zone name")
+	}
+
+	seen := make(map[int16]bool)
+	for i, w := range r.WeeklyDepartures {
+		field := fmt.Sprintf("weeklyDepartures[%d]", i)
+		if w.Weekday < 0 || w.Weekday > 6 {
+			errs.Add(field+".weekday", "Weekday must be between 0 (Sunday) and 6 (Saturday)")
+		} else if seen[w.Weekday] {
+			errs.Add(field+".weekday", "Only one departure per weekday is allowed")
+		}
+		seen[w.Weekday] = true
+
+		if _, err := parseMinuteOfDay(w.Time); err != nil {
+			errs.Add(field+".time", "Time must be formatted as HH:MM")
+		}
+	}
+
+	return errs
+}
+
+func parseMinuteOfDay(s string) (int16, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+
+	return int16(t.Hour()*60 + t.Minute()), nil
+}
+
+type BoostRequest struct {
+	DurationMinutes int `json:"durationMinutes,omitempty"`
+}
+
+type PreferencesResponse struct {
+	VehicleID          uuid.UUID         `json:"vehicleId"`
+	TargetSoc          float64           `json:"targetSoc"`
+	MinSoc             float64           `json:"minSoc"`
+	MaxDischargeCycles int               `json:"maxDischargeCycles"`
+	Priority           int               `json:"priority"`
+	ChargingMode       string            `json:"chargingMode"`
+	SolarMinKw         float64           `json:"solarMinKw"`
+	FlexibilityOptOut  bool              `json:"flexibilityOptOut"`
+	WearCostPerKwh     *float64          `json:"wearCostPerKwh,omitempty"`
+	CarbonWeight       float64           `json:"carbonWeight"`
+	DepartureAt        *time.Time        `json:"departureAt,omitempty"`
+	WeeklyDepartures   []WeeklyDeparture `json:"weeklyDepartures"`
+	Timezone           string            `json:"timezone"`
+	BoostUntil         *time.Time        `json:"boostUntil,omitempty"`
+	NextDepartureAt    *time.Time        `json:"nextDepartureAt,omitempty"`
+	UpdatedAt          time.Time         `json:"updatedAt"`
+}
+
+func NewPreferencesResponse(p *Preferences, now time.Time) *PreferencesResponse {
+	res := &PreferencesResponse{
+		VehicleID:          p.VehicleID,
+		TargetSoc:          p.TargetSoc,
+		MinSoc:             p.MinSoc,
+		MaxDischargeCycles: p.MaxDischargeCycles,
+		Priority:           p.Priority,
+		ChargingMode:       p.ChargingMode,
+		SolarMinKw:         p.SolarMinKw,
+		FlexibilityOptOut:  p.FlexibilityOptOut,
+		WearCostPerKwh:     p.WearCostPerKwh,
+		CarbonWeight:       p.CarbonWeight,
+		DepartureAt:        p.DepartureAt,
+		WeeklyDepartures:   make([]WeeklyDeparture, 0, len(p.Weekly)),
+		Timezone:           p.Location.String(),
+		UpdatedAt:          p.UpdatedAt,
+	}
+
+	for _, w := range p.Weekly {
+		res.WeeklyDepartures = append(res.WeeklyDepartures, WeeklyDeparture{
+			Weekday: w.Weekday,
+			Time:    fmt.Sprintf("%02d:%02d", w.MinuteOfDay/60, w.MinuteOfDay%60),
+		})
+	}
+
+	if p.Boosting(now) {
+		res.BoostUntil = p.BoostUntil
+	}
+
+	if next, ok := p.NextDeparture(now); ok {
+		res.NextDepartureAt = &next
+	}
+
+	return res
+}
@@ -0,0 +1,176 @@
+package vehicle
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req CreateVehicleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	v, err := h.svc.Create(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, NewVehicleResponse(v))
+	return nil
+}
+
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicles, err := h.svc.List(ctx, identityID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*VehicleResponse, 0, len(vehicles))
+	for i := range vehicles {
+		res = append(res, NewVehicleResponse(&vehicles[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	v, err := h.svc.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewVehicleResponse(v))
+	return nil
+}
+
+func (h *Handler) GetPreferencesHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	prefs, err := h.svc.GetPreferences(ctx, identityID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewPreferencesResponse(prefs, time.Now()))
+	return nil
+}
+
+func (h *Handler) PutPreferencesHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req PreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	prefs, err := h.svc.UpdatePreferences(ctx, identityID, vehicleID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewPreferencesResponse(prefs, time.Now()))
+	return nil
+}
+
+func (h *Handler) BoostHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req BoostRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return httpx.BadRequest(ctx, "Could not parse JSON body")
+		}
+	}
+
+	prefs, err := h.svc.Boost(ctx, identityID, vehicleID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewPreferencesResponse(prefs, time.Now()))
+	return nil
+}
+
+func (h *Handler) CancelBoostHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	prefs, err := h.svc.CancelBoost(ctx, identityID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewPreferencesResponse(prefs, time.Now()))
+	return nil
+}
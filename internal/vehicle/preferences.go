@@ -0,0 +1,117 @@
+package vehicle
+
+import (
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Charging modes of a vehicle. In cost mode the scheduler plans the cheapest
+// charging, in solar mode the vehicle absorbs the PV surplus of its site and
+// only charges from the grid to reach its minimum state of charge. In
+// greenest mode it charges when the grid emits the least, whatever the price.
+const (
+	ModeCost     = "cost"
+	ModeSolar    = "solar"
+	ModeGreenest = "greenest"
+)
+
+// Preferences is the decoded charging preference set of a vehicle as used by
+// the scheduling logic.
+type Preferences struct {
+	VehicleID          uuid.UUID
+	TargetSoc          float64
+	MinSoc             float64
+	MaxDischargeCycles int
+	Priority           int
+	ChargingMode       string
+	SolarMinKw         float64
+	DepartureAt        *time.Time
+	Weekly             []repository.VehicleWeeklyDeparture
+	Location           *time.Location
+	BoostUntil         *time.Time
+	FlexibilityOptOut  bool
+	// WearCostPerKwh overrides the battery wear cost per kWh discharged that
+	// is estimated from the health of the battery.
+	WearCostPerKwh *float64
+	// CarbonWeight mixes emissions into the cost the scheduler minimises,
+	// from 0 for only cost to 1 for only emissions.
+	CarbonWeight float64
+	UpdatedAt    time.Time
+}
+
+func NewPreferences(p *repository.VehiclePreference, weekly []repository.VehicleWeeklyDeparture) (*Preferences, error) {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", p.Timezone, err)
+	}
+
+	prefs := &Preferences{
+		VehicleID:          p.VehicleID,
+		TargetSoc:          float64(p.TargetSoc),
+		MinSoc:             float64(p.MinSoc),
+		MaxDischargeCycles: int(p.MaxDischargeCycles),
+		Priority:           int(p.Priority),
+		ChargingMode:       p.ChargingMode,
+		SolarMinKw:         p.SolarMinKw,
+		FlexibilityOptOut:  p.FlexibilityOptOut,
+		CarbonWeight:       p.CarbonWeight,
+		Weekly:             weekly,
+		Location:           loc,
+		UpdatedAt:          p.UpdatedAt,
+	}
+
+	if p.DepartureAt.Valid {
+		prefs.DepartureAt = &p.DepartureAt.Time
+	}
+
+	if p.BoostUntil.Valid {
+		prefs.BoostUntil = &p.BoostUntil.Time
+	}
+
+	if p.WearCostPerKwh.Valid {
+		prefs.WearCostPerKwh = &p.WearCostPerKwh.Float64
+	}
+
+	return prefs, nil
+}
+
+// NextDeparture returns the earliest upcoming departure, considering both the
+// one-off departure and the weekly recurring ones.
+func (p *Preferences) NextDeparture(now time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+
+	consider := func(t time.Time) {
+		if t.After(now) && (!found || t.Before(next)) {
+			next, found = t, true
+		}
+	}
+
+	if p.DepartureAt != nil {
+		consider(*p.DepartureAt)
+	}
+
+	local := now.In(p.Location)
+	for _, w := range p.Weekly {
+		for d := 0; d <= 7; d++ {
+			day := local.AddDate(0, 0, d)
+			if int16(day.Weekday()) != w.Weekday {
+				continue
+			}
+
+			t := time.Date(day.Year(), day.Month(), day.Day(), int(w.MinuteOfDay)/60, int(w.MinuteOfDay)%60, 0, 0, p.Location)
+			if t.After(now) {
+				consider(t)
+				break
+			}
+		}
+	}
+
+	return next, found
+}
+
+func (p *Preferences) Boosting(now time.Time) bool {
+	return p.BoostUntil != nil && p.BoostUntil.After(now)
+}
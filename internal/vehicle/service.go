@@ -0,0 +1,244 @@
+package vehicle
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/device"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"time"
+)
+
+const DefaultTimezone = "Europe/Amsterdam"
+
+type Service struct {
+	db      *pgxpool.Pool
+	queries *repository.Queries
+	devices *device.Service
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, devices *device.Service) *Service {
+	return &Service{db: db, queries: queries, devices: devices}
+}
+
+func (s *Service) Create(ctx context.Context, ownerID uuid.UUID, req CreateVehicleRequest) (*repository.Vehicle, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	if req.ChargerID != nil {
+		d, err := s.devices.GetOwned(ctx, ownerID, *req.ChargerID)
+		if err != nil {
+			return nil, err
+		}
+
+		if d.Kind != device.KindCharger {
+			return nil, httpx.ValidationFailed(ctx, httpx.ValidationErrors{"chargerId": "Device is not a charger"})
+		}
+	}
+
+	v, err := s.queries.CreateVehicle(ctx, req.ToCreateVehicleParams(ownerID))
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to create vehicle", err)
+	}
+
+	return &v, nil
+}
+
+func (s *Service) List(ctx context.Context, ownerID uuid.UUID) ([]repository.Vehicle, error) {
+	vehicles, err := s.queries.ListVehiclesByOwnerId(ctx, ownerID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve vehicles", err)
+	}
+
+	return vehicles, nil
+}
+
+func (s *Service) GetOwned(ctx context.Context, identityID, vehicleID uuid.UUID) (*repository.Vehicle, error) {
+	v, err := s.queries.GetVehicleById(ctx, vehicleID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "Vehicle could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve vehicle", err)
+	}
+
+	if v.OwnerID != identityID {
+		return nil, httpx.NotFound(ctx, "Vehicle could not be found")
+	}
+
+	return &v, nil
+}
+
+func (s *Service) GetPreferences(ctx context.Context, identityID, vehicleID uuid.UUID) (*Preferences, error) {
+	v, err := s.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := s.LoadPreferences(ctx, v.ID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve vehicle preferences", err)
+	}
+
+	return prefs, nil
+}
+
+// LoadPreferences returns the preferences of a vehicle without authorisation,
+// creating the defaults on first access.
+func (s *Service) LoadPreferences(ctx context.Context, vehicleID uuid.UUID) (*Preferences, error) {
+	return loadPreferences(ctx, s.queries, vehicleID)
+}
+
+func (s *Service) UpdatePreferences(ctx context.Context, identityID, vehicleID uuid.UUID, req PreferencesRequest) (*Preferences, error) {
+	v, err := s.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = DefaultTimezone
+	}
+	if req.ChargingMode == "" {
+		req.ChargingMode = ModeCost
+	}
+
+	if errs := req.Validate(time.Now()); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.EnsureVehiclePreferences(ctx, v.ID); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to initialise vehicle preferences", err)
+	}
+
+	params := repository.UpdateVehiclePreferencesParams{
+		VehicleID:          v.ID,
+		TargetSoc:          req.TargetSoc,
+		MinSoc:             req.MinSoc,
+		MaxDischargeCycles: req.MaxDischargeCycles,
+		Timezone:           req.Timezone,
+		Priority:           req.Priority,
+		ChargingMode:       req.ChargingMode,
+		SolarMinKw:         req.SolarMinKw,
+		FlexibilityOptOut:  req.FlexibilityOptOut,
+		CarbonWeight:       req.CarbonWeight,
+	}
+	if req.DepartureAt != nil {
+		params.DepartureAt = pgtype.Timestamptz{Time: req.DepartureAt.UTC(), Valid: true}
+	}
+	if req.WearCostPerKwh != nil {
+		params.WearCostPerKwh = pgtype.Float8{Float64: *req.WearCostPerKwh, Valid: true}
+	}
+
+	if _, err := qtx.UpdateVehiclePreferences(ctx, params); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to update vehicle preferences", err)
+	}
+
+	if err := qtx.DeleteVehicleWeeklyDepartures(ctx, v.ID); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to replace weekly departures", err)
+	}
+
+	for _, w := range req.WeeklyDepartures {
+		minute, _ := parseMinuteOfDay(w.Time)
+		if err := qtx.CreateVehicleWeeklyDeparture(ctx, repository.CreateVehicleWeeklyDepartureParams{
+			VehicleID:   v.ID,
+			Weekday:     w.Weekday,
+			MinuteOfDay: minute,
+		}); err != nil {
+			return nil, httpx.InternalErr(ctx, "Failed to store weekly departure", err)
+		}
+	}
+
+	prefs, err := loadPreferences(ctx, qtx, v.ID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve vehicle preferences", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	return prefs, nil
+}
+
+// Boost overrides the schedule and charges at full power for the given
+// duration, or until the boost is cancelled.
+func (s *Service) Boost(ctx context.Context, identityID, vehicleID uuid.UUID, req BoostRequest) (*Preferences, error) {
+	if req.DurationMinutes == 0 {
+		req.DurationMinutes = defaultBoostMinutes
+	}
+
+	if req.DurationMinutes < 1 || req.DurationMinutes > maxBoostMinutes {
+		return nil, httpx.ValidationFailed(ctx, httpx.ValidationErrors{
+			"durationMinutes": "Boost duration must be between 1 and 720 minutes",
+		})
+	}
+
+	until := time.Now().UTC().Add(time.Duration(req.DurationMinutes) * time.Minute)
+	return s.setBoost(ctx, identityID, vehicleID, pgtype.Timestamptz{Time: until, Valid: true})
+}
+
+func (s *Service) CancelBoost(ctx context.Context, identityID, vehicleID uuid.UUID) (*Preferences, error) {
+	return s.setBoost(ctx, identityID, vehicleID, pgtype.Timestamptz{})
+}
+
+func (s *Service) setBoost(ctx context.Context, identityID, vehicleID uuid.UUID, until pgtype.Timestamptz) (*Preferences, error) {
+	v, err := s.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.queries.EnsureVehiclePreferences(ctx, v.ID); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to initialise vehicle preferences", err)
+	}
+
+	if _, err := s.queries.SetVehicleBoost(ctx, repository.SetVehicleBoostParams{
+		VehicleID:  v.ID,
+		BoostUntil: until,
+	}); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to update boost charge", err)
+	}
+
+	prefs, err := s.LoadPreferences(ctx, v.ID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve vehicle preferences", err)
+	}
+
+	return prefs, nil
+}
+
+func loadPreferences(ctx context.Context, queries *repository.Queries, vehicleID uuid.UUID) (*Preferences, error) {
+	if err := queries.EnsureVehiclePreferences(ctx, vehicleID); err != nil {
+		return nil, err
+	}
+
+	p, err := queries.GetVehiclePreferences(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	weekly, err := queries.ListVehicleWeeklyDepartures(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPreferences(&p, weekly)
+}
@@ -0,0 +1,401 @@
+package ven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Client takes part in the programme of an aggregator's VTN as a single VEN
+// representing the whole fleet. It registers, polls for events, turns their
+// signals into fleet-level targets and reports the fleet's telemetry.
+type Client struct {
+	cfg   *config.Ven
+	oadr  *openadr.Client
+	store Store
+	fleet FleetSource
+	reg   *Registration
+	// synced is set once the reports are registered and the events requested
+	// after registering or starting.
+	synced  bool
+	reports []*reportRequest
+}
+
+func NewClient(cfg *config.Ven, store Store, fleet FleetSource) *Client {
+	return NewClientWithHTTP(cfg, store, fleet, &http.Client{Timeout: requestTimeout})
+}
+
+// NewClientWithHTTP uses httpClient to reach the VTN, such as the client of
+// an httptest server running an openadrtest.VTN.
+func NewClientWithHTTP(cfg *config.Ven, store Store, fleet FleetSource, httpClient *http.Client) *Client {
+	return &Client{
+		cfg:   cfg,
+		oadr:  openadr.NewClient(httpClient, cfg.VtnURL, cfg.Token),
+		store: store,
+		fleet: fleet,
+	}
+}
+
+// Run polls the VTN at the frequency it asked for until ctx is cancelled.
+// Nothing is done when no VTN is configured.
+func (c *Client) Run(ctx context.Context) {
+	if c.cfg.VtnURL == "" {
+		slog.InfoContext(ctx, "No VTN configured, not taking part as VEN")
+		return
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-timer.C:
+			if err := c.Step(ctx, now.UTC()); err != nil {
+				slog.ErrorContext(ctx, "Failed to exchange messages with VTN", "vtn.url", c.cfg.VtnURL, "error", err)
+			}
+			timer.Reset(c.PollFreq())
+		}
+	}
+}
+
+// PollFreq is the frequency the VTN asked for, or the configured one before
+// registering.
+func (c *Client) PollFreq() time.Duration {
+	freq := c.cfg.PollFreq
+	if c.reg != nil && c.reg.PollFreq > 0 {
+		freq = c.reg.PollFreq
+	}
+	if freq <= 0 {
+		freq = defaultPollFreq
+	}
+
+	return max(freq, minPollFreq)
+}
+
+// Step registers when needed, polls once and sends the telemetry that is
+// due. Run calls it at the poll frequency, tests may call it directly.
+func (c *Client) Step(ctx context.Context, now time.Time) error {
+	if c.reg == nil {
+		reg, err := c.store.Registration(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load registration: %w", err)
+		}
+		if reg == nil {
+			if reg, err = c.register(ctx); err != nil {
+				return err
+			}
+		}
+		c.reg, c.synced = reg, false
+	}
+
+	if !c.synced {
+		if err := c.registerReports(ctx, now); err != nil {
+			return err
+		}
+		if err := c.requestEvents(ctx, now); err != nil {
+			return err
+		}
+		c.synced = true
+	}
+
+	if err := c.poll(ctx, now); err != nil {
+		return err
+	}
+
+	return c.report(ctx, now)
+}
+
+// Registered reports whether the client holds a registration.
+func (c *Client) Registered() bool {
+	return c.reg != nil
+}
+
+// register queries the profiles of the VTN and registers for the 2.0b
+// profile over simple HTTP with the pull model.
+func (c *Client) register(ctx context.Context) (*Registration, error) {
+	res, err := c.oadr.Send(ctx, openadr.ServiceRegisterParty, &openadr.QueryRegistration{
+		SchemaVersion: openadr.SchemaVersion,
+		RequestID:     uuid.NewString(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registration: %w", err)
+	}
+	if res.CreatedPartyRegistration == nil {
+		return nil, unexpected(openadr.ServiceRegisterParty)
+	}
+	if !supportsSimpleHTTP(res.CreatedPartyRegistration.Profiles) {
+		return nil, errors.New("VTN does not offer the 2.0b profile over simple HTTP")
+	}
+
+	res, err = c.oadr.Send(ctx, openadr.ServiceRegisterParty, &openadr.CreatePartyRegistration{
+		SchemaVersion: openadr.SchemaVersion,
+		RequestID:     uuid.NewString(),
+		ProfileName:   openadr.ProfileName,
+		TransportName: openadr.TransportHTTP,
+		VenName:       c.cfg.VenName,
+		HTTPPullModel: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register: %w", err)
+	}
+
+	created := res.CreatedPartyRegistration
+	if created == nil {
+		return nil, unexpected(openadr.ServiceRegisterParty)
+	}
+	if created.EiResponse.ResponseCode != openadr.CodeOK {
+		return nil, fmt.Errorf("VTN refused registration: %d %s", created.EiResponse.ResponseCode, created.EiResponse.ResponseDescription)
+	}
+
+	reg := &Registration{
+		VtnID:          created.VtnID,
+		VenID:          created.VenID,
+		RegistrationID: created.RegistrationID,
+		PollFreq:       time.Duration(created.PollFreq.Value),
+	}
+	if err := c.store.SaveRegistration(ctx, reg); err != nil {
+		return nil, fmt.Errorf("failed to store registration: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Registered with VTN", "vtn.id", reg.VtnID, "ven.id", reg.VenID)
+	return reg, nil
+}
+
+func supportsSimpleHTTP(profiles []openadr.Profile) bool {
+	// A VTN that lists no profiles is assumed to support the only one this
+	// client speaks.
+	if len(profiles) == 0 {
+		return true
+	}
+
+	for _, p := range profiles {
+		if p.Name == openadr.ProfileName && slices.ContainsFunc(p.Transports, func(t openadr.Transport) bool {
+			return t.Name == openadr.TransportHTTP
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forget drops the registration after the VTN no longer knows it, the next
+// step registers again.
+func (c *Client) forget(ctx context.Context) error {
+	c.reg, c.synced, c.reports = nil, false, nil
+	if err := c.store.DeleteRegistration(ctx); err != nil {
+		return fmt.Errorf("failed to delete registration: %w", err)
+	}
+
+	return ErrNotRegistered
+}
+
+// check turns a failed response into an error, dropping the registration
+// when the VTN says it has none.
+func (c *Client) check(ctx context.Context, service string, res openadr.EiResponse) error {
+	switch res.ResponseCode {
+	case openadr.CodeOK:
+		return nil
+	case openadr.CodeNotRegistered:
+		return c.forget(ctx)
+	}
+
+	return fmt.Errorf("%s failed: %d %s", service, res.ResponseCode, res.ResponseDescription)
+}
+
+func (c *Client) requestEvents(ctx context.Context, now time.Time) error {
+	res, err := c.oadr.Send(ctx, openadr.ServiceEvent, &openadr.RequestEvent{
+		SchemaVersion: openadr.SchemaVersion,
+		EiRequestEvent: openadr.EiRequestEvent{
+			RequestID: uuid.NewString(),
+			VenID:     c.reg.VenID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to request events: %w", err)
+	}
+
+	switch {
+	case res.DistributeEvent != nil:
+		return c.distributeEvent(ctx, res.DistributeEvent, now)
+	case res.Response != nil:
+		return c.check(ctx, openadr.ServiceEvent, res.Response.EiResponse)
+	}
+
+	return unexpected(openadr.ServiceEvent)
+}
+
+// poll asks the VTN for pending messages. Only events and the cancellation
+// of the registration are acted upon, other messages are not supported.
+func (c *Client) poll(ctx context.Context, now time.Time) error {
+	res, err := c.oadr.Send(ctx, openadr.ServicePoll, &openadr.Poll{
+		SchemaVersion: openadr.SchemaVersion,
+		VenID:         c.reg.VenID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to poll: %w", err)
+	}
+
+	switch {
+	case res.DistributeEvent != nil:
+		return c.distributeEvent(ctx, res.DistributeEvent, now)
+	case res.CancelPartyRegistration != nil:
+		return c.cancelledRegistration(ctx, res.CancelPartyRegistration)
+	case res.Response != nil:
+		return c.check(ctx, openadr.ServicePoll, res.Response.EiResponse)
+	}
+
+	return unexpected(openadr.ServicePoll)
+}
+
+// cancelledRegistration acknowledges that the VTN cancelled the
+// registration.
+func (c *Client) cancelledRegistration(ctx context.Context, m *openadr.CancelPartyRegistration) error {
+	if _, err := c.oadr.Send(ctx, openadr.ServiceRegisterParty, &openadr.CanceledPartyRegistration{
+		SchemaVersion:  openadr.SchemaVersion,
+		EiResponse:     openadr.OK(m.RequestID),
+		RegistrationID: m.RegistrationID,
+		VenID:          c.reg.VenID,
+	}); err != nil {
+		slog.WarnContext(ctx, "Failed to acknowledge cancelled registration", "error", err)
+	}
+
+	slog.InfoContext(ctx, "VTN cancelled the registration", "vtn.id", c.reg.VtnID)
+	return c.forget(ctx)
+}
+
+// distributeEvent stores the events of the VTN and their targets. The VTN
+// always sends the complete set, known events that are missing have been
+// removed and are cancelled. New events and new modifications are answered
+// with an opt when the VTN requires a response.
+func (c *Client) distributeEvent(ctx context.Context, m *openadr.DistributeEvent, now time.Time) error {
+	if m.EiResponse != nil {
+		if err := c.check(ctx, openadr.ServiceEvent, *m.EiResponse); err != nil {
+			return err
+		}
+	}
+
+	known, err := c.store.Events(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to load events: %w", err)
+	}
+	previous := make(map[string]Event, len(known))
+	for _, e := range known {
+		previous[e.ID] = e
+	}
+
+	fleet, err := c.fleet.Fleet(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to describe fleet: %w", err)
+	}
+
+	var responses []openadr.EventResponse
+	received := make(map[string]bool, len(m.Events))
+	for _, oe := range m.Events {
+		ei := &oe.EiEvent
+		if !c.targeted(ei) {
+			continue
+		}
+		received[ei.Descriptor.EventID] = true
+
+		prev, seen := previous[ei.Descriptor.EventID]
+		if seen && prev.ModificationNumber == ei.Descriptor.ModificationNumber && prev.Status == ei.Descriptor.EventStatus {
+			continue
+		}
+
+		optType, err := c.storeEvent(ctx, ei, fleet)
+		if err != nil {
+			return err
+		}
+
+		changed := !seen || prev.ModificationNumber != ei.Descriptor.ModificationNumber
+		if changed && oe.ResponseRequired != openadr.ResponseRequiredNever {
+			responses = append(responses, openadr.EventResponse{
+				ResponseCode:        openadr.CodeOK,
+				ResponseDescription: "OK",
+				RequestID:           m.RequestID,
+				QualifiedEventID: openadr.QualifiedEventID{
+					EventID:            ei.Descriptor.EventID,
+					ModificationNumber: ei.Descriptor.ModificationNumber,
+				},
+				OptType: optType,
+			})
+		}
+	}
+
+	for _, e := range known {
+		if !received[e.ID] && e.Status != openadr.StatusCancelled {
+			if err := c.store.CancelEvent(ctx, e.ID); err != nil {
+				return fmt.Errorf("failed to cancel event %s: %w", e.ID, err)
+			}
+			slog.InfoContext(ctx, "VTN removed event", "event.id", e.ID)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil
+	}
+
+	return c.createdEvent(ctx, m.RequestID, responses)
+}
+
+// targeted reports whether the event is meant for this VEN, events without
+// VEN IDs target every VEN of the VTN.
+func (c *Client) targeted(e *openadr.EiEvent) bool {
+	return len(e.Target.VenIDs) == 0 || slices.Contains(e.Target.VenIDs, c.reg.VenID)
+}
+
+// storeEvent saves the event with its targets and returns the opt. The
+// server opts in to every event with at least one supported signal, test
+// and cancelled events are acknowledged without targets.
+func (c *Client) storeEvent(ctx context.Context, e *openadr.EiEvent, fleet Fleet) (string, error) {
+	targets := Targets(e, fleet)
+	optType := openadr.OptIn
+	if len(targets) == 0 && e.Descriptor.EventStatus != openadr.StatusCancelled {
+		optType = openadr.OptOut
+	}
+
+	ev := newEvent(e, targets, optType)
+	if ev.TestEvent || ev.Status == openadr.StatusCancelled {
+		targets = nil
+	}
+
+	if err := c.store.SaveEvent(ctx, ev, targets); err != nil {
+		return "", fmt.Errorf("failed to store event %s: %w", ev.ID, err)
+	}
+
+	slog.InfoContext(ctx, "Received event from VTN", "event.id", ev.ID, "modification", ev.ModificationNumber, "status", ev.Status, "targets", len(targets), "opt", optType)
+	return optType, nil
+}
+
+func (c *Client) createdEvent(ctx context.Context, requestID string, responses []openadr.EventResponse) error {
+	res, err := c.oadr.Send(ctx, openadr.ServiceEvent, &openadr.CreatedEvent{
+		SchemaVersion: openadr.SchemaVersion,
+		EiCreatedEvent: openadr.EiCreatedEvent{
+			EiResponse:     openadr.OK(requestID),
+			EventResponses: openadr.EventResponses{Responses: responses},
+			VenID:          c.reg.VenID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to respond to events: %w", err)
+	}
+	if res.Response == nil {
+		return unexpected(openadr.ServiceEvent)
+	}
+
+	return c.check(ctx, openadr.ServiceEvent, res.Response.EiResponse)
+}
+
+func unexpected(service string) error {
+	return fmt.Errorf("VTN sent an unexpected answer to %s", service)
+}
@@ -0,0 +1,260 @@
+package ven
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr/openadrtest"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// memStore keeps what the client learned in memory.
+type memStore struct {
+	reg     *Registration
+	events  map[string]Event
+	targets map[string][]Target
+}
+
+func newMemStore() *memStore {
+	return &memStore{events: map[string]Event{}, targets: map[string][]Target{}}
+}
+
+func (s *memStore) Registration(context.Context) (*Registration, error) {
+	return s.reg, nil
+}
+
+func (s *memStore) SaveRegistration(_ context.Context, r *Registration) error {
+	s.reg = r
+	return nil
+}
+
+func (s *memStore) DeleteRegistration(context.Context) error {
+	s.reg = nil
+	return nil
+}
+
+func (s *memStore) Events(_ context.Context, endsAfter time.Time) ([]Event, error) {
+	var res []Event
+	for _, e := range s.events {
+		if e.End.After(endsAfter) {
+			res = append(res, e)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *memStore) SaveEvent(_ context.Context, e *Event, targets []Target) error {
+	s.events[e.ID] = *e
+	s.targets[e.ID] = targets
+	return nil
+}
+
+func (s *memStore) CancelEvent(_ context.Context, eventID string) error {
+	e := s.events[eventID]
+	e.Status = openadr.StatusCancelled
+	s.events[eventID] = e
+	delete(s.targets, eventID)
+	return nil
+}
+
+// fleet has 10 vehicles that take 100 kW together, the telemetry at each
+// minute after now is the minute as power and ten times it as energy.
+type fleet struct{}
+
+func (fleet) Fleet(context.Context) (Fleet, error) {
+	return Fleet{Vehicles: 10, MaxChargeKw: 100, MaxDischargeKw: 50}, nil
+}
+
+func (fleet) Telemetry(_ context.Context, at time.Time) (Telemetry, error) {
+	m := at.Sub(now).Minutes()
+	return Telemetry{PowerKw: m, EnergyKwh: 10 * m}, nil
+}
+
+func newTestClient(t *testing.T) (*Client, *openadrtest.VTN, *memStore) {
+	t.Helper()
+
+	vtn := openadrtest.NewVTN("test-vtn")
+	vtn.Token = "secret"
+	srv := httptest.NewServer(vtn)
+	t.Cleanup(srv.Close)
+
+	store := newMemStore()
+	cfg := &config.Ven{VtnURL: srv.URL, Token: vtn.Token, VenName: "test-ven"}
+	return NewClientWithHTTP(cfg, store, fleet{}, srv.Client()), vtn, store
+}
+
+func TestClientRegisters(t *testing.T) {
+	ctx := context.Background()
+	c, vtn, store := newTestClient(t)
+
+	if err := c.Step(ctx, now); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+
+	want := &Registration{VtnID: "test-vtn", VenID: "ven-1", RegistrationID: "reg-1", PollFreq: 10 * time.Second}
+	if !reflect.DeepEqual(store.reg, want) {
+		t.Errorf("registration = %+v, want %+v", store.reg, want)
+	}
+	if c.PollFreq() != 10*time.Second {
+		t.Errorf("poll frequency = %v, want the 10s of the VTN", c.PollFreq())
+	}
+
+	var specifiers []string
+	for _, r := range vtn.ReportRequests() {
+		specifiers = append(specifiers, r.Specifier.ReportSpecifierID)
+	}
+	if !reflect.DeepEqual(specifiers, []string{specifierTelemetryUsage, specifierTelemetryStatus}) {
+		t.Errorf("requested reports = %v, want usage and status", specifiers)
+	}
+
+	// The VTN forgets the VEN, which registers again on the step after.
+	vtn.Deregister()
+	if err := c.Step(ctx, now.Add(10*time.Second)); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("Step after deregistering = %v, want %v", err, ErrNotRegistered)
+	}
+	if store.reg != nil {
+		t.Errorf("registration = %+v, want it deleted", store.reg)
+	}
+	if err := c.Step(ctx, now.Add(20*time.Second)); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	if vtn.Registrations() != 2 || store.reg == nil || store.reg.RegistrationID != "reg-2" {
+		t.Errorf("registrations = %d with %+v, want to be registered again", vtn.Registrations(), store.reg)
+	}
+}
+
+func TestClientMapsEventsToTargets(t *testing.T) {
+	ctx := context.Background()
+	c, vtn, store := newTestClient(t)
+	if err := c.Step(ctx, now); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+
+	start := now.Add(time.Hour)
+	vtn.SetEvents(
+		openadrtest.Event("simple", openadr.SignalSimple, start, 30*time.Minute, 1, 2, 0),
+		openadrtest.Event("dispatch", openadr.SignalLoadDispatch, start, time.Hour, -20),
+		openadrtest.Event("price", openadr.SignalElectricityPrice, start, time.Hour, 0.25),
+		openadrtest.Event("bid", "BID_LOAD", start, time.Hour, 1),
+	)
+	if err := c.Step(ctx, now.Add(10*time.Second)); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	want := map[string][]Target{
+		// Level 0 is normal operation and sets no target.
+		"simple": {
+			{EventID: "simple", Kind: TargetMaxImport, Start: at(0), End: at(30 * time.Minute), Value: 50},
+			{EventID: "simple", Kind: TargetMaxImport, Start: at(30 * time.Minute), End: at(time.Hour), Value: 0},
+		},
+		"dispatch": {{EventID: "dispatch", Kind: TargetSetpoint, Start: at(0), End: at(time.Hour), Value: -20}},
+		"price":    {{EventID: "price", Kind: TargetPrice, Start: at(0), End: at(time.Hour), Value: 0.25}},
+		"bid":      nil,
+	}
+	if !reflect.DeepEqual(store.targets, want) {
+		t.Errorf("targets = %+v, want %+v", store.targets, want)
+	}
+
+	opts := map[string]string{}
+	for _, r := range vtn.EventResponses() {
+		opts[r.QualifiedEventID.EventID] = r.OptType
+	}
+	wantOpts := map[string]string{"simple": openadr.OptIn, "dispatch": openadr.OptIn, "price": openadr.OptIn, "bid": openadr.OptOut}
+	if !reflect.DeepEqual(opts, wantOpts) {
+		t.Errorf("opts = %v, want %v", opts, wantOpts)
+	}
+
+	// Unchanged events are not answered again, missing ones are cancelled.
+	vtn.SetEvents(
+		openadrtest.Event("dispatch", openadr.SignalLoadDispatch, start, time.Hour, -20),
+		openadrtest.Event("price", openadr.SignalElectricityPrice, start, time.Hour, 0.25),
+		openadrtest.Event("bid", "BID_LOAD", start, time.Hour, 1),
+	)
+	if err := c.Step(ctx, now.Add(20*time.Second)); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	if n := len(vtn.EventResponses()); n != 4 {
+		t.Errorf("event responses = %d, want the 4 of the first delivery", n)
+	}
+	if e := store.events["simple"]; e.Status != openadr.StatusCancelled {
+		t.Errorf("status of removed event = %q, want %q", e.Status, openadr.StatusCancelled)
+	}
+	if _, ok := store.targets["simple"]; ok {
+		t.Errorf("targets of removed event are kept")
+	}
+}
+
+func TestClientReportsTelemetry(t *testing.T) {
+	ctx := context.Background()
+	c, vtn, _ := newTestClient(t)
+
+	// The VTN samples every minute and wants a report every five.
+	for m := range 5 {
+		if err := c.Step(ctx, now.Add(time.Duration(m)*time.Minute)); err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+	}
+	if n := len(vtn.Reports()); n != 0 {
+		t.Fatalf("reports = %d before the report back duration passed, want 0", n)
+	}
+
+	if err := c.Step(ctx, now.Add(5*time.Minute)); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+
+	reports := vtn.Reports()
+	if len(reports) != 2 {
+		t.Fatalf("reports = %d, want usage and status", len(reports))
+	}
+
+	requests := map[string]string{}
+	for _, r := range vtn.ReportRequests() {
+		requests[r.Specifier.ReportSpecifierID] = r.ReportRequestID
+	}
+
+	tests := []struct {
+		name      string
+		specifier string
+		rid       string
+		scale     float64
+	}{
+		{name: openadr.ReportTelemetryUsage, specifier: specifierTelemetryUsage, rid: RIDFleetPower, scale: 1},
+		{name: openadr.ReportTelemetryStatus, specifier: specifierTelemetryStatus, rid: RIDFleetEnergy, scale: 10},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := reports[i]
+			if r.ReportName != tt.name || r.ReportSpecifierID != tt.specifier || r.ReportRequestID != requests[tt.specifier] {
+				t.Errorf("report = %s for %s as %s, want %s for %s as %s", r.ReportName, r.ReportSpecifierID, r.ReportRequestID, tt.name, tt.specifier, requests[tt.specifier])
+			}
+			if r.Intervals == nil || len(r.Intervals.Intervals) != 6 {
+				t.Fatalf("intervals = %+v, want a sample for every minute", r.Intervals)
+			}
+
+			for m, in := range r.Intervals.Intervals {
+				if in.DtStart == nil || !in.DtStart.Value.Equal(now.Add(time.Duration(m)*time.Minute)) {
+					t.Errorf("interval %d starts at %+v, want minute %d", m, in.DtStart, m)
+				}
+				if len(in.ReportPayloads) != 1 || in.ReportPayloads[0].RID != tt.rid || in.ReportPayloads[0].PayloadFloat.Value != tt.scale*float64(m) {
+					t.Errorf("interval %d payloads = %+v, want %s of %v", m, in.ReportPayloads, tt.rid, tt.scale*float64(m))
+				}
+			}
+		})
+	}
+
+	// Sent samples are not reported again.
+	if err := c.Step(ctx, now.Add(6*time.Minute)); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	if n := len(vtn.Reports()); n != 2 {
+		t.Errorf("reports = %d, want no new ones before the next report back duration", n)
+	}
+}
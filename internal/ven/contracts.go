@@ -0,0 +1,110 @@
+package ven
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Target kinds. A max_import target caps what the fleet takes from the grid,
+// a setpoint asks the fleet to take exactly Value kW, negative values
+// discharge, and a price is what energy costs per kWh during the target.
+const (
+	TargetMaxImport = "max_import"
+	TargetSetpoint  = "setpoint"
+	TargetPrice     = "price"
+)
+
+// Data points of the telemetry reports the server offers the VTN.
+const (
+	RIDFleetPower  = "fleet_power"
+	RIDFleetEnergy = "fleet_energy"
+
+	specifierTelemetryUsage  = "TELEMETRY_USAGE"
+	specifierTelemetryStatus = "TELEMETRY_STATUS"
+)
+
+const (
+	defaultPollFreq    = 30 * time.Second
+	minPollFreq        = 5 * time.Second
+	defaultGranularity = time.Minute
+	requestTimeout     = 30 * time.Second
+	// readingStaleAfter ignores shadow readings older than this.
+	readingStaleAfter = 2 * time.Minute
+)
+
+var ErrNotRegistered = errors.New("VEN is not registered with the VTN")
+
+// simpleLevelShare is the share of the fleet's maximum charging power it
+// may still take at each SIMPLE level, like the VTN applies to its own
+// VENs. Level 0 is normal operation and sets no target.
+var simpleLevelShare = map[float64]float64{1: 0.5, 2: 0, 3: 0}
+
+// Registration is what the VTN assigned when the server registered.
+type Registration struct {
+	VtnID          string
+	VenID          string
+	RegistrationID string
+	PollFreq       time.Duration
+}
+
+// Event is an event as received from the VTN, with the opt the server
+// responded with.
+type Event struct {
+	ID                 string
+	ModificationNumber int
+	Priority           int
+	MarketContext      string
+	Status             string
+	Start              time.Time
+	End                time.Time
+	TestEvent          bool
+	OptType            string
+}
+
+// Target is a fleet-level flexibility target for one interval of an event
+// signal. ID is only set on targets loaded from the Store.
+type Target struct {
+	ID      uuid.UUID
+	EventID string
+	Kind    string
+	Start   time.Time
+	End     time.Time
+	Value   float64
+}
+
+// Fleet is what the vehicles the server represents can do together.
+type Fleet struct {
+	Vehicles       int
+	MaxChargeKw    float64
+	MaxDischargeKw float64
+}
+
+// Telemetry is a measurement of the fleet. PowerKw is negative while the
+// fleet discharges, EnergyKwh counts the vehicles with a known state of
+// charge.
+type Telemetry struct {
+	PowerKw   float64
+	EnergyKwh float64
+}
+
+// Store keeps what the client learned from the VTN.
+type Store interface {
+	// Registration returns nil when the server is not registered.
+	Registration(ctx context.Context) (*Registration, error)
+	SaveRegistration(ctx context.Context, r *Registration) error
+	DeleteRegistration(ctx context.Context) error
+	// Events returns the known events that end after the given time,
+	// including cancelled ones.
+	Events(ctx context.Context, endsAfter time.Time) ([]Event, error)
+	// SaveEvent stores the event and replaces its targets.
+	SaveEvent(ctx context.Context, e *Event, targets []Target) error
+	CancelEvent(ctx context.Context, eventID string) error
+}
+
+// FleetSource describes and measures the fleet.
+type FleetSource interface {
+	Fleet(ctx context.Context) (Fleet, error)
+	Telemetry(ctx context.Context, now time.Time) (Telemetry, error)
+}
@@ -0,0 +1,238 @@
+package ven
+
+import (
+	"context"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// maxSamples bounds the samples kept per report while the VTN cannot be
+// reached, the oldest are dropped first.
+const maxSamples = 1440
+
+// reportRequest is a report the VTN asked for. Samples are taken every
+// granularity and sent every reportBack.
+type reportRequest struct {
+	id          string
+	specifierID string
+	name        string
+	rids        []string
+	granularity time.Duration
+	reportBack  time.Duration
+	samples     []sample
+	nextSample  time.Time
+	nextReport  time.Time
+}
+
+type sample struct {
+	at        time.Time
+	telemetry Telemetry
+}
+
+// reportNames maps the specifiers the server offers to the names of the
+// reports sent for them.
+var reportNames = map[string]string{
+	specifierTelemetryUsage:  openadr.ReportTelemetryUsage,
+	specifierTelemetryStatus: openadr.ReportTelemetryStatus,
+}
+
+// registerReports offers the fleet's charging power and stored energy, and
+// keeps the requests the VTN answers with.
+func (c *Client) registerReports(ctx context.Context, now time.Time) error {
+	samplingRate := &openadr.SamplingRate{
+		MinPeriod: openadr.Duration(defaultGranularity),
+		MaxPeriod: openadr.Duration(time.Hour),
+	}
+
+	res, err := c.oadr.Send(ctx, openadr.ServiceReport, &openadr.RegisterReport{
+		SchemaVersion: openadr.SchemaVersion,
+		RequestID:     uuid.NewString(),
+		Reports: []openadr.Report{
+			{
+				Descriptions: []openadr.ReportDescription{{
+					RID:          RIDFleetPower,
+					ReportType:   openadr.ReportTypeUsage,
+					ReadingType:  openadr.ReadingTypeDirectRead,
+					SamplingRate: samplingRate,
+				}},
+				ReportRequestID:   "0",
+				ReportSpecifierID: specifierTelemetryUsage,
+				ReportName:        openadr.ReportMetadataTelemetryUsage,
+				CreatedDateTime:   now,
+			},
+			{
+				Descriptions: []openadr.ReportDescription{{
+					RID:          RIDFleetEnergy,
+					ReportType:   openadr.ReportTypeStorage,
+					ReadingType:  openadr.ReadingTypeDirectRead,
+					SamplingRate: samplingRate,
+				}},
+				ReportRequestID:   "0",
+				ReportSpecifierID: specifierTelemetryStatus,
+				ReportName:        openadr.ReportMetadataTelemetryStatus,
+				CreatedDateTime:   now,
+			},
+		},
+		VenID: c.reg.VenID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register reports: %w", err)
+	}
+
+	switch {
+	case res.RegisteredReport != nil:
+		if err := c.check(ctx, openadr.ServiceReport, res.RegisteredReport.EiResponse); err != nil {
+			return err
+		}
+		c.reports = newReportRequests(ctx, res.RegisteredReport.ReportRequests, now)
+		return nil
+	case res.Response != nil:
+		return c.check(ctx, openadr.ServiceReport, res.Response.EiResponse)
+	}
+
+	return unexpected(openadr.ServiceReport)
+}
+
+// newReportRequests keeps the requests for reports the server offered,
+// limited to the data points it knows.
+func newReportRequests(ctx context.Context, requests []openadr.ReportRequest, now time.Time) []*reportRequest {
+	var result []*reportRequest
+	for _, req := range requests {
+		spec := req.Specifier
+		name, ok := reportNames[spec.ReportSpecifierID]
+		if !ok {
+			slog.WarnContext(ctx, "VTN requested an unknown report", "specifier", spec.ReportSpecifierID)
+			continue
+		}
+
+		r := &reportRequest{
+			id:          req.ReportRequestID,
+			specifierID: spec.ReportSpecifierID,
+			name:        name,
+			granularity: time.Duration(spec.Granularity.Value),
+			reportBack:  time.Duration(spec.ReportBackDuration.Value),
+			nextSample:  now,
+		}
+		if r.granularity <= 0 {
+			r.granularity = defaultGranularity
+		}
+		if r.reportBack <= 0 {
+			r.reportBack = r.granularity
+		}
+		r.nextReport = now.Add(r.reportBack)
+
+		for _, p := range spec.Payloads {
+			if p.RID == RIDFleetPower || p.RID == RIDFleetEnergy {
+				r.rids = append(r.rids, p.RID)
+			}
+		}
+		if len(r.rids) > 0 {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// report samples the fleet for the requests whose next sample is due and
+// sends the reports whose report back duration has passed. Samples stay
+// buffered when the VTN cannot be reached.
+func (c *Client) report(ctx context.Context, now time.Time) error {
+	var telemetry *Telemetry
+	var due []*reportRequest
+	for _, r := range c.reports {
+		if !now.Before(r.nextSample) {
+			if telemetry == nil {
+				t, err := c.fleet.Telemetry(ctx, now)
+				if err != nil {
+					return fmt.Errorf("failed to measure fleet: %w", err)
+				}
+				telemetry = &t
+			}
+
+			r.samples = append(r.samples, sample{at: now, telemetry: *telemetry})
+			if len(r.samples) > maxSamples {
+				r.samples = slices.Delete(r.samples, 0, len(r.samples)-maxSamples)
+			}
+			r.nextSample = now.Truncate(r.granularity).Add(r.granularity)
+		}
+
+		if !now.Before(r.nextReport) && len(r.samples) > 0 {
+			due = append(due, r)
+		}
+	}
+
+	if len(due) == 0 {
+		return nil
+	}
+
+	m := &openadr.UpdateReport{
+		SchemaVersion: openadr.SchemaVersion,
+		RequestID:     uuid.NewString(),
+		VenID:         c.reg.VenID,
+	}
+	for _, r := range due {
+		m.Reports = append(m.Reports, r.report(now))
+	}
+
+	res, err := c.oadr.Send(ctx, openadr.ServiceReport, m)
+	if err != nil {
+		return fmt.Errorf("failed to send reports: %w", err)
+	}
+
+	switch {
+	case res.UpdatedReport != nil:
+		err = c.check(ctx, openadr.ServiceReport, res.UpdatedReport.EiResponse)
+	case res.Response != nil:
+		err = c.check(ctx, openadr.ServiceReport, res.Response.EiResponse)
+	default:
+		err = unexpected(openadr.ServiceReport)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, r := range due {
+		r.samples = nil
+		r.nextReport = now.Add(r.reportBack)
+	}
+
+	return nil
+}
+
+func (r *reportRequest) report(now time.Time) openadr.Report {
+	intervals := &openadr.Intervals{}
+	for _, s := range r.samples {
+		in := openadr.Interval{
+			DtStart:  &openadr.DateTime{Value: s.at.UTC()},
+			Duration: openadr.DurationValue{Value: openadr.Duration(r.granularity)},
+		}
+		for _, rid := range r.rids {
+			in.ReportPayloads = append(in.ReportPayloads, openadr.ReportPayload{
+				RID:          rid,
+				PayloadFloat: openadr.PayloadFloat{Value: s.telemetry.value(rid)},
+			})
+		}
+		intervals.Intervals = append(intervals.Intervals, in)
+	}
+
+	return openadr.Report{
+		Intervals:         intervals,
+		ReportRequestID:   r.id,
+		ReportSpecifierID: r.specifierID,
+		ReportName:        r.name,
+		CreatedDateTime:   now.UTC(),
+	}
+}
+
+func (t Telemetry) value(rid string) float64 {
+	if rid == RIDFleetEnergy {
+		return t.EnergyKwh
+	}
+
+	return t.PowerKw
+}
@@ -0,0 +1,248 @@
+// Package ven lets the server take part in an aggregator's demand response
+// programme as an OpenADR 2.0b VEN (Virtual End Node) that represents the
+// whole fleet. Events received from the VTN are turned into fleet-level
+// flexibility targets and the charging power and stored energy of the fleet
+// are reported back. The protocol is handled by Client, Service keeps its
+// state in the database and measures the fleet.
+package ven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/chargingprofile"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"time"
+)
+
+// Service stores the registration, events and targets of the configured
+// VTN, keyed by its URL so that a new VTN starts afresh.
+type Service struct {
+	db      *pgxpool.Pool
+	queries *repository.Queries
+	cfg     *config.Ven
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, cfg *config.Ven) *Service {
+	return &Service{db: db, queries: queries, cfg: cfg}
+}
+
+func (s *Service) Registration(ctx context.Context) (*Registration, error) {
+	r, err := s.queries.GetVenRegistration(ctx, s.cfg.VtnURL)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registration{
+		VtnID:          r.VtnID,
+		VenID:          r.VenID,
+		RegistrationID: r.RegistrationID,
+		PollFreq:       time.Duration(r.PollFreqSeconds) * time.Second,
+	}, nil
+}
+
+func (s *Service) SaveRegistration(ctx context.Context, r *Registration) error {
+	_, err := s.queries.UpsertVenRegistration(ctx, repository.UpsertVenRegistrationParams{
+		VtnUrl:          s.cfg.VtnURL,
+		VtnID:           r.VtnID,
+		VenID:           r.VenID,
+		RegistrationID:  r.RegistrationID,
+		PollFreqSeconds: int32(max(1, r.PollFreq/time.Second)),
+	})
+
+	return err
+}
+
+func (s *Service) DeleteRegistration(ctx context.Context) error {
+	return s.queries.DeleteVenRegistration(ctx, s.cfg.VtnURL)
+}
+
+func (s *Service) Events(ctx context.Context, endsAfter time.Time) ([]Event, error) {
+	events, err := s.queries.ListVenEvents(ctx, repository.ListVenEventsParams{
+		VtnUrl:    s.cfg.VtnURL,
+		EndsAfter: endsAfter,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Event, 0, len(events))
+	for _, e := range events {
+		result = append(result, Event{
+			ID:                 e.EventID,
+			ModificationNumber: int(e.ModificationNumber),
+			Priority:           int(e.Priority),
+			MarketContext:      e.MarketContext,
+			Status:             e.Status,
+			Start:              e.StartsAt,
+			End:                e.EndsAt,
+			TestEvent:          e.TestEvent,
+			OptType:            e.OptType,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *Service) SaveEvent(ctx context.Context, e *Event, targets []Target) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	if _, err := qtx.UpsertVenEvent(ctx, repository.UpsertVenEventParams{
+		VtnUrl:             s.cfg.VtnURL,
+		EventID:            e.ID,
+		ModificationNumber: int32(e.ModificationNumber),
+		Priority:           int32(e.Priority),
+		MarketContext:      e.MarketContext,
+		Status:             e.Status,
+		StartsAt:           e.Start,
+		EndsAt:             e.End,
+		TestEvent:          e.TestEvent,
+		OptType:            e.OptType,
+	}); err != nil {
+		return err
+	}
+
+	if err := qtx.DeleteFlexibilityTargetsByEvent(ctx, repository.DeleteFlexibilityTargetsByEventParams{
+		VtnUrl:  s.cfg.VtnURL,
+		EventID: e.ID,
+	}); err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		if err := qtx.CreateFlexibilityTarget(ctx, repository.CreateFlexibilityTargetParams{
+			ID:       uuid.New(),
+			VtnUrl:   s.cfg.VtnURL,
+			EventID:  e.ID,
+			Kind:     t.Kind,
+			StartsAt: t.Start,
+			EndsAt:   t.End,
+			Value:    t.Value,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *Service) CancelEvent(ctx context.Context, eventID string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.CancelVenEvent(ctx, repository.CancelVenEventParams{VtnUrl: s.cfg.VtnURL, EventID: eventID}); err != nil {
+		return err
+	}
+
+	if err := qtx.DeleteFlexibilityTargetsByEvent(ctx, repository.DeleteFlexibilityTargetsByEventParams{
+		VtnUrl:  s.cfg.VtnURL,
+		EventID: eventID,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Targets returns the fleet-level targets overlapping [from, to), ordered by
+// start.
+func (s *Service) Targets(ctx context.Context, from, to time.Time) ([]Target, error) {
+	targets, err := s.queries.ListFlexibilityTargets(ctx, repository.ListFlexibilityTargetsParams{
+		VtnUrl:       s.cfg.VtnURL,
+		EndsAfter:    from,
+		StartsBefore: to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Target, 0, len(targets))
+	for _, t := range targets {
+		result = append(result, Target{
+			ID:      t.ID,
+			EventID: t.EventID,
+			Kind:    t.Kind,
+			Start:   t.StartsAt,
+			End:     t.EndsAt,
+			Value:   t.Value,
+		})
+	}
+
+	return result, nil
+}
+
+// Fleet sums up the vehicles that have a charger.
+func (s *Service) Fleet(ctx context.Context) (Fleet, error) {
+	vehicles, err := s.queries.ListVehiclesWithCharger(ctx)
+	if err != nil {
+		return Fleet{}, err
+	}
+
+	var f Fleet
+	for _, v := range vehicles {
+		f.Vehicles++
+		f.MaxChargeKw += v.MaxChargeKw
+		f.MaxDischargeKw += v.MaxDischargeKw
+	}
+
+	return f, nil
+}
+
+// Telemetry sums up the power and state of charge the chargers of the fleet
+// report in their shadow, stale readings are left out.
+func (s *Service) Telemetry(ctx context.Context, now time.Time) (Telemetry, error) {
+	vehicles, err := s.queries.ListVehiclesWithCharger(ctx)
+	if err != nil {
+		return Telemetry{}, err
+	}
+
+	var t Telemetry
+	for _, v := range vehicles {
+		sh, err := s.queries.GetDeviceShadow(ctx, uuid.UUID(v.ChargerID.Bytes))
+		if err != nil || !sh.ReportedUpdatedAt.Valid || now.Sub(sh.ReportedUpdatedAt.Time) > readingStaleAfter {
+			continue
+		}
+
+		var reported map[string]any
+		if err := json.Unmarshal(sh.Reported, &reported); err != nil {
+			continue
+		}
+
+		if power, ok := reported[chargingprofile.PowerField].(float64); ok {
+			t.PowerKw += power
+		}
+		if soc, ok := reported[scheduling.SocField].(float64); ok && soc >= 0 && soc <= 100 {
+			t.EnergyKwh += soc / 100 * v.BatteryCapacityKwh
+		}
+	}
+
+	return t, nil
+}
@@ -0,0 +1,85 @@
+package ven
+
+import (
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"strings"
+	"time"
+)
+
+// Targets maps the signals of an event to fleet-level targets, one for each
+// signal interval. Intervals follow each other from the start of the event,
+// an interval without duration lasts until the end of the active period.
+//
+// SIMPLE levels cap the fleet's import at a share of its charging power,
+// LOAD_DISPATCH setpoints and ELECTRICITY_PRICE prices are taken as they
+// are. Other signals, and these signals with another type, are not
+// supported and yield no targets.
+func Targets(e *openadr.EiEvent, fleet Fleet) []Target {
+	var targets []Target
+	for _, s := range e.Signals {
+		start := e.ActivePeriod.Start()
+		for _, in := range s.Intervals.Intervals {
+			end := start.Add(time.Duration(in.Duration.Value))
+			if in.Duration.Value == 0 {
+				end = e.ActivePeriod.End()
+			}
+			if in.SignalPayload == nil || !end.After(start) {
+				start = end
+				continue
+			}
+
+			if t, ok := target(s.SignalName, s.SignalType, in.SignalPayload.PayloadFloat.Value, fleet); ok {
+				t.EventID = e.Descriptor.EventID
+				t.Start, t.End = start.UTC(), end.UTC()
+				targets = append(targets, t)
+			}
+			start = end
+		}
+	}
+
+	return targets
+}
+
+func target(signalName, signalType string, value float64, fleet Fleet) (Target, bool) {
+	switch {
+	case signalName == openadr.SignalSimple && signalType == openadr.SignalTypeLevel:
+		share, ok := simpleLevelShare[value]
+		if !ok {
+			return Target{}, false
+		}
+		return Target{Kind: TargetMaxImport, Value: share * fleet.MaxChargeKw}, true
+	case signalName == openadr.SignalLoadDispatch && signalType == openadr.SignalTypeSetpoint:
+		return Target{Kind: TargetSetpoint, Value: value}, true
+	case signalName == openadr.SignalElectricityPrice && signalType == openadr.SignalTypePrice:
+		return Target{Kind: TargetPrice, Value: value}, true
+	}
+
+	return Target{}, false
+}
+
+// newEvent describes a received event. Events without a duration last
+// until their last signal interval ends.
+func newEvent(e *openadr.EiEvent, targets []Target, optType string) *Event {
+	d := e.Descriptor
+	ev := &Event{
+		ID:                 d.EventID,
+		ModificationNumber: d.ModificationNumber,
+		Priority:           d.Priority,
+		MarketContext:      d.MarketContext.Value,
+		Status:             d.EventStatus,
+		Start:              e.ActivePeriod.Start().UTC(),
+		End:                e.ActivePeriod.End().UTC(),
+		TestEvent:          strings.EqualFold(d.TestEvent, "true"),
+		OptType:            optType,
+	}
+
+	if e.ActivePeriod.Properties.Duration.Value == 0 {
+		for _, t := range targets {
+			if t.End.After(ev.End) {
+				ev.End = t.End
+			}
+		}
+	}
+
+	return ev
+}
@@ -0,0 +1,326 @@
+package vtn
+
+import (
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math"
+	"net/url"
+	"time"
+)
+
+const (
+	// maxEventDuration bounds events so the ones still running can be found
+	// by their start.
+	maxEventDuration  = 24 * time.Hour
+	maxEventIntervals = 96
+	// nearWindow is how long before its start an event is near rather than
+	// far.
+	nearWindow         = time.Hour
+	maxSimpleLevel     = 3
+	defaultReportRange = 24 * time.Hour
+	maxReportRange     = 31 * 24 * time.Hour
+)
+
+type VenRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+func (r *VenRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if r.Name == "" {
+		errs.Add("name", "Name is required")
+	} else if len(r.Name) > 100 {
+		errs.Add("name", "Name cannot be longer than 100 characters")
+	}
+
+	return errs
+}
+
+type VenResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	RegistrationID *uuid.UUID `json:"registrationId"`
+	RegisteredAt   *time.Time `json:"registeredAt"`
+	LastSeenAt     *time.Time `json:"lastSeenAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	// Token authenticates the VEN as a bearer token, it is only returned
+	// when the VEN is created.
+	Token string `json:"token,omitempty"`
+}
+
+func NewVenResponse(v *repository.OpenadrVen, token string) *VenResponse {
+	res := &VenResponse{
+		ID:        v.ID,
+		Name:      v.Name,
+		CreatedAt: v.CreatedAt,
+		Token:     token,
+	}
+	if v.RegistrationID.Valid {
+		id := uuid.UUID(v.RegistrationID.Bytes)
+		res.RegistrationID = &id
+	}
+	res.RegisteredAt = timePtr(v.RegisteredAt)
+	res.LastSeenAt = timePtr(v.LastSeenAt)
+
+	return res
+}
+
+type EnrolmentResponse struct {
+	VehicleID uuid.UUID `json:"vehicleId"`
+	VenID     uuid.UUID `json:"venId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewEnrolmentResponse(e *repository.OpenadrVenVehicle) *EnrolmentResponse {
+	return &EnrolmentResponse{VehicleID: e.VehicleID, VenID: e.VenID, CreatedAt: e.CreatedAt}
+}
+
+// Interval is one step of an event signal, intervals follow each other from
+// the start of the event. Value is a SIMPLE level from 0 to 3, a price per
+// kWh or for LOAD_DISPATCH the power in kW all vehicles of a VEN may draw
+// together.
+type Interval struct {
+	DurationMinutes int     `json:"durationMinutes"`
+	Value           float64 `json:"value"`
+}
+
+type EventRequest struct {
+	VenIDs           []uuid.UUID `json:"venIds,omitempty"`
+	SignalName       string      `json:"signalName,omitempty"`
+	Start            time.Time   `json:"start"`
+	Intervals        []Interval  `json:"intervals,omitempty"`
+	Priority         int32       `json:"priority,omitempty"`
+	MarketContext    string      `json:"marketContext,omitempty"`
+	TestEvent        bool        `json:"testEvent,omitempty"`
+	ResponseRequired *bool       `json:"responseRequired,omitempty"`
+}
+
+// Validate checks the request, the response is required unless stated
+// otherwise.
+func (r *EventRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	if len(r.VenIDs) == 0 {
+		errs.Add("venIds", "At least one VEN must be targeted")
+	}
+
+	if r.Start.IsZero() {
+		errs.Add("start", "Start is required")
+	}
+
+	if r.Priority < 0 {
+		errs.Add("priority", "Priority cannot be negative")
+	}
+
+	if r.MarketContext == "" {
+		errs.Add("marketContext", "Market context is required")
+	} else if u, err := url.Parse(r.MarketContext); err != nil || !u.IsAbs() || len(r.MarketContext) > 255 {
+		errs.Add("marketContext", "Market context must be an absolute URI of at most 255 characters")
+	}
+
+	switch r.SignalName {
+	case openadr.SignalSimple, openadr.SignalElectricityPrice, openadr.SignalLoadDispatch:
+	default:
+		errs.Add("signalName", "Signal name must be SIMPLE, ELECTRICITY_PRICE or LOAD_DISPATCH")
+	}
+
+	if len(r.Intervals) == 0 || len(r.Intervals) > maxEventIntervals {
+		errs.Add("intervals", "An event must have between 1 and 96 intervals")
+	}
+
+	total := 0
+	for _, i := range r.Intervals {
+		if i.DurationMinutes <= 0 {
+			errs.Add("intervals", "Interval durations must be positive")
+			break
+		}
+		total += i.DurationMinutes
+	}
+	if time.Duration(total)*time.Minute > maxEventDuration {
+		errs.Add("intervals", "An event cannot last longer than 24 hours")
+	}
+
+	for _, i := range r.Intervals {
+		switch r.SignalName {
+		case openadr.SignalSimple:
+			if i.Value != math.Trunc(i.Value) || i.Value < 0 || i.Value > maxSimpleLevel {
+				errs.Add("intervals", "SIMPLE levels must be 0, 1, 2 or 3")
+			}
+		case openadr.SignalLoadDispatch:
+			if i.Value < 0 {
+				errs.Add("intervals", "Dispatched load cannot be negative")
+			}
+		}
+		if len(errs["intervals"]) > 0 {
+			break
+		}
+	}
+
+	if r.ResponseRequired == nil {
+		required := true
+		r.ResponseRequired = &required
+	}
+
+	return errs
+}
+
+type TargetResponse struct {
+	VenID       uuid.UUID  `json:"venId"`
+	OptType     *string    `json:"optType"`
+	RespondedAt *time.Time `json:"respondedAt"`
+}
+
+type EventResponse struct {
+	ID                 uuid.UUID        `json:"id"`
+	SignalName         string           `json:"signalName"`
+	Start              time.Time        `json:"start"`
+	End                time.Time        `json:"end"`
+	Status             string           `json:"status"`
+	ModificationNumber int32            `json:"modificationNumber"`
+	Priority           int32            `json:"priority"`
+	MarketContext      string           `json:"marketContext"`
+	Intervals          []Interval       `json:"intervals"`
+	TestEvent          bool             `json:"testEvent"`
+	ResponseRequired   bool             `json:"responseRequired"`
+	Targets            []TargetResponse `json:"targets,omitempty"`
+	CreatedAt          time.Time        `json:"createdAt"`
+	UpdatedAt          time.Time        `json:"updatedAt"`
+}
+
+func NewEventResponse(e *repository.OpenadrEvent, targets []repository.OpenadrEventTarget, now time.Time) (*EventResponse, error) {
+	intervals, err := decodeIntervals(e.Intervals)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &EventResponse{
+		ID:                 e.ID,
+		SignalName:         e.SignalName,
+		Start:              e.StartsAt,
+		End:                eventEnd(e.StartsAt, intervals),
+		Status:             eventStatus(e.StartsAt, intervals, e.Cancelled, now),
+		ModificationNumber: e.ModificationNumber,
+		Priority:           e.Priority,
+		MarketContext:      e.MarketContext,
+		Intervals:          intervals,
+		TestEvent:          e.TestEvent,
+		ResponseRequired:   e.ResponseRequired,
+		CreatedAt:          e.CreatedAt,
+		UpdatedAt:          e.UpdatedAt,
+	}
+
+	for _, t := range targets {
+		tr := TargetResponse{VenID: t.VenID, RespondedAt: timePtr(t.RespondedAt)}
+		if t.OptType.Valid {
+			tr.OptType = &t.OptType.String
+		}
+		res.Targets = append(res.Targets, tr)
+	}
+
+	return res, nil
+}
+
+type ReportRequest struct {
+	From time.Time
+	To   time.Time
+
+	parseErrs httpx.ValidationErrors
+}
+
+// ParseReportRequest reads the from and to query parameters, they default to
+// the last 24 hours.
+func ParseReportRequest(q url.Values, now time.Time) ReportRequest {
+	req := ReportRequest{
+		To:        now.UTC(),
+		parseErrs: httpx.ValidationErrors{},
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("to", "To must be an RFC 3339 timestamp")
+		}
+		req.To = to.UTC()
+	}
+
+	req.From = req.To.Add(-defaultReportRange)
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			req.parseErrs.Add("from", "From must be an RFC 3339 timestamp")
+		}
+		req.From = from.UTC()
+	}
+
+	return req
+}
+
+func (r *ReportRequest) Validate() httpx.ValidationErrors {
+	errs := httpx.ValidationErrors{}
+	for field, reason := range r.parseErrs {
+		errs.Add(field, reason)
+	}
+
+	if len(errs) == 0 {
+		if !r.To.After(r.From) {
+			errs.Add("to", "To must be after from")
+		} else if r.To.Sub(r.From) > maxReportRange {
+			errs.Add("to", "The requested range cannot exceed 31 days")
+		}
+	}
+
+	return errs
+}
+
+type ReportValueResponse struct {
+	RID        string    `json:"rId"`
+	MeasuredAt time.Time `json:"measuredAt"`
+	Value      float64   `json:"value"`
+}
+
+func NewReportValueResponse(v *repository.OpenadrReportValue) *ReportValueResponse {
+	return &ReportValueResponse{RID: v.RID, MeasuredAt: v.MeasuredAt, Value: v.Value}
+}
+
+func decodeIntervals(raw []byte) ([]Interval, error) {
+	var intervals []Interval
+	if err := json.Unmarshal(raw, &intervals); err != nil {
+		return nil, err
+	}
+
+	return intervals, nil
+}
+
+func eventEnd(start time.Time, intervals []Interval) time.Time {
+	for _, i := range intervals {
+		start = start.Add(time.Duration(i.DurationMinutes) * time.Minute)
+	}
+
+	return start
+}
+
+func eventStatus(start time.Time, intervals []Interval, cancelled bool, now time.Time) string {
+	switch {
+	case cancelled:
+		return openadr.StatusCancelled
+	case now.Before(start.Add(-nearWindow)):
+		return openadr.StatusFar
+	case now.Before(start):
+		return openadr.StatusNear
+	case now.Before(eventEnd(start, intervals)):
+		return openadr.StatusActive
+	}
+
+	return openadr.StatusCompleted
+}
+
+func timePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
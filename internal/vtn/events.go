@@ -0,0 +1,152 @@
+package vtn
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/scheduling"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"math"
+	"slices"
+	"time"
+)
+
+// simpleLevelShare is the share of its maximum charging power a vehicle
+// keeps at each SIMPLE level, moderate (1) halves it while high (2) and
+// special (3) stop charging from the grid.
+var simpleLevelShare = map[float64]float64{1: 0.5, 2: 0, 3: 0}
+
+// Events turns the events of the VEN the vehicle is enrolled with into
+// scheduling events. Cancelled and test events are ignored, as are events
+// and periods the VEN opted out of. A dispatched load is shared equally by
+// the enrolled vehicles.
+func (s *Service) Events(ctx context.Context, v *repository.Vehicle, from, to time.Time) ([]scheduling.Event, error) {
+	enrolment, err := s.queries.GetOpenadrVenVehicle(ctx, v.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.queries.ListOpenadrEventsByVenId(ctx, repository.ListOpenadrEventsByVenIdParams{
+		VenID:      enrolment.VenID,
+		StartsFrom: from.Add(-maxEventDuration),
+	})
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+
+	enrolled, err := s.queries.ListOpenadrVenVehicles(ctx, enrolment.VenID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := s.queries.ListOpenadrOptsByVenId(ctx, repository.ListOpenadrOptsByVenIdParams{
+		VenID:     enrolment.VenID,
+		EndsAfter: from,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Priority 1 is the highest, 0 means none and is the lowest.
+	slices.SortStableFunc(events, func(a, b repository.ListOpenadrEventsByVenIdRow) int {
+		return rank(b.Priority) - rank(a.Priority)
+	})
+
+	var result []scheduling.Event
+	for _, e := range events {
+		if e.Cancelled || e.TestEvent || (e.OptType.Valid && e.OptType.String == openadr.OptOut) {
+			continue
+		}
+
+		intervals, err := decodeIntervals(e.Intervals)
+		if err != nil {
+			return nil, err
+		}
+
+		start := e.StartsAt
+		for _, in := range intervals {
+			end := start.Add(time.Duration(in.DurationMinutes) * time.Minute)
+			if start.Before(to) && end.After(from) && !optedOut(opts, start, end) {
+				if se, ok := schedulingEvent(e.SignalName, in.Value, v, len(enrolled)); ok {
+					se.Start, se.End = start, end
+					result = append(result, se)
+				}
+			}
+			start = end
+		}
+	}
+
+	return result, nil
+}
+
+// UpdatedAt is the latest change to the events or opts of the VEN the
+// vehicle is enrolled with, or to the enrolment itself.
+func (s *Service) UpdatedAt(ctx context.Context, vehicleID uuid.UUID) (time.Time, error) {
+	enrolment, err := s.queries.GetOpenadrVenVehicle(ctx, vehicleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	v, err := s.queries.GetOpenadrVenById(ctx, enrolment.VenID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	latest := enrolment.CreatedAt
+	for _, t := range []time.Time{v.EventsChangedAt.Time, v.OptsChangedAt.Time} {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	return latest, nil
+}
+
+func schedulingEvent(signalName string, value float64, v *repository.Vehicle, enrolled int) (scheduling.Event, bool) {
+	var e scheduling.Event
+	switch signalName {
+	case openadr.SignalSimple:
+		share, ok := simpleLevelShare[value]
+		if !ok {
+			return e, false
+		}
+		limit := share * v.MaxChargeKw
+		e.MaxImportKw = &limit
+	case openadr.SignalLoadDispatch:
+		limit := value / float64(max(1, enrolled))
+		e.MaxImportKw = &limit
+	case openadr.SignalElectricityPrice:
+		price := value
+		e.ImportPrice = &price
+	default:
+		return e, false
+	}
+
+	return e, true
+}
+
+func optedOut(opts []repository.OpenadrOpt, start, end time.Time) bool {
+	for _, o := range opts {
+		if o.OptType == openadr.OptOut && o.StartsAt.Before(end) && o.EndsAt.After(start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func rank(priority int32) int {
+	if priority <= 0 {
+		return math.MaxInt32
+	}
+
+	return int(priority)
+}
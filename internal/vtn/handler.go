@@ -0,0 +1,347 @@
+package vtn
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/middleware"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// ServiceHandler is the endpoint of every OpenADR service, VENs authenticate
+// with the token they were given as bearer token.
+func (h *Handler) ServiceHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	v, err := h.svc.Authenticate(ctx, strings.TrimSpace(token))
+	if err != nil {
+		return err
+	}
+
+	p, err := openadr.Decode(r.Body)
+	if err != nil {
+		return httpx.BadRequest(ctx, "Could not parse OpenADR payload: "+err.Error())
+	}
+
+	res, err := h.svc.Handle(ctx, v, chi.URLParam(r, "service"), p, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := openadr.Encode(&body, res); err != nil {
+		return httpx.InternalErr(ctx, "Failed to encode OpenADR payload", err)
+	}
+
+	w.Header().Set("Content-Type", openadr.ContentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body.Bytes()); err != nil {
+		slog.ErrorContext(ctx, "Error writing response body", "error", err)
+	}
+
+	return nil
+}
+
+func (h *Handler) CreateVenHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req VenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	v, token, err := h.svc.CreateVen(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, NewVenResponse(v, token))
+	return nil
+}
+
+func (h *Handler) ListVensHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	vens, err := h.svc.ListVens(ctx, identityID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*VenResponse, 0, len(vens))
+	for i := range vens {
+		res = append(res, NewVenResponse(&vens[i], ""))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetVenHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	venID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	v, err := h.svc.GetOwnedVen(ctx, identityID, venID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewVenResponse(v, ""))
+	return nil
+}
+
+func (h *Handler) DeleteVenHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	venID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.DeleteVen(ctx, identityID, venID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) ListVehiclesHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	venID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	enrolments, err := h.svc.ListEnrolments(ctx, identityID, venID)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*EnrolmentResponse, 0, len(enrolments))
+	for i := range enrolments {
+		res = append(res, NewEnrolmentResponse(&enrolments[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) EnrolHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	venID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "vehicleId")
+	if err != nil {
+		return err
+	}
+
+	e, err := h.svc.Enrol(ctx, identityID, venID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, NewEnrolmentResponse(e))
+	return nil
+}
+
+func (h *Handler) UnenrolHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	venID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	vehicleID, err := httpx.URLParamUUID(r, "vehicleId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Unenrol(ctx, identityID, venID, vehicleID); err != nil {
+		return err
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusNoContent, nil)
+	return nil
+}
+
+func (h *Handler) ListReportsHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	venID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	values, err := h.svc.ListReportValues(ctx, identityID, venID, ParseReportRequest(r.URL.Query(), time.Now()))
+	if err != nil {
+		return err
+	}
+
+	res := make([]*ReportValueResponse, 0, len(values))
+	for i := range values {
+		res = append(res, NewReportValueResponse(&values[i]))
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) CreateEventHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req EventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return httpx.BadRequest(ctx, "Could not parse JSON body")
+	}
+
+	e, targets, err := h.svc.CreateEvent(ctx, identityID, req)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewEventResponse(e, targets, time.Now())
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to decode event", err)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusCreated, res)
+	return nil
+}
+
+func (h *Handler) ListEventsHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	events, err := h.svc.ListEvents(ctx, identityID, now)
+	if err != nil {
+		return err
+	}
+
+	res := make([]*EventResponse, 0, len(events))
+	for i := range events {
+		e, err := NewEventResponse(&events[i], nil, now)
+		if err != nil {
+			return httpx.InternalErr(ctx, "Failed to decode event", err)
+		}
+		res = append(res, e)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) GetEventHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	eventID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	e, targets, err := h.svc.GetOwnedEvent(ctx, identityID, eventID)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewEventResponse(e, targets, time.Now())
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to decode event", err)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) CancelEventHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	identityID, err := middleware.GetIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	eventID, err := httpx.URLParamUUID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	e, targets, err := h.svc.CancelEvent(ctx, identityID, eventID, now)
+	if err != nil {
+		return err
+	}
+
+	res, err := NewEventResponse(e, targets, now)
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to decode event", err)
+	}
+
+	httpx.ResponseWithJSON(w, http.StatusOK, res)
+	return nil
+}
@@ -0,0 +1,341 @@
+// Package vtn implements an OpenADR 2.0b Virtual Top Node. Users register
+// VENs (Virtual End Nodes), enrol their vehicles with them and send them
+// demand response events, which VENs receive by polling and may opt out of.
+// Events of the VEN a vehicle is enrolled with are taken into account when
+// its charging is scheduled.
+package vtn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/internal/vehicle"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"time"
+)
+
+const tokenBytes = 32
+
+type Service struct {
+	db       *pgxpool.Pool
+	queries  *repository.Queries
+	vehicles *vehicle.Service
+	cfg      *config.OpenADR
+}
+
+func NewService(db *pgxpool.Pool, queries *repository.Queries, vehicles *vehicle.Service, cfg *config.OpenADR) *Service {
+	return &Service{db: db, queries: queries, vehicles: vehicles, cfg: cfg}
+}
+
+// CreateVen adds a VEN and returns the token it authenticates with, only its
+// hash is stored.
+func (s *Service) CreateVen(ctx context.Context, ownerID uuid.UUID, req VenRequest) (*repository.OpenadrVen, string, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, "", httpx.ValidationFailed(ctx, errs)
+	}
+
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", httpx.InternalErr(ctx, "Could not generate VEN token", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	v, err := s.queries.CreateOpenadrVen(ctx, repository.CreateOpenadrVenParams{
+		ID:        uuid.New(),
+		OwnerID:   ownerID,
+		Name:      req.Name,
+		TokenHash: hashToken(token),
+	})
+	if err != nil {
+		return nil, "", httpx.InternalErr(ctx, "Failed to create VEN", err)
+	}
+
+	return &v, token, nil
+}
+
+func (s *Service) ListVens(ctx context.Context, ownerID uuid.UUID) ([]repository.OpenadrVen, error) {
+	vens, err := s.queries.ListOpenadrVensByOwnerId(ctx, ownerID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve VENs", err)
+	}
+
+	return vens, nil
+}
+
+func (s *Service) GetOwnedVen(ctx context.Context, identityID, venID uuid.UUID) (*repository.OpenadrVen, error) {
+	v, err := s.queries.GetOpenadrVenById(ctx, venID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.NotFound(ctx, "VEN could not be found")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve VEN", err)
+	}
+
+	if v.OwnerID != identityID {
+		return nil, httpx.NotFound(ctx, "VEN could not be found")
+	}
+
+	return &v, nil
+}
+
+func (s *Service) DeleteVen(ctx context.Context, identityID, venID uuid.UUID) error {
+	v, err := s.GetOwnedVen(ctx, identityID, venID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.DeleteOpenadrVen(ctx, v.ID); err != nil {
+		return httpx.InternalErr(ctx, "Failed to delete VEN", err)
+	}
+
+	return nil
+}
+
+// Enrol places a vehicle under a VEN, moving it when it was enrolled with
+// another one. Both must belong to the caller.
+func (s *Service) Enrol(ctx context.Context, identityID, venID, vehicleID uuid.UUID) (*repository.OpenadrVenVehicle, error) {
+	v, err := s.GetOwnedVen(ctx, identityID, venID)
+	if err != nil {
+		return nil, err
+	}
+
+	veh, err := s.vehicles.GetOwned(ctx, identityID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := s.queries.UpsertOpenadrVenVehicle(ctx, repository.UpsertOpenadrVenVehicleParams{
+		VehicleID: veh.ID,
+		VenID:     v.ID,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to enrol vehicle", err)
+	}
+
+	return &e, nil
+}
+
+// Unenrol removes a vehicle from a VEN. Its schedule drops the events of
+// the VEN when it is next re-planned.
+func (s *Service) Unenrol(ctx context.Context, identityID, venID, vehicleID uuid.UUID) error {
+	v, err := s.GetOwnedVen(ctx, identityID, venID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.queries.DeleteOpenadrVenVehicle(ctx, repository.DeleteOpenadrVenVehicleParams{
+		VehicleID: vehicleID,
+		VenID:     v.ID,
+	})
+	if err != nil {
+		return httpx.InternalErr(ctx, "Failed to unenrol vehicle", err)
+	}
+
+	if rows == 0 {
+		return httpx.NotFound(ctx, "Vehicle is not enrolled with this VEN")
+	}
+
+	return nil
+}
+
+func (s *Service) ListEnrolments(ctx context.Context, identityID, venID uuid.UUID) ([]repository.OpenadrVenVehicle, error) {
+	v, err := s.GetOwnedVen(ctx, identityID, venID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrolments, err := s.queries.ListOpenadrVenVehicles(ctx, v.ID)
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve enrolled vehicles", err)
+	}
+
+	return enrolments, nil
+}
+
+// CreateEvent stores an event for VENs of the caller, they receive it on
+// their next poll.
+func (s *Service) CreateEvent(ctx context.Context, identityID uuid.UUID, req EventRequest) (*repository.OpenadrEvent, []repository.OpenadrEventTarget, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	venIDs := make(map[uuid.UUID]bool)
+	for _, id := range req.VenIDs {
+		if _, err := s.GetOwnedVen(ctx, identityID, id); err != nil {
+			return nil, nil, err
+		}
+		venIDs[id] = true
+	}
+
+	intervals, err := json.Marshal(req.Intervals)
+	if err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to encode intervals", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	e, err := qtx.CreateOpenadrEvent(ctx, repository.CreateOpenadrEventParams{
+		ID:               uuid.New(),
+		OwnerID:          identityID,
+		Priority:         req.Priority,
+		MarketContext:    req.MarketContext,
+		SignalName:       req.SignalName,
+		StartsAt:         req.Start.UTC(),
+		Intervals:        intervals,
+		TestEvent:        req.TestEvent,
+		ResponseRequired: *req.ResponseRequired,
+	})
+	if err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to create event", err)
+	}
+
+	targets := make([]repository.OpenadrEventTarget, 0, len(venIDs))
+	for id := range venIDs {
+		if err := qtx.CreateOpenadrEventTarget(ctx, repository.CreateOpenadrEventTargetParams{EventID: e.ID, VenID: id}); err != nil {
+			return nil, nil, httpx.InternalErr(ctx, "Failed to target VEN", err)
+		}
+		targets = append(targets, repository.OpenadrEventTarget{EventID: e.ID, VenID: id})
+	}
+
+	if err := qtx.MarkOpenadrVensEventsChanged(ctx, e.ID); err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to notify VENs", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	return &e, targets, nil
+}
+
+// ListEvents returns the events of the caller that are upcoming, running or
+// started within the last day.
+func (s *Service) ListEvents(ctx context.Context, identityID uuid.UUID, now time.Time) ([]repository.OpenadrEvent, error) {
+	events, err := s.queries.ListOpenadrEventsByOwnerId(ctx, repository.ListOpenadrEventsByOwnerIdParams{
+		OwnerID:    identityID,
+		StartsFrom: now.Add(-maxEventDuration),
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve events", err)
+	}
+
+	return events, nil
+}
+
+func (s *Service) GetOwnedEvent(ctx context.Context, identityID, eventID uuid.UUID) (*repository.OpenadrEvent, []repository.OpenadrEventTarget, error) {
+	e, err := s.queries.GetOpenadrEventById(ctx, eventID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, httpx.NotFound(ctx, "Event could not be found")
+		}
+
+		return nil, nil, httpx.InternalErr(ctx, "Failed to retrieve event", err)
+	}
+
+	if e.OwnerID != identityID {
+		return nil, nil, httpx.NotFound(ctx, "Event could not be found")
+	}
+
+	targets, err := s.queries.ListOpenadrEventTargets(ctx, e.ID)
+	if err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to retrieve event targets", err)
+	}
+
+	return &e, targets, nil
+}
+
+// CancelEvent marks an event as cancelled, which is a modification the VENs
+// receive like any other.
+func (s *Service) CancelEvent(ctx context.Context, identityID, eventID uuid.UUID, now time.Time) (*repository.OpenadrEvent, []repository.OpenadrEventTarget, error) {
+	e, targets, err := s.GetOwnedEvent(ctx, identityID, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	intervals, err := decodeIntervals(e.Intervals)
+	if err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to decode intervals", err)
+	}
+
+	switch eventStatus(e.StartsAt, intervals, e.Cancelled, now) {
+	case openadr.StatusCancelled:
+		return nil, nil, httpx.Conflict(ctx, "Event has already been cancelled")
+	case openadr.StatusCompleted:
+		return nil, nil, httpx.Conflict(ctx, "Event has already completed")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to begin transaction", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "Rollback failed", "err", err)
+		}
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	cancelled, err := qtx.CancelOpenadrEvent(ctx, e.ID)
+	if err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to cancel event", err)
+	}
+
+	if err := qtx.MarkOpenadrVensEventsChanged(ctx, e.ID); err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to notify VENs", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, httpx.InternalErr(ctx, "Failed to commit transaction", err)
+	}
+
+	return &cancelled, targets, nil
+}
+
+func (s *Service) ListReportValues(ctx context.Context, identityID, venID uuid.UUID, req ReportRequest) ([]repository.OpenadrReportValue, error) {
+	v, err := s.GetOwnedVen(ctx, identityID, venID)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return nil, httpx.ValidationFailed(ctx, errs)
+	}
+
+	values, err := s.queries.ListOpenadrReportValuesByVenId(ctx, repository.ListOpenadrReportValuesByVenIdParams{
+		VenID:          v.ID,
+		MeasuredFrom:   req.From,
+		MeasuredBefore: req.To,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve report values", err)
+	}
+
+	return values, nil
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,572 @@
+package vtn
+
+import (
+	"context"
+	"errors"
+	"github.com/V2G-Minor-Fontys/server/internal/httpx"
+	"github.com/V2G-Minor-Fontys/server/internal/repository"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+const (
+	// reportGranularity and reportBackDuration are requested for every
+	// telemetry report a VEN registers.
+	reportGranularity  = time.Minute
+	reportBackDuration = 5 * time.Minute
+)
+
+// serviceMessages lists which messages each endpoint accepts.
+var serviceMessages = map[string]func(o *openadr.SignedObject) bool{
+	openadr.ServiceRegisterParty: func(o *openadr.SignedObject) bool {
+		return o.QueryRegistration != nil || o.CreatePartyRegistration != nil || o.CancelPartyRegistration != nil || o.Response != nil
+	},
+	openadr.ServiceEvent: func(o *openadr.SignedObject) bool {
+		return o.RequestEvent != nil || o.CreatedEvent != nil
+	},
+	openadr.ServiceReport: func(o *openadr.SignedObject) bool {
+		return o.RegisterReport != nil || o.UpdateReport != nil || o.Response != nil
+	},
+	openadr.ServiceOpt: func(o *openadr.SignedObject) bool {
+		return o.CreateOpt != nil || o.CancelOpt != nil
+	},
+	openadr.ServicePoll: func(o *openadr.SignedObject) bool {
+		return o.Poll != nil
+	},
+}
+
+// Authenticate finds the VEN a bearer token belongs to.
+func (s *Service) Authenticate(ctx context.Context, token string) (*repository.OpenadrVen, error) {
+	if token == "" {
+		return nil, httpx.Unauthorized(ctx, "A VEN token is required")
+	}
+
+	v, err := s.queries.GetOpenadrVenByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httpx.Unauthorized(ctx, "VEN token is invalid")
+		}
+
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve VEN", err)
+	}
+
+	if err := s.queries.TouchOpenadrVen(ctx, v.ID); err != nil {
+		slog.WarnContext(ctx, "Failed to update VEN last seen", "ven.id", v.ID, "error", err)
+	}
+
+	return &v, nil
+}
+
+// Handle answers a message a VEN posted to one of the service endpoints.
+// Problems with the message itself are reported in the OpenADR response,
+// only failures of the server are returned as errors.
+func (s *Service) Handle(ctx context.Context, v *repository.OpenadrVen, service string, p *openadr.Payload, now time.Time) (*openadr.Payload, error) {
+	o := &p.SignedObject
+	accepts, ok := serviceMessages[service]
+	if !ok {
+		return nil, httpx.NotFound(ctx, "Unknown OpenADR service")
+	}
+	if !accepts(o) {
+		return failure(openadr.CodeNotRecognized, "", "Message is not supported by "+service), nil
+	}
+
+	switch {
+	case o.QueryRegistration != nil:
+		return openadr.Wrap(s.createdPartyRegistration(openadr.OK(o.QueryRegistration.RequestID), "", "")), nil
+	case o.CreatePartyRegistration != nil:
+		return s.register(ctx, v, o.CreatePartyRegistration)
+	case o.CancelPartyRegistration != nil:
+		return s.cancelRegistration(ctx, v, o.CancelPartyRegistration)
+	case o.Response != nil:
+		// Acknowledgements of our responses need no answer of their own.
+		return openadr.Wrap(&openadr.Response{SchemaVersion: openadr.SchemaVersion, EiResponse: openadr.OK(o.Response.EiResponse.RequestID), VenID: v.ID.String()}), nil
+	}
+
+	if !v.RegistrationID.Valid {
+		return failure(openadr.CodeNotRegistered, "", "VEN is not registered"), nil
+	}
+
+	switch {
+	case o.Poll != nil:
+		return s.poll(ctx, v, o.Poll, now)
+	case o.RequestEvent != nil:
+		if !ownVenID(v, o.RequestEvent.EiRequestEvent.VenID) {
+			return failure(openadr.CodeInvalidID, o.RequestEvent.EiRequestEvent.RequestID, "VEN ID does not match"), nil
+		}
+		return s.distributeEvent(ctx, v, o.RequestEvent.EiRequestEvent.RequestID, now)
+	case o.CreatedEvent != nil:
+		return s.createdEvent(ctx, v, o.CreatedEvent)
+	case o.RegisterReport != nil:
+		return s.registerReport(v, o.RegisterReport), nil
+	case o.UpdateReport != nil:
+		return s.updateReport(ctx, v, o.UpdateReport)
+	case o.CreateOpt != nil:
+		return s.createOpt(ctx, v, o.CreateOpt)
+	case o.CancelOpt != nil:
+		return s.cancelOpt(ctx, v, o.CancelOpt)
+	}
+
+	return failure(openadr.CodeNotRecognized, "", "Message is not supported"), nil
+}
+
+func (s *Service) createdPartyRegistration(res openadr.EiResponse, registrationID, venID string) *openadr.CreatedPartyRegistration {
+	return &openadr.CreatedPartyRegistration{
+		SchemaVersion:  openadr.SchemaVersion,
+		EiResponse:     res,
+		RegistrationID: registrationID,
+		VenID:          venID,
+		VtnID:          s.cfg.VtnID,
+		Profiles: []openadr.Profile{{
+			Name:       openadr.ProfileName,
+			Transports: []openadr.Transport{{Name: openadr.TransportHTTP}},
+		}},
+		PollFreq: openadr.DurationValue{Value: openadr.Duration(s.cfg.PollFreq)},
+	}
+}
+
+// register (re-)registers the VEN. Only the pull model over simple HTTP is
+// supported, the VEN ID is assigned by the VTN.
+func (s *Service) register(ctx context.Context, v *repository.OpenadrVen, m *openadr.CreatePartyRegistration) (*openadr.Payload, error) {
+	fail := func(code int, description string) *openadr.Payload {
+		return openadr.Wrap(s.createdPartyRegistration(openadr.Failed(code, m.RequestID, description), "", ""))
+	}
+
+	switch {
+	case m.ProfileName != openadr.ProfileName:
+		return fail(openadr.CodeNotAllowed, "Only the 2.0b profile is supported"), nil
+	case m.TransportName != openadr.TransportHTTP:
+		return fail(openadr.CodeNotAllowed, "Only the simpleHttp transport is supported"), nil
+	case !m.HTTPPullModel:
+		return fail(openadr.CodeNotAllowed, "Only the pull model is supported"), nil
+	case m.XMLSignature:
+		return fail(openadr.CodeNotAllowed, "XML signatures are not supported"), nil
+	case m.VenID != "" && !ownVenID(v, m.VenID):
+		return fail(openadr.CodeInvalidID, "VEN ID does not match"), nil
+	case m.RegistrationID != "" && m.RegistrationID != registrationID(v):
+		return fail(openadr.CodeInvalidID, "Registration ID does not match"), nil
+	}
+
+	registration := v.RegistrationID
+	if !registration.Valid {
+		registration = pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	}
+
+	registered, err := s.queries.RegisterOpenadrVen(ctx, repository.RegisterOpenadrVenParams{
+		ID:             v.ID,
+		RegistrationID: registration,
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to register VEN", err)
+	}
+
+	slog.InfoContext(ctx, "VEN registered", "ven.id", v.ID, "name", m.VenName)
+	return openadr.Wrap(s.createdPartyRegistration(openadr.OK(m.RequestID), registrationID(&registered), v.ID.String())), nil
+}
+
+func (s *Service) cancelRegistration(ctx context.Context, v *repository.OpenadrVen, m *openadr.CancelPartyRegistration) (*openadr.Payload, error) {
+	res := &openadr.CanceledPartyRegistration{
+		SchemaVersion:  openadr.SchemaVersion,
+		EiResponse:     openadr.OK(m.RequestID),
+		RegistrationID: m.RegistrationID,
+		VenID:          v.ID.String(),
+	}
+
+	if !v.RegistrationID.Valid || m.RegistrationID != registrationID(v) {
+		res.EiResponse = openadr.Failed(openadr.CodeInvalidID, m.RequestID, "Registration ID does not match")
+		return openadr.Wrap(res), nil
+	}
+
+	if err := s.queries.CancelOpenadrVenRegistration(ctx, v.ID); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to cancel registration", err)
+	}
+
+	return openadr.Wrap(res), nil
+}
+
+// poll delivers the events when they changed since they were last sent,
+// there is nothing else a VEN has to be told.
+func (s *Service) poll(ctx context.Context, v *repository.OpenadrVen, m *openadr.Poll, now time.Time) (*openadr.Payload, error) {
+	if !ownVenID(v, m.VenID) {
+		return failure(openadr.CodeInvalidID, "", "VEN ID does not match"), nil
+	}
+
+	changed := v.EventsChangedAt.Valid && (!v.EventsDeliveredAt.Valid || v.EventsChangedAt.Time.After(v.EventsDeliveredAt.Time))
+	if changed {
+		return s.distributeEvent(ctx, v, uuid.NewString(), now)
+	}
+
+	return openadr.Wrap(&openadr.Response{SchemaVersion: openadr.SchemaVersion, EiResponse: openadr.OK(""), VenID: v.ID.String()}), nil
+}
+
+// distributeEvent sends every event of the VEN that has not completed.
+func (s *Service) distributeEvent(ctx context.Context, v *repository.OpenadrVen, requestID string, now time.Time) (*openadr.Payload, error) {
+	events, err := s.queries.ListOpenadrEventsByVenId(ctx, repository.ListOpenadrEventsByVenIdParams{
+		VenID:      v.ID,
+		StartsFrom: now.Add(-maxEventDuration),
+	})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to retrieve events", err)
+	}
+
+	res := &openadr.DistributeEvent{
+		SchemaVersion: openadr.SchemaVersion,
+		EiResponse:    &openadr.EiResponse{ResponseCode: openadr.CodeOK, ResponseDescription: "OK", RequestID: requestID},
+		RequestID:     requestID,
+		VtnID:         s.cfg.VtnID,
+	}
+	for i := range events {
+		e, ok, err := oadrEvent(&events[i], v, now)
+		if err != nil {
+			return nil, httpx.InternalErr(ctx, "Failed to decode event", err)
+		}
+		if ok {
+			res.Events = append(res.Events, e)
+		}
+	}
+
+	if err := s.queries.MarkOpenadrVenEventsDelivered(ctx, repository.MarkOpenadrVenEventsDeliveredParams{
+		ID:                v.ID,
+		EventsDeliveredAt: pgtype.Timestamptz{Time: now, Valid: true},
+	}); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to mark events delivered", err)
+	}
+
+	return openadr.Wrap(res), nil
+}
+
+func oadrEvent(e *repository.ListOpenadrEventsByVenIdRow, v *repository.OpenadrVen, now time.Time) (openadr.OadrEvent, bool, error) {
+	intervals, err := decodeIntervals(e.Intervals)
+	if err != nil {
+		return openadr.OadrEvent{}, false, err
+	}
+
+	end := eventEnd(e.StartsAt, intervals)
+	if !now.Before(end) {
+		return openadr.OadrEvent{}, false, nil
+	}
+
+	signal := openadr.EventSignal{
+		SignalName: e.SignalName,
+		SignalType: signalType(e.SignalName),
+		SignalID:   "0",
+	}
+	current := 0.0
+	start := e.StartsAt
+	for i, in := range intervals {
+		length := time.Duration(in.DurationMinutes) * time.Minute
+		if !now.Before(start) && now.Before(start.Add(length)) {
+			current = in.Value
+		}
+		start = start.Add(length)
+
+		signal.Intervals.Intervals = append(signal.Intervals.Intervals, openadr.Interval{
+			Duration:      openadr.DurationValue{Value: openadr.Duration(length)},
+			UID:           &openadr.UID{Text: strconv.Itoa(i)},
+			SignalPayload: &openadr.SignalPayload{PayloadFloat: openadr.PayloadFloat{Value: in.Value}},
+		})
+	}
+	signal.CurrentValue = &openadr.CurrentValue{PayloadFloat: openadr.PayloadFloat{Value: current}}
+
+	responseRequired := openadr.ResponseRequiredNever
+	if e.ResponseRequired {
+		responseRequired = openadr.ResponseRequiredAlways
+	}
+
+	return openadr.OadrEvent{
+		EiEvent: openadr.EiEvent{
+			Descriptor: openadr.EventDescriptor{
+				EventID:            e.ID.String(),
+				ModificationNumber: int(e.ModificationNumber),
+				Priority:           int(e.Priority),
+				MarketContext:      openadr.MarketContext{Value: e.MarketContext},
+				CreatedDateTime:    e.CreatedAt.UTC(),
+				EventStatus:        eventStatus(e.StartsAt, intervals, e.Cancelled, now),
+				TestEvent:          strconv.FormatBool(e.TestEvent),
+			},
+			ActivePeriod: openadr.ActivePeriod{Properties: openadr.Properties{
+				DtStart:  openadr.DateTime{Value: e.StartsAt.UTC()},
+				Duration: openadr.DurationValue{Value: openadr.Duration(end.Sub(e.StartsAt))},
+			}},
+			Signals: []openadr.EventSignal{signal},
+			Target:  openadr.Target{VenIDs: []string{v.ID.String()}},
+		},
+		ResponseRequired: responseRequired,
+	}, true, nil
+}
+
+func signalType(name string) string {
+	switch name {
+	case openadr.SignalElectricityPrice:
+		return openadr.SignalTypePrice
+	case openadr.SignalLoadDispatch:
+		return openadr.SignalTypeSetpoint
+	}
+
+	return openadr.SignalTypeLevel
+}
+
+// createdEvent records the opt of the VEN for each event it responded to.
+// Responses to an outdated modification of an event are rejected.
+func (s *Service) createdEvent(ctx context.Context, v *repository.OpenadrVen, m *openadr.CreatedEvent) (*openadr.Payload, error) {
+	requestID := m.EiCreatedEvent.EiResponse.RequestID
+	if !ownVenID(v, m.EiCreatedEvent.VenID) {
+		return failure(openadr.CodeInvalidID, requestID, "VEN ID does not match"), nil
+	}
+
+	for _, r := range m.EiCreatedEvent.EventResponses.Responses {
+		if r.OptType != openadr.OptIn && r.OptType != openadr.OptOut {
+			return failure(openadr.CodeInvalidData, requestID, "Opt type must be optIn or optOut"), nil
+		}
+
+		e, ok, err := s.targetedEvent(ctx, v, r.QualifiedEventID.EventID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return failure(openadr.CodeInvalidID, requestID, "Unknown event "+r.QualifiedEventID.EventID), nil
+		}
+		if int32(r.QualifiedEventID.ModificationNumber) != e.ModificationNumber {
+			return failure(openadr.CodeInvalidData, requestID, "Event "+r.QualifiedEventID.EventID+" has been modified"), nil
+		}
+
+		if err := s.setOpt(ctx, v, e.ID, r.OptType); err != nil {
+			return nil, err
+		}
+	}
+
+	return openadr.Wrap(&openadr.Response{SchemaVersion: openadr.SchemaVersion, EiResponse: openadr.OK(requestID), VenID: v.ID.String()}), nil
+}
+
+// targetedEvent returns the event with the given ID when it targets the VEN.
+func (s *Service) targetedEvent(ctx context.Context, v *repository.OpenadrVen, eventID string) (*repository.OpenadrEvent, bool, error) {
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	e, err := s.queries.GetOpenadrEventById(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, httpx.InternalErr(ctx, "Failed to retrieve event", err)
+	}
+
+	targets, err := s.queries.ListOpenadrEventTargets(ctx, e.ID)
+	if err != nil {
+		return nil, false, httpx.InternalErr(ctx, "Failed to retrieve event targets", err)
+	}
+	for _, t := range targets {
+		if t.VenID == v.ID {
+			return &e, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func (s *Service) setOpt(ctx context.Context, v *repository.OpenadrVen, eventID uuid.UUID, optType string) error {
+	if _, err := s.queries.SetOpenadrEventOpt(ctx, repository.SetOpenadrEventOptParams{
+		EventID: eventID,
+		VenID:   v.ID,
+		OptType: pgtype.Text{String: optType, Valid: true},
+	}); err != nil {
+		return httpx.InternalErr(ctx, "Failed to store opt", err)
+	}
+
+	if err := s.queries.MarkOpenadrVenOptsChanged(ctx, v.ID); err != nil {
+		return httpx.InternalErr(ctx, "Failed to store opt", err)
+	}
+
+	return nil
+}
+
+// registerReport requests every telemetry report the VEN offers.
+func (s *Service) registerReport(v *repository.OpenadrVen, m *openadr.RegisterReport) *openadr.Payload {
+	res := &openadr.RegisteredReport{
+		SchemaVersion: openadr.SchemaVersion,
+		EiResponse:    openadr.OK(m.RequestID),
+		VenID:         v.ID.String(),
+	}
+
+	for _, r := range m.Reports {
+		if r.ReportName != openadr.ReportMetadataTelemetryUsage && r.ReportName != openadr.ReportMetadataTelemetryStatus {
+			continue
+		}
+
+		req := openadr.ReportRequest{
+			ReportRequestID: uuid.NewString(),
+			Specifier: openadr.ReportSpecifier{
+				ReportSpecifierID:  r.ReportSpecifierID,
+				Granularity:        openadr.DurationValue{Value: openadr.Duration(reportGranularity)},
+				ReportBackDuration: openadr.DurationValue{Value: openadr.Duration(reportBackDuration)},
+			},
+		}
+		for _, d := range r.Descriptions {
+			req.Specifier.Payloads = append(req.Specifier.Payloads, openadr.SpecifierPayload{
+				RID:         d.RID,
+				ReadingType: d.ReadingType,
+			})
+		}
+		res.ReportRequests = append(res.ReportRequests, req)
+	}
+
+	return openadr.Wrap(res)
+}
+
+// updateReport stores the values of the report intervals, intervals without
+// a start cannot be placed and are skipped.
+func (s *Service) updateReport(ctx context.Context, v *repository.OpenadrVen, m *openadr.UpdateReport) (*openadr.Payload, error) {
+	if !ownVenID(v, m.VenID) {
+		return failure(openadr.CodeInvalidID, m.RequestID, "VEN ID does not match"), nil
+	}
+
+	for _, r := range m.Reports {
+		if r.Intervals == nil {
+			continue
+		}
+
+		for _, in := range r.Intervals.Intervals {
+			if in.DtStart == nil {
+				continue
+			}
+
+			for _, p := range in.ReportPayloads {
+				if err := s.queries.CreateOpenadrReportValue(ctx, repository.CreateOpenadrReportValueParams{
+					VenID:      v.ID,
+					RID:        p.RID,
+					MeasuredAt: in.DtStart.Value.UTC(),
+					Value:      p.PayloadFloat.Value,
+				}); err != nil {
+					return nil, httpx.InternalErr(ctx, "Failed to store report value", err)
+				}
+			}
+		}
+	}
+
+	return openadr.Wrap(&openadr.UpdatedReport{
+		SchemaVersion: openadr.SchemaVersion,
+		EiResponse:    openadr.OK(m.RequestID),
+		VenID:         v.ID.String(),
+	}), nil
+}
+
+// createOpt opts in or out of a single event, or of every event during the
+// availability periods when no event is given.
+func (s *Service) createOpt(ctx context.Context, v *repository.OpenadrVen, m *openadr.CreateOpt) (*openadr.Payload, error) {
+	fail := func(code int, description string) *openadr.Payload {
+		return openadr.Wrap(&openadr.CreatedOpt{
+			SchemaVersion: openadr.SchemaVersion,
+			EiResponse:    openadr.Failed(code, m.RequestID, description),
+			OptID:         m.OptID,
+		})
+	}
+
+	switch {
+	case !ownVenID(v, m.VenID):
+		return fail(openadr.CodeInvalidID, "VEN ID does not match"), nil
+	case m.OptID == "" || len(m.OptID) > 100:
+		return fail(openadr.CodeInvalidData, "Opt ID must have between 1 and 100 characters"), nil
+	case m.OptType != openadr.OptIn && m.OptType != openadr.OptOut:
+		return fail(openadr.CodeInvalidData, "Opt type must be optIn or optOut"), nil
+	case m.QualifiedEventID == nil && (m.Availability == nil || len(m.Availability.Components) == 0):
+		return fail(openadr.CodeInvalidData, "An event or availability is required"), nil
+	}
+
+	if m.QualifiedEventID != nil {
+		e, ok, err := s.targetedEvent(ctx, v, m.QualifiedEventID.EventID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return fail(openadr.CodeInvalidID, "Unknown event "+m.QualifiedEventID.EventID), nil
+		}
+
+		if err := s.setOpt(ctx, v, e.ID, m.OptType); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, a := range m.Availability.Components {
+			start := a.Properties.DtStart.Value.UTC()
+			end := start.Add(time.Duration(a.Properties.Duration.Value))
+			if !end.After(start) {
+				return fail(openadr.CodeInvalidData, "Availability periods must have a positive duration"), nil
+			}
+
+			if err := s.queries.CreateOpenadrOpt(ctx, repository.CreateOpenadrOptParams{
+				VenID:     v.ID,
+				OptID:     m.OptID,
+				OptType:   m.OptType,
+				OptReason: m.OptReason,
+				StartsAt:  start,
+				EndsAt:    end,
+			}); err != nil {
+				return nil, httpx.InternalErr(ctx, "Failed to store opt", err)
+			}
+		}
+
+		if err := s.queries.MarkOpenadrVenOptsChanged(ctx, v.ID); err != nil {
+			return nil, httpx.InternalErr(ctx, "Failed to store opt", err)
+		}
+	}
+
+	return openadr.Wrap(&openadr.CreatedOpt{
+		SchemaVersion: openadr.SchemaVersion,
+		EiResponse:    openadr.OK(m.RequestID),
+		OptID:         m.OptID,
+	}), nil
+}
+
+func (s *Service) cancelOpt(ctx context.Context, v *repository.OpenadrVen, m *openadr.CancelOpt) (*openadr.Payload, error) {
+	res := &openadr.CanceledOpt{
+		SchemaVersion: openadr.SchemaVersion,
+		EiResponse:    openadr.OK(m.RequestID),
+		OptID:         m.OptID,
+	}
+
+	if !ownVenID(v, m.VenID) {
+		res.EiResponse = openadr.Failed(openadr.CodeInvalidID, m.RequestID, "VEN ID does not match")
+		return openadr.Wrap(res), nil
+	}
+
+	rows, err := s.queries.DeleteOpenadrOpt(ctx, repository.DeleteOpenadrOptParams{VenID: v.ID, OptID: m.OptID})
+	if err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to cancel opt", err)
+	}
+	if rows == 0 {
+		res.EiResponse = openadr.Failed(openadr.CodeInvalidID, m.RequestID, "Unknown opt "+m.OptID)
+		return openadr.Wrap(res), nil
+	}
+
+	if err := s.queries.MarkOpenadrVenOptsChanged(ctx, v.ID); err != nil {
+		return nil, httpx.InternalErr(ctx, "Failed to cancel opt", err)
+	}
+
+	return openadr.Wrap(res), nil
+}
+
+// ownVenID reports whether a VEN ID in a message is the one of the
+// authenticated VEN.
+func ownVenID(v *repository.OpenadrVen, venID string) bool {
+	return venID == v.ID.String()
+}
+
+func registrationID(v *repository.OpenadrVen) string {
+	if !v.RegistrationID.Valid {
+		return ""
+	}
+
+	return uuid.UUID(v.RegistrationID.Bytes).String()
+}
+
+func failure(code int, requestID, description string) *openadr.Payload {
+	return openadr.Wrap(&openadr.Response{
+		SchemaVersion: openadr.SchemaVersion,
+		EiResponse:    openadr.Failed(code, requestID, description),
+	})
+}
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	"golang.org/x/sync/singleflight"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const DefaultExpire = 5 * time.Minute
+
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New returns a Redis backed cache when a connection string is configured and
+// falls back to an in-memory cache otherwise.
+func New(cfg *config.Redis) (*Loader, error) {
+	if cfg == nil || cfg.ConnectionString == "" {
+		slog.Warn("Redis is not configured, using in-memory cache")
+		return NewLoader(NewMemory(), DefaultExpire), nil
+	}
+
+	r, err := NewRedis(cfg.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	expire := cfg.Expire
+	if expire <= 0 {
+		expire = DefaultExpire
+	}
+
+	return NewLoader(r, expire), nil
+}
+
+// Loader wraps a Cache with JSON encoding and collapses concurrent loads of
+// the same key into a single call to protect the database from stampedes.
+type Loader struct {
+	cache  Cache
+	expire time.Duration
+	group  singleflight.Group
+
+	// loading counts the invalidations of every key that is being loaded, a
+	// load that overlaps one of its key does not write its possibly stale
+	// result back. Keys leave the map when their last load finishes.
+	mu      sync.Mutex
+	loading map[string]*keyLoad
+}
+
+type keyLoad struct {
+	running    int
+	generation uint64
+}
+
+func NewLoader(c Cache, expire time.Duration) *Loader {
+	return &Loader{cache: c, expire: expire, loading: make(map[string]*keyLoad)}
+}
+
+func (l *Loader) Invalidate(ctx context.Context, keys ...string) {
+	l.mu.Lock()
+	for _, key := range keys {
+		if ld, ok := l.loading[key]; ok {
+			ld.generation++
+		}
+	}
+	l.mu.Unlock()
+
+	for _, key := range keys {
+		l.group.Forget(key)
+	}
+
+	if err := l.cache.Delete(ctx, keys...); err != nil {
+		slog.WarnContext(ctx, "Could not invalidate cache keys", "keys", keys, "error", err)
+	}
+}
+
+func (l *Loader) Close() error {
+	if r, ok := l.cache.(*Redis); ok {
+		return r.Close()
+	}
+
+	return nil
+}
+
+// GetOrLoad returns the cached value for key or calls load once, caches and
+// returns its result. Cache failures are logged and never fail the request.
+func GetOrLoad[T any](ctx context.Context, l *Loader, key string, load func(context.Context) (T, error)) (T, error) {
+	return GetOrLoadFor(ctx, l, key, l.expire, load)
+}
+
+func GetOrLoadFor[T any](ctx context.Context, l *Loader, key string, ttl time.Duration, load func(context.Context) (T, error)) (T, error) {
+	var value T
+	raw, ok, err := l.cache.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "Cache read failed", "key", key, "error", err)
+	}
+
+	if ok {
+		if err := json.Unmarshal(raw, &value); err == nil {
+			return value, nil
+		}
+		slog.WarnContext(ctx, "Discarding undecodable cache entry", "key", key)
+	}
+
+	// The load is shared by every caller of the key, so it must not be
+	// cancelled with the first one. Each caller still stops waiting when its
+	// own context is done.
+	ch := l.group.DoChan(key, func() (any, error) {
+		ctx := context.WithoutCancel(ctx)
+		generation := l.startLoad(key)
+		defer l.finishLoad(key)
+
+		v, err := load(ctx)
+		if err != nil {
+			return v, err
+		}
+
+		if l.generation(key) != generation {
+			return v, nil
+		}
+
+		if raw, err := json.Marshal(v); err == nil {
+			if err := l.cache.Set(ctx, key, raw, ttl); err != nil {
+				slog.WarnContext(ctx, "Cache write failed", "key", key, "error", err)
+			}
+		}
+
+		// An invalidation between the check and the write deleted nothing.
+		if l.generation(key) != generation {
+			l.Invalidate(ctx, key)
+		}
+
+		return v, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return value, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return value, res.Err
+		}
+
+		return res.Val.(T), nil
+	}
+}
+
+func (l *Loader) startLoad(key string) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ld, ok := l.loading[key]
+	if !ok {
+		ld = &keyLoad{}
+		l.loading[key] = ld
+	}
+	ld.running++
+
+	return ld.generation
+}
+
+func (l *Loader) finishLoad(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ld := l.loading[key]
+	if ld.running--; ld.running == 0 {
+		delete(l.loading, key)
+	}
+}
+
+func (l *Loader) generation(key string) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.loading[key].generation
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadCancelledCallerDoesNotFailOthers(t *testing.T) {
+	l := NewLoader(NewMemory(), time.Minute)
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := GetOrLoad(first, l, "key", load)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan int, 1)
+	go func() {
+		v, err := GetOrLoad(context.Background(), l, "key", load)
+		if err != nil {
+			t.Errorf("second caller: %v", err)
+		}
+		second <- v
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if v := <-second; v != 42 {
+		t.Errorf("second caller got %d, want 42", v)
+	}
+}
+
+func TestGetOrLoadInvalidatedDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	l := NewLoader(NewMemory(), time.Minute)
+	started, release := make(chan struct{}), make(chan struct{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, err := GetOrLoad(ctx, l, "key", func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "stale", nil
+		})
+		if err != nil || v != "stale" {
+			t.Errorf("GetOrLoad = %q, %v, want the loaded value", v, err)
+		}
+	}()
+	<-started
+
+	l.Invalidate(ctx, "key")
+	close(release)
+	<-done
+
+	v, err := GetOrLoad(ctx, l, "key", func(context.Context) (string, error) {
+		return "fresh", nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if v != "fresh" {
+		t.Errorf("GetOrLoad after invalidation = %q, want %q", v, "fresh")
+	}
+}
+
+func TestGetOrLoadInvalidatingOtherKeyKeepsLoad(t *testing.T) {
+	ctx := context.Background()
+	l := NewLoader(NewMemory(), time.Minute)
+	started, release := make(chan struct{}), make(chan struct{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := GetOrLoad(ctx, l, "b", func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "loaded", nil
+		}); err != nil {
+			t.Errorf("GetOrLoad: %v", err)
+		}
+	}()
+	<-started
+
+	l.Invalidate(ctx, "a")
+	close(release)
+	<-done
+
+	v, err := GetOrLoad(ctx, l, "b", func(context.Context) (string, error) {
+		return "reloaded", nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if v != "loaded" {
+		t.Errorf("GetOrLoad after invalidating another key = %q, want the cached %q", v, "loaded")
+	}
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepEvery = 1024
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	writes  int
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]entry)}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if time.Now().After(e.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+
+	return e.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = entry{value: value, expiresAt: time.Now().Add(ttl)}
+	m.writes++
+	if m.writes%sweepEvery == 0 {
+		m.sweep()
+	}
+
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+
+	return nil
+}
+
+func (m *Memory) sweep() {
+	now := time.Now()
+	for key, e := range m.entries {
+		if now.After(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
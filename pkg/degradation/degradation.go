@@ -0,0 +1,165 @@
+// Package degradation estimates the capacity a lithium-ion battery loses to
+// cycling and calendar ageing. Cycles are counted with the rainflow method on
+// the state of charge, and both cycles and time are weighed by stress factors
+// for depth of discharge, state of charge and temperature, after Xu et al.,
+// "Modeling of Lithium-Ion Battery Degradation for Cell Life Assessment"
+// (IEEE Transactions on Smart Grid, 2018). Like the optimizer it has no
+// dependencies on the rest of the server.
+package degradation
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// Depth of discharge stress (kδ1 δ^kδ2 + kδ3)^-1.
+	depthK1 = 1.40e5
+	depthK2 = -0.501
+	depthK3 = -1.23e5
+	// State of charge stress exp(kσ (σ - σref)).
+	socK   = 1.04
+	socRef = 0.5
+	// Temperature stress exp(kT (T - Tref) Tref / T), in kelvin.
+	temperatureK   = 0.0693
+	temperatureRef = 298.15
+	kelvin         = 273.15
+	// calendarK is the calendar ageing per second at reference conditions.
+	calendarK = 4.14e-10
+	// The solid electrolyte interphase formed early in the life of a cell
+	// takes a share seiShare of the capacity at rate seiRate.
+	seiShare = 0.0575
+	seiRate  = 121
+	// ReferenceTemperatureC is assumed when the battery does not report its
+	// temperature.
+	ReferenceTemperatureC = temperatureRef - kelvin
+	epsilon               = 1e-9
+)
+
+// Cycle is a closed or half cycle of the state of charge. Depth and MeanSoc
+// are fractions of the capacity, Count is 1 for a full and 0.5 for a half
+// cycle.
+type Cycle struct {
+	Depth   float64
+	MeanSoc float64
+	Count   float64
+}
+
+// Counter counts rainflow cycles in a state of charge series that arrives
+// piece by piece. Residue holds the reversals of cycles that have not closed
+// yet, it is all that needs to be kept between calls.
+type Counter struct {
+	Residue []float64
+}
+
+// Add feeds states of charge, as fractions, to the counter and returns the
+// full cycles they close. It implements the four point rainflow method of
+// ASTM E1049 on the reversals of the series.
+func (c *Counter) Add(socs ...float64) []Cycle {
+	var cycles []Cycle
+	for _, soc := range socs {
+		// The last point is checked again when it is extended, as a longer
+		// half cycle can close the cycle before it.
+		c.push(soc)
+
+		for len(c.Residue) >= 4 {
+			n := len(c.Residue)
+			a, b, x, y := c.Residue[n-4], c.Residue[n-3], c.Residue[n-2], c.Residue[n-1]
+			inner, outer := math.Abs(x-b), math.Abs(y-x)
+			if inner > outer+epsilon || inner > math.Abs(b-a)+epsilon {
+				break
+			}
+
+			cycles = append(cycles, Cycle{Depth: inner, MeanSoc: (b + x) / 2, Count: 1})
+			c.Residue = append(c.Residue[:n-3], y)
+		}
+	}
+
+	return cycles
+}
+
+// push appends soc to the residue when it reverses the direction of the
+// series. When soc extends the current half cycle it replaces the last point
+// instead, and a repeated value is ignored.
+func (c *Counter) push(soc float64) {
+	n := len(c.Residue)
+	switch {
+	case n == 0:
+		c.Residue = append(c.Residue, soc)
+		return
+	case math.Abs(soc-c.Residue[n-1]) < epsilon:
+		return
+	case n == 1:
+		c.Residue = append(c.Residue, soc)
+		return
+	}
+
+	rising := c.Residue[n-1] > c.Residue[n-2]
+	if rising == (soc > c.Residue[n-1]) {
+		c.Residue[n-1] = soc
+		return
+	}
+
+	c.Residue = append(c.Residue, soc)
+}
+
+// HalfCycles returns the ranges of the residue as half cycles, the usual way
+// to account for cycles that have not closed.
+func (c *Counter) HalfCycles() []Cycle {
+	cycles := make([]Cycle, 0, max(0, len(c.Residue)-1))
+	for i := 1; i < len(c.Residue); i++ {
+		a, b := c.Residue[i-1], c.Residue[i]
+		cycles = append(cycles, Cycle{Depth: math.Abs(b - a), MeanSoc: (a + b) / 2, Count: 0.5})
+	}
+
+	return cycles
+}
+
+// CycleStress is the ageing a cycle contributes at a battery temperature.
+func CycleStress(c Cycle, temperatureC float64) float64 {
+	if c.Depth < epsilon {
+		return 0
+	}
+
+	depth := math.Min(1, c.Depth)
+	return c.Count * socStress(c.MeanSoc) * temperatureStress(temperatureC) / (depthK1*math.Pow(depth, depthK2) + depthK3)
+}
+
+// CalendarStress is the ageing of resting at a state of charge and
+// temperature for a duration.
+func CalendarStress(d time.Duration, soc, temperatureC float64) float64 {
+	return calendarK * d.Seconds() * socStress(soc) * temperatureStress(temperatureC)
+}
+
+// CapacityLoss converts accumulated stress into the fraction of capacity
+// lost. The loss grows quickly while the interphase forms and then almost
+// linearly with the stress.
+func CapacityLoss(stress float64) float64 {
+	return 1 - seiShare*math.Exp(-seiRate*stress) - (1-seiShare)*math.Exp(-stress)
+}
+
+// MarginalLoss is the capacity lost per unit of additional stress at the
+// accumulated stress.
+func MarginalLoss(stress float64) float64 {
+	return seiShare*seiRate*math.Exp(-seiRate*stress) + (1-seiShare)*math.Exp(-stress)
+}
+
+// WearPerKwh is the fraction of capacity lost per kWh discharged in cycles
+// of the given depth by a battery that has accumulated stress.
+func WearPerKwh(capacityKwh, depth, stress float64) float64 {
+	if capacityKwh <= 0 || depth < epsilon {
+		return 0
+	}
+
+	cycle := Cycle{Depth: depth, MeanSoc: socRef, Count: 1}
+	return CycleStress(cycle, ReferenceTemperatureC) * MarginalLoss(stress) / (depth * capacityKwh)
+}
+
+func socStress(soc float64) float64 {
+	return math.Exp(socK * (soc - socRef))
+}
+
+func temperatureStress(temperatureC float64) float64 {
+	t := temperatureC + kelvin
+	return math.Exp(temperatureK * (t - temperatureRef) * temperatureRef / t)
+}
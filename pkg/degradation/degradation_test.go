@@ -0,0 +1,113 @@
+package degradation
+
+import (
+	"math"
+	"testing"
+)
+
+// astm is the rainflow counting example of ASTM E1049-85 (figure 6) as
+// states of charge, load -5 to 5 mapped to 0 to 1.
+var astm = []float64{0.3, 0.6, 0.2, 1.0, 0.4, 0.8, 0.1, 0.9, 0.3}
+
+// astmCounts are the cycles of the example by range in units of the
+// standard: half cycles of 3, 6 and 9, one and a half of 4 and one of 8.
+var astmCounts = map[int]float64{3: 0.5, 4: 1.5, 6: 0.5, 8: 1.0, 9: 0.5}
+
+func TestCounterASTM(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks [][]float64
+	}{
+		{name: "reversals", chunks: [][]float64{astm}},
+		{name: "finely sampled", chunks: [][]float64{sample(astm, 10)}},
+		{name: "point by point", chunks: split(sample(astm, 4))},
+		{name: "repeated values", chunks: [][]float64{repeat(sample(astm, 3))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Counter
+			var cycles []Cycle
+			for _, chunk := range tt.chunks {
+				cycles = append(cycles, c.Add(chunk...)...)
+			}
+
+			got := counts(append(cycles, c.HalfCycles()...))
+			if len(got) != len(astmCounts) {
+				t.Fatalf("counts = %v, want %v", got, astmCounts)
+			}
+			for r, want := range astmCounts {
+				if math.Abs(got[r]-want) > 1e-9 {
+					t.Errorf("count of range %d = %v, want %v (all %v)", r, got[r], want, got)
+				}
+			}
+
+			if len(cycles) != 1 || math.Abs(cycles[0].Depth-0.4) > 1e-9 || math.Abs(cycles[0].MeanSoc-0.6) > 1e-9 {
+				t.Errorf("full cycles = %+v, want one of depth 0.4 around 0.6", cycles)
+			}
+		})
+	}
+}
+
+func TestCounterExtendedPointClosesCycle(t *testing.T) {
+	tests := []struct {
+		name string
+		socs []float64
+	}{
+		{name: "reversals", socs: []float64{0, 0.5, 0.3, 0.9, 0.1}},
+		{name: "extended", socs: []float64{0, 0.5, 0.3, 0.35, 0.9, 0.1}},
+		{name: "extended past the cycle", socs: []float64{0, 0.5, 0.3, 0.35, 0.6, 0.9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Counter
+			cycles := c.Add(tt.socs...)
+			if len(cycles) != 1 || math.Abs(cycles[0].Depth-0.2) > 1e-9 || math.Abs(cycles[0].MeanSoc-0.4) > 1e-9 {
+				t.Errorf("cycles = %+v, want one of depth 0.2 around 0.4", cycles)
+			}
+		})
+	}
+}
+
+// sample puts n points on every straight line between reversals, like
+// telemetry that is sampled finer than the state of charge turns.
+func sample(reversals []float64, n int) []float64 {
+	res := []float64{reversals[0]}
+	for i := 1; i < len(reversals); i++ {
+		a, b := reversals[i-1], reversals[i]
+		for j := 1; j <= n; j++ {
+			res = append(res, a+(b-a)*float64(j)/float64(n))
+		}
+	}
+
+	return res
+}
+
+func split(socs []float64) [][]float64 {
+	res := make([][]float64, 0, len(socs))
+	for _, soc := range socs {
+		res = append(res, []float64{soc})
+	}
+
+	return res
+}
+
+func repeat(socs []float64) []float64 {
+	res := make([]float64, 0, 2*len(socs))
+	for _, soc := range socs {
+		res = append(res, soc, soc)
+	}
+
+	return res
+}
+
+// counts sums the cycle counts by range in units of ASTM E1049.
+func counts(cycles []Cycle) map[int]float64 {
+	res := map[int]float64{}
+	for _, c := range cycles {
+		res[int(math.Round(c.Depth*10))] += c.Count
+	}
+
+	return res
+}
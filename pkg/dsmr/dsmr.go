@@ -0,0 +1,363 @@
+// Package dsmr parses the telegrams Dutch and Belgian smart meters send on
+// their P1 port, as specified by DSMR 4 and 5.
+//
+// A telegram starts with a /-prefixed identification line, followed by one
+// COSEM object per line and ends with ! and a CRC16 of everything before it:
+//
+//	/ISk5\2MT382-1000
+//
+//	1-3:0.2.8(50)
+//	0-0:1.0.0(101209113020W)
+//	1-0:1.7.0(01.193*kW)
+//	!6D5F
+package dsmr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OBIS codes of the objects the parser interprets, other objects are kept in
+// Telegram.Objects.
+const (
+	CodeVersion          = "1-3:0.2.8"
+	CodeVersionBelgium   = "0-0:96.1.4"
+	CodeTimestamp        = "0-0:1.0.0"
+	CodeEquipmentID      = "0-0:96.1.1"
+	CodeImportTariff1    = "1-0:1.8.1"
+	CodeImportTariff2    = "1-0:1.8.2"
+	CodeExportTariff1    = "1-0:2.8.1"
+	CodeExportTariff2    = "1-0:2.8.2"
+	CodeTariff           = "0-0:96.14.0"
+	CodeImportPower      = "1-0:1.7.0"
+	CodeExportPower      = "1-0:2.7.0"
+	codeDeviceType       = "24.1.0"
+	codeDeviceReading    = "24.2.1"
+	deviceTypeGas        = 3
+	deviceTypeGasBelgium = 7
+)
+
+// phaseCodes are the OBIS codes of the per phase measurements of L1 to L3.
+var phaseCodes = [3]struct{ importKw, exportKw, voltage, current string }{
+	{"1-0:21.7.0", "1-0:22.7.0", "1-0:32.7.0", "1-0:31.7.0"},
+	{"1-0:41.7.0", "1-0:42.7.0", "1-0:52.7.0", "1-0:51.7.0"},
+	{"1-0:61.7.0", "1-0:62.7.0", "1-0:72.7.0", "1-0:71.7.0"},
+}
+
+var (
+	ErrIncomplete = errors.New("telegram must start with / and end with !")
+	ErrChecksum   = errors.New("telegram checksum does not match")
+
+	objectPattern = regexp.MustCompile(`^(\d+-\d+:\d+\.\d+\.\d+)((?:\([^()]*\))+)$`)
+	valuePattern  = regexp.MustCompile(`\(([^()]*)\)`)
+
+	// Meters report local time with S for summer and W for winter time.
+	summerTime = time.FixedZone("CEST", 2*60*60)
+	winterTime = time.FixedZone("CET", 60*60)
+)
+
+type Phase struct {
+	ImportKw float64  `json:"importKw"`
+	ExportKw float64  `json:"exportKw"`
+	VoltageV *float64 `json:"voltageV,omitempty"`
+	CurrentA *float64 `json:"currentA,omitempty"`
+}
+
+type Gas struct {
+	MeasuredAt time.Time `json:"measuredAt"`
+	M3         float64   `json:"m3"`
+}
+
+type Telegram struct {
+	Header      string
+	Version     string
+	Timestamp   time.Time
+	EquipmentID string
+	// Energy registers in kWh, tariff 1 is the low and tariff 2 the normal
+	// tariff in the Netherlands.
+	ImportTariff1Kwh float64
+	ImportTariff2Kwh float64
+	ExportTariff1Kwh float64
+	ExportTariff2Kwh float64
+	Tariff           int
+	ImportKw         float64
+	ExportKw         float64
+	// Phases holds one entry for single phase and three for three phase
+	// connections, empty when the meter does not report them.
+	Phases []Phase
+	Gas    *Gas
+	// Objects are the raw values of every object by OBIS code.
+	Objects map[string][]string
+}
+
+func (t *Telegram) ImportedKwh() float64 {
+	return t.ImportTariff1Kwh + t.ImportTariff2Kwh
+}
+
+func (t *Telegram) ExportedKwh() float64 {
+	return t.ExportTariff1Kwh + t.ExportTariff2Kwh
+}
+
+// NetKw is the power drawn from the grid, negative while exporting.
+func (t *Telegram) NetKw() float64 {
+	return t.ImportKw - t.ExportKw
+}
+
+// Parse validates the checksum of a telegram and decodes its objects. Data
+// before the identification line is ignored, so a buffer that starts in the
+// middle of a previous telegram can be passed as is.
+func Parse(raw []byte) (*Telegram, error) {
+	start := bytes.IndexByte(raw, '/')
+	if start < 0 {
+		return nil, ErrIncomplete
+	}
+	raw = raw[start:]
+
+	end := bytes.IndexByte(raw, '!')
+	if end < 0 {
+		return nil, ErrIncomplete
+	}
+
+	sum := strings.TrimSpace(string(raw[end+1:]))
+	if len(sum) < 4 {
+		return nil, fmt.Errorf("%w: DSMR 4 and 5 telegrams end with a CRC16", ErrIncomplete)
+	}
+	expected, err := strconv.ParseUint(sum[:4], 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum %q: %w", sum[:4], err)
+	}
+	if CRC16(raw[:end+1]) != uint16(expected) {
+		return nil, ErrChecksum
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(raw[:end]), "\r\n", "\n"), "\n")
+	t := &Telegram{Header: strings.TrimPrefix(lines[0], "/"), Objects: make(map[string][]string)}
+	for i, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		m := objectPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: malformed object %q", i+2, line)
+		}
+
+		var values []string
+		for _, v := range valuePattern.FindAllStringSubmatch(m[2], -1) {
+			values = append(values, v[1])
+		}
+		t.Objects[m[1]] = values
+	}
+
+	if err := t.decode(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Telegram) decode() error {
+	var err error
+	t.Version = t.first(CodeVersion)
+	if t.Version == "" {
+		t.Version = t.first(CodeVersionBelgium)
+	}
+	t.EquipmentID = t.first(CodeEquipmentID)
+
+	if v := t.first(CodeTimestamp); v != "" {
+		if t.Timestamp, err = ParseTimestamp(v); err != nil {
+			return fmt.Errorf("%s: %w", CodeTimestamp, err)
+		}
+	}
+
+	registers := []struct {
+		code string
+		dst  *float64
+	}{
+		{CodeImportTariff1, &t.ImportTariff1Kwh},
+		{CodeImportTariff2, &t.ImportTariff2Kwh},
+		{CodeExportTariff1, &t.ExportTariff1Kwh},
+		{CodeExportTariff2, &t.ExportTariff2Kwh},
+		{CodeImportPower, &t.ImportKw},
+		{CodeExportPower, &t.ExportKw},
+	}
+	for _, r := range registers {
+		if v, ok, err := t.quantity(r.code); err != nil {
+			return err
+		} else if ok {
+			*r.dst = v
+		}
+	}
+
+	if v := t.first(CodeTariff); v != "" {
+		if t.Tariff, err = strconv.Atoi(v); err != nil {
+			return fmt.Errorf("%s: invalid tariff %q", CodeTariff, v)
+		}
+	}
+
+	if err := t.decodePhases(); err != nil {
+		return err
+	}
+
+	return t.decodeGas()
+}
+
+func (t *Telegram) decodePhases() error {
+	for _, codes := range phaseCodes {
+		var p Phase
+		found := false
+		for _, q := range []struct {
+			code string
+			dst  *float64
+		}{{codes.importKw, &p.ImportKw}, {codes.exportKw, &p.ExportKw}} {
+			v, ok, err := t.quantity(q.code)
+			if err != nil {
+				return err
+			}
+			if ok {
+				*q.dst, found = v, true
+			}
+		}
+
+		for _, q := range []struct {
+			code string
+			dst  **float64
+		}{{codes.voltage, &p.VoltageV}, {codes.current, &p.CurrentA}} {
+			v, ok, err := t.quantity(q.code)
+			if err != nil {
+				return err
+			}
+			if ok {
+				*q.dst, found = &v, true
+			}
+		}
+
+		if !found {
+			break
+		}
+		t.Phases = append(t.Phases, p)
+	}
+
+	return nil
+}
+
+// decodeGas reads the gas meter among the devices on the M-Bus channels 1 to
+// 4, the reading carries its own timestamp since gas meters report hourly or
+// every five minutes.
+func (t *Telegram) decodeGas() error {
+	for channel := 1; channel <= 4; channel++ {
+		prefix := fmt.Sprintf("0-%d:", channel)
+		kind, err := strconv.Atoi(t.first(prefix + codeDeviceType))
+		if err != nil || (kind != deviceTypeGas && kind != deviceTypeGasBelgium) {
+			continue
+		}
+
+		values := t.Objects[prefix+codeDeviceReading]
+		if len(values) != 2 {
+			return fmt.Errorf("%s%s: expected timestamp and reading", prefix, codeDeviceReading)
+		}
+
+		at, err := ParseTimestamp(values[0])
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", prefix, codeDeviceReading, err)
+		}
+
+		m3, err := parseQuantity(values[1])
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", prefix, codeDeviceReading, err)
+		}
+
+		t.Gas = &Gas{MeasuredAt: at, M3: m3}
+		return nil
+	}
+
+	return nil
+}
+
+func (t *Telegram) first(code string) string {
+	if values := t.Objects[code]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (t *Telegram) quantity(code string) (float64, bool, error) {
+	values, ok := t.Objects[code]
+	if !ok || len(values) == 0 {
+		return 0, false, nil
+	}
+
+	v, err := parseQuantity(values[0])
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", code, err)
+	}
+
+	return v, true, nil
+}
+
+// parseQuantity parses a value such as 001234.567*kWh, watt based units are
+// converted to their kilo variant.
+func parseQuantity(s string) (float64, error) {
+	number, unit, _ := strings.Cut(s, "*")
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+
+	switch unit {
+	case "W", "Wh":
+		v /= 1000
+	}
+
+	return v, nil
+}
+
+// ParseTimestamp parses the YYMMDDhhmmssX timestamps of meters, where X is S
+// during summer and W during winter time.
+func ParseTimestamp(s string) (time.Time, error) {
+	if len(s) != 13 {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var loc *time.Location
+	switch s[12] {
+	case 'S':
+		loc = summerTime
+	case 'W':
+		loc = winterTime
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp %q, expected S or W suffix", s)
+	}
+
+	t, err := time.ParseInLocation("060102150405", s[:12], loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	return t.UTC(), nil
+}
+
+// CRC16 is the CRC-16/ARC checksum DSMR computes over a telegram from the
+// leading / up to and including the !.
+func CRC16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+
+	return crc
+}
@@ -0,0 +1,143 @@
+package dsmr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// example is the DSMR 5 example telegram of the P1 companion standard, up to
+// and including the !, with the CRLF line endings meters send.
+var example = strings.ReplaceAll(`/ISk5\2MT382-1000
+
+1-3:0.2.8(50)
+0-0:1.0.0(101209113020W)
+0-0:96.1.1(4B384547303034303436333935353037)
+1-0:1.8.1(123456.789*kWh)
+1-0:1.8.2(123456.789*kWh)
+1-0:2.8.1(123456.789*kWh)
+1-0:2.8.2(123456.789*kWh)
+0-0:96.14.0(0002)
+1-0:1.7.0(01.193*kW)
+1-0:2.7.0(00.000*kW)
+0-0:96.7.21(00004)
+0-0:96.7.9(00002)
+1-0:99.97.0(2)(0-0:96.7.19)(101208152415W)(0000000240*s)(101208151004W)(0000000301*s)
+1-0:32.32.0(00002)
+1-0:52.32.0(00001)
+1-0:72.32.0(00000)
+1-0:32.36.0(00000)
+1-0:52.36.0(00003)
+1-0:72.36.0(00000)
+0-0:96.13.0(303132333435363738393A3B3C3D3E3F303132333435363738393A3B3C3D3E3F303132333435363738393A3B3C3D3E3F303132333435363738393A3B3C3D3E3F303132333435363738393A3B3C3D3E3F)
+1-0:32.7.0(220.1*V)
+1-0:52.7.0(220.2*V)
+1-0:72.7.0(220.3*V)
+1-0:31.7.0(001*A)
+1-0:51.7.0(002*A)
+1-0:71.7.0(003*A)
+1-0:21.7.0(01.111*kW)
+1-0:41.7.0(02.222*kW)
+1-0:61.7.0(03.333*kW)
+1-0:22.7.0(04.444*kW)
+1-0:42.7.0(05.555*kW)
+1-0:62.7.0(06.666*kW)
+0-1:24.1.0(003)
+0-1:96.1.0(3232323241424344313233343536373839)
+0-1:24.2.1(101209112500W)(12785.123*m3)
+!`, "\n", "\r\n")
+
+// seal appends the checksum the meter sends after the !.
+func seal(body string) string {
+	return fmt.Sprintf("%s%04X\r\n", body, CRC16([]byte(body)))
+}
+
+func TestCRC16(t *testing.T) {
+	// The check value of CRC-16/ARC.
+	if got := CRC16([]byte("123456789")); got != 0xBB3D {
+		t.Errorf("CRC16 = %04X, want BB3D", got)
+	}
+}
+
+func TestParseExample(t *testing.T) {
+	// A buffer may start in the middle of the previous telegram.
+	tg, err := Parse([]byte("62.7.0(06.666*kW)\r\n!1234\r\n" + seal(example)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if tg.Header != `ISk5\2MT382-1000` || tg.Version != "50" || tg.EquipmentID != "4B384547303034303436333935353037" {
+		t.Errorf("header %q, version %q, equipment %q", tg.Header, tg.Version, tg.EquipmentID)
+	}
+	if want := time.Date(2010, 12, 9, 10, 30, 20, 0, time.UTC); !tg.Timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", tg.Timestamp, want)
+	}
+	if tg.ImportedKwh() != 246913.578 || tg.ExportedKwh() != 246913.578 {
+		t.Errorf("imported %v kWh, exported %v kWh, want 246913.578 each", tg.ImportedKwh(), tg.ExportedKwh())
+	}
+	if tg.Tariff != 2 || tg.ImportKw != 1.193 || tg.ExportKw != 0 {
+		t.Errorf("tariff %d, import %v kW, export %v kW", tg.Tariff, tg.ImportKw, tg.ExportKw)
+	}
+
+	if len(tg.Phases) != 3 {
+		t.Fatalf("got %d phases, want 3", len(tg.Phases))
+	}
+	l3 := tg.Phases[2]
+	if l3.ImportKw != 3.333 || l3.ExportKw != 6.666 || l3.VoltageV == nil || *l3.VoltageV != 220.3 || l3.CurrentA == nil || *l3.CurrentA != 3 {
+		t.Errorf("L3 = %+v", l3)
+	}
+
+	if tg.Gas == nil {
+		t.Fatal("gas reading is missing")
+	}
+	if want := time.Date(2010, 12, 9, 10, 25, 0, 0, time.UTC); !tg.Gas.MeasuredAt.Equal(want) || tg.Gas.M3 != 12785.123 {
+		t.Errorf("gas = %+v", tg.Gas)
+	}
+
+	if got := tg.Objects["1-0:99.97.0"]; len(got) != 6 {
+		t.Errorf("power failure log has %d values, want 6", len(got))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	sealed := seal(example)
+	bad := []byte(sealed)
+	// Change a digit of the import power, the checksum no longer matches.
+	i := strings.Index(sealed, "01.193")
+	bad[i+1] = '2'
+
+	tests := []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{name: "bad checksum", raw: string(bad), want: ErrChecksum},
+		{name: "truncated before the end", raw: sealed[:len(sealed)/2], want: ErrIncomplete},
+		{name: "truncated checksum", raw: example + "EF", want: ErrIncomplete},
+		{name: "no identification line", raw: "1-0:1.7.0(01.193*kW)\r\n!0000\r\n", want: ErrIncomplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.raw)); !errors.Is(err, tt.want) {
+				t.Errorf("Parse = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	summer, err := ParseTimestamp("240701120000S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC); !summer.Equal(want) {
+		t.Errorf("summer time = %v, want %v", summer, want)
+	}
+
+	if _, err := ParseTimestamp("240701120000"); err == nil {
+		t.Error("timestamp without S or W suffix was accepted")
+	}
+}
@@ -0,0 +1,86 @@
+// Package evsim models the vehicles and charge points of the development
+// simulator. A battery follows the power its charger offers within what the
+// vehicle accepts at its state of charge, and scenarios describe when which
+// vehicle is plugged in to which charger. Like the optimizer it has no
+// dependencies on the rest of the server.
+package evsim
+
+import (
+	"math"
+	"time"
+)
+
+// trickleShare is the part of the maximum charge power a vehicle still
+// accepts just below a full battery, so tapering ends at 100% instead of
+// approaching it forever.
+const trickleShare = 0.05
+
+// Battery is the state of a vehicle while the simulation runs.
+type Battery struct {
+	vehicle *Vehicle
+	// Soc is the state of charge in percent.
+	Soc float64
+}
+
+func NewBattery(v *Vehicle) *Battery {
+	return &Battery{vehicle: v, Soc: v.InitialSoc}
+}
+
+func (b *Battery) EnergyKwh() float64 {
+	return b.Soc / 100 * b.vehicle.CapacityKwh
+}
+
+// ChargeLimitKw is the grid power the vehicle accepts at its state of
+// charge. Above the taper threshold it drops linearly to a trickle at 100%.
+func (b *Battery) ChargeLimitKw() float64 {
+	v := b.vehicle
+	switch {
+	case b.Soc >= 100:
+		return 0
+	case v.TaperSoc <= 0 || b.Soc <= v.TaperSoc:
+		return v.MaxChargeKw
+	}
+
+	share := (100 - b.Soc) / (100 - v.TaperSoc)
+	return v.MaxChargeKw * math.Max(trickleShare, share)
+}
+
+// DischargeLimitKw is the grid power the vehicle can feed back, zero at or
+// below its minimum state of charge.
+func (b *Battery) DischargeLimitKw() float64 {
+	if b.Soc <= b.vehicle.MinSoc {
+		return 0
+	}
+
+	return b.vehicle.MaxDischargeKw
+}
+
+// Step lets the battery follow a grid power setpoint for d, negative to
+// discharge. It returns the grid power that actually flowed, which is lower
+// than the setpoint when the vehicle does not accept it or the battery fills
+// up or empties within d. Losses are taken on the battery side in both
+// directions.
+func (b *Battery) Step(setpointKw float64, d time.Duration) float64 {
+	hours := d.Hours()
+	if hours <= 0 || setpointKw == 0 {
+		return 0
+	}
+
+	v := b.vehicle
+	if setpointKw > 0 {
+		powerKw := math.Min(setpointKw, b.ChargeLimitKw())
+		roomKwh := (100 - b.Soc) / 100 * v.CapacityKwh
+		powerKw = math.Min(powerKw, roomKwh/v.Efficiency/hours)
+		b.Soc = math.Min(100, b.Soc+powerKw*hours*v.Efficiency/v.CapacityKwh*100)
+		return powerKw
+	}
+
+	powerKw := math.Min(-setpointKw, b.DischargeLimitKw())
+	availableKwh := math.Max(0, b.Soc-v.MinSoc) / 100 * v.CapacityKwh
+	powerKw = math.Min(powerKw, availableKwh*v.Efficiency/hours)
+	if powerKw <= 0 {
+		return 0
+	}
+	b.Soc = math.Max(v.MinSoc, b.Soc-powerKw*hours/v.Efficiency/v.CapacityKwh*100)
+	return -powerKw
+}
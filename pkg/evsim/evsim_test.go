@@ -0,0 +1,242 @@
+package evsim
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// vehicle has a 40 kWh battery with 10 kW in both directions, losing a
+// tenth on the way in and out, and tapers above 80%.
+var vehicle = Vehicle{
+	ID:             "ev-1",
+	Charger:        "cp-1",
+	PlugInMinute:   0,
+	PlugOutMinute:  60,
+	CapacityKwh:    40,
+	InitialSoc:     50,
+	MinSoc:         20,
+	MaxChargeKw:    10,
+	MaxDischargeKw: 10,
+	TaperSoc:       80,
+	Efficiency:     0.9,
+}
+
+func TestBatteryLimits(t *testing.T) {
+	tests := []struct {
+		soc       float64
+		charge    float64
+		discharge float64
+	}{
+		{soc: 20, charge: 10, discharge: 0},
+		{soc: 50, charge: 10, discharge: 10},
+		{soc: 80, charge: 10, discharge: 10},
+		{soc: 90, charge: 5, discharge: 10},
+		{soc: 99.5, charge: 10 * trickleShare, discharge: 10},
+		{soc: 100, charge: 0, discharge: 10},
+	}
+
+	for _, tt := range tests {
+		v := vehicle
+		b := NewBattery(&v)
+		b.Soc = tt.soc
+		if got := b.ChargeLimitKw(); math.Abs(got-tt.charge) > 1e-9 {
+			t.Errorf("charge limit at %v%% = %v, want %v", tt.soc, got, tt.charge)
+		}
+		if got := b.DischargeLimitKw(); got != tt.discharge {
+			t.Errorf("discharge limit at %v%% = %v, want %v", tt.soc, got, tt.discharge)
+		}
+	}
+}
+
+func TestBatteryStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		soc        float64
+		setpointKw float64
+		d          time.Duration
+		powerKw    float64
+		endSoc     float64
+	}{
+		{
+			// 7 kWh from the grid stores 6.3 kWh.
+			name:       "charging below the limit",
+			soc:        50,
+			setpointKw: 7,
+			d:          time.Hour,
+			powerKw:    7,
+			endSoc:     65.75,
+		},
+		{
+			// The taper allows 2.5 kW but 2 kWh of room fills first.
+			name:       "charging stops when full",
+			soc:        95,
+			setpointKw: 10,
+			d:          time.Hour,
+			powerKw:    2 / 0.9,
+			endSoc:     100,
+		},
+		{
+			name:       "discharging at the limit",
+			soc:        50,
+			setpointKw: -20,
+			d:          30 * time.Minute,
+			powerKw:    -10,
+			endSoc:     50 - 5/0.9/40*100,
+		},
+		{
+			// 0.4 kWh above the minimum delivers 0.36 kWh.
+			name:       "discharging stops at the minimum",
+			soc:        21,
+			setpointKw: -10,
+			d:          time.Hour,
+			powerKw:    -0.36,
+			endSoc:     20,
+		},
+		{
+			name:       "no discharging below the minimum",
+			soc:        15,
+			setpointKw: -10,
+			d:          time.Hour,
+			powerKw:    0,
+			endSoc:     15,
+		},
+		{
+			name:       "no time passes",
+			soc:        50,
+			setpointKw: 10,
+			d:          0,
+			powerKw:    0,
+			endSoc:     50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vehicle
+			b := NewBattery(&v)
+			b.Soc = tt.soc
+
+			if got := b.Step(tt.setpointKw, tt.d); math.Abs(got-tt.powerKw) > 1e-9 {
+				t.Errorf("Step = %v kW, want %v", got, tt.powerKw)
+			}
+			if math.Abs(b.Soc-tt.endSoc) > 1e-9 {
+				t.Errorf("soc = %v%%, want %v", b.Soc, tt.endSoc)
+			}
+		})
+	}
+}
+
+func scenario() Scenario {
+	second := vehicle
+	second.ID, second.PlugInMinute, second.PlugOutMinute = "ev-2", 60, 120
+
+	return Scenario{
+		Chargers: []Charger{{Serial: "cp-1", MaxKw: 11}},
+		Vehicles: []Vehicle{second, vehicle},
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	sc := scenario()
+	if err := sc.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if sc.TimeScale != 1 || sc.MeterIntervalSeconds != defaultMeterIntervalSeconds {
+		t.Errorf("time scale %v and meter interval %v were not defaulted", sc.TimeScale, sc.MeterIntervalSeconds)
+	}
+	if c := sc.Chargers[0]; c.Version != VersionOcpp16 || c.Connectors != 1 {
+		t.Errorf("charger %+v was not defaulted to one OCPP 1.6 connector", c)
+	}
+	if v := sc.Vehicles[0]; v.Connector != 1 || v.IdTag != v.ID {
+		t.Errorf("vehicle %+v was not defaulted to connector 1 with its id as tag", v)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(sc *Scenario)
+		want   string
+	}{
+		{name: "no chargers", modify: func(sc *Scenario) { sc.Chargers = nil }, want: "no chargers"},
+		{name: "unknown version", modify: func(sc *Scenario) { sc.Chargers[0].Version = "ocpp2.1" }, want: "version"},
+		{
+			name:   "duplicate charger",
+			modify: func(sc *Scenario) { sc.Chargers = append(sc.Chargers, sc.Chargers[0]) },
+			want:   "listed twice",
+		},
+		{name: "unknown charger", modify: func(sc *Scenario) { sc.Vehicles[0].Charger = "cp-2" }, want: "not in the scenario"},
+		{name: "missing connector", modify: func(sc *Scenario) { sc.Vehicles[0].Connector = 2 }, want: "no connector 2"},
+		{name: "plugs out before in", modify: func(sc *Scenario) { sc.Vehicles[0].PlugOutMinute = 30 }, want: "plugOutMinute"},
+		{name: "plugs in after the end", modify: func(sc *Scenario) { sc.DurationMinutes = 30 }, want: "after the simulation ended"},
+		{name: "taper at full", modify: func(sc *Scenario) { sc.Vehicles[0].TaperSoc = 100 }, want: "taperSoc"},
+		{name: "no efficiency", modify: func(sc *Scenario) { sc.Vehicles[0].Efficiency = 0 }, want: "efficiency"},
+		{
+			name:   "overlapping vehicles on a connector",
+			modify: func(sc *Scenario) { sc.Vehicles[0].PlugInMinute = 59 },
+			want:   "same connector at the same time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := scenario()
+			tt.modify(&sc)
+			if err := sc.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestScenarioDuration(t *testing.T) {
+	sc := scenario()
+	if got := sc.Duration(); got != 121*time.Minute {
+		t.Errorf("Duration = %v, want a minute after the last vehicle left", got)
+	}
+
+	sc.DurationMinutes = 90
+	if got := sc.Duration(); got != 90*time.Minute {
+		t.Errorf("Duration = %v, want 90m", got)
+	}
+}
+
+func TestScenarioReplicate(t *testing.T) {
+	sc := scenario()
+	if got := sc.Replicate(1); !reflect.DeepEqual(got, sc) {
+		t.Errorf("Replicate(1) = %+v, want the scenario unchanged", got)
+	}
+
+	sc.Vehicles[1].IdTag = "tag"
+	got := sc.Replicate(2)
+	if len(got.Chargers) != 2 || len(got.Vehicles) != 4 {
+		t.Fatalf("Replicate(2) has %d chargers and %d vehicles, want 2 and 4", len(got.Chargers), len(got.Vehicles))
+	}
+	if got.Chargers[1].Serial != "cp-1-2" {
+		t.Errorf("second charger is %q, want cp-1-2", got.Chargers[1].Serial)
+	}
+	if v := got.Vehicles[3]; v.ID != "ev-1-2" || v.Charger != "cp-1-2" || v.IdTag != "tag-2" {
+		t.Errorf("last vehicle = %+v, want ev-1-2 on cp-1-2 with tag-2", v)
+	}
+	if v := got.Vehicles[2]; v.IdTag != "" {
+		t.Errorf("vehicle without a tag got %q", v.IdTag)
+	}
+	if sc.Chargers[0].Serial != "cp-1" || sc.Vehicles[0].ID != "ev-2" {
+		t.Error("Replicate changed the original scenario")
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("replicated scenario is invalid: %v", err)
+	}
+}
+
+func TestScenarioVehiclesOn(t *testing.T) {
+	sc := scenario()
+	got := sc.VehiclesOn("cp-1")
+	if len(got) != 2 || got[0].ID != "ev-1" || got[1].ID != "ev-2" {
+		t.Errorf("VehiclesOn = %v, want ev-1 and ev-2 in plug-in order", got)
+	}
+	if got := sc.VehiclesOn("cp-2"); got != nil {
+		t.Errorf("VehiclesOn unknown charger = %v, want nil", got)
+	}
+}
@@ -0,0 +1,236 @@
+package evsim
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	VersionOcpp16  = "ocpp1.6"
+	VersionOcpp201 = "ocpp2.0.1"
+
+	defaultMeterIntervalSeconds = 60
+)
+
+// Scenario describes the chargers to connect and the vehicles that plug in
+// to them. Times of vehicles are minutes since the simulation started.
+type Scenario struct {
+	// TimeScale is the number of simulated seconds per real second, one
+	// when zero. Timestamps sent to the server follow the simulated clock.
+	TimeScale float64 `json:"timeScale"`
+	// DurationMinutes is how long the simulation runs, zero to stop one
+	// minute after the last vehicle left.
+	DurationMinutes float64 `json:"durationMinutes"`
+	// MeterIntervalSeconds is the simulated time between meter values, 60
+	// when zero.
+	MeterIntervalSeconds int       `json:"meterIntervalSeconds"`
+	Chargers             []Charger `json:"chargers"`
+	Vehicles             []Vehicle `json:"vehicles"`
+}
+
+type Charger struct {
+	// Serial is the identity the charger connects with, it must be
+	// registered as a charger on the server.
+	Serial string `json:"serial"`
+	// Version is the OCPP version the charger speaks, 1.6 when empty.
+	Version string `json:"version"`
+	// Connectors is the number of connectors, one when zero.
+	Connectors int     `json:"connectors"`
+	MaxKw      float64 `json:"maxKw"`
+	// Bidirectional chargers discharge when a 2.0.1 profile sets a negative
+	// limit, 1.6 has no way to request it.
+	Bidirectional bool `json:"bidirectional"`
+}
+
+type Vehicle struct {
+	ID      string `json:"id"`
+	Charger string `json:"charger"`
+	// Connector is the connector of the charger the vehicle plugs in to,
+	// one when zero.
+	Connector     int     `json:"connector"`
+	PlugInMinute  float64 `json:"plugInMinute"`
+	PlugOutMinute float64 `json:"plugOutMinute"`
+	CapacityKwh   float64 `json:"capacityKwh"`
+	InitialSoc    float64 `json:"initialSoc"`
+	// MinSoc is the state of charge below which the vehicle refuses to
+	// discharge.
+	MinSoc         float64 `json:"minSoc"`
+	MaxChargeKw    float64 `json:"maxChargeKw"`
+	MaxDischargeKw float64 `json:"maxDischargeKw"`
+	// TaperSoc is the state of charge above which the charge power drops,
+	// zero when the vehicle charges at full power until it is full.
+	TaperSoc float64 `json:"taperSoc"`
+	// Efficiency is the one way efficiency of charging and discharging.
+	Efficiency float64 `json:"efficiency"`
+	// IdTag authorises the transaction, the vehicle id when empty.
+	IdTag string `json:"idTag"`
+}
+
+// Validate checks the scenario and fills in the defaults.
+func (sc *Scenario) Validate() error {
+	if sc.TimeScale == 0 {
+		sc.TimeScale = 1
+	}
+	if sc.MeterIntervalSeconds == 0 {
+		sc.MeterIntervalSeconds = defaultMeterIntervalSeconds
+	}
+
+	switch {
+	case sc.TimeScale < 0:
+		return errors.New("timeScale cannot be negative")
+	case sc.DurationMinutes < 0:
+		return errors.New("durationMinutes cannot be negative")
+	case sc.MeterIntervalSeconds < 0:
+		return errors.New("meterIntervalSeconds cannot be negative")
+	case len(sc.Chargers) == 0:
+		return errors.New("scenario has no chargers")
+	}
+
+	chargers := make(map[string]*Charger, len(sc.Chargers))
+	for i := range sc.Chargers {
+		c := &sc.Chargers[i]
+		if c.Version == "" {
+			c.Version = VersionOcpp16
+		}
+		if c.Connectors == 0 {
+			c.Connectors = 1
+		}
+
+		switch {
+		case c.Serial == "":
+			return fmt.Errorf("charger %d has no serial", i)
+		case chargers[c.Serial] != nil:
+			return fmt.Errorf("charger %s is listed twice", c.Serial)
+		case c.Version != VersionOcpp16 && c.Version != VersionOcpp201:
+			return fmt.Errorf("charger %s: version must be %s or %s", c.Serial, VersionOcpp16, VersionOcpp201)
+		case c.Connectors < 0:
+			return fmt.Errorf("charger %s: connectors cannot be negative", c.Serial)
+		case c.MaxKw <= 0:
+			return fmt.Errorf("charger %s: maxKw must be positive", c.Serial)
+		}
+		chargers[c.Serial] = c
+	}
+
+	for i := range sc.Vehicles {
+		v := &sc.Vehicles[i]
+		if v.Connector == 0 {
+			v.Connector = 1
+		}
+		if v.IdTag == "" {
+			v.IdTag = v.ID
+		}
+
+		c := chargers[v.Charger]
+		switch {
+		case v.ID == "":
+			return fmt.Errorf("vehicle %d has no id", i)
+		case c == nil:
+			return fmt.Errorf("vehicle %s: charger %q is not in the scenario", v.ID, v.Charger)
+		case v.Connector < 0 || v.Connector > c.Connectors:
+			return fmt.Errorf("vehicle %s: charger %s has no connector %d", v.ID, c.Serial, v.Connector)
+		case v.PlugInMinute < 0 || v.PlugOutMinute <= v.PlugInMinute:
+			return fmt.Errorf("vehicle %s: plugOutMinute must be after plugInMinute", v.ID)
+		case sc.DurationMinutes > 0 && v.PlugInMinute >= sc.DurationMinutes:
+			return fmt.Errorf("vehicle %s plugs in after the simulation ended", v.ID)
+		case v.CapacityKwh <= 0:
+			return fmt.Errorf("vehicle %s: capacityKwh must be positive", v.ID)
+		case v.InitialSoc < 0 || v.InitialSoc > 100:
+			return fmt.Errorf("vehicle %s: initialSoc must be between 0 and 100", v.ID)
+		case v.MinSoc < 0 || v.MinSoc > 100:
+			return fmt.Errorf("vehicle %s: minSoc must be between 0 and 100", v.ID)
+		case v.TaperSoc < 0 || v.TaperSoc >= 100:
+			return fmt.Errorf("vehicle %s: taperSoc must be at least 0 and below 100", v.ID)
+		case v.MaxChargeKw <= 0 || v.MaxDischargeKw < 0:
+			return fmt.Errorf("vehicle %s: maxChargeKw must be positive and maxDischargeKw cannot be negative", v.ID)
+		case v.Efficiency <= 0 || v.Efficiency > 1:
+			return fmt.Errorf("vehicle %s: efficiency must be above 0 and at most 1", v.ID)
+		}
+	}
+
+	// Vehicles sharing a connector must take turns.
+	for i := range sc.Vehicles {
+		a := &sc.Vehicles[i]
+		for j := i + 1; j < len(sc.Vehicles); j++ {
+			b := &sc.Vehicles[j]
+			if a.Charger == b.Charger && a.Connector == b.Connector &&
+				a.PlugInMinute < b.PlugOutMinute && b.PlugInMinute < a.PlugOutMinute {
+				return fmt.Errorf("vehicles %s and %s are plugged in to the same connector at the same time", a.ID, b.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Duration is the simulated time the scenario runs.
+func (sc *Scenario) Duration() time.Duration {
+	if sc.DurationMinutes > 0 {
+		return minutes(sc.DurationMinutes)
+	}
+
+	last := 0.0
+	for _, v := range sc.Vehicles {
+		last = max(last, v.PlugOutMinute)
+	}
+
+	return minutes(last + 1)
+}
+
+// Replicate returns the scenario with n copies of every charger and vehicle
+// for load tests. Copies get a -1 to -n suffix on their serial and id, those
+// serials must be registered on the server too. A single copy keeps the
+// scenario as it is.
+func (sc Scenario) Replicate(n int) Scenario {
+	if n <= 1 {
+		return sc
+	}
+
+	res := sc
+	res.Chargers = make([]Charger, 0, len(sc.Chargers)*n)
+	res.Vehicles = make([]Vehicle, 0, len(sc.Vehicles)*n)
+	for i := 1; i <= n; i++ {
+		suffix := fmt.Sprintf("-%d", i)
+		for _, c := range sc.Chargers {
+			c.Serial += suffix
+			res.Chargers = append(res.Chargers, c)
+		}
+		for _, v := range sc.Vehicles {
+			v.ID += suffix
+			v.Charger += suffix
+			if v.IdTag != "" {
+				v.IdTag += suffix
+			}
+			res.Vehicles = append(res.Vehicles, v)
+		}
+	}
+
+	return res
+}
+
+// VehiclesOn returns the vehicles that plug in to a charger, ordered by the
+// minute they plug in.
+func (sc *Scenario) VehiclesOn(serial string) []*Vehicle {
+	var res []*Vehicle
+	for i := range sc.Vehicles {
+		if sc.Vehicles[i].Charger == serial {
+			res = append(res, &sc.Vehicles[i])
+		}
+	}
+
+	slices.SortFunc(res, func(a, b *Vehicle) int {
+		switch {
+		case a.PlugInMinute < b.PlugInMinute:
+			return -1
+		case a.PlugInMinute > b.PlugInMinute:
+			return 1
+		}
+		return 0
+	})
+	return res
+}
+
+func minutes(m float64) time.Duration {
+	return time.Duration(m * float64(time.Minute))
+}
@@ -0,0 +1,144 @@
+// Package fcr models frequency containment reserve delivered by a fleet of
+// vehicles. Recorded grid frequency is replayed against the droop curve of
+// the product, the required response is shared over the vehicles and what
+// they deliver is checked against the activation times and capacity the TSO
+// demands. Like the optimizer it has no dependencies on the rest of the
+// server.
+package fcr
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	NominalHz = 50.0
+	// DeadbandHz is the insensitivity range around the nominal frequency in
+	// which no response is required.
+	DeadbandHz = 0.010
+	// FullActivationHz is the deviation at which the full contracted power
+	// must be delivered.
+	FullActivationHz = 0.200
+	minFrequencyHz   = 45.0
+	maxFrequencyHz   = 55.0
+	epsilon          = 1e-9
+)
+
+// Sample is a frequency measurement at an offset from the first sample.
+type Sample struct {
+	At        time.Duration
+	Frequency float64
+}
+
+// Droop returns the share of the contracted power that is required at a
+// frequency, between -1 and 1. It is positive for upward regulation when the
+// frequency is too low, so the fleet has to lower its import or discharge,
+// and negative when the frequency is too high.
+func Droop(frequencyHz float64) float64 {
+	deviation := NominalHz - frequencyHz
+	if math.Abs(deviation) <= DeadbandHz {
+		return 0
+	}
+
+	return math.Max(-1, math.Min(1, deviation/FullActivationHz))
+}
+
+// ParseFrequencyCSV reads frequency samples from a CSV file with a header
+// row. The time column holds either RFC 3339 timestamps or seconds since the
+// start of the recording, the frequency column is in Hz. Samples must be in
+// chronological order.
+func ParseFrequencyCSV(r io.Reader) ([]Sample, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+
+	timeCol, freqCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "time", "timestamp":
+			timeCol = i
+		case "frequency", "frequency_hz":
+			freqCol = i
+		}
+	}
+	if timeCol < 0 || freqCol < 0 {
+		return nil, errors.New("CSV needs a time and a frequency column")
+	}
+
+	var (
+		samples []Sample
+		origin  time.Time
+	)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		at, err := parseTime(record[timeCol], &origin, len(samples) == 0)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		frequency, err := strconv.ParseFloat(record[freqCol], 64)
+		if err != nil || frequency < minFrequencyHz || frequency > maxFrequencyHz {
+			return nil, fmt.Errorf("line %d: frequency must be a number of Hz between %g and %g", line, minFrequencyHz, maxFrequencyHz)
+		}
+
+		if len(samples) > 0 && at <= samples[len(samples)-1].At {
+			return nil, fmt.Errorf("line %d: samples must be in chronological order", line)
+		}
+
+		samples = append(samples, Sample{At: at, Frequency: frequency})
+	}
+
+	if len(samples) < 2 {
+		return nil, errors.New("CSV needs at least two samples")
+	}
+
+	if first := samples[0].At; first != 0 {
+		for i := range samples {
+			samples[i].At -= first
+		}
+	}
+
+	return samples, nil
+}
+
+// parseTime returns the offset of a sample. The first sample decides whether
+// the file uses timestamps, which are then taken relative to origin.
+func parseTime(v string, origin *time.Time, first bool) (time.Duration, error) {
+	if ts, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		if first {
+			*origin = ts
+		}
+		if origin.IsZero() {
+			return 0, errors.New("time cannot mix timestamps and seconds")
+		}
+
+		return ts.Sub(*origin), nil
+	}
+
+	seconds, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return 0, errors.New("time must be an RFC 3339 timestamp or seconds")
+	}
+	if !origin.IsZero() {
+		return 0, errors.New("time cannot mix timestamps and seconds")
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,295 @@
+package fcr
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDroop(t *testing.T) {
+	tests := []struct {
+		frequency float64
+		want      float64
+	}{
+		{frequency: 50, want: 0},
+		{frequency: 49.99, want: 0},
+		{frequency: 50.01, want: 0},
+		{frequency: 49.9, want: 0.5},
+		{frequency: 50.1, want: -0.5},
+		{frequency: 49.8, want: 1},
+		{frequency: 49.5, want: 1},
+		{frequency: 50.5, want: -1},
+	}
+
+	for _, tt := range tests {
+		if got := Droop(tt.frequency); math.Abs(got-tt.want) > epsilon {
+			t.Errorf("Droop(%v) = %v, want %v", tt.frequency, got, tt.want)
+		}
+	}
+}
+
+func TestParseFrequencyCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []Sample
+		wantErr string
+	}{
+		{
+			name: "seconds are taken relative to the first sample",
+			csv:  "time,frequency\n10,50.01\n11,49.95\n12.5,49.9\n",
+			want: []Sample{
+				{At: 0, Frequency: 50.01},
+				{At: time.Second, Frequency: 49.95},
+				{At: 2500 * time.Millisecond, Frequency: 49.9},
+			},
+		},
+		{
+			name: "timestamps and extra columns",
+			csv:  "Timestamp, site, Frequency_Hz\n2025-03-01T12:00:00Z, a, 50\n2025-03-01T12:00:00.5Z, a, 50.02\n",
+			want: []Sample{
+				{At: 0, Frequency: 50},
+				{At: 500 * time.Millisecond, Frequency: 50.02},
+			},
+		},
+		{
+			name:    "missing frequency column",
+			csv:     "time,value\n0,50\n1,50\n",
+			wantErr: "frequency column",
+		},
+		{
+			name:    "frequency out of range",
+			csv:     "time,frequency\n0,50\n1,60\n",
+			wantErr: "line 3",
+		},
+		{
+			name:    "samples out of order",
+			csv:     "time,frequency\n1,50\n1,50\n",
+			wantErr: "chronological",
+		},
+		{
+			name:    "timestamps mixed with seconds",
+			csv:     "time,frequency\n2025-03-01T12:00:00Z,50\n1,50\n",
+			wantErr: "cannot mix",
+		},
+		{
+			name:    "single sample",
+			csv:     "time,frequency\n0,50\n",
+			wantErr: "at least two",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFrequencyCSV(strings.NewReader(tt.csv))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseFrequencyCSV error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFrequencyCSV: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d samples, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("sample %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// scenario contracts 10 kW with a single vehicle that offers 11 kW in both
+// directions and reacts immediately.
+func scenario() Scenario {
+	return Scenario{
+		ContractedKw:           10,
+		HalfActivationSeconds:  15,
+		FullActivationSeconds:  30,
+		ToleranceFraction:      0.1,
+		EventThresholdHz:       0.1,
+		CapacityPriceEurPerMwh: 10,
+		PenaltyFactor:          1,
+		Vehicles: []Vehicle{{
+			ID:             "ev",
+			CapacityKwh:    50,
+			Soc:            50,
+			MinSoc:         10,
+			MaxSoc:         90,
+			MaxChargeKw:    11,
+			MaxDischargeKw: 11,
+			Efficiency:     1,
+		}},
+	}
+}
+
+// underFrequency is two minutes of samples every second with a full
+// activation at 49.8 Hz from second 30 to 90.
+func underFrequency() []Sample {
+	samples := make([]Sample, 120)
+	for i := range samples {
+		samples[i] = Sample{At: time.Duration(i) * time.Second, Frequency: NominalHz}
+		if i >= 30 && i < 90 {
+			samples[i].Frequency = 49.8
+		}
+	}
+
+	return samples
+}
+
+func TestSimulate(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario func() Scenario
+		check    func(t *testing.T, r *Report)
+	}{
+		{
+			name:     "immediate response is compliant",
+			scenario: scenario,
+			check: func(t *testing.T, r *Report) {
+				if !r.Compliant() {
+					t.Errorf("report is not compliant: %+v", r)
+				}
+				if r.AvailabilityPct != 100 || r.MinOfferedKw != 11 {
+					t.Errorf("availability = %v%% with %v kW offered, want 100%% with 11 kW", r.AvailabilityPct, r.MinOfferedKw)
+				}
+				if len(r.Activations) != 1 {
+					t.Fatalf("got %d activations, want 1", len(r.Activations))
+				}
+
+				a := r.Activations[0]
+				if a.StartSecond != 30 || a.DurationSeconds != 60 || a.PeakDeviationMHz != -200 || a.RequiredKw != 10 {
+					t.Errorf("activation = %+v, want 60 s of -200 mHz requiring 10 kW from second 30", a)
+				}
+				if a.HalfSeconds == nil || *a.HalfSeconds != 0 || a.FullSeconds == nil || *a.FullSeconds != 0 {
+					t.Errorf("activation reached half after %v and full after %v, want both immediately", a.HalfSeconds, a.FullSeconds)
+				}
+
+				// 10 kW of discharge for a minute is a sixth of a kWh.
+				if r.UpwardKwh != 0.167 || r.DownwardKwh != 0 {
+					t.Errorf("upward = %v kWh, downward = %v kWh, want 0.167 and 0", r.UpwardKwh, r.DownwardKwh)
+				}
+				if v := r.Vehicles[0]; v.EndSoc != 49.667 {
+					t.Errorf("vehicle ends at %v%%, want 49.667", v.EndSoc)
+				}
+				// 10 kW for two minutes at 10 EUR per MWh.
+				if r.RevenueEur != 0.003 || r.PenaltyEur != 0 {
+					t.Errorf("revenue = %v, penalty = %v, want 0.003 and 0", r.RevenueEur, r.PenaltyEur)
+				}
+			},
+		},
+		{
+			name: "slow reaction misses the activation times",
+			scenario: func() Scenario {
+				sc := scenario()
+				sc.Vehicles[0].ReactionSeconds = 40
+				return sc
+			},
+			check: func(t *testing.T, r *Report) {
+				if r.Compliant() {
+					t.Fatal("report is compliant, want a missed activation")
+				}
+
+				a := r.Activations[0]
+				if a.Compliant || a.HalfSeconds == nil || *a.HalfSeconds != 40 {
+					t.Errorf("activation = %+v, want half reached after 40 s and not compliant", a)
+				}
+				if r.NonCompliantSeconds == 0 || r.MaxShortfallKw != 10 {
+					t.Errorf("non-compliant for %v s with %v kW shortfall, want a 10 kW shortfall", r.NonCompliantSeconds, r.MaxShortfallKw)
+				}
+			},
+		},
+		{
+			name: "too little capacity is unavailable",
+			scenario: func() Scenario {
+				sc := scenario()
+				sc.Vehicles[0].MaxDischargeKw = 4
+				return sc
+			},
+			check: func(t *testing.T, r *Report) {
+				if r.AvailabilityPct != 0 || r.MinOfferedKw != 4 || r.RevenueEur != 0 {
+					t.Errorf("availability = %v%% with %v kW offered and %v EUR, want none with 4 kW", r.AvailabilityPct, r.MinOfferedKw, r.RevenueEur)
+				}
+				// 6 kW short for two minutes.
+				if r.MissingCapacityKwh != 0.2 {
+					t.Errorf("missing capacity = %v kWh, want 0.2", r.MissingCapacityKwh)
+				}
+				if r.Compliant() {
+					t.Error("report is compliant, want missing capacity")
+				}
+			},
+		},
+		{
+			name: "reservation limits an almost empty vehicle",
+			scenario: func() Scenario {
+				sc := scenario()
+				// 1 kWh above the minimum sustains 4 kW for 15 minutes.
+				sc.ReservationMinutes = 15
+				sc.Vehicles[0].Soc = 12
+				return sc
+			},
+			check: func(t *testing.T, r *Report) {
+				if r.AvailabilityPct != 0 || r.MinOfferedKw > 4 {
+					t.Errorf("availability = %v%% with %v kW offered, want none with at most 4 kW", r.AvailabilityPct, r.MinOfferedKw)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Simulate(tt.scenario(), underFrequency())
+			if err != nil {
+				t.Fatalf("Simulate: %v", err)
+			}
+			tt.check(t, r)
+		})
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(sc *Scenario)
+		want   string
+	}{
+		{name: "valid", modify: func(*Scenario) {}},
+		{name: "no contract", modify: func(sc *Scenario) { sc.ContractedKw = 0 }, want: "contractedKw"},
+		{name: "full before half activation", modify: func(sc *Scenario) { sc.FullActivationSeconds = 10 }, want: "activation times"},
+		{name: "threshold inside the deadband", modify: func(sc *Scenario) { sc.EventThresholdHz = 0.005 }, want: "eventThresholdHz"},
+		{name: "no vehicles", modify: func(sc *Scenario) { sc.Vehicles = nil }, want: "vehicles cannot be empty"},
+		{
+			name: "duplicate vehicle",
+			modify: func(sc *Scenario) {
+				sc.Vehicles = append(sc.Vehicles, sc.Vehicles[0])
+			},
+			want: "vehicles[1]: id",
+		},
+		{name: "soc above maximum", modify: func(sc *Scenario) { sc.Vehicles[0].Soc = 95 }, want: "vehicles[0]: soc"},
+		{name: "baseline above the charger", modify: func(sc *Scenario) { sc.Vehicles[0].BaselineKw = 12 }, want: "vehicles[0]: baselineKw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := scenario()
+			tt.modify(&sc)
+
+			err := sc.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
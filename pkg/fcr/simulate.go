@@ -0,0 +1,527 @@
+package fcr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Scenario describes the contract and the fleet for the simulator. Times of
+// vehicles are minutes since the first frequency sample.
+type Scenario struct {
+	// ContractedKw is the symmetric power the fleet has sold.
+	ContractedKw float64 `json:"contractedKw"`
+	// ReservationMinutes is how long the fleet must be able to deliver the
+	// full activation in either direction, 15 in continental Europe. It
+	// limits the capacity of vehicles that are almost empty or full.
+	ReservationMinutes float64 `json:"reservationMinutes"`
+	// HalfActivationSeconds and FullActivationSeconds are the times within
+	// which half and all of the required power must be delivered.
+	HalfActivationSeconds float64 `json:"halfActivationSeconds"`
+	FullActivationSeconds float64 `json:"fullActivationSeconds"`
+	// ToleranceFraction is the part of the contracted power the delivery
+	// may fall short before it counts as non-compliant.
+	ToleranceFraction float64 `json:"toleranceFraction"`
+	// EventThresholdHz is the deviation from the nominal frequency that
+	// starts an activation whose reaction time is checked.
+	EventThresholdHz float64 `json:"eventThresholdHz"`
+	// CapacityPriceEurPerMwh is paid per MW of contracted capacity for every
+	// hour the fleet is available.
+	CapacityPriceEurPerMwh float64 `json:"capacityPriceEurPerMwh"`
+	// PenaltyFactor is the multiple of the capacity price charged for every
+	// MWh of missing capacity and of power not delivered.
+	PenaltyFactor float64   `json:"penaltyFactor"`
+	Vehicles      []Vehicle `json:"vehicles"`
+}
+
+type Vehicle struct {
+	ID            string `json:"id"`
+	ArrivalMinute int    `json:"arrivalMinute"`
+	// DepartureMinute is when the vehicle leaves, zero when it stays until
+	// the end of the recording.
+	DepartureMinute int     `json:"departureMinute"`
+	CapacityKwh     float64 `json:"capacityKwh"`
+	Soc             float64 `json:"soc"`
+	MinSoc          float64 `json:"minSoc"`
+	MaxSoc          float64 `json:"maxSoc"`
+	// BaselineKw is the scheduled power the vehicle responds around,
+	// negative while discharging.
+	BaselineKw     float64 `json:"baselineKw"`
+	MaxChargeKw    float64 `json:"maxChargeKw"`
+	MaxDischargeKw float64 `json:"maxDischargeKw"`
+	// ReactionSeconds is the delay before a new setpoint takes effect, from
+	// measuring the frequency to the charger changing its current.
+	ReactionSeconds float64 `json:"reactionSeconds"`
+	// RampKwPerSecond limits how fast the power changes, zero when the
+	// charger steps immediately.
+	RampKwPerSecond float64 `json:"rampKwPerSecond"`
+	// Efficiency is the one way efficiency of charging and discharging.
+	Efficiency float64 `json:"efficiency"`
+}
+
+// Activation is a frequency deviation beyond the event threshold. The
+// reaction times are measured until the delivery reaches half and all of
+// the power required when it started, nil when it never did.
+type Activation struct {
+	StartSecond     float64 `json:"startSecond"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	// PeakDeviationMHz is the largest deviation from the nominal frequency,
+	// negative for under-frequency.
+	PeakDeviationMHz float64  `json:"peakDeviationMHz"`
+	RequiredKw       float64  `json:"requiredKw"`
+	HalfSeconds      *float64 `json:"halfSeconds,omitempty"`
+	FullSeconds      *float64 `json:"fullSeconds,omitempty"`
+	Compliant        bool     `json:"compliant"`
+}
+
+type VehicleResult struct {
+	ID             string  `json:"id"`
+	PresentMinutes float64 `json:"presentMinutes"`
+	// AvgCapacityKw is the average symmetric capacity while present.
+	AvgCapacityKw float64 `json:"avgCapacityKw"`
+	StartSoc      float64 `json:"startSoc"`
+	EndSoc        float64 `json:"endSoc"`
+	UpwardKwh     float64 `json:"upwardKwh"`
+	DownwardKwh   float64 `json:"downwardKwh"`
+	// LimitedSeconds counts the time the ramp rate, the power limits or the
+	// state of charge kept the vehicle from the setpoint it was following.
+	LimitedSeconds float64 `json:"limitedSeconds"`
+}
+
+type Report struct {
+	Samples         int     `json:"samples"`
+	DurationMinutes float64 `json:"durationMinutes"`
+	ContractedKw    float64 `json:"contractedKw"`
+	MinOfferedKw    float64 `json:"minOfferedKw"`
+	// AvailabilityPct is the share of time the fleet could offer the
+	// contracted capacity.
+	AvailabilityPct float64 `json:"availabilityPct"`
+	// MissingCapacityKwh integrates the capacity short of the contract.
+	MissingCapacityKwh float64 `json:"missingCapacityKwh"`
+	// CompliancePct is the share of time, after the first full activation
+	// time, in which the delivery met the requirement within tolerance.
+	CompliancePct       float64 `json:"compliancePct"`
+	NonCompliantSeconds float64 `json:"nonCompliantSeconds"`
+	MaxShortfallKw      float64 `json:"maxShortfallKw"`
+	// ShortfallKwh integrates the power that was required but not
+	// delivered.
+	ShortfallKwh float64 `json:"shortfallKwh"`
+	UpwardKwh    float64 `json:"upwardKwh"`
+	DownwardKwh  float64 `json:"downwardKwh"`
+	// RevenueEur is the capacity payment for the available hours,
+	// PenaltyEur the exposure for missing capacity and shortfall.
+	RevenueEur  float64         `json:"revenueEur"`
+	PenaltyEur  float64         `json:"penaltyEur"`
+	Activations []Activation    `json:"activations"`
+	Vehicles    []VehicleResult `json:"vehicles"`
+}
+
+// Compliant tells whether the fleet was always available and delivered
+// every activation in time.
+func (r *Report) Compliant() bool {
+	if r.MissingCapacityKwh > 0 || r.NonCompliantSeconds > 0 {
+		return false
+	}
+	for _, a := range r.Activations {
+		if !a.Compliant {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (sc *Scenario) Validate() error {
+	var errs []error
+	if sc.ContractedKw <= 0 {
+		errs = append(errs, errors.New("contractedKw must be positive"))
+	}
+	if sc.ReservationMinutes < 0 {
+		errs = append(errs, errors.New("reservationMinutes cannot be negative"))
+	}
+	if sc.HalfActivationSeconds <= 0 || sc.FullActivationSeconds < sc.HalfActivationSeconds {
+		errs = append(errs, errors.New("activation times must satisfy 0 < halfActivationSeconds <= fullActivationSeconds"))
+	}
+	if sc.ToleranceFraction < 0 || sc.ToleranceFraction >= 1 {
+		errs = append(errs, errors.New("toleranceFraction must be between 0 and 1"))
+	}
+	if sc.EventThresholdHz <= DeadbandHz || sc.EventThresholdHz > FullActivationHz {
+		errs = append(errs, fmt.Errorf("eventThresholdHz must be above %g and at most %g", DeadbandHz, FullActivationHz))
+	}
+	if sc.CapacityPriceEurPerMwh < 0 || sc.PenaltyFactor < 0 {
+		errs = append(errs, errors.New("capacityPriceEurPerMwh and penaltyFactor cannot be negative"))
+	}
+	if len(sc.Vehicles) == 0 {
+		errs = append(errs, errors.New("vehicles cannot be empty"))
+	}
+
+	seen := make(map[string]bool)
+	for i, v := range sc.Vehicles {
+		if v.ID == "" || seen[v.ID] {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: id must be unique and not empty", i))
+		}
+		seen[v.ID] = true
+
+		if v.ArrivalMinute < 0 || (v.DepartureMinute != 0 && v.DepartureMinute <= v.ArrivalMinute) {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: departure must be after arrival", i))
+		}
+		if v.CapacityKwh <= 0 {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: capacityKwh must be positive", i))
+		}
+		if v.MinSoc < 0 || v.MinSoc >= v.MaxSoc || v.MaxSoc > 100 || v.Soc < v.MinSoc || v.Soc > v.MaxSoc {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: soc must satisfy 0 <= minSoc <= soc <= maxSoc <= 100", i))
+		}
+		if v.MaxChargeKw <= 0 || v.MaxDischargeKw < 0 {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: maxChargeKw must be positive and maxDischargeKw cannot be negative", i))
+		}
+		if v.BaselineKw > v.MaxChargeKw || v.BaselineKw < -v.MaxDischargeKw {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: baselineKw must be between -maxDischargeKw and maxChargeKw", i))
+		}
+		if v.ReactionSeconds < 0 || v.RampKwPerSecond < 0 {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: reactionSeconds and rampKwPerSecond cannot be negative", i))
+		}
+		if v.Efficiency <= 0 || v.Efficiency > 1 {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: efficiency must be above 0 and at most 1", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// setpoint is a target power of a vehicle from the moment it was computed.
+type setpoint struct {
+	at       time.Duration
+	targetKw float64
+}
+
+// vehicleState follows a vehicle through the replay.
+type vehicleState struct {
+	present   bool
+	soc       float64
+	powerKw   float64
+	setpoints []setpoint
+	result    VehicleResult
+	capacity  float64
+}
+
+// requirement is the fleet response required at a sample.
+type requirement struct {
+	at         time.Duration
+	requiredKw float64
+}
+
+// activation tracks an activation while it lasts.
+type activation struct {
+	Activation
+	start time.Duration
+}
+
+// Simulate replays the frequency samples. Each sample the vehicles present
+// offer the capacity their power limits and state of charge allow, and the
+// response the droop curve requires is shared in proportion to it. Vehicles
+// move towards their setpoint after their reaction time and within their ramp
+// rate. Once the first full activation time has passed, the delivery must
+// match the lowest requirement of the last full activation time, which a
+// compliant unit has had time to reach.
+func Simulate(sc Scenario, samples []Sample) (*Report, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	if len(samples) < 2 {
+		return nil, errors.New("at least two frequency samples are required")
+	}
+
+	reserveHours := sc.ReservationMinutes / 60
+	fullActivation := time.Duration(sc.FullActivationSeconds * float64(time.Second))
+	toleranceKw := sc.ToleranceFraction * sc.ContractedKw
+
+	states := make([]vehicleState, len(sc.Vehicles))
+	for i, v := range sc.Vehicles {
+		states[i].soc = v.Soc
+		states[i].result = VehicleResult{ID: v.ID, StartSoc: v.Soc}
+	}
+
+	report := &Report{
+		Samples:      len(samples),
+		ContractedKw: sc.ContractedKw,
+		MinOfferedKw: math.Inf(1),
+		Activations:  []Activation{},
+	}
+
+	var (
+		window                                          []requirement
+		current                                         *activation
+		availableSeconds, assessedSeconds, totalSeconds float64
+	)
+	for k, sample := range samples {
+		step := samples[len(samples)-1].At - samples[len(samples)-2].At
+		if k+1 < len(samples) {
+			step = samples[k+1].At - sample.At
+		}
+		seconds, hours := step.Seconds(), step.Hours()
+		minute := int(sample.At.Minutes())
+
+		offeredKw := 0.0
+		for i, v := range sc.Vehicles {
+			st := &states[i]
+			present := minute >= v.ArrivalMinute && (v.DepartureMinute == 0 || minute < v.DepartureMinute)
+			if present && !st.present {
+				st.powerKw = v.baselineKw(st.soc)
+				st.setpoints = nil
+			}
+			st.present = present
+			if !present {
+				continue
+			}
+
+			st.capacity = v.capacityKw(st.soc, v.baselineKw(st.soc), reserveHours)
+			offeredKw += st.capacity
+		}
+
+		requiredKw := sc.ContractedKw * Droop(sample.Frequency)
+		deliveredKw := 0.0
+		for i, v := range sc.Vehicles {
+			st := &states[i]
+			if !st.present {
+				continue
+			}
+
+			baselineKw := v.baselineKw(st.soc)
+			shareKw := 0.0
+			if offeredKw > epsilon {
+				shareKw = math.Max(-st.capacity, math.Min(st.capacity, requiredKw*st.capacity/offeredKw))
+			}
+
+			targetKw := st.follow(&v, sample.At, baselineKw-shareKw, baselineKw, seconds)
+			if math.Abs(st.powerKw-targetKw) > epsilon {
+				st.result.LimitedSeconds += seconds
+			}
+
+			responseKw := baselineKw - st.powerKw
+			deliveredKw += responseKw
+			if responseKw > 0 {
+				st.result.UpwardKwh += responseKw * hours
+			} else {
+				st.result.DownwardKwh -= responseKw * hours
+			}
+
+			if st.powerKw > 0 {
+				st.soc += st.powerKw * hours * v.Efficiency / v.CapacityKwh * 100
+			} else {
+				st.soc += st.powerKw * hours / v.Efficiency / v.CapacityKwh * 100
+			}
+			st.soc = math.Max(0, math.Min(100, st.soc))
+
+			st.result.PresentMinutes += step.Minutes()
+			st.result.AvgCapacityKw += st.capacity * step.Minutes()
+		}
+
+		totalSeconds += seconds
+		report.MinOfferedKw = math.Min(report.MinOfferedKw, offeredKw)
+		if offeredKw+epsilon >= sc.ContractedKw {
+			availableSeconds += seconds
+		} else {
+			report.MissingCapacityKwh += (sc.ContractedKw - offeredKw) * hours
+		}
+		if deliveredKw > 0 {
+			report.UpwardKwh += deliveredKw * hours
+		} else {
+			report.DownwardKwh -= deliveredKw * hours
+		}
+
+		window = append(window, requirement{at: sample.At, requiredKw: requiredKw})
+		for len(window) > 1 && window[1].at <= sample.At-fullActivation {
+			window = window[1:]
+		}
+		if sample.At >= fullActivation {
+			assessedSeconds += seconds
+			envelopeKw := envelope(window)
+			shortfallKw := math.Max(0, math.Abs(envelopeKw)-deliveredKw*sign(envelopeKw))
+			if shortfallKw > toleranceKw+epsilon {
+				report.NonCompliantSeconds += seconds
+				report.ShortfallKwh += shortfallKw * hours
+				report.MaxShortfallKw = math.Max(report.MaxShortfallKw, shortfallKw)
+			}
+		}
+
+		deviationHz := sample.Frequency - NominalHz
+		switch {
+		case current == nil && math.Abs(deviationHz) >= sc.EventThresholdHz:
+			current = &activation{start: sample.At}
+			current.StartSecond = sample.At.Seconds()
+			current.RequiredKw = requiredKw
+			fallthrough
+		case current != nil && math.Abs(deviationHz) >= sc.EventThresholdHz:
+			if math.Abs(deviationHz)*1000 > math.Abs(current.PeakDeviationMHz) {
+				current.PeakDeviationMHz = deviationHz * 1000
+			}
+			current.measure(sample.At, deliveredKw, toleranceKw)
+		case current != nil:
+			report.Activations = append(report.Activations, current.finish(sample.At, &sc))
+			current = nil
+		}
+	}
+
+	if current != nil {
+		report.Activations = append(report.Activations, current.finish(samples[len(samples)-1].At, &sc))
+	}
+
+	for i := range states {
+		res := &states[i].result
+		if res.PresentMinutes > 0 {
+			res.AvgCapacityKw = round(res.AvgCapacityKw / res.PresentMinutes)
+		}
+		res.PresentMinutes = round(res.PresentMinutes)
+		res.EndSoc = round(states[i].soc)
+		res.UpwardKwh = round(res.UpwardKwh)
+		res.DownwardKwh = round(res.DownwardKwh)
+		res.LimitedSeconds = round(res.LimitedSeconds)
+		report.Vehicles = append(report.Vehicles, *res)
+	}
+
+	availableHours := availableSeconds / 3600
+	report.RevenueEur = round(sc.ContractedKw / 1000 * availableHours * sc.CapacityPriceEurPerMwh)
+	report.PenaltyEur = round(sc.PenaltyFactor * sc.CapacityPriceEurPerMwh * (report.MissingCapacityKwh + report.ShortfallKwh) / 1000)
+
+	report.DurationMinutes = round(totalSeconds / 60)
+	report.AvailabilityPct = round(availableSeconds / totalSeconds * 100)
+	report.CompliancePct = 100
+	if assessedSeconds > 0 {
+		report.CompliancePct = round((assessedSeconds - report.NonCompliantSeconds) / assessedSeconds * 100)
+	}
+	report.MinOfferedKw = round(report.MinOfferedKw)
+	report.MissingCapacityKwh = round(report.MissingCapacityKwh)
+	report.NonCompliantSeconds = round(report.NonCompliantSeconds)
+	report.MaxShortfallKw = round(report.MaxShortfallKw)
+	report.ShortfallKwh = round(report.ShortfallKwh)
+	report.UpwardKwh = round(report.UpwardKwh)
+	report.DownwardKwh = round(report.DownwardKwh)
+	return report, nil
+}
+
+// baselineKw is the scheduled power, charging stops when the vehicle is full
+// and discharging when it is empty.
+func (v *Vehicle) baselineKw(soc float64) float64 {
+	if (v.BaselineKw > 0 && soc >= v.MaxSoc) || (v.BaselineKw < 0 && soc <= v.MinSoc) {
+		return 0
+	}
+
+	return v.BaselineKw
+}
+
+// capacityKw is the symmetric power the vehicle can offer around its
+// baseline: what its charger allows in both directions, reduced so it can
+// sustain the full activation for the reservation time without leaving its
+// state of charge limits.
+func (v *Vehicle) capacityKw(soc, baselineKw, reserveHours float64) float64 {
+	capacityKw := math.Min(baselineKw+v.MaxDischargeKw, v.MaxChargeKw-baselineKw)
+	if reserveHours > 0 {
+		availableKwh := (soc - v.MinSoc) / 100 * v.CapacityKwh
+		roomKwh := (v.MaxSoc - soc) / 100 * v.CapacityKwh
+		capacityKw = math.Min(capacityKw, baselineKw+availableKwh*v.Efficiency/reserveHours)
+		capacityKw = math.Min(capacityKw, roomKwh/(v.Efficiency*reserveHours)-baselineKw)
+	}
+
+	return math.Max(0, capacityKw)
+}
+
+// follow records the setpoint and moves the power towards the setpoint that
+// is older than the reaction time, within the ramp rate and the limits of the
+// battery. It returns the setpoint the vehicle was following.
+func (st *vehicleState) follow(v *Vehicle, at time.Duration, setpointKw, baselineKw, seconds float64) float64 {
+	st.setpoints = append(st.setpoints, setpoint{at: at, targetKw: setpointKw})
+
+	due := at - time.Duration(v.ReactionSeconds*float64(time.Second))
+	for len(st.setpoints) > 1 && st.setpoints[1].at <= due {
+		st.setpoints = st.setpoints[1:]
+	}
+
+	targetKw := baselineKw
+	if st.setpoints[0].at <= due {
+		targetKw = st.setpoints[0].targetKw
+	}
+
+	powerKw := targetKw
+	if v.RampKwPerSecond > 0 {
+		maxChangeKw := v.RampKwPerSecond * seconds
+		powerKw = st.powerKw + math.Max(-maxChangeKw, math.Min(maxChangeKw, targetKw-st.powerKw))
+	}
+
+	powerKw = math.Max(-v.MaxDischargeKw, math.Min(v.MaxChargeKw, powerKw))
+	if st.soc >= v.MaxSoc {
+		powerKw = math.Min(0, powerKw)
+	}
+	if st.soc <= v.MinSoc {
+		powerKw = math.Max(0, powerKw)
+	}
+
+	st.powerKw = powerKw
+	return targetKw
+}
+
+// measure records when the delivery first reached half and all of the power
+// required at the start of the activation.
+func (a *activation) measure(at time.Duration, deliveredKw, toleranceKw float64) {
+	requiredKw := math.Abs(a.RequiredKw)
+	responseKw := deliveredKw * sign(a.RequiredKw)
+	elapsed := (at - a.start).Seconds()
+
+	if a.HalfSeconds == nil && responseKw+epsilon >= requiredKw/2 {
+		a.HalfSeconds = &elapsed
+	}
+	if a.FullSeconds == nil && responseKw+toleranceKw+epsilon >= requiredKw {
+		a.FullSeconds = &elapsed
+	}
+}
+
+// finish closes the activation. An activation that ended before a reaction
+// time had passed cannot have missed it.
+func (a *activation) finish(at time.Duration, sc *Scenario) Activation {
+	duration := (at - a.start).Seconds()
+	met := func(reached *float64, limit float64) bool {
+		if reached == nil {
+			return duration < limit
+		}
+
+		return *reached <= limit
+	}
+
+	a.DurationSeconds = round(duration)
+	a.StartSecond = round(a.StartSecond)
+	a.PeakDeviationMHz = round(a.PeakDeviationMHz)
+	a.RequiredKw = round(a.RequiredKw)
+	a.Compliant = met(a.HalfSeconds, sc.HalfActivationSeconds) && met(a.FullSeconds, sc.FullActivationSeconds)
+	return a.Activation
+}
+
+// envelope is the requirement a compliant unit must meet: the smallest
+// requirement of the window when it all points in one direction, nothing
+// otherwise.
+func envelope(window []requirement) float64 {
+	lowestKw := math.Inf(1)
+	direction := sign(window[0].requiredKw)
+	for _, r := range window {
+		if sign(r.requiredKw) != direction {
+			return 0
+		}
+		lowestKw = math.Min(lowestKw, math.Abs(r.requiredKw))
+	}
+
+	return direction * lowestKw
+}
+
+func sign(v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,57 @@
+package flexibility
+
+import "math"
+
+// Offer is what a vehicle can deliver right now relative to its baseline,
+// in the same convention as Slot.
+type Offer struct {
+	BaselineKw float64
+	UpKw       float64
+	DownKw     float64
+}
+
+// Disaggregate divides a change of the fleet's power over the offers and
+// returns the power each vehicle should draw. A negative deltaKw lowers the
+// import and is taken from the upward flexibility, a positive one from the
+// downward flexibility.
+//
+// Every vehicle contributes the same share of its flexibility in the
+// requested direction, so no vehicle is drained while others stay idle and
+// no vehicle is asked beyond its limits. The part of deltaKw the offers
+// cannot cover is returned as shortfall, with the sign of deltaKw.
+func Disaggregate(deltaKw float64, offers []Offer) ([]float64, float64) {
+	setpoints := make([]float64, len(offers))
+	totalKw := 0.0
+	for i, o := range offers {
+		setpoints[i] = o.BaselineKw
+		if deltaKw < 0 {
+			totalKw += math.Max(0, o.UpKw)
+		} else {
+			totalKw += math.Max(0, o.DownKw)
+		}
+	}
+
+	if math.Abs(deltaKw) < epsilon || totalKw < epsilon {
+		return setpoints, dropNoise(deltaKw)
+	}
+
+	share := math.Min(1, math.Abs(deltaKw)/totalKw)
+	for i, o := range offers {
+		if deltaKw < 0 {
+			setpoints[i] -= share * math.Max(0, o.UpKw)
+		} else {
+			setpoints[i] += share * math.Max(0, o.DownKw)
+		}
+	}
+
+	return setpoints, dropNoise(math.Copysign(math.Max(0, math.Abs(deltaKw)-totalKw), deltaKw))
+}
+
+// dropNoise rounds values within epsilon of zero to zero.
+func dropNoise(v float64) float64 {
+	if math.Abs(v) < epsilon {
+		return 0
+	}
+
+	return v
+}
@@ -0,0 +1,156 @@
+// Package flexibility computes how far a plugged-in vehicle can deviate from
+// its planned charging power, per interval, without breaking its state of
+// charge limits or missing its departure target, and divides activations of
+// the fleet over its vehicles. Like the optimizer it has no dependencies on
+// the rest of the server so forecasts and dispatches can be reproduced on
+// recorded inputs.
+//
+// Flexibility follows the balancing convention: UpKw is how much the import
+// of the vehicle can drop below its baseline (charging less or discharging),
+// DownKw how much it can rise above it (charging more). Both are positive.
+package flexibility
+
+import (
+	"math"
+	"time"
+)
+
+const epsilon = 1e-9
+
+// Vehicle is a plugged-in vehicle. States of charge are percentages,
+// powers are measured at the grid side, positive while charging.
+type Vehicle struct {
+	CapacityKwh    float64
+	Soc            float64
+	MinSoc         float64
+	TargetSoc      float64
+	MaxChargeKw    float64
+	MaxDischargeKw float64
+	// Efficiency applies in both directions, defaults to one.
+	Efficiency float64
+	// Departure is when the vehicle leaves, zero when unknown. Energy held
+	// back must be recovered before it to still reach TargetSoc.
+	Departure time.Time
+	// BaselineKw is the planned power per interval from the start, missing
+	// intervals are idle.
+	BaselineKw []float64
+}
+
+// Slot is the flexibility of a vehicle from Start until the next slot.
+// Available is false once the vehicle has left.
+type Slot struct {
+	Start      time.Time
+	Available  bool
+	BaselineKw float64
+	UpKw       float64
+	DownKw     float64
+}
+
+// Compute returns the flexibility of the vehicle for the given number of
+// intervals from start. Each interval is considered on its own: the vehicle
+// deviates for that interval only and then follows its baseline again,
+// charging more later to make up for energy held back or less to make room
+// for energy taken. Flexibility of consecutive intervals can therefore not
+// all be delivered at once.
+func Compute(v Vehicle, start time.Time, resolution time.Duration, intervals int) []Slot {
+	if intervals <= 0 || resolution <= 0 {
+		return nil
+	}
+
+	eff := v.Efficiency
+	if eff <= 0 || eff > 1 {
+		eff = 1
+	}
+	hours := resolution.Hours()
+
+	// Only intervals that start before the departure are available.
+	available := intervals
+	if !v.Departure.IsZero() {
+		available = 0
+		for available < intervals && start.Add(time.Duration(available)*resolution).Before(v.Departure) {
+			available++
+		}
+	}
+
+	minKwh := v.MinSoc / 100 * v.CapacityKwh
+	targetKwh := v.TargetSoc / 100 * v.CapacityKwh
+	maxChargeKw := math.Max(0, v.MaxChargeKw)
+	maxDischargeKw := math.Max(0, v.MaxDischargeKw)
+
+	// The baseline trajectory of the stored energy, energy[i] is at the
+	// start of interval i.
+	baseline := make([]float64, available)
+	energy := make([]float64, available+1)
+	energy[0] = clamp(v.Soc/100*v.CapacityKwh, 0, v.CapacityKwh)
+	for i := range available {
+		if i < len(v.BaselineKw) {
+			baseline[i] = clamp(v.BaselineKw[i], -maxDischargeKw, maxChargeKw)
+		}
+		energy[i+1] = clamp(energy[i]+stored(baseline[i], hours, eff), 0, v.CapacityKwh)
+	}
+
+	// spareKwh[i] is what the vehicle could charge on top of its baseline
+	// from interval i until it leaves.
+	spareKwh := make([]float64, available+1)
+	for i := available - 1; i >= 0; i-- {
+		spareKwh[i] = spareKwh[i+1] + stored(maxChargeKw, hours, eff) - stored(baseline[i], hours, eff)
+	}
+
+	slots := make([]Slot, intervals)
+	for i := range slots {
+		slots[i].Start = start.Add(time.Duration(i) * resolution)
+		if i >= available {
+			continue
+		}
+
+		b := baseline[i]
+		roomKwh := math.Max(0, v.CapacityKwh-energy[i+1])
+		slackKwh := math.Max(0, energy[i+1]-minKwh)
+		if !v.Departure.IsZero() {
+			surplusKwh := math.Max(0, energy[available]-targetKwh)
+			slackKwh = math.Min(slackKwh, surplusKwh+spareKwh[i+1])
+		}
+
+		maxKw := math.Min(maxChargeKw, grid(stored(b, hours, eff)+roomKwh, hours, eff))
+		minKw := math.Max(-maxDischargeKw, grid(stored(b, hours, eff)-slackKwh, hours, eff))
+
+		slots[i].Available = true
+		slots[i].BaselineKw = b
+		slots[i].DownKw = positive(maxKw - b)
+		slots[i].UpKw = positive(b - minKw)
+	}
+
+	return slots
+}
+
+// stored is the energy that ends up in the battery when the grid power is
+// held for the given hours.
+func stored(powerKw, hours, eff float64) float64 {
+	if powerKw > 0 {
+		return powerKw * hours * eff
+	}
+
+	return powerKw * hours / eff
+}
+
+// grid is the inverse of stored.
+func grid(energyKwh, hours, eff float64) float64 {
+	if energyKwh > 0 {
+		return energyKwh / hours / eff
+	}
+
+	return energyKwh / hours * eff
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// positive drops rounding noise and negative values.
+func positive(v float64) float64 {
+	if v < epsilon {
+		return 0
+	}
+
+	return v
+}
@@ -0,0 +1,188 @@
+package flexibility
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// vehicle is a lossless 10 kWh battery with 2 kW in both directions.
+var vehicle = Vehicle{
+	CapacityKwh:    10,
+	Soc:            50,
+	MinSoc:         20,
+	TargetSoc:      70,
+	MaxChargeKw:    2,
+	MaxDischargeKw: 2,
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name      string
+		vehicle   func() Vehicle
+		intervals int
+		// want holds the available, baseline, up and down values per slot.
+		want []Slot
+	}{
+		{
+			name:      "idle vehicle without departure offers its charger",
+			vehicle:   func() Vehicle { return vehicle },
+			intervals: 2,
+			want: []Slot{
+				{Available: true, UpKw: 2, DownKw: 2},
+				{Available: true, UpKw: 2, DownKw: 2},
+			},
+		},
+		{
+			name: "slots after the departure are unavailable",
+			vehicle: func() Vehicle {
+				v := vehicle
+				v.Departure = start.Add(90 * time.Minute)
+				v.TargetSoc = 0
+				return v
+			},
+			intervals: 3,
+			want: []Slot{
+				{Available: true, UpKw: 2, DownKw: 2},
+				{Available: true, UpKw: 2, DownKw: 2},
+				{},
+			},
+		},
+		{
+			name: "energy held back must be recovered before departure",
+			vehicle: func() Vehicle {
+				v := vehicle
+				v.Departure = start.Add(2 * time.Hour)
+				v.BaselineKw = []float64{2, 0}
+				return v
+			},
+			intervals: 2,
+			// Pausing the first hour can be made up in the second, which
+			// then has nothing left to give.
+			want: []Slot{
+				{Available: true, BaselineKw: 2, UpKw: 2, DownKw: 0},
+				{Available: true, UpKw: 0, DownKw: 2},
+			},
+		},
+		{
+			name: "almost full vehicle has little room",
+			vehicle: func() Vehicle {
+				v := vehicle
+				v.Soc = 95
+				return v
+			},
+			intervals: 1,
+			want:      []Slot{{Available: true, UpKw: 2, DownKw: 0.5}},
+		},
+		{
+			name: "losses reduce what reaches the grid",
+			vehicle: func() Vehicle {
+				v := vehicle
+				v.MinSoc, v.Efficiency = 45, 0.9
+				return v
+			},
+			intervals: 1,
+			// 0.5 kWh above the minimum delivers 0.45 kWh.
+			want: []Slot{{Available: true, UpKw: 0.45, DownKw: 2}},
+		},
+		{
+			name: "baseline above the charger is clamped",
+			vehicle: func() Vehicle {
+				v := vehicle
+				v.BaselineKw = []float64{5}
+				return v
+			},
+			intervals: 1,
+			want:      []Slot{{Available: true, BaselineKw: 2, UpKw: 4, DownKw: 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compute(tt.vehicle(), start, time.Hour, tt.intervals)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d slots, want %d", len(got), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				want.Start = start.Add(time.Duration(i) * time.Hour)
+				s := got[i]
+				if !s.Start.Equal(want.Start) || s.Available != want.Available ||
+					!near(s.BaselineKw, want.BaselineKw) || !near(s.UpKw, want.UpKw) || !near(s.DownKw, want.DownKw) {
+					t.Errorf("slot %d = %+v, want %+v", i, s, want)
+				}
+			}
+		})
+	}
+
+	if got := Compute(vehicle, start, time.Hour, 0); got != nil {
+		t.Errorf("Compute without intervals = %v, want nil", got)
+	}
+}
+
+func TestDisaggregate(t *testing.T) {
+	tests := []struct {
+		name      string
+		deltaKw   float64
+		offers    []Offer
+		want      []float64
+		shortfall float64
+	}{
+		{
+			name:    "lowering import takes the same share of every vehicle",
+			deltaKw: -3,
+			offers:  []Offer{{BaselineKw: 1, UpKw: 2}, {UpKw: 4, DownKw: 1}},
+			want:    []float64{0, -2},
+		},
+		{
+			name:      "raising import beyond the offers leaves a shortfall",
+			deltaKw:   5,
+			offers:    []Offer{{DownKw: 1}, {BaselineKw: -1, DownKw: 1}},
+			want:      []float64{1, 0},
+			shortfall: 3,
+		},
+		{
+			name:      "no flexibility in the requested direction",
+			deltaKw:   -1,
+			offers:    []Offer{{BaselineKw: 2, DownKw: 3}},
+			want:      []float64{2},
+			shortfall: -1,
+		},
+		{
+			name:    "no change keeps the baselines",
+			deltaKw: 0,
+			offers:  []Offer{{BaselineKw: 2, UpKw: 1}},
+			want:    []float64{2},
+		},
+		{
+			name:    "negative flexibility is ignored",
+			deltaKw: -1,
+			offers:  []Offer{{BaselineKw: 1, UpKw: -1}, {BaselineKw: 1, UpKw: 2}},
+			want:    []float64{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, shortfall := Disaggregate(tt.deltaKw, tt.offers)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d setpoints, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !near(got[i], tt.want[i]) {
+					t.Errorf("setpoints = %v, want %v", got, tt.want)
+					break
+				}
+			}
+			if !near(shortfall, tt.shortfall) {
+				t.Errorf("shortfall = %v, want %v", shortfall, tt.shortfall)
+			}
+		})
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
@@ -0,0 +1,137 @@
+// Package loadbalance divides the spare capacity of a grid connection among
+// the vehicles charging behind it. Like the optimizer it has no dependencies
+// on the rest of the server, so allocations can be simulated and replayed.
+package loadbalance
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+const epsilon = 1e-9
+
+// Session is a vehicle charging behind the connection.
+type Session struct {
+	ID       string
+	Priority int
+	// Departure is when the vehicle leaves, zero when unknown.
+	Departure time.Time
+	// NeededKwh is the energy still to be charged, negative when unknown.
+	NeededKwh float64
+	// MinKw is the lowest power the charger can deliver without pausing,
+	// IEC 61851 chargers cannot go below 6 A.
+	MinKw float64
+	MaxKw float64
+}
+
+// Available is the power left for charging: the connection limit minus a
+// safety margin and everything else drawn on the site. The other load is the
+// measured site import minus what the chargers draw themselves.
+func Available(limitKw, siteImportKw, chargersKw, marginKw float64) float64 {
+	otherKw := math.Max(0, siteImportKw-chargersKw)
+	return math.Max(0, limitKw-marginKw-otherKw)
+}
+
+// Allocate divides availableKw over the sessions and returns the limit per
+// session id. Sessions are served in order of priority and then urgency:
+//
+//  1. every session gets the power it needs to finish by its departure, and
+//     at least its minimum power, before the sessions after it get their
+//     minimum. Sessions that do not fit are paused with a limit of zero;
+//  2. what is left is shared equally up to each session's maximum.
+func Allocate(availableKw float64, sessions []Session, now time.Time) map[string]float64 {
+	ordered := slices.Clone(sessions)
+	slices.SortStableFunc(ordered, func(a, b Session) int {
+		if a.Priority != b.Priority {
+			return b.Priority - a.Priority
+		}
+		if la, lb := laxity(a, now), laxity(b, now); la != lb {
+			if la < lb {
+				return -1
+			}
+			return 1
+		}
+		if a.ID < b.ID {
+			return -1
+		}
+		if a.ID > b.ID {
+			return 1
+		}
+		return 0
+	})
+
+	remaining := math.Max(0, availableKw)
+	alloc := make([]float64, len(ordered))
+	for i, s := range ordered {
+		if s.MaxKw <= 0 || remaining+epsilon < s.MinKw {
+			continue
+		}
+		alloc[i] = math.Min(math.Max(math.Max(s.MinKw, 0), math.Min(requiredKw(s, now), remaining)), s.MaxKw)
+		remaining -= alloc[i]
+	}
+
+	for remaining > epsilon {
+		open := 0
+		for i, s := range ordered {
+			if (alloc[i] > 0 || s.MinKw <= 0) && alloc[i] < s.MaxKw-epsilon {
+				open++
+			}
+		}
+		if open == 0 {
+			break
+		}
+
+		share := remaining / float64(open)
+		for i, s := range ordered {
+			if (alloc[i] > 0 || s.MinKw <= 0) && alloc[i] < s.MaxKw-epsilon {
+				extra := math.Min(share, s.MaxKw-alloc[i])
+				alloc[i] += extra
+				remaining -= extra
+			}
+		}
+	}
+
+	limits := make(map[string]float64, len(ordered))
+	for i, s := range ordered {
+		// Round down so the sum never exceeds what is available, but never
+		// below the minimum: the charger would pause instead.
+		limit := math.Floor(alloc[i]*10+epsilon) / 10
+		if alloc[i] > 0 && alloc[i]+epsilon >= s.MinKw {
+			limit = math.Max(limit, s.MinKw)
+		}
+		limits[s.ID] = limit
+	}
+
+	return limits
+}
+
+// requiredKw is the average power needed to finish by the departure, capped
+// at the session maximum. Sessions without a deadline require nothing.
+func requiredKw(s Session, now time.Time) float64 {
+	if s.Departure.IsZero() || s.NeededKwh <= 0 {
+		return 0
+	}
+
+	hours := s.Departure.Sub(now).Hours()
+	if hours <= 0 {
+		return s.MaxKw
+	}
+
+	return math.Min(s.NeededKwh/hours, s.MaxKw)
+}
+
+// laxity is how many hours charging can be postponed while still finishing
+// at full power before the departure. Sessions without a deadline come last.
+func laxity(s Session, now time.Time) float64 {
+	if s.Departure.IsZero() {
+		return math.Inf(1)
+	}
+
+	hours := s.Departure.Sub(now).Hours()
+	if s.NeededKwh > 0 && s.MaxKw > 0 {
+		hours -= s.NeededKwh / s.MaxKw
+	}
+
+	return hours
+}
@@ -0,0 +1,53 @@
+package loadbalance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllocateKeepsMinimum(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		availableKw float64
+		sessions    []Session
+		want        map[string]float64
+	}{
+		{
+			name:        "single session just above the minimum",
+			availableKw: 4.19,
+			sessions:    []Session{{ID: "a", MinKw: 4.14, MaxKw: 11}},
+			want:        map[string]float64{"a": 4.14},
+		},
+		{
+			name:        "two sessions sharing",
+			availableKw: 8.3,
+			sessions:    []Session{{ID: "a", MinKw: 4.14, MaxKw: 11}, {ID: "b", MinKw: 4.14, MaxKw: 11}},
+			want:        map[string]float64{"a": 4.14, "b": 4.14},
+		},
+		{
+			name:        "single phase",
+			availableKw: 1.39,
+			sessions:    []Session{{ID: "a", MinKw: 1.38, MaxKw: 7.4}},
+			want:        map[string]float64{"a": 1.38},
+		},
+		{
+			name:        "below the minimum pauses",
+			availableKw: 4.1,
+			sessions:    []Session{{ID: "a", MinKw: 4.14, MaxKw: 11}},
+			want:        map[string]float64{"a": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Allocate(tt.availableKw, tt.sessions, now)
+			for id, want := range tt.want {
+				if got[id] != want {
+					t.Errorf("%s: got %v kW, want %v kW", id, got[id], want)
+				}
+			}
+		})
+	}
+}
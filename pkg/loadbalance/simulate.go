@@ -0,0 +1,216 @@
+package loadbalance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Scenario describes a site for the simulator. Times are minutes since the
+// start of the simulation.
+type Scenario struct {
+	LimitKw  float64 `json:"limitKw"`
+	MarginKw float64 `json:"marginKw"`
+	// StepSeconds is the control interval, the balancer sees the building
+	// load measured one step earlier.
+	StepSeconds    int `json:"stepSeconds"`
+	HorizonMinutes int `json:"horizonMinutes"`
+	// BaseLoadKw is the building load at the start of every hour, linearly
+	// interpolated in between. The last value holds for the rest of the
+	// horizon.
+	BaseLoadKw []float64 `json:"baseLoadKw"`
+	Vehicles   []Vehicle `json:"vehicles"`
+}
+
+type Vehicle struct {
+	ID              string  `json:"id"`
+	Priority        int     `json:"priority"`
+	ArrivalMinute   int     `json:"arrivalMinute"`
+	DepartureMinute int     `json:"departureMinute"`
+	NeededKwh       float64 `json:"neededKwh"`
+	MinKw           float64 `json:"minKw"`
+	MaxKw           float64 `json:"maxKw"`
+}
+
+type VehicleResult struct {
+	ID           string  `json:"id"`
+	NeededKwh    float64 `json:"neededKwh"`
+	DeliveredKwh float64 `json:"deliveredKwh"`
+	ShortfallKwh float64 `json:"shortfallKwh"`
+	// FinishedMinute is when the vehicle was fully charged, nil when it left
+	// before.
+	FinishedMinute *int `json:"finishedMinute,omitempty"`
+}
+
+type Report struct {
+	Steps int `json:"steps"`
+	// PeakKw is the highest import of the site including the building.
+	PeakKw float64 `json:"peakKw"`
+	// Violations counts the steps in which the import exceeded the limit.
+	Violations   int             `json:"violations"`
+	OverloadKwh  float64         `json:"overloadKwh"`
+	DeliveredKwh float64         `json:"deliveredKwh"`
+	ShortfallKwh float64         `json:"shortfallKwh"`
+	Vehicles     []VehicleResult `json:"vehicles"`
+}
+
+func (sc *Scenario) Validate() error {
+	var errs []error
+	if sc.LimitKw <= 0 {
+		errs = append(errs, errors.New("limitKw must be positive"))
+	}
+	if sc.MarginKw < 0 || sc.MarginKw >= sc.LimitKw {
+		errs = append(errs, errors.New("marginKw must be between 0 and limitKw"))
+	}
+	if sc.StepSeconds <= 0 {
+		errs = append(errs, errors.New("stepSeconds must be positive"))
+	}
+	if sc.HorizonMinutes <= 0 {
+		errs = append(errs, errors.New("horizonMinutes must be positive"))
+	}
+
+	seen := make(map[string]bool)
+	for i, v := range sc.Vehicles {
+		if v.ID == "" || seen[v.ID] {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: id must be unique and not empty", i))
+		}
+		seen[v.ID] = true
+
+		if v.DepartureMinute <= v.ArrivalMinute {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: departure must be after arrival", i))
+		}
+		if v.MaxKw <= 0 || v.MinKw < 0 || v.MinKw > v.MaxKw {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: power must satisfy 0 <= minKw <= maxKw and maxKw > 0", i))
+		}
+		if v.NeededKwh < 0 {
+			errs = append(errs, fmt.Errorf("vehicles[%d]: neededKwh cannot be negative", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stepResult is what the balancer had available and allocated in one step of a
+// simulation.
+type stepResult struct {
+	elapsed     time.Duration
+	availableKw float64
+	sessions    []Session
+	limits      map[string]float64
+}
+
+// Simulate runs the balancer over the scenario. Every step it allocates the
+// capacity left by the building load of the previous step, after which each
+// vehicle draws up to its limit until it is full.
+func Simulate(sc Scenario) (*Report, error) {
+	return simulate(sc, nil)
+}
+
+// simulate is Simulate, calling observe with every step when it is set.
+func simulate(sc Scenario, observe func(stepResult)) (*Report, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+
+	origin := time.Unix(0, 0).UTC()
+	step := time.Duration(sc.StepSeconds) * time.Second
+	hours := step.Hours()
+
+	delivered := make([]float64, len(sc.Vehicles))
+	finished := make([]*int, len(sc.Vehicles))
+	report := &Report{}
+
+	lastBaseKw, lastChargersKw := sc.baseLoadAt(0), 0.0
+	for elapsed := time.Duration(0); elapsed < time.Duration(sc.HorizonMinutes)*time.Minute; elapsed += step {
+		now := origin.Add(elapsed)
+		minute := int(elapsed.Minutes())
+
+		var sessions []Session
+		for i, v := range sc.Vehicles {
+			if minute < v.ArrivalMinute || minute >= v.DepartureMinute || finished[i] != nil {
+				continue
+			}
+
+			sessions = append(sessions, Session{
+				ID:        v.ID,
+				Priority:  v.Priority,
+				Departure: origin.Add(time.Duration(v.DepartureMinute) * time.Minute),
+				NeededKwh: v.NeededKwh - delivered[i],
+				MinKw:     v.MinKw,
+				MaxKw:     v.MaxKw,
+			})
+		}
+
+		available := Available(sc.LimitKw, lastBaseKw+lastChargersKw, lastChargersKw, sc.MarginKw)
+		limits := Allocate(available, sessions, now)
+		if observe != nil {
+			observe(stepResult{elapsed: elapsed, availableKw: available, sessions: sessions, limits: limits})
+		}
+
+		chargersKw := 0.0
+		for i, v := range sc.Vehicles {
+			limit, ok := limits[v.ID]
+			if !ok {
+				continue
+			}
+
+			powerKw := math.Min(limit, (v.NeededKwh-delivered[i])/hours)
+			delivered[i] += powerKw * hours
+			chargersKw += powerKw
+			if v.NeededKwh-delivered[i] < epsilon {
+				end := int((elapsed + step).Minutes())
+				finished[i] = &end
+			}
+		}
+
+		baseKw := sc.baseLoadAt(elapsed)
+		importKw := baseKw + chargersKw
+		report.Steps++
+		report.PeakKw = math.Max(report.PeakKw, importKw)
+		if importKw > sc.LimitKw+epsilon {
+			report.Violations++
+			report.OverloadKwh += (importKw - sc.LimitKw) * hours
+		}
+
+		lastBaseKw, lastChargersKw = baseKw, chargersKw
+	}
+
+	for i, v := range sc.Vehicles {
+		res := VehicleResult{
+			ID:             v.ID,
+			NeededKwh:      v.NeededKwh,
+			DeliveredKwh:   round(delivered[i]),
+			ShortfallKwh:   round(math.Max(0, v.NeededKwh-delivered[i])),
+			FinishedMinute: finished[i],
+		}
+
+		report.DeliveredKwh += res.DeliveredKwh
+		report.ShortfallKwh += res.ShortfallKwh
+		report.Vehicles = append(report.Vehicles, res)
+	}
+
+	report.PeakKw = round(report.PeakKw)
+	report.OverloadKwh = round(report.OverloadKwh)
+	report.DeliveredKwh = round(report.DeliveredKwh)
+	report.ShortfallKwh = round(report.ShortfallKwh)
+	return report, nil
+}
+
+func (sc *Scenario) baseLoadAt(elapsed time.Duration) float64 {
+	if len(sc.BaseLoadKw) == 0 {
+		return 0
+	}
+
+	hour := int(elapsed.Hours())
+	if hour >= len(sc.BaseLoadKw)-1 {
+		return sc.BaseLoadKw[len(sc.BaseLoadKw)-1]
+	}
+
+	frac := elapsed.Hours() - float64(hour)
+	return sc.BaseLoadKw[hour] + (sc.BaseLoadKw[hour+1]-sc.BaseLoadKw[hour])*frac
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
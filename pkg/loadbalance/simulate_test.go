@@ -0,0 +1,56 @@
+package loadbalance
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// TestSimulateOffice replays the scenario of the load simulator.
+func TestSimulateOffice(t *testing.T) {
+	f, err := os.Open("../../cmd/loadsim/scenarios/office.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var sc Scenario
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		t.Fatalf("invalid scenario: %v", err)
+	}
+
+	vehicles := make(map[string]Vehicle, len(sc.Vehicles))
+	for _, v := range sc.Vehicles {
+		vehicles[v.ID] = v
+	}
+
+	report, err := simulate(sc, func(s stepResult) {
+		total := 0.0
+		for id, limit := range s.limits {
+			total += limit
+			if v := vehicles[id]; limit != 0 && (limit < v.MinKw-epsilon || limit > v.MaxKw+epsilon) {
+				t.Errorf("minute %v: %s is allocated %v kW, outside [%v, %v]", s.elapsed.Minutes(), id, limit, v.MinKw, v.MaxKw)
+			}
+		}
+
+		if total > s.availableKw+epsilon {
+			t.Errorf("minute %v: allocated %v kW of the %v kW available", s.elapsed.Minutes(), total, s.availableKw)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+
+	if report.Violations > 0 {
+		t.Errorf("connection limit exceeded in %d of %d steps", report.Violations, report.Steps)
+	}
+
+	for _, res := range report.Vehicles {
+		v := vehicles[res.ID]
+		if res.FinishedMinute == nil || *res.FinishedMinute > v.DepartureMinute {
+			t.Errorf("%s left at minute %d with %v of %v kWh", res.ID, v.DepartureMinute, res.DeliveredKwh, res.NeededKwh)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"github.com/V2G-Minor-Fontys/server/internal/config"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	QosAtLeastOnce byte = 1
+	connectTimeout      = 10 * time.Second
+)
+
+type MessageHandler func(topic string, payload []byte)
+
+type Client struct {
+	client paho.Client
+	mu     sync.RWMutex
+	subs   map[string]MessageHandler
+}
+
+func Connect(cfg *config.Mqtt) (*Client, error) {
+	c := &Client{subs: make(map[string]MessageHandler)}
+
+	opts := paho.NewClientOptions().
+		AddBroker(fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)).
+		SetClientID("v2g-server-" + uuid.NewString()).
+		SetUsername(cfg.Username).
+		SetAutoReconnect(true).
+		SetConnectTimeout(connectTimeout).
+		SetOnConnectHandler(c.resubscribe).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			slog.Warn("MQTT connection lost", "error", err)
+		})
+
+	c.client = paho.NewClient(opts)
+	token := c.client.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		return nil, fmt.Errorf("timed out connecting to MQTT broker %s", cfg.Host)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+
+	return c, nil
+}
+
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte) error {
+	return wait(ctx, c.client.Publish(topic, QosAtLeastOnce, false, payload))
+}
+
+func (c *Client) Subscribe(topic string, handler MessageHandler) error {
+	c.mu.Lock()
+	c.subs[topic] = handler
+	c.mu.Unlock()
+
+	return wait(context.Background(), c.subscribe(topic, handler))
+}
+
+func (c *Client) Close() {
+	c.client.Disconnect(250)
+}
+
+func (c *Client) subscribe(topic string, handler MessageHandler) paho.Token {
+	return c.client.Subscribe(topic, QosAtLeastOnce, func(_ paho.Client, msg paho.Message) {
+		handler(msg.Topic(), msg.Payload())
+	})
+}
+
+// resubscribe restores subscriptions after a reconnect, since the broker
+// drops them together with the clean session.
+func (c *Client) resubscribe(_ paho.Client) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for topic, handler := range c.subs {
+		if err := wait(context.Background(), c.subscribe(topic, handler)); err != nil {
+			slog.Error("Could not restore MQTT subscription", "topic", topic, "error", err)
+		}
+	}
+}
+
+func wait(ctx context.Context, token paho.Token) error {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,57 @@
+package ocpp
+
+import "time"
+
+const (
+	ActionBootNotification   = "BootNotification"
+	ActionHeartbeat          = "Heartbeat"
+	ActionStatusNotification = "StatusNotification"
+)
+
+const (
+	RegistrationAccepted = "Accepted"
+	RegistrationRejected = "Rejected"
+)
+
+// BootNotificationRequest16 is sent by 1.6 charge points, 2.0.1 charge points
+// nest the same fields in chargingStation.
+type BootNotificationRequest16 struct {
+	ChargePointVendor       string `json:"chargePointVendor"`
+	ChargePointModel        string `json:"chargePointModel"`
+	ChargePointSerialNumber string `json:"chargePointSerialNumber,omitempty"`
+	FirmwareVersion         string `json:"firmwareVersion,omitempty"`
+}
+
+type BootNotificationRequest201 struct {
+	Reason          string `json:"reason"`
+	ChargingStation struct {
+		Model           string `json:"model"`
+		VendorName      string `json:"vendorName"`
+		SerialNumber    string `json:"serialNumber,omitempty"`
+		FirmwareVersion string `json:"firmwareVersion,omitempty"`
+	} `json:"chargingStation"`
+}
+
+type BootNotificationResponse struct {
+	CurrentTime time.Time `json:"currentTime"`
+	Interval    int       `json:"interval"`
+	Status      string    `json:"status"`
+}
+
+type HeartbeatResponse struct {
+	CurrentTime time.Time `json:"currentTime"`
+}
+
+type StatusNotificationRequest16 struct {
+	ConnectorID int        `json:"connectorId"`
+	ErrorCode   string     `json:"errorCode"`
+	Status      string     `json:"status"`
+	Timestamp   *time.Time `json:"timestamp,omitempty"`
+}
+
+type StatusNotificationRequest201 struct {
+	Timestamp       time.Time `json:"timestamp"`
+	ConnectorStatus string    `json:"connectorStatus"`
+	EvseID          int       `json:"evseId"`
+	ConnectorID     int       `json:"connectorId"`
+}
@@ -0,0 +1,104 @@
+// Package ocpp contains the OCPP-J message framing and the payloads the
+// server exchanges with charge points for OCPP 1.6 and 2.0.1.
+package ocpp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type Version string
+
+const (
+	V16  Version = "ocpp1.6"
+	V201 Version = "ocpp2.0.1"
+)
+
+var SupportedVersions = []string{string(V201), string(V16)}
+
+const (
+	TypeCall       = 2
+	TypeCallResult = 3
+	TypeCallError  = 4
+)
+
+const (
+	ErrorNotImplemented     = "NotImplemented"
+	ErrorFormationViolation = "FormationViolation"
+	ErrorInternalError      = "InternalError"
+	ErrorGenericError       = "GenericError"
+)
+
+var ErrMalformedMessage = errors.New("ocpp: malformed message")
+
+// Message is a decoded OCPP-J frame, Action and Payload are set for calls,
+// Payload for results and the Error fields for call errors.
+type Message struct {
+	Type             int
+	ID               string
+	Action           string
+	Payload          json.RawMessage
+	ErrorCode        string
+	ErrorDescription string
+}
+
+func Decode(raw []byte) (*Message, error) {
+	var frame []json.RawMessage
+	if err := json.Unmarshal(raw, &frame); err != nil || len(frame) < 3 {
+		return nil, ErrMalformedMessage
+	}
+
+	m := &Message{}
+	if err := json.Unmarshal(frame[0], &m.Type); err != nil {
+		return nil, ErrMalformedMessage
+	}
+	if err := json.Unmarshal(frame[1], &m.ID); err != nil {
+		return nil, ErrMalformedMessage
+	}
+
+	switch m.Type {
+	case TypeCall:
+		if len(frame) != 4 {
+			return nil, ErrMalformedMessage
+		}
+		if err := json.Unmarshal(frame[2], &m.Action); err != nil {
+			return nil, ErrMalformedMessage
+		}
+		m.Payload = frame[3]
+	case TypeCallResult:
+		m.Payload = frame[2]
+	case TypeCallError:
+		if len(frame) < 4 {
+			return nil, ErrMalformedMessage
+		}
+		_ = json.Unmarshal(frame[2], &m.ErrorCode)
+		_ = json.Unmarshal(frame[3], &m.ErrorDescription)
+	default:
+		return nil, ErrMalformedMessage
+	}
+
+	return m, nil
+}
+
+func EncodeCall(id, action string, payload any) ([]byte, error) {
+	return json.Marshal([]any{TypeCall, id, action, payload})
+}
+
+func EncodeResult(id string, payload any) ([]byte, error) {
+	return json.Marshal([]any{TypeCallResult, id, payload})
+}
+
+func EncodeError(id, code, description string) ([]byte, error) {
+	return json.Marshal([]any{TypeCallError, id, code, description, struct{}{}})
+}
+
+// CallError is returned when a charge point answers a call with an error frame.
+type CallError struct {
+	Code        string
+	Description string
+}
+
+func (e *CallError) Error() string {
+	return fmt.Sprintf("ocpp call error %s: %s", e.Code, e.Description)
+}
@@ -0,0 +1,34 @@
+package ocpp
+
+// Remote start and stop of transactions, 2.0.1 renamed the actions and
+// answers a start with the transaction id when it began right away.
+const (
+	ActionRemoteStartTransaction  = "RemoteStartTransaction"
+	ActionRemoteStopTransaction   = "RemoteStopTransaction"
+	ActionRequestStartTransaction = "RequestStartTransaction"
+	ActionRequestStopTransaction  = "RequestStopTransaction"
+)
+
+type RemoteStartTransactionRequest16 struct {
+	ConnectorID *int   `json:"connectorId,omitempty"`
+	IdTag       string `json:"idTag"`
+}
+
+type RemoteStopTransactionRequest16 struct {
+	TransactionID int `json:"transactionId"`
+}
+
+type RequestStartTransactionRequest201 struct {
+	EvseID        *int    `json:"evseId,omitempty"`
+	RemoteStartID int     `json:"remoteStartId"`
+	IdToken       IdToken `json:"idToken"`
+}
+
+type RequestStartTransactionResponse201 struct {
+	Status        string `json:"status"`
+	TransactionID string `json:"transactionId,omitempty"`
+}
+
+type RequestStopTransactionRequest201 struct {
+	TransactionID string `json:"transactionId"`
+}
@@ -0,0 +1,51 @@
+package ocpp
+
+import "time"
+
+const (
+	ActionReserveNow              = "ReserveNow"
+	ActionCancelReservation       = "CancelReservation"
+	ActionReservationStatusUpdate = "ReservationStatusUpdate"
+)
+
+// ReserveNow answers besides Accepted and Rejected.
+const (
+	StatusOccupied    = "Occupied"
+	StatusFaulted     = "Faulted"
+	StatusUnavailable = "Unavailable"
+)
+
+// ReservationUpdate statuses, a 2.0.1 station reports them when it drops a
+// reservation on its own.
+const (
+	ReservationUpdateExpired = "Expired"
+	ReservationUpdateRemoved = "Removed"
+)
+
+// IdTokenCentral is the 2.0.1 token type of ids generated by the central
+// system rather than read from a card.
+const IdTokenCentral = "Central"
+
+type ReserveNowRequest16 struct {
+	ConnectorID   int       `json:"connectorId"`
+	ExpiryDate    time.Time `json:"expiryDate"`
+	IdTag         string    `json:"idTag"`
+	ReservationID int       `json:"reservationId"`
+}
+
+type ReserveNowRequest201 struct {
+	ID             int       `json:"id"`
+	ExpiryDateTime time.Time `json:"expiryDateTime"`
+	IdToken        IdToken   `json:"idToken"`
+	EvseID         *int      `json:"evseId,omitempty"`
+}
+
+// CancelReservationRequest is the same for both versions.
+type CancelReservationRequest struct {
+	ReservationID int `json:"reservationId"`
+}
+
+type ReservationStatusUpdateRequest201 struct {
+	ReservationID           int    `json:"reservationId"`
+	ReservationUpdateStatus string `json:"reservationUpdateStatus"`
+}
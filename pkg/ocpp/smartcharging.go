@@ -0,0 +1,146 @@
+package ocpp
+
+import "time"
+
+const (
+	ActionSetChargingProfile   = "SetChargingProfile"
+	ActionClearChargingProfile = "ClearChargingProfile"
+	ActionGetCompositeSchedule = "GetCompositeSchedule"
+)
+
+const (
+	PurposeTxProfile        = "TxProfile"
+	PurposeTxDefaultProfile = "TxDefaultProfile"
+	// PurposeChargePointMaxProfile limits the charger as a whole and is only
+	// accepted on connector 0, 2.0.1 renamed it to ChargingStationMaxProfile.
+	PurposeChargePointMaxProfile     = "ChargePointMaxProfile"
+	PurposeChargingStationMaxProfile = "ChargingStationMaxProfile"
+	KindAbsolute                     = "Absolute"
+	RateUnitWatt                     = "W"
+)
+
+const (
+	StatusAccepted = "Accepted"
+	StatusRejected = "Rejected"
+	StatusUnknown  = "Unknown"
+)
+
+type ChargingSchedulePeriod struct {
+	StartPeriod  int     `json:"startPeriod"`
+	Limit        float64 `json:"limit"`
+	NumberPhases int     `json:"numberPhases,omitempty"`
+}
+
+// ChargingSchedule16 is the 1.6 schedule, limits must not be negative.
+type ChargingSchedule16 struct {
+	Duration               int                      `json:"duration,omitempty"`
+	StartSchedule          *time.Time               `json:"startSchedule,omitempty"`
+	ChargingRateUnit       string                   `json:"chargingRateUnit"`
+	ChargingSchedulePeriod []ChargingSchedulePeriod `json:"chargingSchedulePeriod"`
+}
+
+type ChargingProfile16 struct {
+	ChargingProfileID      int                `json:"chargingProfileId"`
+	TransactionID          int                `json:"transactionId,omitempty"`
+	StackLevel             int                `json:"stackLevel"`
+	ChargingProfilePurpose string             `json:"chargingProfilePurpose"`
+	ChargingProfileKind    string             `json:"chargingProfileKind"`
+	ChargingSchedule       ChargingSchedule16 `json:"chargingSchedule"`
+}
+
+type SetChargingProfileRequest16 struct {
+	ConnectorID        int               `json:"connectorId"`
+	CsChargingProfiles ChargingProfile16 `json:"csChargingProfiles"`
+}
+
+// ChargingSchedule201 is the 2.0.1 schedule, negative limits request
+// discharging when the station supports V2X.
+type ChargingSchedule201 struct {
+	ID                     int                      `json:"id"`
+	Duration               int                      `json:"duration,omitempty"`
+	StartSchedule          *time.Time               `json:"startSchedule,omitempty"`
+	ChargingRateUnit       string                   `json:"chargingRateUnit"`
+	ChargingSchedulePeriod []ChargingSchedulePeriod `json:"chargingSchedulePeriod"`
+}
+
+type ChargingProfile201 struct {
+	ID                     int                   `json:"id"`
+	StackLevel             int                   `json:"stackLevel"`
+	ChargingProfilePurpose string                `json:"chargingProfilePurpose"`
+	ChargingProfileKind    string                `json:"chargingProfileKind"`
+	TransactionID          string                `json:"transactionId,omitempty"`
+	ChargingSchedule       []ChargingSchedule201 `json:"chargingSchedule"`
+}
+
+type SetChargingProfileRequest201 struct {
+	EvseID          int                `json:"evseId"`
+	ChargingProfile ChargingProfile201 `json:"chargingProfile"`
+}
+
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+type ClearChargingProfileRequest16 struct {
+	ID                     *int   `json:"id,omitempty"`
+	ConnectorID            *int   `json:"connectorId,omitempty"`
+	ChargingProfilePurpose string `json:"chargingProfilePurpose,omitempty"`
+	StackLevel             *int   `json:"stackLevel,omitempty"`
+}
+
+type ClearChargingProfileCriteria201 struct {
+	EvseID                 *int   `json:"evseId,omitempty"`
+	ChargingProfilePurpose string `json:"chargingProfilePurpose,omitempty"`
+	StackLevel             *int   `json:"stackLevel,omitempty"`
+}
+
+type ClearChargingProfileRequest201 struct {
+	ChargingProfileID       *int                             `json:"chargingProfileId,omitempty"`
+	ChargingProfileCriteria *ClearChargingProfileCriteria201 `json:"chargingProfileCriteria,omitempty"`
+}
+
+type GetCompositeScheduleRequest16 struct {
+	ConnectorID      int    `json:"connectorId"`
+	Duration         int    `json:"duration"`
+	ChargingRateUnit string `json:"chargingRateUnit,omitempty"`
+}
+
+type GetCompositeScheduleResponse16 struct {
+	Status           string              `json:"status"`
+	ConnectorID      int                 `json:"connectorId,omitempty"`
+	ScheduleStart    *time.Time          `json:"scheduleStart,omitempty"`
+	ChargingSchedule *ChargingSchedule16 `json:"chargingSchedule,omitempty"`
+}
+
+type GetCompositeScheduleRequest201 struct {
+	Duration         int    `json:"duration"`
+	ChargingRateUnit string `json:"chargingRateUnit,omitempty"`
+	EvseID           int    `json:"evseId"`
+}
+
+type CompositeSchedule201 struct {
+	EvseID                 int                      `json:"evseId"`
+	Duration               int                      `json:"duration"`
+	ScheduleStart          time.Time                `json:"scheduleStart"`
+	ChargingRateUnit       string                   `json:"chargingRateUnit"`
+	ChargingSchedulePeriod []ChargingSchedulePeriod `json:"chargingSchedulePeriod"`
+}
+
+type GetCompositeScheduleResponse201 struct {
+	Status   string                `json:"status"`
+	Schedule *CompositeSchedule201 `json:"schedule,omitempty"`
+}
+
+// LimitAt returns the limit of the period active offset seconds after the
+// schedule start, periods must be ordered by start.
+func LimitAt(periods []ChargingSchedulePeriod, offset int) (float64, bool) {
+	limit, found := 0.0, false
+	for _, p := range periods {
+		if p.StartPeriod > offset {
+			break
+		}
+		limit, found = p.Limit, true
+	}
+
+	return limit, found
+}
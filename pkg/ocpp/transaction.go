@@ -0,0 +1,220 @@
+package ocpp
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	ActionAuthorize        = "Authorize"
+	ActionStartTransaction = "StartTransaction"
+	ActionStopTransaction  = "StopTransaction"
+	ActionMeterValues      = "MeterValues"
+	ActionTransactionEvent = "TransactionEvent"
+)
+
+const (
+	AuthorizationAccepted = "Accepted"
+
+	TransactionEventStarted = "Started"
+	TransactionEventUpdated = "Updated"
+	TransactionEventEnded   = "Ended"
+
+	MeasurandEnergyImport = "Energy.Active.Import.Register"
+	MeasurandEnergyExport = "Energy.Active.Export.Register"
+	MeasurandPowerImport  = "Power.Active.Import"
+	MeasurandPowerExport  = "Power.Active.Export"
+	MeasurandSoC          = "SoC"
+)
+
+type IdTagInfo struct {
+	Status string `json:"status"`
+}
+
+type AuthorizeRequest16 struct {
+	IdTag string `json:"idTag"`
+}
+
+type AuthorizeResponse16 struct {
+	IdTagInfo IdTagInfo `json:"idTagInfo"`
+}
+
+type AuthorizeResponse201 struct {
+	IdTokenInfo IdTagInfo `json:"idTokenInfo"`
+}
+
+type StartTransactionRequest16 struct {
+	ConnectorID   int       `json:"connectorId"`
+	IdTag         string    `json:"idTag"`
+	MeterStart    int       `json:"meterStart"`
+	ReservationID *int      `json:"reservationId,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type StartTransactionResponse16 struct {
+	IdTagInfo     IdTagInfo `json:"idTagInfo"`
+	TransactionID int       `json:"transactionId"`
+}
+
+type StopTransactionRequest16 struct {
+	IdTag           string         `json:"idTag,omitempty"`
+	MeterStop       int            `json:"meterStop"`
+	Timestamp       time.Time      `json:"timestamp"`
+	TransactionID   int            `json:"transactionId"`
+	Reason          string         `json:"reason,omitempty"`
+	TransactionData []MeterValue16 `json:"transactionData,omitempty"`
+}
+
+type StopTransactionResponse16 struct {
+	IdTagInfo *IdTagInfo `json:"idTagInfo,omitempty"`
+}
+
+type SampledValue16 struct {
+	Value     string `json:"value"`
+	Context   string `json:"context,omitempty"`
+	Measurand string `json:"measurand,omitempty"`
+	Phase     string `json:"phase,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+}
+
+type MeterValue16 struct {
+	Timestamp    time.Time        `json:"timestamp"`
+	SampledValue []SampledValue16 `json:"sampledValue"`
+}
+
+type MeterValuesRequest16 struct {
+	ConnectorID   int            `json:"connectorId"`
+	TransactionID *int           `json:"transactionId,omitempty"`
+	MeterValue    []MeterValue16 `json:"meterValue"`
+}
+
+type UnitOfMeasure struct {
+	Unit       string `json:"unit,omitempty"`
+	Multiplier int    `json:"multiplier,omitempty"`
+}
+
+type SampledValue201 struct {
+	Value         float64        `json:"value"`
+	Context       string         `json:"context,omitempty"`
+	Measurand     string         `json:"measurand,omitempty"`
+	Phase         string         `json:"phase,omitempty"`
+	UnitOfMeasure *UnitOfMeasure `json:"unitOfMeasure,omitempty"`
+}
+
+type MeterValue201 struct {
+	Timestamp    time.Time         `json:"timestamp"`
+	SampledValue []SampledValue201 `json:"sampledValue"`
+}
+
+type EVSE struct {
+	ID          int `json:"id"`
+	ConnectorID int `json:"connectorId,omitempty"`
+}
+
+type IdToken struct {
+	IdToken string `json:"idToken"`
+	Type    string `json:"type"`
+}
+
+type TransactionInfo struct {
+	TransactionID string `json:"transactionId"`
+	ChargingState string `json:"chargingState,omitempty"`
+	StoppedReason string `json:"stoppedReason,omitempty"`
+}
+
+type TransactionEventRequest201 struct {
+	EventType       string          `json:"eventType"`
+	Timestamp       time.Time       `json:"timestamp"`
+	TriggerReason   string          `json:"triggerReason"`
+	SeqNo           int             `json:"seqNo"`
+	Offline         bool            `json:"offline,omitempty"`
+	TransactionInfo TransactionInfo `json:"transactionInfo"`
+	IdToken         *IdToken        `json:"idToken,omitempty"`
+	EVSE            *EVSE           `json:"evse,omitempty"`
+	MeterValue      []MeterValue201 `json:"meterValue,omitempty"`
+}
+
+type TransactionEventResponse201 struct {
+	IdTokenInfo *IdTagInfo `json:"idTokenInfo,omitempty"`
+}
+
+type MeterValuesRequest201 struct {
+	EvseID     int             `json:"evseId"`
+	MeterValue []MeterValue201 `json:"meterValue"`
+}
+
+// Reading is an energy register sample in Wh, Export is nil when the charge
+// point did not report the export register.
+type Reading struct {
+	Timestamp time.Time
+	ImportWh  float64
+	ExportWh  *float64
+}
+
+// Readings16 extracts the import and export registers of 1.6 meter values,
+// samples without an import register are skipped.
+func Readings16(values []MeterValue16) []Reading {
+	var res []Reading
+	for _, mv := range values {
+		r := Reading{Timestamp: mv.Timestamp}
+		hasImport := false
+		for _, sv := range mv.SampledValue {
+			if sv.Phase != "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(sv.Value, 64)
+			if err != nil {
+				continue
+			}
+			if sv.Unit == "kWh" {
+				v *= 1000
+			}
+
+			switch sv.Measurand {
+			case "", MeasurandEnergyImport:
+				r.ImportWh, hasImport = v, true
+			case MeasurandEnergyExport:
+				r.ExportWh = &v
+			}
+		}
+		if hasImport {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
+
+// Readings201 is the 2.0.1 counterpart of Readings16.
+func Readings201(values []MeterValue201) []Reading {
+	var res []Reading
+	for _, mv := range values {
+		r := Reading{Timestamp: mv.Timestamp}
+		hasImport := false
+		for _, sv := range mv.SampledValue {
+			if sv.Phase != "" {
+				continue
+			}
+			v := sv.Value
+			if u := sv.UnitOfMeasure; u != nil {
+				v *= math.Pow10(u.Multiplier)
+				if u.Unit == "kWh" {
+					v *= 1000
+				}
+			}
+
+			switch sv.Measurand {
+			case "", MeasurandEnergyImport:
+				r.ImportWh, hasImport = v, true
+			case MeasurandEnergyExport:
+				r.ExportWh = &v
+			}
+		}
+		if hasImport {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
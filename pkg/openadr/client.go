@@ -0,0 +1,62 @@
+package openadr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Client posts messages to the service endpoints of a VTN on behalf of a
+// VEN.
+type Client struct {
+	http    *http.Client
+	baseURL string
+	token   string
+}
+
+// NewClient talks to the VTN at baseURL, the URL the service paths below
+// BasePath are appended to. A non-empty token is sent as bearer token.
+func NewClient(httpClient *http.Client, baseURL, token string) *Client {
+	return &Client{
+		http:    httpClient,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+	}
+}
+
+// Send posts m, a pointer to one of the message types of SignedObject, to
+// the endpoint of service and returns the message the VTN answered with.
+func (c *Client) Send(ctx context.Context, service string, m any) (*SignedObject, error) {
+	var body bytes.Buffer
+	if err := Encode(&body, Wrap(m)); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+BasePath+"/"+service, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openadr: %s responded with status %d", service, res.StatusCode)
+	}
+
+	p, err := Decode(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("openadr: could not decode %s response: %w", service, err)
+	}
+
+	return &p.SignedObject, nil
+}
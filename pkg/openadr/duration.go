@@ -0,0 +1,90 @@
+package openadr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// Duration is an RFC 5545 duration such as PT15M or P1D. Years and months are
+// not allowed in RFC 5545 and are rejected.
+type Duration time.Duration
+
+func ParseDuration(s string) (Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("openadr: invalid duration %q", s)
+	}
+
+	var d time.Duration
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute}
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("openadr: invalid duration %q", s)
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[6] != "" {
+		seconds, err := strconv.ParseFloat(m[6], 64)
+		if err != nil {
+			return 0, fmt.Errorf("openadr: invalid duration %q", s)
+		}
+		d += time.Duration(seconds * float64(time.Second))
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+
+	return Duration(d), nil
+}
+
+// String formats the duration in hours, minutes and seconds, which every
+// implementation accepts.
+func (d Duration) String() string {
+	v := time.Duration(d)
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	if v == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	b.WriteString(sign + "PT")
+	if h := v / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+		v -= h * time.Hour
+	}
+	if m := v / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+		v -= m * time.Minute
+	}
+	if v > 0 {
+		b.WriteString(strconv.FormatFloat(v.Seconds(), 'f', -1, 64) + "S")
+	}
+
+	return b.String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = v
+	return nil
+}
@@ -0,0 +1,158 @@
+package openadr
+
+import "time"
+
+// Event statuses, derived from the active period of the event.
+const (
+	StatusNone      = "none"
+	StatusFar       = "far"
+	StatusNear      = "near"
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// Signal names and the types they are sent with. SIMPLE levels range from 0
+// (normal operation) to 3 (special), ELECTRICITY_PRICE carries a price per
+// kWh and LOAD_DISPATCH a power setpoint in kW.
+const (
+	SignalSimple           = "SIMPLE"
+	SignalElectricityPrice = "ELECTRICITY_PRICE"
+	SignalLoadDispatch     = "LOAD_DISPATCH"
+	SignalTypeLevel        = "level"
+	SignalTypePrice        = "price"
+	SignalTypeSetpoint     = "setpoint"
+)
+
+const (
+	OptIn  = "optIn"
+	OptOut = "optOut"
+
+	ResponseRequiredAlways = "always"
+	ResponseRequiredNever  = "never"
+)
+
+// RequestEvent asks for all events of the VEN.
+type RequestEvent struct {
+	SchemaVersion  string         `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiRequestEvent EiRequestEvent `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads eiRequestEvent"`
+}
+
+type EiRequestEvent struct {
+	RequestID  string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	VenID      string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+	ReplyLimit int    `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads replyLimit,omitempty"`
+}
+
+// DistributeEvent carries the complete set of events for a VEN, events the
+// VEN knows that are missing have been removed.
+type DistributeEvent struct {
+	SchemaVersion string      `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiResponse    *EiResponse `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse,omitempty"`
+	RequestID     string      `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	VtnID         string      `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 vtnID"`
+	Events        []OadrEvent `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrEvent"`
+}
+
+type OadrEvent struct {
+	EiEvent          EiEvent `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiEvent"`
+	ResponseRequired string  `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrResponseRequired"`
+}
+
+type EiEvent struct {
+	Descriptor   EventDescriptor `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eventDescriptor"`
+	ActivePeriod ActivePeriod    `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiActivePeriod"`
+	Signals      []EventSignal   `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiEventSignals>eiEventSignal"`
+	Target       Target          `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiTarget"`
+}
+
+type EventDescriptor struct {
+	EventID            string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eventID"`
+	ModificationNumber int           `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 modificationNumber"`
+	Priority           int           `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 priority,omitempty"`
+	MarketContext      MarketContext `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiMarketContext"`
+	CreatedDateTime    time.Time     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 createdDateTime"`
+	EventStatus        string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eventStatus"`
+	TestEvent          string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 testEvent,omitempty"`
+}
+
+type MarketContext struct {
+	Value string `xml:"http://docs.oasis-open.org/ns/emix/2011/06 marketContext"`
+}
+
+type ActivePeriod struct {
+	Properties Properties `xml:"urn:ietf:params:xml:ns:icalendar-2.0 properties"`
+}
+
+// Start and End of the active period.
+func (a ActivePeriod) Start() time.Time {
+	return a.Properties.DtStart.Value
+}
+
+func (a ActivePeriod) End() time.Time {
+	return a.Start().Add(time.Duration(a.Properties.Duration.Value))
+}
+
+type EventSignal struct {
+	Intervals    Intervals     `xml:"urn:ietf:params:xml:ns:icalendar-2.0:stream intervals"`
+	SignalName   string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 signalName"`
+	SignalType   string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 signalType"`
+	SignalID     string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 signalID"`
+	CurrentValue *CurrentValue `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 currentValue,omitempty"`
+}
+
+type Intervals struct {
+	Intervals []Interval `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 interval"`
+}
+
+// Interval is one step of a signal, or of a report when DtStart is set.
+// Signal intervals follow each other from the start of the event.
+type Interval struct {
+	DtStart        *DateTime       `xml:"urn:ietf:params:xml:ns:icalendar-2.0 dtstart,omitempty"`
+	Duration       DurationValue   `xml:"urn:ietf:params:xml:ns:icalendar-2.0 duration"`
+	UID            *UID            `xml:"urn:ietf:params:xml:ns:icalendar-2.0 uid,omitempty"`
+	SignalPayload  *SignalPayload  `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 signalPayload,omitempty"`
+	ReportPayloads []ReportPayload `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrReportPayload"`
+}
+
+type UID struct {
+	Text string `xml:"urn:ietf:params:xml:ns:icalendar-2.0 text"`
+}
+
+type SignalPayload struct {
+	PayloadFloat PayloadFloat `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 payloadFloat"`
+}
+
+type CurrentValue struct {
+	PayloadFloat PayloadFloat `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 payloadFloat"`
+}
+
+type Target struct {
+	VenIDs []string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
+
+// CreatedEvent is the VEN's opt in or out of the events it received.
+type CreatedEvent struct {
+	SchemaVersion  string         `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiCreatedEvent EiCreatedEvent `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads eiCreatedEvent"`
+}
+
+type EiCreatedEvent struct {
+	EiResponse     EiResponse     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse"`
+	EventResponses EventResponses `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eventResponses"`
+	VenID          string         `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
+
+// EventResponses is a separate element since its parent is in another
+// namespace.
+type EventResponses struct {
+	Responses []EventResponse `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eventResponse"`
+}
+
+type EventResponse struct {
+	ResponseCode        int              `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 responseCode"`
+	ResponseDescription string           `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 responseDescription,omitempty"`
+	RequestID           string           `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	QualifiedEventID    QualifiedEventID `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 qualifiedEventID"`
+	OptType             string           `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 optType"`
+}
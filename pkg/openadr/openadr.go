@@ -0,0 +1,208 @@
+// Package openadr contains the OpenADR 2.0b payloads exchanged between a
+// Virtual Top Node (VTN) and Virtual End Nodes (VENs) over the simple HTTP
+// transport. Every message is wrapped in an oadrPayload and posted to the
+// endpoint of its service:
+//
+//	/OpenADR2/Simple/2.0b/EiRegisterParty
+//	/OpenADR2/Simple/2.0b/EiEvent
+//	/OpenADR2/Simple/2.0b/EiReport
+//	/OpenADR2/Simple/2.0b/EiOpt
+//	/OpenADR2/Simple/2.0b/OadrPoll
+//
+// Elements are matched by namespace, so documents may use any prefixes.
+package openadr
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	NamespaceOadr  = "http://openadr.org/oadr-2.0b/2012/07"
+	NamespaceEi    = "http://docs.oasis-open.org/ns/energyinterop/201110"
+	NamespacePyld  = "http://docs.oasis-open.org/ns/energyinterop/201110/payloads"
+	NamespaceEmix  = "http://docs.oasis-open.org/ns/emix/2011/06"
+	NamespaceXcal  = "urn:ietf:params:xml:ns:icalendar-2.0"
+	NamespaceStrm  = "urn:ietf:params:xml:ns:icalendar-2.0:stream"
+	SchemaVersion  = "2.0b"
+	ProfileName    = "2.0b"
+	TransportHTTP  = "simpleHttp"
+	BasePath       = "/OpenADR2/Simple/2.0b"
+	ContentType    = "application/xml"
+	maxPayloadSize = 1 << 20
+)
+
+// Services are the endpoints below BasePath.
+const (
+	ServiceRegisterParty = "EiRegisterParty"
+	ServiceEvent         = "EiEvent"
+	ServiceReport        = "EiReport"
+	ServiceOpt           = "EiOpt"
+	ServicePoll          = "OadrPoll"
+)
+
+// Response codes follow HTTP for success, the 45x range is defined by
+// OpenADR for errors.
+const (
+	CodeOK            = 200
+	CodeNotAllowed    = 451
+	CodeInvalidID     = 452
+	CodeNotRecognized = 453
+	CodeInvalidData   = 454
+	CodeNotRegistered = 461
+)
+
+var ErrEmptyPayload = errors.New("openadr: payload contains no message")
+
+// Payload is the envelope of every message, exactly one field of the signed
+// object is set.
+type Payload struct {
+	XMLName      xml.Name     `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrPayload"`
+	SignedObject SignedObject `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrSignedObject"`
+}
+
+type SignedObject struct {
+	QueryRegistration         *QueryRegistration         `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrQueryRegistration,omitempty"`
+	CreatePartyRegistration   *CreatePartyRegistration   `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCreatePartyRegistration,omitempty"`
+	CreatedPartyRegistration  *CreatedPartyRegistration  `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCreatedPartyRegistration,omitempty"`
+	CancelPartyRegistration   *CancelPartyRegistration   `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCancelPartyRegistration,omitempty"`
+	CanceledPartyRegistration *CanceledPartyRegistration `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCanceledPartyRegistration,omitempty"`
+	RequestEvent              *RequestEvent              `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrRequestEvent,omitempty"`
+	DistributeEvent           *DistributeEvent           `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrDistributeEvent,omitempty"`
+	CreatedEvent              *CreatedEvent              `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCreatedEvent,omitempty"`
+	RegisterReport            *RegisterReport            `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrRegisterReport,omitempty"`
+	RegisteredReport          *RegisteredReport          `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrRegisteredReport,omitempty"`
+	UpdateReport              *UpdateReport              `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrUpdateReport,omitempty"`
+	UpdatedReport             *UpdatedReport             `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrUpdatedReport,omitempty"`
+	CreateOpt                 *CreateOpt                 `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCreateOpt,omitempty"`
+	CreatedOpt                *CreatedOpt                `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCreatedOpt,omitempty"`
+	CancelOpt                 *CancelOpt                 `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCancelOpt,omitempty"`
+	CanceledOpt               *CanceledOpt               `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrCanceledOpt,omitempty"`
+	Poll                      *Poll                      `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrPoll,omitempty"`
+	Response                  *Response                  `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrResponse,omitempty"`
+}
+
+// Decode reads a payload, rejecting documents without a message.
+func Decode(r io.Reader) (*Payload, error) {
+	var p Payload
+	if err := xml.NewDecoder(io.LimitReader(r, maxPayloadSize)).Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.SignedObject == (SignedObject{}) {
+		return nil, ErrEmptyPayload
+	}
+
+	return &p, nil
+}
+
+// Encode writes the payload with an XML declaration.
+func Encode(w io.Writer, p *Payload) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(p)
+}
+
+// Wrap puts a message into a payload, m must be a pointer to one of the
+// message types of SignedObject.
+func Wrap(m any) *Payload {
+	p := &Payload{}
+	o := &p.SignedObject
+	switch m := m.(type) {
+	case *QueryRegistration:
+		o.QueryRegistration = m
+	case *CreatePartyRegistration:
+		o.CreatePartyRegistration = m
+	case *CreatedPartyRegistration:
+		o.CreatedPartyRegistration = m
+	case *CancelPartyRegistration:
+		o.CancelPartyRegistration = m
+	case *CanceledPartyRegistration:
+		o.CanceledPartyRegistration = m
+	case *RequestEvent:
+		o.RequestEvent = m
+	case *DistributeEvent:
+		o.DistributeEvent = m
+	case *CreatedEvent:
+		o.CreatedEvent = m
+	case *RegisterReport:
+		o.RegisterReport = m
+	case *RegisteredReport:
+		o.RegisteredReport = m
+	case *UpdateReport:
+		o.UpdateReport = m
+	case *UpdatedReport:
+		o.UpdatedReport = m
+	case *CreateOpt:
+		o.CreateOpt = m
+	case *CreatedOpt:
+		o.CreatedOpt = m
+	case *CancelOpt:
+		o.CancelOpt = m
+	case *CanceledOpt:
+		o.CanceledOpt = m
+	case *Poll:
+		o.Poll = m
+	case *Response:
+		o.Response = m
+	}
+
+	return p
+}
+
+// EiResponse reports the outcome of a request.
+type EiResponse struct {
+	ResponseCode        int    `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 responseCode"`
+	ResponseDescription string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 responseDescription,omitempty"`
+	RequestID           string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+}
+
+func OK(requestID string) EiResponse {
+	return EiResponse{ResponseCode: CodeOK, ResponseDescription: "OK", RequestID: requestID}
+}
+
+func Failed(code int, requestID, description string) EiResponse {
+	return EiResponse{ResponseCode: code, ResponseDescription: description, RequestID: requestID}
+}
+
+// Response is the generic answer to messages without a dedicated one.
+type Response struct {
+	SchemaVersion string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiResponse    EiResponse `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse"`
+	VenID         string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID,omitempty"`
+}
+
+// Poll asks the VTN for pending messages, it answers with the message or
+// with a Response when there is nothing to deliver.
+type Poll struct {
+	SchemaVersion string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	VenID         string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
+
+// DateTime is an xcal date-time element.
+type DateTime struct {
+	Value time.Time `xml:"urn:ietf:params:xml:ns:icalendar-2.0 date-time"`
+}
+
+// DurationValue is an xcal duration element such as PT1H.
+type DurationValue struct {
+	Value Duration `xml:"urn:ietf:params:xml:ns:icalendar-2.0 duration"`
+}
+
+// Properties describes a period by its start and duration.
+type Properties struct {
+	DtStart  DateTime      `xml:"urn:ietf:params:xml:ns:icalendar-2.0 dtstart"`
+	Duration DurationValue `xml:"urn:ietf:params:xml:ns:icalendar-2.0 duration"`
+}
+
+type PayloadFloat struct {
+	Value float64 `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 value"`
+}
+
+type QualifiedEventID struct {
+	EventID            string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eventID"`
+	ModificationNumber int    `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 modificationNumber"`
+}
@@ -0,0 +1,252 @@
+package openadr
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "PT15M", want: 15 * time.Minute},
+		{in: "PT1H30M", want: 90 * time.Minute},
+		{in: "P1D", want: 24 * time.Hour},
+		{in: "P1W", want: 7 * 24 * time.Hour},
+		{in: "P1DT2H", want: 26 * time.Hour},
+		{in: "PT0.5S", want: 500 * time.Millisecond},
+		{in: "-PT5M", want: -5 * time.Minute},
+		{in: "+PT10S", want: 10 * time.Second},
+		{in: " PT1H ", want: time.Hour},
+		{in: "", wantErr: true},
+		{in: "P", wantErr: true},
+		{in: "PT", wantErr: true},
+		{in: "P1DT", wantErr: true},
+		{in: "P1Y", wantErr: true},
+		{in: "P1M", wantErr: true},
+		{in: "15M", wantErr: true},
+		{in: "PT1.5H", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDuration(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDuration(%q) = %v, want an error", tt.in, time.Duration(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuration(%q): %v", tt.in, err)
+			}
+			if time.Duration(got) != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, time.Duration(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 0, want: "PT0S"},
+		{in: 15 * time.Minute, want: "PT15M"},
+		{in: 26 * time.Hour, want: "PT26H"},
+		{in: time.Hour + 30*time.Second, want: "PT1H30S"},
+		{in: 1500 * time.Millisecond, want: "PT1.5S"},
+		{in: -5 * time.Minute, want: "-PT5M"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			d := Duration(tt.in)
+			if got := d.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+
+			text, err := d.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText: %v", err)
+			}
+			var back Duration
+			if err := back.UnmarshalText(text); err != nil || back != d {
+				t.Errorf("UnmarshalText(%q) = %v, %v, want %v", text, time.Duration(back), err, tt.in)
+			}
+		})
+	}
+}
+
+// distributeEvent uses other prefixes than Encode to check that elements are
+// matched by namespace.
+const distributeEvent = `<?xml version="1.0" encoding="UTF-8"?>
+<p1:oadrPayload xmlns:p1="http://openadr.org/oadr-2.0b/2012/07"
+    xmlns:ei="http://docs.oasis-open.org/ns/energyinterop/201110"
+    xmlns:pyld="http://docs.oasis-open.org/ns/energyinterop/201110/payloads"
+    xmlns:emix="http://docs.oasis-open.org/ns/emix/2011/06"
+    xmlns:cal="urn:ietf:params:xml:ns:icalendar-2.0"
+    xmlns:strm="urn:ietf:params:xml:ns:icalendar-2.0:stream">
+  <p1:oadrSignedObject>
+    <p1:oadrDistributeEvent ei:schemaVersion="2.0b">
+      <pyld:requestID>req-1</pyld:requestID>
+      <ei:vtnID>vtn-1</ei:vtnID>
+      <p1:oadrEvent>
+        <ei:eiEvent>
+          <ei:eventDescriptor>
+            <ei:eventID>event-1</ei:eventID>
+            <ei:modificationNumber>2</ei:modificationNumber>
+            <ei:eiMarketContext><emix:marketContext>http://market</emix:marketContext></ei:eiMarketContext>
+            <ei:createdDateTime>2025-03-01T08:00:00Z</ei:createdDateTime>
+            <ei:eventStatus>far</ei:eventStatus>
+          </ei:eventDescriptor>
+          <ei:eiActivePeriod>
+            <cal:properties>
+              <cal:dtstart><cal:date-time>2025-03-01T12:00:00Z</cal:date-time></cal:dtstart>
+              <cal:duration><cal:duration>PT2H</cal:duration></cal:duration>
+            </cal:properties>
+          </ei:eiActivePeriod>
+          <ei:eiEventSignals>
+            <ei:eiEventSignal>
+              <strm:intervals>
+                <ei:interval>
+                  <cal:duration><cal:duration>PT1H</cal:duration></cal:duration>
+                  <cal:uid><cal:text>0</cal:text></cal:uid>
+                  <ei:signalPayload><ei:payloadFloat><ei:value>1</ei:value></ei:payloadFloat></ei:signalPayload>
+                </ei:interval>
+                <ei:interval>
+                  <cal:duration><cal:duration>PT1H</cal:duration></cal:duration>
+                  <cal:uid><cal:text>1</cal:text></cal:uid>
+                  <ei:signalPayload><ei:payloadFloat><ei:value>3</ei:value></ei:payloadFloat></ei:signalPayload>
+                </ei:interval>
+              </strm:intervals>
+              <ei:signalName>SIMPLE</ei:signalName>
+              <ei:signalType>level</ei:signalType>
+              <ei:signalID>0</ei:signalID>
+            </ei:eiEventSignal>
+          </ei:eiEventSignals>
+          <ei:eiTarget><ei:venID>ven-1</ei:venID></ei:eiTarget>
+        </ei:eiEvent>
+        <p1:oadrResponseRequired>always</p1:oadrResponseRequired>
+      </p1:oadrEvent>
+    </p1:oadrDistributeEvent>
+  </p1:oadrSignedObject>
+</p1:oadrPayload>`
+
+func TestDecodeDistributeEvent(t *testing.T) {
+	p, err := Decode(strings.NewReader(distributeEvent))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	d := p.SignedObject.DistributeEvent
+	if d == nil || d.RequestID != "req-1" || d.VtnID != "vtn-1" || len(d.Events) != 1 {
+		t.Fatalf("decoded %+v, want one event from vtn-1", d)
+	}
+
+	e := d.Events[0]
+	if e.EiEvent.Descriptor.EventID != "event-1" || e.EiEvent.Descriptor.ModificationNumber != 2 || e.ResponseRequired != ResponseRequiredAlways {
+		t.Errorf("event = %+v, want event-1 at modification 2 requiring a response", e)
+	}
+
+	period := e.EiEvent.ActivePeriod
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if !period.Start().Equal(start) || !period.End().Equal(start.Add(2*time.Hour)) {
+		t.Errorf("active period = %s to %s, want 12:00 to 14:00", period.Start(), period.End())
+	}
+
+	signals := e.EiEvent.Signals
+	if len(signals) != 1 || signals[0].SignalName != SignalSimple || len(signals[0].Intervals.Intervals) != 2 {
+		t.Fatalf("signals = %+v, want one SIMPLE signal with two intervals", signals)
+	}
+	for i, want := range []float64{1, 3} {
+		in := signals[0].Intervals.Intervals[i]
+		if in.SignalPayload == nil || in.SignalPayload.PayloadFloat.Value != want || time.Duration(in.Duration.Value) != time.Hour {
+			t.Errorf("interval %d = %+v, want level %v for an hour", i, in, want)
+		}
+	}
+	if got := e.EiEvent.Target.VenIDs; !reflect.DeepEqual(got, []string{"ven-1"}) {
+		t.Errorf("target = %v, want ven-1", got)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want error
+	}{
+		{
+			name: "no message",
+			xml:  `<oadrPayload xmlns="http://openadr.org/oadr-2.0b/2012/07"><oadrSignedObject/></oadrPayload>`,
+			want: ErrEmptyPayload,
+		},
+		{
+			name: "wrong namespace",
+			xml:  `<oadrPayload xmlns="urn:other"><oadrSignedObject/></oadrPayload>`,
+		},
+		{
+			name: "not XML",
+			xml:  `{"oadrPayload": {}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.xml))
+			if err == nil {
+				t.Fatal("Decode succeeded, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Decode error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	messages := []any{
+		&Poll{SchemaVersion: SchemaVersion, VenID: "ven-1"},
+		&Response{SchemaVersion: SchemaVersion, EiResponse: OK("req-1"), VenID: "ven-1"},
+		&CreatedEvent{
+			SchemaVersion: SchemaVersion,
+			EiCreatedEvent: EiCreatedEvent{
+				EiResponse: OK("req-2"),
+				EventResponses: EventResponses{Responses: []EventResponse{{
+					ResponseCode:     CodeOK,
+					RequestID:        "req-2",
+					QualifiedEventID: QualifiedEventID{EventID: "event-1", ModificationNumber: 2},
+					OptType:          OptIn,
+				}}},
+				VenID: "ven-1",
+			},
+		},
+	}
+
+	for _, m := range messages {
+		t.Run(reflect.TypeOf(m).Elem().Name(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, Wrap(m)); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if !strings.HasPrefix(buf.String(), "<?xml") {
+				t.Errorf("encoded payload has no XML declaration: %s", buf.String())
+			}
+
+			p, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if got := Wrap(m); !reflect.DeepEqual(p.SignedObject, got.SignedObject) {
+				t.Errorf("decoded %+v, want %+v", p.SignedObject, got.SignedObject)
+			}
+		})
+	}
+}
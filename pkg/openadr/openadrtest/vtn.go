@@ -0,0 +1,316 @@
+// Package openadrtest provides an in-memory VTN to exercise VEN clients
+// against, in the spirit of net/http/httptest:
+//
+//	vtn := openadrtest.NewVTN("test-vtn")
+//	srv := httptest.NewServer(vtn)
+//	defer srv.Close()
+//	client := openadr.NewClient(srv.Client(), srv.URL, "")
+//	vtn.SetEvents(openadrtest.Event("e1", openadr.SignalSimple, start, time.Hour, 1))
+//
+// It registers a single VEN, delivers the configured events on the next
+// poll, requests every telemetry report offered and records what the VEN
+// sends back.
+package openadrtest
+
+import (
+	"bytes"
+	"github.com/V2G-Minor-Fontys/server/pkg/openadr"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VTN is an http.Handler serving the OpenADR service endpoints. The exported
+// fields may be changed before it is first used.
+type VTN struct {
+	ID    string
+	VenID string
+	// Token is required as bearer token when set.
+	Token              string
+	PollFreq           time.Duration
+	Granularity        time.Duration
+	ReportBackDuration time.Duration
+
+	mu             sync.Mutex
+	registrationID string
+	registrations  int
+	events         []openadr.OadrEvent
+	pending        bool
+	responses      []openadr.EventResponse
+	reportRequests []openadr.ReportRequest
+	reports        []openadr.Report
+	opts           []openadr.CreateOpt
+}
+
+func NewVTN(id string) *VTN {
+	return &VTN{
+		ID:                 id,
+		VenID:              "ven-1",
+		PollFreq:           10 * time.Second,
+		Granularity:        time.Minute,
+		ReportBackDuration: 5 * time.Minute,
+	}
+}
+
+// Event builds an event with a single signal whose intervals of length step
+// follow each other from start.
+func Event(id, signalName string, start time.Time, step time.Duration, values ...float64) openadr.OadrEvent {
+	signal := openadr.EventSignal{SignalName: signalName, SignalType: signalType(signalName), SignalID: "0"}
+	for i, v := range values {
+		signal.Intervals.Intervals = append(signal.Intervals.Intervals, openadr.Interval{
+			Duration:      openadr.DurationValue{Value: openadr.Duration(step)},
+			UID:           &openadr.UID{Text: strconv.Itoa(i)},
+			SignalPayload: &openadr.SignalPayload{PayloadFloat: openadr.PayloadFloat{Value: v}},
+		})
+	}
+
+	return openadr.OadrEvent{
+		EiEvent: openadr.EiEvent{
+			Descriptor: openadr.EventDescriptor{
+				EventID:         id,
+				MarketContext:   openadr.MarketContext{Value: "http://example.com/market"},
+				CreatedDateTime: start.UTC(),
+				EventStatus:     openadr.StatusFar,
+				TestEvent:       "false",
+			},
+			ActivePeriod: openadr.ActivePeriod{Properties: openadr.Properties{
+				DtStart:  openadr.DateTime{Value: start.UTC()},
+				Duration: openadr.DurationValue{Value: openadr.Duration(step * time.Duration(len(values)))},
+			}},
+			Signals: []openadr.EventSignal{signal},
+		},
+		ResponseRequired: openadr.ResponseRequiredAlways,
+	}
+}
+
+func signalType(name string) string {
+	switch name {
+	case openadr.SignalElectricityPrice:
+		return openadr.SignalTypePrice
+	case openadr.SignalLoadDispatch:
+		return openadr.SignalTypeSetpoint
+	}
+
+	return openadr.SignalTypeLevel
+}
+
+// SetEvents replaces the events of the VEN, they are delivered on its next
+// poll.
+func (v *VTN) SetEvents(events ...openadr.OadrEvent) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.events = events
+	v.pending = true
+}
+
+// Deregister forgets the registration, as if it was cancelled on the VTN.
+func (v *VTN) Deregister() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.registrationID = ""
+}
+
+// Registered reports whether the VEN holds a registration.
+func (v *VTN) Registered() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.registrationID != ""
+}
+
+// Registrations counts the successful registrations.
+func (v *VTN) Registrations() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.registrations
+}
+
+// EventResponses are the opts of every oadrCreatedEvent received.
+func (v *VTN) EventResponses() []openadr.EventResponse {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return append([]openadr.EventResponse(nil), v.responses...)
+}
+
+// ReportRequests are the reports requested in answer to oadrRegisterReport.
+func (v *VTN) ReportRequests() []openadr.ReportRequest {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return append([]openadr.ReportRequest(nil), v.reportRequests...)
+}
+
+// Reports are the reports of every oadrUpdateReport received.
+func (v *VTN) Reports() []openadr.Report {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return append([]openadr.Report(nil), v.reports...)
+}
+
+// Opts are the oadrCreateOpt messages received.
+func (v *VTN) Opts() []openadr.CreateOpt {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return append([]openadr.CreateOpt(nil), v.opts...)
+}
+
+func (v *VTN) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service, ok := strings.CutPrefix(r.URL.Path, openadr.BasePath+"/")
+	if !ok || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if v.Token != "" && r.Header.Get("Authorization") != "Bearer "+v.Token {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	p, err := openadr.Decode(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v.mu.Lock()
+	res := v.handle(service, &p.SignedObject)
+	v.mu.Unlock()
+
+	var body bytes.Buffer
+	if err := openadr.Encode(&body, openadr.Wrap(res)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", openadr.ContentType)
+	_, _ = w.Write(body.Bytes())
+}
+
+// handle answers a message, v.mu is held.
+func (v *VTN) handle(service string, o *openadr.SignedObject) any {
+	switch {
+	case service == openadr.ServiceRegisterParty && o.QueryRegistration != nil:
+		return v.createdPartyRegistration(openadr.OK(o.QueryRegistration.RequestID), "", "")
+	case service == openadr.ServiceRegisterParty && o.CreatePartyRegistration != nil:
+		m := o.CreatePartyRegistration
+		if m.RegistrationID != "" && m.RegistrationID != v.registrationID {
+			return v.createdPartyRegistration(openadr.Failed(openadr.CodeInvalidID, m.RequestID, "Registration ID does not match"), "", "")
+		}
+		if v.registrationID == "" {
+			v.registrations++
+			v.registrationID = "reg-" + strconv.Itoa(v.registrations)
+			v.pending = true
+		}
+		return v.createdPartyRegistration(openadr.OK(m.RequestID), v.registrationID, v.VenID)
+	case service == openadr.ServiceRegisterParty && o.CancelPartyRegistration != nil:
+		m := o.CancelPartyRegistration
+		v.registrationID = ""
+		return &openadr.CanceledPartyRegistration{
+			SchemaVersion:  openadr.SchemaVersion,
+			EiResponse:     openadr.OK(m.RequestID),
+			RegistrationID: m.RegistrationID,
+			VenID:          v.VenID,
+		}
+	}
+
+	if v.registrationID == "" {
+		return v.response(openadr.Failed(openadr.CodeNotRegistered, "", "VEN is not registered"))
+	}
+
+	switch {
+	case service == openadr.ServicePoll && o.Poll != nil:
+		if v.pending {
+			return v.distributeEvent("")
+		}
+		return v.response(openadr.OK(""))
+	case service == openadr.ServiceEvent && o.RequestEvent != nil:
+		return v.distributeEvent(o.RequestEvent.EiRequestEvent.RequestID)
+	case service == openadr.ServiceEvent && o.CreatedEvent != nil:
+		v.responses = append(v.responses, o.CreatedEvent.EiCreatedEvent.EventResponses.Responses...)
+		return v.response(openadr.OK(o.CreatedEvent.EiCreatedEvent.EiResponse.RequestID))
+	case service == openadr.ServiceReport && o.RegisterReport != nil:
+		return v.registeredReport(o.RegisterReport)
+	case service == openadr.ServiceReport && o.UpdateReport != nil:
+		v.reports = append(v.reports, o.UpdateReport.Reports...)
+		return &openadr.UpdatedReport{SchemaVersion: openadr.SchemaVersion, EiResponse: openadr.OK(o.UpdateReport.RequestID), VenID: v.VenID}
+	case service == openadr.ServiceOpt && o.CreateOpt != nil:
+		v.opts = append(v.opts, *o.CreateOpt)
+		return &openadr.CreatedOpt{SchemaVersion: openadr.SchemaVersion, EiResponse: openadr.OK(o.CreateOpt.RequestID), OptID: o.CreateOpt.OptID}
+	}
+
+	return v.response(openadr.Failed(openadr.CodeNotRecognized, "", "Message is not supported by "+service))
+}
+
+func (v *VTN) createdPartyRegistration(res openadr.EiResponse, registrationID, venID string) *openadr.CreatedPartyRegistration {
+	return &openadr.CreatedPartyRegistration{
+		SchemaVersion:  openadr.SchemaVersion,
+		EiResponse:     res,
+		RegistrationID: registrationID,
+		VenID:          venID,
+		VtnID:          v.ID,
+		Profiles: []openadr.Profile{{
+			Name:       openadr.ProfileName,
+			Transports: []openadr.Transport{{Name: openadr.TransportHTTP}},
+		}},
+		PollFreq: openadr.DurationValue{Value: openadr.Duration(v.PollFreq)},
+	}
+}
+
+func (v *VTN) response(res openadr.EiResponse) *openadr.Response {
+	return &openadr.Response{SchemaVersion: openadr.SchemaVersion, EiResponse: res, VenID: v.VenID}
+}
+
+func (v *VTN) distributeEvent(requestID string) *openadr.DistributeEvent {
+	v.pending = false
+	events := make([]openadr.OadrEvent, len(v.events))
+	for i, e := range v.events {
+		e.EiEvent.Target = openadr.Target{VenIDs: []string{v.VenID}}
+		events[i] = e
+	}
+
+	res := openadr.OK(requestID)
+	return &openadr.DistributeEvent{
+		SchemaVersion: openadr.SchemaVersion,
+		EiResponse:    &res,
+		RequestID:     requestID,
+		VtnID:         v.ID,
+		Events:        events,
+	}
+}
+
+func (v *VTN) registeredReport(m *openadr.RegisterReport) *openadr.RegisteredReport {
+	res := &openadr.RegisteredReport{
+		SchemaVersion: openadr.SchemaVersion,
+		EiResponse:    openadr.OK(m.RequestID),
+		VenID:         v.VenID,
+	}
+
+	for i, r := range m.Reports {
+		if !strings.HasPrefix(r.ReportName, "METADATA_") {
+			continue
+		}
+
+		req := openadr.ReportRequest{
+			ReportRequestID: "req-" + strconv.Itoa(len(v.reportRequests)+i+1),
+			Specifier: openadr.ReportSpecifier{
+				ReportSpecifierID:  r.ReportSpecifierID,
+				Granularity:        openadr.DurationValue{Value: openadr.Duration(v.Granularity)},
+				ReportBackDuration: openadr.DurationValue{Value: openadr.Duration(v.ReportBackDuration)},
+			},
+		}
+		for _, d := range r.Descriptions {
+			req.Specifier.Payloads = append(req.Specifier.Payloads, openadr.SpecifierPayload{RID: d.RID, ReadingType: d.ReadingType})
+		}
+		res.ReportRequests = append(res.ReportRequests, req)
+	}
+	v.reportRequests = append(v.reportRequests, res.ReportRequests...)
+
+	return res
+}
@@ -0,0 +1,57 @@
+package openadr
+
+import "time"
+
+// Opt reasons a VEN may give.
+const (
+	OptReasonEconomic         = "economic"
+	OptReasonEmergency        = "emergency"
+	OptReasonMustRun          = "mustRun"
+	OptReasonNotParticipating = "notParticipating"
+	OptReasonOutageRunStatus  = "outageRunStatus"
+	OptReasonOverrideStatus   = "overrideStatus"
+	OptReasonParticipating    = "participating"
+	OptReasonSchedule         = "x-schedule"
+)
+
+// CreateOpt opts the VEN in or out of a single event, when QualifiedEventID
+// is set, or of every event during the periods of Availability.
+type CreateOpt struct {
+	SchemaVersion    string            `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	OptID            string            `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 optID"`
+	OptType          string            `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 optType"`
+	OptReason        string            `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 optReason"`
+	MarketContext    string            `xml:"http://docs.oasis-open.org/ns/emix/2011/06 marketContext,omitempty"`
+	VenID            string            `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+	Availability     *Availability     `xml:"urn:ietf:params:xml:ns:icalendar-2.0 vavailability,omitempty"`
+	CreatedDateTime  time.Time         `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 createdDateTime"`
+	RequestID        string            `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	QualifiedEventID *QualifiedEventID `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 qualifiedEventID,omitempty"`
+}
+
+type Availability struct {
+	Components []Available `xml:"urn:ietf:params:xml:ns:icalendar-2.0 components>available"`
+}
+
+type Available struct {
+	Properties Properties `xml:"urn:ietf:params:xml:ns:icalendar-2.0 properties"`
+}
+
+type CreatedOpt struct {
+	SchemaVersion string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiResponse    EiResponse `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse"`
+	OptID         string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 optID"`
+}
+
+type CancelOpt struct {
+	SchemaVersion string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	RequestID     string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	OptID         string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 optID"`
+	VenID         string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
+
+type CanceledOpt struct {
+	SchemaVersion string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiResponse    EiResponse `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse"`
+	OptID         string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 optID"`
+}
@@ -0,0 +1,59 @@
+package openadr
+
+// QueryRegistration asks the VTN which profiles and transports it supports
+// before registering.
+type QueryRegistration struct {
+	SchemaVersion string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	RequestID     string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+}
+
+// CreatePartyRegistration registers a VEN, or re-registers it when the
+// registration and VEN IDs of an earlier registration are given.
+type CreatePartyRegistration struct {
+	SchemaVersion    string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	RequestID        string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	RegistrationID   string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 registrationID,omitempty"`
+	VenID            string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID,omitempty"`
+	ProfileName      string `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrProfileName"`
+	TransportName    string `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrTransportName"`
+	TransportAddress string `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrTransportAddress,omitempty"`
+	ReportOnly       bool   `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrReportOnly"`
+	XMLSignature     bool   `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrXmlSignature"`
+	VenName          string `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrVenName,omitempty"`
+	HTTPPullModel    bool   `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrHttpPullModel"`
+}
+
+type Transport struct {
+	Name string `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrTransportName"`
+}
+
+type Profile struct {
+	Name       string      `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrProfileName"`
+	Transports []Transport `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrTransports>oadrTransport"`
+}
+
+// CreatedPartyRegistration answers both the query and the registration, the
+// IDs are empty in the answer to a query.
+type CreatedPartyRegistration struct {
+	SchemaVersion  string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiResponse     EiResponse    `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse"`
+	RegistrationID string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 registrationID,omitempty"`
+	VenID          string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID,omitempty"`
+	VtnID          string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 vtnID"`
+	Profiles       []Profile     `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrProfiles>oadrProfile"`
+	PollFreq       DurationValue `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrRequestedOadrPollFreq"`
+}
+
+type CancelPartyRegistration struct {
+	SchemaVersion  string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	RequestID      string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	RegistrationID string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 registrationID"`
+	VenID          string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
+
+type CanceledPartyRegistration struct {
+	SchemaVersion  string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiResponse     EiResponse `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse"`
+	RegistrationID string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 registrationID"`
+	VenID          string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
@@ -0,0 +1,102 @@
+package openadr
+
+import "time"
+
+// Report names and types of the telemetry the VTN understands. A VEN
+// registers the reports it can deliver with their METADATA_ name, the VTN
+// requests the ones it wants and the VEN sends them periodically.
+const (
+	ReportMetadataTelemetryUsage  = "METADATA_TELEMETRY_USAGE"
+	ReportMetadataTelemetryStatus = "METADATA_TELEMETRY_STATUS"
+	ReportTelemetryUsage          = "TELEMETRY_USAGE"
+	ReportTelemetryStatus         = "TELEMETRY_STATUS"
+	ReportTypeUsage               = "usage"
+	ReportTypeStorage             = "storedEnergy"
+	ReadingTypeDirectRead         = "Direct Read"
+)
+
+// RegisterReport announces the reports a VEN can deliver.
+type RegisterReport struct {
+	SchemaVersion string   `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	RequestID     string   `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	Reports       []Report `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrReport"`
+	VenID         string   `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
+
+// RegisteredReport acknowledges the registration and requests the reports
+// the VTN wants to receive.
+type RegisteredReport struct {
+	SchemaVersion  string          `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiResponse     EiResponse      `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse"`
+	ReportRequests []ReportRequest `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrReportRequest"`
+	VenID          string          `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
+
+type ReportRequest struct {
+	ReportRequestID string          `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportRequestID"`
+	Specifier       ReportSpecifier `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportSpecifier"`
+}
+
+// ReportSpecifier asks for the data points in Payloads every Granularity,
+// sent in batches of ReportBackDuration.
+type ReportSpecifier struct {
+	ReportSpecifierID  string             `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportSpecifierID"`
+	Granularity        DurationValue      `xml:"urn:ietf:params:xml:ns:icalendar-2.0 granularity"`
+	ReportBackDuration DurationValue      `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportBackDuration"`
+	Interval           *ReportInterval    `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportInterval,omitempty"`
+	Payloads           []SpecifierPayload `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 specifierPayload"`
+}
+
+type ReportInterval struct {
+	Properties Properties `xml:"urn:ietf:params:xml:ns:icalendar-2.0 properties"`
+}
+
+type SpecifierPayload struct {
+	RID         string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 rID"`
+	ReadingType string `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 readingType"`
+}
+
+// Report is both the description of a report in RegisterReport and the
+// report itself in UpdateReport, where Intervals carries the values.
+type Report struct {
+	Intervals         *Intervals          `xml:"urn:ietf:params:xml:ns:icalendar-2.0:stream intervals,omitempty"`
+	Descriptions      []ReportDescription `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrReportDescription"`
+	ReportRequestID   string              `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportRequestID"`
+	ReportSpecifierID string              `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportSpecifierID"`
+	ReportName        string              `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportName"`
+	CreatedDateTime   time.Time           `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 createdDateTime"`
+}
+
+// ReportDescription describes one data point of a report.
+type ReportDescription struct {
+	RID          string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 rID"`
+	ReportType   string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 reportType"`
+	ReadingType  string        `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 readingType"`
+	SamplingRate *SamplingRate `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrSamplingRate,omitempty"`
+}
+
+type SamplingRate struct {
+	MinPeriod Duration `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrMinPeriod"`
+	MaxPeriod Duration `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrMaxPeriod"`
+	OnChange  bool     `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrOnChange"`
+}
+
+// ReportPayload is the value of one data point in a report interval.
+type ReportPayload struct {
+	RID          string       `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 rID"`
+	PayloadFloat PayloadFloat `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 payloadFloat"`
+}
+
+// UpdateReport delivers the values of requested reports.
+type UpdateReport struct {
+	SchemaVersion string   `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	RequestID     string   `xml:"http://docs.oasis-open.org/ns/energyinterop/201110/payloads requestID"`
+	Reports       []Report `xml:"http://openadr.org/oadr-2.0b/2012/07 oadrReport"`
+	VenID         string   `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
+
+type UpdatedReport struct {
+	SchemaVersion string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 schemaVersion,attr"`
+	EiResponse    EiResponse `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 eiResponse"`
+	VenID         string     `xml:"http://docs.oasis-open.org/ns/energyinterop/201110 venID"`
+}
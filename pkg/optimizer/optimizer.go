@@ -0,0 +1,406 @@
+// Package optimizer computes cost optimal charge and discharge schedules for a
+// single vehicle battery using dynamic programming over a discretised state of
+// charge. It has no dependencies on the rest of the server so it can be run
+// deterministically on recorded inputs.
+package optimizer
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	DefaultResolution = 15 * time.Minute
+	DefaultLevels     = 400
+	// CarbonPricePerKg values a kg of CO2 emitted when a plan weighs
+	// emissions, it puts grid carbon intensities on the scale of energy
+	// prices.
+	CarbonPricePerKg = 0.5
+	// shortfallPenalty is the cost per kWh missing at departure. It is large
+	// enough to dominate any price so the target is always preferred when it
+	// is physically reachable.
+	shortfallPenalty = 1000.0
+	epsilon          = 1e-9
+	bisections       = 30
+	// maxBudgetLevels is the largest discharge budget, counted in levels of
+	// state of charge, that is planned exactly by tracking the budget used in
+	// the state. Larger budgets are planned by Lagrangian relaxation, which
+	// may leave up to a slot of discharge unused.
+	maxBudgetLevels = 40
+)
+
+var (
+	ErrNoIntervals     = errors.New("optimizer: at least one interval is required")
+	ErrInvalidBattery  = errors.New("optimizer: battery parameters are invalid")
+	ErrInvalidInterval = errors.New("optimizer: intervals must be consecutive and evenly spaced")
+	ErrInvalidWeight   = errors.New("optimizer: carbon weight must be between 0 and 1")
+)
+
+// Interval is one planning slot with the price paid for energy taken from the
+// grid and the price received for energy fed back, both per kWh.
+type Interval struct {
+	Start       time.Time
+	ImportPrice float64
+	ExportPrice float64
+	// MaxImportKw and MaxExportKw optionally cap the grid power in this slot,
+	// zero means the battery limits apply.
+	MaxImportKw float64
+	MaxExportKw float64
+	// SurplusKw is local production that would otherwise be exported. The
+	// battery may charge it on top of MaxImportKw and it costs the export
+	// price that is forgone rather than the import price.
+	SurplusKw float64
+	// CarbonIntensity is the emission of grid energy in gCO2eq/kWh. Energy
+	// fed back displaces grid energy and avoids the same emission.
+	CarbonIntensity float64
+}
+
+type Battery struct {
+	CapacityKwh         float64
+	InitialSoc          float64
+	MinSoc              float64
+	TargetSoc           float64
+	MaxChargeKw         float64
+	MaxDischargeKw      float64
+	ChargeEfficiency    float64
+	DischargeEfficiency float64
+}
+
+type Problem struct {
+	Battery    Battery
+	Intervals  []Interval
+	Resolution time.Duration
+	// MaxDischargeKwh limits the energy delivered back to the grid over the
+	// horizon, negative means unlimited.
+	MaxDischargeKwh float64
+	// WearCostPerKwh is the battery wear cost of every kWh taken out of the
+	// battery, so discharging only pays when the price spread covers it.
+	WearCostPerKwh float64
+	// CarbonWeight trades cost off against emissions: 0 plans the cheapest
+	// schedule, 1 the one emitting the least and values in between minimise
+	// the weighted sum with emissions valued at CarbonPricePerKg.
+	CarbonWeight float64
+	// Levels is the number of discrete state of charge steps, defaults to
+	// DefaultLevels.
+	Levels int
+}
+
+type Setpoint struct {
+	Start    time.Time `json:"start"`
+	PowerKw  float64   `json:"powerKw"`
+	SocStart float64   `json:"socStart"`
+	SocEnd   float64   `json:"socEnd"`
+	Cost     float64   `json:"cost"`
+}
+
+type Schedule struct {
+	Setpoints     []Setpoint `json:"setpoints"`
+	TotalCost     float64    `json:"totalCost"`
+	FinalSoc      float64    `json:"finalSoc"`
+	TargetReached bool       `json:"targetReached"`
+	ChargedKwh    float64    `json:"chargedKwh"`
+	DischargedKwh float64    `json:"dischargedKwh"`
+	// WearCost is the battery wear of the discharges, it is not part of
+	// TotalCost.
+	WearCost float64 `json:"wearCost"`
+	// EmissionsKg is the CO2 of the energy drawn from the grid minus that
+	// avoided by the energy fed back.
+	EmissionsKg float64 `json:"emissionsKg"`
+}
+
+type solver struct {
+	p       Problem
+	step    float64
+	hours   float64
+	levels  int
+	kMin    int
+	kTarget int
+	kInit   int
+}
+
+// Optimize returns the schedule with the lowest total energy cost that reaches
+// the target state of charge at the end of the horizon and never discharges
+// below the minimum. When the target cannot be reached the schedule charges as
+// much as possible and TargetReached is false.
+func Optimize(p Problem) (*Schedule, error) {
+	if len(p.Intervals) == 0 {
+		return nil, ErrNoIntervals
+	}
+
+	if p.CarbonWeight < 0 || p.CarbonWeight > 1 {
+		return nil, ErrInvalidWeight
+	}
+
+	b := p.Battery
+	if b.CapacityKwh <= 0 || b.MaxChargeKw < 0 || b.MaxDischargeKw < 0 ||
+		b.ChargeEfficiency <= 0 || b.ChargeEfficiency > 1 ||
+		b.DischargeEfficiency <= 0 || b.DischargeEfficiency > 1 {
+		return nil, ErrInvalidBattery
+	}
+
+	if p.Resolution <= 0 {
+		p.Resolution = DefaultResolution
+	}
+
+	for i := 1; i < len(p.Intervals); i++ {
+		if p.Intervals[i].Start.Sub(p.Intervals[i-1].Start) != p.Resolution {
+			return nil, ErrInvalidInterval
+		}
+	}
+
+	if p.Levels <= 0 {
+		p.Levels = DefaultLevels
+	}
+
+	s := &solver{
+		p:      p,
+		levels: p.Levels,
+		step:   b.CapacityKwh / float64(p.Levels),
+		hours:  p.Resolution.Hours(),
+	}
+	s.kMin = s.level(b.MinSoc, math.Ceil)
+	s.kTarget = s.level(b.TargetSoc, math.Ceil)
+	s.kInit = s.level(b.InitialSoc, math.Round)
+
+	plan := s.solve(0, 0)
+	if p.MaxDischargeKwh < 0 || plan.DischargedKwh <= p.MaxDischargeKwh+epsilon {
+		return plan, nil
+	}
+
+	if levels := s.budgetLevels(); levels <= maxBudgetLevels {
+		return s.solve(0, levels), nil
+	}
+
+	// Lagrangian relaxation of the discharge budget: bisect on a per kWh
+	// penalty for discharging until the plan respects the budget.
+	lo, hi := 0.0, s.maxPrice()+1
+	best := s.solve(hi, 0)
+	for range bisections {
+		mid := (lo + hi) / 2
+		candidate := s.solve(mid, 0)
+		if candidate.DischargedKwh <= p.MaxDischargeKwh+epsilon {
+			hi, best = mid, candidate
+		} else {
+			lo = mid
+		}
+	}
+
+	return best, nil
+}
+
+// budgetLevels is how many levels the battery may discharge within the
+// discharge budget.
+func (s *solver) budgetLevels() int {
+	return int(math.Floor(s.p.MaxDischargeKwh/(s.step*s.p.Battery.DischargeEfficiency) + epsilon))
+}
+
+func (s *solver) level(soc float64, round func(float64) float64) int {
+	k := int(round(soc / 100 * float64(s.levels)))
+	return max(0, min(s.levels, k))
+}
+
+func (s *solver) soc(k int) float64 {
+	return float64(k) / float64(s.levels) * 100
+}
+
+func (s *solver) maxPrice() float64 {
+	m := 0.0
+	for _, in := range s.p.Intervals {
+		m = max(m, math.Abs(in.ImportPrice), math.Abs(in.ExportPrice), in.CarbonIntensity/1000*CarbonPricePerKg)
+	}
+
+	return m
+}
+
+// actionBounds returns how many levels the battery can move down and up in
+// the given interval.
+func (s *solver) actionBounds(in Interval) (int, int) {
+	b := s.p.Battery
+	chargeKw, dischargeKw := b.MaxChargeKw, b.MaxDischargeKw
+	if in.MaxImportKw > 0 {
+		chargeKw = min(chargeKw, in.MaxImportKw+max(0, in.SurplusKw))
+	}
+	if in.MaxExportKw > 0 {
+		dischargeKw = min(dischargeKw, in.MaxExportKw)
+	}
+	if s.p.MaxDischargeKwh == 0 {
+		dischargeKw = 0
+	}
+
+	up := int(math.Floor(chargeKw*s.hours*b.ChargeEfficiency/s.step + epsilon))
+	down := int(math.Floor(dischargeKw*s.hours/b.DischargeEfficiency/s.step + epsilon))
+
+	return down, up
+}
+
+// transition returns the grid power, energy cost and emissions in kg of
+// moving the battery by a levels, positive a meaning charging.
+func (s *solver) transition(in Interval, a int) (float64, float64, float64) {
+	b := s.p.Battery
+	stored := float64(a) * s.step
+	if a >= 0 {
+		drawn := stored / b.ChargeEfficiency
+		surplus := min(drawn, max(0, in.SurplusKw)*s.hours)
+		imported := drawn - surplus
+		return drawn / s.hours, surplus*in.ExportPrice + imported*in.ImportPrice, imported * in.CarbonIntensity / 1000
+	}
+
+	grid := -stored * b.DischargeEfficiency
+	return -grid / s.hours, -grid * in.ExportPrice, -grid * in.CarbonIntensity / 1000
+}
+
+// objective is what the solver minimises for moving the battery by a levels:
+// cost and emissions in proportion to the carbon weight, the wear and the
+// penalty per kWh discharged.
+func (s *solver) objective(in Interval, a int, penalty float64) float64 {
+	power, cost, emissions := s.transition(in, a)
+	w := s.p.CarbonWeight
+	return (1-w)*cost + w*emissions*CarbonPricePerKg + s.wear(a) + max(0, -power)*s.hours*penalty
+}
+
+// budgetUsed returns how many levels of the discharge budget moving the
+// battery by a levels uses up, none when the budget is not tracked.
+func budgetUsed(a, budget int) int {
+	if a >= 0 || budget == 0 {
+		return 0
+	}
+
+	return -a
+}
+
+// wear returns the wear cost of moving the battery by a levels.
+func (s *solver) wear(a int) float64 {
+	if a >= 0 {
+		return 0
+	}
+
+	return float64(-a) * s.step * s.p.WearCostPerKwh
+}
+
+func (s *solver) terminal(k int) float64 {
+	if k >= s.kTarget {
+		return 0
+	}
+
+	return float64(s.kTarget-k) * s.step * shortfallPenalty
+}
+
+// solve plans by backward induction. A budget above zero is the number of
+// levels the battery may discharge in total, the levels used are then tracked
+// as a second dimension of the state.
+func (s *solver) solve(penalty float64, budget int) *Schedule {
+	n := len(s.p.Intervals)
+	states := s.levels + 1
+	budgets := budget + 1
+	inf := math.Inf(1)
+
+	// value[k*budgets+u] is the minimal cost from the current interval
+	// onwards at level k with u levels of the budget used.
+	value := make([]float64, states*budgets)
+	next := make([]float64, states*budgets)
+	choice := make([][]int, n)
+	for k := range states {
+		for u := range budgets {
+			next[k*budgets+u] = s.terminal(k)
+		}
+	}
+
+	for t := n - 1; t >= 0; t-- {
+		in := s.p.Intervals[t]
+		actions := orderedActions(s.actionBounds(in))
+		choice[t] = make([]int, states*budgets)
+
+		for k := range states {
+			for u := range budgets {
+				best, bestA := inf, 0
+				for _, a := range actions {
+					k2 := k + a
+					if k2 > s.levels || k2 < 0 {
+						continue
+					}
+					// Discharging is never allowed to end below the floor.
+					if a < 0 && k2 < s.kMin {
+						continue
+					}
+					u2 := u + budgetUsed(a, budget)
+					if u2 >= budgets {
+						continue
+					}
+
+					total := s.objective(in, a, penalty) + next[k2*budgets+u2]
+					if total < best-epsilon {
+						best, bestA = total, a
+					}
+				}
+
+				value[k*budgets+u] = best
+				choice[t][k*budgets+u] = bestA
+			}
+		}
+
+		value, next = next, value
+	}
+
+	return s.trace(choice, budget)
+}
+
+// orderedActions lists idle first, then moves of increasing magnitude, so
+// ties resolve towards the smallest power and results are deterministic.
+func orderedActions(down, up int) []int {
+	actions := []int{0}
+	for m := 1; m <= max(up, down); m++ {
+		if m <= up {
+			actions = append(actions, m)
+		}
+		if m <= down {
+			actions = append(actions, -m)
+		}
+	}
+
+	return actions
+}
+
+func (s *solver) trace(choice [][]int, budget int) *Schedule {
+	sched := &Schedule{Setpoints: make([]Setpoint, 0, len(s.p.Intervals))}
+	k, u := s.kInit, 0
+	for t, in := range s.p.Intervals {
+		a := choice[t][k*(budget+1)+u]
+		u += budgetUsed(a, budget)
+		power, cost, emissions := s.transition(in, a)
+		next := k + a
+
+		sched.Setpoints = append(sched.Setpoints, Setpoint{
+			Start:    in.Start,
+			PowerKw:  round(power, 3),
+			SocStart: round(s.soc(k), 2),
+			SocEnd:   round(s.soc(next), 2),
+			Cost:     round(cost, 4),
+		})
+		sched.TotalCost += cost
+		sched.WearCost += s.wear(a)
+		sched.EmissionsKg += emissions
+		if power > 0 {
+			sched.ChargedKwh += power * s.hours
+		} else {
+			sched.DischargedKwh += -power * s.hours
+		}
+
+		k = next
+	}
+
+	sched.TotalCost = round(sched.TotalCost, 4)
+	sched.WearCost = round(sched.WearCost, 4)
+	sched.EmissionsKg = round(sched.EmissionsKg, 3)
+	sched.ChargedKwh = round(sched.ChargedKwh, 3)
+	sched.DischargedKwh = round(sched.DischargedKwh, 3)
+	sched.FinalSoc = round(s.soc(k), 2)
+	sched.TargetReached = k >= s.kTarget
+
+	return sched
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
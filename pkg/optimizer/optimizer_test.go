@@ -0,0 +1,267 @@
+package optimizer
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// battery is a lossless 10 kWh battery with 2 kW in both directions, at
+// 100 levels every level is 0.1 kWh.
+var battery = Battery{
+	CapacityKwh:         10,
+	InitialSoc:          50,
+	MinSoc:              20,
+	TargetSoc:           70,
+	MaxChargeKw:         2,
+	MaxDischargeKw:      2,
+	ChargeEfficiency:    1,
+	DischargeEfficiency: 1,
+}
+
+// problem plans hourly intervals with the given import prices, exporting
+// pays the same.
+func problem(b Battery, prices ...float64) Problem {
+	intervals := make([]Interval, len(prices))
+	for i, p := range prices {
+		intervals[i] = Interval{Start: start.Add(time.Duration(i) * time.Hour), ImportPrice: p, ExportPrice: p}
+	}
+
+	return Problem{Battery: b, Intervals: intervals, Resolution: time.Hour, MaxDischargeKwh: 0, Levels: 100}
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name    string
+		problem func() Problem
+		// powers are the expected setpoints, nil when only the totals are
+		// checked.
+		powers        []float64
+		finalSoc      float64
+		targetReached bool
+		check         func(t *testing.T, s *Schedule)
+	}{
+		{
+			name: "target reached by departure in the cheapest slots",
+			problem: func() Problem {
+				b := battery
+				b.TargetSoc = 80
+				return problem(b, 0.30, 0.10, 0.20, 0.40)
+			},
+			powers:        []float64{0, 2, 1, 0},
+			finalSoc:      80,
+			targetReached: true,
+		},
+		{
+			name: "infeasible target charges as much as possible",
+			problem: func() Problem {
+				b := battery
+				b.InitialSoc, b.TargetSoc = 10, 100
+				return problem(b, 0.30, 0.10)
+			},
+			powers:        []float64{2, 2},
+			finalSoc:      50,
+			targetReached: false,
+		},
+		{
+			name: "discharging stops at the minimum state of charge",
+			problem: func() Problem {
+				b := battery
+				// Without charging it cannot trade its way below the floor.
+				b.InitialSoc, b.TargetSoc, b.MaxChargeKw = 50, 20, 0
+				p := problem(b, 0.50, 0.60, 0.70)
+				p.MaxDischargeKwh = -1
+				return p
+			},
+			powers:        []float64{0, -1, -2},
+			finalSoc:      20,
+			targetReached: true,
+			check: func(t *testing.T, s *Schedule) {
+				for _, sp := range s.Setpoints {
+					if sp.SocEnd < 20 {
+						t.Errorf("setpoint at %s ends at %v%%, below the minimum", sp.Start.Format(time.TimeOnly), sp.SocEnd)
+					}
+				}
+			},
+		},
+		{
+			name: "discharge budget is honoured",
+			problem: func() Problem {
+				b := battery
+				b.InitialSoc, b.TargetSoc = 50, 20
+				p := problem(b, 0.10, 0.50, 0.40, 0.30)
+				p.MaxDischargeKwh = 2
+				return p
+			},
+			powers:        []float64{0, -2, 0, 0},
+			finalSoc:      30,
+			targetReached: true,
+		},
+		{
+			name: "discharge budget below a full slot discharges part of it",
+			problem: func() Problem {
+				b := battery
+				b.InitialSoc, b.TargetSoc = 50, 20
+				p := problem(b, 0.10, 0.50, 0.40, 0.30)
+				p.MaxDischargeKwh = 1.5
+				return p
+			},
+			powers:        []float64{0, -1.5, 0, 0},
+			finalSoc:      35,
+			targetReached: true,
+			check: func(t *testing.T, s *Schedule) {
+				if s.DischargedKwh > 1.5+epsilon {
+					t.Errorf("discharged %v kWh, budget is 1.5", s.DischargedKwh)
+				}
+			},
+		},
+		{
+			name: "no discharging without a budget",
+			problem: func() Problem {
+				b := battery
+				b.InitialSoc, b.TargetSoc = 50, 20
+				return problem(b, 0.50, 0.50)
+			},
+			powers:        []float64{0, 0},
+			finalSoc:      50,
+			targetReached: true,
+		},
+		{
+			name: "equally priced slots charge as late as possible",
+			problem: func() Problem {
+				return problem(battery, 0.20, 0.20, 0.20)
+			},
+			powers:        []float64{0, 0, 2},
+			finalSoc:      70,
+			targetReached: true,
+		},
+		{
+			name: "zero import limit means the battery limits",
+			problem: func() Problem {
+				p := problem(battery, 0.20, 0.10)
+				p.Intervals[1].MaxImportKw = 0
+				return p
+			},
+			powers:        []float64{0, 2},
+			finalSoc:      70,
+			targetReached: true,
+		},
+		{
+			name: "import limit caps the charging power",
+			problem: func() Problem {
+				p := problem(battery, 0.20, 0.10)
+				p.Intervals[1].MaxImportKw = 1
+				return p
+			},
+			powers:        []float64{1, 1},
+			finalSoc:      70,
+			targetReached: true,
+		},
+		{
+			name: "tiny import limit blocks charging",
+			problem: func() Problem {
+				p := problem(battery, 0.20, 0.10)
+				p.Intervals[0].MaxImportKw = 1e-6
+				p.Intervals[1].MaxImportKw = 1e-6
+				return p
+			},
+			powers:        []float64{0, 0},
+			finalSoc:      50,
+			targetReached: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Optimize(tt.problem())
+			if err != nil {
+				t.Fatalf("Optimize: %v", err)
+			}
+
+			if tt.powers != nil {
+				if got := powers(s); !reflect.DeepEqual(got, tt.powers) {
+					t.Errorf("powers = %v, want %v", got, tt.powers)
+				}
+			}
+			if math.Abs(s.FinalSoc-tt.finalSoc) > epsilon {
+				t.Errorf("final soc = %v, want %v", s.FinalSoc, tt.finalSoc)
+			}
+			if s.TargetReached != tt.targetReached {
+				t.Errorf("target reached = %v, want %v", s.TargetReached, tt.targetReached)
+			}
+			if tt.check != nil {
+				tt.check(t, s)
+			}
+
+			again, err := Optimize(tt.problem())
+			if err != nil {
+				t.Fatalf("Optimize: %v", err)
+			}
+			if !reflect.DeepEqual(s, again) {
+				t.Errorf("schedule is not deterministic: %+v and %+v", s, again)
+			}
+		})
+	}
+}
+
+func TestOptimizeInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		problem func() Problem
+		want    error
+	}{
+		{
+			name:    "no intervals",
+			problem: func() Problem { return problem(battery) },
+			want:    ErrNoIntervals,
+		},
+		{
+			name: "zero capacity",
+			problem: func() Problem {
+				b := battery
+				b.CapacityKwh = 0
+				return problem(b, 0.1)
+			},
+			want: ErrInvalidBattery,
+		},
+		{
+			name: "gap between intervals",
+			problem: func() Problem {
+				p := problem(battery, 0.1, 0.2)
+				p.Intervals[1].Start = p.Intervals[1].Start.Add(time.Minute)
+				return p
+			},
+			want: ErrInvalidInterval,
+		},
+		{
+			name: "carbon weight above one",
+			problem: func() Problem {
+				p := problem(battery, 0.1)
+				p.CarbonWeight = 1.5
+				return p
+			},
+			want: ErrInvalidWeight,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Optimize(tt.problem()); !errors.Is(err, tt.want) {
+				t.Errorf("Optimize error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func powers(s *Schedule) []float64 {
+	res := make([]float64, len(s.Setpoints))
+	for i, sp := range s.Setpoints {
+		res[i] = sp.PowerKw
+	}
+
+	return res
+}
@@ -0,0 +1,184 @@
+// Package pvforecast predicts the output of PV arrays from weather forecasts.
+// Global irradiance comes from the forecast or, when only cloud cover is
+// known, from a clear-sky model reduced by the clouds. It is split into its
+// direct and diffuse parts and projected onto the tilted panels. Like the
+// optimizer it has no dependencies on the rest of the server.
+package pvforecast
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// PerformanceRatio covers inverter, wiring, mismatch and soiling losses.
+	PerformanceRatio = 0.86
+	// temperatureCoefficient is the relative power loss per degree the cells
+	// are warmer than 25 °C, typical for crystalline silicon.
+	temperatureCoefficient = -0.004
+	// cellHeatingPerWm2 is how much warmer than the air the cells get per
+	// W/m² of irradiance, from a nominal operating cell temperature of 45 °C.
+	cellHeatingPerWm2 = 25.0 / 800
+	groundAlbedo      = 0.2
+	// samplesPerSlot evaluates a slot at several moments so sunrise and
+	// sunset are not all or nothing.
+	samplesPerSlot = 3
+	// minCosZenith avoids dividing by almost zero with the sun at the horizon.
+	minCosZenith = 0.065
+	// Calibration factors are clamped to this range so a few bad days, or
+	// an inverter that was offline, cannot throw the forecast off entirely.
+	MinCalibration = 0.5
+	MaxCalibration = 1.5
+)
+
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Array is a group of panels with the same orientation. Azimuth is a compass
+// bearing, 180 faces south, and tilt is measured from the horizontal.
+type Array struct {
+	PeakKw     float64
+	TiltDeg    float64
+	AzimuthDeg float64
+}
+
+// Weather is the forecast for one interval. Each field is optional, cloud
+// cover is a fraction between 0 and 1.
+type Weather struct {
+	Start        time.Time
+	Resolution   time.Duration
+	GhiWm2       *float64
+	CloudCover   *float64
+	TemperatureC *float64
+}
+
+func (w Weather) covers(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.Start.Add(w.Resolution))
+}
+
+// Slot is the expected average production from Start until the next slot.
+// ClearSkyKw is what a cloudless sky would give.
+type Slot struct {
+	Start      time.Time
+	PowerKw    float64
+	ClearSkyKw float64
+}
+
+// Forecast predicts the combined production of the arrays per slot in
+// [from, to). Slots without weather are left out, weather must be ordered by
+// start.
+func Forecast(loc Location, arrays []Array, weather []Weather, from, to time.Time, resolution time.Duration) []Slot {
+	var slots []Slot
+	for start := from.UTC().Truncate(resolution); start.Before(to); start = start.Add(resolution) {
+		slot := Slot{Start: start}
+		covered := false
+		for i := range samplesPerSlot {
+			t := start.Add(resolution * time.Duration(2*i+1) / (2 * samplesPerSlot))
+			w, ok := weatherAt(weather, t)
+			if !ok {
+				continue
+			}
+			covered = true
+
+			elevation, azimuth := SunPosition(t, loc.Latitude, loc.Longitude)
+			clearSky := clearSkyGhi(elevation)
+			ghi := clearSky
+			switch {
+			case w.GhiWm2 != nil:
+				ghi = math.Max(0, *w.GhiWm2)
+			case w.CloudCover != nil:
+				ghi = cloudyGhi(clearSky, *w.CloudCover)
+			}
+
+			for _, a := range arrays {
+				slot.PowerKw += arrayKw(a, t, elevation, azimuth, ghi, w.TemperatureC) / samplesPerSlot
+				slot.ClearSkyKw += arrayKw(a, t, elevation, azimuth, clearSky, w.TemperatureC) / samplesPerSlot
+			}
+		}
+
+		if covered {
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots
+}
+
+func weatherAt(weather []Weather, t time.Time) (Weather, bool) {
+	for i := len(weather) - 1; i >= 0; i-- {
+		if weather[i].covers(t) {
+			return weather[i], true
+		}
+	}
+
+	return Weather{}, false
+}
+
+// clearSkyGhi is the global horizontal irradiance under a cloudless sky
+// according to the Haurwitz model.
+func clearSkyGhi(elevation float64) float64 {
+	cosZenith := math.Sin(radians(elevation))
+	if cosZenith <= 0 {
+		return 0
+	}
+
+	return 1098 * cosZenith * math.Exp(-0.057/cosZenith)
+}
+
+// cloudyGhi reduces the clear-sky irradiance for the cloud cover with the
+// Kasten-Czeplak relation.
+func cloudyGhi(clearSky, cloudCover float64) float64 {
+	c := math.Max(0, math.Min(1, cloudCover))
+	return clearSky * (1 - 0.75*math.Pow(c, 3.4))
+}
+
+// arrayKw converts global horizontal irradiance into the output of an array.
+// The Erbs correlation splits it into direct and diffuse light, which is
+// projected onto the panels with an isotropic sky.
+func arrayKw(a Array, t time.Time, elevation, sunAzimuth, ghi float64, temperatureC *float64) float64 {
+	cosZenith := math.Sin(radians(elevation))
+	if cosZenith <= 0 || ghi <= 0 {
+		return 0
+	}
+
+	extraterrestrial := extraterrestrialWm2(t)
+	clearness := math.Min(1, ghi/(extraterrestrial*math.Max(cosZenith, minCosZenith)))
+	var diffuseShare float64
+	switch {
+	case clearness <= 0.22:
+		diffuseShare = 1 - 0.09*clearness
+	case clearness <= 0.8:
+		diffuseShare = 0.9511 - 0.1604*clearness + 4.388*math.Pow(clearness, 2) - 16.638*math.Pow(clearness, 3) + 12.336*math.Pow(clearness, 4)
+	default:
+		diffuseShare = 0.165
+	}
+	dhi := ghi * diffuseShare
+	dni := math.Min(extraterrestrial, (ghi-dhi)/math.Max(cosZenith, minCosZenith))
+
+	tilt := radians(a.TiltDeg)
+	zenith := math.Acos(cosZenith)
+	cosIncidence := math.Cos(zenith)*math.Cos(tilt) + math.Sin(zenith)*math.Sin(tilt)*math.Cos(radians(sunAzimuth-a.AzimuthDeg))
+
+	poa := dni*math.Max(0, cosIncidence) + dhi*(1+math.Cos(tilt))/2 + ghi*groundAlbedo*(1-math.Cos(tilt))/2
+
+	derate := 1.0
+	if temperatureC != nil {
+		cellC := *temperatureC + poa*cellHeatingPerWm2
+		derate = 1 + temperatureCoefficient*(cellC-25)
+	}
+
+	return math.Max(0, math.Min(a.PeakKw, a.PeakKw*poa/1000*PerformanceRatio*derate))
+}
+
+// Calibration is the factor the model output is multiplied by to match what
+// the arrays actually produced. Without enough modelled energy to compare
+// against it is 1.
+func Calibration(actualKwh, modeledKwh, minModeledKwh float64) float64 {
+	if modeledKwh < math.Max(minModeledKwh, 1e-9) {
+		return 1
+	}
+
+	return math.Max(MinCalibration, math.Min(MaxCalibration, actualKwh/modeledKwh))
+}
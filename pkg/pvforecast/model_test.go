@@ -0,0 +1,169 @@
+package pvforecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var eindhoven = Location{Latitude: 51.44, Longitude: 5.48}
+
+func TestSunPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		at        time.Time
+		loc       Location
+		elevation float64
+		azimuth   float64
+	}{
+		{
+			// Solar noon on the summer solstice: 90 - 51.44 + 23.44 degrees.
+			name:      "summer solstice at solar noon",
+			at:        time.Date(2025, 6, 21, 11, 40, 0, 0, time.UTC),
+			loc:       eindhoven,
+			elevation: 62.0,
+			azimuth:   180,
+		},
+		{
+			name:      "winter solstice at solar noon",
+			at:        time.Date(2025, 12, 21, 11, 36, 0, 0, time.UTC),
+			loc:       eindhoven,
+			elevation: 15.1,
+			azimuth:   180,
+		},
+		{
+			name:      "equator at the equinox",
+			at:        time.Date(2025, 3, 20, 12, 7, 0, 0, time.UTC),
+			loc:       Location{},
+			elevation: 90,
+		},
+		{
+			name:      "morning sun in the east",
+			at:        time.Date(2025, 3, 20, 6, 0, 0, 0, time.UTC),
+			loc:       Location{},
+			elevation: -1.8,
+			azimuth:   90,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elevation, azimuth := SunPosition(tt.at, tt.loc.Latitude, tt.loc.Longitude)
+			if math.Abs(elevation-tt.elevation) > 0.5 {
+				t.Errorf("elevation = %.2f, want %.2f", elevation, tt.elevation)
+			}
+			// The azimuth is meaningless with the sun straight overhead.
+			if tt.elevation < 89 && math.Abs(azimuth-tt.azimuth) > 1 {
+				t.Errorf("azimuth = %.2f, want %.2f", azimuth, tt.azimuth)
+			}
+		})
+	}
+}
+
+func TestForecast(t *testing.T) {
+	south := []Array{{PeakKw: 10, TiltDeg: 35, AzimuthDeg: 180}}
+	day := time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC)
+	weather := func(w Weather) []Weather {
+		w.Start, w.Resolution = day, 24*time.Hour
+		return []Weather{w}
+	}
+	ptr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		weather []Weather
+		check   func(t *testing.T, slots []Slot)
+	}{
+		{
+			name:    "clear sky follows the sun",
+			weather: weather(Weather{}),
+			check: func(t *testing.T, slots []Slot) {
+				if len(slots) != 24 {
+					t.Fatalf("got %d slots, want 24", len(slots))
+				}
+				if slots[0].PowerKw != 0 || slots[23].PowerKw != 0 {
+					t.Errorf("night produces %v and %v kW, want nothing", slots[0].PowerKw, slots[23].PowerKw)
+				}
+
+				noon := slots[11]
+				if noon.PowerKw < 7 || noon.PowerKw > 10 {
+					t.Errorf("noon produces %v kW, want between 7 and the 10 kW peak", noon.PowerKw)
+				}
+				if noon.PowerKw != noon.ClearSkyKw {
+					t.Errorf("clear sky forecast %v kW differs from the clear sky %v kW", noon.PowerKw, noon.ClearSkyKw)
+				}
+				if slots[8].PowerKw >= noon.PowerKw || slots[15].PowerKw >= noon.PowerKw {
+					t.Errorf("morning %v kW and afternoon %v kW are not below noon %v kW", slots[8].PowerKw, slots[15].PowerKw, noon.PowerKw)
+				}
+			},
+		},
+		{
+			name:    "overcast keeps a quarter of the clear sky",
+			weather: weather(Weather{CloudCover: ptr(1)}),
+			check: func(t *testing.T, slots []Slot) {
+				noon := slots[11]
+				if ratio := noon.PowerKw / noon.ClearSkyKw; ratio < 0.15 || ratio > 0.4 {
+					t.Errorf("overcast noon produces %v of the clear sky, want about a quarter", ratio)
+				}
+			},
+		},
+		{
+			name:    "measured irradiance replaces the clear sky",
+			weather: weather(Weather{GhiWm2: ptr(0), CloudCover: ptr(0)}),
+			check: func(t *testing.T, slots []Slot) {
+				if slots[11].PowerKw != 0 || slots[11].ClearSkyKw == 0 {
+					t.Errorf("noon produces %v kW of %v kW clear sky, want nothing", slots[11].PowerKw, slots[11].ClearSkyKw)
+				}
+			},
+		},
+		{
+			name:    "heat lowers the output",
+			weather: weather(Weather{TemperatureC: ptr(35)}),
+			check: func(t *testing.T, slots []Slot) {
+				cool := Forecast(eindhoven, south, weather(Weather{TemperatureC: ptr(5)}), day, day.Add(24*time.Hour), time.Hour)
+				if slots[11].PowerKw >= cool[11].PowerKw {
+					t.Errorf("35 °C produces %v kW, not less than %v kW at 5 °C", slots[11].PowerKw, cool[11].PowerKw)
+				}
+			},
+		},
+		{
+			name: "slots without weather are left out",
+			weather: []Weather{
+				{Start: day.Add(10 * time.Hour), Resolution: 2 * time.Hour},
+			},
+			check: func(t *testing.T, slots []Slot) {
+				if len(slots) != 2 || !slots[0].Start.Equal(day.Add(10*time.Hour)) {
+					t.Errorf("got slots %+v, want the two from 10:00", slots)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, Forecast(eindhoven, south, tt.weather, day, day.Add(24*time.Hour), time.Hour))
+		})
+	}
+}
+
+func TestCalibration(t *testing.T) {
+	tests := []struct {
+		name                        string
+		actual, modeled, minModeled float64
+		want                        float64
+	}{
+		{name: "ratio of actual to modelled", actual: 9, modeled: 10, minModeled: 5, want: 0.9},
+		{name: "too little modelled energy", actual: 3, modeled: 4, minModeled: 5, want: 1},
+		{name: "nothing modelled", actual: 3, modeled: 0, minModeled: 0, want: 1},
+		{name: "clamped below", actual: 1, modeled: 10, minModeled: 5, want: MinCalibration},
+		{name: "clamped above", actual: 30, modeled: 10, minModeled: 5, want: MaxCalibration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Calibration(tt.actual, tt.modeled, tt.minModeled); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Calibration = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
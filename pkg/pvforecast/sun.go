@@ -0,0 +1,54 @@
+package pvforecast
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	solarConstantWm2 = 1367
+	unixEpochJulian  = 2440587.5
+	j2000Julian      = 2451545.0
+)
+
+// SunPosition returns the elevation above the horizon and the compass azimuth
+// of the sun, both in degrees, using the low precision algorithm of the
+// Astronomical Almanac which is accurate to about 0.01 degrees until 2050.
+func SunPosition(t time.Time, latitude, longitude float64) (elevation, azimuth float64) {
+	n := float64(t.UnixNano())/float64(24*time.Hour) + unixEpochJulian - j2000Julian
+
+	meanLongitude := math.Mod(280.460+0.9856474*n, 360)
+	meanAnomaly := radians(math.Mod(357.528+0.9856003*n, 360))
+	eclipticLongitude := radians(meanLongitude + 1.915*math.Sin(meanAnomaly) + 0.020*math.Sin(2*meanAnomaly))
+	obliquity := radians(23.439 - 0.0000004*n)
+
+	rightAscension := math.Atan2(math.Cos(obliquity)*math.Sin(eclipticLongitude), math.Cos(eclipticLongitude))
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(eclipticLongitude))
+
+	siderealHours := math.Mod(18.697374558+24.06570982441908*n, 24)
+	hourAngle := radians(siderealHours*15+longitude) - rightAscension
+
+	lat := radians(latitude)
+	sinElevation := math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle)
+	elevation = degrees(math.Asin(math.Max(-1, math.Min(1, sinElevation))))
+
+	// Measured from the south towards the west, turned into a compass bearing.
+	fromSouth := math.Atan2(math.Sin(hourAngle), math.Cos(hourAngle)*math.Sin(lat)-math.Tan(declination)*math.Cos(lat))
+	azimuth = math.Mod(degrees(fromSouth)+180+360, 360)
+
+	return elevation, azimuth
+}
+
+// extraterrestrialWm2 is the irradiance at the top of the atmosphere, which
+// varies with the distance to the sun over the year.
+func extraterrestrialWm2(t time.Time) float64 {
+	return solarConstantWm2 * (1 + 0.033*math.Cos(2*math.Pi*float64(t.YearDay())/365))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}